@@ -2,20 +2,28 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/playpool/backend/internal/accounts"
 	"github.com/playpool/backend/internal/admin"
 	"github.com/playpool/backend/internal/api"
 	"github.com/playpool/backend/internal/config"
 	"github.com/playpool/backend/internal/database"
 	"github.com/playpool/backend/internal/game"
+	"github.com/playpool/backend/internal/logger"
 	"github.com/playpool/backend/internal/middleware"
 	"github.com/playpool/backend/internal/migrations"
 	"github.com/playpool/backend/internal/payment"
 	"github.com/playpool/backend/internal/redis"
+	"github.com/playpool/backend/internal/selftest"
 	"github.com/playpool/backend/internal/sms"
 	"github.com/playpool/backend/internal/ws"
 )
@@ -28,6 +36,12 @@ func main() {
 
 	// Initialize configuration
 	cfg := config.Load()
+	logger.SetLevel(cfg.LogLevel)
+
+	// Real payments need signed DMarkPay callbacks
+	if !cfg.MockMode && cfg.DMarkPayWebhookSecret == "" {
+		log.Fatalf("DMARK_PAY_WEBHOOK_SECRET must be set when MOCK_MODE=false")
+	}
 
 	// Initialize database
 	db, err := database.Connect(cfg.DatabaseURL)
@@ -63,11 +77,18 @@ func main() {
 	}
 	defer rdb.Close()
 
+	// Background workers stop when this context is cancelled during shutdown
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
 	// Initialize Game Manager with Redis and config
-	game.InitializeManager(db, rdb, cfg)
+	game.InitializeManager(workerCtx, db, rdb, cfg)
+
+	// Player SMS opt-outs are read from the database
+	sms.SetPreferencesDB(db)
 
 	// Initialize DMark SMS client (if configured)
-	if cfg.SMSServiceBaseURL != "" && cfg.SMSServiceUsername != "" && cfg.SMSServicePassword != "" {
+	if cfg.SMSSandboxMode || (cfg.SMSServiceBaseURL != "" && cfg.SMSServiceUsername != "" && cfg.SMSServicePassword != "") {
 		smsClient := sms.NewClient(cfg, rdb)
 		if smsClient != nil {
 			sms.SetDefault(smsClient)
@@ -77,6 +98,11 @@ func main() {
 		log.Printf("[SMS] SMS is not configured (SMS_SERVICE_BASE_URL/SMS_SERVICE_USERNAME missing)")
 	}
 
+	// Notification SMS go through a bounded queue and worker pool
+	smsDispatcher := sms.NewDispatcher(cfg.SMSWorkers, cfg.SMSQueueSize)
+	smsDispatcher.Start(workerCtx)
+	sms.SetDispatcher(smsDispatcher)
+
 	// Initialize DMarkPay client (if configured)
 	if cfg.DMarkPayBaseURL != "" && cfg.DMarkPayUsername != "" && cfg.DMarkPayPassword != "" {
 		paymentClient := payment.NewClient(cfg, rdb)
@@ -89,24 +115,49 @@ func main() {
 	}
 
 	// Start payment status checker (polls DMarkPay for PENDING transaction status)
-	go payment.StartStatusChecker(context.Background(), db, rdb, cfg, 2) // Check every 2 minutes
+	go payment.StartStatusChecker(workerCtx, db, rdb, cfg, 2) // Check every 2 minutes
+
+	// Verify the double-entry ledger periodically (discrepancies are logged, not fixed)
+	if cfg.LedgerReconcileHours > 0 {
+		go accounts.StartReconciler(workerCtx, db, time.Duration(cfg.LedgerReconcileHours)*time.Hour)
+	}
+
+	// Catch a misconfigured deploy before it takes traffic
+	if cfg.StartupSelfTest {
+		ctx, cancel := context.WithTimeout(workerCtx, 30*time.Second)
+		err := selftest.Run(ctx, selftest.Checks(db, rdb))
+		cancel()
+		if err != nil && cfg.StartupSelfTestStrict {
+			log.Fatalf("Startup self-test failed: %v", err)
+		}
+	}
 
 	// Wire Redis and start idle event subscriber in WS layer
 	ws.SetRedisClient(rdb, cfg)
-	ws.StartIdleEventSubscriber(context.Background())
+	ws.StartIdleEventSubscriber(workerCtx)
+
+	// Start the pool shot clock (timeouts are fouls; repeated timeouts forfeit)
+	if cfg.PoolTurnSeconds > 0 {
+		ws.StartTurnClock(workerCtx)
+	}
 
 	// Start idle worker (warning -> forfeit) for idle detection
-	game.StartIdleWorker(context.Background(), db, rdb, cfg)
+	game.StartIdleWorker(workerCtx, db, rdb, cfg)
 
 	// Start matchmaker worker (pairs players from DB queue and sends SMS)
-	go game.StartMatchmakerWorker(context.Background(), db, rdb, cfg)
+	go game.StartMatchmakerWorker(workerCtx, db, rdb, cfg)
 
 	// Set up Gin router
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	router := gin.Default()
+	// gin.Default() without logging the load balancer probes
+	router := gin.New()
+	router.Use(gin.LoggerWithConfig(gin.LoggerConfig{SkipPaths: []string{"/healthz", "/readyz"}}), gin.Recovery())
+
+	// Tag every request with an X-Request-ID that the structured logs carry
+	router.Use(middleware.RequestID())
 
 	// Apply CORS middleware before routes
 	router.Use(middleware.CORSMiddleware(cfg))
@@ -121,8 +172,26 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("Starting PlayMatatu server on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	srv := &http.Server{Addr: ":" + port, Handler: router}
+
+	go func() {
+		log.Printf("Starting PlayMatatu server on port %s", port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// Wait for SIGINT/SIGTERM (deploys send SIGTERM) then drain instead of dropping games
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-sigCtx.Done()
+	stop()
+
+	log.Printf("[SHUTDOWN] Signal received, draining (timeout %s)", shutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := gracefulShutdown(ctx, srv, stopWorkers); err != nil {
+		log.Printf("[SHUTDOWN] Forced exit: %v", err)
 	}
+	log.Printf("[SHUTDOWN] Server stopped")
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/playpool/backend/internal/game"
+	"github.com/playpool/backend/internal/ws"
+)
+
+// shutdownTimeout bounds the whole drain; it should stay below the orchestrator's kill grace period
+const shutdownTimeout = 20 * time.Second
+
+// gracefulShutdown stops the server without losing in-progress games:
+// clients are told to reconnect, active games are written to Redis so another
+// instance can resume them, background workers stop, in-flight HTTP requests
+// finish, and finally the remaining WebSockets are closed.
+func gracefulShutdown(ctx context.Context, srv *http.Server, stopWorkers context.CancelFunc) error {
+	notified := ws.GameHub.Drain()
+	log.Printf("[SHUTDOWN] Notified %d WebSocket clients", notified)
+
+	if game.Manager != nil {
+		saved := game.Manager.PersistAllGames()
+		log.Printf("[SHUTDOWN] Persisted %d active games", saved)
+	}
+
+	stopWorkers()
+	if game.Manager != nil {
+		game.Manager.Stop()
+	}
+
+	err := srv.Shutdown(ctx)
+
+	// Hijacked WebSocket connections are not tracked by srv.Shutdown
+	ws.GameHub.CloseAll()
+	return err
+}
@@ -17,9 +17,10 @@ interface WithdrawRow {
   created_at: string;
   processed_at?: string;
   note?: string;
+  approved_by?: string;
 }
 
-const STATUS_FILTERS = ['all', 'pending', 'completed', 'failed'] as const;
+const STATUS_FILTERS = ['all', 'hold', 'review', 'pending', 'processing', 'completed', 'failed'] as const;
 
 export function AdminWithdrawals() {
   const { get, post } = useAdminApi();
@@ -118,6 +119,9 @@ export function AdminWithdrawals() {
           className={`inline-flex px-2 py-0.5 rounded-full text-xs font-medium ${
             row.status === 'COMPLETED' ? 'bg-green-100 text-green-700' :
             row.status === 'PENDING' ? 'bg-yellow-100 text-yellow-700' :
+            row.status === 'PENDING_REVIEW' ? 'bg-orange-100 text-orange-700' :
+            row.status === 'SETTLEMENT_HOLD' ? 'bg-red-100 text-red-700' :
+            row.status === 'PROCESSING' ? 'bg-blue-100 text-blue-700' :
             row.status === 'FAILED' ? 'bg-red-100 text-red-700' :
             'bg-gray-100 text-gray-700'
           }`}
@@ -135,7 +139,23 @@ export function AdminWithdrawals() {
       key: 'actions',
       label: 'Actions',
       render: (_, row) => {
-        if (row.status !== 'PENDING') return row.note || '—';
+        // Paid out by the provider but not yet covered by settlement: settle again once funded
+        if (row.status === 'SETTLEMENT_HOLD') {
+          return (
+            <div className="flex items-center gap-2">
+              <span className="text-xs text-gray-600">{row.note}</span>
+              <button
+                onClick={(e) => { e.stopPropagation(); setSelectedWithdraw(row); setApproveModalOpen(true); }}
+                className="px-2 py-1 text-xs font-medium text-green-700 bg-green-50 border border-green-200 rounded hover:bg-green-100"
+              >
+                Settle
+              </button>
+            </div>
+          );
+        }
+        if (row.status !== 'PENDING' && row.status !== 'PENDING_REVIEW') {
+          return row.note || (row.approved_by ? `Approved by ${row.approved_by}` : '—');
+        }
         return (
           <div className="flex gap-1">
             <button
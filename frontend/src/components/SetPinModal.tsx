@@ -1,8 +1,8 @@
 import { useState, useCallback } from 'react';
 import PinInput from './PinInput';
-import { setPIN } from '../utils/apiClient';
+import { setPIN, requestOTP, verifyOTPAction } from '../utils/apiClient';
 
-type Step = 'enter-pin' | 'confirm-pin' | 'saving' | 'done';
+type Step = 'otp' | 'enter-pin' | 'confirm-pin' | 'saving' | 'done';
 
 interface SetPinModalProps {
   phone: string;
@@ -12,15 +12,45 @@ interface SetPinModalProps {
 
 /**
  * SetPinModal - Used after a game to prompt user to set their PIN.
- * Since the user just played a game, their phone is already verified,
- * so we skip OTP verification and go directly to PIN setup.
+ * The phone is verified by OTP (action set_pin) first; the server
+ * refuses to set a PIN without that action token.
  */
 export default function SetPinModal({ phone, onComplete, onCancel }: SetPinModalProps) {
-  const [step, setStep] = useState<Step>('enter-pin');
+  const [step, setStep] = useState<Step>('otp');
+  const [otpSent, setOtpSent] = useState(false);
+  const [otpCode, setOtpCode] = useState('');
+  const [actionToken, setActionToken] = useState('');
   const [newPin, setNewPin] = useState('');
   const [error, setError] = useState('');
   const [loading, setLoading] = useState(false);
 
+  const handleSendOtp = async () => {
+    setLoading(true);
+    setError('');
+    try {
+      await requestOTP(phone);
+      setOtpSent(true);
+    } catch (err: any) {
+      setError(err.message || 'Failed to send OTP');
+    } finally {
+      setLoading(false);
+    }
+  };
+
+  const handleVerifyOtp = async () => {
+    setLoading(true);
+    setError('');
+    try {
+      const result = await verifyOTPAction(phone, otpCode, 'set_pin');
+      setActionToken(result.action_token);
+      setStep('enter-pin');
+    } catch (err: any) {
+      setError(err.message || 'Invalid OTP');
+    } finally {
+      setLoading(false);
+    }
+  };
+
   const handleEnterPin = useCallback((pin: string) => {
     setNewPin(pin);
     setError('');
@@ -38,8 +68,7 @@ export default function SetPinModal({ phone, onComplete, onCancel }: SetPinModal
     setStep('saving');
 
     try {
-      // No action_token needed - user is verified via player_token from localStorage
-      await setPIN(phone, newPin);
+      await setPIN(phone, newPin, actionToken);
       setStep('done');
       // Small delay before completing
       setTimeout(() => {
@@ -51,14 +80,14 @@ export default function SetPinModal({ phone, onComplete, onCancel }: SetPinModal
     } finally {
       setLoading(false);
     }
-  }, [newPin, phone, onComplete]);
+  }, [newPin, phone, actionToken, onComplete]);
 
   const handleBack = () => {
     setError('');
     if (step === 'confirm-pin') {
       setStep('enter-pin');
       setNewPin('');
-    } else if (step === 'enter-pin' && onCancel) {
+    } else if ((step === 'otp' || step === 'enter-pin') && onCancel) {
       onCancel();
     }
   };
@@ -71,6 +100,52 @@ export default function SetPinModal({ phone, onComplete, onCancel }: SetPinModal
       {/* Modal */}
       <div className="bg-white rounded-xl p-6 max-w-sm w-full shadow-lg z-10">
 
+        {/* Step: Verify phone by OTP */}
+        {step === 'otp' && (
+          <div className="text-center">
+            <h2 className="text-xl font-bold text-[#373536] mb-2">Create Your PIN</h2>
+            <p className="text-gray-600 text-sm mb-4">We'll send a code to verify your phone first</p>
+            {!otpSent ? (
+              <button
+                onClick={handleSendOtp}
+                disabled={loading}
+                className="w-full bg-[#373536] text-white py-3 px-6 rounded-lg font-semibold disabled:opacity-50"
+              >
+                {loading ? 'Sending...' : 'Send OTP'}
+              </button>
+            ) : (
+              <>
+                <input
+                  type="text"
+                  value={otpCode}
+                  onChange={(e) => setOtpCode(e.target.value.replace(/\D/g, '').slice(0, 4))}
+                  placeholder="Enter 4-digit code"
+                  maxLength={4}
+                  className="w-full px-4 py-3 border border-gray-300 rounded-lg text-center text-2xl tracking-widest font-mono"
+                />
+                <button
+                  onClick={handleVerifyOtp}
+                  disabled={loading || otpCode.length !== 4}
+                  className="mt-4 w-full bg-[#373536] text-white py-3 px-6 rounded-lg font-semibold disabled:opacity-50"
+                >
+                  {loading ? 'Verifying...' : 'Verify Code'}
+                </button>
+              </>
+            )}
+            {error && (
+              <div className="mt-4 p-3 bg-red-50 border border-red-200 rounded text-red-700 text-sm">
+                {error}
+              </div>
+            )}
+            <button
+              onClick={handleBack}
+              className="w-full mt-4 text-gray-500 hover:text-gray-700 text-sm"
+            >
+              ← Back
+            </button>
+          </div>
+        )}
+
         {/* Step: Enter PIN */}
         {step === 'enter-pin' && (
           <div>
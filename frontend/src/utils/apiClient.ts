@@ -223,11 +223,11 @@ export async function checkPlayerStatus(phone: string): Promise<{
   return { exists: data.exists, has_pin: data.has_pin, display_name: data.display_name };
 }
 
-export async function setPIN(phone: string, pin: string): Promise<{ success: boolean }> {
+export async function setPIN(phone: string, pin: string, actionToken: string): Promise<{ success: boolean }> {
   const response = await fetch(`${API_BASE}/auth/set-pin`, {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify({ phone: formatPhone(phone), pin }),
+    body: JSON.stringify({ phone: formatPhone(phone), pin, action_token: actionToken }),
     ...withCredentials
   });
 
@@ -2,6 +2,7 @@ package accounts
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 
@@ -18,6 +19,10 @@ const (
 	AccountTax            = "tax"
 )
 
+// ErrSettlementOverdraft is returned when a transfer or payout would take the settlement
+// account below zero, i.e. pay out money that never came in.
+var ErrSettlementOverdraft = errors.New("settlement account would be overdrawn")
+
 // GetOrCreateAccount returns an account for the given owner and type, creating it if missing
 func GetOrCreateAccount(db *sqlx.DB, accountType string, ownerPlayerID *int) (*models.Account, error) {
 	if db == nil {
@@ -55,7 +60,8 @@ func GetOrCreateAccount(db *sqlx.DB, accountType string, ownerPlayerID *int) (*m
 
 // Transfer performs a single debit/credit between accounts within an existing tx.
 // It selects both accounts FOR UPDATE, checks balances, updates balances and inserts an account_transactions row.
-func Transfer(tx *sqlx.Tx, debitAccountID, creditAccountID int, amount float64, referenceType string, referenceID sql.NullInt64, description string) error {
+// settlementGuard (config SETTLEMENT_OVERDRAFT_GUARD) refuses transfers that would overdraw settlement.
+func Transfer(tx *sqlx.Tx, debitAccountID, creditAccountID int, amount float64, referenceType string, referenceID sql.NullInt64, description string, settlementGuard bool) error {
 	if tx == nil {
 		return fmt.Errorf("tx is nil")
 	}
@@ -85,6 +91,9 @@ func Transfer(tx *sqlx.Tx, debitAccountID, creditAccountID int, amount float64,
 	if debitAcc.AccountType == AccountPlayerWinnings && debitAcc.Balance < amount {
 		return fmt.Errorf("insufficient funds in account %d", debitAccountID)
 	}
+	if debitAcc.AccountType == AccountSettlement && settlementGuard && debitAcc.Balance < amount {
+		return fmt.Errorf("%w: account %d holds %.2f, transfer needs %.2f", ErrSettlementOverdraft, debitAccountID, debitAcc.Balance, amount)
+	}
 
 	// Update balances
 	newDebitBalance := debitAcc.Balance - amount
@@ -107,3 +116,18 @@ func Transfer(tx *sqlx.Tx, debitAccountID, creditAccountID int, amount float64,
 
 	return nil
 }
+
+// DebitSettlement takes amount out of the settlement account for money leaving the system
+// (a payout). With settlementGuard the balance update is conditional so concurrent payouts
+// cannot overdraw it.
+func DebitSettlement(tx *sqlx.Tx, settlementAccountID int, amount float64, settlementGuard bool) error {
+	res, err := tx.Exec(`UPDATE accounts SET balance = balance - $1, updated_at = NOW() WHERE id = $2 AND (balance >= $1 OR NOT $3)`,
+		amount, settlementAccountID, settlementGuard)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: payout of %.2f from account %d", ErrSettlementOverdraft, amount, settlementAccountID)
+	}
+	return nil
+}
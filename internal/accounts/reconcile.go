@@ -0,0 +1,186 @@
+package accounts
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Discrepancy is one ledger invariant that does not hold
+type Discrepancy struct {
+	Kind      string  `json:"kind"` // account_balance, negative_settlement, session_escrow or system_total
+	AccountID int     `json:"account_id,omitempty"`
+	SessionID int     `json:"session_id,omitempty"`
+	Expected  float64 `json:"expected"`
+	Actual    float64 `json:"actual"`
+	Detail    string  `json:"detail"`
+}
+
+// ReconcileReport is the outcome of a ledger integrity check
+type ReconcileReport struct {
+	CheckedAt       time.Time     `json:"checked_at"`
+	TotalDebits     float64       `json:"total_debits"`
+	TotalCredits    float64       `json:"total_credits"`
+	AccountsChecked int           `json:"accounts_checked"`
+	SessionsChecked int           `json:"sessions_checked"`
+	Balanced        bool          `json:"balanced"`
+	Discrepancies   []Discrepancy `json:"discrepancies"`
+}
+
+// Reconcile verifies the double-entry ledger without changing it:
+//   - every account's balance equals its credits minus its debits in account_transactions
+//   - the settlement account is never negative (payouts before the payins that fund them)
+//   - the sum of all balances equals money brought in minus money paid out (rows with no
+//     debit or no credit account)
+//   - escrow nets to zero for every COMPLETED or CANCELLED session
+//
+// Amounts are NUMERIC(12,2) so the comparisons are done exactly in SQL.
+func Reconcile(q sqlx.Queryer) (*ReconcileReport, error) {
+	report := &ReconcileReport{CheckedAt: time.Now(), Discrepancies: []Discrepancy{}}
+
+	var totals struct {
+		Debits   float64 `db:"debits"`
+		Credits  float64 `db:"credits"`
+		Inflow   float64 `db:"inflow"`
+		Outflow  float64 `db:"outflow"`
+		Balances float64 `db:"balances"`
+		Mismatch bool    `db:"mismatch"`
+	}
+	if err := sqlx.Get(q, &totals, `
+		WITH t AS (
+			SELECT
+				COALESCE(SUM(amount) FILTER (WHERE debit_account_id IS NOT NULL), 0) AS debits,
+				COALESCE(SUM(amount) FILTER (WHERE credit_account_id IS NOT NULL), 0) AS credits,
+				COALESCE(SUM(amount) FILTER (WHERE debit_account_id IS NULL), 0) AS inflow,
+				COALESCE(SUM(amount) FILTER (WHERE credit_account_id IS NULL), 0) AS outflow
+			FROM account_transactions
+		), b AS (
+			SELECT COALESCE(SUM(balance), 0) AS balances FROM accounts
+		)
+		SELECT t.debits, t.credits, t.inflow, t.outflow, b.balances,
+			b.balances <> t.inflow - t.outflow AS mismatch
+		FROM t, b`); err != nil {
+		return nil, err
+	}
+	report.TotalDebits, report.TotalCredits = totals.Debits, totals.Credits
+	if totals.Mismatch {
+		report.Discrepancies = append(report.Discrepancies, Discrepancy{
+			Kind:     "system_total",
+			Expected: totals.Inflow - totals.Outflow,
+			Actual:   totals.Balances,
+			Detail:   "sum of account balances differs from deposits minus payouts",
+		})
+	}
+
+	var accountRows []struct {
+		ID      int     `db:"id"`
+		Type    string  `db:"account_type"`
+		Balance float64 `db:"balance"`
+		Ledger  float64 `db:"ledger"`
+		Match   bool    `db:"match"`
+	}
+	if err := sqlx.Select(q, &accountRows, `
+		SELECT a.id, a.account_type, a.balance, l.ledger, a.balance = l.ledger AS match
+		FROM accounts a
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(SUM(CASE WHEN t.credit_account_id = a.id THEN t.amount ELSE 0 END), 0)
+			     - COALESCE(SUM(CASE WHEN t.debit_account_id = a.id THEN t.amount ELSE 0 END), 0) AS ledger
+			FROM account_transactions t
+			WHERE t.credit_account_id = a.id OR t.debit_account_id = a.id
+		) l
+		ORDER BY a.id`); err != nil {
+		return nil, err
+	}
+	report.AccountsChecked = len(accountRows)
+	for _, a := range accountRows {
+		if a.Type == AccountSettlement && a.Balance < 0 {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				Kind:      "negative_settlement",
+				AccountID: a.ID,
+				Expected:  0,
+				Actual:    a.Balance,
+				Detail:    "settlement paid out more than it received",
+			})
+		}
+		if !a.Match {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				Kind:      "account_balance",
+				AccountID: a.ID,
+				Expected:  a.Ledger,
+				Actual:    a.Balance,
+				Detail:    a.Type + " balance differs from its ledger movements",
+			})
+		}
+	}
+
+	var sessionRows []struct {
+		ID     int     `db:"id"`
+		Status string  `db:"status"`
+		Net    float64 `db:"net"`
+	}
+	if err := sqlx.Select(q, &sessionRows, `
+		SELECT s.id, s.status,
+			COALESCE(SUM(CASE WHEN t.credit_account_id = e.id THEN t.amount ELSE 0 END), 0)
+		  - COALESCE(SUM(CASE WHEN t.debit_account_id = e.id THEN t.amount ELSE 0 END), 0) AS net
+		FROM game_sessions s
+		JOIN accounts e ON e.account_type = $1 AND e.owner_player_id IS NULL
+		LEFT JOIN account_transactions t ON t.reference_type = 'SESSION' AND t.reference_id = s.id
+			AND (t.credit_account_id = e.id OR t.debit_account_id = e.id)
+		WHERE s.status IN ('COMPLETED', 'CANCELLED')
+		GROUP BY s.id, s.status
+		ORDER BY s.id`, AccountEscrow); err != nil {
+		return nil, err
+	}
+	report.SessionsChecked = len(sessionRows)
+	for _, s := range sessionRows {
+		if s.Net != 0 {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{
+				Kind:      "session_escrow",
+				SessionID: s.ID,
+				Expected:  0,
+				Actual:    s.Net,
+				Detail:    "escrow still holds money for " + s.Status + " session",
+			})
+		}
+	}
+
+	report.Balanced = len(report.Discrepancies) == 0
+	return report, nil
+}
+
+// StartReconciler runs Reconcile every interval until ctx is cancelled and logs any
+// discrepancies. It only reports: fixing the ledger is left to an operator.
+func StartReconciler(ctx context.Context, db *sqlx.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("[RECONCILE] Ledger reconciliation every %s", interval)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := Reconcile(db)
+			if err != nil {
+				log.Printf("[RECONCILE] Reconciliation failed: %v", err)
+				continue
+			}
+			logReconcileReport(report)
+		}
+	}
+}
+
+func logReconcileReport(r *ReconcileReport) {
+	if r.Balanced {
+		log.Printf("[RECONCILE] Ledger balanced: %d accounts, %d sessions", r.AccountsChecked, r.SessionsChecked)
+		return
+	}
+	log.Printf("[RECONCILE] ⚠ %d ledger discrepancies (%d accounts, %d sessions checked)",
+		len(r.Discrepancies), r.AccountsChecked, r.SessionsChecked)
+	for _, d := range r.Discrepancies {
+		log.Printf("[RECONCILE]   %s account=%d session=%d expected=%.2f actual=%.2f: %s",
+			d.Kind, d.AccountID, d.SessionID, d.Expected, d.Actual, d.Detail)
+	}
+}
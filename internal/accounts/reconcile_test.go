@@ -0,0 +1,187 @@
+package accounts
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+)
+
+// testDB connects to TEST_DATABASE_URL (a migrated schema); tests that need Postgres are skipped when it is unset
+func testDB(t *testing.T) *sqlx.DB {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Skipf("postgres unavailable: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// ledgerFixture holds the ids of a small ledger seeded by seedLedger
+type ledgerFixture struct {
+	settlement, escrow, winner, session int
+}
+
+// seedLedger empties the ledger inside tx (rolled back by the caller) and records one
+// deposit pair and one completed game: both stakes into escrow, tax and payout out of it.
+func seedLedger(t *testing.T, tx *sqlx.Tx) ledgerFixture {
+	t.Helper()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+	_, err := tx.Exec(`TRUNCATE account_transactions, accounts CASCADE`)
+	must(err)
+
+	suffix := time.Now().UnixNano() % 10000000
+	var p1, p2 int
+	must(tx.Get(&p1, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, fmt.Sprintf("2567%08d", suffix)))
+	must(tx.Get(&p2, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, fmt.Sprintf("2568%08d", suffix)))
+
+	account := func(kind string, owner interface{}) int {
+		var id int
+		must(tx.Get(&id, `INSERT INTO accounts (account_type, owner_player_id, balance) VALUES ($1, $2, 0) RETURNING id`, kind, owner))
+		return id
+	}
+	settlement := account(AccountSettlement, nil)
+	escrow := account(AccountEscrow, nil)
+	tax := account(AccountTax, nil)
+	w1 := account(AccountPlayerWinnings, p1)
+	w2 := account(AccountPlayerWinnings, p2)
+
+	var session int
+	must(tx.Get(&session, `INSERT INTO game_sessions (game_token, player1_id, player2_id, stake_amount, status, expiry_time)
+		VALUES ($1, $2, $3, 1000, 'COMPLETED', NOW()) RETURNING id`, fmt.Sprintf("recon-%d", suffix), p1, p2))
+
+	// Deposits enter the system with no debit account
+	_, err = tx.Exec(`INSERT INTO account_transactions (debit_account_id, credit_account_id, amount, reference_type, description)
+		VALUES (NULL, $1, 2000, 'TRANSACTION', 'Deposit (gross)')`, settlement)
+	must(err)
+	_, err = tx.Exec(`UPDATE accounts SET balance = 2000 WHERE id = $1`, settlement)
+	must(err)
+	ref := sql.NullInt64{Int64: int64(session), Valid: true}
+	must(Transfer(tx, settlement, w1, 1000, "TRANSACTION", sql.NullInt64{}, "Deposit", true))
+	must(Transfer(tx, settlement, w2, 1000, "TRANSACTION", sql.NullInt64{}, "Deposit", true))
+	must(Transfer(tx, w1, escrow, 1000, "SESSION", ref, "Stake", true))
+	must(Transfer(tx, w2, escrow, 1000, "SESSION", ref, "Stake", true))
+	must(Transfer(tx, escrow, tax, 200, "SESSION", ref, "Payout tax", true))
+	must(Transfer(tx, escrow, w1, 1800, "SESSION", ref, "Winner payout (after tax)", true))
+
+	return ledgerFixture{settlement: settlement, escrow: escrow, winner: w1, session: session}
+}
+
+func TestReconcileBalancedLedger(t *testing.T) {
+	db := testDB(t)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	seedLedger(t, tx)
+
+	report, err := Reconcile(tx)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if !report.Balanced || len(report.Discrepancies) != 0 {
+		t.Fatalf("balanced ledger reported discrepancies: %+v", report.Discrepancies)
+	}
+	if report.AccountsChecked != 5 || report.TotalCredits != 8000 || report.TotalDebits != 6000 {
+		t.Errorf("unexpected totals: %+v", report)
+	}
+}
+
+func TestReconcileReportsCorruption(t *testing.T) {
+	db := testDB(t)
+
+	t.Run("account balance drift", func(t *testing.T) {
+		tx := db.MustBegin()
+		defer tx.Rollback()
+		f := seedLedger(t, tx)
+		// A rounding bug credited the winner one cent more than the ledger shows
+		tx.MustExec(`UPDATE accounts SET balance = balance + 0.01 WHERE id = $1`, f.winner)
+
+		report, err := Reconcile(tx)
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		if report.Balanced {
+			t.Fatal("corrupted ledger reported as balanced")
+		}
+		var found bool
+		for _, d := range report.Discrepancies {
+			if d.Kind == "account_balance" && d.AccountID == f.winner && d.Expected == 1800 && d.Actual == 1800.01 {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("winner balance drift not reported: %+v", report.Discrepancies)
+		}
+	})
+
+	t.Run("escrow left in completed session", func(t *testing.T) {
+		tx := db.MustBegin()
+		defer tx.Rollback()
+		f := seedLedger(t, tx)
+		// The payout moved 0.01 less than it should have: balances agree with the ledger, escrow does not net out
+		tx.MustExec(`UPDATE account_transactions SET amount = 1799.99 WHERE debit_account_id = $1 AND credit_account_id = $2`, f.escrow, f.winner)
+		tx.MustExec(`UPDATE accounts SET balance = balance - 0.01 WHERE id = $1`, f.winner)
+		tx.MustExec(`UPDATE accounts SET balance = balance + 0.01 WHERE id = $1`, f.escrow)
+
+		report, err := Reconcile(tx)
+		if err != nil {
+			t.Fatalf("Reconcile: %v", err)
+		}
+		if report.Balanced || len(report.Discrepancies) != 1 {
+			t.Fatalf("want exactly one discrepancy, got %+v", report.Discrepancies)
+		}
+		d := report.Discrepancies[0]
+		if d.Kind != "session_escrow" || d.SessionID != f.session || d.Actual != 0.01 {
+			t.Errorf("unexpected discrepancy: %+v", d)
+		}
+	})
+}
+
+func TestSettlementOverdraftRejected(t *testing.T) {
+	db := testDB(t)
+	tx := db.MustBegin()
+	defer tx.Rollback()
+	f := seedLedger(t, tx)
+
+	// Fund settlement with 500 from the winner's balance, then try to move out more than that
+	if err := Transfer(tx, f.winner, f.settlement, 500, "WITHDRAW_REQUEST", sql.NullInt64{}, "reserve", true); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	err := Transfer(tx, f.settlement, f.winner, 500.01, "WITHDRAW_REFUND", sql.NullInt64{}, "refund", true)
+	if !errors.Is(err, ErrSettlementOverdraft) {
+		t.Fatalf("overdrawing transfer: got %v, want ErrSettlementOverdraft", err)
+	}
+	if err := DebitSettlement(tx, f.settlement, 600, true); !errors.Is(err, ErrSettlementOverdraft) {
+		t.Fatalf("overdrawing payout: got %v, want ErrSettlementOverdraft", err)
+	}
+	if err := DebitSettlement(tx, f.settlement, 500, true); err != nil {
+		t.Fatalf("funded payout rejected: %v", err)
+	}
+
+	// With the guard off the overdraft goes through, and reconciliation reports it
+	if err := DebitSettlement(tx, f.settlement, 100, false); err != nil {
+		t.Fatalf("guard disabled: %v", err)
+	}
+	tx.MustExec(`INSERT INTO account_transactions (debit_account_id, credit_account_id, amount, reference_type, description) VALUES ($1, NULL, 600, 'WITHDRAW', 'Payout to external')`, f.settlement)
+
+	report, err := Reconcile(tx)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if report.Balanced || len(report.Discrepancies) != 1 || report.Discrepancies[0].Kind != "negative_settlement" || report.Discrepancies[0].Actual != -100 {
+		t.Fatalf("want one negative_settlement of -100, got %+v", report.Discrepancies)
+	}
+}
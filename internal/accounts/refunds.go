@@ -0,0 +1,29 @@
+package accounts
+
+import (
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Refund taxonomy: every stake returned from escrow writes one escrow_ledger row per player with
+// entry_type LedgerRefund and refund_reason set to one of the Refund* reasons, so reports can
+// count refunds with a single entry type and break them down by reason.
+const (
+	LedgerRefund = "REFUND"
+
+	RefundQueueCancel     = "QUEUE_CANCEL"     // historical only: queued stakes stay in winnings, so leaving refunds nothing
+	RefundSessionExpired  = "SESSION_EXPIRED"  // matched game never started (no-show) and expired
+	RefundEarlyDisconnect = "EARLY_DISCONNECT" // player dropped before the first shot
+	RefundAdminCancel     = "ADMIN_CANCEL"     // an admin force-cancelled the game
+	RefundDraw            = "DRAW"             // game ended in a draw; both stakes returned
+	RefundDisputeUpheld   = "DISPUTE_UPHELD"   // an admin voided a held result after the loser's dispute
+)
+
+// RecordRefund writes the escrow_ledger row for a refund of amount to playerID. sessionID is
+// unset for refunds of queue entries that never reached a game.
+func RecordRefund(tx *sqlx.Tx, sessionID sql.NullInt64, playerID int, amount float64, reason, description string) error {
+	_, err := tx.Exec(`INSERT INTO escrow_ledger (session_id, entry_type, refund_reason, player_id, amount, balance_after, description, created_at) VALUES ($1, $2, $3, $4, $5, 0.0, $6, NOW())`,
+		sessionID, LedgerRefund, reason, playerID, amount, description)
+	return err
+}
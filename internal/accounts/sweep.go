@@ -0,0 +1,49 @@
+package accounts
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ReferenceBank marks account_transactions rows for revenue swept out to the operator's bank
+const ReferenceBank = "BANK"
+
+// ErrSweepExceedsBalance is returned when a sweep asks for more than the account holds
+var ErrSweepExceedsBalance = errors.New("sweep exceeds account balance")
+
+// SweepToBank takes amount of revenue out of the system account accountType (platform or tax)
+// to the bank. The balance drops and a debit-only account_transactions row (reference_type
+// BANK, no credit account) books where it went, so Reconcile counts it as money paid out.
+// It returns the ledger row's id.
+func SweepToBank(tx *sqlx.Tx, accountType string, amount float64, description string) (int, error) {
+	if accountType != AccountPlatform && accountType != AccountTax {
+		return 0, fmt.Errorf("cannot sweep %s account", accountType)
+	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("sweep amount must be positive")
+	}
+
+	var accountID int
+	err := tx.Get(&accountID, `UPDATE accounts SET balance = balance - $1, updated_at = NOW()
+		WHERE id = (SELECT id FROM accounts WHERE account_type = $2 AND owner_player_id IS NULL ORDER BY id LIMIT 1)
+		  AND balance >= $1
+		RETURNING id`, amount, accountType)
+	if err == sql.ErrNoRows {
+		return 0, ErrSweepExceedsBalance
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var txnID int
+	if err := tx.Get(&txnID, `INSERT INTO account_transactions (debit_account_id, credit_account_id, amount, reference_type, description, created_at)
+		VALUES ($1, NULL, $2, $3, $4, NOW()) RETURNING id`, accountID, amount, ReferenceBank, description); err != nil {
+		return 0, err
+	}
+	log.Printf("[ACCT] Swept %.2f from %s account %d to bank (txn %d): %s", amount, accountType, accountID, txnID, description)
+	return txnID, nil
+}
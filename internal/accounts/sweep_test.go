@@ -0,0 +1,63 @@
+package accounts
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestSweepToBankBooksExternalTransfer(t *testing.T) {
+	db := testDB(t)
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+
+	f := seedLedger(t, tx)
+	var platform int
+	if err := tx.Get(&platform, `INSERT INTO accounts (account_type, balance) VALUES ($1, 0) RETURNING id`, AccountPlatform); err != nil {
+		t.Fatalf("platform account: %v", err)
+	}
+	if err := Transfer(tx, f.winner, platform, 300, "TRANSACTION", sql.NullInt64{}, "Commission", true); err != nil {
+		t.Fatalf("commission: %v", err)
+	}
+
+	txnID, err := SweepToBank(tx, AccountPlatform, 250, "March revenue")
+	if err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	var balance float64
+	tx.Get(&balance, `SELECT balance FROM accounts WHERE id=$1`, platform)
+	if balance != 50 {
+		t.Errorf("platform balance %.2f after sweep, want 50", balance)
+	}
+
+	var row struct {
+		Debit  sql.NullInt64 `db:"debit_account_id"`
+		Credit sql.NullInt64 `db:"credit_account_id"`
+		Amount float64       `db:"amount"`
+		Ref    string        `db:"reference_type"`
+	}
+	if err := tx.Get(&row, `SELECT debit_account_id, credit_account_id, amount, reference_type FROM account_transactions WHERE id=$1`, txnID); err != nil {
+		t.Fatalf("sweep row: %v", err)
+	}
+	if row.Debit.Int64 != int64(platform) || row.Credit.Valid || row.Amount != 250 || row.Ref != ReferenceBank {
+		t.Errorf("sweep booked as %+v, want platform -> (none) 250 BANK", row)
+	}
+
+	report, err := Reconcile(tx)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if !report.Balanced {
+		t.Errorf("ledger unbalanced after sweep: %+v", report.Discrepancies)
+	}
+
+	if _, err := SweepToBank(tx, AccountPlatform, 51, "too much"); !errors.Is(err, ErrSweepExceedsBalance) {
+		t.Errorf("oversweep err = %v, want ErrSweepExceedsBalance", err)
+	}
+	if _, err := SweepToBank(tx, AccountEscrow, 10, "not revenue"); err == nil {
+		t.Error("swept the escrow account")
+	}
+}
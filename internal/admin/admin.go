@@ -12,6 +12,19 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// RoleSuperAdmin is the role of the startup admin account; it may do everything
+const RoleSuperAdmin = "super_admin"
+
+// HasRole reports whether the admin account holds role
+func HasRole(acc *models.AdminAccount, role string) bool {
+	for _, r := range acc.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
 // GetAdminAccountByUsername retrieves an admin account by username
 func GetAdminAccountByUsername(db *sqlx.DB, username string) (*models.AdminAccount, error) {
 	var admin models.AdminAccount
@@ -83,7 +96,7 @@ func EnsureSuperAdmin(db *sqlx.DB, username, password, phone string) error {
 			token_hash = EXCLUDED.token_hash,
 			roles = EXCLUDED.roles,
 			updated_at = NOW()
-	`, phone, username, "Super Admin", string(dummyTokenHash), string(passwordHash), pq.Array([]string{"super_admin"}), pq.Array([]string{}))
+	`, phone, username, "Super Admin", string(dummyTokenHash), string(passwordHash), pq.Array([]string{RoleSuperAdmin}), pq.Array([]string{}))
 
 	if err != nil {
 		return fmt.Errorf("failed to upsert super admin: %w", err)
@@ -106,6 +106,12 @@ func ApplyRuntimeConfigToConfig(db *sqlx.DB, cfg *config.Config) error {
 			if v, err := strconv.Atoi(c.Value); err == nil {
 				cfg.MinWithdrawAmount = v
 			}
+		case "withdraw_auto_approve_limit":
+			if v, err := strconv.Atoi(c.Value); err == nil {
+				cfg.WithdrawAutoApproveLimit = v
+			}
+		case "min_client_version":
+			cfg.MinClientVersion = c.Value
 		}
 	}
 
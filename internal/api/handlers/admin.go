@@ -19,6 +19,7 @@ import (
 	"github.com/playpool/backend/internal/config"
 	"github.com/playpool/backend/internal/models"
 	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -64,8 +65,7 @@ func AdminLogin(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerF
 		}
 
 		// Send SMS to admin's phone
-		message := fmt.Sprintf("Your PlayPool admin OTP is: %s. Valid for 5 minutes.", otp)
-		if _, err := sms.SendSMS(ctx, adminAcc.Phone, message); err != nil {
+		if _, err := sms.NotifyTemplate(ctx, sms.TypeOTP, adminAcc.Phone, templates.AdminOTP, templates.Params{"Code": otp}); err != nil {
 			log.Printf("[ADMIN] Failed to send OTP SMS to %s: %v", adminAcc.Phone, err)
 			// In mock mode, log the OTP for development
 			if cfg.MockMode {
@@ -205,6 +205,70 @@ func AdminSessionMiddleware(rdb *redis.Client, db *sqlx.DB) gin.HandlerFunc {
 	}
 }
 
+// loadAdminAccount returns the account of the admin with username (replaced in tests)
+var loadAdminAccount = admin.GetAdminAccountByUsername
+
+// adminClientIP is the address an admin request came from: the first entry of the trusted
+// proxy header when one is configured and present, else the connection's address. Headers
+// are never trusted without configuration, since clients can set them freely.
+func adminClientIP(c *gin.Context, cfg *config.Config) string {
+	if cfg != nil && cfg.AdminTrustedProxyHeader != "" {
+		if v := c.GetHeader(cfg.AdminTrustedProxyHeader); v != "" {
+			return strings.TrimSpace(strings.Split(v, ",")[0])
+		}
+	}
+	return c.RemoteIP()
+}
+
+// AdminIPAllowlist rejects requests from an admin session whose account restricts
+// allowed_ips to addresses that don't include the client's. Runs after AdminSessionMiddleware.
+func AdminIPAllowlist(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.GetString("admin_username")
+		acc, err := loadAdminAccount(db, username)
+		if err != nil {
+			log.Printf("[ADMIN] Failed to load IP allowlist for %s: %v", username, err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+			c.Abort()
+			return
+		}
+
+		ip := adminClientIP(c, cfg)
+		if !admin.IPAllowed(acc.AllowedIPs, ip) {
+			log.Printf("[ADMIN] Denied %s %s for %s from %s (not in allowlist)", c.Request.Method, c.Request.URL.Path, username, ip)
+			if db != nil {
+				admin.LogAdminAction(db, username, ip, c.Request.URL.Path, "ip_denied", map[string]interface{}{"method": c.Request.Method}, false)
+			}
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access from this IP is not allowed"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdminRole lets only admins holding role (or super_admin) through. Runs after
+// AdminSessionMiddleware.
+func RequireAdminRole(db *sqlx.DB, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		username := c.GetString("admin_username")
+		acc, err := loadAdminAccount(db, username)
+		if err != nil {
+			log.Printf("[ADMIN] Failed to load roles for %s: %v", username, err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+			c.Abort()
+			return
+		}
+		if !admin.HasRole(acc, role) && !admin.HasRole(acc, admin.RoleSuperAdmin) {
+			log.Printf("[ADMIN] Denied %s %s for %s (needs role %s)", c.Request.Method, c.Request.URL.Path, username, role)
+			c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // GetAdminAccounts returns list of accounts and their balances
 func GetAdminAccounts(db *sqlx.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -456,6 +520,25 @@ func GetAdminStats(db *sqlx.DB) gin.HandlerFunc {
 			stats["pending_withdrawals"] = pendingWithdrawals
 		}
 
+		// Estimated SMS spend today (see SMS_COST_PER_SEGMENT)
+		var smsToday struct {
+			Messages int     `db:"messages"`
+			Segments int     `db:"segments"`
+			Cost     float64 `db:"cost"`
+		}
+		err = db.Get(&smsToday, `
+			SELECT COUNT(*) AS messages, COALESCE(SUM(segments), 0) AS segments, COALESCE(SUM(estimated_cost), 0) AS cost
+			FROM sms_messages
+			WHERE created_at >= CURRENT_DATE
+		`)
+		if err != nil {
+			log.Printf("[ADMIN] Failed to fetch SMS cost: %v", err)
+		} else {
+			stats["sms_sent_today"] = smsToday.Messages
+			stats["sms_segments_today"] = smsToday.Segments
+			stats["sms_cost_today"] = smsToday.Cost
+		}
+
 		admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/stats", "get_stats", nil, true)
 		c.JSON(http.StatusOK, stats)
 	}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/admin"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/models"
+)
+
+// allowlistRouter serves /admin/me as if AdminSessionMiddleware had accepted a session for "ops",
+// whose account allows the given IPs
+func allowlistRouter(t *testing.T, cfg *config.Config, allowed []string) *gin.Engine {
+	prev := loadAdminAccount
+	loadAdminAccount = func(db *sqlx.DB, username string) (*models.AdminAccount, error) {
+		return &models.AdminAccount{AllowedIPs: allowed}, nil
+	}
+	t.Cleanup(func() { loadAdminAccount = prev })
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/admin/me", func(c *gin.Context) { c.Set("admin_username", "ops") }, AdminIPAllowlist(nil, cfg), AdminMe())
+	return r
+}
+
+func adminGet(r *gin.Engine, remoteAddr string, header map[string]string) int {
+	req := httptest.NewRequest(http.MethodGet, "/admin/me", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestAdminIPAllowlist(t *testing.T) {
+	allowed := []string{"203.0.113.7", "10.20.0.0/16"}
+	cases := []struct {
+		name    string
+		allowed []string
+		remote  string
+		want    int
+	}{
+		{"listed address", allowed, "203.0.113.7:5100", http.StatusOK},
+		{"address in listed range", allowed, "10.20.4.9:5100", http.StatusOK},
+		{"unlisted address", allowed, "198.51.100.2:5100", http.StatusForbidden},
+		{"empty allowlist allows any", nil, "198.51.100.2:5100", http.StatusOK},
+	}
+	for _, tc := range cases {
+		r := allowlistRouter(t, &config.Config{}, tc.allowed)
+		if got := adminGet(r, tc.remote, nil); got != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAdminIPAllowlistTrustsOnlyConfiguredHeader(t *testing.T) {
+	allowed := []string{"203.0.113.7"}
+	proxy := "10.0.0.1:443"
+
+	// Without a trusted header, a spoofed forwarding header is ignored
+	r := allowlistRouter(t, &config.Config{}, allowed)
+	if got := adminGet(r, proxy, map[string]string{"X-Forwarded-For": "203.0.113.7"}); got != http.StatusForbidden {
+		t.Errorf("untrusted header: status %d, want 403", got)
+	}
+
+	r = allowlistRouter(t, &config.Config{AdminTrustedProxyHeader: "X-Forwarded-For"}, allowed)
+	if got := adminGet(r, proxy, map[string]string{"X-Forwarded-For": "203.0.113.7, 10.0.0.1"}); got != http.StatusOK {
+		t.Errorf("trusted header with allowed client: status %d, want 200", got)
+	}
+	if got := adminGet(r, proxy, map[string]string{"X-Forwarded-For": "198.51.100.2"}); got != http.StatusForbidden {
+		t.Errorf("trusted header with other client: status %d, want 403", got)
+	}
+}
+
+func TestRevenueSweepNeedsSuperAdmin(t *testing.T) {
+	prev := loadAdminAccount
+	t.Cleanup(func() { loadAdminAccount = prev })
+
+	gin.SetMode(gin.TestMode)
+	for _, tc := range []struct {
+		roles []string
+		want  int
+	}{
+		{[]string{"support"}, http.StatusForbidden},
+		{nil, http.StatusForbidden},
+		// Let through to the handler, which rejects the empty body
+		{[]string{admin.RoleSuperAdmin}, http.StatusBadRequest},
+	} {
+		roles := tc.roles
+		loadAdminAccount = func(db *sqlx.DB, username string) (*models.AdminAccount, error) {
+			return &models.AdminAccount{Roles: roles}, nil
+		}
+		r := gin.New()
+		r.POST("/revenue/sweep", func(c *gin.Context) { c.Set("admin_username", "ops") },
+			RequireAdminRole(nil, admin.RoleSuperAdmin), AdminSweepRevenue(nil))
+		if w := postJSON(r, "/revenue/sweep", gin.H{}); w.Code != tc.want {
+			t.Errorf("roles %v: status %d, want %d", roles, w.Code, tc.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
+)
+
+func TestLoggedChatVisibleToAdminsOnly(t *testing.T) {
+	db := testDB(t)
+	gin.SetMode(gin.TestMode)
+
+	var pid, sessionID int
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if err := db.Get(&pid, `INSERT INTO players (phone_number, display_name) VALUES ($1, 'Chatty') RETURNING id`, phone); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+	token := fmt.Sprintf("chat-%d", time.Now().UnixNano())
+	if err := db.Get(&sessionID, `INSERT INTO game_sessions (game_token, player1_id, stake_amount, status, expiry_time) VALUES ($1,$2,1000,'COMPLETED',NOW()) RETURNING id`, token, pid); err != nil {
+		t.Fatalf("insert session: %v", err)
+	}
+	game.NewGameManager(db, nil, &config.Config{}).RecordChat(sessionID, pid, "you are terrible")
+
+	r := gin.New()
+	asAdmin := func(c *gin.Context) { c.Set("admin_username", "ops") }
+	r.GET("/admin/games/:id/chat", asAdmin, GetAdminGameChat(db))
+	// The real admin group: requests without an admin session cookie never reach the handler
+	r.GET("/player/games/:id/chat", AdminSessionMiddleware(nil, db), GetAdminGameChat(db))
+
+	for _, ref := range []string{fmt.Sprint(sessionID), token} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/games/"+ref+"/chat", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("admin %s: status %d: %s", ref, w.Code, w.Body.String())
+		}
+		var resp struct {
+			SessionID int `json:"session_id"`
+			Messages  []struct {
+				PlayerID  *int   `json:"player_id"`
+				Message   string `json:"message"`
+				CreatedAt string `json:"created_at"`
+			} `json:"messages"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.SessionID != sessionID || len(resp.Messages) != 1 {
+			t.Fatalf("admin %s: unexpected transcript %s", ref, w.Body.String())
+		}
+		m := resp.Messages[0]
+		if m.Message != "you are terrible" || m.PlayerID == nil || *m.PlayerID != pid || m.CreatedAt == "" {
+			t.Errorf("admin %s: message %+v", ref, m)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/player/games/"+token+"/chat", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("player without admin session: status %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/game"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	adminDashboardCacheKey = "admin:dashboard"
+	adminDashboardCacheTTL = 15 * time.Second
+)
+
+// adminDashboard is the headline financial and activity summary for the admin dashboard
+type adminDashboard struct {
+	PlatformRevenue    float64   `json:"platform_revenue"` // commission + tax account balances
+	CommissionBalance  float64   `json:"commission_balance"`
+	TaxBalance         float64   `json:"tax_balance"`
+	EscrowBalance      float64   `json:"escrow_balance"`
+	PendingWithdrawals float64   `json:"pending_withdrawals"` // requested but not yet paid out
+	ActivePlayers      int       `json:"active_players"`      // in a game on this server
+	ActiveGames        int       `json:"active_games"`
+	QueuedPlayers      int       `json:"queued_players"`
+	GamesPlayedToday   int       `json:"games_played_today"`
+	GGRToday           float64   `json:"ggr_today"` // stakes kept by the house today: commission + tax credited
+	GeneratedAt        time.Time `json:"generated_at"`
+}
+
+// loadAdminDashboard computes the dashboard from account balances, the ledger and the game manager
+func loadAdminDashboard(db *sqlx.DB) (adminDashboard, error) {
+	d := adminDashboard{GeneratedAt: time.Now()}
+
+	var balances []struct {
+		AccountType string  `db:"account_type"`
+		Balance     float64 `db:"balance"`
+	}
+	if err := db.Select(&balances, `SELECT account_type, COALESCE(SUM(balance), 0) AS balance FROM accounts
+		WHERE account_type IN ($1, $2, $3) GROUP BY account_type`,
+		accounts.AccountPlatform, accounts.AccountTax, accounts.AccountEscrow); err != nil {
+		return d, err
+	}
+	for _, b := range balances {
+		switch b.AccountType {
+		case accounts.AccountPlatform:
+			d.CommissionBalance = b.Balance
+		case accounts.AccountTax:
+			d.TaxBalance = b.Balance
+		case accounts.AccountEscrow:
+			d.EscrowBalance = b.Balance
+		}
+	}
+	d.PlatformRevenue = d.CommissionBalance + d.TaxBalance
+
+	if err := db.Get(&d.PendingWithdrawals, `SELECT COALESCE(SUM(amount), 0) FROM withdraw_requests WHERE status IN ($1, $2, $3)`,
+		WithdrawStatusPendingReview, WithdrawStatusPending, WithdrawStatusProcessing); err != nil {
+		return d, err
+	}
+	if err := db.Get(&d.QueuedPlayers, `SELECT COUNT(*) FROM matchmaking_queue WHERE status = 'queued'`); err != nil {
+		return d, err
+	}
+	if err := db.Get(&d.GamesPlayedToday, `SELECT COUNT(*) FROM game_sessions WHERE status = $1 AND completed_at >= CURRENT_DATE`,
+		string(game.StatusCompleted)); err != nil {
+		return d, err
+	}
+	if err := db.Get(&d.GGRToday, `SELECT COALESCE(SUM(at.amount), 0) FROM account_transactions at
+		JOIN accounts a ON at.credit_account_id = a.id
+		WHERE a.account_type IN ($1, $2) AND at.created_at >= CURRENT_DATE`,
+		accounts.AccountPlatform, accounts.AccountTax); err != nil {
+		return d, err
+	}
+
+	if game.Manager != nil {
+		active := game.Manager.ActivePoolGames()
+		d.ActiveGames = len(active)
+		d.ActivePlayers = 2 * len(active)
+	}
+	return d, nil
+}
+
+// GetAdminDashboard returns revenue, escrow, pending withdrawals, activity counts and today's GGR.
+// The summary is cached in Redis for a few seconds so a dashboard left open doesn't load the DB.
+// GET /api/v1/admin/dashboard
+func GetAdminDashboard(db *sqlx.DB, rdb *redis.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := context.Background()
+		if rdb != nil {
+			if cached, err := rdb.Get(ctx, adminDashboardCacheKey).Bytes(); err == nil {
+				c.Data(http.StatusOK, "application/json; charset=utf-8", cached)
+				return
+			}
+		}
+
+		d, err := loadAdminDashboard(db)
+		if err != nil {
+			log.Printf("[ADMIN] Failed to load dashboard: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load dashboard"})
+			return
+		}
+		body, err := json.Marshal(d)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode dashboard"})
+			return
+		}
+		if rdb != nil {
+			if err := rdb.Set(ctx, adminDashboardCacheKey, body, adminDashboardCacheTTL).Err(); err != nil {
+				log.Printf("[ADMIN] Failed to cache dashboard: %v", err)
+			}
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}
+}
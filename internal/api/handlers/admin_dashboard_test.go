@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"database/sql"
+	"math"
+	"testing"
+
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestAdminDashboardTotalsFollowLedger(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000, WithdrawAutoApproveLimit: 50000}
+	r, pid := withdrawFixture(t, db, cfg, 100000)
+
+	before, err := loadAdminDashboard(db)
+	if err != nil {
+		t.Fatalf("dashboard: %v", err)
+	}
+
+	// Seed: commission, tax and an escrowed stake paid out of the player's winnings
+	winnings, _ := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
+	tx, err := db.Beginx()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	for accType, amount := range map[string]float64{accounts.AccountPlatform: 1500, accounts.AccountTax: 300, accounts.AccountEscrow: 5000} {
+		var accID int
+		if err := tx.Get(&accID, `SELECT id FROM accounts WHERE account_type=$1 AND owner_player_id IS NULL LIMIT 1`, accType); err != nil {
+			t.Fatalf("%s account: %v", accType, err)
+		}
+		if err := accounts.Transfer(tx, winnings.ID, accID, amount, "TEST", sql.NullInt64{}, "dashboard seed", true); err != nil {
+			t.Fatalf("seed %s: %v", accType, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	requestWithdraw(t, r, 20000)
+
+	after, err := loadAdminDashboard(db)
+	if err != nil {
+		t.Fatalf("dashboard: %v", err)
+	}
+	deltas := []struct {
+		name      string
+		got, want float64
+	}{
+		{"platform_revenue", after.PlatformRevenue - before.PlatformRevenue, 1800},
+		{"commission_balance", after.CommissionBalance - before.CommissionBalance, 1500},
+		{"tax_balance", after.TaxBalance - before.TaxBalance, 300},
+		{"escrow_balance", after.EscrowBalance - before.EscrowBalance, 5000},
+		{"pending_withdrawals", after.PendingWithdrawals - before.PendingWithdrawals, 20000},
+		{"ggr_today", after.GGRToday - before.GGRToday, 1800},
+	}
+	for _, d := range deltas {
+		if math.Abs(d.got-d.want) > 0.001 {
+			t.Errorf("%s rose by %.2f, want %.2f", d.name, d.got, d.want)
+		}
+	}
+}
@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/accounts"
 	"github.com/playpool/backend/internal/admin"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
+	"github.com/playpool/backend/internal/payment"
 )
 
 // GetAdminWithdrawals returns a paginated list of withdrawal requests
@@ -34,6 +40,7 @@ func GetAdminWithdrawals(db *sqlx.DB) gin.HandlerFunc {
 			CreatedAt   string  `db:"created_at" json:"created_at"`
 			ProcessedAt *string `db:"processed_at" json:"processed_at"`
 			Note        *string `db:"note" json:"note"`
+			ApprovedBy  *string `db:"approved_by" json:"approved_by"`
 			TotalCount  int     `db:"total_count" json:"-"`
 		}
 
@@ -45,16 +52,19 @@ func GetAdminWithdrawals(db *sqlx.DB) gin.HandlerFunc {
 				wr.status,
 				to_char(wr.created_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"') as created_at,
 				to_char(wr.processed_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"') as processed_at,
-				wr.note,
+				wr.note, wr.approved_by,
 				COUNT(*) OVER() as total_count
 			FROM withdraw_requests wr
 			LEFT JOIN players p ON wr.player_id = p.id
 			WHERE ($1 = 'all'
+				OR ($1 = 'review' AND wr.status = 'PENDING_REVIEW')
 				OR ($1 = 'pending' AND wr.status = 'PENDING')
+				OR ($1 = 'processing' AND wr.status = 'PROCESSING')
+				OR ($1 = 'hold' AND wr.status = 'SETTLEMENT_HOLD')
 				OR ($1 = 'completed' AND wr.status = 'COMPLETED')
 				OR ($1 = 'failed' AND wr.status = 'FAILED'))
 			ORDER BY
-				CASE WHEN wr.status = 'PENDING' THEN 0 ELSE 1 END,
+				CASE wr.status WHEN 'SETTLEMENT_HOLD' THEN 0 WHEN 'PENDING_REVIEW' THEN 1 WHEN 'PENDING' THEN 2 ELSE 3 END,
 				wr.created_at DESC
 			LIMIT $2 OFFSET $3
 		`
@@ -76,41 +86,73 @@ func GetAdminWithdrawals(db *sqlx.DB) gin.HandlerFunc {
 	}
 }
 
-// AdminApproveWithdrawal marks a pending withdrawal as completed
-func AdminApproveWithdrawal(db *sqlx.DB) gin.HandlerFunc {
+// AdminApproveWithdrawal approves a withdrawal. Requests held for review are released
+// to the payout path; PENDING requests are marked as completed; payouts on settlement
+// hold are settled again (once settlement has been funded).
+func AdminApproveWithdrawal(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		adminUsername := c.GetString("admin_username")
 		withdrawID := c.Param("id")
 
-		// Verify status is PENDING
+		var playerID int
+		var amount float64
 		var currentStatus string
-		err := db.Get(&currentStatus, `SELECT status FROM withdraw_requests WHERE id = $1`, withdrawID)
+		err := db.QueryRowx(`SELECT player_id, amount, status FROM withdraw_requests WHERE id = $1`, withdrawID).Scan(&playerID, &amount, &currentStatus)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Withdrawal not found"})
 			return
 		}
-		if currentStatus != "PENDING" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Can only approve PENDING withdrawals"})
+
+		var res sql.Result
+		switch currentStatus {
+		case payment.PayoutStatusSettlementHold:
+			reqID, _ := strconv.Atoi(withdrawID)
+			payment.ProcessPayoutSuccess(db, cfg, reqID, "", "Settled by admin")
+			var settled string
+			if err := db.Get(&settled, `SELECT status FROM withdraw_requests WHERE id = $1`, reqID); err != nil || settled != WithdrawStatusCompleted {
+				admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/withdrawals/"+withdrawID+"/approve", "approve_withdrawal", map[string]interface{}{"withdraw_id": withdrawID}, false)
+				c.JSON(http.StatusConflict, gin.H{"error": "Settlement still cannot cover this payout"})
+				return
+			}
+			admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/withdrawals/"+withdrawID+"/approve", "approve_withdrawal", map[string]interface{}{"withdraw_id": withdrawID, "previous_status": currentStatus, "amount": amount}, true)
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+			return
+		case WithdrawStatusPendingReview:
+			res, err = db.Exec(`
+				UPDATE withdraw_requests SET status = 'PENDING', approved_by = $1
+				WHERE id = $2 AND status = 'PENDING_REVIEW'
+			`, adminUsername, withdrawID)
+		case WithdrawStatusPending:
+			res, err = db.Exec(`
+				UPDATE withdraw_requests SET status = 'COMPLETED', processed_at = NOW(), note = 'Approved by admin', approved_by = $1
+				WHERE id = $2 AND status = 'PENDING'
+			`, adminUsername, withdrawID)
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Can only approve PENDING, PENDING_REVIEW or SETTLEMENT_HOLD withdrawals"})
 			return
 		}
-
-		_, err = db.Exec(`
-			UPDATE withdraw_requests SET status = 'COMPLETED', processed_at = NOW(), note = 'Approved by admin'
-			WHERE id = $1
-		`, withdrawID)
 		if err != nil {
 			log.Printf("[ADMIN] Failed to approve withdrawal %s: %v", withdrawID, err)
 			admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/withdrawals/"+withdrawID+"/approve", "approve_withdrawal", map[string]interface{}{"withdraw_id": withdrawID}, false)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve withdrawal"})
 			return
 		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Withdrawal was already processed"})
+			return
+		}
+
+		if currentStatus == WithdrawStatusPendingReview {
+			reqID, _ := strconv.Atoi(withdrawID)
+			startWithdrawPayout(db, cfg, reqID, playerID, amount)
+		}
 
-		admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/withdrawals/"+withdrawID+"/approve", "approve_withdrawal", map[string]interface{}{"withdraw_id": withdrawID}, true)
+		admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/withdrawals/"+withdrawID+"/approve", "approve_withdrawal", map[string]interface{}{"withdraw_id": withdrawID, "previous_status": currentStatus, "amount": amount}, true)
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	}
 }
 
-// AdminRejectWithdrawal rejects a pending withdrawal and refunds the player
+// AdminRejectWithdrawal rejects a pending or held withdrawal and refunds the player
 func AdminRejectWithdrawal(db *sqlx.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		adminUsername := c.GetString("admin_username")
@@ -133,8 +175,8 @@ func AdminRejectWithdrawal(db *sqlx.DB) gin.HandlerFunc {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Withdrawal not found"})
 			return
 		}
-		if currentStatus != "PENDING" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Can only reject PENDING withdrawals"})
+		if currentStatus != WithdrawStatusPending && currentStatus != WithdrawStatusPendingReview {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Can only reject PENDING or PENDING_REVIEW withdrawals"})
 			return
 		}
 
@@ -182,16 +224,20 @@ func AdminRejectWithdrawal(db *sqlx.DB) gin.HandlerFunc {
 			// Non-critical
 		}
 
-		// Update withdrawal status
-		_, err = tx.Exec(`
+		// Update withdrawal status (guarded so a concurrent approval cannot be refunded twice)
+		res, err := tx.Exec(`
 			UPDATE withdraw_requests SET status = 'FAILED', processed_at = NOW(), note = $1
-			WHERE id = $2
-		`, "Rejected: "+req.Reason, withdrawID)
+			WHERE id = $2 AND status = $3
+		`, "Rejected: "+req.Reason, withdrawID, currentStatus)
 		if err != nil {
 			log.Printf("[ADMIN] Failed to update withdrawal status: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject withdrawal"})
 			return
 		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Withdrawal was already processed"})
+			return
+		}
 
 		if err := tx.Commit(); err != nil {
 			log.Printf("[ADMIN] Failed to commit rejection: %v", err)
@@ -204,6 +250,114 @@ func AdminRejectWithdrawal(db *sqlx.DB) gin.HandlerFunc {
 	}
 }
 
+// GetAdminPayoutHolds lists high-stake payouts held for disputes, disputed ones first
+// (?status=all|held|disputed|released|voided, default disputed)
+func GetAdminPayoutHolds(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.DefaultQuery("status", "disputed")
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "25"))
+		offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if limit > 200 {
+			limit = 200
+		}
+
+		type holdRow struct {
+			SessionID     int     `db:"session_id" json:"session_id"`
+			WinnerID      int     `db:"winner_id" json:"winner_id"`
+			WinnerName    *string `db:"winner_name" json:"winner_name"`
+			LoserID       int     `db:"loser_id" json:"loser_id"`
+			LoserName     *string `db:"loser_name" json:"loser_name"`
+			StakeAmount   float64 `db:"stake_amount" json:"stake_amount"`
+			Status        string  `db:"status" json:"status"`
+			ReleaseAt     string  `db:"release_at" json:"release_at"`
+			DisputeReason *string `db:"dispute_reason" json:"dispute_reason"`
+			DisputedAt    *string `db:"disputed_at" json:"disputed_at"`
+			ResolvedAt    *string `db:"resolved_at" json:"resolved_at"`
+			ResolvedBy    *string `db:"resolved_by" json:"resolved_by"`
+			TotalCount    int     `db:"total_count" json:"-"`
+		}
+
+		var rows []holdRow
+		err := db.Select(&rows, `
+			SELECT ph.session_id, ph.winner_id, w.display_name as winner_name,
+				ph.loser_id, l.display_name as loser_name,
+				ph.stake_amount, ph.status,
+				to_char(ph.release_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"') as release_at,
+				ph.dispute_reason,
+				to_char(ph.disputed_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"') as disputed_at,
+				to_char(ph.resolved_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"') as resolved_at,
+				ph.resolved_by,
+				COUNT(*) OVER() as total_count
+			FROM payout_holds ph
+			LEFT JOIN players w ON ph.winner_id = w.id
+			LEFT JOIN players l ON ph.loser_id = l.id
+			WHERE ($1 = 'all' OR ph.status = UPPER($1))
+			ORDER BY CASE ph.status WHEN 'DISPUTED' THEN 0 WHEN 'HELD' THEN 1 ELSE 2 END, ph.created_at DESC
+			LIMIT $2 OFFSET $3
+		`, status, limit, offset)
+		if err != nil {
+			log.Printf("[ADMIN] Failed to fetch payout holds: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch payout holds"})
+			return
+		}
+
+		total := 0
+		if len(rows) > 0 {
+			total = rows[0].TotalCount
+		}
+
+		c.JSON(http.StatusOK, gin.H{"payout_holds": rows, "total": total, "limit": limit, "offset": offset})
+	}
+}
+
+// AdminResolveDispute decides a disputed payout: "release" pays the winner, "void" refunds
+// both stakes. Body: {"decision": "release"|"void", "reason": "..."}
+func AdminResolveDispute(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminUsername := c.GetString("admin_username")
+		holdID := c.Param("id")
+
+		var req struct {
+			Decision string `json:"decision" binding:"required"`
+			Reason   string `json:"reason" binding:"required"`
+		}
+		if err := c.BindJSON(&req); err != nil || (req.Decision != "release" && req.Decision != "void") {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "decision (release or void) and reason are required"})
+			return
+		}
+		sessionID, err := strconv.Atoi(holdID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Disputed payout not found"})
+			return
+		}
+		if game.Manager == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Game manager not initialized"})
+			return
+		}
+
+		path := "/api/v1/admin/payout-holds/" + holdID + "/resolve"
+		details := map[string]interface{}{"session_id": sessionID, "decision": req.Decision, "reason": req.Reason}
+		err = game.Manager.ResolveDispute(sessionID, req.Decision == "release", adminUsername, req.Reason)
+		switch {
+		case errors.Is(err, game.ErrNoPayoutHold):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Disputed payout not found"})
+			return
+		case errors.Is(err, game.ErrSessionSettled):
+			admin.LogAdminAction(db, adminUsername, c.ClientIP(), path, "resolve_dispute", details, false)
+			c.JSON(http.StatusConflict, gin.H{"error": "Game already paid out or refunded"})
+			return
+		case err != nil:
+			log.Printf("[ADMIN] Failed to resolve dispute for session %d: %v", sessionID, err)
+			admin.LogAdminAction(db, adminUsername, c.ClientIP(), path, "resolve_dispute", details, false)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve dispute"})
+			return
+		}
+
+		admin.LogAdminAction(db, adminUsername, c.ClientIP(), path, "resolve_dispute", details, true)
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	}
+}
+
 // GetAdminRevenue returns revenue summary data
 func GetAdminRevenue(db *sqlx.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -284,3 +438,73 @@ func GetAdminRevenue(db *sqlx.DB) gin.HandlerFunc {
 		c.JSON(http.StatusOK, gin.H{"summary": summary, "balances": balances})
 	}
 }
+
+// AdminReconcileAccounts runs the ledger integrity check on demand. Discrepancies are
+// reported (with account and session ids) but never fixed automatically.
+func AdminReconcileAccounts(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminUsername := c.GetString("admin_username")
+
+		report, err := accounts.Reconcile(db)
+		if err != nil {
+			log.Printf("[ADMIN] Ledger reconciliation failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile ledger"})
+			return
+		}
+
+		admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/accounts/reconcile", "reconcile_accounts", map[string]interface{}{"balanced": report.Balanced, "discrepancies": len(report.Discrepancies)}, true)
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// AdminSweepRevenue records revenue moved out to the operator's bank: the platform (commission)
+// or tax account is debited and the ledger books the money as leaving the system, so balances
+// show what is still held and reconciliation keeps balancing. Super admins only.
+// POST /api/v1/admin/revenue/sweep {"account": "platform"|"tax", "amount": 500000, "reference": "bank slip"}
+func AdminSweepRevenue(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		adminUsername := c.GetString("admin_username")
+
+		var req struct {
+			Account   string  `json:"account" binding:"required"`
+			Amount    float64 `json:"amount" binding:"required"`
+			Reference string  `json:"reference" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || req.Amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account, a positive amount and a bank reference are required"})
+			return
+		}
+		if req.Account != accounts.AccountPlatform && req.Account != accounts.AccountTax {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "account must be platform or tax"})
+			return
+		}
+		details := map[string]interface{}{"account": req.Account, "amount": req.Amount, "reference": req.Reference}
+
+		tx, err := db.Beginx()
+		if err != nil {
+			log.Printf("[ADMIN] Failed to begin transaction: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record sweep"})
+			return
+		}
+		defer tx.Rollback()
+
+		txnID, err := accounts.SweepToBank(tx, req.Account, req.Amount, "Sweep to bank by "+adminUsername+": "+req.Reference)
+		if err == nil {
+			err = tx.Commit()
+		}
+		if err != nil {
+			admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/revenue/sweep", "sweep_revenue", details, false)
+			if errors.Is(err, accounts.ErrSweepExceedsBalance) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Sweep exceeds the account balance"})
+				return
+			}
+			log.Printf("[ADMIN] Revenue sweep failed: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record sweep"})
+			return
+		}
+
+		details["transaction_id"] = txnID
+		admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/revenue/sweep", "sweep_revenue", details, true)
+		c.JSON(http.StatusOK, gin.H{"ok": true, "transaction_id": txnID})
+	}
+}
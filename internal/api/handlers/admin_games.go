@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/playpool/backend/internal/admin"
+	"github.com/playpool/backend/internal/game"
 )
 
 // GetAdminGames returns a paginated list of games with filters
@@ -147,7 +153,9 @@ func GetAdminGameDetail(db *sqlx.DB) gin.HandlerFunc {
 	}
 }
 
-// AdminCancelGame cancels a stuck game and refunds escrow
+// AdminCancelGame force-cancels a stuck game: both stakes go back from escrow, the live game
+// (if any) is ended and the players get a session_cancelled event. Sessions that were already
+// paid out or refunded are refused, so calling it twice never refunds twice.
 func AdminCancelGame(db *sqlx.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		adminUsername := c.GetString("admin_username")
@@ -161,9 +169,15 @@ func AdminCancelGame(db *sqlx.DB) gin.HandlerFunc {
 			return
 		}
 
+		sessionID, err := strconv.Atoi(gameID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+			return
+		}
+
 		// Verify game exists and is cancellable
 		var currentStatus string
-		err := db.Get(&currentStatus, `SELECT status FROM game_sessions WHERE id = $1`, gameID)
+		err = db.Get(&currentStatus, `SELECT status FROM game_sessions WHERE id = $1`, sessionID)
 		if err != nil {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
 			return
@@ -174,107 +188,166 @@ func AdminCancelGame(db *sqlx.DB) gin.HandlerFunc {
 			return
 		}
 
-		// Check if already refunded (idempotency)
-		var refundCount int
-		_ = db.Get(&refundCount, `SELECT COUNT(*) FROM escrow_ledger WHERE session_id = $1 AND entry_type = 'SESSION_CANCEL'`, gameID)
-		if refundCount > 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Game already cancelled/refunded"})
+		if game.Manager == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Game manager not initialized"})
+			return
+		}
+		err = game.Manager.ForceCancelSession(sessionID, req.Reason)
+		if errors.Is(err, game.ErrSessionSettled) {
+			admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/games/"+gameID+"/cancel", "cancel_game", map[string]interface{}{"game_id": gameID, "reason": req.Reason, "error": err.Error()}, false)
+			c.JSON(http.StatusConflict, gin.H{"error": "Game already paid out or refunded"})
 			return
 		}
-
-		// Use a transaction for atomicity
-		tx, err := db.Beginx()
 		if err != nil {
-			log.Printf("[ADMIN] Failed to begin transaction: %v", err)
+			log.Printf("[ADMIN] Failed to cancel game %d: %v", sessionID, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel game"})
 			return
 		}
-		defer tx.Rollback()
 
-		// Get game session details for refund
-		var sessionID int
-		var player1ID, player2ID *int
-		var stakeAmount float64
-		err = tx.QueryRowx(`
-			SELECT id, player1_id, player2_id, stake_amount FROM game_sessions WHERE id = $1 FOR UPDATE
-		`, gameID).Scan(&sessionID, &player1ID, &player2ID, &stakeAmount)
+		admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/games/"+gameID+"/cancel", "cancel_game", map[string]interface{}{"game_id": gameID, "reason": req.Reason}, true)
+		c.JSON(http.StatusOK, gin.H{"ok": true})
+	}
+}
+
+// GetAdminGameActionLog dumps the in-memory action log of a live game, looked up by
+// game id, token or session id. Logging is opt-in via GAME_ACTION_LOG_SIZE.
+func GetAdminGameActionLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ref := c.Param("id")
+		if game.Manager == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Game manager not initialized"})
+			return
+		}
+		g, err := game.Manager.FindGame(ref)
 		if err != nil {
-			log.Printf("[ADMIN] Failed to lock game session: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel game"})
+			c.JSON(http.StatusNotFound, gin.H{"error": "Game not in memory on this server"})
 			return
 		}
 
-		// Refund each player's stake from escrow
-		players := []*int{player1ID, player2ID}
-		for _, pid := range players {
-			if pid == nil {
-				continue
-			}
+		actions := g.ActionLog()
+		if actions == nil {
+			actions = []game.ActionLogEntry{}
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"game_id":    g.ID,
+			"game_token": g.Token,
+			"session_id": g.SessionID,
+			"enabled":    game.Manager.GetConfig().GameActionLogSize > 0,
+			"actions":    actions,
+		})
+	}
+}
 
-			// Credit player's winnings account
-			_, err = tx.Exec(`
-				UPDATE accounts SET balance = balance + $1, updated_at = NOW()
-				WHERE account_type = 'player_winnings' AND owner_player_id = $2
-			`, stakeAmount, *pid)
-			if err != nil {
-				log.Printf("[ADMIN] Failed to refund player %d: %v", *pid, err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refund player"})
+// GetAdminGameFullState returns the complete server-side state of a game (by game id, token
+// or session id) for dispute review: the live in-memory state when this server holds the game,
+// else the last state persisted to game_states. Never exposed on player routes.
+func GetAdminGameFullState(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ref := c.Param("id")
+		if game.Manager != nil {
+			if g, err := game.Manager.FindGame(ref); err == nil {
+				state, err := g.FullStateJSON()
+				if err != nil {
+					log.Printf("[ADMIN] Failed to marshal state of game %s: %v", g.ID, err)
+					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read game state"})
+					return
+				}
+				c.JSON(http.StatusOK, gin.H{"source": "live", "session_id": g.SessionID, "state": json.RawMessage(state)})
 				return
 			}
+		}
 
-			// Debit escrow
-			_, err = tx.Exec(`
-				UPDATE accounts SET balance = balance - $1, updated_at = NOW()
-				WHERE account_type = 'escrow'
-			`, stakeAmount)
-			if err != nil {
-				log.Printf("[ADMIN] Failed to debit escrow: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process refund"})
-				return
+		var saved struct {
+			SessionID int       `db:"session_id"`
+			State     string    `db:"game_state"`
+			SavedAt   time.Time `db:"created_at"`
+		}
+		query := `SELECT st.session_id, st.game_state, st.created_at FROM game_states st
+			JOIN game_sessions gs ON gs.id = st.session_id
+			WHERE %s ORDER BY st.created_at DESC, st.id DESC LIMIT 1`
+		var err error
+		if id, convErr := strconv.Atoi(ref); convErr == nil {
+			err = db.Get(&saved, fmt.Sprintf(query, "gs.id = $1"), id)
+		} else {
+			err = db.Get(&saved, fmt.Sprintf(query, "gs.game_token = $1"), ref)
+		}
+		if err != nil {
+			if err != sql.ErrNoRows {
+				log.Printf("[ADMIN] Failed to load saved state of game %s: %v", ref, err)
 			}
+			c.JSON(http.StatusNotFound, gin.H{"error": "No state for this game"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"source": "persisted", "session_id": saved.SessionID, "saved_at": saved.SavedAt, "state": json.RawMessage(saved.State)})
+	}
+}
 
-			// Record escrow ledger entry
-			_, err = tx.Exec(`
-				INSERT INTO escrow_ledger (session_id, entry_type, player_id, amount, balance_after, description, created_at)
-				VALUES ($1, 'SESSION_CANCEL', $2, $3, 0, $4, NOW())
-			`, sessionID, *pid, stakeAmount, "Admin cancelled: "+req.Reason)
-			if err != nil {
-				log.Printf("[ADMIN] Failed to create escrow ledger entry: %v", err)
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record refund"})
-				return
-			}
+// GetAdminGameChat returns the logged chat of a game (by session id or game token) for moderation,
+// with the reports (disputes) filed against the session. Only logged when GAME_CHAT_LOGGING is on.
+func GetAdminGameChat(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ref := c.Param("id")
 
-			// Record account transaction
-			_, err = tx.Exec(`
-				INSERT INTO account_transactions (debit_account_id, credit_account_id, amount, reference_type, reference_id, description)
-				SELECT
-					(SELECT id FROM accounts WHERE account_type = 'escrow' LIMIT 1),
-					(SELECT id FROM accounts WHERE account_type = 'player_winnings' AND owner_player_id = $1),
-					$2, 'game_session', $3, $4
-			`, *pid, stakeAmount, sessionID, "Admin cancel refund: "+req.Reason)
-			if err != nil {
-				log.Printf("[ADMIN] Failed to record account transaction: %v", err)
-				// Non-critical, continue
-			}
+		var session struct {
+			ID        int    `db:"id"`
+			GameToken string `db:"game_token"`
+		}
+		var err error
+		if id, convErr := strconv.Atoi(ref); convErr == nil {
+			err = db.Get(&session, `SELECT id, game_token FROM game_sessions WHERE id = $1`, id)
+		} else {
+			err = db.Get(&session, `SELECT id, game_token FROM game_sessions WHERE game_token = $1`, ref)
 		}
-
-		// Update game status
-		_, err = tx.Exec(`
-			UPDATE game_sessions SET status = 'CANCELLED', completed_at = NOW() WHERE id = $1
-		`, gameID)
 		if err != nil {
-			log.Printf("[ADMIN] Failed to update game status: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update game status"})
+			c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
 			return
 		}
 
-		if err := tx.Commit(); err != nil {
-			log.Printf("[ADMIN] Failed to commit cancel transaction: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel game"})
+		type chatRow struct {
+			ID         int     `db:"id" json:"id"`
+			PlayerID   *int    `db:"player_id" json:"player_id"`
+			PlayerName *string `db:"player_name" json:"player_name"`
+			Message    string  `db:"message" json:"message"`
+			CreatedAt  string  `db:"created_at" json:"created_at"`
+		}
+		messages := []chatRow{}
+		if err := db.Select(&messages, `
+			SELECT gc.id, gc.player_id, p.display_name as player_name, gc.message,
+				to_char(gc.created_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"') as created_at
+			FROM game_chat_messages gc
+			LEFT JOIN players p ON gc.player_id = p.id
+			WHERE gc.session_id = $1
+			ORDER BY gc.created_at ASC, gc.id ASC
+		`, session.ID); err != nil {
+			log.Printf("[ADMIN] Failed to load chat for session %d: %v", session.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load chat"})
 			return
 		}
 
-		admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/games/"+gameID+"/cancel", "cancel_game", map[string]interface{}{"game_id": gameID, "reason": req.Reason}, true)
-		c.JSON(http.StatusOK, gin.H{"ok": true})
+		type reportRow struct {
+			ID          int     `db:"id" json:"id"`
+			ReportedBy  *int    `db:"reported_by" json:"reported_by"`
+			DisputeType *string `db:"dispute_type" json:"dispute_type"`
+			Description *string `db:"description" json:"description"`
+			Status      *string `db:"status" json:"status"`
+			CreatedAt   string  `db:"created_at" json:"created_at"`
+		}
+		reports := []reportRow{}
+		if err := db.Select(&reports, `
+			SELECT id, reported_by, dispute_type, description, status,
+				to_char(created_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"') as created_at
+			FROM disputes WHERE session_id = $1 ORDER BY created_at ASC
+		`, session.ID); err != nil {
+			log.Printf("[ADMIN] Failed to load reports for session %d: %v", session.ID, err)
+		}
+
+		admin.LogAdminAction(db, c.GetString("admin_username"), c.ClientIP(), c.FullPath(), "view_game_chat", map[string]interface{}{"session_id": session.ID}, true)
+
+		c.JSON(http.StatusOK, gin.H{
+			"session_id": session.ID,
+			"game_token": session.GameToken,
+			"messages":   messages,
+			"reports":    reports,
+		})
 	}
 }
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
+)
+
+func TestAdminGameActionLogDump(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	prev := game.Manager
+	game.Manager = game.NewGameManager(nil, nil, &config.Config{GameActionLogSize: 10})
+	t.Cleanup(func() { game.Manager = prev })
+
+	g, err := game.Manager.CreateTestPoolGame("256700000001", "256700000002", 1000, false)
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+	shooter := g.CurrentTurn
+	stale := g.MoveSeq + 5
+	g.BeginShot(shooter, game.ShotParams{Angle: 0, Power: 500}, &stale)
+	g.PlaceCueBall(shooter, 0, 0)
+	if err := g.BeginShot(shooter, game.ShotParams{Angle: 0, Power: 500}, nil); err != nil {
+		t.Fatalf("BeginShot: %v", err)
+	}
+
+	r := gin.New()
+	r.GET("/games/:id/actions", GetAdminGameActionLog())
+
+	for _, ref := range []string{g.ID, g.Token} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/games/"+ref+"/actions", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", ref, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Enabled bool                  `json:"enabled"`
+			Actions []game.ActionLogEntry `json:"actions"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if !resp.Enabled || len(resp.Actions) != 3 {
+			t.Fatalf("%s: unexpected dump %s", ref, w.Body.String())
+		}
+		wantActions := []string{"take_shot", "place_cue_ball", "take_shot"}
+		for i, e := range resp.Actions {
+			if e.Action != wantActions[i] || e.Seq != i+1 {
+				t.Errorf("%s: action %d = %s (seq %d), want %s", ref, i, e.Action, e.Seq, wantActions[i])
+			}
+		}
+		if resp.Actions[0].Error != game.ErrStaleMove.Error() || resp.Actions[2].Error != "" {
+			t.Errorf("%s: rejection reasons wrong: %+v", ref, resp.Actions)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/games/missing/actions", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown game: status %d, want 404", w.Code)
+	}
+}
+
+// stakedSession inserts two players and a session in status with both 1000 stakes in escrow
+func stakedSession(t *testing.T, db *sqlx.DB, status string) (sessionID, p1, p2 int) {
+	t.Helper()
+	ids := make([]int, 2)
+	for i := range ids {
+		phone := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+int64(i))%100000000)
+		if err := db.Get(&ids[i], `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+			t.Fatalf("insert player: %v", err)
+		}
+	}
+	escrow, err := accounts.GetOrCreateAccount(db, accounts.AccountEscrow, nil)
+	if err != nil {
+		t.Fatalf("escrow account: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE accounts SET balance = balance + 2000 WHERE id=$1`, escrow.ID); err != nil {
+		t.Fatalf("fund escrow: %v", err)
+	}
+	token := fmt.Sprintf("cancel-%d", time.Now().UnixNano())
+	if err := db.Get(&sessionID, `INSERT INTO game_sessions (game_token, player1_id, player2_id, stake_amount, status, expiry_time) VALUES ($1,$2,$3,1000,$4,NOW() + INTERVAL '3 minutes') RETURNING id`, token, ids[0], ids[1], status); err != nil {
+		t.Fatalf("insert session: %v", err)
+	}
+	return sessionID, ids[0], ids[1]
+}
+
+func adminCancelRouter(t *testing.T, db *sqlx.DB) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	prev := game.Manager
+	game.Manager = game.NewGameManager(db, nil, &config.Config{})
+	t.Cleanup(func() { game.Manager = prev })
+
+	r := gin.New()
+	r.POST("/admin/games/:id/cancel", func(c *gin.Context) { c.Set("admin_username", "ops") }, AdminCancelGame(db))
+	return r
+}
+
+func TestAdminCancelRefundsWaitingGame(t *testing.T) {
+	db := testDB(t)
+	r := adminCancelRouter(t, db)
+	sessionID, p1, p2 := stakedSession(t, db, "WAITING")
+
+	path := fmt.Sprintf("/admin/games/%d/cancel", sessionID)
+	if w := postJSON(r, path, gin.H{"reason": "both players stuck"}); w.Code != http.StatusOK {
+		t.Fatalf("cancel: status %d: %s", w.Code, w.Body.String())
+	}
+	for _, pid := range []int{p1, p2} {
+		if got := winningsBalance(t, db, pid); got != 1000 {
+			t.Errorf("player %d refunded %.2f, want 1000", pid, got)
+		}
+	}
+	var status string
+	if err := db.Get(&status, `SELECT status FROM game_sessions WHERE id=$1`, sessionID); err != nil || status != "CANCELLED" {
+		t.Errorf("session status %q (%v), want CANCELLED", status, err)
+	}
+
+	// A second call must not refund again
+	if w := postJSON(r, path, gin.H{"reason": "retry"}); w.Code == http.StatusOK {
+		t.Errorf("second cancel succeeded")
+	}
+	if got := winningsBalance(t, db, p1); got != 1000 {
+		t.Errorf("after second cancel player refunded %.2f, want 1000", got)
+	}
+}
+
+func TestAdminCancelRefusesPaidOutGame(t *testing.T) {
+	db := testDB(t)
+	r := adminCancelRouter(t, db)
+	// Paid out, but the session row never left IN_PROGRESS
+	sessionID, p1, p2 := stakedSession(t, db, "IN_PROGRESS")
+	if _, err := db.Exec(`INSERT INTO escrow_ledger (session_id, entry_type, player_id, amount, balance_after, description, created_at) VALUES ($1,'PAYOUT',$2,1800,0,'Winner payout',NOW())`, sessionID, p1); err != nil {
+		t.Fatalf("insert payout: %v", err)
+	}
+
+	w := postJSON(r, fmt.Sprintf("/admin/games/%d/cancel", sessionID), gin.H{"reason": "looks stuck"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("cancel paid-out game: status %d, want 409: %s", w.Code, w.Body.String())
+	}
+	for _, pid := range []int{p1, p2} {
+		if got := winningsBalance(t, db, pid); got != 0 {
+			t.Errorf("player %d refunded %.2f after a payout", pid, got)
+		}
+	}
+}
+
+func TestAdminGameFullStateIsAdminOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	prev := game.Manager
+	game.Manager = game.NewGameManager(nil, nil, &config.Config{})
+	t.Cleanup(func() { game.Manager = prev })
+
+	g, err := game.Manager.CreateTestPoolGame("256700000001", "256700000002", 1000, false)
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+
+	r := gin.New()
+	// As mounted in routes.go: behind the admin session check
+	r.GET("/admin/games/:id/full-state", AdminSessionMiddleware(nil, nil), GetAdminGameFullState(nil))
+	r.GET("/as-admin/games/:id/full-state", func(c *gin.Context) { c.Set("admin_username", "ops") }, GetAdminGameFullState(nil))
+
+	// A participant presenting their player token (and a player JWT) is turned away
+	req := httptest.NewRequest(http.MethodGet, "/admin/games/"+g.Token+"/full-state?pt="+g.Player1.PlayerToken, nil)
+	req.Header.Set("Authorization", "Bearer player-jwt")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("participant: status %d, want 401", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/as-admin/games/"+g.ID+"/full-state", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("admin: status %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Source string `json:"source"`
+		State  struct {
+			Balls   []game.BallState `json:"balls"`
+			Player1 map[string]interface{}
+		} `json:"state"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Source != "live" || len(resp.State.Balls) != game.NumBalls {
+		t.Fatalf("admin view: %s", w.Body.String())
+	}
+	if _, leaked := resp.State.Player1["player_token"]; leaked {
+		t.Fatal("full state includes the player's token")
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
+)
+
+// AdminSimulateMatchmaking replays synthetic arrivals against an in-memory matchmaking queue
+// and reports the expected wait, match rate and expiry rate per stake, to help choose stake
+// tiers and expiry windows. It reads and writes no production data.
+// POST /api/v1/admin/matchmaking/simulate
+// {"arrivals_per_minute": 30, "stakes": [{"amount": 1000, "weight": 3}], "duration_minutes": 60}
+func AdminSimulateMatchmaking(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req game.SimParams
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		if req.ExpiryMinutes == 0 {
+			req.ExpiryMinutes = cfg.QueueExpiryMinutes
+		}
+
+		report, err := game.SimulateMatchmaking(req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	}
+}
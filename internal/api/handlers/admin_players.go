@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"database/sql"
 	"log"
 	"net/http"
 	"strconv"
@@ -9,6 +10,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/playpool/backend/internal/admin"
+	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
 )
 
 // GetAdminPlayers returns a paginated list of players with search
@@ -191,10 +194,15 @@ func AdminBlockPlayer(db *sqlx.DB) gin.HandlerFunc {
 			blockUntil = &t
 		}
 
-		_, err := db.Exec(`
+		var phone string
+		err := db.Get(&phone, `
 			UPDATE players SET is_blocked = true, block_reason = $1, block_until = $2
-			WHERE id = $3
+			WHERE id = $3 RETURNING phone_number
 		`, req.Reason, blockUntil, playerID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Player not found"})
+			return
+		}
 		if err != nil {
 			log.Printf("[ADMIN] Failed to block player %s: %v", playerID, err)
 			admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/players/"+playerID+"/block", "block_player", map[string]interface{}{"player_id": playerID, "reason": req.Reason}, false)
@@ -202,8 +210,20 @@ func AdminBlockPlayer(db *sqlx.DB) gin.HandlerFunc {
 			return
 		}
 
-		admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/players/"+playerID+"/block", "block_player", map[string]interface{}{"player_id": playerID, "reason": req.Reason, "duration_hours": req.DurationHours}, true)
-		c.JSON(http.StatusOK, gin.H{"ok": true})
+		// A blocked player can't wait in the queue either: refund anything they have queued
+		cancelled := 0
+		if pid, err := strconv.Atoi(playerID); err == nil {
+			cancelled = cancelQueuesOfBlockedPlayer(db, pid)
+		}
+		if blockUntil != nil {
+			sms.EnqueueTemplate(sms.TypeAccount, phone, templates.AccountBlockedUntil,
+				templates.Params{"Reason": req.Reason, "Until": blockUntil.Format("02 Jan 2006 15:04")})
+		} else {
+			sms.EnqueueTemplate(sms.TypeAccount, phone, templates.AccountBlocked, templates.Params{"Reason": req.Reason})
+		}
+
+		admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/players/"+playerID+"/block", "block_player", map[string]interface{}{"player_id": playerID, "reason": req.Reason, "duration_hours": req.DurationHours, "queues_cancelled": cancelled}, true)
+		c.JSON(http.StatusOK, gin.H{"ok": true, "queues_cancelled": cancelled})
 	}
 }
 
@@ -213,10 +233,15 @@ func AdminUnblockPlayer(db *sqlx.DB) gin.HandlerFunc {
 		adminUsername := c.GetString("admin_username")
 		playerID := c.Param("id")
 
-		_, err := db.Exec(`
+		var phone string
+		err := db.Get(&phone, `
 			UPDATE players SET is_blocked = false, block_reason = NULL, block_until = NULL
-			WHERE id = $1
+			WHERE id = $1 RETURNING phone_number
 		`, playerID)
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Player not found"})
+			return
+		}
 		if err != nil {
 			log.Printf("[ADMIN] Failed to unblock player %s: %v", playerID, err)
 			admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/players/"+playerID+"/unblock", "unblock_player", map[string]interface{}{"player_id": playerID}, false)
@@ -224,6 +249,8 @@ func AdminUnblockPlayer(db *sqlx.DB) gin.HandlerFunc {
 			return
 		}
 
+		sms.EnqueueTemplate(sms.TypeAccount, phone, templates.AccountUnblocked, nil)
+
 		admin.LogAdminAction(db, adminUsername, c.ClientIP(), "/api/v1/admin/players/"+playerID+"/unblock", "unblock_player", map[string]interface{}{"player_id": playerID}, true)
 		c.JSON(http.StatusOK, gin.H{"ok": true})
 	}
@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/ws"
+)
+
+// GetAdminWSStats reports this instance's live WebSocket connections: connected players,
+// spectators and the per-room counts, busiest room first.
+// GET /api/v1/admin/ws/stats
+func GetAdminWSStats() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rooms := []ws.RoomStats{}
+		connected, spectators := 0, 0
+		if ws.GameHub != nil {
+			connected = ws.GameHub.ConnectedCount()
+			rooms = ws.GameHub.Rooms()
+		}
+		for _, r := range rooms {
+			spectators += r.Spectators
+		}
+		sort.Slice(rooms, func(i, j int) bool {
+			if a, b := rooms[i].Players+rooms[i].Spectators, rooms[j].Players+rooms[j].Spectators; a != b {
+				return a > b
+			}
+			return rooms[i].GameID < rooms[j].GameID
+		})
+
+		c.JSON(http.StatusOK, gin.H{
+			"connected_players": connected,
+			"spectators":        spectators,
+			"rooms":             rooms,
+		})
+	}
+}
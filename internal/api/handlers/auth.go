@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/rand"
 	"crypto/sha256"
-	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -12,6 +11,7 @@ import (
 	"math/big"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,7 +22,10 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/playpool/backend/internal/accounts"
 	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
+	"github.com/playpool/backend/internal/payment"
 	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -54,26 +57,27 @@ func RequestOTP(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerF
 			}
 		}
 
-		// generate 4-digit OTP
-		n, err := rand.Int(rand.Reader, bigInt(10000))
+		// generate OTP (OTP_LENGTH digits, 4 by default)
+		code, err := generateOTP(cfg)
 		if err != nil {
 			log.Printf("Failed to generate OTP: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
 			return
 		}
-		code := fmt.Sprintf("%04d", n.Int64())
 
 		// hash and store in Redis
 		h := sha256.Sum256([]byte(code))
 		hash := hex.EncodeToString(h[:])
 		if rdb != nil {
 			rdb.Set(ctx, fmt.Sprintf("otp:%s", phone), hash, time.Duration(cfg.OTPTokenTTLSeconds)*time.Second)
+			// A fresh code starts a fresh set of attempts
+			clearOTPFailures(ctx, rdb, phone)
 		}
 
 		// send SMS via DMark
-		msg := fmt.Sprintf("Your PlayPool OTP is %s. It expires in %d minutes.", code, cfg.OTPTokenTTLSeconds/60)
 		if sms.Default != nil {
-			if _, err := sms.SendSMS(ctx, phone, msg); err != nil {
+			params := templates.Params{"Code": code, "Minutes": cfg.OTPTokenTTLSeconds / 60}
+			if _, err := sms.NotifyTemplate(ctx, sms.TypeOTP, phone, templates.OTP, params); err != nil {
 				log.Printf("Failed to send OTP SMS to %s: %v", phone, err)
 				// We still return success for best-effort but log the error
 			}
@@ -101,27 +105,16 @@ func VerifyOTP(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFu
 			return
 		}
 
-		ctx := context.Background()
-		val, err := rdb.Get(ctx, fmt.Sprintf("otp:%s", phone)).Result()
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired code"})
+		if !verifyOTPCode(c, rdb, cfg, phone, code) {
 			return
 		}
-		h := sha256.Sum256([]byte(code))
-		if subtle.ConstantTimeCompare([]byte(val), []byte(hex.EncodeToString(h[:]))) != 1 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired code"})
-			return
-		}
-
-		// Delete OTP after successful verify
-		rdb.Del(ctx, fmt.Sprintf("otp:%s", phone))
 
 		// Ensure player exists
 		var player struct {
 			ID          int    `db:"id"`
 			DisplayName string `db:"display_name"`
 		}
-		err = db.Get(&player, `SELECT id, display_name FROM players WHERE phone_number=$1`, phone)
+		err := db.Get(&player, `SELECT id, display_name FROM players WHERE phone_number=$1`, phone)
 		if err != nil {
 			// create player
 			if _, err2 := db.Exec(`INSERT INTO players (phone_number, display_name, created_at, is_active) VALUES ($1, $2, NOW(), true)`, phone, ""); err2 != nil {
@@ -138,11 +131,7 @@ func VerifyOTP(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFu
 		}
 
 		// Issue JWT
-		exp := time.Now().Add(24 * time.Hour)
-		claims := jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(exp)}
-		custom := jwt.MapClaims{"player_id": player.ID, "phone": phone, "exp": claims.ExpiresAt.Unix()}
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, custom)
-		signed, err := token.SignedString([]byte(cfg.JWTSecret))
+		signed, err := issuePlayerJWT(cfg, player.ID, phone)
 		if err != nil {
 			log.Printf("Failed to sign token: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
@@ -153,6 +142,15 @@ func VerifyOTP(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFu
 	}
 }
 
+// issuePlayerJWT signs the 24h player JWT returned by VerifyOTP and PINLogin
+func issuePlayerJWT(cfg *config.Config, playerID int, phone string) (string, error) {
+	exp := time.Now().Add(24 * time.Hour)
+	claims := jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(exp)}
+	custom := jwt.MapClaims{"player_id": playerID, "phone": phone, "exp": claims.ExpiresAt.Unix()}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, custom)
+	return token.SignedString([]byte(cfg.JWTSecret))
+}
+
 // VerifyOTPAction validates the OTP and issues a short-lived action token instead of JWT
 func VerifyOTPAction(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -183,26 +181,16 @@ func VerifyOTPAction(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Han
 
 		ctx := context.Background()
 
-		// Verify OTP using same logic as VerifyOTP
-		val, err := rdb.Get(ctx, fmt.Sprintf("otp:%s", phone)).Result()
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired code"})
+		// Verify OTP using same logic as VerifyOTP (shared attempt lockout)
+		if !verifyOTPCode(c, rdb, cfg, phone, code) {
 			return
 		}
-		h := sha256.Sum256([]byte(code))
-		if subtle.ConstantTimeCompare([]byte(val), []byte(hex.EncodeToString(h[:]))) != 1 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired code"})
-			return
-		}
-
-		// Delete OTP after successful verify (single-use)
-		rdb.Del(ctx, fmt.Sprintf("otp:%s", phone))
 
 		// Ensure player exists
 		var player struct {
 			ID int `db:"id"`
 		}
-		err = db.Get(&player, `SELECT id FROM players WHERE phone_number=$1`, phone)
+		err := db.Get(&player, `SELECT id FROM players WHERE phone_number=$1`, phone)
 		if err != nil {
 			// Create player if not exists
 			if _, err2 := db.Exec(`INSERT INTO players (phone_number, display_name, created_at, is_active) VALUES ($1, $2, NOW(), true)`, phone, ""); err2 != nil {
@@ -334,6 +322,10 @@ func AuthMiddleware(cfg *config.Config, rdb *redis.Client) gin.HandlerFunc {
 
 			if tokenData.PlayerID > 0 {
 				c.Set("player_id", tokenData.PlayerID)
+				// Handlers that need a specific OTP-verified action (e.g. SetMyPIN) check these
+				c.Set("auth_action", tokenData.Action)
+				c.Set("auth_method", tokenData.AuthMethod)
+				c.Set("action_token_key", fmt.Sprintf("action_token:%s", tokenHashStr))
 				c.Next()
 				return
 			}
@@ -359,8 +351,9 @@ func GetMe(db *sqlx.DB) gin.HandlerFunc {
 			ID          int    `db:"id" json:"id"`
 			PhoneNumber string `db:"phone_number" json:"phone_number"`
 			DisplayName string `db:"display_name" json:"display_name"`
+			Language    string `db:"language" json:"language"`
 		}
-		if err := db.Get(&player, `SELECT id, phone_number, display_name FROM players WHERE id=$1`, pid); err != nil {
+		if err := db.Get(&player, `SELECT id, phone_number, display_name, language FROM players WHERE id=$1`, pid); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "player not found"})
 			return
 		}
@@ -371,13 +364,15 @@ func GetMe(db *sqlx.DB) gin.HandlerFunc {
 			TotalGamesWon    int     `db:"total_games_won"`
 			TotalGamesDrawn  int     `db:"total_games_drawn"`
 			TotalWinnings    float64 `db:"total_winnings"`
+			EloRating        int     `db:"elo_rating"`
 		}
-		if err := db.Get(&stats, `SELECT total_games_played, total_games_won, total_games_drawn, total_winnings FROM players WHERE id=$1`, pid); err != nil {
+		if err := db.Get(&stats, `SELECT total_games_played, total_games_won, total_games_drawn, total_winnings, elo_rating FROM players WHERE id=$1`, pid); err != nil {
 			// fallback to zeros if needed
 			stats.TotalGamesPlayed = 0
 			stats.TotalGamesWon = 0
 			stats.TotalGamesDrawn = 0
 			stats.TotalWinnings = 0
+			stats.EloRating = game.DefaultEloRating
 		}
 
 		// Get player winnings account balance
@@ -386,19 +381,37 @@ func GetMe(db *sqlx.DB) gin.HandlerFunc {
 			winningsBalance = acc.Balance
 		}
 
+		// Active self-exclusion (nil when none)
+		excludedUntil, err := selfExcludedUntil(db, player.ID, time.Now())
+		if err != nil {
+			log.Printf("[DB] Failed to read self-exclusion for player %d: %v", player.ID, err)
+		}
+
 		profile := gin.H{
-			"display_name":       player.DisplayName,
-			"phone":              player.PhoneNumber,
-			"player_winnings":    winningsBalance,
-			"total_games_played": stats.TotalGamesPlayed,
-			"total_games_won":    stats.TotalGamesWon,
-			"total_games_drawn":  stats.TotalGamesDrawn,
-			"total_winnings":     stats.TotalWinnings,
+			"display_name":        player.DisplayName,
+			"phone":               player.PhoneNumber,
+			"player_winnings":     winningsBalance,
+			"total_games_played":  stats.TotalGamesPlayed,
+			"total_games_won":     stats.TotalGamesWon,
+			"total_games_drawn":   stats.TotalGamesDrawn,
+			"total_winnings":      stats.TotalWinnings,
+			"elo_rating":          stats.EloRating,
+			"self_excluded_until": excludedUntil,
+			"language":            player.Language,
 		}
 		c.JSON(http.StatusOK, profile)
 	}
 }
 
+// Withdraw request statuses
+const (
+	WithdrawStatusPendingReview = "PENDING_REVIEW"
+	WithdrawStatusPending       = "PENDING"
+	WithdrawStatusProcessing    = "PROCESSING"
+	WithdrawStatusCompleted     = "COMPLETED"
+	WithdrawStatusFailed        = "FAILED"
+)
+
 // POST /api/v1/me/withdraw
 func RequestWithdraw(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -431,6 +444,23 @@ func RequestWithdraw(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
+		// Frequency limits, independent of the amount-based review
+		reason, wait, err := withdrawThrottle(db, cfg, pid, time.Now())
+		if err != nil {
+			log.Printf("[DB] Failed to check withdraw limits for player %d: %v", pid, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check withdraw limits"})
+			return
+		}
+		if reason != "" {
+			resp := gin.H{"error": reason}
+			if wait > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(wait.Seconds())))
+				resp["retry_after_seconds"] = int(wait.Seconds())
+			}
+			c.JSON(http.StatusTooManyRequests, resp)
+			return
+		}
+
 		// Read player's winnings account balance
 		wAcc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
 		if err != nil {
@@ -460,15 +490,16 @@ func RequestWithdraw(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 		}
 
 		// Transfer player_winnings -> settlement (reserve full amount)
-		if err := accounts.Transfer(tx, wAcc.ID, sett.ID, req.Amount, "WITHDRAW_REQUEST", sql.NullInt64{Int64: 0, Valid: false}, "Withdraw request reserve"); err != nil {
+		if err := accounts.Transfer(tx, wAcc.ID, sett.ID, req.Amount, "WITHDRAW_REQUEST", sql.NullInt64{Int64: 0, Valid: false}, "Withdraw request reserve", cfg.SettlementOverdraftGuard); err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reserve funds"})
 			return
 		}
 
-		// Insert withdraw_request
+		// Insert withdraw_request (large amounts wait for admin review before payout)
+		status := withdrawInitialStatus(cfg, req.Amount)
 		var reqID int
-		if err := tx.QueryRowx(`INSERT INTO withdraw_requests (player_id, amount, fee, net_amount, method, destination, status, created_at) VALUES ($1,$2,$3,$4,$5,$6,'PENDING',NOW()) RETURNING id`, pid, req.Amount, 0, req.Amount, req.Method, req.Destination).Scan(&reqID); err != nil {
+		if err := tx.QueryRowx(`INSERT INTO withdraw_requests (player_id, amount, fee, net_amount, method, destination, status, created_at) VALUES ($1,$2,$3,$4,$5,$6,$7,NOW()) RETURNING id`, pid, req.Amount, 0, req.Amount, req.Method, req.Destination, status).Scan(&reqID); err != nil {
 			tx.Rollback()
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create withdraw request"})
 			return
@@ -479,15 +510,97 @@ func RequestWithdraw(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		// If MOCK_MODE, process immediately (synchronously) with simulated transfers
-		if cfg.MockMode {
-			go func(reqID int, amount float64) {
-				processWithdrawMock(db, cfg, reqID, pid, amount)
-			}(reqID, req.Amount)
+		if status == WithdrawStatusPending {
+			startWithdrawPayout(db, cfg, reqID, pid, req.Amount)
+		} else {
+			log.Printf("[WITHDRAW] Request %d amount=%.2f queued for admin review (limit %d)", reqID, req.Amount, cfg.WithdrawAutoApproveLimit)
 		}
 
-		c.JSON(http.StatusOK, gin.H{"request_id": reqID, "amount": req.Amount})
+		c.JSON(http.StatusOK, gin.H{"request_id": reqID, "amount": req.Amount, "status": status})
+	}
+}
+
+// withdrawInitialStatus decides whether a new withdraw request is paid out straight away
+// or has to wait for an admin. A limit of 0 disables review.
+func withdrawInitialStatus(cfg *config.Config, amount float64) string {
+	if cfg.WithdrawAutoApproveLimit > 0 && amount > float64(cfg.WithdrawAutoApproveLimit) {
+		return WithdrawStatusPendingReview
 	}
+	return WithdrawStatusPending
+}
+
+// startWithdrawPayout runs the payout for an approved request.
+// In MOCK_MODE it is processed immediately with simulated transfers; otherwise it is sent
+// to DMarkPay. Without a payment client the request stays PENDING for manual settlement.
+func startWithdrawPayout(db *sqlx.DB, cfg *config.Config, reqID, pid int, amount float64) {
+	if cfg.MockMode {
+		go func(reqID int, amount float64) {
+			processWithdrawMock(db, cfg, reqID, pid, amount)
+		}(reqID, amount)
+		return
+	}
+	if payment.Default != nil {
+		go processWithdrawPayout(db, cfg, reqID, amount)
+	}
+}
+
+// processWithdrawPayout initiates the DMarkPay disbursement and moves the request to PROCESSING.
+// Funds stay in settlement until the payout webhook (or status checker) confirms the outcome.
+func processWithdrawPayout(db *sqlx.DB, cfg *config.Config, reqID int, amount float64) {
+	var destination string
+	if err := db.Get(&destination, `SELECT destination FROM withdraw_requests WHERE id=$1`, reqID); err != nil {
+		log.Printf("[WITHDRAW] Failed to load withdraw %d: %v", reqID, err)
+		return
+	}
+
+	// Record our transaction id before calling the provider so a webhook or the status
+	// checker can always match the payout, even if we crash mid-call
+	txnID := fmt.Sprintf("%d", payment.GenerateTransactionID())
+	res, err := db.Exec(`UPDATE withdraw_requests SET status='PROCESSING', provider_txn_id=$1 WHERE id=$2 AND status='PENDING'`, txnID, reqID)
+	if err != nil {
+		log.Printf("[WITHDRAW] Failed to mark withdraw %d processing: %v", reqID, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		log.Printf("[WITHDRAW] Withdraw %d no longer pending, payout skipped", reqID)
+		return
+	}
+
+	payoutReq := payment.PayoutRequest{
+		Phone:         destination,
+		Amount:        amount,
+		TransactionID: txnID,
+		NotifyURL:     fmt.Sprintf("%s/api/v1/webhooks/dmark/payout", cfg.DMarkPayCallbackURL),
+		Description:   fmt.Sprintf("PlayPool withdraw: %.0f UGX", amount),
+	}
+
+	payoutResp, err := payment.Default.Payout(context.Background(), payoutReq)
+	if payoutResp != nil && payoutResp.Rejected() {
+		// Provider explicitly rejected the payout: return the reserved funds
+		log.Printf("[WITHDRAW] Payout rejected for withdraw %d: %v", reqID, err)
+		payment.ProcessPayoutFailed(db, cfg, reqID, payoutResp.StatusCode, payoutResp.Message)
+		return
+	}
+	if err != nil {
+		// 5xx or no response: the payout may still have gone through, so nothing is refunded.
+		// The payout webhook (matched on our transaction id) or the status checker settles it.
+		log.Printf("[WITHDRAW] Payout outcome unknown for withdraw %d, left PROCESSING: %v", reqID, err)
+		if payoutResp == nil {
+			payoutResp = &payment.PayoutResponse{}
+		}
+	}
+
+	if _, err := db.Exec(`UPDATE withdraw_requests SET
+		dmark_transaction_id=NULLIF($1, ''),
+		provider_status_code=$2,
+		provider_status_message=$3
+		WHERE id=$4 AND status='PROCESSING'`,
+		payoutResp.TransactionID, payoutResp.StatusCode, payoutResp.Status, reqID); err != nil {
+		log.Printf("[WITHDRAW] Failed to record provider response for withdraw %d: %v", reqID, err)
+		return
+	}
+
+	log.Printf("[WITHDRAW] Payout initiated: withdraw=%d txn=%s dmark_id=%s status=%s", reqID, txnID, payoutResp.TransactionID, payoutResp.Status)
 }
 
 // processWithdrawMock simulates a payout: settlement -> money leaves system (full amount to provider)
@@ -515,7 +628,7 @@ func processWithdrawMock(db *sqlx.DB, cfg *config.Config, reqID, pid int, amount
 			return
 		}
 		// refund settlement -> player winnings
-		if err := accounts.Transfer(tx, sett.ID, pwAcc.ID, amount, "WITHDRAW_REFUND", sql.NullInt64{Int64: int64(reqID), Valid: true}, "Withdraw failed - refunded"); err != nil {
+		if err := accounts.Transfer(tx, sett.ID, pwAcc.ID, amount, "WITHDRAW_REFUND", sql.NullInt64{Int64: int64(reqID), Valid: true}, "Withdraw failed - refunded", cfg.SettlementOverdraftGuard); err != nil {
 			tx.Rollback()
 			log.Printf("[WITHDRAW MOCK] refund transfer failed: %v", err)
 			return
@@ -557,7 +670,7 @@ func processWithdrawMock(db *sqlx.DB, cfg *config.Config, reqID, pid int, amount
 		// Attempt to refund to player_winnings just in case (though reservation should have ensured funds)
 		pwAcc, _ := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
 		if pwAcc != nil {
-			if rErr := accounts.Transfer(tx, sett.ID, pwAcc.ID, amount, "WITHDRAW_REFUND", sql.NullInt64{Int64: int64(reqID), Valid: true}, "Refund on insufficient settlement"); rErr != nil {
+			if rErr := accounts.Transfer(tx, sett.ID, pwAcc.ID, amount, "WITHDRAW_REFUND", sql.NullInt64{Int64: int64(reqID), Valid: true}, "Refund on insufficient settlement", cfg.SettlementOverdraftGuard); rErr != nil {
 				log.Printf("[WITHDRAW MOCK] refund attempt failed: %v", rErr)
 			}
 		}
@@ -626,6 +739,104 @@ func GetMyWithdraws(db *sqlx.DB) gin.HandlerFunc {
 	}
 }
 
+// UpdateNotificationPreferences turns optional SMS types on or off for the current player.
+// Body is a map of type -> enabled, e.g. {"match": false}. OTP cannot be turned off.
+// PUT /api/v1/me/notifications
+func UpdateNotificationPreferences(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pidI, ok := c.Get("player_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		pid := pidI.(int)
+
+		var req map[string]bool
+		if err := c.ShouldBindJSON(&req); err != nil || len(req) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "preferences required"})
+			return
+		}
+		for smsType := range req {
+			if !sms.IsOptionalType(smsType) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("cannot change %s notifications", smsType)})
+				return
+			}
+		}
+
+		tx, err := db.Beginx()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+		defer tx.Rollback()
+		for smsType, enabled := range req {
+			if _, err := tx.Exec(`INSERT INTO notification_preferences (player_id, sms_type, enabled, updated_at) VALUES ($1,$2,$3,NOW())
+                ON CONFLICT (player_id, sms_type) DO UPDATE SET enabled=EXCLUDED.enabled, updated_at=NOW()`, pid, smsType, enabled); err != nil {
+				log.Printf("[NOTIFY] Failed to save preference %s for player %d: %v", smsType, pid, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save preferences"})
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save preferences"})
+			return
+		}
+
+		prefs, err := loadNotificationPreferences(db, pid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load preferences"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"notifications": prefs})
+	}
+}
+
+// loadNotificationPreferences returns every optional SMS type with its on/off state (default on)
+func loadNotificationPreferences(db *sqlx.DB, pid int) (map[string]bool, error) {
+	prefs := make(map[string]bool, len(sms.OptionalTypes))
+	for _, t := range sms.OptionalTypes {
+		prefs[t] = true
+	}
+	var rows []struct {
+		SMSType string `db:"sms_type"`
+		Enabled bool   `db:"enabled"`
+	}
+	if err := db.Select(&rows, `SELECT sms_type, enabled FROM notification_preferences WHERE player_id=$1`, pid); err != nil {
+		return nil, err
+	}
+	for _, r := range rows {
+		if _, ok := prefs[r.SMSType]; ok {
+			prefs[r.SMSType] = r.Enabled
+		}
+	}
+	return prefs, nil
+}
+
+// UpdateSMSLanguage sets the language the current player's SMS are sent in.
+// PUT /api/v1/me/language {"language": "lg"}
+func UpdateSMSLanguage(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pidI, ok := c.Get("player_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		var req struct {
+			Language string `json:"language" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || !templates.Supported(req.Language) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "language must be en or lg"})
+			return
+		}
+		if _, err := db.Exec(`UPDATE players SET language=$1 WHERE id=$2`, req.Language, pidI.(int)); err != nil {
+			log.Printf("[NOTIFY] Failed to save language for player %d: %v", pidI.(int), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save language"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"language": req.Language})
+	}
+}
+
 // PlayerSessionMiddleware validates player session from cookie, sets player_id/player_phone in context.
 // Refreshes TTL on each request (sliding window).
 func PlayerSessionMiddleware(rdb *redis.Client, db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
@@ -12,9 +12,13 @@ func GetConfig(cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{
 			"commission_flat":               cfg.CommissionFlat,
+			"commission_mode":               cfg.CommissionMode,
+			"commission_percentage":         cfg.CommissionPercentage,
+			"commission_tiers":              cfg.CommissionTiers,
 			"payout_tax_percent":            cfg.PayoutTaxPercent,
 			"min_stake_amount":              cfg.MinStakeAmount,
 "min_withdraw_amount":           cfg.MinWithdrawAmount,
+			"game_chat_enabled":             cfg.GameChatEnabled,
 		})
 	}
 }
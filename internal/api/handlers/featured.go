@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/game"
+	"github.com/playpool/backend/internal/ws"
+)
+
+const (
+	featuredDefaultLimit = 10
+	featuredMaxLimit     = 50
+)
+
+// GetFeaturedGames lists in-progress games worth spectating. Ranking weighs the audience
+// (decayed, so a game that just lost its viewers does not drop off at once) and the stake.
+func GetFeaturedGames() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := featuredDefaultLimit
+		if v := c.Query("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+				return
+			}
+			if n > featuredMaxLimit {
+				n = featuredMaxLimit
+			}
+			limit = n
+		}
+
+		games := []ws.FeaturedGame{}
+		if game.Manager != nil && ws.GameHub != nil {
+			games = ws.GameHub.FeaturedGames(game.Manager.ActivePoolGames(), time.Now(), limit)
+		}
+		c.JSON(http.StatusOK, gin.H{"games": games})
+	}
+}
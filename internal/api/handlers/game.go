@@ -22,6 +22,7 @@ import (
 	"github.com/playpool/backend/internal/game"
 	"github.com/playpool/backend/internal/payment"
 	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -102,6 +103,11 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 			return
 		}
 
+		// Self-excluded players cannot stake (cash or winnings) until the exclusion ends
+		if rejectIfSelfExcluded(c, db, player.ID) || rejectIfBlocked(c, db, player.ID) {
+			return
+		}
+
 		// If client supplied a display name, validate and persist it (overrides generated/default)
 		if req.DisplayName != "" {
 			name := strings.TrimSpace(req.DisplayName)
@@ -232,6 +238,21 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 
 		log.Printf("[INFO] InitiateStake - player: id=%d phone=%s display_name=%s", player.ID, player.PhoneNumber, player.DisplayName)
 
+		// Responsible gaming limits, checked before any money moves
+		allowance, err := loadStakeAllowance(db, cfg, player.ID, time.Now())
+		if err != nil {
+			log.Printf("[ERROR] InitiateStake - failed to load stake limits for player %d: %v", player.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check stake limits"})
+			return
+		}
+		// Commission on top of the stake, per the configured model; every path below charges this
+		commission := cfg.Commission(req.StakeAmount)
+		grossAmount := float64(req.StakeAmount + commission)
+		if err := allowance.check(grossAmount); err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "stake_allowance": allowance.response()})
+			return
+		}
+
 		// DUMMY PAYMENT: Auto-approve payment (no actual Mobile Money call)
 		transactionID := generateTransactionID()
 		queueToken := generateQueueToken()
@@ -242,10 +263,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 
 		if useWinnings {
 			// WINNINGS FLOW: Charge commission like normal stake
-			commission := float64(cfg.CommissionFlat)
-			grossAmount := float64(req.StakeAmount + cfg.CommissionFlat)
-
-			log.Printf("[WINNINGS STAKE] Player %d using winnings for stake %d UGX (with %d commission)", player.ID, req.StakeAmount, cfg.CommissionFlat)
+			log.Printf("[WINNINGS STAKE] Player %d using winnings for stake %d UGX (with %d commission)", player.ID, req.StakeAmount, commission)
 
 			// Record transaction (type=STAKE_WINNINGS, WITH commission)
 			if err := db.QueryRowx(`INSERT INTO transactions (player_id, transaction_type, amount, status, created_at) VALUES ($1,'STAKE_WINNINGS',$2,'COMPLETED',NOW()) RETURNING id`, player.ID, grossAmount).Scan(&txID); err != nil {
@@ -301,7 +319,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 			}
 
 			// Transfer: PLAYER_WINNINGS → SETTLEMENT (gross amount)
-			if err := accounts.Transfer(tx, winningsAcc.ID, settlementAcc.ID, grossAmount, "TRANSACTION", sql.NullInt64{Int64: int64(txID), Valid: txID > 0}, "Winnings stake (gross)"); err != nil {
+			if err := accounts.Transfer(tx, winningsAcc.ID, settlementAcc.ID, grossAmount, "TRANSACTION", sql.NullInt64{Int64: int64(txID), Valid: txID > 0}, "Winnings stake (gross)", cfg.SettlementOverdraftGuard); err != nil {
 				tx.Rollback()
 				log.Printf("[DB] Failed to transfer from winnings to settlement: %v", err)
 				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -309,7 +327,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 			}
 
 			// Transfer: SETTLEMENT → PLATFORM (commission)
-			if err := accounts.Transfer(tx, settlementAcc.ID, platformAcc.ID, commission, "TRANSACTION", sql.NullInt64{Int64: int64(txID), Valid: txID > 0}, "Commission (winnings stake)"); err != nil {
+			if err := accounts.Transfer(tx, settlementAcc.ID, platformAcc.ID, float64(commission), "TRANSACTION", sql.NullInt64{Int64: int64(txID), Valid: txID > 0}, "Commission (winnings stake)", cfg.SettlementOverdraftGuard); err != nil {
 				tx.Rollback()
 				log.Printf("[DB] Failed to transfer commission: %v", err)
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process commission"})
@@ -317,7 +335,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 			}
 
 			// Transfer: SETTLEMENT → PLAYER_WINNINGS (net stake - stays in winnings for matching)
-			if err := accounts.Transfer(tx, settlementAcc.ID, winningsAcc.ID, netAmount, "TRANSACTION", sql.NullInt64{Int64: int64(txID), Valid: txID > 0}, "Stake (net)"); err != nil {
+			if err := accounts.Transfer(tx, settlementAcc.ID, winningsAcc.ID, netAmount, "TRANSACTION", sql.NullInt64{Int64: int64(txID), Valid: txID > 0}, "Stake (net)", cfg.SettlementOverdraftGuard); err != nil {
 				tx.Rollback()
 				log.Printf("[DB] Failed to transfer net stake back to winnings: %v", err)
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process net stake"})
@@ -330,7 +348,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 				return
 			}
 
-			log.Printf("[WINNINGS STAKE] Successfully processed winnings stake for player %d: commission=%.2f, net=%.2f", player.ID, commission, netAmount)
+			log.Printf("[WINNINGS STAKE] Successfully processed winnings stake for player %d: commission=%d, net=%.2f", player.ID, commission, netAmount)
 
 		} else {
 			// NORMAL FLOW: Real DMarkPay payin integration (unless MockMode is enabled)
@@ -346,13 +364,13 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 				// Initiate payin
 				payinReq := payment.PayinRequest{
 					Phone:         phone,
-					Amount:        float64(req.StakeAmount + cfg.CommissionFlat),
+					Amount:        grossAmount,
 					TransactionID: txnID,
 					NotifyURL:     callbackURL,
 					Description:   fmt.Sprintf("PlayPool stake: %d UGX", req.StakeAmount),
 				}
 
-				payinResp, err := payment.Default.Payin(context.Background(), payinReq)
+				payinResp, err := payment.Default.Payin(context.WithoutCancel(c.Request.Context()), payinReq)
 				if err != nil {
 					log.Printf("[PAYMENT] Payin failed for %s: %v", phone, err)
 					c.JSON(http.StatusInternalServerError, gin.H{"error": "payment initiation failed"})
@@ -365,7 +383,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 						(player_id, transaction_type, amount, status, dmark_transaction_id, provider_status_code, provider_status_message, created_at)
 						VALUES ($1, 'STAKE', $2, 'PENDING', $3, $4, $5, NOW()) RETURNING id`,
 						player.ID,
-						float64(req.StakeAmount+cfg.CommissionFlat),
+						grossAmount,
 						payinResp.TransactionID,
 						payinResp.StatusCode,
 						payinResp.Status).Scan(&txID); err != nil {
@@ -383,6 +401,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 					"transaction_id":       txnID,
 					"dmark_transaction_id": payinResp.TransactionID,
 					"status":               "PENDING",
+					"commission":           commission,
 				})
 				return
 
@@ -391,15 +410,15 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 				realPayment = false
 				if cfg.MockMode {
 					log.Printf("[MOCK PAYMENT] MockMode enabled - simulating payment for %s %d UGX (transaction: %s)",
-						phone, req.StakeAmount+cfg.CommissionFlat, transactionID)
+						phone, req.StakeAmount+commission, transactionID)
 				} else {
 					log.Printf("[DUMMY PAYMENT] DMarkPay not configured - would charge %s %d UGX (transaction: %s)",
-						phone, req.StakeAmount+cfg.CommissionFlat, transactionID)
+						phone, req.StakeAmount+commission, transactionID)
 				}
 
 				// Record a transaction in DB and capture its id
 				if db != nil {
-					if err := db.QueryRowx(`INSERT INTO transactions (player_id, transaction_type, amount, status, created_at) VALUES ($1,'STAKE',$2,'COMPLETED',NOW()) RETURNING id`, player.ID, float64(req.StakeAmount+cfg.CommissionFlat)).Scan(&txID); err != nil {
+					if err := db.QueryRowx(`INSERT INTO transactions (player_id, transaction_type, amount, status, created_at) VALUES ($1,'STAKE',$2,'COMPLETED',NOW()) RETURNING id`, player.ID, grossAmount).Scan(&txID); err != nil {
 						log.Printf("[DB] Failed to insert transaction for player %d: %v", player.ID, err)
 						// continue - transaction best-effort for now
 					}
@@ -416,7 +435,6 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 			// Perform account movements ONLY in dummy mode (real payment happens in webhook)
 			if !realPayment {
 				// Perform account movements: debit settlement, credit platform (commission), credit player_winnings (net)
-				commission := float64(cfg.CommissionFlat)
 				tx, err := db.Beginx()
 				if err != nil {
 					log.Printf("[DB] Failed to begin tx for stake deposit: %v", err)
@@ -438,24 +456,23 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 								tx.Rollback()
 							} else {
 								// Credit settlement account with the gross amount (stake + commission) so transfers can debit it
-								gross := float64(req.StakeAmount + cfg.CommissionFlat)
-								if _, err := tx.Exec(`UPDATE accounts SET balance = balance + $1, updated_at = NOW() WHERE id = $2`, gross, settlementAcc.ID); err != nil {
+								if _, err := tx.Exec(`UPDATE accounts SET balance = balance + $1, updated_at = NOW() WHERE id = $2`, grossAmount, settlementAcc.ID); err != nil {
 									log.Printf("[DB] Failed to credit settlement account: %v", err)
 									tx.Rollback()
 								} else {
 									// Record deposit as an account transaction (external -> settlement)
-									if _, err := tx.Exec(`INSERT INTO account_transactions (debit_account_id, credit_account_id, amount, reference_type, reference_id, description, created_at) VALUES ($1,$2,$3,$4,$5,$6,NOW())`, nil, settlementAcc.ID, gross, "TRANSACTION", sql.NullInt64{Int64: int64(txID), Valid: txID > 0}, "Deposit (gross)"); err != nil {
+									if _, err := tx.Exec(`INSERT INTO account_transactions (debit_account_id, credit_account_id, amount, reference_type, reference_id, description, created_at) VALUES ($1,$2,$3,$4,$5,$6,NOW())`, nil, settlementAcc.ID, grossAmount, "TRANSACTION", sql.NullInt64{Int64: int64(txID), Valid: txID > 0}, "Deposit (gross)"); err != nil {
 										log.Printf("[DB] Failed to insert settlement deposit account_transaction: %v", err)
 										tx.Rollback()
 									} else {
-										log.Printf("[DB] Credited settlement account id=%d amount=%.2f (tx=%d)", settlementAcc.ID, gross, txID)
+										log.Printf("[DB] Credited settlement account id=%d amount=%.2f (tx=%d)", settlementAcc.ID, grossAmount, txID)
 										// Debit settlement -> credit platform (commission)
-										if err := accounts.Transfer(tx, settlementAcc.ID, platformAcc.ID, commission, "TRANSACTION", sql.NullInt64{Int64: int64(txID), Valid: txID > 0}, "Commission (flat)"); err != nil {
+										if err := accounts.Transfer(tx, settlementAcc.ID, platformAcc.ID, float64(commission), "TRANSACTION", sql.NullInt64{Int64: int64(txID), Valid: txID > 0}, "Commission", cfg.SettlementOverdraftGuard); err != nil {
 											log.Printf("[DB] Failed to transfer commission: %v", err)
 											tx.Rollback()
 										} else {
 											// Debit settlement -> credit player winnings (net amount)
-											if err := accounts.Transfer(tx, settlementAcc.ID, winningsAcc.ID, netAmount, "TRANSACTION", sql.NullInt64{Int64: int64(txID), Valid: txID > 0}, "Deposit (net)"); err != nil {
+											if err := accounts.Transfer(tx, settlementAcc.ID, winningsAcc.ID, netAmount, "TRANSACTION", sql.NullInt64{Int64: int64(txID), Valid: txID > 0}, "Deposit (net)", cfg.SettlementOverdraftGuard); err != nil {
 												log.Printf("[DB] Failed to credit player winnings: %v", err)
 												tx.Rollback()
 											} else {
@@ -523,16 +540,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 					if strings.TrimSpace(req.InvitePhone) != "" && sms.Default != nil {
 						invitePhone := normalizePhone(req.InvitePhone)
 						if invitePhone != "" {
-							smsInviteQueued = true
-							joinLink := fmt.Sprintf("%s/join?matchcode=%s", cfg.FrontendURL, code)
-							go func(code string, invite string, stake int, link string) {
-								msg := fmt.Sprintf("Join my PlayPool match!\nCode: %s\nStake: %d UGX\n\n%s", code, stake, link)
-								if msgID, err := sms.SendSMS(context.Background(), invite, msg); err != nil {
-									log.Printf("[SMS] Failed to send invite to %s: %v", invite, err)
-								} else {
-									log.Printf("[SMS] Invite sent to %s msg_id=%s", invite, msgID)
-								}
-							}(code, invitePhone, req.StakeAmount, joinLink)
+							smsInviteQueued = sendInviteSMS(rdb, cfg, invitePhone, code, req.StakeAmount)
 						}
 					}
 
@@ -543,6 +551,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 						"queue_id":          queueID,
 						"queue_token":       queueToken,
 						"sms_invite_queued": smsInviteQueued,
+						"commission":        commission,
 						"message":           "Private match created. Share the code with a friend.",
 						"player_token":      player.PlayerToken,
 					})
@@ -602,6 +611,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 				"player_token":          player.PlayerToken,
 				"game_link":             myLink,
 				"stake_amount":          req.StakeAmount,
+				"commission":            commission,
 				"prize_amount":          int(float64(req.StakeAmount*2) * 0.9), // 10% commission
 				"expires_at":            matchResult.ExpiresAt,
 				"message":               "Opponent found! Click link to start game.",
@@ -616,7 +626,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 		// Try to match immediately using Redis (pop-before-push). If no match, push our queue id into Redis.
 		if game.Manager != nil {
 			log.Printf("[MATCH] Attempting immediate Redis match for queue_id=%d stake=%d phone=%s", queueID, req.StakeAmount, phone)
-			matchResult, err := game.Manager.TryMatchFromRedis(req.StakeAmount, queueID, phone, player.ID, player.DisplayName)
+			matchResult, err := game.Manager.TryMatchFromRedis(c.Request.Context(), req.StakeAmount, queueID, phone, player.ID, player.DisplayName)
 			if err != nil {
 				log.Printf("[ERROR] TryMatchFromRedis failed: %v", err)
 			}
@@ -636,6 +646,10 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 					myDisplayName = matchResult.Player1DisplayName
 					opponentDisplayName = matchResult.Player2DisplayName
 				}
+				// Neither player has connected yet; with a reveal delay the name comes later
+				if game.HideOpponentAtMatch(cfg) {
+					opponentDisplayName = ""
+				}
 
 				c.JSON(http.StatusOK, gin.H{
 					"status":                "matched",
@@ -646,6 +660,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 					"player_token":          player.PlayerToken,
 					"game_link":             myLink,
 					"stake_amount":          req.StakeAmount,
+					"commission":            commission,
 					"prize_amount":          int(float64(req.StakeAmount*2) * 0.9), // 10% commission (legacy field, precise payout computed later)
 					"expires_at":            matchResult.ExpiresAt,
 					"message":               "Opponent found! Click link to start game.",
@@ -668,6 +683,7 @@ func InitiateStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handl
 			"player_token":   player.PlayerToken,
 			"queue_id":       queueID,
 			"stake_amount":   req.StakeAmount,
+			"commission":     commission,
 			"display_name":   player.DisplayName,
 			"message":        "Payment received! Finding opponent...",
 			"transaction_id": transactionID,
@@ -704,6 +720,14 @@ func CheckQueueStatus(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Ha
 			} else {
 				// No active queue found for this phone
 				log.Printf("[QUEUE STATUS] No active queue found for phone %s", phone)
+				if payinCancelled(db, phone) {
+					// The player abandoned the payin; tell the poller to stop waiting
+					c.JSON(http.StatusOK, gin.H{
+						"status":  "cancelled",
+						"message": "Payment cancelled.",
+					})
+					return
+				}
 				c.JSON(http.StatusOK, gin.H{
 					"status":  "not_found",
 					"message": "Payment not yet confirmed. Please wait...",
@@ -768,13 +792,18 @@ func CheckQueueStatus(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Ha
 
 			// Get game from in-memory for player token lookup
 			gameState, err := game.Manager.GetGameByToken(*dbQueue.GameToken)
-			var gameLink string
+			var gameLink, opponentName string
 			if err == nil {
 				// Found in memory - use player tokens
 				if gameState.Player1.ID == queueToken {
 					gameLink = cfg.FrontendURL + "/g/" + *dbQueue.GameToken + "?pt=" + gameState.Player1.PlayerToken
+					opponentName = gameState.Player2.DisplayName
 				} else {
 					gameLink = cfg.FrontendURL + "/g/" + *dbQueue.GameToken + "?pt=" + gameState.Player2.PlayerToken
+					opponentName = gameState.Player1.DisplayName
+				}
+				if !gameState.OpponentRevealed(cfg, time.Now()) {
+					opponentName = ""
 				}
 			} else {
 				// Not in memory yet - use basic link (player will auth via queue token)
@@ -782,13 +811,14 @@ func CheckQueueStatus(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Ha
 			}
 
 			c.JSON(http.StatusOK, gin.H{
-				"status":       "matched",
-				"game_token":   *dbQueue.GameToken,
-				"game_link":    gameLink,
-				"queue_token":  queueToken,
-				"player_token": playerToken,
-				"stake_amount": int(dbQueue.StakeAmount),
-				"message":      "Opponent found! Click link to play.",
+				"status":                "matched",
+				"game_token":            *dbQueue.GameToken,
+				"game_link":             gameLink,
+				"queue_token":           queueToken,
+				"player_token":          playerToken,
+				"stake_amount":          int(dbQueue.StakeAmount),
+				"opponent_display_name": opponentName, // empty until revealed (OPPONENT_REVEAL_DELAY_SECONDS)
+				"message":               "Opponent found! Click link to play.",
 			})
 
 		case "queued", "processing", "matching":
@@ -858,10 +888,64 @@ func GetGameState(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handle
 
 		state := gameState.GetGameStateForPlayer(resolvedPlayerID)
 		state["game_type"] = "pool"
+		if !gameState.OpponentRevealed(cfg, time.Now()) {
+			state["opponent_display_name"] = ""
+		}
 		c.JSON(http.StatusOK, state)
 	}
 }
 
+// PreviewPoolShot predicts the outcome of a shot without playing it
+// POST /api/v1/pool/:token/preview
+func PreviewPoolShot(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			PlayerToken string  `json:"pt" binding:"required"`
+			Angle       float64 `json:"angle"`
+			Power       float64 `json:"power"`
+			Screw       float64 `json:"screw"`
+			English     float64 `json:"english"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pt required"})
+			return
+		}
+
+		gameState, err := game.Manager.GetGameByToken(c.Param("token"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+			return
+		}
+
+		var playerID string
+		if req.PlayerToken == gameState.Player1.PlayerToken {
+			playerID = gameState.Player1.ID
+		} else if req.PlayerToken == gameState.Player2.PlayerToken {
+			playerID = gameState.Player2.ID
+		} else {
+			c.JSON(http.StatusForbidden, gin.H{"error": "invalid player token"})
+			return
+		}
+
+		preview, err := gameState.DryRunShot(playerID, game.ShotParams{
+			Angle:   req.Angle,
+			Power:   req.Power,
+			Screw:   req.Screw,
+			English: req.English,
+		})
+		if err == game.ErrSimulationBusy {
+			c.Header("Retry-After", "1")
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error(), "retriable": true})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, preview)
+	}
+}
+
 // GetPlayerStats returns player statistics
 func GetPlayerStats(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -874,8 +958,9 @@ func GetPlayerStats(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 			TotalGamesWon    int     `db:"total_games_won"`
 			TotalGamesDrawn  int     `db:"total_games_drawn"`
 			TotalWinnings    float64 `db:"total_winnings"`
+			EloRating        int     `db:"elo_rating"`
 		}
-		if err := db.Get(&p, `SELECT id, total_games_played, total_games_won, total_games_drawn, total_winnings FROM players WHERE phone_number=$1`, phone); err != nil {
+		if err := db.Get(&p, `SELECT id, total_games_played, total_games_won, total_games_drawn, total_winnings, elo_rating FROM players WHERE phone_number=$1`, phone); err != nil {
 			// If no player found, return defaults
 			c.JSON(http.StatusOK, gin.H{
 				"phone_number":   phone,
@@ -885,6 +970,8 @@ func GetPlayerStats(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 				"win_rate":       0.0,
 				"total_winnings": 0,
 				"current_streak": 0,
+				"longest_streak": 0,
+				"elo_rating":     game.DefaultEloRating,
 				"rank":           "Bronze",
 			})
 			return
@@ -896,34 +983,17 @@ func GetPlayerStats(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 			winRate = (float64(p.TotalGamesWon) / float64(p.TotalGamesPlayed)) * 100.0
 		}
 
-		// Compute current streak (consecutive wins from most recent completed games)
-		rows, err := db.Queryx(`SELECT winner_id FROM game_sessions WHERE (player1_id=$1 OR player2_id=$1) AND status='COMPLETED' ORDER BY completed_at DESC LIMIT 50`, p.ID)
-		if err != nil {
+		// Streaks over the 50 most recent completed games (newest first; NULL winner = draw)
+		var winners []sql.NullInt64
+		if err := db.Select(&winners, `SELECT winner_id FROM game_sessions WHERE (player1_id=$1 OR player2_id=$1) AND status='COMPLETED' ORDER BY completed_at DESC LIMIT 50`, p.ID); err != nil {
 			log.Printf("Failed to query recent games for streak: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load stats"})
+			return
 		}
-		streak := 0
-		for rows != nil && rows.Next() {
-			var winnerID sql.NullInt64
-			if err := rows.Scan(&winnerID); err != nil {
-				break
-			}
-			if winnerID.Valid && int(winnerID.Int64) == p.ID {
-				streak++
-			} else {
-				break
-			}
-		}
-		if rows != nil {
-			rows.Close()
-		}
+		streak, longestStreak := winStreaks(winners, p.ID)
 
 		// Derive a simple rank from total winnings
-		rank := "Bronze"
-		if p.TotalWinnings >= 20000 {
-			rank = "Gold"
-		} else if p.TotalWinnings >= 5000 {
-			rank = "Silver"
-		}
+		rank := playerRank(p.TotalWinnings)
 
 		c.JSON(http.StatusOK, gin.H{
 			"phone_number":   phone,
@@ -933,11 +1003,33 @@ func GetPlayerStats(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 			"win_rate":       winRate,
 			"total_winnings": p.TotalWinnings,
 			"current_streak": streak,
+			"longest_streak": longestStreak,
+			"elo_rating":     p.EloRating,
 			"rank":           rank,
 		})
 	}
 }
 
+// winStreaks returns the current (most recent) and longest run of wins in winners, which
+// lists game winners newest first. Losses and draws (NULL winner) both end a run.
+func winStreaks(winners []sql.NullInt64, playerID int) (current, longest int) {
+	run := 0
+	for i, w := range winners {
+		if w.Valid && int(w.Int64) == playerID {
+			run++
+		} else {
+			run = 0
+		}
+		if run == i+1 {
+			current = run
+		}
+		if run > longest {
+			longest = run
+		}
+	}
+	return current, longest
+}
+
 // GetQueueStatus returns the current matchmaking queue status
 func GetQueueStatus(rdb *redis.Client) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -966,24 +1058,64 @@ func CreateTestGame(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Hand
 			"+256700111111",
 			"+256700222222",
 			req.StakeAmount,
+			false,
 		)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		c.JSON(http.StatusOK, gin.H{
-			"game_id":       poolGame.ID,
-			"game_token":    poolGame.Token,
-			"player1_id":    poolGame.Player1.ID,
-			"player1_token": poolGame.Player1.PlayerToken,
-			"player2_id":    poolGame.Player2.ID,
-			"player2_token": poolGame.Player2.PlayerToken,
-			"stake":         poolGame.StakeAmount,
-			"message":       "Test pool game created",
-			"player1_url":   "/g/" + poolGame.Token + "?pt=" + poolGame.Player1.PlayerToken,
-			"player2_url":   "/g/" + poolGame.Token + "?pt=" + poolGame.Player2.PlayerToken,
-		})
+		c.JSON(http.StatusOK, testPoolGameResponse(poolGame, "Test pool game created"))
+	}
+}
+
+// CreateTestPoolGame creates a racked pool game for QA (dev mode only)
+// POST /api/v1/dev/test-pool-game
+// Body: {"stake_amount": 1000, "near_win": true} — near_win puts player 1 on the 8-ball
+func CreateTestPoolGame(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			StakeAmount int    `json:"stake_amount"`
+			NearWin     bool   `json:"near_win"`
+			Player1     string `json:"player1_phone"`
+			Player2     string `json:"player2_phone"`
+		}
+		_ = c.ShouldBindJSON(&req)
+		if req.StakeAmount <= 0 {
+			req.StakeAmount = cfg.MinStakeAmount
+		}
+		if req.Player1 == "" {
+			req.Player1 = "+256700111111"
+		}
+		if req.Player2 == "" {
+			req.Player2 = "+256700222222"
+		}
+
+		poolGame, err := game.Manager.CreateTestPoolGame(req.Player1, req.Player2, req.StakeAmount, req.NearWin)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		resp := testPoolGameResponse(poolGame, "Test pool game created")
+		resp["near_win"] = req.NearWin
+		c.JSON(http.StatusOK, resp)
+	}
+}
+
+// testPoolGameResponse returns the ids, tokens and join URLs for a test pool game
+func testPoolGameResponse(poolGame *game.PoolGameState, message string) gin.H {
+	return gin.H{
+		"game_id":       poolGame.ID,
+		"game_token":    poolGame.Token,
+		"player1_id":    poolGame.Player1.ID,
+		"player1_token": poolGame.Player1.PlayerToken,
+		"player2_id":    poolGame.Player2.ID,
+		"player2_token": poolGame.Player2.PlayerToken,
+		"stake":         poolGame.StakeAmount,
+		"message":       message,
+		"player1_url":   "/g/" + poolGame.Token + "?pt=" + poolGame.Player1.PlayerToken,
+		"player2_url":   "/g/" + poolGame.Token + "?pt=" + poolGame.Player2.PlayerToken,
 	}
 }
 
@@ -1044,16 +1176,7 @@ func DeclineMatchInvite(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.
 		}
 
 		// Notify the inviter about the decline via SMS
-		go func() {
-			ctx := context.Background()
-			message := fmt.Sprintf("Your PlayPool match invite (Code: %s) was declined. You can create a new match anytime!", matchCode)
-
-			if _, err := sms.SendSMS(ctx, queue.InviterPhone, message); err != nil {
-				log.Printf("Failed to send decline SMS to %s: %v", queue.InviterPhone, err)
-			} else {
-				log.Printf("Decline SMS sent to %s for match %s", queue.InviterPhone, matchCode)
-			}
-		}()
+		sms.EnqueueTemplate(sms.TypeDecline, queue.InviterPhone, templates.InviteDeclined, templates.Params{"Code": matchCode})
 
 		c.JSON(http.StatusOK, gin.H{
 			"success": true,
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
+	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
+	"github.com/redis/go-redis/v9"
+)
+
+// sendGameLinkSMS texts a player their join link again (replaced in tests)
+var sendGameLinkSMS = func(phone string, params templates.Params) bool {
+	return sms.EnqueueTemplate(sms.TypeMatch, phone, templates.Match, params)
+}
+
+// ResendGameLink re-sends a matched player their own join link, for players who lost the
+// match SMS. The phone must be one of the game's two players and the game still waiting or
+// in progress; each phone gets at most one resend per GameLinkResendSeconds.
+// POST /api/v1/game/:token/resend-link {"phone": "256700123456"}
+func ResendGameLink(rdb *redis.Client, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Phone string `json:"phone"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || normalizePhone(req.Phone) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "valid phone required"})
+			return
+		}
+		phone := normalizePhone(req.Phone)
+
+		g, err := game.Manager.GetGameByToken(c.Param("token"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+			return
+		}
+		if g.Status != game.StatusWaiting && g.Status != game.StatusInProgress {
+			c.JSON(http.StatusConflict, gin.H{"error": "game is over"})
+			return
+		}
+
+		me, opp := g.Player1, g.Player2
+		if normalizePhone(opp.PhoneNumber) == phone {
+			me, opp = opp, me
+		} else if normalizePhone(me.PhoneNumber) != phone {
+			c.JSON(http.StatusForbidden, gin.H{"error": "phone is not a player in this game"})
+			return
+		}
+
+		ctx := context.Background()
+		key := fmt.Sprintf("game_link_resend:%s", phone)
+		if rdb != nil && cfg.GameLinkResendSeconds > 0 {
+			window := time.Duration(cfg.GameLinkResendSeconds) * time.Second
+			ok, err := rdb.SetNX(ctx, key, "1", window).Result()
+			if err == nil && !ok {
+				wait := window
+				if ttl, err := rdb.TTL(ctx, key).Result(); err == nil && ttl > 0 {
+					wait = ttl
+				}
+				c.Header("Retry-After", fmt.Sprint(int(wait.Seconds())))
+				c.JSON(http.StatusTooManyRequests, gin.H{"error": "link already resent, try again shortly", "retry_after_seconds": int(wait.Seconds())})
+				return
+			}
+		}
+
+		oppName := opp.DisplayName
+		if oppName == "" || !g.OpponentRevealed(cfg, time.Now()) {
+			oppName = "an opponent"
+		}
+		link := cfg.FrontendURL + "/g/" + g.Token + "?pt=" + me.PlayerToken
+		if !sendGameLinkSMS(phone, templates.Params{"Opponent": oppName, "Stake": g.StakeAmount, "Link": link}) {
+			// Nothing went out, so do not hold the player to the cooldown
+			if rdb != nil {
+				rdb.Del(ctx, key)
+			}
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "SMS unavailable, try again later"})
+			return
+		}
+
+		log.Printf("[GAME] Resent game link for %s to player %s", g.ID, me.ID)
+		c.JSON(http.StatusOK, gin.H{"sent": true})
+	}
+}
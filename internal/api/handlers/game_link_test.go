@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
+	"github.com/playpool/backend/internal/sms/templates"
+	"github.com/redis/go-redis/v9"
+)
+
+type sentLink struct {
+	phone  string
+	params templates.Params
+}
+
+// resendFixture creates a live game between p1 and p2 and records game link texts instead of sending them
+func resendFixture(t *testing.T, rdb *redis.Client, p1, p2 string) (*game.PoolGameState, *gin.Engine, *[]sentLink) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	prev := game.Manager
+	game.Manager = game.NewGameManager(nil, nil, &config.Config{})
+	t.Cleanup(func() { game.Manager = prev })
+
+	g, err := game.Manager.CreateTestPoolGame(p1, p2, 2000, false)
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+
+	var sent []sentLink
+	prevSend := sendGameLinkSMS
+	sendGameLinkSMS = func(phone string, params templates.Params) bool {
+		sent = append(sent, sentLink{phone, params})
+		return true
+	}
+	t.Cleanup(func() { sendGameLinkSMS = prevSend })
+
+	r := gin.New()
+	r.POST("/game/:token/resend-link", ResendGameLink(rdb, &config.Config{FrontendURL: "https://play.test", GameLinkResendSeconds: 60}))
+	return g, r, &sent
+}
+
+func resendLink(r *gin.Engine, token, phone string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	body := fmt.Sprintf(`{"phone":%q}`, phone)
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/game/"+token+"/resend-link", bytes.NewBufferString(body)))
+	return w
+}
+
+func TestResendGameLinkToParticipant(t *testing.T) {
+	g, r, sent := resendFixture(t, nil, "256700000001", "256700000002")
+
+	// Local format is accepted; player 2 gets their own link naming player 1
+	if w := resendLink(r, g.Token, "0700000002"); w.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", w.Code, w.Body.String())
+	}
+	if len(*sent) != 1 {
+		t.Fatalf("%d texts sent, want 1", len(*sent))
+	}
+	s := (*sent)[0]
+	if want := "https://play.test/g/" + g.Token + "?pt=" + g.Player2.PlayerToken; s.phone != "256700000002" || s.params["Link"] != want {
+		t.Errorf("sent %s link %v, want 256700000002 %s", s.phone, s.params["Link"], want)
+	}
+	if s.params["Opponent"] != g.Player1.DisplayName || s.params["Stake"] != 2000 {
+		t.Errorf("params = %v", s.params)
+	}
+
+	g.Status = game.StatusCompleted
+	if w := resendLink(r, g.Token, "256700000001"); w.Code != http.StatusConflict {
+		t.Errorf("finished game: status %d, want 409", w.Code)
+	}
+}
+
+func TestResendGameLinkRejectsNonParticipant(t *testing.T) {
+	g, r, sent := resendFixture(t, nil, "256700000001", "256700000002")
+
+	if w := resendLink(r, g.Token, "256700000003"); w.Code != http.StatusForbidden {
+		t.Fatalf("stranger: status %d, want 403", w.Code)
+	}
+	if w := resendLink(r, "nope", "256700000001"); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown game: status %d, want 404", w.Code)
+	}
+	if len(*sent) != 0 {
+		t.Fatalf("texts sent to %+v", *sent)
+	}
+}
+
+func TestResendGameLinkRateLimited(t *testing.T) {
+	rdb := testRedis(t)
+	ctx := context.Background()
+	p1 := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	p2 := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+1)%100000000)
+	t.Cleanup(func() { rdb.Del(ctx, "game_link_resend:"+p1, "game_link_resend:"+p2) })
+	g, r, sent := resendFixture(t, rdb, p1, p2)
+
+	if w := resendLink(r, g.Token, p1); w.Code != http.StatusOK {
+		t.Fatalf("first resend: status %d", w.Code)
+	}
+	w := resendLink(r, g.Token, p1)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" || !strings.Contains(w.Body.String(), "retry_after_seconds") {
+		t.Fatalf("second resend: status %d headers %v body %s", w.Code, w.Header(), w.Body.String())
+	}
+	// The opponent has their own allowance
+	if w := resendLink(r, g.Token, p2); w.Code != http.StatusOK {
+		t.Fatalf("opponent resend: status %d", w.Code)
+	}
+	if len(*sent) != 2 {
+		t.Fatalf("%d texts sent, want 2", len(*sent))
+	}
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
+)
+
+func TestWinStreaks(t *testing.T) {
+	const me = 7
+	win := sql.NullInt64{Int64: me, Valid: true}
+	loss := sql.NullInt64{Int64: 9, Valid: true}
+	draw := sql.NullInt64{}
+
+	cases := []struct {
+		name             string
+		winners          []sql.NullInt64 // newest first
+		current, longest int
+	}{
+		{"no games", nil, 0, 0},
+		{"all wins", []sql.NullInt64{win, win, win}, 3, 3},
+		{"interrupted by draw", []sql.NullInt64{win, win, draw, win, win, win}, 2, 3},
+		{"interrupted by loss", []sql.NullInt64{win, loss, win, win}, 1, 2},
+		{"latest game a draw", []sql.NullInt64{draw, win, win}, 0, 2},
+		{"latest game a loss", []sql.NullInt64{loss, win}, 0, 1},
+	}
+	for _, tc := range cases {
+		current, longest := winStreaks(tc.winners, me)
+		if current != tc.current || longest != tc.longest {
+			t.Errorf("%s: got current=%d longest=%d, want %d/%d", tc.name, current, longest, tc.current, tc.longest)
+		}
+	}
+}
+
+func TestGameStateWithholdsOpponentUntilBothConnect(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	prev := game.Manager
+	game.Manager = game.NewGameManager(nil, nil, &config.Config{})
+	t.Cleanup(func() { game.Manager = prev })
+
+	g, err := game.Manager.CreateTestPoolGame("256700000001", "256700000002", 1000, false)
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+	r := gin.New()
+	r.GET("/game/:token", GetGameState(nil, nil, &config.Config{OpponentRevealDelaySeconds: 300}))
+	opponentName := func() string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/game/"+g.Token+"?pt="+g.Player1.PlayerToken, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", w.Code, w.Body.String())
+		}
+		var state struct {
+			Opponent string `json:"opponent_display_name"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &state)
+		return state.Opponent
+	}
+
+	g.MarkPlayerShowedUp(g.Player1.ID)
+	if name := opponentName(); name != "" {
+		t.Fatalf("opponent %q shown before both players connected", name)
+	}
+	g.MarkPlayerShowedUp(g.Player2.ID)
+	if name := opponentName(); name != g.Player2.DisplayName {
+		t.Fatalf("opponent = %q after both connected, want %q", name, g.Player2.DisplayName)
+	}
+}
@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/game"
+	"github.com/playpool/backend/internal/payment"
+	"github.com/playpool/backend/internal/sms"
+	"github.com/redis/go-redis/v9"
 )
 
 var startTime = time.Now()
@@ -20,3 +26,62 @@ func HealthCheck(c *gin.Context) {
 		"uptime":  time.Since(startTime).String(),
 	})
 }
+
+// readinessTimeout bounds each dependency ping so a hung DB or Redis fails the probe quickly
+const readinessTimeout = 2 * time.Second
+
+// Liveness reports that the process is up; it never touches dependencies.
+func Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness pings Postgres and Redis and returns 503 if either is unreachable.
+// SMS and payment client status is informational and never fails the probe.
+func Readiness(db *sqlx.DB, rdb *redis.Client) gin.HandlerFunc {
+	var dbPing, redisPing func(ctx context.Context) error
+	if db != nil {
+		dbPing = db.PingContext
+	}
+	if rdb != nil {
+		redisPing = func(ctx context.Context) error { return rdb.Ping(ctx).Err() }
+	}
+	return readiness(dbPing, redisPing)
+}
+
+// readiness is Readiness with the pings injected; a nil ping counts as unavailable.
+func readiness(dbPing, redisPing func(ctx context.Context) error) gin.HandlerFunc {
+	check := func(ping func(ctx context.Context) error) string {
+		if ping == nil {
+			return "unavailable: not configured"
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), readinessTimeout)
+		defer cancel()
+		if err := ping(ctx); err != nil {
+			return "unavailable: " + err.Error()
+		}
+		return "ok"
+	}
+
+	return func(c *gin.Context) {
+		dbStatus := check(dbPing)
+		redisStatus := check(redisPing)
+
+		status, code := "ready", http.StatusOK
+		if dbStatus != "ok" || redisStatus != "ok" {
+			status, code = "not_ready", http.StatusServiceUnavailable
+		}
+
+		resp := gin.H{
+			"status":             status,
+			"database":           dbStatus,
+			"redis":              redisStatus,
+			"sms_configured":     sms.Default != nil,
+			"sms_stats":          sms.Default.Stats(),
+			"payment_configured": payment.Default != nil,
+		}
+		if game.Manager != nil {
+			resp["match_stats"] = game.Manager.MatchStats()
+		}
+		c.JSON(code, resp)
+	}
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func probe(t *testing.T, h gin.HandlerFunc) (int, map[string]interface{}) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/probe", h)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/probe", nil))
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return w.Code, body
+}
+
+func TestLivenessAlwaysOK(t *testing.T) {
+	if code, _ := probe(t, Liveness); code != http.StatusOK {
+		t.Fatalf("status = %d", code)
+	}
+}
+
+func TestReadiness(t *testing.T) {
+	healthy := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+
+	cases := []struct {
+		name      string
+		db, redis func(context.Context) error
+		wantCode  int
+	}{
+		{"all healthy", healthy, healthy, http.StatusOK},
+		{"database down", down, healthy, http.StatusServiceUnavailable},
+		{"redis down", healthy, down, http.StatusServiceUnavailable},
+		{"redis not configured", healthy, nil, http.StatusServiceUnavailable},
+	}
+	for _, tc := range cases {
+		code, body := probe(t, readiness(tc.db, tc.redis))
+		if code != tc.wantCode {
+			t.Errorf("%s: status = %d, want %d (%v)", tc.name, code, tc.wantCode, body)
+		}
+		if _, ok := body["sms_configured"]; !ok {
+			t.Errorf("%s: missing sms_configured", tc.name)
+		}
+		if _, ok := body["payment_configured"]; !ok {
+			t.Errorf("%s: missing payment_configured", tc.name)
+		}
+	}
+
+	_, body := probe(t, readiness(down, healthy))
+	if body["database"] != "unavailable: connection refused" || body["redis"] != "ok" {
+		t.Errorf("unexpected detail: %v", body)
+	}
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
+	"github.com/redis/go-redis/v9"
+)
+
+// allowInviteSMS counts an invite to phone against the per-recipient window and reports
+// whether it may be sent. Without Redis (or with the limit off) every invite is allowed.
+func allowInviteSMS(ctx context.Context, rdb *redis.Client, cfg *config.Config, phone string) bool {
+	if rdb == nil || cfg.InviteSMSLimit <= 0 || cfg.InviteSMSWindowSeconds <= 0 {
+		return true
+	}
+	key := fmt.Sprintf("invite_sms:%s", phone)
+	n, err := rdb.Incr(ctx, key).Result()
+	if err != nil {
+		// Redis trouble should not swallow a legitimate invite
+		return true
+	}
+	if n == 1 {
+		rdb.Expire(ctx, key, time.Duration(cfg.InviteSMSWindowSeconds)*time.Second)
+	}
+	return n <= int64(cfg.InviteSMSLimit)
+}
+
+// sendInviteSMS texts the private match code to invite in the background. It returns false
+// when the invite was suppressed because the number already got one recently.
+func sendInviteSMS(rdb *redis.Client, cfg *config.Config, invite, code string, stake int) bool {
+	if !allowInviteSMS(context.Background(), rdb, cfg, invite) {
+		log.Printf("[SMS] Invite to %s suppressed: already invited within %ds", invite, cfg.InviteSMSWindowSeconds)
+		return false
+	}
+
+	link := fmt.Sprintf("%s/join?matchcode=%s", cfg.FrontendURL, code)
+	return sms.EnqueueTemplate(sms.TypeInvite, invite, templates.Invite, templates.Params{"Code": code, "Stake": stake, "Link": link})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// testRedis connects to TEST_REDIS_URL; tests that need Redis are skipped when it is unset
+func testRedis(t *testing.T) *redis.Client {
+	url := os.Getenv("TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TEST_REDIS_URL not set")
+	}
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("invalid TEST_REDIS_URL: %v", err)
+	}
+	rdb := redis.NewClient(opt)
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("redis unavailable: %v", err)
+	}
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func TestRepeatedInvitesToSameNumberSuppressed(t *testing.T) {
+	rdb := testRedis(t)
+	cfg := &config.Config{InviteSMSLimit: 1, InviteSMSWindowSeconds: 60}
+	ctx := context.Background()
+
+	friend := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	other := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+1)%100000000)
+	t.Cleanup(func() { rdb.Del(ctx, "invite_sms:"+friend, "invite_sms:"+other) })
+
+	if !allowInviteSMS(ctx, rdb, cfg, friend) {
+		t.Fatal("first invite was suppressed")
+	}
+	for i := 0; i < 3; i++ {
+		if allowInviteSMS(ctx, rdb, cfg, friend) {
+			t.Fatalf("repeat invite %d was allowed", i+1)
+		}
+	}
+	if !allowInviteSMS(ctx, rdb, cfg, other) {
+		t.Error("a different recipient was suppressed")
+	}
+	if ttl := rdb.TTL(ctx, "invite_sms:"+friend).Val(); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("window ttl = %v", ttl)
+	}
+}
+
+func TestInviteLimitDisabled(t *testing.T) {
+	cfg := &config.Config{InviteSMSLimit: 0, InviteSMSWindowSeconds: 60}
+	for i := 0; i < 3; i++ {
+		if !allowInviteSMS(context.Background(), nil, cfg, "256700000001") {
+			t.Fatal("invite suppressed with the limit off")
+		}
+	}
+}
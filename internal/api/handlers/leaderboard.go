@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	leaderboardDefaultLimit = 50
+	leaderboardMaxLimit     = 100
+	leaderboardCacheTTL     = 60 * time.Second
+)
+
+// LeaderboardEntry is one ranked player in a leaderboard window
+type LeaderboardEntry struct {
+	Position      int     `db:"-" json:"position"`
+	PlayerID      int     `db:"player_id" json:"-"`
+	DisplayName   string  `db:"display_name" json:"display_name"`
+	GamesPlayed   int     `db:"games_played" json:"games_played"`
+	GamesWon      int     `db:"games_won" json:"games_won"`
+	WinRate       float64 `db:"-" json:"win_rate"`
+	NetWinnings   float64 `db:"net_winnings" json:"net_winnings"`
+	TotalWinnings float64 `db:"total_winnings" json:"-"`
+	Rank          string  `db:"-" json:"rank"`
+}
+
+// playerRank derives the tier shown on profiles and the leaderboard from lifetime winnings
+func playerRank(totalWinnings float64) string {
+	switch {
+	case totalWinnings > 50000:
+		return "Platinum"
+	case totalWinnings >= 20000:
+		return "Gold"
+	case totalWinnings >= 5000:
+		return "Silver"
+	default:
+		return "Bronze"
+	}
+}
+
+// leaderboardSince returns the start of a leaderboard period (invalid for all time)
+func leaderboardSince(period string, now time.Time) (sql.NullTime, error) {
+	switch period {
+	case "week":
+		return sql.NullTime{Time: now.AddDate(0, 0, -7), Valid: true}, nil
+	case "month":
+		return sql.NullTime{Time: now.AddDate(0, -1, 0), Valid: true}, nil
+	case "all":
+		return sql.NullTime{}, nil
+	default:
+		return sql.NullTime{}, fmt.Errorf("period must be week, month or all")
+	}
+}
+
+// queryLeaderboard ranks players by net winnings from completed games since `since`: a win
+// earns the taxed pot minus the stake, a loss costs the stake and a draw is refunded.
+// Ties go to the player with more wins.
+func queryLeaderboard(db *sqlx.DB, cfg *config.Config, since sql.NullTime, limit int) ([]LeaderboardEntry, error) {
+	payoutShare := 1 - float64(cfg.PayoutTaxPercent)/100.0
+
+	entries := []LeaderboardEntry{}
+	err := db.Select(&entries, `
+		WITH results AS (
+			SELECT player1_id AS player_id, winner_id, stake_amount FROM game_sessions
+			WHERE status='COMPLETED' AND ($1::timestamp IS NULL OR completed_at >= $1)
+			UNION ALL
+			SELECT player2_id AS player_id, winner_id, stake_amount FROM game_sessions
+			WHERE status='COMPLETED' AND ($1::timestamp IS NULL OR completed_at >= $1)
+		)
+		SELECT p.id AS player_id,
+		       COALESCE(p.display_name, '') AS display_name,
+		       COALESCE(p.total_winnings, 0) AS total_winnings,
+		       COUNT(*) AS games_played,
+		       COUNT(*) FILTER (WHERE r.winner_id = p.id) AS games_won,
+		       COALESCE(SUM(CASE
+		           WHEN r.winner_id = p.id THEN r.stake_amount * 2 * $2 - r.stake_amount
+		           WHEN r.winner_id IS NULL THEN 0
+		           ELSE -r.stake_amount
+		       END), 0) AS net_winnings
+		FROM results r
+		JOIN players p ON p.id = r.player_id
+		WHERE COALESCE(p.is_blocked, FALSE) = FALSE
+		GROUP BY p.id
+		ORDER BY net_winnings DESC, games_won DESC, p.id ASC
+		LIMIT $3`, since, payoutShare, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		e.Position = i + 1
+		if e.GamesPlayed > 0 {
+			e.WinRate = (float64(e.GamesWon) / float64(e.GamesPlayed)) * 100.0
+		}
+		e.Rank = playerRank(e.TotalWinnings)
+	}
+	return entries, nil
+}
+
+// GetLeaderboard ranks players by net winnings over a period (week, month or all).
+// Results are cached in Redis briefly so the page does not hit the DB on every load.
+func GetLeaderboard(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		period := c.DefaultQuery("period", "week")
+		since, err := leaderboardSince(period, time.Now())
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		limit := leaderboardDefaultLimit
+		if v := c.Query("limit"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive number"})
+				return
+			}
+			if n > leaderboardMaxLimit {
+				n = leaderboardMaxLimit
+			}
+			limit = n
+		}
+
+		ctx := context.Background()
+		cacheKey := fmt.Sprintf("leaderboard:%s:%d", period, limit)
+		if rdb != nil {
+			if cached, err := rdb.Get(ctx, cacheKey).Bytes(); err == nil {
+				c.Data(http.StatusOK, "application/json; charset=utf-8", cached)
+				return
+			}
+		}
+
+		entries, err := queryLeaderboard(db, cfg, since, limit)
+		if err != nil {
+			log.Printf("[LEADERBOARD] Failed to load %s leaderboard: %v", period, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load leaderboard"})
+			return
+		}
+
+		body, err := json.Marshal(gin.H{"period": period, "entries": entries})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode leaderboard"})
+			return
+		}
+		if rdb != nil {
+			if err := rdb.Set(ctx, cacheKey, body, leaderboardCacheTTL).Err(); err != nil {
+				log.Printf("[LEADERBOARD] Failed to cache %s: %v", cacheKey, err)
+			}
+		}
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestPlayerRankTiers(t *testing.T) {
+	cases := map[float64]string{0: "Bronze", 4999: "Bronze", 5000: "Silver", 20000: "Gold", 50000: "Gold", 50001: "Platinum"}
+	for winnings, want := range cases {
+		if got := playerRank(winnings); got != want {
+			t.Errorf("playerRank(%.0f) = %s, want %s", winnings, got, want)
+		}
+	}
+}
+
+// seedPlayer inserts a player with a unique phone and display name
+func seedPlayer(t *testing.T, db *sqlx.DB, name string, blocked bool) int {
+	t.Helper()
+	var pid int
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if err := db.Get(&pid, `INSERT INTO players (phone_number, display_name, is_blocked) VALUES ($1,$2,$3) RETURNING id`, phone, name, blocked); err != nil {
+		t.Fatalf("insert player %s: %v", name, err)
+	}
+	return pid
+}
+
+// seedResult records a completed session; winner 0 is a draw
+func seedResult(t *testing.T, db *sqlx.DB, p1, p2, winner int, stake float64, completedAt time.Time) {
+	t.Helper()
+	var winnerID interface{}
+	if winner > 0 {
+		winnerID = winner
+	}
+	token := fmt.Sprintf("lb_%d", time.Now().UnixNano())
+	if _, err := db.Exec(`INSERT INTO game_sessions (game_token, player1_id, player2_id, stake_amount, status, winner_id, completed_at, expiry_time)
+		VALUES ($1,$2,$3,$4,'COMPLETED',$5,$6,$6)`, token, p1, p2, stake, winnerID, completedAt); err != nil {
+		t.Fatalf("insert session: %v", err)
+	}
+}
+
+func getLeaderboard(t *testing.T, db *sqlx.DB, cfg *config.Config, query string) []LeaderboardEntry {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/leaderboard", GetLeaderboard(db, nil, cfg))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/leaderboard"+query, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("leaderboard: status %d body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Entries []LeaderboardEntry `json:"entries"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return resp.Entries
+}
+
+// orderOf returns the seeded names in leaderboard order, ignoring players from other tests
+func orderOf(entries []LeaderboardEntry, names map[string]bool) []string {
+	var order []string
+	for _, e := range entries {
+		if names[e.DisplayName] {
+			order = append(order, e.DisplayName)
+		}
+	}
+	return order
+}
+
+func TestLeaderboardOrderingAndTieBreak(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{PayoutTaxPercent: 0}
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano()%100000)
+	name := func(n string) string { return n + suffix }
+	a := seedPlayer(t, db, name("A"), false)
+	b := seedPlayer(t, db, name("B"), false)
+	c := seedPlayer(t, db, name("C"), false)
+	d := seedPlayer(t, db, name("D"), true)
+	e := seedPlayer(t, db, name("E"), false)
+
+	now := time.Now()
+	seedResult(t, db, a, b, a, 1000, now) // A +1000
+	seedResult(t, db, a, b, a, 1000, now) // A +1000
+	seedResult(t, db, a, c, c, 1000, now) // C +1000, A -1000: A and C tie on net, A has more wins
+	seedResult(t, db, d, b, d, 5000, now) // blocked D never appears
+	seedResult(t, db, e, b, 0, 1000, now) // draw: E nets 0
+	seedResult(t, db, c, e, c, 10000, now.AddDate(0, 0, -40))
+
+	names := map[string]bool{name("A"): true, name("B"): true, name("C"): true, name("D"): true, name("E"): true}
+
+	week := getLeaderboard(t, db, cfg, "?period=week&limit=100")
+	got := orderOf(week, names)
+	want := []string{name("A"), name("C"), name("E"), name("B")}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("week order = %v, want %v", got, want)
+	}
+	for _, en := range week {
+		if en.DisplayName == name("A") && (en.GamesPlayed != 3 || en.GamesWon != 2 || en.NetWinnings != 1000) {
+			t.Errorf("A stats = %+v", en)
+		}
+		if en.DisplayName == name("B") && en.NetWinnings != -7000 {
+			t.Errorf("B net = %.0f, want -7000", en.NetWinnings)
+		}
+	}
+
+	all := getLeaderboard(t, db, cfg, "?period=all&limit=100")
+	got = orderOf(all, names)
+	if len(got) == 0 || got[0] != name("C") {
+		t.Fatalf("all-time order = %v, want C first", got)
+	}
+}
+
+func TestLeaderboardRejectsUnknownPeriod(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/leaderboard", GetLeaderboard(nil, nil, &config.Config{}))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/leaderboard?period=year", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+}
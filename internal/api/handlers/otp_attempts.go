@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// otpFailKey counts OTP verification attempts for a phone; once it passes
+// OTPMaxVerifyAttempts verification is locked until the key expires.
+func otpFailKey(phone string) string {
+	return fmt.Sprintf("otp_fail:%s", phone)
+}
+
+// reserveOTPAttempt counts a verification attempt for phone before the code is checked, so
+// parallel guesses can't get past the lockout. It returns the attempt number, or 0 when
+// attempts aren't limited. A counter already past OTPMaxVerifyAttempts means locked.
+func reserveOTPAttempt(ctx context.Context, rdb *redis.Client, cfg *config.Config, phone string) int {
+	if cfg.OTPMaxVerifyAttempts <= 0 {
+		return 0
+	}
+	key := otpFailKey(phone)
+	n, err := rdb.Incr(ctx, key).Result()
+	if err != nil {
+		return 0
+	}
+	if n == 1 {
+		// The window starts at the first attempt
+		rdb.Expire(ctx, key, time.Duration(cfg.OTPLockoutSeconds)*time.Second)
+	}
+	return int(n)
+}
+
+// otpLockedFor returns how long OTP verification for phone is still locked
+func otpLockedFor(ctx context.Context, rdb *redis.Client, cfg *config.Config, phone string) time.Duration {
+	ttl, err := rdb.TTL(ctx, otpFailKey(phone)).Result()
+	if err != nil || ttl <= 0 {
+		return time.Duration(cfg.OTPLockoutSeconds) * time.Second
+	}
+	return ttl
+}
+
+// lockOTP starts the lockout for phone after its last allowed attempt failed. It also
+// discards the outstanding OTP, so the codes already tried tell an attacker nothing about
+// the next one.
+func lockOTP(ctx context.Context, rdb *redis.Client, cfg *config.Config, phone string) {
+	rdb.Expire(ctx, otpFailKey(phone), time.Duration(cfg.OTPLockoutSeconds)*time.Second)
+	rdb.Del(ctx, fmt.Sprintf("otp:%s", phone))
+}
+
+// consumeOTPScript deletes the stored OTP only if it matches, so a code verifies once
+var consumeOTPScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// clearOTPFailures resets the counter after a correct code or a newly issued OTP
+func clearOTPFailures(ctx context.Context, rdb *redis.Client, phone string) {
+	rdb.Del(ctx, otpFailKey(phone))
+}
+
+// generateOTP returns a random numeric code of cfg.OTPLength digits (4 to 8, default 4)
+func generateOTP(cfg *config.Config) (string, error) {
+	digits := cfg.OTPLength
+	if digits < 4 || digits > 8 {
+		digits = 4
+	}
+	limit := int64(1)
+	for i := 0; i < digits; i++ {
+		limit *= 10
+	}
+	n, err := rand.Int(rand.Reader, bigInt(limit))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n.Int64()), nil
+}
+
+// verifyOTPCode checks code against the OTP stored for phone and consumes it. On failure it
+// has already written the 401 (or 429 while locked) response.
+func verifyOTPCode(c *gin.Context, rdb *redis.Client, cfg *config.Config, phone, code string) bool {
+	ctx := context.Background()
+	attempt := reserveOTPAttempt(ctx, rdb, cfg, phone)
+	if attempt > cfg.OTPMaxVerifyAttempts {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"error":               "too many incorrect codes, try again later",
+			"retry_after_seconds": int(otpLockedFor(ctx, rdb, cfg, phone).Seconds()),
+		})
+		return false
+	}
+
+	h := sha256.Sum256([]byte(code))
+	consumed, err := consumeOTPScript.Run(ctx, rdb, []string{fmt.Sprintf("otp:%s", phone)}, hex.EncodeToString(h[:])).Int()
+	if err != nil || consumed != 1 {
+		if attempt == 0 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired code"})
+		} else if attempt == cfg.OTPMaxVerifyAttempts {
+			lockOTP(ctx, rdb, cfg, phone)
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":               "too many incorrect codes, try again later",
+				"retry_after_seconds": cfg.OTPLockoutSeconds,
+			})
+		} else {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired code", "attempts_remaining": cfg.OTPMaxVerifyAttempts - attempt})
+		}
+		return false
+	}
+
+	clearOTPFailures(ctx, rdb, phone)
+	return true
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+func otpRouter(rdb *redis.Client, cfg *config.Config) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/verify", func(c *gin.Context) {
+		var req struct{ Phone, Code string }
+		c.BindJSON(&req)
+		if verifyOTPCode(c, rdb, cfg, req.Phone, req.Code) {
+			c.JSON(http.StatusOK, gin.H{"ok": true})
+		}
+	})
+	return r
+}
+
+func issueOTP(t *testing.T, rdb *redis.Client, phone, code string) {
+	h := sha256.Sum256([]byte(code))
+	if err := rdb.Set(context.Background(), "otp:"+phone, hex.EncodeToString(h[:]), time.Minute).Err(); err != nil {
+		t.Fatalf("store otp: %v", err)
+	}
+}
+
+func TestOTPLockoutAfterRepeatedFailures(t *testing.T) {
+	rdb := testRedis(t)
+	cfg := &config.Config{OTPMaxVerifyAttempts: 3, OTPLockoutSeconds: 60}
+	r := otpRouter(rdb, cfg)
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	t.Cleanup(func() { rdb.Del(context.Background(), "otp:"+phone, otpFailKey(phone)) })
+
+	issueOTP(t, rdb, phone, "1234")
+	for i := 1; i < cfg.OTPMaxVerifyAttempts; i++ {
+		if w := postJSON(r, "/verify", gin.H{"phone": phone, "code": "0000"}); w.Code != http.StatusUnauthorized {
+			t.Fatalf("wrong code %d: status %d, want 401", i, w.Code)
+		}
+	}
+	if w := postJSON(r, "/verify", gin.H{"phone": phone, "code": "0000"}); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("last allowed wrong code: status %d, want 429", w.Code)
+	}
+	// Locked: even the right code is refused until the lockout expires
+	if w := postJSON(r, "/verify", gin.H{"phone": phone, "code": "1234"}); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("correct code while locked: status %d, want 429", w.Code)
+	}
+	if n, _ := rdb.Exists(context.Background(), "otp:"+phone).Result(); n != 0 {
+		t.Fatal("outstanding OTP not discarded on lockout")
+	}
+}
+
+func TestOTPFailuresResetOnSuccessAndNewCode(t *testing.T) {
+	rdb := testRedis(t)
+	cfg := &config.Config{OTPMaxVerifyAttempts: 3, OTPLockoutSeconds: 60}
+	r := otpRouter(rdb, cfg)
+	ctx := context.Background()
+	phone := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+7)%100000000)
+	t.Cleanup(func() { rdb.Del(ctx, "otp:"+phone, otpFailKey(phone)) })
+
+	issueOTP(t, rdb, phone, "4321")
+	postJSON(r, "/verify", gin.H{"phone": phone, "code": "0000"})
+	postJSON(r, "/verify", gin.H{"phone": phone, "code": "0000"})
+	if w := postJSON(r, "/verify", gin.H{"phone": phone, "code": "4321"}); w.Code != http.StatusOK {
+		t.Fatalf("correct code: status %d body %s", w.Code, w.Body.String())
+	}
+	if n, _ := rdb.Exists(ctx, otpFailKey(phone)).Result(); n != 0 {
+		t.Fatal("failure counter not reset after a correct code")
+	}
+
+	// Two more failures would lock if the count had carried over; a new code resets it too
+	issueOTP(t, rdb, phone, "5555")
+	postJSON(r, "/verify", gin.H{"phone": phone, "code": "0000"})
+	postJSON(r, "/verify", gin.H{"phone": phone, "code": "0000"})
+	clearOTPFailures(ctx, rdb, phone) // what RequestOTP does when issuing
+	if w := postJSON(r, "/verify", gin.H{"phone": phone, "code": "0000"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("first failure after new code: status %d, want 401", w.Code)
+	}
+}
+
+func TestConcurrentOTPVerifiesSucceedOnce(t *testing.T) {
+	rdb := testRedis(t)
+	cfg := &config.Config{OTPMaxVerifyAttempts: 20, OTPLockoutSeconds: 60}
+	r := otpRouter(rdb, cfg)
+	phone := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+13)%100000000)
+	t.Cleanup(func() { rdb.Del(context.Background(), "otp:"+phone, otpFailKey(phone)) })
+
+	issueOTP(t, rdb, phone, "8642")
+	const n = 10
+	codes := make(chan int, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- postJSON(r, "/verify", gin.H{"phone": phone, "code": "8642"}).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+	ok := 0
+	for code := range codes {
+		if code == http.StatusOK {
+			ok++
+		}
+	}
+	if ok != 1 {
+		t.Errorf("%d verifies accepted the same code, want 1", ok)
+	}
+}
+
+func TestGenerateOTPLength(t *testing.T) {
+	for _, tc := range []struct{ cfg, want int }{{0, 4}, {4, 4}, {6, 6}, {12, 4}} {
+		code, err := generateOTP(&config.Config{OTPLength: tc.cfg})
+		if err != nil {
+			t.Fatalf("generateOTP: %v", err)
+		}
+		if len(code) != tc.want {
+			t.Errorf("OTPLength %d: code %q has %d digits, want %d", tc.cfg, code, len(code), tc.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/payment"
+)
+
+// CancelPayin lets a player abandon their own real-money stake payin while it is still
+// PENDING (not yet approved on the phone), so they aren't queued if it completes later.
+// POST /api/v1/transactions/:dmark_id/cancel
+func CancelPayin(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pidI, ok := c.Get("player_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		switch err := payment.CancelPayin(db, pidI.(int), c.Param("dmark_id")); {
+		case errors.Is(err, payment.ErrPayinNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, payment.ErrPayinNotPending):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err != nil:
+			log.Printf("[PAYMENT] Failed to cancel payin %s: %v", c.Param("dmark_id"), err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel payment"})
+		default:
+			c.JSON(http.StatusOK, gin.H{"status": "CANCELLED", "message": "Payment cancelled. If you already approved it, the amount will be added to your balance."})
+		}
+	}
+}
+
+// payinCancelled reports whether phone's most recent stake payin was cancelled by the player
+func payinCancelled(db *sqlx.DB, phone string) bool {
+	var status string
+	err := db.Get(&status, `SELECT t.status FROM transactions t JOIN players p ON p.id = t.player_id
+		WHERE p.phone_number=$1 AND t.transaction_type='STAKE' ORDER BY t.created_at DESC LIMIT 1`, phone)
+	return err == nil && status == "CANCELLED"
+}
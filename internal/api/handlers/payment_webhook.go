@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
 	"encoding/json"
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
@@ -21,11 +26,65 @@ type WebhookPayload struct {
 	Message         string `json:"message"`
 }
 
+// WebhookSignatureHeader carries the hex HMAC-SHA256 of the raw callback body
+const WebhookSignatureHeader = "X-Signature"
+
+// signWebhookBody returns the hex HMAC-SHA256 of body using secret
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifyWebhookSignature reports whether a callback may be processed.
+// Until a secret is configured (only allowed in mock mode) callbacks are accepted
+// unsigned with a warning; in permissive mode failures are logged but accepted.
+func verifyWebhookSignature(cfg *config.Config, body []byte, signature string) bool {
+	if cfg.DMarkPayWebhookSecret == "" {
+		log.Printf("[WEBHOOK] Warning: DMARK_PAY_WEBHOOK_SECRET not set, accepting unsigned callback")
+		return true
+	}
+
+	signature = strings.TrimPrefix(strings.TrimSpace(signature), "sha256=")
+
+	var reason string
+	switch {
+	case signature == "":
+		reason = "missing signature"
+	default:
+		expected := signWebhookBody(cfg.DMarkPayWebhookSecret, body)
+		if subtle.ConstantTimeCompare([]byte(strings.ToLower(signature)), []byte(expected)) == 1 {
+			return true
+		}
+		reason = "signature mismatch"
+	}
+
+	if cfg.DMarkPayWebhookPermissive {
+		log.Printf("[WEBHOOK] Signature check failed (%s), accepting in permissive mode", reason)
+		return true
+	}
+	log.Printf("[WEBHOOK] Signature check failed (%s), rejecting callback", reason)
+	return false
+}
+
 // DMarkPayinWebhook handles payin (deposit) callbacks
 func DMarkPayinWebhook(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		body, err := c.GetRawData()
+		if err != nil {
+			log.Printf("[WEBHOOK] Failed to read body: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+			return
+		}
+
+		// Verify before touching the database so forged callbacks never move money
+		if !verifyWebhookSignature(cfg, body, c.GetHeader(WebhookSignatureHeader)) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+
 		var webhook WebhookPayload
-		if err := c.BindJSON(&webhook); err != nil {
+		if err := json.Unmarshal(body, &webhook); err != nil {
 			log.Printf("[WEBHOOK] Invalid payload: %v", err)
 			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
 			return
@@ -49,7 +108,7 @@ func DMarkPayinWebhook(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.H
 			PhoneNumber string  `db:"phone_number"`
 		}
 
-		err := db.Get(&txn, `
+		err = db.Get(&txn, `
             SELECT t.id, t.player_id, t.amount, t.status, p.phone_number
             FROM transactions t
             JOIN players p ON t.player_id = p.id
@@ -93,3 +152,77 @@ func DMarkPayinWebhook(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.H
 	}
 }
 
+
+// DMarkPayoutWebhook handles payout (withdraw) callbacks
+func DMarkPayoutWebhook(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := c.GetRawData()
+		if err != nil {
+			log.Printf("[WEBHOOK] Failed to read body: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+			return
+		}
+
+		if !verifyWebhookSignature(cfg, body, c.GetHeader(WebhookSignatureHeader)) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+			return
+		}
+
+		var webhook WebhookPayload
+		if err := json.Unmarshal(body, &webhook); err != nil {
+			log.Printf("[WEBHOOK] Invalid payload: %v", err)
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+			return
+		}
+
+		log.Printf("[WEBHOOK] Payout callback: sp_txn=%s dmark_txn=%s status=%s code=%s",
+			webhook.SPTransactionID, webhook.TransactionID, webhook.Status, webhook.StatusCode)
+
+		// Log webhook for audit trail
+		payloadJSON, _ := json.Marshal(webhook)
+		db.Exec(`INSERT INTO payment_webhooks (dmark_transaction_id, sp_transaction_id, status, status_code, payload, processed, created_at)
+                 VALUES ($1, $2, $3, $4, $5, FALSE, NOW())`,
+			webhook.TransactionID, webhook.SPTransactionID, webhook.Status, webhook.StatusCode, payloadJSON)
+
+		// Find withdraw request by DMarkPay ID, falling back to our own transaction ID
+		var wr struct {
+			ID     int    `db:"id"`
+			Status string `db:"status"`
+		}
+		err = db.Get(&wr, `
+            SELECT id, status FROM withdraw_requests
+            WHERE dmark_transaction_id = $1 OR ($2 != '' AND provider_txn_id = $2)
+            LIMIT 1`,
+			webhook.TransactionID, webhook.SPTransactionID)
+		if err != nil {
+			log.Printf("[WEBHOOK] Withdraw request not found: %v", err)
+			c.JSON(http.StatusNotFound, gin.H{"error": "withdraw request not found"})
+			return
+		}
+
+		// Idempotency check
+		if wr.Status == WithdrawStatusCompleted || wr.Status == WithdrawStatusFailed {
+			log.Printf("[WEBHOOK] Withdraw already processed: status=%s", wr.Status)
+			db.Exec(`UPDATE payment_webhooks SET processed=TRUE WHERE dmark_transaction_id=$1`, webhook.TransactionID)
+			c.JSON(http.StatusOK, gin.H{"message": "already processed"})
+			return
+		}
+
+		switch webhook.Status {
+		case "Successful":
+			log.Printf("[WEBHOOK] Payout succeeded for withdraw %d", wr.ID)
+			payment.ProcessPayoutSuccess(db, cfg, wr.ID, webhook.StatusCode, webhook.Status)
+		case "Failed":
+			log.Printf("[WEBHOOK] Payout failed for withdraw %d", wr.ID)
+			payment.ProcessPayoutFailed(db, cfg, wr.ID, webhook.StatusCode, webhook.Message)
+		case "Pending":
+			log.Printf("[WEBHOOK] Payout still pending for withdraw %d", wr.ID)
+		default:
+			log.Printf("[WEBHOOK] Unknown payout status '%s' for withdraw %d", webhook.Status, wr.ID)
+		}
+
+		db.Exec(`UPDATE payment_webhooks SET processed=TRUE WHERE dmark_transaction_id=$1`, webhook.TransactionID)
+
+		c.JSON(http.StatusOK, gin.H{"message": "webhook processed"})
+	}
+}
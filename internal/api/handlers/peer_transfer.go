@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
+)
+
+// ReferencePeerTransfer marks ledger rows and transactions for winnings gifted between players
+const ReferencePeerTransfer = "PEER_TRANSFER"
+
+// peerTransferredToday sums what the sender's winnings account has gifted since the start of
+// now's day. Call it inside the transfer's transaction, with the account locked.
+func peerTransferredToday(tx *sqlx.Tx, accountID int, now time.Time) (float64, error) {
+	dayStart, _ := stakeLimitWindows(now)
+	var sent float64
+	err := tx.Get(&sent, `SELECT COALESCE(SUM(amount), 0) FROM account_transactions
+		WHERE debit_account_id=$1 AND reference_type=$2 AND created_at >= $3`, accountID, ReferencePeerTransfer, dayStart)
+	return sent, err
+}
+
+// TransferWinnings sends part of the player's winnings to another player's winnings.
+// Blocked and self-excluded players cannot receive, and each sender has a daily cap.
+// POST /api/v1/me/transfer {"phone": "2567...", "amount": 5000}
+func TransferWinnings(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pidI, ok := c.Get("player_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		pid := pidI.(int)
+
+		var req struct {
+			Phone  string  `json:"phone"`
+			Amount float64 `json:"amount"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+			return
+		}
+		if req.Amount <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid amount"})
+			return
+		}
+		if req.Amount < float64(cfg.PeerTransferMinAmount) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("minimum transfer is %d", cfg.PeerTransferMinAmount)})
+			return
+		}
+		phone := normalizePhone(req.Phone)
+		if phone == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid phone format"})
+			return
+		}
+
+		var recipient struct {
+			ID          int    `db:"id"`
+			DisplayName string `db:"display_name"`
+		}
+		if err := db.Get(&recipient, `SELECT id, COALESCE(display_name, '') AS display_name FROM players WHERE phone_number=$1`, phone); err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no player with that phone number"})
+			return
+		} else if err != nil {
+			log.Printf("[DB] Failed to look up transfer recipient %s: %v", phone, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to look up recipient"})
+			return
+		}
+		if recipient.ID == pid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "you cannot transfer to yourself"})
+			return
+		}
+
+		now := time.Now()
+		block, err := activePlayerBlock(db, recipient.ID, now)
+		if err != nil {
+			log.Printf("[DB] Failed to check block for player %d: %v", recipient.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check recipient"})
+			return
+		}
+		excluded, err := selfExcludedUntil(db, recipient.ID, now)
+		if err != nil {
+			log.Printf("[DB] Failed to check self-exclusion for player %d: %v", recipient.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check recipient"})
+			return
+		}
+		if block != nil || excluded != nil {
+			// Which of the two applies is the recipient's business
+			c.JSON(http.StatusForbidden, gin.H{"error": "this player cannot receive transfers right now"})
+			return
+		}
+
+		from, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read account"})
+			return
+		}
+		to, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &recipient.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read account"})
+			return
+		}
+
+		tx, err := db.Beginx()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+		defer tx.Rollback()
+
+		// Lock both accounts in id order so opposite transfers between two players can't deadlock,
+		// and so the balance and cap checks hold until commit
+		var locked []struct {
+			ID      int     `db:"id"`
+			Balance float64 `db:"balance"`
+		}
+		if err := tx.Select(&locked, `SELECT id, balance FROM accounts WHERE id IN ($1,$2) ORDER BY id FOR UPDATE`, from.ID, to.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+		balance := 0.0
+		for _, a := range locked {
+			if a.ID == from.ID {
+				balance = a.Balance
+			}
+		}
+		if balance < req.Amount {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "insufficient winnings balance"})
+			return
+		}
+		if cfg.PeerTransferDailyLimit > 0 {
+			sent, err := peerTransferredToday(tx, from.ID, now)
+			if err != nil {
+				log.Printf("[DB] Failed to sum transfers for player %d: %v", pid, err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check transfer limit"})
+				return
+			}
+			if sent+req.Amount > float64(cfg.PeerTransferDailyLimit) {
+				c.JSON(http.StatusForbidden, gin.H{
+					"error":           fmt.Sprintf("daily transfer limit of %d UGX reached", cfg.PeerTransferDailyLimit),
+					"daily_remaining": *remaining(cfg.PeerTransferDailyLimit, sent),
+				})
+				return
+			}
+		}
+
+		var txnID int
+		if err := tx.Get(&txnID, `INSERT INTO transactions (player_id, transaction_type, amount, status, created_at, completed_at)
+			VALUES ($1,$2,$3,'COMPLETED',NOW(),NOW()) RETURNING id`, pid, ReferencePeerTransfer, req.Amount); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record transfer"})
+			return
+		}
+		if _, err := tx.Exec(`INSERT INTO transactions (player_id, transaction_type, amount, status, created_at, completed_at)
+			VALUES ($1,$2,$3,'COMPLETED',NOW(),NOW())`, recipient.ID, ReferencePeerTransfer, req.Amount); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record transfer"})
+			return
+		}
+		desc := fmt.Sprintf("Transfer from player %d to player %d", pid, recipient.ID)
+		if err := accounts.Transfer(tx, from.ID, to.ID, req.Amount, ReferencePeerTransfer, sql.NullInt64{Int64: int64(txnID), Valid: true}, desc, cfg.SettlementOverdraftGuard); err != nil {
+			log.Printf("[TRANSFER] %s failed: %v", desc, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to transfer"})
+			return
+		}
+		if err := tx.Commit(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to commit"})
+			return
+		}
+
+		var sender string
+		if err := db.Get(&sender, `SELECT COALESCE(NULLIF(display_name, ''), phone_number) FROM players WHERE id=$1`, pid); err != nil {
+			sender = "A PlayPool player"
+		}
+		sms.EnqueueTemplate(sms.TypePayment, phone, templates.TransferReceived, templates.Params{"Sender": sender, "Amount": int(req.Amount)})
+
+		log.Printf("[TRANSFER] Player %d sent %.2f to player %d (txn %d)", pid, req.Amount, recipient.ID, txnID)
+		c.JSON(http.StatusOK, gin.H{
+			"transaction_id": txnID,
+			"amount":         req.Amount,
+			"recipient_name": recipient.DisplayName,
+			"balance":        balance - req.Amount,
+		})
+	}
+}
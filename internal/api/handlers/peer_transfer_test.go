@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+)
+
+// transferPlayer creates a player holding balance in player_winnings and returns their id and phone
+func transferPlayer(t *testing.T, db *sqlx.DB, n int, balance float64) (int, string) {
+	t.Helper()
+	var pid int
+	phone := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+int64(n))%100000000)
+	if err := db.Get(&pid, `INSERT INTO players (phone_number, display_name) VALUES ($1, $2) RETURNING id`, phone, fmt.Sprintf("Gift%d", n)); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+	acc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
+	if err != nil {
+		t.Fatalf("create winnings account: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE accounts SET balance=$1 WHERE id=$2`, balance, acc.ID); err != nil {
+		t.Fatalf("fund winnings account: %v", err)
+	}
+	return pid, phone
+}
+
+func transferRouter(db *sqlx.DB, cfg *config.Config, pid int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/me/transfer", func(c *gin.Context) { c.Set("player_id", pid) }, TransferWinnings(db, cfg))
+	return r
+}
+
+func TestTransferWinningsMovesBalance(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{PeerTransferMinAmount: 500, PeerTransferDailyLimit: 100000}
+	sender, senderPhone := transferPlayer(t, db, 1, 20000)
+	recipient, phone := transferPlayer(t, db, 2, 1000)
+	r := transferRouter(db, cfg, sender)
+
+	if w := postJSON(r, "/me/transfer", gin.H{"phone": senderPhone, "amount": 1000}); w.Code != http.StatusBadRequest {
+		t.Fatalf("self transfer: status %d, want 400", w.Code)
+	}
+	if w := postJSON(r, "/me/transfer", gin.H{"phone": phone, "amount": 7500}); w.Code != http.StatusOK {
+		t.Fatalf("transfer: status %d body %s", w.Code, w.Body.String())
+	}
+	if got := winningsBalance(t, db, sender); got != 12500 {
+		t.Errorf("sender balance %.2f, want 12500", got)
+	}
+	if got := winningsBalance(t, db, recipient); got != 8500 {
+		t.Errorf("recipient balance %.2f, want 8500", got)
+	}
+	var n int
+	db.Get(&n, `SELECT COUNT(*) FROM transactions WHERE player_id IN ($1,$2) AND transaction_type=$3 AND amount=7500`, sender, recipient, ReferencePeerTransfer)
+	if n != 2 {
+		t.Errorf("%d PEER_TRANSFER transactions, want one per player", n)
+	}
+}
+
+func TestTransferWinningsInsufficientBalance(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{PeerTransferMinAmount: 500}
+	sender, _ := transferPlayer(t, db, 1, 3000)
+	recipient, phone := transferPlayer(t, db, 2, 0)
+
+	if w := postJSON(transferRouter(db, cfg, sender), "/me/transfer", gin.H{"phone": phone, "amount": 5000}); w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d body %s, want 400", w.Code, w.Body.String())
+	}
+	if s, r := winningsBalance(t, db, sender), winningsBalance(t, db, recipient); s != 3000 || r != 0 {
+		t.Errorf("balances %.2f/%.2f after refused transfer, want 3000/0", s, r)
+	}
+}
+
+func TestTransferWinningsDailyCap(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{PeerTransferMinAmount: 500, PeerTransferDailyLimit: 10000}
+	sender, _ := transferPlayer(t, db, 1, 50000)
+	recipient, phone := transferPlayer(t, db, 2, 0)
+	r := transferRouter(db, cfg, sender)
+
+	if w := postJSON(r, "/me/transfer", gin.H{"phone": phone, "amount": 6000}); w.Code != http.StatusOK {
+		t.Fatalf("first transfer: status %d body %s", w.Code, w.Body.String())
+	}
+	if w := postJSON(r, "/me/transfer", gin.H{"phone": phone, "amount": 5000}); w.Code != http.StatusForbidden {
+		t.Fatalf("over cap: status %d body %s, want 403", w.Code, w.Body.String())
+	}
+	if w := postJSON(r, "/me/transfer", gin.H{"phone": phone, "amount": 4000}); w.Code != http.StatusOK {
+		t.Fatalf("up to cap: status %d body %s", w.Code, w.Body.String())
+	}
+	if got := winningsBalance(t, db, recipient); got != 10000 {
+		t.Errorf("recipient balance %.2f, want 10000", got)
+	}
+}
@@ -63,14 +63,14 @@ func CheckPlayerStatus(db *sqlx.DB) gin.HandlerFunc {
 	}
 }
 
-// SetPIN sets or updates a player's PIN
-// Verifies the player has a recently completed game (within 30 min) before allowing PIN setup.
+// SetPIN sets or updates a player's PIN (requires OTP action_token for 'set_pin')
 // POST /api/v1/auth/set-pin
 func SetPIN(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req struct {
-			Phone string `json:"phone"`
-			PIN   string `json:"pin"`
+			Phone       string `json:"phone"`
+			PIN         string `json:"pin"`
+			ActionToken string `json:"action_token"`
 		}
 		if err := c.BindJSON(&req); err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "phone and pin required"})
@@ -79,6 +79,7 @@ func SetPIN(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFunc
 
 		phone := strings.TrimSpace(req.Phone)
 		pin := strings.TrimSpace(req.PIN)
+		actionToken := strings.TrimSpace(req.ActionToken)
 
 		if phone == "" || pin == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "phone and pin required"})
@@ -91,7 +92,37 @@ func SetPIN(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFunc
 			return
 		}
 
-		log.Printf("[SetPIN] Allowing PIN setup for %s (onboarding flow)", phone)
+		// Without proof of the phone, anyone could set a PIN and then PIN-login as that player
+		if actionToken == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "verify by OTP (action set_pin) before setting a PIN"})
+			return
+		}
+
+		// Consume the action token atomically (single-use)
+		tokenHash := sha256.Sum256([]byte(actionToken))
+		tokenHashStr := hex.EncodeToString(tokenHash[:])
+		luaScript := `
+			local payload = redis.call('GET', KEYS[1])
+			if payload then
+				redis.call('DEL', KEYS[1])
+				return payload
+			else
+				return nil
+			end
+		`
+		result, err := rdb.Eval(context.Background(), luaScript, []string{fmt.Sprintf("action_token:%s", tokenHashStr)}).Result()
+		if err != nil || result == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired action token"})
+			return
+		}
+		var tokenPayload struct {
+			Phone  string `json:"phone"`
+			Action string `json:"action"`
+		}
+		if err := json.Unmarshal([]byte(result.(string)), &tokenPayload); err != nil || tokenPayload.Action != "set_pin" || tokenPayload.Phone != phone {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid action token for this operation"})
+			return
+		}
 
 		// Hash PIN with bcrypt
 		pinHash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
@@ -162,81 +193,11 @@ func VerifyPIN(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFu
 
 		ctx := context.Background()
 
-		// Get player with PIN info
-		var player struct {
-			ID                int            `db:"id"`
-			PINHash           sql.NullString `db:"pin_hash"`
-			PINFailedAttempts int            `db:"pin_failed_attempts"`
-			PINLockedUntil    sql.NullTime   `db:"pin_locked_until"`
-		}
-
-		err := db.Get(&player, `
-			SELECT id, pin_hash, pin_failed_attempts, pin_locked_until 
-			FROM players WHERE phone_number=$1
-		`, phone)
-		if err == sql.ErrNoRows {
-			c.JSON(http.StatusNotFound, gin.H{"error": "player not found"})
-			return
-		}
-		if err != nil {
-			log.Printf("VerifyPIN DB error: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
-			return
-		}
-
-		// Check if player has a PIN set
-		if !player.PINHash.Valid || player.PINHash.String == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "no PIN set for this account"})
+		playerID, ok := checkPlayerPIN(c, db, cfg, phone, pin)
+		if !ok {
 			return
 		}
 
-		// Check if account is locked
-		if player.PINLockedUntil.Valid && player.PINLockedUntil.Time.After(time.Now()) {
-			remaining := time.Until(player.PINLockedUntil.Time).Minutes()
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":             "account temporarily locked due to too many failed attempts",
-				"locked_until":      player.PINLockedUntil.Time.Format(time.RFC3339),
-				"minutes_remaining": int(remaining) + 1,
-			})
-			return
-		}
-
-		// Verify PIN with bcrypt
-		err = bcrypt.CompareHashAndPassword([]byte(player.PINHash.String), []byte(pin))
-		if err != nil {
-			// Wrong PIN - increment failed attempts
-			newAttempts := player.PINFailedAttempts + 1
-
-			if newAttempts >= cfg.PINMaxAttempts {
-				// Lock account
-				lockUntil := time.Now().Add(time.Duration(cfg.PINLockoutMinutes) * time.Minute)
-				db.Exec(`
-					UPDATE players 
-					SET pin_failed_attempts = $1, pin_locked_until = $2 
-					WHERE id = $3
-				`, newAttempts, lockUntil, player.ID)
-
-				c.JSON(http.StatusTooManyRequests, gin.H{
-					"error":             "too many failed attempts, account locked",
-					"locked_until":      lockUntil.Format(time.RFC3339),
-					"minutes_remaining": cfg.PINLockoutMinutes,
-				})
-				return
-			}
-
-			db.Exec(`UPDATE players SET pin_failed_attempts = $1 WHERE id = $2`, newAttempts, player.ID)
-
-			attemptsRemaining := cfg.PINMaxAttempts - newAttempts
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error":              "incorrect PIN",
-				"attempts_remaining": attemptsRemaining,
-			})
-			return
-		}
-
-		// PIN correct - reset failed attempts
-		db.Exec(`UPDATE players SET pin_failed_attempts = 0, pin_locked_until = NULL WHERE id = $1`, player.ID)
-
 		// Generate action token (same pattern as OTP action token)
 		tokenBytes := make([]byte, 32)
 		if _, err := rand.Read(tokenBytes); err != nil {
@@ -252,7 +213,7 @@ func VerifyPIN(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFu
 
 		// Store token payload in Redis
 		payload := fmt.Sprintf(`{"phone":"%s","action":"%s","player_id":%d,"created_at":"%s","auth_method":"pin"}`,
-			phone, action, player.ID, time.Now().Format(time.RFC3339))
+			phone, action, playerID, time.Now().Format(time.RFC3339))
 
 		ttl := time.Duration(cfg.PINTokenTTLSeconds) * time.Second
 		if err := rdb.Set(ctx, fmt.Sprintf("action_token:%s", tokenHashStr), payload, ttl).Err(); err != nil {
@@ -272,7 +233,7 @@ func VerifyPIN(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFu
 			sessionToken := hex.EncodeToString(sessionBytes)
 			sessionKey := fmt.Sprintf("player_session:%s", sessionToken)
 			sessionData, _ := json.Marshal(map[string]interface{}{
-				"player_id":  player.ID,
+				"player_id":  playerID,
 				"phone":      phone,
 				"created_at": time.Now().Format(time.RFC3339),
 			})
@@ -293,6 +254,185 @@ func VerifyPIN(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFu
 	}
 }
 
+// checkPlayerPIN verifies phone's PIN, counting wrong guesses towards the PIN_MAX_ATTEMPTS
+// lockout. It returns the player id, or false once it has written the error response.
+func checkPlayerPIN(c *gin.Context, db *sqlx.DB, cfg *config.Config, phone, pin string) (int, bool) {
+	// Get player with PIN info
+	var player struct {
+		ID             int            `db:"id"`
+		PINHash        sql.NullString `db:"pin_hash"`
+		PINLockedUntil sql.NullTime   `db:"pin_locked_until"`
+	}
+
+	err := db.Get(&player, `
+		SELECT id, pin_hash, pin_locked_until 
+		FROM players WHERE phone_number=$1
+	`, phone)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "player not found"})
+		return 0, false
+	}
+	if err != nil {
+		log.Printf("PIN check DB error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return 0, false
+	}
+
+	// Check if player has a PIN set
+	if !player.PINHash.Valid || player.PINHash.String == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no PIN set for this account"})
+		return 0, false
+	}
+
+	// Reserve an attempt before comparing, so parallel guesses can't get past the lockout.
+	// A lock that has run out starts a fresh count.
+	var attempts int
+	err = db.Get(&attempts, `
+		UPDATE players
+		SET pin_failed_attempts = CASE WHEN pin_locked_until < NOW() THEN 1 ELSE pin_failed_attempts + 1 END,
+		    pin_locked_until = CASE WHEN pin_locked_until < NOW() THEN NULL ELSE pin_locked_until END
+		WHERE id = $1 AND (pin_locked_until IS NULL OR pin_locked_until < NOW())
+		  AND (pin_failed_attempts < $2 OR pin_locked_until < NOW())
+		RETURNING pin_failed_attempts
+	`, player.ID, cfg.PINMaxAttempts)
+	if err == sql.ErrNoRows {
+		// Locked, or the remaining attempts are taken by guesses still being checked
+		db.Get(&player.PINLockedUntil, `SELECT pin_locked_until FROM players WHERE id = $1`, player.ID)
+		resp := gin.H{"error": "account temporarily locked due to too many failed attempts"}
+		if player.PINLockedUntil.Valid {
+			resp["locked_until"] = player.PINLockedUntil.Time.Format(time.RFC3339)
+			resp["minutes_remaining"] = int(time.Until(player.PINLockedUntil.Time).Minutes()) + 1
+		}
+		c.JSON(http.StatusTooManyRequests, resp)
+		return 0, false
+	}
+	if err != nil {
+		log.Printf("PIN check attempt reservation error: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return 0, false
+	}
+
+	// Verify PIN with bcrypt
+	if err := bcrypt.CompareHashAndPassword([]byte(player.PINHash.String), []byte(pin)); err != nil {
+		// Wrong PIN - the reserved attempt stays counted
+		if attempts >= cfg.PINMaxAttempts {
+			// Lock account
+			lockUntil := time.Now().Add(time.Duration(cfg.PINLockoutMinutes) * time.Minute)
+			db.Exec(`UPDATE players SET pin_locked_until = $1 WHERE id = $2`, lockUntil, player.ID)
+
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"error":             "too many failed attempts, account locked",
+				"locked_until":      lockUntil.Format(time.RFC3339),
+				"minutes_remaining": cfg.PINLockoutMinutes,
+			})
+			return 0, false
+		}
+
+		attemptsRemaining := cfg.PINMaxAttempts - attempts
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":              "incorrect PIN",
+			"attempts_remaining": attemptsRemaining,
+		})
+		return 0, false
+	}
+
+	// PIN correct - reset failed attempts
+	db.Exec(`UPDATE players SET pin_failed_attempts = 0, pin_locked_until = NULL WHERE id = $1`, player.ID)
+	return player.ID, true
+}
+
+// SetMyPIN stores a PIN for the authenticated player. It needs an OTP action token issued for
+// set_pin or reset_pin (a PIN-derived token or plain JWT is not enough), and consumes it.
+// POST /api/v1/me/set-pin
+func SetMyPIN(db *sqlx.DB, rdb *redis.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pid := c.GetInt("player_id")
+		action := c.GetString("auth_action")
+		if pid == 0 || (action != "set_pin" && action != "reset_pin") || c.GetString("auth_method") == "pin" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "verify by OTP (action set_pin) before setting a PIN"})
+			return
+		}
+
+		var req struct {
+			PIN string `json:"pin"`
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pin required"})
+			return
+		}
+		pin := strings.TrimSpace(req.PIN)
+		if len(pin) != 4 || !isDigits(pin) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "PIN must be exactly 4 digits"})
+			return
+		}
+
+		pinHash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+		if err != nil {
+			log.Printf("SetMyPIN bcrypt error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+		if _, err := db.Exec(`
+			UPDATE players 
+			SET pin_hash = $1, pin_failed_attempts = 0, pin_locked_until = NULL 
+			WHERE id = $2
+		`, string(pinHash), pid); err != nil {
+			log.Printf("SetMyPIN DB error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+
+		// Consume the token (single-use)
+		if key := c.GetString("action_token_key"); key != "" && rdb != nil {
+			rdb.Del(context.Background(), key)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	}
+}
+
+// PINLogin lets a returning player log in with phone + PIN instead of an SMS OTP. Wrong PINs
+// count towards the same lockout as VerifyPIN; success returns the same JWT as VerifyOTP.
+// POST /api/v1/auth/pin-login
+func PINLogin(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Phone string `json:"phone"`
+			PIN   string `json:"pin"`
+		}
+		if err := c.BindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "phone and pin required"})
+			return
+		}
+		phone := strings.TrimSpace(req.Phone)
+		pin := strings.TrimSpace(req.PIN)
+		if phone == "" || pin == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "phone and pin required"})
+			return
+		}
+
+		playerID, ok := checkPlayerPIN(c, db, cfg, phone, pin)
+		if !ok {
+			return
+		}
+
+		var displayName string
+		if err := db.Get(&displayName, `SELECT display_name FROM players WHERE id=$1`, playerID); err != nil {
+			log.Printf("PINLogin DB error: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+		signed, err := issuePlayerJWT(cfg, playerID, phone)
+		if err != nil {
+			log.Printf("Failed to sign token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{"token": signed, "player": gin.H{"id": playerID, "phone": phone, "display_name": displayName}})
+	}
+}
+
 // ResetPIN resets a player's PIN (requires OTP action_token for 'reset_pin')
 // POST /api/v1/auth/reset-pin
 func ResetPIN(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFunc {
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/config"
+)
+
+// pinRouter exposes SetMyPIN as if AuthMiddleware had accepted a token for action, plus SetPIN and PINLogin
+func pinRouter(db *sqlx.DB, cfg *config.Config, pid int, action, method string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/me/set-pin", func(c *gin.Context) {
+		c.Set("player_id", pid)
+		c.Set("auth_action", action)
+		c.Set("auth_method", method)
+	}, SetMyPIN(db, nil))
+	r.POST("/auth/set-pin", SetPIN(db, nil, cfg))
+	r.POST("/auth/pin-login", PINLogin(db, cfg))
+	return r
+}
+
+func TestSetMyPINNeedsOTPActionToken(t *testing.T) {
+	cases := []struct{ name, action, method string }{
+		{"jwt or session", "", ""},
+		{"other otp action", "requeue", ""},
+		{"pin-derived token", "set_pin", "pin"},
+	}
+	for _, tc := range cases {
+		r := pinRouter(nil, &config.Config{}, 7, tc.action, tc.method)
+		if w := postJSON(r, "/me/set-pin", gin.H{"pin": "1234"}); w.Code != http.StatusForbidden {
+			t.Errorf("%s: status %d, want 403", tc.name, w.Code)
+		}
+	}
+}
+
+func TestSetPINByPhoneWithoutOTPCannotLogIn(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", PINMaxAttempts: 3, PINLockoutMinutes: 15}
+
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if _, err := db.Exec(`INSERT INTO players (phone_number, display_name) VALUES ($1, 'Victim')`, phone); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+	r := pinRouter(db, cfg, 0, "", "")
+
+	if w := postJSON(r, "/auth/set-pin", gin.H{"phone": phone, "pin": "1357"}); w.Code != http.StatusUnauthorized {
+		t.Fatalf("set-pin without action token: status %d, want 401", w.Code)
+	}
+	if w := postJSON(r, "/auth/pin-login", gin.H{"phone": phone, "pin": "1357"}); w.Code == http.StatusOK {
+		t.Fatalf("pin-login after unauthenticated set-pin succeeded: %s", w.Body.String())
+	}
+}
+
+func TestPINLoginAndLockout(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", PINMaxAttempts: 3, PINLockoutMinutes: 15}
+
+	var pid int
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if err := db.Get(&pid, `INSERT INTO players (phone_number, display_name) VALUES ($1, 'Pinny') RETURNING id`, phone); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+	r := pinRouter(db, cfg, pid, "set_pin", "")
+
+	if w := postJSON(r, "/me/set-pin", gin.H{"pin": "12a4"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("non-digit PIN: status %d, want 400", w.Code)
+	}
+	if w := postJSON(r, "/me/set-pin", gin.H{"pin": "2468"}); w.Code != http.StatusOK {
+		t.Fatalf("set pin: status %d body %s", w.Code, w.Body.String())
+	}
+
+	w := postJSON(r, "/auth/pin-login", gin.H{"phone": phone, "pin": "2468"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: status %d body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Token  string `json:"token"`
+		Player struct {
+			ID          int    `json:"id"`
+			DisplayName string `json:"display_name"`
+		} `json:"player"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	parsed, err := jwt.Parse(resp.Token, func(*jwt.Token) (interface{}, error) { return []byte(cfg.JWTSecret), nil })
+	if err != nil || !parsed.Valid {
+		t.Fatalf("login token invalid: %v", err)
+	}
+	if claims := parsed.Claims.(jwt.MapClaims); claims["player_id"] != float64(pid) || resp.Player.DisplayName != "Pinny" {
+		t.Fatalf("login for wrong player: claims %v, player %+v", claims, resp.Player)
+	}
+
+	for i := 1; i < cfg.PINMaxAttempts; i++ {
+		if w := postJSON(r, "/auth/pin-login", gin.H{"phone": phone, "pin": "0000"}); w.Code != http.StatusUnauthorized {
+			t.Fatalf("wrong PIN %d: status %d, want 401", i, w.Code)
+		}
+	}
+	if w := postJSON(r, "/auth/pin-login", gin.H{"phone": phone, "pin": "0000"}); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("last wrong PIN: status %d, want 429", w.Code)
+	}
+	if w := postJSON(r, "/auth/pin-login", gin.H{"phone": phone, "pin": "2468"}); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("correct PIN while locked: status %d, want 429", w.Code)
+	}
+
+	// Setting a new PIN after OTP clears the lockout
+	postJSON(r, "/me/set-pin", gin.H{"pin": "1357"})
+	if w := postJSON(r, "/auth/pin-login", gin.H{"phone": phone, "pin": "1357"}); w.Code != http.StatusOK {
+		t.Fatalf("login after reset: status %d body %s", w.Code, w.Body.String())
+	}
+}
+
+func TestConcurrentWrongPINsStopAtLockout(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{JWTSecret: "test-secret", PINMaxAttempts: 3, PINLockoutMinutes: 15}
+
+	var pid int
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if err := db.Get(&pid, `INSERT INTO players (phone_number, display_name) VALUES ($1, 'Racer') RETURNING id`, phone); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+	r := pinRouter(db, cfg, pid, "set_pin", "")
+	if w := postJSON(r, "/me/set-pin", gin.H{"pin": "2468"}); w.Code != http.StatusOK {
+		t.Fatalf("set pin: status %d body %s", w.Code, w.Body.String())
+	}
+
+	const guesses = 20
+	codes := make(chan int, guesses)
+	var wg sync.WaitGroup
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes <- postJSON(r, "/auth/pin-login", gin.H{"phone": phone, "pin": fmt.Sprintf("%04d", i)}).Code
+		}(i)
+	}
+	wg.Wait()
+	close(codes)
+
+	wrong := 0
+	for code := range codes {
+		switch code {
+		case http.StatusUnauthorized:
+			wrong++
+		case http.StatusTooManyRequests:
+		default:
+			t.Errorf("guess status %d, want 401 or 429", code)
+		}
+	}
+	if wrong != cfg.PINMaxAttempts-1 {
+		t.Errorf("%d guesses answered as wrong, want %d before the lockout", wrong, cfg.PINMaxAttempts-1)
+	}
+	var attempts int
+	if err := db.Get(&attempts, `SELECT pin_failed_attempts FROM players WHERE id=$1`, pid); err != nil {
+		t.Fatalf("read attempts: %v", err)
+	}
+	if attempts != cfg.PINMaxAttempts {
+		t.Errorf("%d attempts counted, want %d", attempts, cfg.PINMaxAttempts)
+	}
+	if w := postJSON(r, "/auth/pin-login", gin.H{"phone": phone, "pin": "2468"}); w.Code != http.StatusTooManyRequests {
+		t.Errorf("correct PIN after the burst: status %d, want 429", w.Code)
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/game"
+)
+
+// playerBlock is an admin block in force on a player
+type playerBlock struct {
+	Reason string
+	Until  *time.Time // nil = until an admin unblocks
+}
+
+// activePlayerBlock returns the player's block, or nil if they are not blocked at now.
+// A block whose block_until has passed no longer applies.
+func activePlayerBlock(db *sqlx.DB, playerID int, now time.Time) (*playerBlock, error) {
+	var row struct {
+		Blocked bool           `db:"is_blocked"`
+		Reason  sql.NullString `db:"block_reason"`
+		Until   sql.NullTime   `db:"block_until"`
+	}
+	if err := db.Get(&row, `SELECT COALESCE(is_blocked, FALSE) AS is_blocked, block_reason, block_until FROM players WHERE id=$1`, playerID); err != nil {
+		return nil, err
+	}
+	if !row.Blocked || (row.Until.Valid && !row.Until.Time.After(now)) {
+		return nil, nil
+	}
+	b := &playerBlock{Reason: row.Reason.String}
+	if row.Until.Valid {
+		b.Until = &row.Until.Time
+	}
+	return b, nil
+}
+
+// rejectIfBlocked writes a 403 with the block reason and returns true while an admin block
+// is in force on the player.
+func rejectIfBlocked(c *gin.Context, db *sqlx.DB, playerID int) bool {
+	block, err := activePlayerBlock(db, playerID, time.Now())
+	if err != nil {
+		log.Printf("[DB] Failed to check block for player %d: %v", playerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check account status"})
+		return true
+	}
+	if block == nil {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":        "Your account is blocked: " + block.Reason,
+		"block_reason": block.Reason,
+		"block_until":  block.Until,
+	})
+	return true
+}
+
+// cancelQueuesOfBlockedPlayer cancels every waiting queue entry of a newly blocked player so
+// the matchmaker can't pair them; their stakes are already in their winnings. Returns how many entries were cancelled.
+func cancelQueuesOfBlockedPlayer(db *sqlx.DB, playerID int) int {
+	var queues []struct {
+		ID    int    `db:"id"`
+		Token string `db:"queue_token"`
+	}
+	if err := db.Select(&queues, `SELECT id, queue_token FROM matchmaking_queue WHERE player_id=$1 AND status='queued'`, playerID); err != nil {
+		log.Printf("[ADMIN] Failed to list queues of blocked player %d: %v", playerID, err)
+		return 0
+	}
+	cancelled := 0
+	for _, q := range queues {
+		if game.Manager != nil {
+			game.Manager.LeaveQueue(q.Token)
+		}
+		stake, err := cancelQueueEntry(db, q.ID, playerID, []string{"queued"})
+		if err == errQueueNotCancellable {
+			continue // matched meanwhile
+		}
+		if err != nil {
+			log.Printf("[ADMIN] Failed to cancel queue %d of blocked player %d: %v", q.ID, playerID, err)
+			continue
+		}
+		cancelled++
+		log.Printf("[ADMIN] Cancelled queue %d of blocked player %d (%d UGX stays in winnings)", q.ID, playerID, stake)
+	}
+	return cancelled
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestBlockedPlayerCannotStakeUntilUnblocked(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000, MinStakeAmount: 1000}
+	r, pid := withdrawFixture(t, db, cfg, 100000)
+	asAdmin := func(c *gin.Context) { c.Set("admin_username", "ops") }
+	r.POST("/players/:id/block", asAdmin, AdminBlockPlayer(db))
+	r.POST("/players/:id/unblock", asAdmin, AdminUnblockPlayer(db))
+	r.POST("/game/stake", InitiateStake(db, nil, cfg))
+
+	var phone string
+	if err := db.Get(&phone, `SELECT phone_number FROM players WHERE id=$1`, pid); err != nil {
+		t.Fatalf("read phone: %v", err)
+	}
+
+	// A stake already waiting in the queue; it stays in winnings until a match
+	var qid int
+	if err := db.Get(&qid, `INSERT INTO matchmaking_queue (player_id, phone_number, stake_amount, queue_token, status, created_at, expires_at)
+		VALUES ($1, $2, 5000, $3, 'queued', NOW(), NOW() + INTERVAL '10 minutes') RETURNING id`, pid, phone, fmt.Sprintf("blk-%d", time.Now().UnixNano())); err != nil {
+		t.Fatalf("insert queue: %v", err)
+	}
+	before := winningsBalance(t, db, pid)
+
+	if w := postJSON(r, fmt.Sprintf("/players/%d/block", pid), gin.H{"reason": "chargeback"}); w.Code != http.StatusOK {
+		t.Fatalf("block: status %d body %s", w.Code, w.Body.String())
+	}
+	var status string
+	db.Get(&status, `SELECT status FROM matchmaking_queue WHERE id=$1`, qid)
+	if status != "cancelled" || winningsBalance(t, db, pid) != before {
+		t.Fatalf("queued stake after block: status %s, winnings %.2f (was %.2f), want cancelled and unchanged", status, winningsBalance(t, db, pid), before)
+	}
+
+	w := postJSON(r, "/game/stake", gin.H{"phone_number": phone, "stake_amount": 1000})
+	var resp struct {
+		Reason string `json:"block_reason"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusForbidden || resp.Reason != "chargeback" {
+		t.Fatalf("stake while blocked: status %d body %s, want 403 with reason", w.Code, w.Body.String())
+	}
+
+	if w := postJSON(r, fmt.Sprintf("/players/%d/unblock", pid), gin.H{}); w.Code != http.StatusOK {
+		t.Fatalf("unblock: status %d", w.Code)
+	}
+	if block, err := activePlayerBlock(db, pid, time.Now()); err != nil || block != nil {
+		t.Fatalf("still blocked after unblock: %+v (err %v)", block, err)
+	}
+
+	// A timed block stops applying once block_until passes
+	postJSON(r, fmt.Sprintf("/players/%d/block", pid), gin.H{"reason": "cool off", "duration_hours": 1})
+	if block, _ := activePlayerBlock(db, pid, time.Now()); block == nil || block.Until == nil {
+		t.Fatal("timed block not in force")
+	}
+	if block, _ := activePlayerBlock(db, pid, time.Now().Add(2*time.Hour)); block != nil {
+		t.Fatal("timed block still in force after block_until")
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
+)
+
+// playerGamesFilters maps ?status= to the game_sessions statuses it selects
+var playerGamesFilters = map[string][]string{
+	"active":    {string(game.StatusWaiting), string(game.StatusInProgress)},
+	"completed": {string(game.StatusCompleted)},
+}
+
+// GetPlayerGames lists a player's recent games, newest first, for a "my games" screen.
+// Active games this server holds include the link to resume them.
+// GET /api/v1/player/:phone/games?status=active|completed&limit=20 (own games only)
+func GetPlayerGames(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pidI, ok := c.Get("player_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		phone := normalizePhone(c.Param("phone"))
+		if phone == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid phone format"})
+			return
+		}
+		var playerID int
+		if err := db.Get(&playerID, `SELECT id FROM players WHERE phone_number=$1`, phone); err != nil || playerID != pidI.(int) {
+			// Unknown phones get the same answer so the endpoint can't probe for players
+			c.JSON(http.StatusForbidden, gin.H{"error": "you can only list your own games"})
+			return
+		}
+
+		status := c.Query("status")
+		statuses, ok := playerGamesFilters[status]
+		if status != "" && !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or completed"})
+			return
+		}
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+		if limit <= 0 || limit > 100 {
+			limit = 20
+		}
+
+		var rows []struct {
+			SessionID    int            `db:"id"`
+			GameToken    string         `db:"game_token"`
+			OpponentName sql.NullString `db:"opponent_name"`
+			StakeAmount  float64        `db:"stake_amount"`
+			Status       string         `db:"status"`
+			WinnerID     sql.NullInt64  `db:"winner_id"`
+			CreatedAt    time.Time      `db:"created_at"`
+			CompletedAt  sql.NullTime   `db:"completed_at"`
+			PayoutHold   sql.NullString `db:"payout_hold"`
+			ReleaseAt    sql.NullTime   `db:"release_at"`
+		}
+		if err := db.Select(&rows, `
+			SELECT gs.id, gs.game_token, opp.display_name AS opponent_name, gs.stake_amount, gs.status,
+				gs.winner_id, gs.created_at, gs.completed_at, ph.status AS payout_hold, ph.release_at
+			FROM game_sessions gs
+			LEFT JOIN players opp ON opp.id = CASE WHEN gs.player1_id = $1 THEN gs.player2_id ELSE gs.player1_id END
+			LEFT JOIN payout_holds ph ON ph.session_id = gs.id
+			WHERE (gs.player1_id = $1 OR gs.player2_id = $1)
+			  AND ($2::text[] IS NULL OR gs.status = ANY($2))
+			ORDER BY gs.created_at DESC
+			LIMIT $3`, playerID, pq.Array(statuses), limit); err != nil {
+			log.Printf("[DB] Failed to list games for player %d: %v", playerID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch games"})
+			return
+		}
+
+		games := make([]gin.H, 0, len(rows))
+		for _, r := range rows {
+			g := gin.H{
+				"session_id":    r.SessionID,
+				"game_token":    r.GameToken,
+				"opponent_name": r.OpponentName.String,
+				"stake_amount":  int(r.StakeAmount),
+				"status":        r.Status,
+				"created_at":    r.CreatedAt,
+			}
+			if r.WinnerID.Valid {
+				g["winner_id"] = r.WinnerID.Int64
+				g["won"] = int(r.WinnerID.Int64) == playerID
+			}
+			if r.CompletedAt.Valid {
+				g["completed_at"] = r.CompletedAt.Time
+			}
+			if r.PayoutHold.Valid {
+				// High-stake result: the loser may dispute until release_at
+				g["payout_hold"] = r.PayoutHold.String
+				g["payout_release_at"] = r.ReleaseAt.Time
+			}
+			if r.Status == string(game.StatusWaiting) || r.Status == string(game.StatusInProgress) {
+				if pt := resumeToken(r.GameToken, playerID); pt != "" {
+					g["player_token"] = pt
+					g["game_link"] = cfg.FrontendURL + "/g/" + r.GameToken + "?pt=" + pt
+				}
+			}
+			games = append(games, g)
+		}
+
+		c.JSON(http.StatusOK, gin.H{"games": games})
+	}
+}
+
+// DisputeGameResult lets the loser of a high-stake game flag the result while its payout
+// is held; the winnings then wait for an admin instead of releasing automatically.
+// POST /api/v1/me/games/:id/dispute {"reason": "..."}
+func DisputeGameResult() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pidI, ok := c.Get("player_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		sessionID, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "no held payout for this game"})
+			return
+		}
+		var req struct {
+			Reason string `json:"reason" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil || len(req.Reason) > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required (max 500 characters)"})
+			return
+		}
+		if game.Manager == nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "game manager not initialized"})
+			return
+		}
+
+		switch err := game.Manager.DisputePayout(sessionID, pidI.(int), req.Reason); {
+		case errors.Is(err, game.ErrNoPayoutHold):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, game.ErrDisputeWindowClosed):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case err != nil:
+			log.Printf("[DB] Failed to dispute session %d: %v", sessionID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record dispute"})
+		default:
+			c.JSON(http.StatusOK, gin.H{"status": "disputed", "message": "The payout is on hold until an admin reviews the game."})
+		}
+	}
+}
+
+// resumeToken returns the player's token for a live game, or "" if the game isn't held here
+func resumeToken(gameToken string, playerID int) string {
+	if game.Manager == nil {
+		return ""
+	}
+	g, err := game.Manager.GetGameByToken(gameToken)
+	if err != nil {
+		return ""
+	}
+	for _, p := range []*game.PoolPlayer{g.Player1, g.Player2} {
+		if p != nil && p.DBPlayerID == playerID {
+			return p.PlayerToken
+		}
+	}
+	return ""
+}
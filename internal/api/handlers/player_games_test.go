@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestGetPlayerGamesFiltersByStatus(t *testing.T) {
+	db := testDB(t)
+
+	player := func(n int64, name string) (int, string) {
+		var id int
+		phone := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+n)%100000000)
+		if err := db.Get(&id, `INSERT INTO players (phone_number, display_name) VALUES ($1, $2) RETURNING id`, phone, name); err != nil {
+			t.Fatalf("insert player: %v", err)
+		}
+		return id, phone
+	}
+	me, myPhone := player(0, "Me")
+	opp, _ := player(1, "Rival")
+
+	sessions := map[string]int{}
+	for i, status := range []string{"WAITING", "IN_PROGRESS", "COMPLETED", "CANCELLED"} {
+		var id int
+		var winner sql.NullInt64
+		if status == "COMPLETED" {
+			winner = sql.NullInt64{Int64: int64(opp), Valid: true}
+		}
+		if err := db.Get(&id, `INSERT INTO game_sessions (game_token, player1_id, player2_id, stake_amount, status, winner_id, created_at, expiry_time)
+			VALUES ($1, $2, $3, 1000, $4, $5, $6, NOW() + INTERVAL '3 minutes') RETURNING id`,
+			fmt.Sprintf("mygames-%d-%d", i, time.Now().UnixNano()), opp, me, status, winner, time.Now().Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("insert %s session: %v", status, err)
+		}
+		sessions[status] = id
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/player/:phone/games", func(c *gin.Context) { c.Set("player_id", me) }, GetPlayerGames(db, &config.Config{}))
+	list := func(query string) (int, []map[string]interface{}) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/player/"+myPhone+"/games"+query, nil))
+		var resp struct {
+			Games []map[string]interface{} `json:"games"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Games
+	}
+	ids := func(games []map[string]interface{}) []int {
+		var out []int
+		for _, g := range games {
+			out = append(out, int(g["session_id"].(float64)))
+		}
+		return out
+	}
+
+	code, games := list("?status=active")
+	if code != http.StatusOK || fmt.Sprint(ids(games)) != fmt.Sprint([]int{sessions["IN_PROGRESS"], sessions["WAITING"]}) {
+		t.Fatalf("active: status %d sessions %v", code, ids(games))
+	}
+	if games[0]["opponent_name"] != "Rival" {
+		t.Errorf("opponent_name = %v, want Rival", games[0]["opponent_name"])
+	}
+
+	code, games = list("?status=completed")
+	if code != http.StatusOK || fmt.Sprint(ids(games)) != fmt.Sprint([]int{sessions["COMPLETED"]}) {
+		t.Fatalf("completed: status %d sessions %v", code, ids(games))
+	}
+	if games[0]["won"] != false {
+		t.Errorf("won = %v for a game the opponent won", games[0]["won"])
+	}
+
+	if _, games = list(""); len(games) != 4 {
+		t.Errorf("unfiltered: %d games, want 4", len(games))
+	}
+	if code, _ = list("?status=lost"); code != http.StatusBadRequest {
+		t.Errorf("bad status filter: status %d, want 400", code)
+	}
+}
+
+func TestGetPlayerGamesOwnPhoneOnly(t *testing.T) {
+	db := testDB(t)
+	var other int
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if err := db.Get(&other, `INSERT INTO players (phone_number, display_name) VALUES ($1, 'Other') RETURNING id`, phone); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/player/:phone/games", func(c *gin.Context) { c.Set("player_id", other+1) }, GetPlayerGames(db, &config.Config{}))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/player/"+phone+"/games", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("someone else's games: status %d, want 403", w.Code)
+	}
+}
@@ -1,20 +1,22 @@
 package handlers
 
 import (
-	"context"
 	"crypto/rand"
 	"database/sql"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/playpool/backend/internal/accounts"
 	"github.com/playpool/backend/internal/config"
 	"github.com/playpool/backend/internal/game"
@@ -212,7 +214,7 @@ func UpdateDisplayName(db *sqlx.DB) gin.HandlerFunc {
 }
 
 // GetPlayerProfile returns basic player info (display_name) + player winnings balance and expired queue info if any
-func GetPlayerProfile(db *sqlx.DB) gin.HandlerFunc {
+func GetPlayerProfile(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if db == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "db not available"})
@@ -261,6 +263,11 @@ func GetPlayerProfile(db *sqlx.DB) gin.HandlerFunc {
 		}
 
 		resp := gin.H{"display_name": p.DisplayName, "player_winnings": winningsBalance, "player_token": p.PlayerToken}
+		if allowance, err := loadStakeAllowance(db, cfg, p.ID, time.Now()); err == nil {
+			resp["stake_allowance"] = allowance.response()
+		} else {
+			log.Printf("[DB] Failed to load stake limits for player %d: %v", p.ID, err)
+		}
 		if hasExpired {
 			resp["expired_queue"] = gin.H{"id": expired.ID, "stake_amount": int(expired.StakeAmount), "matchcode": expired.MatchCode, "is_private": expired.IsPrivate}
 		}
@@ -317,6 +324,10 @@ func RequeueStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handle
 			return
 		}
 
+		if rejectIfSelfExcluded(c, db, player.ID) || rejectIfBlocked(c, db, player.ID) {
+			return
+		}
+
 		// Determine stake amount
 		var stakeAmount int
 		if req.QueueID != nil {
@@ -411,16 +422,7 @@ func RequeueStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handle
 			if strings.TrimSpace(req.InvitePhone) != "" && sms.Default != nil {
 				invite := normalizePhone(req.InvitePhone)
 				if invite != "" {
-					smsInviteQueued = true
-					joinLink := fmt.Sprintf("%s/join?matchcode=%s", cfg.FrontendURL, code)
-					go func(code string, invite string, stake int, link string) {
-						msg := fmt.Sprintf("Join my PlayPool match!\nCode: %s\nStake: %d UGX\n\n%s", code, stake, link)
-						if msgID, err := sms.SendSMS(context.Background(), invite, msg); err != nil {
-							log.Printf("[SMS] Failed to send invite to %s on requeue: %v", invite, err)
-						} else {
-							log.Printf("[SMS] Invite sent to %s msg_id=%s", invite, msgID)
-						}
-					}(code, invite, stakeAmount, joinLink)
+					smsInviteQueued = sendInviteSMS(rdb, cfg, invite, code, stakeAmount)
 				}
 			}
 
@@ -476,7 +478,29 @@ func RequeueStake(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.Handle
 	}
 }
 
-// CancelQueue cancels an active or expired queue and refunds the stake to player's winnings
+// cancellableQueueStatuses are the queue states CancelQueue may cancel
+var cancellableQueueStatuses = []string{"queued", "processing", "matching", "expired"}
+
+// errQueueNotCancellable means the queue left the allowed states (e.g. it was matched) before the cancel
+var errQueueNotCancellable = errors.New("queue can no longer be cancelled")
+
+// cancelQueueEntry marks the player's queue cancelled and returns its stake. No money moves: an
+// unmatched player's net stake never left their winnings (it only reaches escrow at match time,
+// see reserveStakeForSession). The status check is part of the update, so a queue the matchmaker
+// claims concurrently is left to the match.
+func cancelQueueEntry(db *sqlx.DB, queueID, pid int, statuses []string) (int, error) {
+	var stake float64
+	err := db.Get(&stake, `UPDATE matchmaking_queue SET status='cancelled' WHERE id=$1 AND player_id=$2 AND status = ANY($3) RETURNING stake_amount`, queueID, pid, pq.Array(statuses))
+	if err == sql.ErrNoRows {
+		return 0, errQueueNotCancellable
+	}
+	if err != nil {
+		return 0, fmt.Errorf("update queue status: %w", err)
+	}
+	return int(stake), nil
+}
+
+// CancelQueue cancels an active or expired queue; the stake stays in the player's winnings
 func CancelQueue(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		queueIDStr := c.Param("id")
@@ -515,67 +539,87 @@ func CancelQueue(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		if queue.Status != "queued" && queue.Status != "processing" && queue.Status != "matching" && queue.Status != "expired" {
+		if !slices.Contains(cancellableQueueStatuses, queue.Status) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "queue is not active"})
 			return
 		}
 
-		// Start transaction
-		tx, err := db.Beginx()
-		if err != nil {
-			log.Printf("[CANCEL] Failed to start transaction: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel queue"})
+		stakeAmount, err := cancelQueueEntry(db, queueID, pid, cancellableQueueStatuses)
+		if err == errQueueNotCancellable {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "queue is not active"})
 			return
 		}
-		defer tx.Rollback()
-
-		// Update queue status to cancelled
-		_, err = tx.Exec(`UPDATE matchmaking_queue SET status='cancelled' WHERE id=$1`, queueID)
 		if err != nil {
-			log.Printf("[CANCEL] Failed to update queue status: %v", err)
+			log.Printf("[CANCEL] Failed to cancel queue %d: %v", queueID, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel queue"})
 			return
 		}
 
-		// Refund stake to player's winnings account
-		stakeAmount := int(queue.StakeAmount)
-		escrowAccount, err := accounts.GetOrCreateAccount(db, accounts.AccountEscrow, nil)
-		if err != nil {
-			log.Printf("[CANCEL] Failed to get escrow account: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refund stake"})
-			return
+		log.Printf("[CANCEL] Cancelled queue %d for player %d (%d UGX stays in winnings)", queueID, pid, stakeAmount)
+
+		c.JSON(http.StatusOK, gin.H{"message": "Queue cancelled. Your stake is in your winnings."})
+	}
+}
+
+// LeaveQueue lets a queued player walk away before being matched (e.g. on tab close, via
+// navigator.sendBeacon). The queue token identifies the entry, so no session is needed.
+// The stake never left the player's winnings, so nothing is refunded; a matched player is refused.
+func LeaveQueue(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			QueueToken string `json:"queue_token"`
 		}
-		playerAccount, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
-		if err != nil {
-			log.Printf("[CANCEL] Failed to get player account: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refund stake"})
-			return
+		c.ShouldBindJSON(&req)
+		if req.QueueToken == "" {
+			req.QueueToken = c.Query("queue_token")
 		}
-		err = accounts.Transfer(tx, escrowAccount.ID, playerAccount.ID, float64(stakeAmount), "queue_cancel", sql.NullInt64{Int64: int64(queueID), Valid: true}, fmt.Sprintf("Cancelled queue %d", queueID))
-		if err != nil {
-			log.Printf("[CANCEL] Failed to refund stake: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refund stake"})
+		if req.QueueToken == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "queue_token required"})
 			return
 		}
 
-		// Insert escrow ledger entry
-		_, err = tx.Exec(`INSERT INTO escrow_ledger (entry_type, amount, player_id, balance_after, description, created_at) VALUES ('REFUND', $1, $2, 0.0, $3, NOW())`, float64(stakeAmount), pid, fmt.Sprintf("Queue %d cancelled", queueID))
+		var queue struct {
+			ID       int    `db:"id"`
+			PlayerID int    `db:"player_id"`
+			Status   string `db:"status"`
+		}
+		err := db.Get(&queue, `SELECT id, player_id, status FROM matchmaking_queue WHERE queue_token=$1`, req.QueueToken)
 		if err != nil {
-			log.Printf("[CANCEL] Failed to insert ledger entry: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record refund"})
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusNotFound, gin.H{"error": "queue not found"})
+				return
+			}
+			log.Printf("[LEAVE] Failed to fetch queue: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch queue"})
 			return
 		}
 
-		// Commit transaction
-		err = tx.Commit()
+		// Drop the in-memory entry too so the legacy matcher can't pair it
+		if game.Manager != nil {
+			game.Manager.LeaveQueue(req.QueueToken)
+		}
+
+		stakeAmount, err := cancelQueueEntry(db, queue.ID, queue.PlayerID, []string{"queued"})
+		if err == errQueueNotCancellable {
+			// Re-read: the matchmaker may have claimed the entry since the lookup
+			var status string
+			if db.Get(&status, `SELECT status FROM matchmaking_queue WHERE id=$1`, queue.ID) == nil {
+				queue.Status = status
+			}
+			if queue.Status == "matched" || queue.Status == "matching" {
+				c.JSON(http.StatusConflict, gin.H{"error": "already matched", "status": queue.Status})
+				return
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"error": "queue is not active", "status": queue.Status})
+			return
+		}
 		if err != nil {
-			log.Printf("[CANCEL] Failed to commit transaction: %v", err)
+			log.Printf("[LEAVE] Failed to cancel queue %d: %v", queue.ID, err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel queue"})
 			return
 		}
 
-		log.Printf("[CANCEL] Cancelled queue %d for player %d, refunded %d UGX", queueID, pid, stakeAmount)
-
-		c.JSON(http.StatusOK, gin.H{"message": "Queue cancelled and stake refunded"})
+		log.Printf("[LEAVE] Player %d left queue %d (%d UGX stays in winnings)", queue.PlayerID, queue.ID, stakeAmount)
+		c.JSON(http.StatusOK, gin.H{"message": "Left queue. Your stake is in your winnings.", "stake_amount": stakeAmount})
 	}
 }
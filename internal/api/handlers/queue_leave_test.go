@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+)
+
+// stakedFixture stakes 2000 out of a funded player's winnings through InitiateStake, leaving
+// them queued, and returns the router, player, queue token and winnings after the stake
+func stakedFixture(t *testing.T, db *sqlx.DB) (*gin.Engine, int, string, float64) {
+	t.Helper()
+	cfg := &config.Config{MinWithdrawAmount: 1000, MinStakeAmount: 1000}
+	r, pid := withdrawFixture(t, db, cfg, 10000)
+	r.POST("/game/stake", InitiateStake(db, nil, cfg))
+	r.POST("/queue/leave", LeaveQueue(db))
+
+	var phone string
+	if err := db.Get(&phone, `SELECT phone_number FROM players WHERE id=$1`, pid); err != nil {
+		t.Fatalf("read phone: %v", err)
+	}
+	w := postJSON(r, "/game/stake", gin.H{"phone_number": phone, "stake_amount": 2000})
+	var resp struct {
+		Status     string `json:"status"`
+		QueueToken string `json:"queue_token"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.Status != "queued" || resp.QueueToken == "" {
+		t.Fatalf("stake: status %d body %s", w.Code, w.Body.String())
+	}
+	return r, pid, resp.QueueToken, winningsBalance(t, db, pid)
+}
+
+func queueStatus(t *testing.T, db *sqlx.DB, token string) string {
+	var status string
+	if err := db.Get(&status, `SELECT status FROM matchmaking_queue WHERE queue_token=$1`, token); err != nil {
+		t.Fatalf("read queue status: %v", err)
+	}
+	return status
+}
+
+func TestLeaveQueueKeepsWinningsUnchanged(t *testing.T) {
+	db := testDB(t)
+	r, pid, token, staked := stakedFixture(t, db)
+
+	if w := postJSON(r, "/queue/leave", gin.H{"queue_token": token}); w.Code != http.StatusOK {
+		t.Fatalf("leave: status %d body %s", w.Code, w.Body.String())
+	}
+	if got := queueStatus(t, db, token); got != "cancelled" {
+		t.Errorf("queue status %q, want cancelled", got)
+	}
+	// The queued stake never left winnings, so leaving must not add anything
+	if bal := winningsBalance(t, db, pid); bal != staked {
+		t.Errorf("winnings %.2f after leaving, want %.2f", bal, staked)
+	}
+	var refunds int
+	if err := db.Get(&refunds, `SELECT COUNT(*) FROM escrow_ledger WHERE player_id=$1 AND entry_type=$2`, pid, accounts.LedgerRefund); err != nil {
+		t.Fatalf("read ledger: %v", err)
+	}
+	if refunds != 0 {
+		t.Errorf("%d refund ledger rows for an unmatched queue", refunds)
+	}
+
+	if w := postJSON(r, "/queue/leave", gin.H{"queue_token": token}); w.Code != http.StatusBadRequest {
+		t.Errorf("second leave: status %d, want 400", w.Code)
+	}
+	if bal := winningsBalance(t, db, pid); bal != staked {
+		t.Errorf("winnings after second leave %.2f, want %.2f", bal, staked)
+	}
+}
+
+func TestLeaveQueueRejectedAfterMatch(t *testing.T) {
+	db := testDB(t)
+	r, pid, token, staked := stakedFixture(t, db)
+	if _, err := db.Exec(`UPDATE matchmaking_queue SET status='matched' WHERE queue_token=$1`, token); err != nil {
+		t.Fatalf("mark matched: %v", err)
+	}
+
+	if w := postJSON(r, "/queue/leave", gin.H{"queue_token": token}); w.Code != http.StatusConflict {
+		t.Fatalf("leave after match: status %d body %s, want 409", w.Code, w.Body.String())
+	}
+	if got := queueStatus(t, db, token); got != "matched" {
+		t.Errorf("queue status %q, want matched", got)
+	}
+	if bal := winningsBalance(t, db, pid); bal != staked {
+		t.Errorf("matched player's winnings moved to %.2f", bal)
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+)
+
+// selfExclusionPeriods are the cool-off periods a player can choose
+var selfExclusionPeriods = map[string]time.Duration{
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+	"30d": 30 * 24 * time.Hour,
+}
+
+// selfExcludedUntil returns when the player's self-exclusion ends, or nil if none is active at now
+func selfExcludedUntil(db *sqlx.DB, playerID int, now time.Time) (*time.Time, error) {
+	var until sql.NullTime
+	if err := db.Get(&until, `SELECT self_excluded_until FROM players WHERE id=$1`, playerID); err != nil {
+		return nil, err
+	}
+	if !until.Valid || !until.Time.After(now) {
+		return nil, nil
+	}
+	return &until.Time, nil
+}
+
+// rejectIfSelfExcluded writes a 403 and returns true while the player is self-excluded.
+// Only staking is blocked; withdrawals stay available.
+func rejectIfSelfExcluded(c *gin.Context, db *sqlx.DB, playerID int) bool {
+	until, err := selfExcludedUntil(db, playerID, time.Now())
+	if err != nil {
+		log.Printf("[DB] Failed to check self-exclusion for player %d: %v", playerID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check account status"})
+		return true
+	}
+	if until == nil {
+		return false
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":               fmt.Sprintf("You have paused staking until %s", until.Format("02 Jan 2006 15:04")),
+		"self_excluded_until": until,
+	})
+	return true
+}
+
+// SelfExclude lets an authenticated player block their own staking for 24h, 7d or 30d.
+// An exclusion can be extended but never shortened or lifted early.
+func SelfExclude(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pidI, ok := c.Get("player_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		pid := pidI.(int)
+
+		var req struct {
+			Duration string `json:"duration" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duration is required (24h, 7d or 30d)"})
+			return
+		}
+		period, ok := selfExclusionPeriods[req.Duration]
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duration must be 24h, 7d or 30d"})
+			return
+		}
+
+		now := time.Now()
+		until := now.Add(period)
+
+		// Only move the end forward; an earlier end than the current one is refused
+		var current sql.NullTime
+		err := db.Get(&current, `
+			UPDATE players SET self_excluded_until = $1
+			WHERE id = $2 AND (self_excluded_until IS NULL OR self_excluded_until <= $1)
+			RETURNING self_excluded_until`, until, pid)
+		if err == sql.ErrNoRows {
+			existing, _ := selfExcludedUntil(db, pid, now)
+			c.JSON(http.StatusConflict, gin.H{"error": "an existing self-exclusion ends later and cannot be shortened", "self_excluded_until": existing})
+			return
+		}
+		if err != nil {
+			log.Printf("[DB] Failed to set self-exclusion for player %d: %v", pid, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set self-exclusion"})
+			return
+		}
+
+		log.Printf("[INFO] Player %d self-excluded for %s (until %s)", pid, req.Duration, until.Format(time.RFC3339))
+		c.JSON(http.StatusOK, gin.H{"ok": true, "self_excluded_until": current.Time})
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestSelfExclusionBlocksStakingButNotWithdrawals(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000, MinStakeAmount: 1000}
+	r, pid := withdrawFixture(t, db, cfg, 100000)
+	r.POST("/me/self-exclude", func(c *gin.Context) { c.Set("player_id", pid) }, SelfExclude(db))
+	r.POST("/game/stake", InitiateStake(db, nil, cfg))
+
+	var phone string
+	if err := db.Get(&phone, `SELECT phone_number FROM players WHERE id=$1`, pid); err != nil {
+		t.Fatalf("read phone: %v", err)
+	}
+
+	if w := postJSON(r, "/me/self-exclude", gin.H{"duration": "2h"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("unsupported duration: status %d", w.Code)
+	}
+	if w := postJSON(r, "/me/self-exclude", gin.H{"duration": "7d"}); w.Code != http.StatusOK {
+		t.Fatalf("self-exclude: status %d body %s", w.Code, w.Body.String())
+	}
+
+	// Staking is refused while the exclusion runs
+	w := postJSON(r, "/game/stake", gin.H{"phone_number": phone, "stake_amount": 1000})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("stake during exclusion: status %d body %s", w.Code, w.Body.String())
+	}
+
+	// It cannot be shortened, only extended
+	if w := postJSON(r, "/me/self-exclude", gin.H{"duration": "24h"}); w.Code != http.StatusConflict {
+		t.Fatalf("shortening: status %d, want 409", w.Code)
+	}
+	if w := postJSON(r, "/me/self-exclude", gin.H{"duration": "30d"}); w.Code != http.StatusOK {
+		t.Fatalf("extending: status %d", w.Code)
+	}
+
+	// Withdrawals still work
+	if _, status := requestWithdraw(t, r, 20000); status != WithdrawStatusPending {
+		t.Fatalf("withdraw during exclusion: status %s", status)
+	}
+
+	// Once it has expired staking is allowed again
+	if _, err := db.Exec(`UPDATE players SET self_excluded_until = NOW() - INTERVAL '1 minute' WHERE id=$1`, pid); err != nil {
+		t.Fatalf("expire exclusion: %v", err)
+	}
+	until, err := selfExcludedUntil(db, pid, time.Now())
+	if err != nil || until != nil {
+		t.Fatalf("expired exclusion still active: %v (err %v)", until, err)
+	}
+}
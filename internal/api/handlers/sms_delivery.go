@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/sms"
+)
+
+// SMSDeliveryReport is the DMark delivery-report callback (the dlr_url sent with each SMS).
+// It moves the logged message to DELIVERED or FAILED; interim reports are acknowledged and ignored.
+// POST /api/v1/webhooks/dmark/sms?token=... (JSON or form: msg_id, status)
+func SMSDeliveryReport(db *sqlx.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cfg.SMSDeliveryReportToken != "" && subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(cfg.SMSDeliveryReportToken)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+
+		var report struct {
+			MsgID     string `json:"msg_id" form:"msg_id"`
+			MessageID string `json:"message_id" form:"message_id"`
+			Status    string `json:"status" form:"status"`
+		}
+		if err := c.ShouldBind(&report); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid payload"})
+			return
+		}
+		providerID := report.MsgID
+		if providerID == "" {
+			providerID = report.MessageID
+		}
+		if providerID == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "msg_id is required"})
+			return
+		}
+
+		status := sms.DeliveryStatus(report.Status)
+		if status == "" {
+			c.JSON(http.StatusOK, gin.H{"message": "ignored"})
+			return
+		}
+		found, err := sms.UpdateDeliveryStatus(c.Request.Context(), db, providerID, status)
+		if err != nil {
+			log.Printf("[SMS] Failed to record delivery report for %s: %v", providerID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record report"})
+			return
+		}
+		if !found {
+			// Not ours (or logged before this table existed); acknowledge so DMark stops retrying
+			log.Printf("[SMS] Delivery report for unknown message %s (%s)", providerID, report.Status)
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "recorded", "status": status})
+	}
+}
+
+// GetAdminSMSLog lists recent outbound SMS and their delivery state, newest first, for
+// chasing "I never got my match link" complaints. ?phone= matches the end of the number.
+func GetAdminSMSLog(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := c.DefaultQuery("status", "all")
+		phone := strings.TrimSpace(c.Query("phone"))
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if limit <= 0 || limit > 200 {
+			limit = 50
+		}
+
+		type smsRow struct {
+			ID            int     `db:"id" json:"id"`
+			Type          string  `db:"sms_type" json:"type"`
+			Phone         string  `db:"phone_number" json:"phone"`
+			Segments      int     `db:"segments" json:"segments"`
+			EstimatedCost float64 `db:"estimated_cost" json:"estimated_cost"`
+			ProviderID    *string `db:"provider_message_id" json:"provider_message_id"`
+			Status        string  `db:"status" json:"status"`
+			CreatedAt     string  `db:"created_at" json:"created_at"`
+			UpdatedAt     string  `db:"updated_at" json:"updated_at"`
+			TotalCount    int     `db:"total_count" json:"-"`
+		}
+
+		var rows []smsRow
+		err := db.Select(&rows, `
+			SELECT id, sms_type, phone_number, segments, estimated_cost, provider_message_id, status,
+				to_char(created_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"') as created_at,
+				to_char(updated_at, 'YYYY-MM-DD"T"HH24:MI:SS"Z"') as updated_at,
+				COUNT(*) OVER() as total_count
+			FROM sms_messages
+			WHERE ($1 = 'all' OR status = UPPER($1))
+			  AND ($2 = '' OR phone_number LIKE '%' || $2)
+			ORDER BY created_at DESC
+			LIMIT $3 OFFSET $4
+		`, status, phone, limit, offset)
+		if err != nil {
+			log.Printf("[ADMIN] Failed to fetch SMS log: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch SMS log"})
+			return
+		}
+
+		total := 0
+		if len(rows) > 0 {
+			total = rows[0].TotalCount
+		}
+
+		c.JSON(http.StatusOK, gin.H{"messages": rows, "total": total, "limit": limit, "offset": offset})
+	}
+}
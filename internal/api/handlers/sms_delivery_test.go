@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestSMSDeliveryReportNeedsToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/webhooks/dmark/sms", SMSDeliveryReport(nil, &config.Config{SMSDeliveryReportToken: "s3cret"}))
+
+	for _, path := range []string{"/webhooks/dmark/sms", "/webhooks/dmark/sms?token=wrong"} {
+		if w := postJSON(r, path, gin.H{"msg_id": "m1", "status": "DELIVRD"}); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status %d, want 401", path, w.Code)
+		}
+	}
+	// Interim statuses are acknowledged without touching the log
+	if w := postJSON(r, "/webhooks/dmark/sms?token=s3cret", gin.H{"msg_id": "m1", "status": "ACCEPTD"}); w.Code != http.StatusOK {
+		t.Errorf("interim report: status %d, want 200", w.Code)
+	}
+}
+
+func TestSMSDeliveryReportUpdatesLog(t *testing.T) {
+	db := testDB(t)
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/webhooks/dmark/sms", SMSDeliveryReport(db, &config.Config{}))
+
+	delivered := fmt.Sprintf("dlr-ok-%d", time.Now().UnixNano())
+	failed := fmt.Sprintf("dlr-fail-%d", time.Now().UnixNano())
+	for _, id := range []string{delivered, failed} {
+		if _, err := db.Exec(`INSERT INTO sms_messages (sms_type, phone_number, segments, estimated_cost, provider_message_id) VALUES ('match', '256700000001', 1, 25, $1)`, id); err != nil {
+			t.Fatalf("insert sms: %v", err)
+		}
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM sms_messages WHERE provider_message_id IN ($1, $2)`, delivered, failed) })
+
+	if w := postJSON(r, "/webhooks/dmark/sms", gin.H{"msg_id": delivered, "status": "DELIVRD"}); w.Code != http.StatusOK {
+		t.Fatalf("json report: status %d body %s", w.Code, w.Body.String())
+	}
+	// DMark may post the report as a form
+	form := url.Values{"message_id": {failed}, "status": {"UNDELIV"}}
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/dmark/sms", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("form report: status %d body %s", w.Code, w.Body.String())
+	}
+
+	for id, want := range map[string]string{delivered: "DELIVERED", failed: "FAILED"} {
+		var status string
+		if err := db.Get(&status, `SELECT status FROM sms_messages WHERE provider_message_id=$1`, id); err != nil || status != want {
+			t.Errorf("message %s: status %q err %v, want %s", id, status, err, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/middleware"
+)
+
+func TestIdempotencyConcurrentDuplicatesRunHandlerOnce(t *testing.T) {
+	db := testDB(t)
+	rdb := testRedis(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000, MinStakeAmount: 1000}
+	r, pid := withdrawFixture(t, db, cfg, 10000)
+	r.POST("/game/stake", middleware.Idempotency(rdb, time.Minute), InitiateStake(db, nil, cfg))
+
+	var phone string
+	if err := db.Get(&phone, `SELECT phone_number FROM players WHERE id=$1`, pid); err != nil {
+		t.Fatalf("read phone: %v", err)
+	}
+	key := fmt.Sprintf("stake-%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		ctx := context.Background()
+		if keys, err := rdb.Keys(ctx, "idempotency:*"+key).Result(); err == nil && len(keys) > 0 {
+			rdb.Del(ctx, keys...)
+		}
+	})
+	stake := func() *httptest.ResponseRecorder {
+		b, _ := json.Marshal(gin.H{"phone_number": phone, "stake_amount": 2000})
+		req := httptest.NewRequest(http.MethodPost, "/game/stake", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(middleware.IdempotencyHeader, key)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	var wg sync.WaitGroup
+	codes := make([]int, 5)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			codes[i] = stake().Code
+		}(i)
+	}
+	wg.Wait()
+
+	count := func(query string) int {
+		var n int
+		if err := db.Get(&n, query, pid); err != nil {
+			t.Fatalf("count rows: %v", err)
+		}
+		return n
+	}
+	if n := count(`SELECT COUNT(*) FROM matchmaking_queue WHERE player_id=$1`); n != 1 {
+		t.Fatalf("%d matchmaking_queue rows, want 1 (codes=%v)", n, codes)
+	}
+	if n := count(`SELECT COUNT(*) FROM transactions WHERE player_id=$1`); n != 1 {
+		t.Fatalf("%d transactions, want 1 (codes=%v)", n, codes)
+	}
+
+	// A later retry replays the first response without staking again
+	if w := stake(); w.Code != http.StatusOK || w.Header().Get("Idempotent-Replay") != "true" {
+		t.Fatalf("retry: status %d replay=%q, want replayed 200", w.Code, w.Header().Get("Idempotent-Replay"))
+	}
+	if n := count(`SELECT COUNT(*) FROM matchmaking_queue WHERE player_id=$1`); n != 1 {
+		t.Fatalf("retry staked again: %d queue rows", n)
+	}
+}
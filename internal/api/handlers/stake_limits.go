@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/config"
+)
+
+// stakeAllowance is how much a player has staked against their daily and monthly limits.
+// Amounts are what the player paid (stake plus commission); a zero limit means unlimited.
+type stakeAllowance struct {
+	DailyLimit   int
+	MonthlyLimit int
+	StakedToday  float64
+	StakedMonth  float64
+}
+
+// stakeLimitWindows returns the start of the day and month containing now, in now's location
+func stakeLimitWindows(now time.Time) (dayStart, monthStart time.Time) {
+	y, m, d := now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+}
+
+// loadStakeAllowance applies the player's overrides to the global limits and sums their
+// stakes so far this day and month. Pending payins count so a burst of stakes cannot
+// slip past the limit before they settle.
+func loadStakeAllowance(db *sqlx.DB, cfg *config.Config, playerID int, now time.Time) (*stakeAllowance, error) {
+	a := &stakeAllowance{DailyLimit: cfg.DailyStakeLimit, MonthlyLimit: cfg.MonthlyStakeLimit}
+
+	var overrides struct {
+		Daily   sql.NullInt64 `db:"daily_stake_limit"`
+		Monthly sql.NullInt64 `db:"monthly_stake_limit"`
+	}
+	if err := db.Get(&overrides, `SELECT daily_stake_limit, monthly_stake_limit FROM players WHERE id=$1`, playerID); err != nil {
+		return nil, err
+	}
+	if overrides.Daily.Valid {
+		a.DailyLimit = int(overrides.Daily.Int64)
+	}
+	if overrides.Monthly.Valid {
+		a.MonthlyLimit = int(overrides.Monthly.Int64)
+	}
+	if a.DailyLimit <= 0 && a.MonthlyLimit <= 0 {
+		return a, nil
+	}
+
+	dayStart, monthStart := stakeLimitWindows(now)
+	var sums struct {
+		Today float64 `db:"today"`
+		Month float64 `db:"month"`
+	}
+	if err := db.Get(&sums, `
+		SELECT COALESCE(SUM(amount) FILTER (WHERE created_at >= $2), 0) AS today,
+		       COALESCE(SUM(amount), 0) AS month
+		FROM transactions
+		WHERE player_id=$1
+		  AND transaction_type IN ('STAKE','STAKE_WINNINGS')
+		  AND status IN ('PENDING','COMPLETED')
+		  AND created_at >= $3 AND created_at <= $4`,
+		playerID, dayStart, monthStart, now); err != nil {
+		return nil, err
+	}
+	a.StakedToday, a.StakedMonth = sums.Today, sums.Month
+	return a, nil
+}
+
+// remaining returns what is left under a limit, or nil when the limit is off
+func remaining(limit int, staked float64) *float64 {
+	if limit <= 0 {
+		return nil
+	}
+	left := math.Max(float64(limit)-staked, 0)
+	return &left
+}
+
+// check returns an error describing the first limit that amount would exceed
+func (a *stakeAllowance) check(amount float64) error {
+	if a.DailyLimit > 0 && a.StakedToday+amount > float64(a.DailyLimit) {
+		return fmt.Errorf("daily stake limit of %d UGX reached (%.0f UGX left today)", a.DailyLimit, *remaining(a.DailyLimit, a.StakedToday))
+	}
+	if a.MonthlyLimit > 0 && a.StakedMonth+amount > float64(a.MonthlyLimit) {
+		return fmt.Errorf("monthly stake limit of %d UGX reached (%.0f UGX left this month)", a.MonthlyLimit, *remaining(a.MonthlyLimit, a.StakedMonth))
+	}
+	return nil
+}
+
+// response is the allowance as shown on the player profile (nil remaining = unlimited)
+func (a *stakeAllowance) response() map[string]interface{} {
+	return map[string]interface{}{
+		"daily_limit":       a.DailyLimit,
+		"daily_remaining":   remaining(a.DailyLimit, a.StakedToday),
+		"monthly_limit":     a.MonthlyLimit,
+		"monthly_remaining": remaining(a.MonthlyLimit, a.StakedMonth),
+	}
+}
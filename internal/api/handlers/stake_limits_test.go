@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestStakeAllowanceCheck(t *testing.T) {
+	cases := []struct {
+		name    string
+		a       stakeAllowance
+		amount  float64
+		wantErr string
+	}{
+		{"unlimited", stakeAllowance{StakedToday: 1e9, StakedMonth: 1e9}, 5000, ""},
+		{"under daily", stakeAllowance{DailyLimit: 10000, StakedToday: 4000}, 6000, ""},
+		{"over daily", stakeAllowance{DailyLimit: 10000, StakedToday: 4000}, 6001, "daily stake limit of 10000 UGX reached (6000 UGX left today)"},
+		{"under monthly", stakeAllowance{MonthlyLimit: 50000, StakedMonth: 45000}, 5000, ""},
+		{"over monthly", stakeAllowance{DailyLimit: 10000, MonthlyLimit: 50000, StakedMonth: 46000}, 5000, "monthly stake limit"},
+	}
+	for _, tc := range cases {
+		err := tc.a.check(tc.amount)
+		if tc.wantErr == "" && err != nil {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+			t.Errorf("%s: got %v, want %q", tc.name, err, tc.wantErr)
+		}
+	}
+
+	resp := (&stakeAllowance{DailyLimit: 10000, StakedToday: 12000}).response()
+	if left := resp["daily_remaining"].(*float64); left == nil || *left != 0 {
+		t.Errorf("daily_remaining = %v, want 0", resp["daily_remaining"])
+	}
+	if resp["monthly_remaining"].(*float64) != nil {
+		t.Error("monthly_remaining should be nil (unlimited)")
+	}
+}
+
+func TestStakeAllowanceAcrossBoundaries(t *testing.T) {
+	db := testDB(t)
+
+	var pid int
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if err := db.Get(&pid, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+	stake := func(typ, status string, amount float64, at time.Time) {
+		if _, err := db.Exec(`INSERT INTO transactions (player_id, transaction_type, amount, status, created_at) VALUES ($1,$2,$3,$4,$5)`,
+			pid, typ, amount, status, at); err != nil {
+			t.Fatalf("insert transaction: %v", err)
+		}
+	}
+
+	// Just after midnight on the 1st: yesterday's stakes count for neither the day nor the month
+	now := time.Date(2026, time.March, 1, 0, 30, 0, 0, time.Local)
+	stake("STAKE", "COMPLETED", 9000, now.Add(-time.Hour))
+	stake("STAKE_WINNINGS", "COMPLETED", 3000, now.Add(-10*time.Minute))
+	stake("STAKE", "PENDING", 2000, now.Add(-5*time.Minute))
+	stake("STAKE", "FAILED", 7000, now.Add(-5*time.Minute))
+
+	cfg := &config.Config{DailyStakeLimit: 10000, MonthlyStakeLimit: 20000}
+	a, err := loadStakeAllowance(db, cfg, pid, now)
+	if err != nil {
+		t.Fatalf("loadStakeAllowance: %v", err)
+	}
+	if a.StakedToday != 5000 || a.StakedMonth != 5000 {
+		t.Fatalf("staked today=%.0f month=%.0f, want 5000/5000", a.StakedToday, a.StakedMonth)
+	}
+	if err := a.check(5000); err != nil {
+		t.Errorf("5000 more should fit today's limit: %v", err)
+	}
+	if err := a.check(5001); err == nil || !strings.Contains(err.Error(), "daily") {
+		t.Errorf("5001 more should exceed the daily limit, got %v", err)
+	}
+
+	// Later in the month the day resets but the month keeps counting
+	later := time.Date(2026, time.March, 20, 12, 0, 0, 0, time.Local)
+	stake("STAKE", "COMPLETED", 12000, later.Add(-24*time.Hour))
+	a, err = loadStakeAllowance(db, cfg, pid, later)
+	if err != nil {
+		t.Fatalf("loadStakeAllowance: %v", err)
+	}
+	if a.StakedToday != 0 || a.StakedMonth != 17000 {
+		t.Fatalf("staked today=%.0f month=%.0f, want 0/17000", a.StakedToday, a.StakedMonth)
+	}
+	if err := a.check(4000); err == nil || !strings.Contains(err.Error(), "monthly") {
+		t.Errorf("4000 should exceed the monthly limit, got %v", err)
+	}
+
+	// A per-player override replaces the global limit
+	if _, err := db.Exec(`UPDATE players SET monthly_stake_limit=0 WHERE id=$1`, pid); err != nil {
+		t.Fatalf("set override: %v", err)
+	}
+	a, err = loadStakeAllowance(db, cfg, pid, later)
+	if err != nil {
+		t.Fatalf("loadStakeAllowance: %v", err)
+	}
+	if err := a.check(4000); err != nil {
+		t.Errorf("monthly limit overridden to unlimited, got %v", err)
+	}
+}
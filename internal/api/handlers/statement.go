@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/accounts"
+)
+
+// statementEntry is one movement on a player's winnings account, with the balance it left
+type statementEntry struct {
+	ID            int       `db:"id" json:"id"`
+	CreatedAt     time.Time `db:"created_at" json:"created_at"`
+	ReferenceType string    `db:"reference_type" json:"reference_type"`
+	ReferenceID   *int64    `db:"reference_id" json:"reference_id,omitempty"`
+	Description   string    `db:"description" json:"description"`
+	Amount        float64   `db:"amount" json:"amount"` // credit positive, debit negative
+	BalanceAfter  float64   `db:"balance_after" json:"balance_after"`
+	TotalCount    int       `db:"total_count" json:"-"`
+}
+
+// statementQuery lists the movements on account $1, oldest first. Balances are worked back
+// from the account's current balance so they match what the player sees now, then the
+// from/to dates ($2, $3, inclusive, empty = open) and the page are applied.
+const statementQuery = `
+	WITH moves AS (
+		SELECT id, created_at, reference_type, reference_id, COALESCE(description, '') AS description,
+			CASE WHEN credit_account_id = $1 THEN amount ELSE -amount END AS amount
+		FROM account_transactions
+		WHERE debit_account_id = $1 OR credit_account_id = $1
+	), running AS (
+		SELECT *, (SELECT balance FROM accounts WHERE id = $1)
+			- COALESCE(SUM(amount) OVER (ORDER BY created_at DESC, id DESC ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING), 0) AS balance_after
+		FROM moves
+	)
+	SELECT id, created_at, reference_type, reference_id, description, amount, balance_after, COUNT(*) OVER() AS total_count
+	FROM running
+	WHERE ($2 = '' OR created_at >= $2::date)
+		AND ($3 = '' OR created_at < ($3::date + interval '1 day'))
+	ORDER BY created_at, id
+	LIMIT $4 OFFSET $5`
+
+// GetMyStatement returns the player's account statement: every stake, payout, refund, deposit,
+// transfer and withdrawal through their winnings account, with the running balance.
+// GET /api/v1/me/statement?from=2025-01-01&to=2025-01-31&limit=50&offset=0
+func GetMyStatement(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pidI, ok := c.Get("player_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		pid := pidI.(int)
+
+		from, to := c.Query("from"), c.Query("to")
+		for _, d := range []string{from, to} {
+			if _, err := time.Parse("2006-01-02", d); d != "" && err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be dates (YYYY-MM-DD)"})
+				return
+			}
+		}
+		limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+		if limit <= 0 || limit > 200 {
+			limit = 50
+		}
+		offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+		if offset < 0 {
+			offset = 0
+		}
+
+		acc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to read account"})
+			return
+		}
+
+		entries := []statementEntry{}
+		if err := db.Select(&entries, statementQuery, acc.ID, from, to, limit, offset); err != nil {
+			log.Printf("[DB] Failed to build statement for player %d: %v", pid, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch statement"})
+			return
+		}
+		total := 0
+		if len(entries) > 0 {
+			total = entries[0].TotalCount
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"balance": acc.Balance,
+			"entries": entries,
+			"total":   total,
+			"limit":   limit,
+			"offset":  offset,
+		})
+	}
+}
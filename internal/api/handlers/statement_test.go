@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/accounts"
+)
+
+func TestStatementRunningBalanceAndDateRange(t *testing.T) {
+	db := testDB(t)
+	pid, _ := transferPlayer(t, db, 1, 14000)
+	other, _ := transferPlayer(t, db, 2, 0)
+
+	acc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
+	if err != nil {
+		t.Fatalf("winnings account: %v", err)
+	}
+	otherAcc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &other)
+	if err != nil {
+		t.Fatalf("other winnings account: %v", err)
+	}
+	sett, err := accounts.GetOrCreateAccount(db, accounts.AccountSettlement, nil)
+	if err != nil {
+		t.Fatalf("settlement account: %v", err)
+	}
+	seed := func(debit, credit int, amount float64, ref, at string) {
+		if _, err := db.Exec(`INSERT INTO account_transactions (debit_account_id, credit_account_id, amount, reference_type, description, created_at)
+			VALUES ($1, $2, $3, $4, $4, $5::timestamp)`, debit, credit, amount, ref, at); err != nil {
+			t.Fatalf("seed %s: %v", ref, err)
+		}
+	}
+	seed(sett.ID, acc.ID, 10000, "TRANSACTION", "2024-03-01 09:00:00")
+	seed(acc.ID, sett.ID, 5000, "SESSION", "2024-03-05 18:30:00")
+	seed(sett.ID, otherAcc.ID, 7000, "TRANSACTION", "2024-03-06 12:00:00") // another player's
+	seed(sett.ID, acc.ID, 9000, "SESSION", "2024-03-10 20:00:00")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/me/statement", func(c *gin.Context) { c.Set("player_id", pid) }, GetMyStatement(db))
+	statement := func(query string) []statementEntry {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/me/statement"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("statement%s: status %d body %s", query, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Entries []statementEntry `json:"entries"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Entries
+	}
+
+	all := statement("")
+	want := []struct{ amount, balance float64 }{{10000, 10000}, {-5000, 5000}, {9000, 14000}}
+	if len(all) != len(want) {
+		t.Fatalf("%d entries, want %d (own movements only): %+v", len(all), len(want), all)
+	}
+	for i, w := range want {
+		if all[i].Amount != w.amount || all[i].BalanceAfter != w.balance {
+			t.Errorf("entry %d: amount %.0f balance %.0f, want %.0f/%.0f", i, all[i].Amount, all[i].BalanceAfter, w.amount, w.balance)
+		}
+	}
+
+	// The range keeps balances from the full history and includes the whole of the to day
+	ranged := statement("?from=2024-03-02&to=2024-03-05")
+	if len(ranged) != 1 || ranged[0].Amount != -5000 || ranged[0].BalanceAfter != 5000 {
+		t.Fatalf("ranged statement = %+v, want only the 5000 stake leaving 5000", ranged)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/me/statement?from=March", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("bad date: status %d, want 400", w.Code)
+	}
+}
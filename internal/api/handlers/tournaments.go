@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
+)
+
+// CreateTournament opens a single-elimination tournament for registration (auth required).
+// It starts when max_players have joined; entry fees are paid from winnings.
+func CreateTournament(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pidI, ok := c.Get("player_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		pid := pidI.(int)
+
+		var req struct {
+			Name       string `json:"name" binding:"required"`
+			EntryFee   int    `json:"entry_fee" binding:"required"`
+			MaxPlayers int    `json:"max_players" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name, entry_fee and max_players are required"})
+			return
+		}
+		req.Name = strings.TrimSpace(req.Name)
+		if req.Name == "" || len(req.Name) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name must be 1-100 characters"})
+			return
+		}
+		if req.EntryFee < cfg.MinStakeAmount {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "entry fee is below the minimum stake", "min_entry_fee": cfg.MinStakeAmount})
+			return
+		}
+		if req.MaxPlayers < 2 || req.MaxPlayers > game.TournamentMaxPlayers {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "max_players must be between 2 and " + strconv.Itoa(game.TournamentMaxPlayers)})
+			return
+		}
+
+		t, err := game.Tournaments.Create(req.Name, req.EntryFee, req.MaxPlayers, pid)
+		if err != nil {
+			log.Printf("[TOURNAMENT] Failed to create tournament: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create tournament"})
+			return
+		}
+		c.JSON(http.StatusCreated, t)
+	}
+}
+
+// JoinTournament registers the authenticated player, paying the entry fee from winnings
+func JoinTournament(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pidI, ok := c.Get("player_id")
+		if !ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		pid := pidI.(int)
+
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tournament id"})
+			return
+		}
+
+		// Entry fees are stakes; self-excluded players cannot pay them
+		if rejectIfSelfExcluded(c, db, pid) || rejectIfBlocked(c, db, pid) {
+			return
+		}
+
+		t, err := game.Tournaments.Join(id, pid)
+		switch {
+		case errors.Is(err, game.ErrTournamentNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, game.ErrTournamentNotOpen), errors.Is(err, game.ErrTournamentAlreadyJoined):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, game.ErrInsufficientWinnings):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err != nil:
+			log.Printf("[TOURNAMENT] Player %d failed to join tournament %d: %v", pid, id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to join tournament"})
+		default:
+			c.JSON(http.StatusOK, gin.H{"ok": true, "tournament": t})
+		}
+	}
+}
+
+// GetTournamentBracket returns a tournament, its entrants and (once started) the bracket by round
+func GetTournamentBracket() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := strconv.Atoi(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tournament id"})
+			return
+		}
+
+		t, err := game.Tournaments.Get(id)
+		if errors.Is(err, game.ErrTournamentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		if err != nil {
+			log.Printf("[TOURNAMENT] Failed to load tournament %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load tournament"})
+			return
+		}
+		entrants, err := game.Tournaments.Entrants(id)
+		if err != nil {
+			log.Printf("[TOURNAMENT] Failed to load entrants of tournament %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load tournament"})
+			return
+		}
+		bracket, err := game.Tournaments.Bracket(id)
+		if err != nil {
+			log.Printf("[TOURNAMENT] Failed to load bracket of tournament %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load tournament"})
+			return
+		}
+
+		rounds := [][]*game.BracketMatch{}
+		if bracket != nil {
+			rounds = bracket.Matches
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"tournament": t,
+			"players":    entrants,
+			"rounds":     rounds,
+		})
+	}
+}
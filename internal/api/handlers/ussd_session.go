@@ -219,7 +219,7 @@ func (h *USSDSessionHandler) playMatch(inputString string) (string, string) {
 		h.session.Data["stake"] = stake
 		h.session.MethodLevel = "confirm_stake"
 
-		commission := h.cfg.CommissionFlat
+		commission := h.cfg.Commission(stake)
 		total := stake + commission
 		potentialWin := (stake * 2) - commission
 
@@ -1,6 +1,10 @@
 package handlers
 
 import (
+	"database/sql"
+	"log"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/playpool/backend/internal/config"
@@ -8,7 +12,44 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-// HandleGameWebSocket handles real-time game communication
+// HandleGameWebSocket handles real-time game communication.
+// With SPECTATOR_AUTH_REQUIRED, spectators must be logged in: a player session cookie, or a
+// JWT as a Bearer header or ?access_token= (browsers can't set headers on a WebSocket).
 func HandleGameWebSocket(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) gin.HandlerFunc {
-	return ws.HandleWebSocket
+	auth := AuthMiddleware(cfg, rdb)
+	return func(c *gin.Context) {
+		if cfg.SpectatorAuthRequired && c.Query("spectate") == "true" {
+			if c.GetHeader("Authorization") == "" && c.Query("access_token") != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+c.Query("access_token"))
+			}
+			auth(c)
+			if c.IsAborted() {
+				return
+			}
+		}
+		ws.HandleWebSocket(c)
+	}
+}
+
+// WatchQueue opens the waiting-screen socket for a queue entry: it gets opponent_found the
+// moment the entry is matched. Entries no longer waiting get 409 with their status, so the
+// client can go straight to /queue/status.
+// GET /api/v1/queue/:id/watch (:id is the queue token)
+func WatchQueue(db *sqlx.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.Param("id")
+		var status string
+		if err := db.Get(&status, `SELECT status FROM matchmaking_queue WHERE queue_token=$1 ORDER BY created_at DESC LIMIT 1`, token); err != nil {
+			if err != sql.ErrNoRows {
+				log.Printf("[DB] Failed to look up queue %s for watch: %v", token, err)
+			}
+			c.JSON(http.StatusNotFound, gin.H{"error": "queue entry not found"})
+			return
+		}
+		if status != "queued" && status != "processing" && status != "matching" {
+			c.JSON(http.StatusConflict, gin.H{"error": "queue entry is no longer waiting", "status": status})
+			return
+		}
+		ws.HandleQueueWatch(c, token)
+	}
 }
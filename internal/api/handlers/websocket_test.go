@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestSpectatorAuthOption(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	prev := game.Manager
+	game.Manager = game.NewGameManager(nil, nil, &config.Config{})
+	t.Cleanup(func() { game.Manager = prev })
+
+	g, err := game.Manager.CreateTestPoolGame("256700000001", "256700000002", 1000, false)
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+	cfg := &config.Config{JWTSecret: "test-secret"}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"player_id": 7}).SignedString([]byte(cfg.JWTSecret))
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+
+	// Nothing listens here: action-token lookups fail, so only the JWT can authenticate
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	t.Cleanup(func() { rdb.Close() })
+
+	r := gin.New()
+	r.GET("/ws", HandleGameWebSocket(nil, rdb, cfg))
+	spectate := func(query string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ws?spectate=true&token="+g.Token+query, nil))
+		return w.Code
+	}
+
+	// Requests reaching the upgrader fail with 400 (not a WebSocket handshake); refused ones with 401
+	cfg.SpectatorAuthRequired = false
+	if code := spectate(""); code == http.StatusUnauthorized {
+		t.Fatal("anonymous spectator refused with the option off")
+	}
+
+	cfg.SpectatorAuthRequired = true
+	if code := spectate(""); code != http.StatusUnauthorized {
+		t.Fatalf("anonymous spectator: status %d, want 401", code)
+	}
+	if code := spectate("&access_token=forged"); code != http.StatusUnauthorized {
+		t.Fatalf("forged token: status %d, want 401", code)
+	}
+	if code := spectate("&access_token=" + signed); code == http.StatusUnauthorized {
+		t.Fatal("logged-in spectator refused")
+	}
+
+	// Players still join with their player token only
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ws?token="+g.Token+"&pt="+g.Player1.PlayerToken, nil))
+	if w.Code == http.StatusUnauthorized {
+		t.Fatal("player connection refused by the spectator option")
+	}
+}
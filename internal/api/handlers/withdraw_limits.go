@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/playpool/backend/internal/config"
+)
+
+// withdrawInFlight are the statuses of requests whose money has not gone out yet
+var withdrawInFlight = []string{WithdrawStatusPendingReview, WithdrawStatusPending, WithdrawStatusProcessing}
+
+// withdrawThrottle checks the player's recent withdrawals against the cooldown and the cap on
+// unpaid requests. It returns why a new request must wait and for how long (0 = until one
+// of the pending requests completes), or "" if it may go ahead. Failed requests don't count.
+func withdrawThrottle(db *sqlx.DB, cfg *config.Config, playerID int, now time.Time) (string, time.Duration, error) {
+	if cfg.MaxPendingWithdrawals > 0 {
+		var pending int
+		if err := db.Get(&pending, `SELECT COUNT(*) FROM withdraw_requests WHERE player_id=$1 AND status = ANY($2)`, playerID, pq.Array(withdrawInFlight)); err != nil {
+			return "", 0, err
+		}
+		if pending >= cfg.MaxPendingWithdrawals {
+			return fmt.Sprintf("you already have %d withdrawals in progress; wait for one to complete", pending), 0, nil
+		}
+	}
+
+	if cfg.WithdrawCooldownMinutes > 0 {
+		var last sql.NullTime
+		if err := db.Get(&last, `SELECT MAX(created_at) FROM withdraw_requests WHERE player_id=$1 AND status <> $2`, playerID, WithdrawStatusFailed); err != nil {
+			return "", 0, err
+		}
+		if last.Valid {
+			if wait := last.Time.Add(time.Duration(cfg.WithdrawCooldownMinutes) * time.Minute).Sub(now); wait > 0 {
+				wait = wait.Round(time.Second)
+				return fmt.Sprintf("you can withdraw again in %s", wait), wait, nil
+			}
+		}
+	}
+	return "", 0, nil
+}
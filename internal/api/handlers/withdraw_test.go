@@ -0,0 +1,384 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/payment"
+)
+
+func TestWithdrawInitialStatus(t *testing.T) {
+	cases := []struct {
+		name   string
+		limit  int
+		amount float64
+		want   string
+	}{
+		{"review disabled", 0, 5000000, WithdrawStatusPending},
+		{"below limit", 50000, 20000, WithdrawStatusPending},
+		{"at limit", 50000, 50000, WithdrawStatusPending},
+		{"above limit", 50000, 50001, WithdrawStatusPendingReview},
+	}
+	for _, tc := range cases {
+		cfg := &config.Config{WithdrawAutoApproveLimit: tc.limit}
+		if got := withdrawInitialStatus(cfg, tc.amount); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.name, got, tc.want)
+		}
+	}
+}
+
+// testDB connects to TEST_DATABASE_URL (a migrated schema); tests that need Postgres are skipped when it is unset
+func testDB(t *testing.T) *sqlx.DB {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Skipf("postgres unavailable: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// withdrawFixture creates a player holding `balance` in player_winnings and a router
+// exposing the player withdraw endpoint and the admin review endpoints.
+func withdrawFixture(t *testing.T, db *sqlx.DB, cfg *config.Config, balance float64) (*gin.Engine, int) {
+	gin.SetMode(gin.TestMode)
+
+	var pid int
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if err := db.Get(&pid, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+	acc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
+	if err != nil {
+		t.Fatalf("create winnings account: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE accounts SET balance=$1 WHERE id=$2`, balance, acc.ID); err != nil {
+		t.Fatalf("fund winnings account: %v", err)
+	}
+
+	r := gin.New()
+	r.POST("/me/withdraw", func(c *gin.Context) { c.Set("player_id", pid) }, RequestWithdraw(db, cfg))
+	asAdmin := func(c *gin.Context) { c.Set("admin_username", "ops") }
+	r.POST("/withdrawals/:id/approve", asAdmin, AdminApproveWithdrawal(db, cfg))
+	r.POST("/withdrawals/:id/reject", asAdmin, AdminRejectWithdrawal(db))
+	return r, pid
+}
+
+func postJSON(r *gin.Engine, path string, body interface{}) *httptest.ResponseRecorder {
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func requestWithdraw(t *testing.T, r *gin.Engine, amount float64) (int, string) {
+	w := postJSON(r, "/me/withdraw", gin.H{"amount": amount, "method": "mtn", "destination": "256700000001"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("withdraw: status %d body %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		RequestID int    `json:"request_id"`
+		Status    string `json:"status"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode withdraw response: %v", err)
+	}
+	return resp.RequestID, resp.Status
+}
+
+func winningsBalance(t *testing.T, db *sqlx.DB, pid int) float64 {
+	acc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
+	if err != nil {
+		t.Fatalf("read winnings: %v", err)
+	}
+	return acc.Balance
+}
+
+func TestWithdrawBelowLimitIsAutoApproved(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000, WithdrawAutoApproveLimit: 50000}
+	r, pid := withdrawFixture(t, db, cfg, 100000)
+
+	id, status := requestWithdraw(t, r, 20000)
+	if status != WithdrawStatusPending {
+		t.Fatalf("expected %s, got %s", WithdrawStatusPending, status)
+	}
+	var stored string
+	if err := db.Get(&stored, `SELECT status FROM withdraw_requests WHERE id=$1`, id); err != nil || stored != WithdrawStatusPending {
+		t.Fatalf("stored status %q (err %v)", stored, err)
+	}
+	if bal := winningsBalance(t, db, pid); bal != 80000 {
+		t.Fatalf("expected 80000 left in winnings, got %.2f", bal)
+	}
+}
+
+func TestWithdrawAboveLimitIsQueuedForReview(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000, WithdrawAutoApproveLimit: 50000}
+	r, _ := withdrawFixture(t, db, cfg, 100000)
+
+	id, status := requestWithdraw(t, r, 60000)
+	if status != WithdrawStatusPendingReview {
+		t.Fatalf("expected %s, got %s", WithdrawStatusPendingReview, status)
+	}
+
+	w := postJSON(r, "/withdrawals/"+strconv.Itoa(id)+"/approve", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("approve: status %d body %s", w.Code, w.Body.String())
+	}
+	var row struct {
+		Status     string  `db:"status"`
+		ApprovedBy *string `db:"approved_by"`
+	}
+	if err := db.Get(&row, `SELECT status, approved_by FROM withdraw_requests WHERE id=$1`, id); err != nil {
+		t.Fatalf("read request: %v", err)
+	}
+	if row.Status != WithdrawStatusPending || row.ApprovedBy == nil || *row.ApprovedBy != "ops" {
+		t.Fatalf("unexpected request after approval: %+v", row)
+	}
+}
+
+func TestAdminRejectRefundsHeldWithdraw(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000, WithdrawAutoApproveLimit: 50000}
+	r, pid := withdrawFixture(t, db, cfg, 100000)
+
+	id, status := requestWithdraw(t, r, 60000)
+	if status != WithdrawStatusPendingReview {
+		t.Fatalf("expected %s, got %s", WithdrawStatusPendingReview, status)
+	}
+	if bal := winningsBalance(t, db, pid); bal != 40000 {
+		t.Fatalf("expected funds reserved, winnings %.2f", bal)
+	}
+
+	w := postJSON(r, "/withdrawals/"+strconv.Itoa(id)+"/reject", gin.H{"reason": "kyc"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("reject: status %d body %s", w.Code, w.Body.String())
+	}
+	if bal := winningsBalance(t, db, pid); bal != 100000 {
+		t.Fatalf("expected full refund to winnings, got %.2f", bal)
+	}
+	var stored string
+	if err := db.Get(&stored, `SELECT status FROM withdraw_requests WHERE id=$1`, id); err != nil || stored != WithdrawStatusFailed {
+		t.Fatalf("stored status %q (err %v)", stored, err)
+	}
+
+	// Rejecting again must not refund twice
+	if w := postJSON(r, "/withdrawals/"+strconv.Itoa(id)+"/reject", gin.H{"reason": "kyc"}); w.Code == http.StatusOK {
+		t.Fatal("second rejection succeeded")
+	}
+	if bal := winningsBalance(t, db, pid); bal != 100000 {
+		t.Fatalf("double refund: winnings %.2f", bal)
+	}
+}
+
+// useMockPayout points payment.Default at a fake DMarkPay that accepts every payout as dm-<sp id>
+func useMockPayout(t *testing.T) {
+	useMockPayoutServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			SPTransactionID string `json:"sp_transaction_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprintf(w, `{"status":"Pending","status_code":"1","transaction_id":"dm-%s","sp_transaction_id":"%s"}`, req.SPTransactionID, req.SPTransactionID)
+	})
+}
+
+// useMockPayoutServer points payment.Default at a fake DMarkPay whose payout endpoint is payout
+func useMockPayoutServer(t *testing.T, payout http.HandlerFunc) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/o/token/" {
+			w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+			return
+		}
+		payout(w, r)
+	}))
+	prev := payment.Default
+	payment.SetDefault(payment.NewClient(&config.Config{
+		DMarkPayBaseURL: srv.URL, DMarkPayTokenURL: "/o/token/", DMarkPayUsername: "user",
+		DMarkPayPassword: "pass", DMarkPayAccountCode: "acc", DMarkPayWallet: "dmark", DMarkPayTimeout: 5,
+	}, nil))
+	t.Cleanup(func() {
+		payment.SetDefault(prev)
+		srv.Close()
+	})
+}
+
+// startPayout creates a withdraw for a funded player and sends it to the mock provider
+func startPayout(t *testing.T, db *sqlx.DB, cfg *config.Config) (r *gin.Engine, pid, reqID int, dmarkID string) {
+	r, pid = withdrawFixture(t, db, cfg, 100000)
+	r.POST("/webhooks/dmark/payout", DMarkPayoutWebhook(db, cfg))
+
+	// Without a payment client the request stays PENDING; then run the payout inline so the test is deterministic
+	reqID, _ = requestWithdraw(t, r, 30000)
+	useMockPayout(t)
+	processWithdrawPayout(db, cfg, reqID, 30000)
+
+	var status string
+	if err := db.QueryRowx(`SELECT status, dmark_transaction_id FROM withdraw_requests WHERE id=$1`, reqID).Scan(&status, &dmarkID); err != nil {
+		t.Fatalf("read request: %v", err)
+	}
+	if status != WithdrawStatusProcessing {
+		t.Fatalf("expected %s after initiation, got %s", WithdrawStatusProcessing, status)
+	}
+	return r, pid, reqID, dmarkID
+}
+
+func payoutCallback(t *testing.T, r *gin.Engine, cfg *config.Config, dmarkID, status string) {
+	body := []byte(fmt.Sprintf(`{"transaction_id":%q,"status":%q,"status_code":"0","message":"callback"}`, dmarkID, status))
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/dmark/payout", bytes.NewReader(body))
+	req.Header.Set(WebhookSignatureHeader, signWebhookBody(cfg.DMarkPayWebhookSecret, body))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("payout webhook: status %d body %s", w.Code, w.Body.String())
+	}
+}
+
+func TestPayoutWebhookSuccessCompletesWithdraw(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000, DMarkPayWebhookSecret: "s3cret"}
+	r, pid, reqID, dmarkID := startPayout(t, db, cfg)
+
+	payoutCallback(t, r, cfg, dmarkID, "Successful")
+	// Duplicate delivery must not settle twice
+	payoutCallback(t, r, cfg, dmarkID, "Successful")
+
+	var stored string
+	if err := db.Get(&stored, `SELECT status FROM withdraw_requests WHERE id=$1`, reqID); err != nil || stored != WithdrawStatusCompleted {
+		t.Fatalf("stored status %q (err %v)", stored, err)
+	}
+	var payouts int
+	db.Get(&payouts, `SELECT COUNT(*) FROM account_transactions WHERE reference_type='WITHDRAW' AND reference_id=$1`, reqID)
+	if payouts != 1 {
+		t.Fatalf("expected one payout ledger entry, got %d", payouts)
+	}
+	if bal := winningsBalance(t, db, pid); bal != 70000 {
+		t.Fatalf("winnings should stay debited, got %.2f", bal)
+	}
+}
+
+func TestPayoutWebhookFailureRefundsWinnings(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000, DMarkPayWebhookSecret: "s3cret"}
+	r, pid, reqID, dmarkID := startPayout(t, db, cfg)
+
+	payoutCallback(t, r, cfg, dmarkID, "Failed")
+	payoutCallback(t, r, cfg, dmarkID, "Failed")
+
+	var stored string
+	if err := db.Get(&stored, `SELECT status FROM withdraw_requests WHERE id=$1`, reqID); err != nil || stored != WithdrawStatusFailed {
+		t.Fatalf("stored status %q (err %v)", stored, err)
+	}
+	if bal := winningsBalance(t, db, pid); bal != 100000 {
+		t.Fatalf("expected refund to winnings, got %.2f", bal)
+	}
+}
+
+func TestPayoutServerErrorLeavesWithdrawProcessing(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000}
+	r, pid := withdrawFixture(t, db, cfg, 100000)
+
+	reqID, _ := requestWithdraw(t, r, 30000)
+	useMockPayoutServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(`{"status":"Error","message":"upstream timeout"}`))
+	})
+	processWithdrawPayout(db, cfg, reqID, 30000)
+
+	var stored string
+	if err := db.Get(&stored, `SELECT status FROM withdraw_requests WHERE id=$1`, reqID); err != nil || stored != WithdrawStatusProcessing {
+		t.Fatalf("stored status %q (err %v), want %s", stored, err, WithdrawStatusProcessing)
+	}
+	if bal := winningsBalance(t, db, pid); bal != 70000 {
+		t.Fatalf("payout of unknown outcome was refunded: winnings %.2f", bal)
+	}
+}
+
+func TestWithdrawCooldown(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000, WithdrawCooldownMinutes: 10}
+	r, pid := withdrawFixture(t, db, cfg, 100000)
+
+	requestWithdraw(t, r, 5000)
+	w := postJSON(r, "/me/withdraw", gin.H{"amount": 5000, "method": "mtn", "destination": "256700000001"})
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("within cooldown: status %d body %s, want 429", w.Code, w.Body.String())
+	}
+	var resp struct {
+		RetryAfter int `json:"retry_after_seconds"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.RetryAfter <= 0 || resp.RetryAfter > 600 {
+		t.Fatalf("retry_after_seconds = %d, want within the 10 minute cooldown", resp.RetryAfter)
+	}
+
+	// Once the cooldown has passed the next request goes through
+	if _, err := db.Exec(`UPDATE withdraw_requests SET created_at = created_at - INTERVAL '11 minutes' WHERE player_id=$1`, pid); err != nil {
+		t.Fatalf("backdate request: %v", err)
+	}
+	requestWithdraw(t, r, 5000)
+}
+
+func TestWithdrawPendingCap(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000, MaxPendingWithdrawals: 2}
+	r, pid := withdrawFixture(t, db, cfg, 100000)
+
+	// No payment client: both requests stay PENDING
+	first, _ := requestWithdraw(t, r, 5000)
+	requestWithdraw(t, r, 5000)
+	if w := postJSON(r, "/me/withdraw", gin.H{"amount": 5000, "method": "mtn", "destination": "256700000001"}); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("third pending: status %d body %s, want 429", w.Code, w.Body.String())
+	}
+	if bal := winningsBalance(t, db, pid); bal != 90000 {
+		t.Fatalf("refused request reserved funds: winnings %.2f", bal)
+	}
+
+	if _, err := db.Exec(`UPDATE withdraw_requests SET status=$2 WHERE id=$1`, first, WithdrawStatusCompleted); err != nil {
+		t.Fatalf("complete request: %v", err)
+	}
+	requestWithdraw(t, r, 5000)
+}
+
+func TestPayoutRecordsTransactionIDBeforeProviderCall(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{MinWithdrawAmount: 1000}
+	r, _ := withdrawFixture(t, db, cfg, 100000)
+
+	reqID, _ := requestWithdraw(t, r, 30000)
+	var seenStatus, seenTxn, sentTxn string
+	useMockPayoutServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			SPTransactionID string `json:"sp_transaction_id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		sentTxn = req.SPTransactionID
+		// A crash during the call must still leave a row the webhook and checker can match
+		db.QueryRowx(`SELECT status, COALESCE(provider_txn_id, '') FROM withdraw_requests WHERE id=$1`, reqID).Scan(&seenStatus, &seenTxn)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	processWithdrawPayout(db, cfg, reqID, 30000)
+
+	if seenStatus != WithdrawStatusProcessing || seenTxn == "" || seenTxn != sentTxn {
+		t.Fatalf("during provider call: status %q txn %q, want %s with sent txn %q", seenStatus, seenTxn, WithdrawStatusProcessing, sentTxn)
+	}
+}
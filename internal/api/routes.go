@@ -2,11 +2,14 @@ package api
 
 import (
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/admin"
 	"github.com/playpool/backend/internal/api/handlers"
 	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/middleware"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -26,6 +29,10 @@ func SetupRoutes(router *gin.Engine, db *sqlx.DB, rdb *redis.Client, cfg *config
 		log.Println("[DEV MODE] Aggressive no-cache headers enabled for all routes")
 	}
 
+	// Load balancer probes (unauthenticated, skipped by the request logger)
+	router.GET("/healthz", handlers.Liveness)
+	router.GET("/readyz", handlers.Readiness(db, rdb))
+
 	// API v1 group
 	v1 := router.Group("/api/v1")
 	{
@@ -35,32 +42,68 @@ func SetupRoutes(router *gin.Engine, db *sqlx.DB, rdb *redis.Client, cfg *config
 		// USSD endpoint (internal gateway)
 		v1.GET("/ussd", handlers.HandleUSSD(db, rdb, cfg))
 
-		// DMarkPay webhook endpoint (no auth required)
+		// DMarkPay webhook endpoints (no auth required)
 		v1.POST("/webhooks/dmark", handlers.DMarkPayinWebhook(db, rdb, cfg))
+		v1.POST("/webhooks/dmark/payout", handlers.DMarkPayoutWebhook(db, cfg))
+		v1.POST("/webhooks/dmark/sms", handlers.SMSDeliveryReport(db, cfg))
 
 		// Game endpoints
+		// Outdated clients get a "please update" response on the endpoints whose schemas change
+		clientVersion := middleware.ClientVersion(cfg)
+
 		game := v1.Group("/game")
 		{
-			game.POST("/stake", handlers.InitiateStake(db, rdb, cfg))
+			// Idempotency-Key header makes client retries replay the first response instead of staking twice
+			stakeIdempotency := middleware.Idempotency(rdb, time.Duration(cfg.StakeIdempotencyTTLSec)*time.Second)
+			game.POST("/stake", clientVersion, stakeIdempotency, handlers.InitiateStake(db, rdb, cfg))
 			game.GET("/queue/status", handlers.CheckQueueStatus(db, rdb, cfg))
 			game.GET("/status", handlers.GetQueueStatus(rdb))
 			game.POST("/test", handlers.CreateTestGame(db, rdb, cfg))          // Dev only
-			game.GET("/:token", handlers.GetGameState(db, rdb, cfg))
-			game.GET("/:token/ws", handlers.HandleGameWebSocket(db, rdb, cfg))
+			game.GET("/:token", clientVersion, handlers.GetGameState(db, rdb, cfg))
+			game.GET("/:token/ws", clientVersion, handlers.HandleGameWebSocket(db, rdb, cfg))
+			game.POST("/:token/resend-link", handlers.ResendGameLink(rdb, cfg))
+		}
+
+		// Pool endpoints
+		v1.POST("/pool/:token/preview", handlers.PreviewPoolShot(cfg))
+
+		// QA endpoints (never registered in production)
+		if cfg.Environment != "production" {
+			dev := v1.Group("/dev")
+			dev.POST("/test-pool-game", handlers.CreateTestPoolGame(cfg))
 		}
 
 		// Player endpoints
 		player := v1.Group("/player")
 		{
 			player.GET(":phone/stats", handlers.GetPlayerStats(db, cfg))
-			player.GET(":phone", handlers.GetPlayerProfile(db))
+			player.GET(":phone", handlers.GetPlayerProfile(db, cfg))
 			player.PUT(":phone/display-name", handlers.UpdateDisplayName(db))
-			player.POST(":phone/requeue", handlers.RequeueStake(db, rdb, cfg))
+			player.POST(":phone/requeue", clientVersion, handlers.RequeueStake(db, rdb, cfg))
+			// The player's own active and recent games (?status=active|completed&limit=20)
+			player.GET(":phone/games", handlers.AuthMiddleware(cfg, rdb), handlers.GetPlayerGames(db, cfg))
 		}
 
+		// Featured games to spectate, busiest audiences first (?limit=10)
+		v1.GET("/games/featured", handlers.GetFeaturedGames())
+
+		// Tournaments (single elimination; entry fees are paid from winnings)
+		v1.POST("/tournaments", handlers.AuthMiddleware(cfg, rdb), handlers.CreateTournament(cfg))
+		v1.POST("/tournaments/:id/join", handlers.AuthMiddleware(cfg, rdb), handlers.JoinTournament(db))
+		v1.GET("/tournaments/:id/bracket", handlers.GetTournamentBracket())
+
+		// Leaderboard (?period=week|month|all&limit=50)
+		v1.GET("/leaderboard", handlers.GetLeaderboard(db, rdb, cfg))
+
 		// Queue operations
 		// Cancel an active queue and refund the stake to player's winnings (auth required via session cookie)
 		v1.POST("/queue/:id/cancel", handlers.PlayerSessionMiddleware(rdb, db, cfg), handlers.CancelQueue(db, cfg))
+		// Leave the queue before being matched (by queue token, beacon-friendly) and refund the stake
+		v1.POST("/queue/leave", handlers.LeaveQueue(db))
+		// Waiting-screen socket: opponent_found arrives as soon as the entry is matched
+		v1.GET("/queue/:id/watch", handlers.WatchQueue(db))
+		// Abandon a real-money payin that hasn't been approved on the phone yet
+		v1.POST("/transactions/:dmark_id/cancel", handlers.AuthMiddleware(cfg, rdb), handlers.CancelPayin(db))
 
 		// Auth endpoints (OTP)
 		v1.POST("/auth/request-otp", handlers.RequestOTP(db, rdb, cfg))
@@ -73,9 +116,12 @@ func SetupRoutes(router *gin.Engine, db *sqlx.DB, rdb *redis.Client, cfg *config
 
 		// PIN auth endpoints
 		v1.GET("/player/check", handlers.CheckPlayerStatus(db))
+		// Setting a PIN by phone needs an OTP action token for set_pin
 		v1.POST("/auth/set-pin", handlers.SetPIN(db, rdb, cfg))
 		v1.POST("/auth/verify-pin", handlers.VerifyPIN(db, rdb, cfg))
 		v1.POST("/auth/reset-pin", handlers.ResetPIN(db, rdb, cfg))
+		// Returning players: phone + PIN issues the same JWT as verify-otp
+		v1.POST("/auth/pin-login", handlers.PINLogin(db, cfg))
 
 		// Player session endpoints
 		v1.GET("/session/check", handlers.PlayerSessionMiddleware(rdb, db, cfg), handlers.PlayerCheckSession(rdb, db))
@@ -86,6 +132,19 @@ func SetupRoutes(router *gin.Engine, db *sqlx.DB, rdb *redis.Client, cfg *config
 		// Withdraw
 		v1.POST("/me/withdraw", handlers.AuthMiddleware(cfg, rdb), handlers.RequestWithdraw(db, cfg))
 		v1.GET("/me/withdraws", handlers.AuthMiddleware(cfg, rdb), handlers.GetMyWithdraws(db))
+		// Every movement on the player's winnings account, with running balance
+		v1.GET("/me/statement", handlers.AuthMiddleware(cfg, rdb), handlers.GetMyStatement(db))
+		// Send winnings to another player
+		v1.POST("/me/transfer", handlers.AuthMiddleware(cfg, rdb), handlers.TransferWinnings(db, cfg))
+		// Set a PIN (needs an OTP action token for set_pin)
+		v1.POST("/me/set-pin", handlers.AuthMiddleware(cfg, rdb), handlers.SetMyPIN(db, rdb))
+		// SMS notification opt-outs
+		v1.PUT("/me/notifications", handlers.AuthMiddleware(cfg, rdb), handlers.UpdateNotificationPreferences(db))
+		v1.PUT("/me/language", handlers.AuthMiddleware(cfg, rdb), handlers.UpdateSMSLanguage(db))
+		// Responsible gaming: pause staking for 24h/7d/30d (cannot be undone early)
+		v1.POST("/me/self-exclude", handlers.AuthMiddleware(cfg, rdb), handlers.SelfExclude(db))
+		// Loser of a high-stake game disputes the result while the payout is held
+		v1.POST("/me/games/:id/dispute", handlers.AuthMiddleware(cfg, rdb), handlers.DisputeGameResult())
 
 		// Config endpoint
 		v1.GET("/config", handlers.GetConfig(cfg))
@@ -98,12 +157,13 @@ func SetupRoutes(router *gin.Engine, db *sqlx.DB, rdb *redis.Client, cfg *config
 			adminGroup.POST("/verify-otp", handlers.AdminVerifyOTP(db, rdb, cfg))
 			adminGroup.POST("/logout", handlers.AdminLogout(rdb))
 
-			// Protected admin endpoints (require session cookie)
+			// Protected admin endpoints (require session cookie and an allowlisted IP, if the admin has one)
 			protected := adminGroup.Group("")
-			protected.Use(handlers.AdminSessionMiddleware(rdb, db))
+			protected.Use(handlers.AdminSessionMiddleware(rdb, db), handlers.AdminIPAllowlist(db, cfg))
 			{
 				protected.GET("/me", handlers.AdminMe())
 				protected.GET("/stats", handlers.GetAdminStats(db))
+				protected.GET("/dashboard", handlers.GetAdminDashboard(db, rdb))
 				protected.GET("/accounts", handlers.GetAdminAccounts(db))
 				protected.GET("/account_transactions", handlers.GetAdminAccountTransactions(db))
 				protected.GET("/transactions", handlers.GetAdminTransactions(db))
@@ -120,17 +180,33 @@ func SetupRoutes(router *gin.Engine, db *sqlx.DB, rdb *redis.Client, cfg *config
 				// Game management
 				protected.GET("/games", handlers.GetAdminGames(db))
 				protected.GET("/games/:id", handlers.GetAdminGameDetail(db))
+				protected.GET("/games/:id/actions", handlers.GetAdminGameActionLog())
+				protected.GET("/games/:id/full-state", handlers.GetAdminGameFullState(db))
+				protected.GET("/games/:id/chat", handlers.GetAdminGameChat(db))
 				protected.POST("/games/:id/cancel", handlers.AdminCancelGame(db))
 
 				// Financial operations
 				protected.GET("/withdrawals", handlers.GetAdminWithdrawals(db))
-				protected.POST("/withdrawals/:id/approve", handlers.AdminApproveWithdrawal(db))
+				protected.POST("/withdrawals/:id/approve", handlers.AdminApproveWithdrawal(db, cfg))
 				protected.POST("/withdrawals/:id/reject", handlers.AdminRejectWithdrawal(db))
 				protected.GET("/revenue", handlers.GetAdminRevenue(db))
+				protected.POST("/revenue/sweep", handlers.RequireAdminRole(db, admin.RoleSuperAdmin), handlers.AdminSweepRevenue(db))
+				protected.POST("/accounts/reconcile", handlers.AdminReconcileAccounts(db))
+				protected.GET("/payout-holds", handlers.GetAdminPayoutHolds(db))
+				protected.POST("/payout-holds/:id/resolve", handlers.AdminResolveDispute(db))
+
+				// Outbound SMS and delivery reports
+				protected.GET("/sms", handlers.GetAdminSMSLog(db))
 
 				// Audit log
 				protected.GET("/audit-logs", handlers.GetAdminAuditLogs(db))
 
+				// Live WebSocket connections on this instance
+				protected.GET("/ws/stats", handlers.GetAdminWSStats())
+
+				// Matchmaking capacity planning (in-memory only)
+				protected.POST("/matchmaking/simulate", handlers.AdminSimulateMatchmaking(cfg))
+
 				// Runtime config
 				protected.GET("/config", handlers.GetAdminRuntimeConfig(db))
 				protected.PUT("/config/:key", handlers.UpdateAdminRuntimeConfig(db, cfg))
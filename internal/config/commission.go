@@ -0,0 +1,47 @@
+package config
+
+// Commission modes (COMMISSION_MODE)
+const (
+	CommissionModeFlat    = "flat"    // CommissionFlat on every stake
+	CommissionModePercent = "percent" // CommissionPercentage of the stake, rounded down
+	CommissionModeTiered  = "tiered"  // CommissionTiers by stake; stakes below the lowest tier pay CommissionFlat
+)
+
+// Commission returns the platform commission charged on top of stake. Every payment path
+// uses it so the amount quoted, collected and moved to the platform account agree.
+func (c *Config) Commission(stake int) int {
+	switch c.CommissionMode {
+	case CommissionModePercent:
+		return stake * c.CommissionPercentage / 100
+	case CommissionModeTiered:
+		fee, floor := c.CommissionFlat, -1
+		for f, v := range c.CommissionTiers {
+			if stake >= f && f > floor {
+				fee, floor = v, f
+			}
+		}
+		return fee
+	}
+	return c.CommissionFlat
+}
+
+// StakeFromGross recovers the stake from a gross amount (stake + commission) collected
+// earlier, for payment callbacks that only see what the player paid.
+func (c *Config) StakeFromGross(gross int) int {
+	candidates := []int{gross - c.CommissionFlat}
+	switch c.CommissionMode {
+	case CommissionModePercent:
+		est := gross * 100 / (100 + c.CommissionPercentage)
+		candidates = append(candidates, est, est+1, est-1)
+	case CommissionModeTiered:
+		for _, v := range c.CommissionTiers {
+			candidates = append(candidates, gross-v)
+		}
+	}
+	for _, stake := range candidates {
+		if stake > 0 && stake+c.Commission(stake) == gross {
+			return stake
+		}
+	}
+	return gross - c.Commission(gross)
+}
@@ -0,0 +1,33 @@
+package config
+
+import "testing"
+
+func TestCommissionModes(t *testing.T) {
+	tiers := map[int]int{10000: 1500, 100000: 5000}
+	cases := []struct {
+		mode              string
+		stake, commission int
+	}{
+		{CommissionModeFlat, 1000, 1000},
+		{CommissionModeFlat, 50000, 1000},
+		{CommissionModeFlat, 1000000, 1000},
+		{CommissionModePercent, 1000, 100},
+		{CommissionModePercent, 2555, 255},
+		{CommissionModePercent, 500000, 50000},
+		{CommissionModeTiered, 5000, 1000}, // below the lowest tier
+		{CommissionModeTiered, 10000, 1500},
+		{CommissionModeTiered, 99999, 1500},
+		{CommissionModeTiered, 100000, 5000},
+		{CommissionModeTiered, 2000000, 5000},
+		{"", 20000, 1000}, // unset falls back to flat
+	}
+	for _, tc := range cases {
+		cfg := &Config{CommissionMode: tc.mode, CommissionFlat: 1000, CommissionPercentage: 10, CommissionTiers: tiers}
+		if got := cfg.Commission(tc.stake); got != tc.commission {
+			t.Errorf("%s mode, stake %d: commission %d, want %d", tc.mode, tc.stake, got, tc.commission)
+		}
+		if got := cfg.StakeFromGross(tc.stake + tc.commission); got != tc.stake {
+			t.Errorf("%s mode, gross %d: stake %d, want %d", tc.mode, tc.stake+tc.commission, got, tc.stake)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -29,8 +30,11 @@ type Config struct {
 	NoShowFeePercentage       int
 	CommissionPercentage      int
 	CommissionFlat            int
+	CommissionMode            string      // flat, percent or tiered; see Commission
+	CommissionTiers           map[int]int // tiered mode: lowest stake of tier -> commission (UGX)
 	MinStakeAmount            int
 	PayoutTaxPercent          int
+	StakeIdempotencyTTLSec    int
 
 	// USSD Gateway
 	USSDShortcode  string
@@ -50,6 +54,42 @@ type Config struct {
 	SMSRateLimitSeconds     int
 	SMSTokenFallbackSeconds int
 
+	// Outbound SMS sandbox (staging): redirect every message to one test number, or only log when unset
+	SMSSandboxMode   bool
+	SMSSandboxNumber string
+
+	// Private match invites: at most InviteSMSLimit texts per recipient per window (0 = unlimited)
+	InviteSMSLimit         int
+	InviteSMSWindowSeconds int
+
+	// Game link resend: a player may have their join link texted again once per this many seconds
+	GameLinkResendSeconds int
+
+	// Estimated SMS spend: each sent message costs SMSCostPerSegment (UGX) per segment,
+	// or its type's entry in SMSCostPerSegmentByType
+	SMSCostPerSegment       int
+	SMSCostPerSegmentByType map[string]int
+
+	// Outbound SMS resilience: up to SMSMaxAttempts tries per message with jittered exponential
+	// backoff from SMSRetryBaseMillis on transient errors; after SMSBreakerFailures consecutive
+	// failed messages, sends are refused for SMSBreakerCooldownSeconds (0 failures = no breaker)
+	SMSMaxAttempts            int
+	SMSRetryBaseMillis        int
+	SMSBreakerFailures        int
+	SMSBreakerCooldownSeconds int
+
+	// Notification SMS are sent by SMSWorkers workers from a queue of SMSQueueSize;
+	// messages arriving while the queue is full are dropped
+	SMSWorkers   int
+	SMSQueueSize int
+
+	// DMark posts delivery reports to SMSDeliveryReportURL (empty = none requested); the
+	// callback must carry ?token=SMSDeliveryReportToken when that is set. With
+	// SMSLogPhoneLast4 the SMS log keeps only the last four digits of each number.
+	SMSDeliveryReportURL   string
+	SMSDeliveryReportToken string
+	SMSLogPhoneLast4       bool
+
 	// Mobile Money (Legacy)
 	MomoAPIKey          string
 	MomoAPISecret       string
@@ -66,6 +106,10 @@ type Config struct {
 	DMarkPayCallbackURL string
 	DMarkPayTimeout     int
 
+	// DMarkPay webhook signature verification (HMAC-SHA256 over the raw body in X-Signature)
+	DMarkPayWebhookSecret     string
+	DMarkPayWebhookPermissive bool
+
 	// Security
 	JWTSecret         string
 	SessionTimeoutMin int
@@ -73,7 +117,9 @@ type Config struct {
 	// OTP configuration
 	OTPTokenTTLSeconds         int
 	OTPRequestRateLimitSeconds int
-	OTPMaxVerifyAttempts       int
+	OTPMaxVerifyAttempts       int // wrong codes per phone before verification locks (0 = no lockout)
+	OTPLockoutSeconds          int
+	OTPLength                  int // digits in player OTPs (4-8)
 
 	// PIN configuration
 	PINMaxAttempts     int
@@ -88,14 +134,183 @@ type Config struct {
 	// Disconnect grace period
 	DisconnectGraceSeconds int
 
-	// Matchmaker worker
-	MatchmakerPollSeconds int
+	// Publish opponent_found to queue watchers the moment a match is made, ahead of game setup
+	OpponentFoundEvent bool
+
+	// Withhold the opponent's name after a match until both players have connected or this many
+	// seconds have passed, so players cannot dodge particular opponents (0 = show at once)
+	OpponentRevealDelaySeconds int
+
+	// Matchmaker worker: polls every MatchmakerPollSeconds while players are queued, backing
+	// off to at most MatchmakerMaxPollSeconds while the queue stays empty
+	MatchmakerPollSeconds    int
+	MatchmakerMaxPollSeconds int
+
+	// Log each Redis stake list before matching (costs two extra Redis calls per attempt)
+	MatchDebugLogging bool
+
+	// Resume in-progress games saved in Redis after a server restart
+	ResumeGamesOnRestart bool
+
+	// Run the game manager's periodic checks (expiry, disconnects, queue expiry, stuck
+	// processing, payout holds) from one coordinating worker instead of one goroutine each
+	BatchedMaintenance bool
+
+	// Refuse pool shots when the ball set is inconsistent (missing cue/8-ball, bad ids)
+	PoolBoardIntegrityCheck bool
+
+	// Toss a coin for who breaks instead of always letting player 1 (the first queued) break
+	PoolRandomBreak bool
+
+	// End a pool game after this many shots (0 = no cap); bounds escrow time on stalled games
+	PoolMaxShots int
+
+	// Send spectators the shot params and simulated event timeline as a shot starts
+	PoolSpectatorShotPreview bool
+
+	// Minimum seconds between relayed "thinking" signals from the player on turn (0 = off)
+	PoolThinkingIndicatorSeconds int
+
+	// Offer a rematch link in game_complete (requeue and stats are always offered)
+	RematchEnabled bool
+
+	// Offer the winner a one-tap restake at the same stake from winnings when the balance covers it
+	QuickRestakeEnabled bool
+
+	// Concurrent physics simulations (0 = one per CPU) and how many may queue for a slot
+	PoolMaxConcurrentSimulations int
+	PoolSimulationQueueSize      int
+
+	// Pool shot clock: seconds per turn (0 = off); this many timeouts in a row forfeit the game
+	PoolTurnSeconds        int
+	PoolTurnTimeoutForfeit int
+
+	// Most moves a session may record in game_moves per minute (0 = unlimited); far above
+	// human pace, so only a runaway client or loop hits it
+	MaxMovesPerMinute int
+
 	// Withdraw settings
 	MockMode          bool
 	MinWithdrawAmount int
 	AdminUsername     string
 	AdminPassword     string
 	AdminPhone        string
+
+	// Header carrying the real client IP when behind a trusted proxy (e.g. X-Real-IP); empty
+	// means the connection's address is used for admin IP allowlists
+	AdminTrustedProxyHeader string
+
+	// Withdrawals above this amount wait in PENDING_REVIEW for an admin (0 disables review)
+	WithdrawAutoApproveLimit int
+
+	// A player may request a withdrawal only this long after their last one, and may have at
+	// most MaxPendingWithdrawals not yet paid out (0 = off, the default)
+	WithdrawCooldownMinutes int
+	MaxPendingWithdrawals   int
+
+	// A real payin still PENDING after PayinTimeoutMinutes gets a reminder SMS and
+	// PayinResumeGraceMinutes more to complete before the stake is voided (0 = void at timeout)
+	PayinTimeoutMinutes     int
+	PayinResumeGraceMinutes int
+
+	// Clients reporting an older version get a 426 "please update" (empty = no gate)
+	MinClientVersion string
+
+	// Keep the last N actions of each game in memory for the admin action log (0 = off)
+	GameActionLogSize int
+
+	// How often the ledger reconciliation job runs (0 = only on demand from the admin API)
+	LedgerReconcileHours int
+
+	// Send compact ball positions in game_state to clients that advertise ?caps=compact_state
+	PoolCompactState bool
+
+	// Responsible gaming: most a player may stake per calendar day/month in UGX (0 = unlimited).
+	// players.daily_stake_limit / monthly_stake_limit override these per player.
+	DailyStakeLimit   int
+	MonthlyStakeLimit int
+
+	// Refuse transfers and payouts that would take the settlement account below zero
+	SettlementOverdraftGuard bool
+
+	// Winnings players may gift each other: smallest transfer, and most a player may send
+	// per calendar day in UGX (0 = no daily cap)
+	PeerTransferMinAmount  int
+	PeerTransferDailyLimit int
+
+	// Run the startup self-test (DB, Redis, system accounts, a scripted game) before serving;
+	// with StartupSelfTestStrict a failure stops the server instead of only being logged
+	StartupSelfTest       bool
+	StartupSelfTestStrict bool
+
+	// Featured games ranking: a departed audience loses half its weight every half-life,
+	// and this much stake (UGX) ranks the same as one current viewer
+	FeaturedViewerHalfLifeSeconds int
+	FeaturedStakePerViewer        int
+
+	// Tournaments: the platform keeps this share of the entry fees, and the runner-up
+	// gets this share of the rest (the champion takes the remainder)
+	TournamentPlatformPercent int
+	TournamentRunnerUpPercent int
+
+	// In-game chat between the two players; with logging, lines are stored for moderation
+	GameChatEnabled bool
+	GameChatLogging bool
+
+	// ELO ratings: K factor per game, and ranked matchmaking (opt-in) which only pairs players
+	// within the rating window; the window widens by RankedWindowGrowthPerMinute while they wait
+	EloKFactor                  int
+	RankedMatchmaking           bool
+	RankedRatingWindow          int
+	RankedWindowGrowthPerMinute int
+
+	// A player who disconnects within this many seconds of the start, before any shot, gets the
+	// game cancelled and both stakes refunded instead of a rated forfeit (0 = off)
+	EarlyDisconnectCancelSeconds int
+
+	// Minimum level of the structured JSON logs: debug, info, warn or error
+	LogLevel string
+
+	// Spectator streams require a logged-in player (session cookie or JWT) instead of being open
+	SpectatorAuthRequired bool
+
+	// Queue rows with less than this many seconds left are expired on startup instead of
+	// being rehydrated into Redis for a few seconds (0 = rehydrate anything not yet expired)
+	RehydrateMinTTLSeconds int
+
+	// Risk cap: at most this many live games per stake tier, keyed by the tier's lowest stake
+	// (STAKE_TIER_MAX_GAMES="50000:10,200000:3" caps 50k-199,999 at 10 and 200k+ at 3).
+	// Stakes below the lowest tier are uncapped; matches over the cap wait in the queue.
+	StakeTierMaxGames map[int]int
+
+	// Per-tier override of how long a matched game waits for both players to connect before it
+	// is cancelled and refunded, in seconds, keyed like StakeTierMaxGames
+	// (STAKE_TIER_CONNECT_SECONDS="200000:60"). Tiers without an entry use GameExpiryMinutes.
+	StakeTierConnectSeconds map[int]int
+
+	// Game state shows the opponent's rating as a band (Bronze/Silver/Gold/Platinum) rather than
+	// the exact ELO; players always see their own exact rating
+	OpponentRatingBands bool
+
+	// Seconds a client should wait before reconnecting after the WebSocket server refuses or
+	// drops it (sent as retry_after_seconds and Retry-After)
+	WSRetryAfterSeconds int
+
+	// WebSocket keepalive: the server pings every WSPingIntervalSeconds and drops a connection
+	// (marking the player disconnected) when no pong or message arrives for WSPongTimeoutSeconds
+	WSPingIntervalSeconds int
+	WSPongTimeoutSeconds  int
+
+	// Winnings from games staked at PayoutHoldMinStake or more (0 = never) are held for
+	// PayoutHoldSeconds so the loser can dispute the result; undisputed holds then auto-release
+	// and disputed ones wait for an admin
+	PayoutHoldMinStake int
+	PayoutHoldSeconds  int
+
+	// Every AbuseStrikeThreshold no-shows and disconnect forfeits (0 = off) block the player
+	// from staking for AbuseBlockHours, doubling with each further block
+	AbuseStrikeThreshold int
+	AbuseBlockHours      int
 }
 
 func Load() *Config {
@@ -123,8 +338,11 @@ func Load() *Config {
 		QueueProcessingVisibility: getEnvInt("QUEUE_PROCESSING_VISIBILITY_SECONDS", 30),
 		CommissionPercentage:      getEnvInt("COMMISSION_PERCENTAGE", 10),
 		CommissionFlat:            getEnvInt("COMMISSION_FLAT", 1000),
+		CommissionMode:            getEnv("COMMISSION_MODE", CommissionModeFlat),
+		CommissionTiers:           getEnvIntMap("COMMISSION_TIERS"),
 		MinStakeAmount:            getEnvInt("MIN_STAKE_AMOUNT", 1000),
 		PayoutTaxPercent:          getEnvInt("PAYOUT_TAX_PERCENT", 15),
+		StakeIdempotencyTTLSec:    getEnvInt("STAKE_IDEMPOTENCY_TTL_SECONDS", 600),
 
 		// SMS
 		SMSSenderID:            getEnv("SMS_SENDER_ID", "PlayPool"),
@@ -138,6 +356,36 @@ func Load() *Config {
 		SMSRateLimitSeconds:     getEnvInt("SMS_RATE_LIMIT_SECONDS", 30),
 		SMSTokenFallbackSeconds: getEnvInt("SMS_TOKEN_FALLBACK_SECONDS", 3000),
 
+		// SMS sandbox (never text real users from staging)
+		SMSSandboxMode:   getEnv("SMS_SANDBOX_MODE", "false") == "true",
+		SMSSandboxNumber: getEnv("SMS_SANDBOX_NUMBER", ""),
+
+		// Invite SMS dedup per recipient
+		InviteSMSLimit:         getEnvInt("INVITE_SMS_LIMIT", 1),
+		InviteSMSWindowSeconds: getEnvInt("INVITE_SMS_WINDOW_SECONDS", 3600),
+
+		// Game link resend cooldown per phone
+		GameLinkResendSeconds: getEnvInt("GAME_LINK_RESEND_SECONDS", 60),
+
+		// SMS cost estimate per segment, optionally per type ("otp:35,match:25")
+		SMSCostPerSegment:       getEnvInt("SMS_COST_PER_SEGMENT", 25),
+		SMSCostPerSegmentByType: getEnvNamedIntMap("SMS_COST_PER_SEGMENT_BY_TYPE"),
+
+		// SMS retry/backoff and circuit breaker
+		SMSMaxAttempts:            getEnvInt("SMS_MAX_ATTEMPTS", 3),
+		SMSRetryBaseMillis:        getEnvInt("SMS_RETRY_BASE_MILLIS", 200),
+		SMSBreakerFailures:        getEnvInt("SMS_BREAKER_FAILURES", 5),
+		SMSBreakerCooldownSeconds: getEnvInt("SMS_BREAKER_COOLDOWN_SECONDS", 60),
+
+		// Bounded SMS send queue
+		SMSWorkers:   getEnvInt("SMS_WORKERS", 4),
+		SMSQueueSize: getEnvInt("SMS_QUEUE_SIZE", 500),
+
+		// SMS delivery reports and log privacy
+		SMSDeliveryReportURL:   getEnv("SMS_DELIVERY_REPORT_URL", ""),
+		SMSDeliveryReportToken: getEnv("SMS_DELIVERY_REPORT_TOKEN", ""),
+		SMSLogPhoneLast4:       getEnv("SMS_LOG_PHONE_LAST4", "false") == "true",
+
 		// Mobile Money (Legacy)
 		MomoAPIKey:          getEnv("MOMO_API_KEY", ""),
 		MomoAPISecret:       getEnv("MOMO_API_SECRET", ""),
@@ -154,6 +402,10 @@ func Load() *Config {
 		DMarkPayCallbackURL: getEnv("DMARK_PAY_CALLBACK_URL", ""),
 		DMarkPayTimeout:     getEnvInt("DMARK_PAY_TIMEOUT", 30),
 
+		// Permissive mode only logs bad signatures; use it while rolling out the shared secret
+		DMarkPayWebhookSecret:     getEnv("DMARK_PAY_WEBHOOK_SECRET", ""),
+		DMarkPayWebhookPermissive: getEnv("DMARK_PAY_WEBHOOK_PERMISSIVE", "false") == "true",
+
 		// Security
 		JWTSecret:         getEnv("JWT_SECRET", "change-me-in-production"),
 		SessionTimeoutMin: getEnvInt("SESSION_TIMEOUT_MINUTES", 30),
@@ -163,6 +415,8 @@ func Load() *Config {
 		OTPTokenTTLSeconds:         getEnvInt("OTP_TTL_SECONDS", 300),
 		OTPRequestRateLimitSeconds: getEnvInt("OTP_RATE_LIMIT_SECONDS", 60),
 		OTPMaxVerifyAttempts:       getEnvInt("OTP_MAX_VERIFY_ATTEMPTS", 5),
+		OTPLockoutSeconds:          getEnvInt("OTP_LOCKOUT_SECONDS", 900),
+		OTPLength:                  getEnvInt("OTP_LENGTH", 4),
 
 		// PIN settings
 		PINMaxAttempts:     getEnvInt("PIN_MAX_ATTEMPTS", 5),
@@ -178,7 +432,53 @@ func Load() *Config {
 		DisconnectGraceSeconds: getEnvInt("DISCONNECT_GRACE_SECONDS", 60),
 
 		// Matchmaker worker (how often to check for pairs to match)
-		MatchmakerPollSeconds: getEnvInt("MATCHMAKER_POLL_SECONDS", 2),
+		MatchmakerPollSeconds:    getEnvInt("MATCHMAKER_POLL_SECONDS", 2),
+		MatchmakerMaxPollSeconds: getEnvInt("MATCHMAKER_MAX_POLL_SECONDS", 30),
+
+		// Verbose queue dumps while matching
+		MatchDebugLogging: getEnv("MATCH_DEBUG_LOGGING", "false") == "true",
+
+		// Instant opponent_found on the queue watch socket
+		OpponentFoundEvent: getEnv("OPPONENT_FOUND_EVENT", "true") == "true",
+
+		// Anti-dodging: opponent names hidden until both connect
+		OpponentRevealDelaySeconds: getEnvInt("OPPONENT_REVEAL_DELAY_SECONDS", 0),
+
+		// Restart recovery (players reconnect to the exact saved table, including ball-in-hand)
+		ResumeGamesOnRestart: getEnv("RESUME_GAMES_ON_RESTART", "true") == "true",
+
+		// Single maintenance worker (off: one ticker per check)
+		BatchedMaintenance: getEnv("BATCHED_MAINTENANCE", "false") == "true",
+
+		// Board integrity check before each pool shot
+		PoolBoardIntegrityCheck: getEnv("POOL_BOARD_INTEGRITY_CHECK", "true") == "true",
+
+		// Coin flip for the break (off: player 1 breaks)
+		PoolRandomBreak: getEnv("POOL_RANDOM_BREAK", "false") == "true",
+
+		// Shot cap: leader by cleared balls wins, level games are drawn and refunded
+		PoolMaxShots: getEnvInt("POOL_MAX_SHOTS", 0),
+
+		// Spectator shot preview (players still only receive the authoritative shot_result)
+		PoolSpectatorShotPreview: getEnv("POOL_SPECTATOR_SHOT_PREVIEW", "false") == "true",
+
+		// Opponent "thinking" indicator rate limit
+		PoolThinkingIndicatorSeconds: getEnvInt("POOL_THINKING_INDICATOR_SECONDS", 2),
+
+		// Next actions after a game
+		RematchEnabled:      getEnv("REMATCH_ENABLED", "true") == "true",
+		QuickRestakeEnabled: getEnv("QUICK_RESTAKE_ENABLED", "true") == "true",
+
+		// Simulation limits (excess preview requests get a retriable 503)
+		PoolMaxConcurrentSimulations: getEnvInt("POOL_MAX_CONCURRENT_SIMULATIONS", 0),
+		PoolSimulationQueueSize:      getEnvInt("POOL_SIMULATION_QUEUE_SIZE", 16),
+
+		// Shot clock (a timeout is a foul with ball-in-hand to the opponent)
+		PoolTurnSeconds:        getEnvInt("POOL_TURN_SECONDS", 0),
+		PoolTurnTimeoutForfeit: getEnvInt("POOL_TURN_TIMEOUT_FORFEIT", 3),
+
+		// Move recording guard
+		MaxMovesPerMinute: getEnvInt("MAX_MOVES_PER_MINUTE", 60),
 
 		// Withdraw configuration
 		MockMode:          getEnv("MOCK_MODE", "true") == "true",
@@ -186,6 +486,101 @@ func Load() *Config {
 		AdminUsername:     getEnv("ADMIN_USERNAME", "admin"),
 		AdminPassword:     getEnv("ADMIN_PASSWORD", "change-me-in-production"),
 		AdminPhone:        getEnv("ADMIN_PHONE", "256700000000"),
+
+		// Client IP source for admin allowlists
+		AdminTrustedProxyHeader: getEnv("ADMIN_TRUSTED_PROXY_HEADER", ""),
+
+		// Large-withdrawal review threshold (also editable via runtime_config)
+		WithdrawAutoApproveLimit: getEnvInt("WITHDRAW_AUTO_APPROVE_LIMIT", 0),
+
+		// Withdrawal frequency limits
+		WithdrawCooldownMinutes: getEnvInt("WITHDRAW_COOLDOWN_MINUTES", 0),
+		MaxPendingWithdrawals:   getEnvInt("MAX_PENDING_WITHDRAWALS", 0),
+
+		// Payin timeout and resume grace
+		PayinTimeoutMinutes:     getEnvInt("PAYIN_TIMEOUT_MINUTES", 15),
+		PayinResumeGraceMinutes: getEnvInt("PAYIN_RESUME_GRACE_MINUTES", 10),
+
+		// Minimum client version (also editable via runtime_config)
+		MinClientVersion: getEnv("MIN_CLIENT_VERSION", ""),
+
+		// Per-game debug action log
+		GameActionLogSize: getEnvInt("GAME_ACTION_LOG_SIZE", 0),
+
+		// Ledger reconciliation job (daily)
+		LedgerReconcileHours: getEnvInt("LEDGER_RECONCILE_HOURS", 24),
+
+		// Compact reconnection state (clients opt in)
+		PoolCompactState: getEnv("POOL_COMPACT_STATE", "true") == "true",
+
+		// Stake limits (opt-in)
+		DailyStakeLimit:   getEnvInt("DAILY_STAKE_LIMIT", 0),
+		MonthlyStakeLimit: getEnvInt("MONTHLY_STAKE_LIMIT", 0),
+
+		// Non-negative settlement invariant
+		SettlementOverdraftGuard: getEnv("SETTLEMENT_OVERDRAFT_GUARD", "true") == "true",
+
+		// Peer winnings transfers
+		PeerTransferMinAmount:  getEnvInt("PEER_TRANSFER_MIN_AMOUNT", 500),
+		PeerTransferDailyLimit: getEnvInt("PEER_TRANSFER_DAILY_LIMIT", 200000),
+
+		// Startup self-test (opt-in, meant for staging and other non-prod deploys)
+		StartupSelfTest:       getEnv("STARTUP_SELF_TEST", "false") == "true",
+		StartupSelfTestStrict: getEnv("STARTUP_SELF_TEST_STRICT", "false") == "true",
+
+		// Featured games ranking
+		FeaturedViewerHalfLifeSeconds: getEnvInt("FEATURED_VIEWER_HALF_LIFE_SECONDS", 120),
+		FeaturedStakePerViewer:        getEnvInt("FEATURED_STAKE_PER_VIEWER", 5000),
+
+		// Tournament prize split
+		TournamentPlatformPercent: getEnvInt("TOURNAMENT_PLATFORM_PERCENT", 10),
+		TournamentRunnerUpPercent: getEnvInt("TOURNAMENT_RUNNER_UP_PERCENT", 30),
+
+		// Game chat (off by default)
+		GameChatEnabled: getEnv("GAME_CHAT_ENABLED", "false") == "true",
+		GameChatLogging: getEnv("GAME_CHAT_LOGGING", "true") == "true",
+
+		// ELO ratings and ranked matchmaking (off by default)
+		EloKFactor:                  getEnvInt("ELO_K_FACTOR", 32),
+		RankedMatchmaking:           getEnv("RANKED_MATCHMAKING", "false") == "true",
+		RankedRatingWindow:          getEnvInt("RANKED_RATING_WINDOW", 100),
+		RankedWindowGrowthPerMinute: getEnvInt("RANKED_WINDOW_GROWTH_PER_MINUTE", 100),
+
+		// Early disconnects cancel instead of forfeiting (off by default)
+		EarlyDisconnectCancelSeconds: getEnvInt("EARLY_DISCONNECT_CANCEL_SECONDS", 0),
+
+		// Structured logging
+		LogLevel: getEnv("LOG_LEVEL", "info"),
+
+		// Anonymous spectating (open by default so featured games can be watched by anyone)
+		SpectatorAuthRequired: getEnv("SPECTATOR_AUTH_REQUIRED", "false") == "true",
+
+		// Queue rehydration after a restart
+		RehydrateMinTTLSeconds: getEnvInt("REHYDRATE_MIN_TTL_SECONDS", 30),
+
+		// Per-tier concurrent game caps (none by default)
+		StakeTierMaxGames: getEnvIntMap("STAKE_TIER_MAX_GAMES"),
+
+		// Shorter both-connect timeouts for high-stake tiers (none by default)
+		StakeTierConnectSeconds: getEnvIntMap("STAKE_TIER_CONNECT_SECONDS"),
+
+		// Opponent ratings are banded unless turned off
+		OpponentRatingBands: getEnv("OPPONENT_RATING_BANDS", "true") == "true",
+
+		// Reconnect backoff hint for refused WebSocket clients
+		WSRetryAfterSeconds: getEnvInt("WS_RETRY_AFTER_SECONDS", 5),
+
+		// WebSocket ping/pong keepalive
+		WSPingIntervalSeconds: getEnvInt("WS_PING_INTERVAL_SECONDS", 30),
+		WSPongTimeoutSeconds:  getEnvInt("WS_PONG_TIMEOUT_SECONDS", 60),
+
+		// High-stake payout hold for disputes (off by default)
+		PayoutHoldMinStake: getEnvInt("PAYOUT_HOLD_MIN_STAKE", 0),
+		PayoutHoldSeconds:  getEnvInt("PAYOUT_HOLD_SECONDS", 900),
+
+		// Temporary blocks for repeated no-shows and disconnect forfeits
+		AbuseStrikeThreshold: getEnvInt("ABUSE_STRIKE_THRESHOLD", 0),
+		AbuseBlockHours:      getEnvInt("ABUSE_BLOCK_HOURS", 24),
 	}
 }
 
@@ -204,3 +599,35 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvIntMap parses "k:v,k:v" into a map, skipping malformed pairs
+func getEnvIntMap(key string) map[int]int {
+	m := make(map[int]int)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		ki, err1 := strconv.Atoi(strings.TrimSpace(k))
+		vi, err2 := strconv.Atoi(strings.TrimSpace(v))
+		if err1 == nil && err2 == nil {
+			m[ki] = vi
+		}
+	}
+	return m
+}
+
+// getEnvNamedIntMap parses "name:v,name:v" into a map, skipping malformed pairs
+func getEnvNamedIntMap(key string) map[string]int {
+	m := make(map[string]int)
+	for _, pair := range strings.Split(os.Getenv(key), ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		if vi, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && strings.TrimSpace(k) != "" {
+			m[strings.TrimSpace(k)] = vi
+		}
+	}
+	return m
+}
@@ -0,0 +1,71 @@
+package game
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
+)
+
+// Strike kinds, named after the players column that counts them
+const (
+	StrikeNoShow     = "no_show_count"    // matched but never connected before the game expired
+	StrikeDisconnect = "disconnect_count" // forfeited by staying disconnected past the grace period
+)
+
+// maxBlockDoublings caps how far repeat blocks escalate (AbuseBlockHours << 5 = 32x)
+const maxBlockDoublings = 5
+
+// strikeBlockDuration returns how long a player with strikes strikes is blocked for, or 0 when
+// strikes doesn't complete a block: every AbuseStrikeThreshold strikes block for AbuseBlockHours,
+// and each further block lasts twice as long as the one before.
+func (gm *GameManager) strikeBlockDuration(strikes int) time.Duration {
+	threshold := gm.config.AbuseStrikeThreshold
+	if threshold <= 0 || gm.config.AbuseBlockHours <= 0 || strikes <= 0 || strikes%threshold != 0 {
+		return 0
+	}
+	doublings := strikes/threshold - 1
+	if doublings > maxBlockDoublings {
+		doublings = maxBlockDoublings
+	}
+	return time.Duration(gm.config.AbuseBlockHours<<doublings) * time.Hour
+}
+
+// recordStrike counts a no-show or disconnect forfeit (kind) against the player and blocks them
+// from staking for a while when it completes a block. A longer or permanent block already in
+// force is left alone.
+func (gm *GameManager) recordStrike(playerDBID int, kind string) {
+	if gm.db == nil || playerDBID <= 0 || (kind != StrikeNoShow && kind != StrikeDisconnect) {
+		return
+	}
+	var strikes int
+	if err := gm.db.Get(&strikes, fmt.Sprintf(`UPDATE players SET %[1]s = COALESCE(%[1]s, 0) + 1 WHERE id=$1
+		RETURNING COALESCE(no_show_count, 0) + COALESCE(disconnect_count, 0)`, kind), playerDBID); err != nil {
+		log.Printf("[ABUSE] Failed to record %s for player %d: %v", kind, playerDBID, err)
+		return
+	}
+
+	d := gm.strikeBlockDuration(strikes)
+	if d == 0 {
+		return
+	}
+	until := time.Now().Add(d)
+	reason := fmt.Sprintf("%d missed or abandoned games", strikes)
+	var phone string
+	err := gm.db.Get(&phone, `UPDATE players SET is_blocked=TRUE, block_reason=$2, block_until=$3
+		WHERE id=$1 AND NOT (COALESCE(is_blocked, FALSE) AND (block_until IS NULL OR block_until >= $3))
+		RETURNING phone_number`, playerDBID, reason, until)
+	if err == sql.ErrNoRows {
+		return // already blocked for longer
+	}
+	if err != nil {
+		log.Printf("[ABUSE] Failed to block player %d after %d strikes: %v", playerDBID, strikes, err)
+		return
+	}
+	log.Printf("[ABUSE] Blocked player %d until %s after %d strikes", playerDBID, until.Format(time.RFC3339), strikes)
+	sms.EnqueueTemplate(sms.TypeAccount, phone, templates.StakingSuspended,
+		templates.Params{"Strikes": strikes, "Until": until.Format("02 Jan 2006 15:04")})
+}
@@ -0,0 +1,73 @@
+package game
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestStrikeBlockDurationEscalates(t *testing.T) {
+	gm := NewGameManager(nil, nil, &config.Config{AbuseStrikeThreshold: 3, AbuseBlockHours: 24})
+	cases := map[int]time.Duration{
+		1:  0,
+		2:  0,
+		3:  24 * time.Hour,
+		4:  0,
+		6:  48 * time.Hour,
+		9:  96 * time.Hour,
+		60: 32 * 24 * time.Hour, // capped
+	}
+	for strikes, want := range cases {
+		if got := gm.strikeBlockDuration(strikes); got != want {
+			t.Errorf("%d strikes: block %v, want %v", strikes, got, want)
+		}
+	}
+	if NewGameManager(nil, nil, &config.Config{AbuseBlockHours: 24}).strikeBlockDuration(3) != 0 {
+		t.Error("blocked with strikes turned off")
+	}
+}
+
+func TestNoShowCountsAndBlocksAtThreshold(t *testing.T) {
+	db := testDB(t)
+	gm := NewGameManager(db, nil, &config.Config{GameExpiryMinutes: 3, AbuseStrikeThreshold: 2, AbuseBlockHours: 24})
+	prev := Manager
+	t.Cleanup(func() { Manager = prev })
+	Manager = gm
+
+	g := NewPoolGame("g-noshow", "tok-noshow", "a1", "256700000001", "t1", 0, "A1", "a2", "256700000002", "t2", 0, "A2", 1000)
+	for i, p := range []*PoolPlayer{g.Player1, g.Player2} {
+		phone := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+int64(i))%100000000)
+		if err := db.Get(&p.DBPlayerID, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+			t.Fatalf("insert player: %v", err)
+		}
+	}
+	// Player 2 already walked away from one game
+	if _, err := db.Exec(`UPDATE players SET disconnect_count = 1 WHERE id=$1`, g.Player2.DBPlayerID); err != nil {
+		t.Fatalf("seed strike: %v", err)
+	}
+
+	g.MarkPlayerShowedUp(g.Player1.ID)
+	g.ExpiresAt = time.Now().Add(-time.Second)
+	gm.games[g.ID] = g
+	gm.checkExpiredGames()
+
+	var p struct {
+		NoShows int          `db:"no_show_count"`
+		Blocked bool         `db:"is_blocked"`
+		Until   sql.NullTime `db:"block_until"`
+	}
+	db.Get(&p, `SELECT no_show_count, COALESCE(is_blocked, FALSE) AS is_blocked, block_until FROM players WHERE id=$1`, g.Player1.DBPlayerID)
+	if p.NoShows != 0 || p.Blocked {
+		t.Fatalf("player who showed up: %+v, want no strike", p)
+	}
+	db.Get(&p, `SELECT no_show_count, COALESCE(is_blocked, FALSE) AS is_blocked, block_until FROM players WHERE id=$1`, g.Player2.DBPlayerID)
+	if p.NoShows != 1 {
+		t.Fatalf("no-show count %d, want 1", p.NoShows)
+	}
+	if !p.Blocked || !p.Until.Valid || p.Until.Time.Before(time.Now().Add(23*time.Hour)) || p.Until.Time.After(time.Now().Add(25*time.Hour)) {
+		t.Fatalf("second strike: blocked %v until %v, want a 24h block", p.Blocked, p.Until)
+	}
+}
@@ -0,0 +1,160 @@
+package game
+
+import (
+	"database/sql"
+	"log"
+	"math"
+	"time"
+)
+
+// DefaultEloRating is the rating every player starts on (players.elo_rating default)
+const DefaultEloRating = 1200
+
+// Rating bands shown instead of an opponent's exact rating (lower bound of each band)
+const (
+	ratingBandSilver   = 1100
+	ratingBandGold     = 1300
+	ratingBandPlatinum = 1500
+)
+
+// RatingBand returns the coarse band a rating falls in
+func RatingBand(rating int) string {
+	switch {
+	case rating >= ratingBandPlatinum:
+		return "Platinum"
+	case rating >= ratingBandGold:
+		return "Gold"
+	case rating >= ratingBandSilver:
+		return "Silver"
+	default:
+		return "Bronze"
+	}
+}
+
+// opponentRatingBanded reports whether game state hides the opponent's exact rating.
+// Defaults to on when no manager config is loaded (tests, tools).
+func opponentRatingBanded() bool {
+	if Manager != nil && Manager.config != nil {
+		return Manager.config.OpponentRatingBands
+	}
+	return true
+}
+
+// EloExpected returns the expected score (0..1) of a player rated ra against one rated rb
+func EloExpected(ra, rb int) float64 {
+	return 1 / (1 + math.Pow(10, float64(rb-ra)/400))
+}
+
+// EloUpdate returns both ratings after a game. scoreA is 1 when A won, 0 when B won and
+// 0.5 for a draw, which moves the two ratings towards each other.
+func EloUpdate(ra, rb int, scoreA float64, k int) (int, int) {
+	delta := int(math.Round(float64(k) * (scoreA - EloExpected(ra, rb))))
+	return ra + delta, rb - delta
+}
+
+// RankedRatingWindow returns how far apart two ratings may be for a ranked match once the
+// longer-waiting player has been queued for waited
+func RankedRatingWindow(waited time.Duration, base, growthPerMinute int) int {
+	if waited < 0 {
+		waited = 0
+	}
+	return base + int(waited.Minutes()*float64(growthPerMinute))
+}
+
+// withinRankedWindow reports whether ratings ra and rb may be matched after waited
+func (gm *GameManager) withinRankedWindow(ra, rb int, waited time.Duration) bool {
+	diff := ra - rb
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= RankedRatingWindow(waited, gm.config.RankedRatingWindow, gm.config.RankedWindowGrowthPerMinute)
+}
+
+// updateEloRatings applies the result of a completed game to both players' ratings.
+// winnerDBID is ignored for draws.
+func (gm *GameManager) updateEloRatings(g *PoolGameState, winnerDBID int) {
+	if g.Player1 == nil || g.Player2 == nil || g.Player1.DBPlayerID == 0 || g.Player2.DBPlayerID == 0 {
+		return
+	}
+	p1, p2 := g.Player1.DBPlayerID, g.Player2.DBPlayerID
+
+	score := 0.5
+	if g.WinType != "draw" {
+		switch winnerDBID {
+		case p1:
+			score = 1
+		case p2:
+			score = 0
+		default:
+			return
+		}
+	}
+
+	tx, err := gm.db.Beginx()
+	if err != nil {
+		log.Printf("[DB] Failed to begin tx for ELO update session %d: %v", g.SessionID, err)
+		return
+	}
+	defer tx.Rollback()
+
+	var r1, r2 int
+	if err := tx.Get(&r1, `SELECT elo_rating FROM players WHERE id=$1 FOR UPDATE`, p1); err != nil {
+		log.Printf("[DB] Failed to read ELO rating of player %d: %v", p1, err)
+		return
+	}
+	if err := tx.Get(&r2, `SELECT elo_rating FROM players WHERE id=$1 FOR UPDATE`, p2); err != nil {
+		log.Printf("[DB] Failed to read ELO rating of player %d: %v", p2, err)
+		return
+	}
+
+	n1, n2 := EloUpdate(r1, r2, score, gm.config.EloKFactor)
+	if _, err := tx.Exec(`UPDATE players SET elo_rating = CASE id WHEN $1 THEN $2::int ELSE $4::int END WHERE id IN ($1, $3)`, p1, n1, p2, n2); err != nil {
+		log.Printf("[DB] Failed to update ELO ratings for session %d: %v", g.SessionID, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("[DB] Failed to commit ELO update for session %d: %v", g.SessionID, err)
+		return
+	}
+	log.Printf("[DB] ELO session=%d: player %d %d->%d, player %d %d->%d", g.SessionID, p1, r1, n1, p2, r2, n2)
+}
+
+// rankedProfile returns a queued player's rating and when they joined the queue
+func (gm *GameManager) rankedProfile(queueID int) (int, time.Time, error) {
+	var row struct {
+		Rating   sql.NullInt64 `db:"elo_rating"`
+		JoinedAt time.Time     `db:"created_at"`
+	}
+	err := gm.db.Get(&row, `SELECT p.elo_rating, q.created_at FROM matchmaking_queue q LEFT JOIN players p ON p.id = q.player_id WHERE q.id=$1`, queueID)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	rating := DefaultEloRating
+	if row.Rating.Valid {
+		rating = int(row.Rating.Int64)
+	}
+	return rating, row.JoinedAt, nil
+}
+
+// attachRatings copies both players' current ratings onto a new game for its state payload
+func (gm *GameManager) attachRatings(g *PoolGameState) {
+	if gm.db == nil || g.Player1.DBPlayerID == 0 || g.Player2.DBPlayerID == 0 {
+		return
+	}
+	var rows []struct {
+		ID     int `db:"id"`
+		Rating int `db:"elo_rating"`
+	}
+	if err := gm.db.Select(&rows, `SELECT id, elo_rating FROM players WHERE id IN ($1, $2)`, g.Player1.DBPlayerID, g.Player2.DBPlayerID); err != nil {
+		log.Printf("[DB] Failed to load ratings for game %s: %v", g.ID, err)
+		return
+	}
+	for _, r := range rows {
+		if r.ID == g.Player1.DBPlayerID {
+			g.Player1.Rating = r.Rating
+		}
+		if r.ID == g.Player2.DBPlayerID {
+			g.Player2.Rating = r.Rating
+		}
+	}
+}
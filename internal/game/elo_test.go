@@ -0,0 +1,81 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestEloUpsetGainsMore(t *testing.T) {
+	// Beating a higher-rated opponent is worth more than beating an equal one
+	upset, favourite := EloUpdate(1200, 1400, 1, 32)
+	even, _ := EloUpdate(1200, 1200, 1, 32)
+	if upset-1200 <= even-1200 {
+		t.Fatalf("upset win gained %d, even win gained %d", upset-1200, even-1200)
+	}
+	if even != 1216 {
+		t.Fatalf("even win = %d, want 1216", even)
+	}
+	if upset+favourite != 2600 {
+		t.Fatalf("ratings not conserved: %d + %d", upset, favourite)
+	}
+}
+
+func TestEloDrawNudgesRatingsTogether(t *testing.T) {
+	low, high := EloUpdate(1100, 1500, 0.5, 32)
+	if low <= 1100 || high >= 1500 {
+		t.Fatalf("draw gave %d/%d, want both to move towards each other", low, high)
+	}
+	if a, b := EloUpdate(1300, 1300, 0.5, 32); a != 1300 || b != 1300 {
+		t.Fatalf("draw between equals moved ratings to %d/%d", a, b)
+	}
+}
+
+func TestRankedWindowWidensWithWait(t *testing.T) {
+	gm := &GameManager{config: &config.Config{RankedRatingWindow: 100, RankedWindowGrowthPerMinute: 100}}
+
+	if gm.withinRankedWindow(1200, 1450, 0) {
+		t.Fatal("250 apart matched without waiting")
+	}
+	if !gm.withinRankedWindow(1200, 1290, 0) {
+		t.Fatal("90 apart should match straight away")
+	}
+	if gm.withinRankedWindow(1200, 1450, time.Minute) {
+		t.Fatal("250 apart matched after one minute (window 200)")
+	}
+	if !gm.withinRankedWindow(1450, 1200, 90*time.Second) {
+		t.Fatal("250 apart should match after 90s (window 250)")
+	}
+	if got := RankedRatingWindow(-time.Second, 100, 100); got != 100 {
+		t.Fatalf("window for negative wait = %d, want base 100", got)
+	}
+}
+
+func TestOpponentRatingBandedOwnRatingExact(t *testing.T) {
+	prev := Manager
+	t.Cleanup(func() { Manager = prev })
+
+	g := newTestPoolGame(t)
+	g.Player1.Rating, g.Player2.Rating = 1234, 1560
+
+	Manager = NewGameManager(nil, nil, &config.Config{OpponentRatingBands: true})
+	state := g.GetGameStateForPlayer("p1")
+	if state["my_rating"] != 1234 {
+		t.Fatalf("my_rating = %v, want exact 1234", state["my_rating"])
+	}
+	if state["opponent_rating_band"] != "Platinum" {
+		t.Fatalf("opponent_rating_band = %v, want Platinum", state["opponent_rating_band"])
+	}
+	if _, leaked := state["opponent_rating"]; leaked {
+		t.Fatal("exact opponent rating sent while banding is on")
+	}
+	if state := g.GetGameStateForPlayer("p2"); state["my_rating"] != 1560 || state["opponent_rating_band"] != "Silver" {
+		t.Fatalf("player 2 sees my_rating %v band %v, want 1560/Silver", state["my_rating"], state["opponent_rating_band"])
+	}
+
+	Manager = NewGameManager(nil, nil, &config.Config{OpponentRatingBands: false})
+	if state := g.GetGameStateForPlayer("p1"); state["opponent_rating"] != 1560 {
+		t.Fatalf("opponent_rating = %v with banding off, want 1560", state["opponent_rating"])
+	}
+}
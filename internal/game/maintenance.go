@@ -0,0 +1,82 @@
+package game
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// maintenanceTick is how often the coordinating worker looks for due tasks (a variable so
+// tests can shorten it)
+var maintenanceTick = time.Second
+
+// maintenanceTask is one periodic check run by the coordinating maintenance worker
+type maintenanceTask struct {
+	name     string
+	interval time.Duration
+	run      func()
+	lastRun  time.Time
+	next     time.Time
+}
+
+// schedule sets the task's next run one interval after from, plus up to 10% jitter so tasks
+// sharing an interval don't all land on the same tick
+func (t *maintenanceTask) schedule(from time.Time) {
+	jitter := time.Duration(0)
+	if spread := int64(t.interval / 10); spread > 0 {
+		jitter = time.Duration(rand.Int63n(spread + 1))
+	}
+	t.next = from.Add(t.interval + jitter)
+}
+
+// maintenanceTasks lists the checks the separate Start*Checker workers otherwise run, at
+// the same intervals. Queue checks need both the DB and Redis, as before.
+func (gm *GameManager) maintenanceTasks() []*maintenanceTask {
+	tasks := []*maintenanceTask{
+		{name: "game expiry", interval: expiryCheckInterval, run: gm.checkExpiredGames},
+		{name: "disconnect forfeits", interval: disconnectCheckInterval, run: gm.checkDisconnectForfeits},
+		{name: "payout holds", interval: payoutHoldCheckInterval, run: func() { gm.ReleaseDuePayouts() }},
+	}
+	if gm.db != nil && gm.rdb != nil {
+		tasks = append(tasks,
+			&maintenanceTask{name: "queue expiry", interval: queueExpiryCheckInterval, run: gm.expireQueuedEntriesLogged},
+			&maintenanceTask{name: "processing recovery", interval: gm.processingRecoveryInterval(), run: gm.requeueStuckProcessingLogged},
+		)
+	}
+	return tasks
+}
+
+// StartMaintenanceWorker runs every periodic check from one goroutine until ctx is cancelled
+func (gm *GameManager) StartMaintenanceWorker(ctx context.Context) {
+	gm.runMaintenance(ctx, gm.maintenanceTasks())
+}
+
+// runMaintenance runs each task whenever its next run is due, one at a time, until ctx is
+// cancelled
+func (gm *GameManager) runMaintenance(ctx context.Context, tasks []*maintenanceTask) {
+	now := time.Now()
+	for _, t := range tasks {
+		t.schedule(now)
+	}
+	ticker := time.NewTicker(maintenanceTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for _, t := range tasks {
+				if ctx.Err() != nil {
+					return
+				}
+				if now.Before(t.next) {
+					continue
+				}
+				t.run()
+				t.lastRun = now
+				t.schedule(now)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package game
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestMaintenanceRunsEachTaskAtItsCadence(t *testing.T) {
+	prevTick := maintenanceTick
+	maintenanceTick = 5 * time.Millisecond
+	t.Cleanup(func() { maintenanceTick = prevTick })
+
+	var mu sync.Mutex
+	runs := map[string]int{}
+	task := func(name string, interval time.Duration) *maintenanceTask {
+		return &maintenanceTask{name: name, interval: interval, run: func() {
+			mu.Lock()
+			runs[name]++
+			mu.Unlock()
+		}}
+	}
+	fast, slow := task("fast", 40*time.Millisecond), task("slow", 100*time.Millisecond)
+
+	gm := NewGameManager(nil, nil, &config.Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		gm.runMaintenance(ctx, []*maintenanceTask{fast, slow})
+		close(done)
+	}()
+	time.Sleep(520 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("maintenance loop still running after cancel")
+	}
+
+	// 520ms at 40ms (+ up to 10% jitter and a tick of lag) and at 100ms
+	mu.Lock()
+	defer mu.Unlock()
+	if n := runs["fast"]; n < 8 || n > 13 {
+		t.Errorf("fast task ran %d times, want about 11", n)
+	}
+	if n := runs["slow"]; n < 3 || n > 5 {
+		t.Errorf("slow task ran %d times, want about 5", n)
+	}
+	if fast.lastRun.IsZero() || slow.lastRun.IsZero() {
+		t.Error("last run not recorded")
+	}
+}
+
+func TestMaintenanceTasksMatchSeparateCheckers(t *testing.T) {
+	gm := NewGameManager(nil, nil, &config.Config{QueueProcessingVisibility: 60})
+	want := map[string]time.Duration{
+		"game expiry":         expiryCheckInterval,
+		"disconnect forfeits": disconnectCheckInterval,
+		"payout holds":        payoutHoldCheckInterval,
+	}
+	tasks := gm.maintenanceTasks()
+	if len(tasks) != len(want) {
+		t.Fatalf("%d tasks without DB and Redis, want %d (queue checks need both)", len(tasks), len(want))
+	}
+	for _, task := range tasks {
+		if want[task.name] != task.interval {
+			t.Errorf("%s every %v, want %v", task.name, task.interval, want[task.name])
+		}
+	}
+	if got := gm.processingRecoveryInterval(); got != 30*time.Second {
+		t.Errorf("processing recovery every %v, want half the 60s visibility", got)
+	}
+}
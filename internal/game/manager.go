@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"strconv"
 	"sync"
 	"time"
@@ -16,8 +17,10 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/playpool/backend/internal/accounts"
 	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/logger"
 	"github.com/playpool/backend/internal/models"
 	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -30,8 +33,25 @@ type GameManager struct {
 	db               *sqlx.DB                  // SQL DB for persistent records
 	config           *config.Config            // Application config
 	mu               sync.RWMutex
+
+	stop    context.CancelFunc // cancels the background checkers started by InitializeManager
+	workers sync.WaitGroup     // running background checkers
+
+	tournaments *TournamentManager // advanced when a tournament session finishes (nil = none)
+
+	moveRateMu sync.Mutex
+	moveRate   map[int]*moveWindow // session ID -> moves recorded in the current minute
+
+	matchStats matchStats // Redis matching counters, see MatchStats
 }
 
+// Background checker intervals (variables so tests can shorten them)
+var (
+	expiryCheckInterval      = 30 * time.Second
+	disconnectCheckInterval  = 10 * time.Second
+	queueExpiryCheckInterval = time.Minute
+)
+
 // QueueEntry represents a player in the matchmaking queue
 type QueueEntry struct {
 	QueueToken  string
@@ -64,23 +84,55 @@ var (
 	Manager *GameManager
 )
 
-// InitializeManager initializes the global game manager with Redis, DB and config
-func InitializeManager(db *sqlx.DB, rdb *redis.Client, cfg *config.Config) {
+// InitializeManager initializes the global game manager with Redis, DB and config.
+// Its background jobs stop when ctx is cancelled.
+func InitializeManager(ctx context.Context, db *sqlx.DB, rdb *redis.Client, cfg *config.Config) {
 	Manager = NewGameManager(db, rdb, cfg)
+	Tournaments = NewTournamentManager(db, Manager)
+	ctx, Manager.stop = context.WithCancel(ctx)
 	// Start background jobs
-	go Manager.StartExpiryChecker()
-	go Manager.StartDisconnectChecker()
-	// Rehydrate queue from DB into Redis (if configured)
+	if !cfg.BatchedMaintenance {
+		Manager.startWorker(ctx, Manager.StartExpiryChecker)
+		Manager.startWorker(ctx, Manager.StartDisconnectChecker)
+	}
+	// Move ids left in the old list queues into the sorted sets, then rehydrate from the DB
+	if _, err := Manager.MigrateQueueLists(); err != nil {
+		log.Printf("[MIGRATE] Error migrating queue lists: %v", err)
+	}
 	if err := Manager.RehydrateQueueFromDB(); err != nil {
 		log.Printf("[REHYDRATE] Error rehydrating queue from DB: %v", err)
 	}
 	// Recover in-progress games from Redis
-	if err := Manager.RecoverGamesFromRedis(); err != nil {
-		log.Printf("[RECOVERY] Error recovering games from Redis: %v", err)
+	if cfg.ResumeGamesOnRestart {
+		if err := Manager.RecoverGamesFromRedis(); err != nil {
+			log.Printf("[RECOVERY] Error recovering games from Redis: %v", err)
+		}
+	}
+	if cfg.BatchedMaintenance {
+		Manager.startWorker(ctx, Manager.StartMaintenanceWorker)
+		return
 	}
 	// Start queue expiry checker
-	go Manager.StartQueueExpiryChecker()
-	go Manager.StartProcessingRecoveryChecker()
+	Manager.startWorker(ctx, Manager.StartQueueExpiryChecker)
+	Manager.startWorker(ctx, Manager.StartProcessingRecoveryChecker)
+	Manager.startWorker(ctx, Manager.StartPayoutHoldReleaser)
+}
+
+// startWorker runs a background checker in its own goroutine, tracked so Stop can wait for it
+func (gm *GameManager) startWorker(ctx context.Context, run func(context.Context)) {
+	gm.workers.Add(1)
+	go func() {
+		defer gm.workers.Done()
+		run(ctx)
+	}()
+}
+
+// Stop cancels the background checkers and waits for them to return
+func (gm *GameManager) Stop() {
+	if gm.stop != nil {
+		gm.stop()
+	}
+	gm.workers.Wait()
 }
 
 // NewGameManager creates a new game manager
@@ -271,6 +323,21 @@ func (gm *GameManager) GetGame(gameID string) (*PoolGameState, error) {
 	return nil, errors.New("game not found")
 }
 
+// FindGame looks up an in-memory game by its ID, token or DB session id
+func (gm *GameManager) FindGame(ref string) (*PoolGameState, error) {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	if game, ok := gm.games[ref]; ok {
+		return game, nil
+	}
+	for _, game := range gm.games {
+		if game.Token == ref || (game.SessionID > 0 && strconv.Itoa(game.SessionID) == ref) {
+			return game, nil
+		}
+	}
+	return nil, errors.New("game not found")
+}
+
 // GetGameByToken retrieves a game by its token
 func (gm *GameManager) GetGameByToken(token string) (*PoolGameState, error) {
 	gm.mu.RLock()
@@ -284,9 +351,61 @@ func (gm *GameManager) GetGameByToken(token string) (*PoolGameState, error) {
 	gm.mu.RUnlock()
 
 	log.Printf("[DEBUG] Game %s not found in memory", token)
+
+	// Fell out of memory (or predates a restart): resume it from Redis if it is still live
+	if game, err := gm.loadPoolGameByToken(token); err == nil {
+		return game, nil
+	} else if gm.rdb != nil {
+		log.Printf("[DEBUG] Game %s not reloaded from Redis: %v", token, err)
+	}
 	return nil, errors.New("game not found")
 }
 
+// PersistAllGames writes every unfinished in-memory game to Redis so another instance (or
+// this one after a restart) can pick it up. It returns how many games were saved.
+func (gm *GameManager) PersistAllGames() int {
+	gm.mu.RLock()
+	games := make([]*PoolGameState, 0, len(gm.games))
+	for _, game := range gm.games {
+		games = append(games, game)
+	}
+	gm.mu.RUnlock()
+
+	saved := 0
+	for _, game := range games {
+		// Hold the read lock so a shot landing mid-shutdown can't tear the snapshot
+		game.mu.RLock()
+		var err error
+		done := game.Status == StatusCompleted || game.Status == StatusCancelled
+		if !done {
+			err = gm.savePoolGameToRedis(game)
+		}
+		game.mu.RUnlock()
+		if done {
+			continue
+		}
+		if err != nil {
+			log.Printf("[SHUTDOWN] Failed to persist game %s: %v", game.ID, err)
+			continue
+		}
+		saved++
+	}
+	return saved
+}
+
+// ActivePoolGames returns the games currently in progress.
+func (gm *GameManager) ActivePoolGames() []*PoolGameState {
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	games := make([]*PoolGameState, 0, len(gm.games))
+	for _, game := range gm.games {
+		if game.Status == StatusInProgress {
+			games = append(games, game)
+		}
+	}
+	return games
+}
+
 // GetGameForPlayer retrieves the active game for a player
 func (gm *GameManager) GetGameForPlayer(playerID string) (*PoolGameState, error) {
 	gm.mu.RLock()
@@ -432,96 +551,22 @@ func (gm *GameManager) RecoverGamesFromRedis() error {
 				continue
 			}
 
-			var gameData map[string]interface{}
-			if err := json.Unmarshal([]byte(data), &gameData); err != nil {
-				log.Printf("[RECOVERY] Failed to unmarshal game %s: %v", token, err)
-				continue
-			}
-
-			// Only recover pool games
-			gameType, _ := gameData["game_type"].(string)
-			if gameType != "pool" {
+			game, err := loadPoolGameFromRedis([]byte(data))
+			if errors.Is(err, ErrUnknownGameStatus) {
+				log.Printf("[RECOVERY] Game %s not resumed, needs admin attention: %v", token, err)
 				continue
 			}
-
-			status, _ := gameData["status"].(string)
-			if GameStatus(status) == StatusCompleted {
+			if err != nil {
+				log.Printf("[RECOVERY] Skipping game %s: %v", token, err)
 				continue
 			}
-
-			// Reconstruct pool game state from Redis data
-			game := &PoolGameState{
-				LastActivity: time.Now(),
-			}
-			if id, ok := gameData["id"].(string); ok {
-				game.ID = id
-			}
-			if tok, ok := gameData["token"].(string); ok {
-				game.Token = tok
-			}
-			if ct, ok := gameData["current_turn"].(string); ok {
-				game.CurrentTurn = ct
-			}
-			if s, ok := gameData["status"].(string); ok {
-				game.Status = GameStatus(s)
-			}
-			if w, ok := gameData["winner"].(string); ok {
-				game.Winner = w
-			}
-			if wt, ok := gameData["win_type"].(string); ok {
-				game.WinType = wt
-			}
-			if sa, ok := gameData["stake_amount"].(float64); ok {
-				game.StakeAmount = int(sa)
-			}
-			if sn, ok := gameData["shot_number"].(float64); ok {
-				game.ShotNumber = int(sn)
-			}
-			if ib, ok := gameData["is_break_shot"].(bool); ok {
-				game.IsBreakShot = ib
-			}
-			if bih, ok := gameData["ball_in_hand"].(bool); ok {
-				game.BallInHand = bih
-			}
-			if bihp, ok := gameData["ball_in_hand_player"].(string); ok {
-				game.BallInHandPlayer = bihp
-			}
-			if sid, ok := gameData["session_id"].(float64); ok {
-				game.SessionID = int(sid)
-			}
-			if ca, ok := gameData["created_at"].(string); ok {
-				if t, err := time.Parse(time.RFC3339, ca); err == nil {
-					game.CreatedAt = t
-				}
-			}
-			if sa, ok := gameData["started_at"]; ok && sa != nil {
-				if saStr, ok := sa.(string); ok {
-					if t, err := time.Parse(time.RFC3339, saStr); err == nil {
-						game.StartedAt = &t
-					}
-				}
-			}
-			// Parse players from JSON
-			if p1Data, ok := gameData["player1"].(map[string]interface{}); ok {
-				game.Player1 = parsePoolPlayerFromData(p1Data)
-			}
-			if p2Data, ok := gameData["player2"].(map[string]interface{}); ok {
-				game.Player2 = parsePoolPlayerFromData(p2Data)
-			}
-
-			if game.ID == "" || game.Player1 == nil || game.Player2 == nil {
-				log.Printf("[RECOVERY] Skipping incomplete game %s", token)
+			if game.Status == StatusCompleted || game.Status == StatusCancelled {
 				continue
 			}
 
-			gm.mu.Lock()
-			if _, exists := gm.games[game.ID]; !exists {
-				gm.games[game.ID] = game
-				gm.playerToGame[game.Player1.ID] = game.ID
-				gm.playerToGame[game.Player2.ID] = game.ID
+			if gm.adoptPoolGame(game) == game {
 				recovered++
 			}
-			gm.mu.Unlock()
 		}
 
 		cursor = nextCursor
@@ -534,39 +579,18 @@ func (gm *GameManager) RecoverGamesFromRedis() error {
 	return nil
 }
 
-// parsePoolPlayerFromData reconstructs a PoolPlayer from JSON data
-func parsePoolPlayerFromData(data map[string]interface{}) *PoolPlayer {
-	player := &PoolPlayer{
-		Connected: false, // Reset connection status
-		BallGroup: GroupAny,
-	}
-
-	if id, ok := data["id"].(string); ok {
-		player.ID = id
-	}
-	if phoneNumber, ok := data["phone_number"].(string); ok {
-		player.PhoneNumber = phoneNumber
-	}
-	if displayName, ok := data["display_name"].(string); ok {
-		player.DisplayName = displayName
-	}
-	if dbID, ok := data["db_player_id"].(float64); ok {
-		player.DBPlayerID = int(dbID)
-	}
-	if bg, ok := data["ball_group"].(string); ok {
-		player.BallGroup = BallGroup(bg)
-	}
-
-	return player
-}
-
-// StartExpiryChecker runs a background job to check for expired games
-func (gm *GameManager) StartExpiryChecker() {
-	ticker := time.NewTicker(30 * time.Second)
+// StartExpiryChecker runs a background job to check for expired games until ctx is cancelled
+func (gm *GameManager) StartExpiryChecker(ctx context.Context) {
+	ticker := time.NewTicker(expiryCheckInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		gm.checkExpiredGames()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			gm.checkExpiredGames()
+		}
 	}
 }
 
@@ -587,6 +611,12 @@ func (gm *GameManager) checkExpiredGames() {
 		// Re-check under lock to avoid races
 		g.mu.RLock()
 		isWaiting := g.Status == StatusWaiting
+		var noShows []int
+		for _, p := range []*PoolPlayer{g.Player1, g.Player2} {
+			if p != nil && !p.ShowedUp {
+				noShows = append(noShows, p.DBPlayerID)
+			}
+		}
 		g.mu.RUnlock()
 		if !isWaiting {
 			continue
@@ -594,127 +624,206 @@ func (gm *GameManager) checkExpiredGames() {
 
 		log.Printf("[EXPIRY] Game %s expired; processing cancellation", g.ID)
 
-		// Attempt DB refund if persisted
-		if gm.db != nil && g.SessionID > 0 {
-			p1ID := 0
-			p2ID := 0
-			if g.Player1 != nil {
-				p1ID = g.Player1.DBPlayerID
-			}
-			if g.Player2 != nil {
-				p2ID = g.Player2.DBPlayerID
+		gm.cancelSession(g, accounts.RefundSessionExpired, "Session expired - refund to player", "Game cancelled due to expiry; stakes returned to players.")
+		for _, id := range noShows {
+			gm.recordStrike(id, StrikeNoShow)
+		}
+	}
+}
+
+// ErrSessionSettled is returned when a session's stakes were already paid out or refunded
+var ErrSessionSettled = errors.New("session already paid out or refunded")
+
+// cancelSession refunds both stakes (with reason and description on the escrow ledger), marks
+// the game cancelled in memory and in the DB and tells the clients with message
+func (gm *GameManager) cancelSession(g *PoolGameState, reason, description, message string) {
+	// Attempt DB refund if persisted (tournament games hold no stake)
+	if gm.db != nil && g.SessionID > 0 && g.StakeAmount > 0 {
+		p1ID := 0
+		p2ID := 0
+		if g.Player1 != nil {
+			p1ID = g.Player1.DBPlayerID
+		}
+		if g.Player2 != nil {
+			p2ID = g.Player2.DBPlayerID
+		}
+		if p1ID > 0 && p2ID > 0 {
+			if err := gm.refundSessionStakes(g.SessionID, p1ID, p2ID, float64(g.StakeAmount), reason, description); err == ErrSessionSettled {
+				log.Printf("[DB] Session %d already settled; no cancel refund", g.SessionID)
+			} else if err != nil {
+				log.Printf("[DB] Cancel refund failed for session %d: %v", g.SessionID, err)
+			} else {
+				log.Printf("[DB] Cancel refund processed for session %d", g.SessionID)
 			}
-			if p1ID > 0 && p2ID > 0 {
-				tx, err := gm.db.Beginx()
-				if err != nil {
-					log.Printf("[DB] Failed to begin tx for expiry refund session %d: %v", g.SessionID, err)
-				} else {
-					// Idempotency: skip if SESSION_CANCEL already exists
-					var cnt int
-					if err := tx.Get(&cnt, `SELECT COUNT(*) FROM escrow_ledger WHERE session_id=$1 AND entry_type='SESSION_CANCEL'`, g.SessionID); err != nil {
-						log.Printf("[DB] Failed to check existing session cancel ledger for session %d: %v", g.SessionID, err)
-						tx.Rollback()
-					} else if cnt > 0 {
-						log.Printf("[DB] Session cancel already processed for session %d", g.SessionID)
-						tx.Rollback()
-					} else {
-						// Resolve accounts
-						escrowAcc, err1 := accounts.GetOrCreateAccount(gm.db, accounts.AccountEscrow, nil)
-						p1Acc, err2 := accounts.GetOrCreateAccount(gm.db, accounts.AccountPlayerWinnings, &p1ID)
-						p2Acc, err3 := accounts.GetOrCreateAccount(gm.db, accounts.AccountPlayerWinnings, &p2ID)
-						if err1 != nil || err2 != nil || err3 != nil {
-							log.Printf("[DB] Failed to resolve accounts for expiry refund session %d: %v %v %v", g.SessionID, err1, err2, err3)
-							tx.Rollback()
-						} else {
-							amount := float64(g.StakeAmount)
-							// Refund to player 1
-							if err := accounts.Transfer(tx, escrowAcc.ID, p1Acc.ID, amount, "SESSION", sql.NullInt64{Int64: int64(g.SessionID), Valid: true}, "SESSION_CANCEL"); err != nil {
-								log.Printf("[DB] Failed to transfer expiry refund to player %d for session %d: %v", p1ID, g.SessionID, err)
-								tx.Rollback()
-							} else {
-								if _, err := tx.Exec(`INSERT INTO escrow_ledger (session_id, entry_type, player_id, amount, balance_after, description, created_at) VALUES ($1,$2,$3,$4,$5,$6,NOW())`, g.SessionID, "SESSION_CANCEL", p1ID, amount, 0.0, "Session expired - refund to player"); err != nil {
-									log.Printf("[DB] Failed to insert escrow_ledger for session cancel (p1) session %d: %v", g.SessionID, err)
-									tx.Rollback()
-									goto cancel_end
-								}
-								if _, err := tx.Exec(`INSERT INTO transactions (player_id, transaction_type, amount, status, created_at) VALUES ($1,'REFUND',$2,'COMPLETED',NOW())`, p1ID, amount); err != nil {
-									log.Printf("[DB] Failed to insert transaction for expiry refund p1 session %d: %v", g.SessionID, err)
-								}
-							}
+		} else {
+			log.Printf("[DB] Cannot process cancel refund - missing DB player ids for game %s session %d", g.ID, g.SessionID)
+		}
+	} else {
+		log.Printf("[CANCEL] Skipping DB refund - no DB session or stake for game %s", g.ID)
+	}
 
-							// Refund to player 2
-							if err := accounts.Transfer(tx, escrowAcc.ID, p2Acc.ID, amount, "SESSION", sql.NullInt64{Int64: int64(g.SessionID), Valid: true}, "SESSION_CANCEL"); err != nil {
-								log.Printf("[DB] Failed to transfer expiry refund to player %d for session %d: %v", p2ID, g.SessionID, err)
-								tx.Rollback()
-							} else {
-								if _, err := tx.Exec(`INSERT INTO escrow_ledger (session_id, entry_type, player_id, amount, balance_after, description, created_at) VALUES ($1,$2,$3,$4,$5,$6,NOW())`, g.SessionID, "SESSION_CANCEL", p2ID, amount, 0.0, "Session expired - refund to player"); err != nil {
-									log.Printf("[DB] Failed to insert escrow_ledger for session cancel (p2) session %d: %v", g.SessionID, err)
-									tx.Rollback()
-									goto cancel_end
-								}
-								if _, err := tx.Exec(`INSERT INTO transactions (player_id, transaction_type, amount, status, created_at) VALUES ($1,'REFUND',$2,'COMPLETED',NOW())`, p2ID, amount); err != nil {
-									log.Printf("[DB] Failed to insert transaction for expiry refund p2 session %d: %v", g.SessionID, err)
-								}
-							}
+	gm.markCancelled(g, message)
+}
 
-							// Commit
-							if err := tx.Commit(); err != nil {
-								log.Printf("[DB] Failed to commit expiry refund tx for session %d: %v", g.SessionID, err)
-								tx.Rollback()
-							} else {
-								log.Printf("[DB] Expiry refund processed for session %d", g.SessionID)
-							}
-						}
-					}
-				cancel_end: // label
-				}
+// refundSessionStakes returns both players' stakes from escrow to their winnings, recorded as
+// refunds for reason. It returns ErrSessionSettled, refunding nothing, when the session was
+// already paid out or refunded.
+func (gm *GameManager) refundSessionStakes(sessionID, p1ID, p2ID int, amount float64, reason, description string) error {
+	tx, err := gm.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the session so concurrent cancels (expiry, admin) see each other's ledger rows
+	if _, err := tx.Exec(`SELECT id FROM game_sessions WHERE id=$1 FOR UPDATE`, sessionID); err != nil {
+		return fmt.Errorf("lock session: %w", err)
+	}
+	var cnt int
+	if err := tx.Get(&cnt, `SELECT COUNT(*) FROM escrow_ledger WHERE session_id=$1 AND entry_type IN ('REFUND', 'PAYOUT')`, sessionID); err != nil {
+		return fmt.Errorf("check settlement ledger: %w", err)
+	}
+	if cnt > 0 {
+		return ErrSessionSettled
+	}
+	// A payout still held for disputes is never paid once the stakes go back
+	if _, err := tx.Exec(`UPDATE payout_holds SET status=$2, resolved_at=NOW() WHERE session_id=$1 AND status IN ($3, $4)`, sessionID, HoldVoided, HoldHeld, HoldDisputed); err != nil {
+		return fmt.Errorf("void payout hold: %w", err)
+	}
+
+	escrowAcc, err := accounts.GetOrCreateAccount(gm.db, accounts.AccountEscrow, nil)
+	if err != nil {
+		return fmt.Errorf("escrow account: %w", err)
+	}
+	for _, pid := range []int{p1ID, p2ID} {
+		pid := pid
+		playerAcc, err := accounts.GetOrCreateAccount(gm.db, accounts.AccountPlayerWinnings, &pid)
+		if err != nil {
+			return fmt.Errorf("winnings account of player %d: %w", pid, err)
+		}
+		session := sql.NullInt64{Int64: int64(sessionID), Valid: true}
+		if err := accounts.Transfer(tx, escrowAcc.ID, playerAcc.ID, amount, "SESSION", session, reason, gm.config.SettlementOverdraftGuard); err != nil {
+			return fmt.Errorf("refund player %d: %w", pid, err)
+		}
+		if err := accounts.RecordRefund(tx, session, pid, amount, reason, description); err != nil {
+			return fmt.Errorf("escrow ledger for player %d: %w", pid, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO transactions (player_id, transaction_type, amount, status, created_at) VALUES ($1,'REFUND',$2,'COMPLETED',NOW())`, pid, amount); err != nil {
+			log.Printf("[DB] Failed to insert transaction for cancel refund of player %d session %d: %v", pid, sessionID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// markCancelled marks g cancelled in memory and in the DB and notifies the clients with message
+func (gm *GameManager) markCancelled(g *PoolGameState, message string) {
+	now2 := time.Now()
+	gm.mu.Lock()
+	g.Status = StatusCancelled
+	g.CompletedAt = &now2
+	delete(gm.playerToGame, g.Player1.ID)
+	delete(gm.playerToGame, g.Player2.ID)
+	gm.mu.Unlock()
+	go gm.resumeCappedTier(g.StakeAmount)
+
+	if gm.db != nil && g.SessionID > 0 {
+		if _, err := gm.db.Exec(`UPDATE game_sessions SET status=$1, completed_at=NOW() WHERE id=$2`, string(StatusCancelled), g.SessionID); err != nil {
+			log.Printf("[DB] Failed to update game_sessions for session %d to cancelled: %v", g.SessionID, err)
+		}
+		// A tournament game can't just be cancelled: someone has to advance
+		gm.tournaments.SessionFinished(g.SessionID, noShowWinner(g))
+	}
+	// Save the cancelled state so a reload can't bring the game back in play
+	if err := gm.savePoolGameToRedis(g); err != nil {
+		log.Printf("[DB] Failed to save cancelled state for game %s: %v", g.ID, err)
+	}
+
+	// Publish session_cancelled event to notify clients (if Redis configured)
+	if gm.rdb != nil {
+		p1State := g.GetGameStateForPlayer(g.Player1.ID)
+		p2State := g.GetGameStateForPlayer(g.Player2.ID)
+		payload := map[string]interface{}{"type": "session_cancelled", "game_token": g.Token, "game_id": g.ID, "message": message, "player1_state": p1State, "player2_state": p2State}
+		if b, err := json.Marshal(payload); err != nil {
+			log.Printf("[DB] Failed to marshal session_cancelled event for session %d: %v", g.SessionID, err)
+		} else {
+			if n, err := gm.rdb.Publish(context.Background(), "game_events", b).Result(); err != nil {
+				log.Printf("[DB] publish session_cancelled failed: %v", err)
 			} else {
-				log.Printf("[DB] Cannot process expiry refund - missing DB player ids for game %s session %d", g.ID, g.SessionID)
+				log.Printf("[DB] published session_cancelled: session=%d subscribers=%d", g.SessionID, n)
 			}
-		} else {
-			log.Printf("[EXPIRY] Skipping DB refund - no DB session for game %s", g.ID)
 		}
+	}
+}
 
-		// After attempting DB refund, mark game cancelled in memory and DB and notify clients
-		now2 := time.Now()
-		gm.mu.Lock()
-		g.Status = StatusCancelled
-		g.CompletedAt = &now2
-		delete(gm.playerToGame, g.Player1.ID)
-		delete(gm.playerToGame, g.Player2.ID)
-		gm.mu.Unlock()
+// ForceCancelSession cancels a stuck session for an admin: both stakes are refunded, the session
+// is marked cancelled and, if this server holds the game, it is ended and the players told.
+// Sessions already paid out or refunded return ErrSessionSettled and are left alone.
+func (gm *GameManager) ForceCancelSession(sessionID int, reason string) error {
+	if gm.db == nil {
+		return errors.New("no database")
+	}
+	var session struct {
+		Player1ID   sql.NullInt64 `db:"player1_id"`
+		Player2ID   sql.NullInt64 `db:"player2_id"`
+		StakeAmount float64       `db:"stake_amount"`
+	}
+	if err := gm.db.Get(&session, `SELECT player1_id, player2_id, stake_amount FROM game_sessions WHERE id=$1`, sessionID); err != nil {
+		return err
+	}
+
+	g, findErr := gm.FindGame(strconv.Itoa(sessionID))
+	if findErr == nil {
+		// Hold the game so a shot can't finish it between the refund and the cancel
+		g.mu.Lock()
+		if g.Status == StatusCompleted || g.Status == StatusCancelled {
+			g.mu.Unlock()
+			return ErrSessionSettled
+		}
+	}
 
-		if gm.db != nil && g.SessionID > 0 {
-			if _, err := gm.db.Exec(`UPDATE game_sessions SET status=$1, completed_at=NOW() WHERE id=$2`, string(StatusCancelled), g.SessionID); err != nil {
-				log.Printf("[DB] Failed to update game_sessions for session %d to cancelled: %v", g.SessionID, err)
+	if session.StakeAmount > 0 && session.Player1ID.Valid && session.Player2ID.Valid {
+		if err := gm.refundSessionStakes(sessionID, int(session.Player1ID.Int64), int(session.Player2ID.Int64), session.StakeAmount, accounts.RefundAdminCancel, "Admin cancelled: "+reason); err != nil {
+			if findErr == nil {
+				g.mu.Unlock()
 			}
+			return err
 		}
+	}
 
-		// Publish session_cancelled event to notify clients (if Redis configured)
+	if findErr != nil {
+		// Not live on this server: update the DB row and drop any saved state so no
+		// server can reload the game and play it out again
 		if gm.rdb != nil {
-			p1State := g.GetGameStateForPlayer(g.Player1.ID)
-			p2State := g.GetGameStateForPlayer(g.Player2.ID)
-			payload := map[string]interface{}{"type": "session_cancelled", "game_token": g.Token, "game_id": g.ID, "message": "Game cancelled due to expiry; stakes returned to players.", "player1_state": p1State, "player2_state": p2State}
-			if b, err := json.Marshal(payload); err != nil {
-				log.Printf("[DB] Failed to marshal session_cancelled event for session %d: %v", g.SessionID, err)
-			} else {
-				if n, err := gm.rdb.Publish(context.Background(), "game_events", b).Result(); err != nil {
-					log.Printf("[DB] publish session_cancelled failed: %v", err)
-				} else {
-					log.Printf("[DB] published session_cancelled: session=%d subscribers=%d", g.SessionID, n)
+			var token string
+			if err := gm.db.Get(&token, `SELECT game_token FROM game_sessions WHERE id=$1`, sessionID); err == nil && token != "" {
+				if err := gm.rdb.Del(context.Background(), "game:"+token+":state").Err(); err != nil {
+					log.Printf("[DB] Failed to drop saved state of cancelled session %d: %v", sessionID, err)
 				}
 			}
 		}
+		_, err := gm.db.Exec(`UPDATE game_sessions SET status=$1, completed_at=NOW() WHERE id=$2`, string(StatusCancelled), sessionID)
+		return err
 	}
+	g.Status = StatusCancelled
+	g.ShotInProgress = false // a shot result still in flight is refused
+	g.mu.Unlock()
+	gm.markCancelled(g, "Game cancelled by an administrator; stakes returned to players.")
+	gm.EndGame(g.ID)
+	return nil
 }
 
-// StartDisconnectChecker runs a background job to check for forfeit due to disconnect
-func (gm *GameManager) StartDisconnectChecker() {
-	ticker := time.NewTicker(10 * time.Second)
+// StartDisconnectChecker runs a background job to check for forfeit due to disconnect until ctx is cancelled
+func (gm *GameManager) StartDisconnectChecker(ctx context.Context) {
+	ticker := time.NewTicker(disconnectCheckInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		gm.checkDisconnectForfeits()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			gm.checkDisconnectForfeits()
+		}
 	}
 }
 
@@ -731,6 +840,7 @@ func (gm *GameManager) checkDisconnectForfeits() {
 
 	now := time.Now()
 	gracePeriod := time.Duration(gm.config.DisconnectGraceSeconds) * time.Second
+	earlyWindow := time.Duration(gm.config.EarlyDisconnectCancelSeconds) * time.Second
 
 	for _, game := range gamesToCheck {
 		game.mu.RLock()
@@ -738,15 +848,26 @@ func (gm *GameManager) checkDisconnectForfeits() {
 		p2Disconnected := !game.Player2.Connected && game.Player2.DisconnectedAt != nil
 
 		var forfeitPlayerID string
+		var forfeitDBID int
+		var droppedEarly bool
 		if p1Disconnected && now.Sub(*game.Player1.DisconnectedAt) > gracePeriod {
-			forfeitPlayerID = game.Player1.ID
+			forfeitPlayerID, forfeitDBID = game.Player1.ID, game.Player1.DBPlayerID
+			droppedEarly = game.droppedBeforePlayLocked(game.Player1, earlyWindow)
 		} else if p2Disconnected && now.Sub(*game.Player2.DisconnectedAt) > gracePeriod {
-			forfeitPlayerID = game.Player2.ID
+			forfeitPlayerID, forfeitDBID = game.Player2.ID, game.Player2.DBPlayerID
+			droppedEarly = game.droppedBeforePlayLocked(game.Player2, earlyWindow)
 		}
 		game.mu.RUnlock()
 
-		if forfeitPlayerID != "" {
+		// A drop straight after the start (no shots yet) is treated as a network problem:
+		// the game is cancelled and refunded instead of being a rated loss. Tournament games
+		// (no stake) still need a result, so they forfeit as usual.
+		if forfeitPlayerID != "" && droppedEarly && game.StakeAmount > 0 {
+			log.Printf("[CANCEL] Game %s: player %s dropped before any shot; cancelling instead of forfeit", game.ID, forfeitPlayerID)
+			gm.cancelSession(game, accounts.RefundEarlyDisconnect, "Early disconnect - refund to player", "Game cancelled: a player dropped before play began; stakes returned to players.")
+		} else if forfeitPlayerID != "" {
 			game.ForfeitByDisconnect(forfeitPlayerID)
+			gm.recordStrike(forfeitDBID, StrikeDisconnect)
 		}
 	}
 }
@@ -758,6 +879,17 @@ func (gm *GameManager) RehydrateQueueFromDB() error {
 	}
 
 	ctx := context.Background()
+	// Rows about to expire would only sit in Redis for a few seconds: expire them now, which
+	// sends the usual requeue SMS (their stake stays refundable through cancel/requeue)
+	minTTL := gm.config.RehydrateMinTTLSeconds
+	if minTTL > 0 {
+		if n, err := gm.expireQueued(time.Duration(minTTL)*time.Second, true); err != nil {
+			log.Printf("[REHYDRATE] Failed to expire nearly-expired rows: %v", err)
+		} else if n > 0 {
+			log.Printf("[REHYDRATE] Expired %d queued rows with less than %ds left", n, minTTL)
+		}
+	}
+
 	// Load queued rows grouped by stake
 	rows, err := gm.db.Queryx(`SELECT id, stake_amount FROM matchmaking_queue WHERE status='queued' AND is_private = FALSE AND expires_at > NOW() ORDER BY created_at`)
 	if err != nil {
@@ -779,52 +911,53 @@ func (gm *GameManager) RehydrateQueueFromDB() error {
 	}
 
 	for stake, ids := range grouped {
-		key := fmt.Sprintf("queue:stake:%d", stake)
-		llen, err := gm.rdb.LLen(ctx, key).Result()
-		if err != nil {
-			log.Printf("[REHYDRATE] Failed to check Redis key %s: %v", key, err)
+		key := queueKey(stake)
+		// Ids already waiting keep their score, so a populated queue is safe to top up
+		if err := gm.enqueueIDs(ctx, stake, ids...); err != nil {
+			log.Printf("[REHYDRATE] Failed to queue ids in Redis key %s: %v", key, err)
 			continue
 		}
-		if llen > 0 {
-			// already populated - skip to avoid duplicates
-			continue
-		}
-		// Push ids to Redis (RPUSH to preserve FIFO order)
-		for _, id := range ids {
-			if err := gm.rdb.RPush(ctx, key, id).Err(); err != nil {
-				log.Printf("[REHYDRATE] Failed to push id %d to Redis key %s: %v", id, key, err)
-			}
-		}
 		log.Printf("[REHYDRATE] Loaded %d queued items into Redis key %s", len(ids), key)
 	}
 
 	return nil
 }
 
-// StartQueueExpiryChecker runs a background job to expire queued entries
-func (gm *GameManager) StartQueueExpiryChecker() {
+// StartQueueExpiryChecker runs a background job to expire queued entries until ctx is cancelled
+func (gm *GameManager) StartQueueExpiryChecker(ctx context.Context) {
 	if gm.db == nil || gm.rdb == nil {
 		return
 	}
-	ticker := time.NewTicker(1 * time.Minute)
-	go func() {
-		for range ticker.C {
-			if _, err := gm.ExpireQueuedEntries(); err != nil {
-				log.Printf("[QUEUE EXPIRY] Error during expiry job: %v", err)
-			}
+	ticker := time.NewTicker(queueExpiryCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			gm.expireQueuedEntriesLogged()
 		}
-	}()
+	}
 }
 
 // ExpireQueuedEntries moves expired queued rows to status='expired', removes from Redis, and sends SMS notifications
 func (gm *GameManager) ExpireQueuedEntries() (int, error) {
+	return gm.expireQueued(0, false)
+}
+
+// expireQueued expires queued rows whose expires_at falls within `within` from now (0 = already
+// expired), optionally only public ones. See ExpireQueuedEntries.
+func (gm *GameManager) expireQueued(within time.Duration, publicOnly bool) (int, error) {
 	if gm.db == nil || gm.rdb == nil {
 		return 0, nil
 	}
 
 	ctx := context.Background()
 	// Atomically update expired rows and return their details for SMS notification
-	rows, err := gm.db.Queryx(`UPDATE matchmaking_queue SET status='expired' WHERE expires_at < NOW() AND status='queued' RETURNING id, phone_number, stake_amount`)
+	rows, err := gm.db.Queryx(`UPDATE matchmaking_queue SET status='expired'
+		WHERE expires_at < NOW() + make_interval(secs => $1) AND status='queued' AND (NOT $2 OR is_private = FALSE)
+		RETURNING id, phone_number, stake_amount`, within.Seconds(), publicOnly)
 	if err != nil {
 		return 0, err
 	}
@@ -845,9 +978,9 @@ func (gm *GameManager) ExpireQueuedEntries() (int, error) {
 		}
 
 		// Remove from Redis
-		key := fmt.Sprintf("queue:stake:%d", int(e.StakeAmount))
-		if err := gm.rdb.LRem(ctx, key, 0, e.ID).Err(); err != nil {
-			log.Printf("[QUEUE EXPIRY] Failed to LREM id %d from %s: %v", e.ID, key, err)
+		key := queueKey(int(e.StakeAmount))
+		if err := gm.rdb.ZRem(ctx, key, e.ID).Err(); err != nil {
+			log.Printf("[QUEUE EXPIRY] Failed to ZREM id %d from %s: %v", e.ID, key, err)
 		}
 
 		expired = append(expired, e)
@@ -858,18 +991,9 @@ func (gm *GameManager) ExpireQueuedEntries() (int, error) {
 
 		// Send SMS notifications with requeue link (best-effort, async)
 		for _, e := range expired {
-			go func(phone string, stake float64) {
-				if sms.Default == nil {
-					return
-				}
-				requeueLink := fmt.Sprintf("%s/requeue?phone=%s", gm.config.FrontendURL, phone)
-				msg := fmt.Sprintf("PlayPool: No match found for your %.0f UGX stake. Click to try again: %s", stake, requeueLink)
-				if _, err := sms.SendSMS(ctx, phone, msg); err != nil {
-					log.Printf("[QUEUE EXPIRY] Failed to send expiry SMS to %s: %v", phone, err)
-				} else {
-					log.Printf("[QUEUE EXPIRY] Expiry SMS sent to %s with requeue link", phone)
-				}
-			}(e.PhoneNumber, e.StakeAmount)
+			requeueLink := fmt.Sprintf("%s/requeue?phone=%s", gm.config.FrontendURL, e.PhoneNumber)
+			sms.EnqueueTemplate(sms.TypeExpiry, e.PhoneNumber, templates.QueueExpired,
+				templates.Params{"Stake": int(e.StakeAmount), "Link": requeueLink})
 		}
 	}
 	return len(expired), nil
@@ -880,6 +1004,9 @@ func (gm *GameManager) RecordMove(sessionID int, playerID int, moveType string)
 	if gm == nil || gm.db == nil || sessionID == 0 || playerID == 0 {
 		return
 	}
+	if !gm.allowMove(sessionID, time.Now()) {
+		return
+	}
 
 	// Determine next move number
 	var maxMove int
@@ -896,6 +1023,17 @@ func (gm *GameManager) RecordMove(sessionID int, playerID int, moveType string)
 	}
 }
 
+// RecordChat logs a chat line for moderation (synchronous, best-effort)
+func (gm *GameManager) RecordChat(sessionID int, playerID int, message string) {
+	if gm == nil || gm.db == nil || sessionID == 0 {
+		return
+	}
+	if _, err := gm.db.Exec(`INSERT INTO game_chat_messages (session_id, player_id, message, created_at) VALUES ($1,$2,$3,NOW())`,
+		sessionID, sql.NullInt64{Int64: int64(playerID), Valid: playerID > 0}, message); err != nil {
+		log.Printf("[DB] Failed to record chat for session %d: %v", sessionID, err)
+	}
+}
+
 // SaveFinalGameState persists the final game state JSON and updates the session row
 func (gm *GameManager) SaveFinalGameState(g *PoolGameState) {
 	if gm == nil || gm.db == nil || g == nil || g.SessionID == 0 {
@@ -903,6 +1041,11 @@ func (gm *GameManager) SaveFinalGameState(g *PoolGameState) {
 	}
 
 	log.Printf("[DB] SaveFinalGameState called for session=%d status=%s winner=%s", g.SessionID, g.Status, g.Winner)
+	gm.forgetMoveRate(g.SessionID)
+	if g.Status == StatusCompleted {
+		// Frees a slot if the stake tier is capped (async: we hold g.mu here)
+		go gm.resumeCappedTier(g.StakeAmount)
+	}
 
 	data, err := json.Marshal(g)
 	if err != nil {
@@ -918,13 +1061,13 @@ func (gm *GameManager) SaveFinalGameState(g *PoolGameState) {
 
 	// Update session status and winner if available
 	if g.Status == StatusCompleted {
-		// Resolve winner DB id
-		var winnerDBID int
-		if g.Winner != "" {
-			if g.Player1 != nil && g.Player1.ID == g.Winner {
-				winnerDBID = g.Player1.DBPlayerID
-			} else if g.Player2 != nil && g.Player2.ID == g.Winner {
-				winnerDBID = g.Player2.DBPlayerID
+		// Resolve winner (and loser) DB ids
+		var winnerDBID, loserDBID int
+		if g.Winner != "" && g.Player1 != nil && g.Player2 != nil {
+			if g.Player1.ID == g.Winner {
+				winnerDBID, loserDBID = g.Player1.DBPlayerID, g.Player2.DBPlayerID
+			} else if g.Player2.ID == g.Winner {
+				winnerDBID, loserDBID = g.Player2.DBPlayerID, g.Player1.DBPlayerID
 			}
 		}
 		log.Printf("[DB] Resolved winnerDBID=%d for winnerToken=%s (session=%d)", winnerDBID, g.Winner, g.SessionID)
@@ -935,25 +1078,27 @@ func (gm *GameManager) SaveFinalGameState(g *PoolGameState) {
 
 		// Handle winner payout (non-draw): transfer winnings with tax deduction
 		if winnerDBID > 0 && g.WinType != "draw" {
-			if err := gm.ProcessWinnerPayout(g.SessionID, winnerDBID, g.StakeAmount); err != nil {
-				log.Printf("[PAYOUT ERROR] Failed to process winner payout for session %d: %v", g.SessionID, err)
-			} else {
-				// Update winner's stats: increment games_won and add to total_winnings
-				pot := float64(g.StakeAmount * 2)
-				taxRate := float64(gm.config.PayoutTaxPercent) / 100.0
-				winningsNet := pot - (pot * taxRate)
-
-				_, err := gm.db.Exec(`UPDATE players SET total_games_won = total_games_won + 1, total_winnings = total_winnings + $1 WHERE id = $2`, winningsNet, winnerDBID)
-				if err != nil {
-					log.Printf("[DB] Failed to update winner stats for session %d: %v", g.SessionID, err)
+			// High stakes: hold the winnings so the loser can dispute; ReleaseDuePayouts pays them later
+			held := false
+			if loserDBID > 0 && gm.holdsPayout(g.StakeAmount) {
+				if err := gm.holdPayout(g.SessionID, winnerDBID, loserDBID, g.StakeAmount); err != nil {
+					log.Printf("[PAYOUT HOLD] Failed to hold payout for session %d, paying now: %v", g.SessionID, err)
+				} else {
+					held = true
+					log.Printf("[PAYOUT HOLD] Holding payout for session %d for %ds", g.SessionID, gm.config.PayoutHoldSeconds)
+				}
+			}
+			if !held {
+				if err := gm.payWinner(g.SessionID, winnerDBID, g.StakeAmount); err != nil {
+					log.Printf("[PAYOUT ERROR] Failed to process winner payout for session %d: %v", g.SessionID, err)
 				}
 			}
 		}
 
 		// Handle draw: refund stakes back to both players (no tax)
 		if g.Status == StatusCompleted && g.WinType == "draw" {
-			// Only attempt DB refund if we have a session persisted
-			if gm.db != nil && g.SessionID > 0 {
+			// Only attempt DB refund if we have a session persisted (and a stake to return)
+			if gm.db != nil && g.SessionID > 0 && g.StakeAmount > 0 {
 				p1ID := 0
 				p2ID := 0
 				if g.Player1 != nil {
@@ -967,9 +1112,9 @@ func (gm *GameManager) SaveFinalGameState(g *PoolGameState) {
 					if err != nil {
 						log.Printf("[DB] Failed to begin tx for draw refund session %d: %v", g.SessionID, err)
 					} else {
-						// Idempotency: skip if a DRAW_REFUND already exists for this session
+						// Idempotency: skip if the stakes were already refunded for this session
 						var cnt int
-						if err := tx.Get(&cnt, `SELECT COUNT(*) FROM escrow_ledger WHERE session_id=$1 AND entry_type='DRAW_REFUND'`, g.SessionID); err != nil {
+						if err := tx.Get(&cnt, `SELECT COUNT(*) FROM escrow_ledger WHERE session_id=$1 AND entry_type='REFUND'`, g.SessionID); err != nil {
 							log.Printf("[DB] Failed to check existing draw refunds for session %d: %v", g.SessionID, err)
 							tx.Rollback()
 						} else if cnt > 0 {
@@ -986,11 +1131,11 @@ func (gm *GameManager) SaveFinalGameState(g *PoolGameState) {
 							} else {
 								amount := float64(g.StakeAmount)
 								// Transfer to player1
-								if err := accounts.Transfer(tx, escrowAcc.ID, p1Acc.ID, amount, "SESSION", sql.NullInt64{Int64: int64(g.SessionID), Valid: true}, "DRAW_REFUND"); err != nil {
+								if err := accounts.Transfer(tx, escrowAcc.ID, p1Acc.ID, amount, "SESSION", sql.NullInt64{Int64: int64(g.SessionID), Valid: true}, accounts.RefundDraw, gm.config.SettlementOverdraftGuard); err != nil {
 									log.Printf("[DB] Failed to transfer draw refund to player %d for session %d: %v", p1ID, g.SessionID, err)
 									tx.Rollback()
 								} else {
-									if _, err := tx.Exec(`INSERT INTO escrow_ledger (session_id, entry_type, player_id, amount, balance_after, description, created_at) VALUES ($1,$2,$3,$4,$5,$6,NOW())`, g.SessionID, "DRAW_REFUND", p1ID, amount, 0.0, "Draw refund to player"); err != nil {
+									if err := accounts.RecordRefund(tx, sql.NullInt64{Int64: int64(g.SessionID), Valid: true}, p1ID, amount, accounts.RefundDraw, "Draw refund to player"); err != nil {
 										log.Printf("[DB] Failed to insert escrow_ledger for draw refund (p1) session %d: %v", g.SessionID, err)
 										tx.Rollback()
 										goto draw_refund_end
@@ -1001,11 +1146,11 @@ func (gm *GameManager) SaveFinalGameState(g *PoolGameState) {
 								}
 
 								// Transfer to player2
-								if err := accounts.Transfer(tx, escrowAcc.ID, p2Acc.ID, amount, "SESSION", sql.NullInt64{Int64: int64(g.SessionID), Valid: true}, "DRAW_REFUND"); err != nil {
+								if err := accounts.Transfer(tx, escrowAcc.ID, p2Acc.ID, amount, "SESSION", sql.NullInt64{Int64: int64(g.SessionID), Valid: true}, accounts.RefundDraw, gm.config.SettlementOverdraftGuard); err != nil {
 									log.Printf("[DB] Failed to transfer draw refund to player %d for session %d: %v", p2ID, g.SessionID, err)
 									tx.Rollback()
 								} else {
-									if _, err := tx.Exec(`INSERT INTO escrow_ledger (session_id, entry_type, player_id, amount, balance_after, description, created_at) VALUES ($1,$2,$3,$4,$5,$6,NOW())`, g.SessionID, "DRAW_REFUND", p2ID, amount, 0.0, "Draw refund to player"); err != nil {
+									if err := accounts.RecordRefund(tx, sql.NullInt64{Int64: int64(g.SessionID), Valid: true}, p2ID, amount, accounts.RefundDraw, "Draw refund to player"); err != nil {
 										log.Printf("[DB] Failed to insert escrow_ledger for draw refund (p2) session %d: %v", g.SessionID, err)
 										tx.Rollback()
 										goto draw_refund_end
@@ -1030,7 +1175,7 @@ func (gm *GameManager) SaveFinalGameState(g *PoolGameState) {
 					log.Printf("[DB] Cannot process draw refund - missing DB player ids for game %s session %d", g.ID, g.SessionID)
 				}
 			} else {
-				log.Printf("[DB] Skipping draw refund - no DB session for game %s", g.ID)
+				log.Printf("[DB] Skipping draw refund - no DB session or stake for game %s", g.ID)
 			}
 
 			// Publish game draw event to notify clients (if Redis configured)
@@ -1066,6 +1211,9 @@ func (gm *GameManager) SaveFinalGameState(g *PoolGameState) {
 			}
 		}
 
+		// Rate the result (draws move both ratings towards each other)
+		gm.updateEloRatings(g, winnerDBID)
+
 		// Ensure the game_sessions row reflects the final state (set winner, started_at if missing and completed_at)
 		var winnerParam interface{}
 		if winnerDBID > 0 {
@@ -1082,6 +1230,9 @@ func (gm *GameManager) SaveFinalGameState(g *PoolGameState) {
 		if _, err := gm.db.Exec(`UPDATE game_sessions SET status=$1, winner_id=$2, started_at = COALESCE(started_at, $3), completed_at = NOW() WHERE id = $4`, string(StatusCompleted), winnerParam, startedAtParam, g.SessionID); err != nil {
 			log.Printf("[DB] Failed to update game_sessions for session %d to completed: %v", g.SessionID, err)
 		}
+
+		// Tournament games move their winner on to the next round
+		gm.tournaments.SessionFinished(g.SessionID, winnerDBID)
 	} else {
 		_, err = gm.db.Exec(`UPDATE game_sessions SET status=$1 WHERE id=$2`, string(g.Status), g.SessionID)
 		if err != nil {
@@ -1102,6 +1253,35 @@ func (gm *GameManager) MarkSessionStarted(sessionID int, startedAt time.Time) er
 	return err
 }
 
+// StartSession runs the session-started side effects for g exactly once, however many connect
+// races reach it after Initialize: the session row goes IN_PROGRESS and a single session_started
+// event is published. It reports whether this call was the one that ran them.
+func (gm *GameManager) StartSession(g *PoolGameState) bool {
+	g.mu.Lock()
+	if g.sessionStarted || g.StartedAt == nil {
+		g.mu.Unlock()
+		return false
+	}
+	g.sessionStarted = true
+	startedAt := *g.StartedAt
+	g.mu.Unlock()
+
+	if err := gm.MarkSessionStarted(g.SessionID, startedAt); err != nil {
+		log.Printf("[DB] MarkSessionStarted failed for session %d: %v", g.SessionID, err)
+	}
+	log.Printf("[SESSION] Game %s (session %d) started", g.ID, g.SessionID)
+
+	if gm.rdb != nil {
+		payload := map[string]interface{}{"type": "session_started", "game_token": g.Token, "game_id": g.ID, "session_id": g.SessionID, "started_at": startedAt.Unix()}
+		if b, err := json.Marshal(payload); err != nil {
+			log.Printf("[DB] Failed to marshal session_started event for session %d: %v", g.SessionID, err)
+		} else if err := gm.rdb.Publish(context.Background(), "game_events", b).Err(); err != nil {
+			log.Printf("[DB] publish session_started failed: %v", err)
+		}
+	}
+	return true
+}
+
 // UpdateDisplayName updates queue entries and in-memory game player display names for the given phone.
 // It returns a slice of game IDs that were updated.
 func (gm *GameManager) UpdateDisplayName(phone, name string) []string {
@@ -1142,53 +1322,78 @@ func (gm *GameManager) UpdateDisplayName(phone, name string) []string {
 // from the Redis list for the given stake. If an opponent is found and successfully claimed
 // in the DB, it creates a game session, updates both queue rows, persists the game and
 // returns a MatchResult. If no opponent is available the function pushes this queue id
-// into Redis and returns (nil, nil).
-func (gm *GameManager) TryMatchFromRedis(stakeAmount int, myQueueID int, myPhone string, myDBPlayerID int, myDisplayName string) (*MatchResult, error) {
+// into Redis and returns (nil, nil). Logs carry the request id in ctx; cancelling ctx does
+// not abort a match half way.
+func (gm *GameManager) TryMatchFromRedis(ctx context.Context, stakeAmount int, myQueueID int, myPhone string, myDBPlayerID int, myDisplayName string) (*MatchResult, error) {
+	lg := logger.For(ctx, "match").With("stake", stakeAmount, "queue_id", myQueueID)
 	if gm.rdb == nil || gm.db == nil {
-		log.Printf("[MATCH] TryMatchFromRedis skipped: rdb==nil? %v db==nil? %v", gm.rdb == nil, gm.db == nil)
+		lg.Warn("match skipped", "redis", gm.rdb != nil, "db", gm.db != nil)
 		// No Redis or DB available - nothing to do
 		return nil, nil
 	}
 
-	ctx := context.Background()
-	key := fmt.Sprintf("queue:stake:%d", stakeAmount)
-	// DEBUG: log current list contents to help diagnose matching issues
-	if llen, err := gm.rdb.LLen(ctx, key).Result(); err == nil {
-		if llen > 0 {
-			if items, err := gm.rdb.LRange(ctx, key, 0, -1).Result(); err == nil {
-				log.Printf("[MATCH DEBUG] Redis list %s len=%d items=%v", key, llen, items)
+	start := time.Now()
+	defer func() { gm.matchStats.observe(time.Since(start)) }()
+
+	ctx = context.WithoutCancel(ctx)
+	key := queueKey(stakeAmount)
+	// Log the queue contents to help diagnose matching issues (two extra Redis calls per match)
+	if gm.config.MatchDebugLogging {
+		if llen, err := gm.queueLen(ctx, stakeAmount); err == nil {
+			if llen > 0 {
+				if items, err := gm.rdb.ZRange(ctx, key, 0, -1).Result(); err == nil {
+					lg.Debug("redis queue", "key", key, "len", llen, "items", items)
+				}
+			} else {
+				lg.Debug("redis queue empty", "key", key)
 			}
 		} else {
-			log.Printf("[MATCH DEBUG] Redis list %s is empty (len=0)", key)
+			lg.Debug("redis queue length failed", "key", key, "error", err)
+		}
+	}
+	// Ranked mode: opponents outside the rating window are passed over and put back at
+	// the head of the queue once we are done
+	ranked := gm.config.RankedMatchmaking
+	var myRating int
+	var myJoinedAt time.Time
+	if ranked {
+		var err error
+		if myRating, myJoinedAt, err = gm.rankedProfile(myQueueID); err != nil {
+			lg.Warn("failed to load rating, matching unranked", "error", err)
+			ranked = false
 		}
-	} else {
-		log.Printf("[MATCH DEBUG] Failed to LLen %s: %v", key, err)
 	}
+	var passedOver []int
+	defer func() { gm.releasePassedOver(stakeAmount, passedOver) }()
+
+	// Tier full: wait in the queue; resumeCappedTier pairs us up when a game in the tier ends
+	if gm.tierAtCapacity(stakeAmount) {
+		gm.pushBackToQueue(ctx, lg, stakeAmount, myQueueID)
+		lg.Info("stake tier at capacity, queued")
+		return nil, nil
+	}
+
 	// Try to pop an opponent from Redis. If none, push our own queue id and return.
 	for attempts := 0; attempts < 5; attempts++ {
 		oppID, err := gm.claimJobFromRedis(stakeAmount)
-		log.Printf("[MATCH] claimJobFromRedis returned id=%d for stake=%d (attempt=%d)", oppID, stakeAmount, attempts)
+		lg.Debug("claimed from redis", "opponent_queue_id", oppID, "attempt", attempts)
 		if err != nil {
-			log.Printf("[MATCH] Redis claim error on stake %d: %v", stakeAmount, err)
+			lg.Error("redis claim failed", "error", err)
 			// push own id as a best-effort
-			if err := gm.rdb.LPush(ctx, key, myQueueID).Err(); err != nil {
-				log.Printf("[MATCH] Failed to push own queue id %d to Redis key %s: %v", myQueueID, key, err)
-			}
+			gm.pushBackToQueue(ctx, lg, stakeAmount, myQueueID)
 			return nil, nil
 		}
 
 		if oppID == 0 {
 			// No opponent - claim script returned no id; push own id and return
-			if err := gm.rdb.LPush(ctx, key, myQueueID).Err(); err != nil {
-				log.Printf("[MATCH] Failed to push own queue id %d to Redis key %s: %v", myQueueID, key, err)
-			} else {
-				log.Printf("[MATCH] Pushed my queue id %d into Redis for stake %d", myQueueID, stakeAmount)
+			if gm.pushBackToQueue(ctx, lg, stakeAmount, myQueueID) {
+				lg.Info("no opponent, queued in redis")
 				// Quick, single retry to handle simultaneous arrivals: if list length >=2, attempt one more claim
-				if llen, err := gm.rdb.LLen(ctx, key).Result(); err == nil && llen >= 2 {
-					log.Printf("[MATCH] Detected potential race: list %s len=%d; attempting quick claim retry", key, llen)
+				if llen, err := gm.queueLen(ctx, stakeAmount); err == nil && llen >= 2 {
+					lg.Info("possible simultaneous arrival, retrying claim", "key", key, "len", llen)
 					time.Sleep(50 * time.Millisecond)
 					retryID, err := gm.claimJobFromRedis(stakeAmount)
-					log.Printf("[MATCH] Quick retry claim returned id=%d err=%v", retryID, err)
+					lg.Debug("quick retry claim", "opponent_queue_id", retryID, "error", err)
 					if err == nil && retryID != 0 {
 						oppID = retryID
 						// fall through to DB claim handling below
@@ -1215,56 +1420,74 @@ func (gm *GameManager) TryMatchFromRedis(stakeAmount int, myQueueID int, myPhone
 		if err != nil {
 			// Race - someone else claimed it or it was removed - cleanup processing entry then try next
 			if err == sql.ErrNoRows {
-				log.Printf("[MATCH] DB claim returned no rows for id %d (possibly raced), will cleanup and retry", oppID)
+				gm.matchStats.claimRaces.Add(1)
+				lg.Info("opponent already claimed, retrying", "opponent_queue_id", oppID)
 				// cleanup processing entry (remove from processing list and zset)
 				processingKey := fmt.Sprintf("processing:stake:%d", stakeAmount)
 				processingTsKey := fmt.Sprintf("processing_ts:stake:%d", stakeAmount)
 				if err := gm.rdb.LRem(ctx, processingKey, 0, oppID).Err(); err != nil {
-					log.Printf("[MATCH] Cleanup LREM failed for id %d: %v", oppID, err)
+					lg.Warn("cleanup LREM failed", "opponent_queue_id", oppID, "error", err)
 				}
 				if err := gm.rdb.ZRem(ctx, processingTsKey, oppID).Err(); err != nil {
-					log.Printf("[MATCH] Cleanup ZREM failed for id %d: %v", oppID, err)
+					lg.Warn("cleanup ZREM failed", "opponent_queue_id", oppID, "error", err)
 				}
 				continue
 			}
-			log.Printf("[MATCH] DB claim error for queue id %d: %v", oppID, err)
+			lg.Error("opponent claim failed", "opponent_queue_id", oppID, "error", err)
 			// cleanup and push own id
 			processingKey := fmt.Sprintf("processing:stake:%d", stakeAmount)
 			processingTsKey := fmt.Sprintf("processing_ts:stake:%d", stakeAmount)
 			if err := gm.rdb.LRem(ctx, processingKey, 0, oppID).Err(); err != nil {
-				log.Printf("[MATCH] Cleanup LREM failed for id %d: %v", oppID, err)
+				lg.Warn("cleanup LREM failed", "opponent_queue_id", oppID, "error", err)
 			}
 			if err := gm.rdb.ZRem(ctx, processingTsKey, oppID).Err(); err != nil {
-				log.Printf("[MATCH] Cleanup ZREM failed for id %d: %v", oppID, err)
-			}
-			if err := gm.rdb.LPush(ctx, key, myQueueID).Err(); err != nil {
-				log.Printf("[MATCH] Failed to push own queue id %d to Redis key %s: %v", myQueueID, key, err)
+				lg.Warn("cleanup ZREM failed", "opponent_queue_id", oppID, "error", err)
 			}
+			gm.pushBackToQueue(ctx, lg, stakeAmount, myQueueID)
 			return nil, nil
 		}
 
-		log.Printf("[MATCH] DB claim successful for oppID=%d phone=%s", oppQueue.ID, oppQueue.PhoneNumber)
+		lg.Info("opponent claimed", "opponent_queue_id", oppQueue.ID)
 
 		// Avoid self-match if popped our own row unexpectedly
 		if oppQueue.PhoneNumber == myPhone {
+			gm.matchStats.selfMatchAvoided.Add(1)
 			// cleanup processing entry and continue
 			processingKey := fmt.Sprintf("processing:stake:%d", stakeAmount)
 			processingTsKey := fmt.Sprintf("processing_ts:stake:%d", stakeAmount)
 			if err := gm.rdb.LRem(ctx, processingKey, 0, oppID).Err(); err != nil {
-				log.Printf("[MATCH] Cleanup LREM failed for id %d: %v", oppID, err)
+				lg.Warn("cleanup LREM failed", "opponent_queue_id", oppID, "error", err)
 			}
 			if err := gm.rdb.ZRem(ctx, processingTsKey, oppID).Err(); err != nil {
-				log.Printf("[MATCH] Cleanup ZREM failed for id %d: %v", oppID, err)
+				lg.Warn("cleanup ZREM failed", "opponent_queue_id", oppID, "error", err)
 			}
 			continue
 		}
 
+		// Ranked: the window widens with the wait of whichever player joined first
+		if ranked {
+			oppRating, oppJoinedAt, err := gm.rankedProfile(oppQueue.ID)
+			if err != nil {
+				lg.Warn("failed to load opponent rating", "opponent_queue_id", oppQueue.ID, "error", err)
+			} else {
+				joinedAt := myJoinedAt
+				if oppJoinedAt.Before(joinedAt) {
+					joinedAt = oppJoinedAt
+				}
+				if !gm.withinRankedWindow(myRating, oppRating, time.Since(joinedAt)) {
+					lg.Info("ranked: opponent outside rating window", "opponent_queue_id", oppQueue.ID, "opponent_rating", oppRating, "rating", myRating)
+					passedOver = append(passedOver, oppQueue.ID)
+					continue
+				}
+			}
+		}
+
 		// Build player identities for the in-memory game
 		// Retrieve opponent display name from players table if possible
 		var oppPlayer models.Player
 		if oppQueue.PlayerID.Valid {
 			if err := gm.db.Get(&oppPlayer, `SELECT id, phone_number, display_name FROM players WHERE id=$1`, int(oppQueue.PlayerID.Int64)); err != nil {
-				log.Printf("[MATCH] Failed to get opponent player %d: %v", oppQueue.PlayerID.Int64, err)
+				lg.Warn("failed to load opponent player", "opponent_player_id", oppQueue.PlayerID.Int64, "error", err)
 			}
 		}
 
@@ -1312,6 +1535,8 @@ func (gm *GameManager) TryMatchFromRedis(stakeAmount int, myQueueID int, myPhone
 			stakeAmount,
 		)
 
+		gm.attachRatings(game)
+
 		// Save to memory and Redis, and create session row if possible
 		gm.mu.Lock()
 		gm.games[gameID] = game
@@ -1322,33 +1547,33 @@ func (gm *GameManager) TryMatchFromRedis(stakeAmount int, myQueueID int, myPhone
 		// Persist a game_sessions row if we have DB player ids
 		var sessionID int
 		if gm.db != nil && (!oppQueue.PlayerID.Valid || myDBPlayerID <= 0) {
-			log.Printf("[DB] Skipping DB session creation for in-memory match (oppPlayerID.Valid=%v, myDBPlayerID=%d) -- game will remain in-memory", oppQueue.PlayerID.Valid, myDBPlayerID)
+			lg.Warn("missing player ids, match stays in memory only", "opponent_has_player", oppQueue.PlayerID.Valid, "player_id", myDBPlayerID)
 		}
 		if gm.db != nil && oppQueue.PlayerID.Valid && myDBPlayerID > 0 {
 			tx, err := gm.db.Beginx()
 			if err != nil {
-				log.Printf("[DB] Failed to begin tx for match initialization: %v", err)
+				lg.Error("failed to begin match tx", "error", err)
 				// attempt to set queues back to queued and continue
 				if _, err2 := gm.db.Exec(`UPDATE matchmaking_queue SET status='queued' WHERE id IN ($1,$2)`, oppID, myQueueID); err2 != nil {
-					log.Printf("[DB] Failed to reset queue rows after tx begin failure: %v", err2)
+					lg.Error("failed to reset queue rows", "error", err2)
 				}
 			} else {
 				// Insert session row within tx
 				if err := tx.QueryRowx(`INSERT INTO game_sessions (game_token, player1_id, player2_id, stake_amount, status, created_at, expiry_time) VALUES ($1, $2, $3, $4, $5, NOW(), $6) RETURNING id`,
 					gameToken, int(oppQueue.PlayerID.Int64), myDBPlayerID, stakeAmount, string(StatusWaiting), game.ExpiresAt).Scan(&sessionID); err != nil {
-					log.Printf("[DB] Failed to create game_session for queue pairing: %v", err)
+					lg.Error("failed to create game session", "error", err)
 					tx.Rollback()
 					// revert queue status
 					if _, err2 := gm.db.Exec(`UPDATE matchmaking_queue SET status='queued' WHERE id IN ($1,$2)`, oppID, myQueueID); err2 != nil {
-						log.Printf("[DB] Failed to reset queue rows after session insert failure: %v", err2)
+						lg.Error("failed to reset queue rows", "error", err2)
 					}
 				} else {
 					// Reserve opponent stake
 					if err := gm.reserveStakeForSession(tx, int(oppQueue.PlayerID.Int64), oppID, sessionID, stakeAmount); err != nil {
-						log.Printf("[DB] Failed to reserve opponent stake: %v", err)
+						lg.Error("failed to reserve opponent stake", "session_id", sessionID, "error", err)
 						tx.Rollback()
 						if _, err2 := gm.db.Exec(`UPDATE matchmaking_queue SET status='queued' WHERE id IN ($1,$2)`, oppID, myQueueID); err2 != nil {
-							log.Printf("[DB] Failed to reset queue rows after opponent reserve failure: %v", err2)
+							lg.Error("failed to reset queue rows", "error", err2)
 						}
 						// continue the match loop to try next opponent
 						continue
@@ -1356,36 +1581,74 @@ func (gm *GameManager) TryMatchFromRedis(stakeAmount int, myQueueID int, myPhone
 
 					// Reserve my stake
 					if err := gm.reserveStakeForSession(tx, myDBPlayerID, myQueueID, sessionID, stakeAmount); err != nil {
-						log.Printf("[DB] Failed to reserve my stake: %v", err)
+						lg.Error("failed to reserve stake", "session_id", sessionID, "error", err)
 						tx.Rollback()
 						if _, err2 := gm.db.Exec(`UPDATE matchmaking_queue SET status='queued' WHERE id IN ($1,$2)`, oppID, myQueueID); err2 != nil {
-							log.Printf("[DB] Failed to reset queue rows after my reserve failure: %v", err2)
+							lg.Error("failed to reset queue rows", "error", err2)
 						}
 						continue
 					}
 
-					// All good - update both queue rows and commit
-					if _, err := tx.Exec(`UPDATE matchmaking_queue SET status='matched', matched_at=NOW(), session_id=$1 WHERE id=$2`, sessionID, oppID); err != nil {
-						log.Printf("[DB] Failed to update opponent queue %d: %v", oppID, err)
+					// All good - update both queue rows and commit. Only an opponent row we still
+					// hold: their leave or cancel mid-match wins and we try the next opponent
+					res, err := tx.Exec(`UPDATE matchmaking_queue SET status='matched', matched_at=NOW(), session_id=$1 WHERE id=$2 AND status='matching'`, sessionID, oppID)
+					if err == nil {
+						if n, _ := res.RowsAffected(); n == 0 {
+							err = errLeftWhileMatching
+						}
+					}
+					if err == errLeftWhileMatching {
+						lg.Info("opponent left the queue while being matched, trying next", "opponent_queue_id", oppID)
+						tx.Rollback()
+						gm.dropUnmatchedGame(gameID, opponentEphemeral, myEphemeral)
+						processingKey := fmt.Sprintf("processing:stake:%d", stakeAmount)
+						processingTsKey := fmt.Sprintf("processing_ts:stake:%d", stakeAmount)
+						if err := gm.rdb.LRem(ctx, processingKey, 0, oppID).Err(); err != nil {
+							lg.Warn("cleanup LREM failed", "opponent_queue_id", oppID, "error", err)
+						}
+						if err := gm.rdb.ZRem(ctx, processingTsKey, oppID).Err(); err != nil {
+							lg.Warn("cleanup ZREM failed", "opponent_queue_id", oppID, "error", err)
+						}
+						continue
+					}
+					if err != nil {
+						lg.Error("failed to mark opponent queue matched", "opponent_queue_id", oppID, "error", err)
 						tx.Rollback()
 						if _, err2 := gm.db.Exec(`UPDATE matchmaking_queue SET status='queued' WHERE id=$1`, myQueueID); err2 != nil {
-							log.Printf("[DB] Failed to reset my queue row after update failure: %v", err2)
+							lg.Error("failed to reset queue row", "error", err2)
 						}
 					} else {
-						if _, err := tx.Exec(`UPDATE matchmaking_queue SET status='matched', matched_at=NOW(), session_id=$1 WHERE id=$2`, sessionID, myQueueID); err != nil {
-							log.Printf("[DB] Failed to update my queue %d: %v", myQueueID, err)
+						// Only a row still waiting: a leave or cancel that landed mid-match wins
+						res, err := tx.Exec(`UPDATE matchmaking_queue SET status='matched', matched_at=NOW(), session_id=$1 WHERE id=$2 AND status IN ('queued','matching')`, sessionID, myQueueID)
+						if err == nil {
+							if n, _ := res.RowsAffected(); n == 0 {
+								err = errLeftWhileMatching
+							}
+						}
+						if err == errLeftWhileMatching {
+							lg.Info("left the queue while being matched, opponent requeued", "opponent_queue_id", oppID)
+							tx.Rollback()
+							gm.dropUnmatchedGame(gameID, opponentEphemeral, myEphemeral)
+							passedOver = append(passedOver, oppID)
+							return nil, nil
+						}
+						if err != nil {
+							lg.Error("failed to mark queue matched", "error", err)
 							tx.Rollback()
 							if _, err2 := gm.db.Exec(`UPDATE matchmaking_queue SET status='queued' WHERE id=$1`, oppID); err2 != nil {
-								log.Printf("[DB] Failed to reset opponent queue row after update failure: %v", err2)
+								lg.Error("failed to reset opponent queue row", "opponent_queue_id", oppID, "error", err2)
 							}
 						} else {
 							if err := tx.Commit(); err != nil {
-								log.Printf("[DB] Failed to commit match initialization tx for session %d: %v", sessionID, err)
+								lg.Error("failed to commit match tx", "session_id", sessionID, "error", err)
 								// attempt to reset queue rows
 								if _, err2 := gm.db.Exec(`UPDATE matchmaking_queue SET status='queued' WHERE id IN ($1,$2)`, oppID, myQueueID); err2 != nil {
-									log.Printf("[DB] Failed to reset queue rows after commit failure: %v", err2)
+									lg.Error("failed to reset queue rows", "error", err2)
 								}
 							} else {
+								announceOpponentFound(gm.rdb, gm.config, stakeAmount,
+									OpponentFoundPlayer{QueueToken: oppQueue.QueueToken.String, DisplayName: oppPlayer.DisplayName},
+									OpponentFoundPlayer{QueueToken: myQueueTok.QueueToken.String, DisplayName: myDisplayName})
 								// Remove in-memory queue entries for both players (they are now matched)
 								gm.RemoveQueueEntriesByPhone(stakeAmount, oppQueue.PhoneNumber)
 								gm.RemoveQueueEntriesByPhone(stakeAmount, myPhone)
@@ -1393,7 +1656,7 @@ func (gm *GameManager) TryMatchFromRedis(stakeAmount int, myQueueID int, myPhone
 								gm.mu.Lock()
 								if g, ok := gm.games[gameID]; ok {
 									g.SessionID = sessionID
-									log.Printf("[DB] Session %d attached to in-memory game %s", sessionID, gameID)
+									lg.Info("matched", "session_id", sessionID, "game_id", gameID, "opponent_queue_id", oppID)
 									go g.SaveToRedis()
 								}
 								gm.mu.Unlock()
@@ -1408,26 +1671,17 @@ func (gm *GameManager) TryMatchFromRedis(stakeAmount int, myQueueID int, myPhone
 									if myName == "" {
 										myName = myPhone
 									}
+									if HideOpponentAtMatch(gm.config) {
+										oppName, myName = "an opponent", "an opponent"
+									}
 
 									baseURL := gm.config.FrontendURL
 									player1Link := baseURL + "/g/" + gameToken + "?pt=" + player1Token
 									player2Link := baseURL + "/g/" + gameToken + "?pt=" + player2Token
 
-									go func(oppPhone, joinerPhone, link1, link2, oppName, joinerName string, stake int) {
-										ctx := context.Background()
-										msgOpp := fmt.Sprintf("Matched on PlayPool vs %s! Stake %d UGX. Join: %s", joinerName, stake, link1)
-										if msgID, err := sms.SendSMS(ctx, oppPhone, msgOpp); err != nil {
-											log.Printf("[SMS] Failed to send match SMS to %s: %v", oppPhone, err)
-										} else {
-											log.Printf("[SMS] Match SMS sent to %s msg_id=%s", oppPhone, msgID)
-										}
-										msgMe := fmt.Sprintf("Matched on PlayPool vs %s! Stake %d UGX. Join: %s", oppName, stake, link2)
-										if msgID, err := sms.SendSMS(ctx, joinerPhone, msgMe); err != nil {
-											log.Printf("[SMS] Failed to send match SMS to %s: %v", joinerPhone, err)
-										} else {
-											log.Printf("[SMS] Match SMS sent to %s msg_id=%s", joinerPhone, msgID)
-										}
-									}(oppQueue.PhoneNumber, myPhone, player1Link, player2Link, oppName, myName, stakeAmount)
+									sms.EnqueueTemplate(sms.TypeMatch, oppQueue.PhoneNumber, templates.Match, templates.Params{"Opponent": myName, "Stake": stakeAmount, "Link": player1Link})
+									sms.EnqueueTemplate(sms.TypeMatch, myPhone, templates.Match, templates.Params{"Opponent": oppName, "Stake": stakeAmount, "Link": player2Link})
+									lg.Info("match sms queued", "phones", []string{oppQueue.PhoneNumber, myPhone})
 								}
 							}
 						}
@@ -1459,21 +1713,33 @@ func (gm *GameManager) TryMatchFromRedis(stakeAmount int, myQueueID int, myPhone
 	}
 
 	// Nothing matched after attempts -- push own id and return
-	if err := gm.rdb.LPush(ctx, key, myQueueID).Err(); err != nil {
-		log.Printf("[MATCH] Final push of own queue id %d to Redis key %s failed: %v", myQueueID, key, err)
-	}
+	gm.pushBackToQueue(ctx, lg, stakeAmount, myQueueID)
 	return nil, nil
 }
 
-// claimJobFromRedis atomically pops an id from the main queue and moves it to processing with a timestamp
+// errLeftWhileMatching means a queue row was cancelled while its match was being set up
+var errLeftWhileMatching = errors.New("queue entry left while matching")
+
+// dropUnmatchedGame forgets an in-memory game whose match was rolled back
+func (gm *GameManager) dropUnmatchedGame(gameID string, playerIDs ...string) {
+	gm.mu.Lock()
+	delete(gm.games, gameID)
+	for _, id := range playerIDs {
+		delete(gm.playerToGame, id)
+	}
+	gm.mu.Unlock()
+}
+
+// claimJobFromRedis atomically pops the oldest id from the main queue and moves it to processing with a timestamp
 func (gm *GameManager) claimJobFromRedis(stake int) (int, error) {
 	ctx := context.Background()
-	key := fmt.Sprintf("queue:stake:%d", stake)
+	key := queueKey(stake)
 	processingKey := fmt.Sprintf("processing:stake:%d", stake)
 	processingTsKey := fmt.Sprintf("processing_ts:stake:%d", stake)
 	// current timestamp in seconds
 	ts := time.Now().Unix()
-	script := `local id = redis.call('RPOP', KEYS[1]); if not id then return nil end; redis.call('LPUSH', KEYS[2], id); redis.call('ZADD', KEYS[3], ARGV[1], id); return id`
+	script := `local r = redis.call('ZPOPMIN', KEYS[1]); if #r == 0 then return nil end; local id = r[1]; redis.call('LPUSH', KEYS[2], id); redis.call('ZADD', KEYS[3], ARGV[1], id); return id`
+	gm.matchStats.claimsAttempted.Add(1)
 	res, err := gm.rdb.Eval(ctx, script, []string{key, processingKey, processingTsKey}, ts).Result()
 	if err == redis.Nil {
 		return 0, nil
@@ -1489,9 +1755,46 @@ func (gm *GameManager) claimJobFromRedis(stake int) (int, error) {
 	if err != nil {
 		return 0, err
 	}
+	gm.matchStats.claimsWon.Add(1)
 	return id, nil
 }
 
+// pushBackToQueue puts the caller's queue id back in the stake queue, at its join time, to
+// wait for an opponent and reports whether the push succeeded
+func (gm *GameManager) pushBackToQueue(ctx context.Context, lg *slog.Logger, stake, queueID int) bool {
+	if err := gm.enqueueIDs(ctx, stake, queueID); err != nil {
+		lg.Error("failed to push own queue id", "key", queueKey(stake), "error", err)
+		return false
+	}
+	gm.matchStats.requeued.Add(1)
+	return true
+}
+
+// releasePassedOver puts opponents skipped by ranked matching back in the queue; their join
+// time score keeps their place
+func (gm *GameManager) releasePassedOver(stake int, ids []int) {
+	if len(ids) == 0 {
+		return
+	}
+	ctx := context.Background()
+	processingKey := fmt.Sprintf("processing:stake:%d", stake)
+	processingTsKey := fmt.Sprintf("processing_ts:stake:%d", stake)
+	for _, id := range ids {
+		if _, err := gm.db.Exec(`UPDATE matchmaking_queue SET status='queued' WHERE id=$1 AND status='matching'`, id); err != nil {
+			log.Printf("[MATCH] Failed to update queue row %d back to queued: %v", id, err)
+		}
+		if err := gm.rdb.LRem(ctx, processingKey, 0, id).Err(); err != nil {
+			log.Printf("[MATCH] Cleanup LREM failed for id %d: %v", id, err)
+		}
+		if err := gm.rdb.ZRem(ctx, processingTsKey, id).Err(); err != nil {
+			log.Printf("[MATCH] Cleanup ZREM failed for id %d: %v", id, err)
+		}
+		if err := gm.enqueueIDs(ctx, stake, id); err != nil {
+			log.Printf("[MATCH] Failed to queue id %d again at stake %d: %v", id, stake, err)
+		}
+	}
+}
+
 // RequeueStuckProcessing checks for items in processing that have exceeded visibility timeout and requeues them
 func (gm *GameManager) RequeueStuckProcessing() (int, error) {
 	if gm.rdb == nil || gm.db == nil {
@@ -1513,7 +1816,6 @@ func (gm *GameManager) RequeueStuckProcessing() (int, error) {
 		stake := int(stakeAmt)
 		processingTsKey := fmt.Sprintf("processing_ts:stake:%d", stake)
 		processingKey := fmt.Sprintf("processing:stake:%d", stake)
-		queueKey := fmt.Sprintf("queue:stake:%d", stake)
 
 		threshold := time.Now().Add(-time.Duration(gm.config.QueueProcessingVisibility) * time.Second).Unix()
 		ids, err := gm.rdb.ZRangeByScore(ctx, processingTsKey, &redis.ZRangeBy{Min: "-inf", Max: fmt.Sprintf("%d", threshold)}).Result()
@@ -1540,8 +1842,8 @@ func (gm *GameManager) RequeueStuckProcessing() (int, error) {
 			if err := gm.rdb.ZRem(ctx, processingTsKey, id).Err(); err != nil {
 				log.Printf("[RECOVER] Failed to ZREM id %d from %s: %v", id, processingTsKey, err)
 			}
-			if err := gm.rdb.RPush(ctx, queueKey, id).Err(); err != nil {
-				log.Printf("[RECOVER] Failed to RPush id %d back onto %s: %v", id, queueKey, err)
+			if err := gm.enqueueIDs(ctx, stake, id); err != nil {
+				log.Printf("[RECOVER] Failed to queue id %d again at stake %d: %v", id, stake, err)
 			}
 			requeued++
 		}
@@ -1549,25 +1851,48 @@ func (gm *GameManager) RequeueStuckProcessing() (int, error) {
 	return requeued, nil
 }
 
-// StartProcessingRecoveryChecker runs a background job to requeue stuck processing items
-func (gm *GameManager) StartProcessingRecoveryChecker() {
+// StartProcessingRecoveryChecker runs a background job to requeue stuck processing items until ctx is cancelled
+func (gm *GameManager) StartProcessingRecoveryChecker(ctx context.Context) {
 	if gm.db == nil || gm.rdb == nil {
 		return
 	}
+	ticker := time.NewTicker(gm.processingRecoveryInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			gm.requeueStuckProcessingLogged()
+		}
+	}
+}
+
+// processingRecoveryInterval is how often stuck processing items are checked: half the
+// processing visibility timeout, at least every 5 seconds
+func (gm *GameManager) processingRecoveryInterval() time.Duration {
 	interval := time.Duration(gm.config.QueueProcessingVisibility/2) * time.Second
 	if interval < time.Second*5 {
 		interval = 5 * time.Second
 	}
-	ticker := time.NewTicker(interval)
-	go func() {
-		for range ticker.C {
-			if n, err := gm.RequeueStuckProcessing(); err != nil {
-				log.Printf("[RECOVER] Error requeueing stuck processing items: %v", err)
-			} else if n > 0 {
-				log.Printf("[RECOVER] Requeued %d stuck items", n)
-			}
-		}
-	}()
+	return interval
+}
+
+// requeueStuckProcessingLogged runs one stuck-processing recovery pass, logging the outcome
+func (gm *GameManager) requeueStuckProcessingLogged() {
+	if n, err := gm.RequeueStuckProcessing(); err != nil {
+		log.Printf("[RECOVER] Error requeueing stuck processing items: %v", err)
+	} else if n > 0 {
+		log.Printf("[RECOVER] Requeued %d stuck items", n)
+	}
+}
+
+// expireQueuedEntriesLogged runs one queue expiry pass, logging a failure
+func (gm *GameManager) expireQueuedEntriesLogged() {
+	if _, err := gm.ExpireQueuedEntries(); err != nil {
+		log.Printf("[QUEUE EXPIRY] Error during expiry job: %v", err)
+	}
 }
 
 // reserveStakeForSession debits a player's PLAYER_WINNINGS and credits ESCROW inside the provided tx.
@@ -1582,7 +1907,7 @@ func (gm *GameManager) reserveStakeForSession(tx *sqlx.Tx, playerDBID, queueID,
 		return err
 	}
 	// Perform transfer within tx
-	if err := accounts.Transfer(tx, playerWinningsAcc.ID, escrowAcc.ID, float64(stakeAmount), "SESSION", sql.NullInt64{Int64: int64(sessionID), Valid: true}, "Stake moved to escrow on match init"); err != nil {
+	if err := accounts.Transfer(tx, playerWinningsAcc.ID, escrowAcc.ID, float64(stakeAmount), "SESSION", sql.NullInt64{Int64: int64(sessionID), Valid: true}, "Stake moved to escrow on match init", gm.config.SettlementOverdraftGuard); err != nil {
 		return err
 	}
 	// Insert STAKE_IN escrow ledger row referencing queue and session
@@ -1719,6 +2044,7 @@ func (gm *GameManager) JoinPrivateMatch(matchCode string, myQueueID int, myPhone
 		myDisplayName,
 		stakeAmount,
 	)
+	gm.attachRatings(game)
 
 	// Save to memory
 	gm.mu.Lock()
@@ -1759,18 +2085,32 @@ func (gm *GameManager) JoinPrivateMatch(matchCode string, myQueueID int, myPhone
 		return nil, fmt.Errorf("failed to reserve my stake")
 	}
 
-	// All good - update both queue rows and commit
-	if _, err = tx.Exec(`UPDATE matchmaking_queue SET status='matched', matched_at=NOW(), session_id=$1 WHERE id=$2`, sessionID, oppQueue.ID); err != nil {
+	// All good - update both queue rows and commit. Only rows still waiting: a leave or
+	// cancel that landed mid-match wins
+	var res sql.Result
+	if res, err = tx.Exec(`UPDATE matchmaking_queue SET status='matched', matched_at=NOW(), session_id=$1 WHERE id=$2 AND status='matching'`, sessionID, oppQueue.ID); err == nil {
+		if n, _ := res.RowsAffected(); n == 0 {
+			err = errLeftWhileMatching
+		}
+	}
+	if err != nil {
 		log.Printf("[DB] Failed to update opponent queue %d: %v", oppQueue.ID, err)
 		tx.Rollback()
+		gm.dropUnmatchedGame(gameID, opponentEphemeral, myEphemeral)
 		if _, err2 := gm.db.Exec(`UPDATE matchmaking_queue SET status='queued' WHERE id=$1`, myQueueID); err2 != nil {
 			log.Printf("[DB] Failed to reset my queue row after update failure: %v", err2)
 		}
 		return nil, fmt.Errorf("failed to update opponent queue")
 	}
-	if _, err = tx.Exec(`UPDATE matchmaking_queue SET status='matched', matched_at=NOW(), session_id=$1 WHERE id=$2`, sessionID, myQueueID); err != nil {
+	if res, err = tx.Exec(`UPDATE matchmaking_queue SET status='matched', matched_at=NOW(), session_id=$1 WHERE id=$2 AND status IN ('queued','matching')`, sessionID, myQueueID); err == nil {
+		if n, _ := res.RowsAffected(); n == 0 {
+			err = errLeftWhileMatching
+		}
+	}
+	if err != nil {
 		log.Printf("[DB] Failed to update my queue %d: %v", myQueueID, err)
 		tx.Rollback()
+		gm.dropUnmatchedGame(gameID, opponentEphemeral, myEphemeral)
 		if _, err2 := gm.db.Exec(`UPDATE matchmaking_queue SET status='queued' WHERE id=$1`, oppQueue.ID); err2 != nil {
 			log.Printf("[DB] Failed to reset opponent queue row after update failure: %v", err2)
 		}
@@ -1813,21 +2153,8 @@ func (gm *GameManager) JoinPrivateMatch(matchCode string, myQueueID int, myPhone
 		player1Link := baseURL + "/g/" + gameToken + "?pt=" + player1Token
 		player2Link := baseURL + "/g/" + gameToken + "?pt=" + player2Token
 
-		go func(oppPhone, joinerPhone, link1, link2, oppName, joinerName string, stake int) {
-			ctx := context.Background()
-			msgOpp := fmt.Sprintf("Private match found with %s! Stake %d UGX. Join: %s", joinerName, stake, link1)
-			if msgID, err := sms.SendSMS(ctx, oppPhone, msgOpp); err != nil {
-				log.Printf("[SMS] Failed to send private match SMS to %s: %v", oppPhone, err)
-			} else {
-				log.Printf("[SMS] Private match SMS sent to %s msg_id=%s", oppPhone, msgID)
-			}
-			msgMe := fmt.Sprintf("Private match found with %s! Stake %d UGX. Join: %s", oppName, stake, link2)
-			if msgID, err := sms.SendSMS(ctx, joinerPhone, msgMe); err != nil {
-				log.Printf("[SMS] Failed to send private match SMS to %s: %v", joinerPhone, err)
-			} else {
-				log.Printf("[SMS] Private match SMS sent to %s msg_id=%s", joinerPhone, msgID)
-			}
-		}(oppQueue.PhoneNumber, myPhone, player1Link, player2Link, oppName, myName, stakeAmount)
+		sms.EnqueueTemplate(sms.TypeMatch, oppQueue.PhoneNumber, templates.PrivateMatch, templates.Params{"Opponent": myName, "Stake": stakeAmount, "Link": player1Link})
+		sms.EnqueueTemplate(sms.TypeMatch, myPhone, templates.PrivateMatch, templates.Params{"Opponent": oppName, "Stake": stakeAmount, "Link": player2Link})
 	}
 
 	// Build match result
@@ -1857,6 +2184,9 @@ func (gm *GameManager) ProcessWinnerPayout(sessionID, winnerPlayerID, stakeAmoun
 	if gm.db == nil {
 		return fmt.Errorf("db not available")
 	}
+	if stakeAmount <= 0 {
+		return nil // Tournament games: prizes are paid when the bracket completes
+	}
 
 	pot := float64(stakeAmount * 2) // Full pot (both players' stakes)
 	taxRate := float64(gm.config.PayoutTaxPercent) / 100.0
@@ -1869,14 +2199,18 @@ func (gm *GameManager) ProcessWinnerPayout(sessionID, winnerPlayerID, stakeAmoun
 	}
 	defer tx.Rollback()
 
-	// Idempotency check: skip if payout already processed
+	// Idempotency check: skip if the session was already paid out or refunded. The session
+	// row lock orders this against refundSessionStakes.
+	if _, err := tx.Exec(`SELECT id FROM game_sessions WHERE id=$1 FOR UPDATE`, sessionID); err != nil {
+		return fmt.Errorf("failed to lock session: %w", err)
+	}
 	var cnt int
-	if err := tx.Get(&cnt, `SELECT COUNT(*) FROM escrow_ledger WHERE session_id=$1 AND entry_type='PAYOUT'`, sessionID); err != nil {
+	if err := tx.Get(&cnt, `SELECT COUNT(*) FROM escrow_ledger WHERE session_id=$1 AND entry_type IN ('REFUND', 'PAYOUT')`, sessionID); err != nil {
 		return fmt.Errorf("failed to check existing payouts: %w", err)
 	}
 	if cnt > 0 {
-		log.Printf("[PAYOUT] Payout already processed for session %d", sessionID)
-		return nil // Already paid, not an error
+		logger.For(context.Background(), "payout").Info("session already settled", "session_id", sessionID)
+		return nil // Already paid or refunded, not an error
 	}
 
 	// Get accounts
@@ -1896,12 +2230,12 @@ func (gm *GameManager) ProcessWinnerPayout(sessionID, winnerPlayerID, stakeAmoun
 	}
 
 	// Transfer: ESCROW -> TAX (15%)
-	if err := accounts.Transfer(tx, escrowAcc.ID, taxAcc.ID, taxAmount, "SESSION", sql.NullInt64{Int64: int64(sessionID), Valid: true}, "Payout tax"); err != nil {
+	if err := accounts.Transfer(tx, escrowAcc.ID, taxAcc.ID, taxAmount, "SESSION", sql.NullInt64{Int64: int64(sessionID), Valid: true}, "Payout tax", gm.config.SettlementOverdraftGuard); err != nil {
 		return fmt.Errorf("failed to transfer tax: %w", err)
 	}
 
 	// Transfer: ESCROW -> PLAYER_WINNINGS (85% after tax)
-	if err := accounts.Transfer(tx, escrowAcc.ID, winningsAcc.ID, winningsNet, "SESSION", sql.NullInt64{Int64: int64(sessionID), Valid: true}, "Winner payout (after tax)"); err != nil {
+	if err := accounts.Transfer(tx, escrowAcc.ID, winningsAcc.ID, winningsNet, "SESSION", sql.NullInt64{Int64: int64(sessionID), Valid: true}, "Winner payout (after tax)", gm.config.SettlementOverdraftGuard); err != nil {
 		return fmt.Errorf("failed to transfer winnings: %w", err)
 	}
 
@@ -1915,7 +2249,7 @@ func (gm *GameManager) ProcessWinnerPayout(sessionID, winnerPlayerID, stakeAmoun
 		return fmt.Errorf("failed to commit tx: %w", err)
 	}
 
-	log.Printf("[PAYOUT] Successfully paid %.2f UGX (%.2f%% of %.2f pot) to player %d for session %d", winningsNet, (1.0-taxRate)*100, pot, winnerPlayerID, sessionID)
+	logger.For(context.Background(), "payout").Info("winnings paid", "session_id", sessionID, "player_id", winnerPlayerID, "amount", winningsNet, "pot", pot, "tax_rate", taxRate)
 
 	// Winnings are now accumulated in player account for manual withdrawal
 	return nil
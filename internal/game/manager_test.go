@@ -0,0 +1,463 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestStopEndsBackgroundCheckers(t *testing.T) {
+	const interval = 20 * time.Millisecond
+	prevExpiry, prevDisconnect, prevQueue := expiryCheckInterval, disconnectCheckInterval, queueExpiryCheckInterval
+	expiryCheckInterval, disconnectCheckInterval, queueExpiryCheckInterval = interval, interval, interval
+	t.Cleanup(func() {
+		expiryCheckInterval, disconnectCheckInterval, queueExpiryCheckInterval = prevExpiry, prevDisconnect, prevQueue
+	})
+
+	gm := NewGameManager(nil, nil, &config.Config{})
+	ctx, cancel := context.WithCancel(context.Background())
+	gm.stop = cancel
+	gm.startWorker(ctx, gm.StartExpiryChecker)
+	gm.startWorker(ctx, gm.StartDisconnectChecker)
+	gm.startWorker(ctx, gm.StartQueueExpiryChecker)
+	gm.startWorker(ctx, gm.StartProcessingRecoveryChecker)
+
+	// Let the tickers fire a few times before stopping
+	time.Sleep(3 * interval)
+
+	stopped := make(chan struct{})
+	go func() {
+		gm.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(interval):
+		t.Fatal("checkers still running one interval after Stop")
+	}
+}
+
+// droppedGame adds an in-progress game to Manager in which p1 dropped dropAfter into the
+// game (a minute ago) after shots shots
+func droppedGame(t *testing.T, key string, dropAfter time.Duration, shots int) *PoolGameState {
+	t.Helper()
+	g := newTestPoolGame(t)
+	started := time.Now().Add(-time.Minute)
+	dropped := started.Add(dropAfter)
+	g.StartedAt = &started
+	g.ShotNumber = shots
+	g.Player1.Connected, g.Player2.Connected = false, true
+	g.Player1.DisconnectedAt = &dropped
+	Manager.games[key] = g
+	return g
+}
+
+func withEarlyDisconnectManager(t *testing.T, db *sqlx.DB) {
+	t.Helper()
+	prev := Manager
+	Manager = NewGameManager(db, nil, &config.Config{EarlyDisconnectCancelSeconds: 30, EloKFactor: 32})
+	t.Cleanup(func() { Manager = prev })
+}
+
+func TestEarlyDisconnectCancelsInsteadOfForfeit(t *testing.T) {
+	withEarlyDisconnectManager(t, nil)
+
+	instant := droppedGame(t, "instant", time.Second, 0)
+	late := droppedGame(t, "late", 45*time.Second, 0)
+	afterShots := droppedGame(t, "after-shots", 5*time.Second, 2)
+
+	Manager.checkDisconnectForfeits()
+
+	if instant.Status != StatusCancelled {
+		t.Errorf("instant drop: status %s, want cancelled", instant.Status)
+	}
+	for name, g := range map[string]*PoolGameState{"late drop": late, "drop after shots": afterShots} {
+		if g.Status != StatusCompleted || g.WinType != "forfeit" || g.Winner != "p2" {
+			t.Errorf("%s: status=%s win_type=%s winner=%s, want p2 winning by forfeit", name, g.Status, g.WinType, g.Winner)
+		}
+	}
+}
+
+func TestEarlyDisconnectRefundsAndLateDropIsRated(t *testing.T) {
+	db := testDB(t)
+	withEarlyDisconnectManager(t, db)
+
+	escrow, err := accounts.GetOrCreateAccount(db, accounts.AccountEscrow, nil)
+	if err != nil {
+		t.Fatalf("escrow account: %v", err)
+	}
+	// Both stakes of both games
+	if _, err := db.Exec(`UPDATE accounts SET balance = balance + 4000 WHERE id=$1`, escrow.ID); err != nil {
+		t.Fatalf("fund escrow: %v", err)
+	}
+
+	// players returns two fresh players for a game
+	players := func() (int, int) {
+		ids := make([]int, 2)
+		for i := range ids {
+			phone := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+int64(i))%100000000)
+			if err := db.Get(&ids[i], `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+				t.Fatalf("insert player: %v", err)
+			}
+		}
+		return ids[0], ids[1]
+	}
+	session := func(g *PoolGameState, p1, p2 int) {
+		g.Player1.DBPlayerID, g.Player2.DBPlayerID = p1, p2
+		if err := db.Get(&g.SessionID, `INSERT INTO game_sessions (game_token, player1_id, player2_id, stake_amount, status, created_at, expiry_time) VALUES ($1, $2, $3, $4, 'IN_PROGRESS', NOW(), NOW() + INTERVAL '3 minutes') RETURNING id`, fmt.Sprintf("tok_%d_%d", p1, p2), p1, p2, g.StakeAmount); err != nil {
+			t.Fatalf("insert session: %v", err)
+		}
+	}
+	rating := func(pid int) int {
+		var r int
+		if err := db.Get(&r, `SELECT elo_rating FROM players WHERE id=$1`, pid); err != nil {
+			t.Fatalf("read rating: %v", err)
+		}
+		return r
+	}
+
+	a1, a2 := players()
+	instant := droppedGame(t, "instant", time.Second, 0)
+	session(instant, a1, a2)
+	b1, b2 := players()
+	late := droppedGame(t, "late", 45*time.Second, 0)
+	session(late, b1, b2)
+
+	Manager.checkDisconnectForfeits()
+
+	for _, pid := range []int{a1, a2} {
+		acc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
+		if err != nil {
+			t.Fatalf("winnings account: %v", err)
+		}
+		if acc.Balance != float64(instant.StakeAmount) {
+			t.Errorf("player %d refund %.2f, want %d", pid, acc.Balance, instant.StakeAmount)
+		}
+		if r := rating(pid); r != DefaultEloRating {
+			t.Errorf("player %d rating %d after an early drop, want unchanged", pid, r)
+		}
+	}
+	if r1, r2 := rating(b1), rating(b2); r1 != DefaultEloRating-16 || r2 != DefaultEloRating+16 {
+		t.Errorf("late drop ratings %d/%d, want %d/%d", r1, r2, DefaultEloRating-16, DefaultEloRating+16)
+	}
+}
+
+func TestSessionRefundPathsRecordStandardEntries(t *testing.T) {
+	db := testDB(t)
+	withEarlyDisconnectManager(t, db)
+
+	escrow, err := accounts.GetOrCreateAccount(db, accounts.AccountEscrow, nil)
+	if err != nil {
+		t.Fatalf("escrow account: %v", err)
+	}
+	// Four games, two stakes each
+	if _, err := db.Exec(`UPDATE accounts SET balance = balance + 8000 WHERE id=$1`, escrow.ID); err != nil {
+		t.Fatalf("fund escrow: %v", err)
+	}
+	persist := func(key string, g *PoolGameState) {
+		for _, p := range []*PoolPlayer{g.Player1, g.Player2} {
+			phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+			if err := db.Get(&p.DBPlayerID, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+				t.Fatalf("insert player: %v", err)
+			}
+		}
+		if err := db.Get(&g.SessionID, `INSERT INTO game_sessions (game_token, player1_id, player2_id, stake_amount, status, created_at, expiry_time) VALUES ($1, $2, $3, $4, 'WAITING', NOW(), NOW()) RETURNING id`,
+			fmt.Sprintf("%s_%d", key, time.Now().UnixNano()), g.Player1.DBPlayerID, g.Player2.DBPlayerID, g.StakeAmount); err != nil {
+			t.Fatalf("insert session: %v", err)
+		}
+		Manager.games[key] = g
+	}
+
+	expired := NewPoolGame("exp", "exp-tok", "p1", "256700000001", "t1", 0, "One", "p2", "256700000002", "t2", 0, "Two", 1000)
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+	persist("expired", expired)
+
+	early := droppedGame(t, "early", time.Second, 0)
+	persist("early", early)
+
+	adminCancelled := newTestPoolGame(t)
+	persist("admin", adminCancelled)
+
+	drawn := newTestPoolGame(t)
+	persist("draw", drawn)
+
+	Manager.checkExpiredGames()
+	Manager.checkDisconnectForfeits()
+	if err := Manager.ForceCancelSession(adminCancelled.SessionID, "stuck"); err != nil {
+		t.Fatalf("ForceCancelSession: %v", err)
+	}
+	drawn.Status, drawn.WinType = StatusCompleted, "draw"
+	Manager.SaveFinalGameState(drawn)
+
+	for g, reason := range map[*PoolGameState]string{
+		expired:        accounts.RefundSessionExpired,
+		early:          accounts.RefundEarlyDisconnect,
+		adminCancelled: accounts.RefundAdminCancel,
+		drawn:          accounts.RefundDraw,
+	} {
+		var rows []struct {
+			EntryType    string `db:"entry_type"`
+			RefundReason string `db:"refund_reason"`
+		}
+		if err := db.Select(&rows, `SELECT entry_type, refund_reason FROM escrow_ledger WHERE session_id=$1`, g.SessionID); err != nil {
+			t.Fatalf("read ledger: %v", err)
+		}
+		if len(rows) != 2 {
+			t.Errorf("%s: %d ledger rows, want one refund per player", reason, len(rows))
+		}
+		for _, row := range rows {
+			if row.EntryType != accounts.LedgerRefund || row.RefundReason != reason {
+				t.Errorf("%s: ledger row %s/%s, want %s/%s", reason, row.EntryType, row.RefundReason, accounts.LedgerRefund, reason)
+			}
+		}
+	}
+}
+
+func TestForceCancelledSessionIsNeverPaidOut(t *testing.T) {
+	db := testDB(t)
+	withEarlyDisconnectManager(t, db)
+
+	escrow, err := accounts.GetOrCreateAccount(db, accounts.AccountEscrow, nil)
+	if err != nil {
+		t.Fatalf("escrow account: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE accounts SET balance = balance + 2000 WHERE id=$1`, escrow.ID); err != nil {
+		t.Fatalf("fund escrow: %v", err)
+	}
+	g := newTestPoolGame(t)
+	for _, p := range []*PoolPlayer{g.Player1, g.Player2} {
+		phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+		if err := db.Get(&p.DBPlayerID, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+			t.Fatalf("insert player: %v", err)
+		}
+	}
+	if err := db.Get(&g.SessionID, `INSERT INTO game_sessions (game_token, player1_id, player2_id, stake_amount, status, created_at, expiry_time) VALUES ($1, $2, $3, $4, 'IN_PROGRESS', NOW(), NOW()) RETURNING id`,
+		fmt.Sprintf("force_%d", time.Now().UnixNano()), g.Player1.DBPlayerID, g.Player2.DBPlayerID, g.StakeAmount); err != nil {
+		t.Fatalf("insert session: %v", err)
+	}
+	Manager.games[g.ID] = g
+	if err := g.BeginShot(g.CurrentTurn, ShotParams{Angle: 1, Power: 100}, nil); err != nil {
+		t.Fatalf("begin shot: %v", err)
+	}
+	shooter := g.CurrentTurn
+
+	if err := Manager.ForceCancelSession(g.SessionID, "stuck"); err != nil {
+		t.Fatalf("ForceCancelSession: %v", err)
+	}
+	if _, err := g.ApplyShotResult(shooter, shotData(g, 1, true)); err == nil {
+		t.Error("shot result applied to a cancelled game")
+	}
+	if err := Manager.ForceCancelSession(g.SessionID, "again"); !errors.Is(err, ErrSessionSettled) {
+		t.Errorf("second cancel: %v, want ErrSessionSettled", err)
+	}
+	if err := Manager.ProcessWinnerPayout(g.SessionID, g.Player1.DBPlayerID, g.StakeAmount); err != nil {
+		t.Fatalf("ProcessWinnerPayout: %v", err)
+	}
+	var payouts int
+	if err := db.Get(&payouts, `SELECT COUNT(*) FROM escrow_ledger WHERE session_id=$1 AND entry_type='PAYOUT'`, g.SessionID); err != nil {
+		t.Fatalf("read ledger: %v", err)
+	}
+	if payouts != 0 {
+		t.Errorf("%d payouts for a refunded session, want 0", payouts)
+	}
+}
+
+func TestUnstartedPoolGameExpiresAndRefundsBothStakes(t *testing.T) {
+	db := testDB(t)
+	prev := Manager
+	t.Cleanup(func() { Manager = prev })
+	Manager = NewGameManager(db, nil, &config.Config{GameExpiryMinutes: 7})
+
+	g := NewPoolGame("unstarted", "unstarted-tok", "p1", "256700000001", "t1", 0, "One", "p2", "256700000002", "t2", 0, "Two", 3000)
+	if until := time.Until(g.ExpiresAt); until < 6*time.Minute || until > 7*time.Minute {
+		t.Fatalf("join window %v, want GAME_EXPIRY_MINUTES (7m)", until)
+	}
+
+	escrow, err := accounts.GetOrCreateAccount(db, accounts.AccountEscrow, nil)
+	if err != nil {
+		t.Fatalf("escrow account: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE accounts SET balance = balance + 6000 WHERE id=$1`, escrow.ID); err != nil {
+		t.Fatalf("fund escrow: %v", err)
+	}
+	for i, p := range []*PoolPlayer{g.Player1, g.Player2} {
+		phone := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+int64(i))%100000000)
+		if err := db.Get(&p.DBPlayerID, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+			t.Fatalf("insert player: %v", err)
+		}
+	}
+	if err := db.Get(&g.SessionID, `INSERT INTO game_sessions (game_token, player1_id, player2_id, stake_amount, status, created_at, expiry_time) VALUES ($1, $2, $3, $4, 'WAITING', NOW(), $5) RETURNING id`,
+		fmt.Sprintf("unstarted_%d", time.Now().UnixNano()), g.Player1.DBPlayerID, g.Player2.DBPlayerID, g.StakeAmount, g.ExpiresAt); err != nil {
+		t.Fatalf("insert session: %v", err)
+	}
+
+	// Nobody connects before the window closes
+	g.ExpiresAt = time.Now().Add(-time.Second)
+	Manager.games[g.ID] = g
+	Manager.checkExpiredGames()
+
+	if g.Status != StatusCancelled {
+		t.Fatalf("game status %s, want cancelled", g.Status)
+	}
+	var status string
+	db.Get(&status, `SELECT status FROM game_sessions WHERE id=$1`, g.SessionID)
+	if status != string(StatusCancelled) {
+		t.Errorf("session status %s, want %s", status, StatusCancelled)
+	}
+	for _, pid := range []int{g.Player1.DBPlayerID, g.Player2.DBPlayerID} {
+		acc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
+		if err != nil {
+			t.Fatalf("winnings account: %v", err)
+		}
+		if acc.Balance != float64(g.StakeAmount) {
+			t.Errorf("player %d refunded %.2f, want %d", pid, acc.Balance, g.StakeAmount)
+		}
+	}
+}
+
+func TestRehydrateExpiresNearlyExpiredRows(t *testing.T) {
+	db := testDB(t)
+	rdb := testRedis(t)
+	ctx := context.Background()
+	gm := NewGameManager(db, rdb, &config.Config{RehydrateMinTTLSeconds: 30})
+
+	// A stake nobody else queues at, so the Redis queue starts empty
+	stake := 900000 + int(time.Now().UnixNano()%90000)
+	key := queueKey(stake)
+	t.Cleanup(func() { rdb.Del(ctx, key) })
+
+	queue := func(ttl string) int {
+		var id int
+		if err := db.Get(&id, `INSERT INTO matchmaking_queue (phone_number, stake_amount, status, created_at, expires_at)
+			VALUES ('256700000001', $1, 'queued', NOW(), NOW() + $2::interval) RETURNING id`, stake, ttl); err != nil {
+			t.Fatalf("insert queue: %v", err)
+		}
+		return id
+	}
+	nearly, healthy := queue("5 seconds"), queue("2 minutes")
+
+	if err := gm.RehydrateQueueFromDB(); err != nil {
+		t.Fatalf("rehydrate: %v", err)
+	}
+
+	status := func(id int) string {
+		var s string
+		db.Get(&s, `SELECT status FROM matchmaking_queue WHERE id=$1`, id)
+		return s
+	}
+	if s := status(nearly); s != "expired" {
+		t.Fatalf("row with 5s left: status %q, want expired", s)
+	}
+	if s := status(healthy); s != "queued" {
+		t.Fatalf("row with 2m left: status %q, want queued", s)
+	}
+	items, _ := rdb.ZRange(ctx, key, 0, -1).Result()
+	if len(items) != 1 || items[0] != fmt.Sprint(healthy) {
+		t.Fatalf("redis queue = %v, want only %d", items, healthy)
+	}
+}
+
+// connectRace runs Initialize + StartSession from n concurrent "both players connected" paths
+// and returns how many of them ran the session-started side effects
+func connectRace(gm *GameManager, g *PoolGameState, n int) int {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	started := 0
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := g.Initialize(); err != nil {
+				return
+			}
+			if gm.StartSession(g) {
+				mu.Lock()
+				started++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return started
+}
+
+func TestStartSessionRunsOncePerGame(t *testing.T) {
+	gm := NewGameManager(nil, nil, &config.Config{})
+	g := NewPoolGame("g1", "tok", "p1", "256700000001", "t1", 0, "One", "p2", "256700000002", "t2", 0, "Two", 1000)
+
+	if gm.StartSession(g) {
+		t.Fatal("session started before the game was initialized")
+	}
+	if n := connectRace(gm, g, 8); n != 1 {
+		t.Fatalf("session-started side effects ran %d times, want 1", n)
+	}
+	if gm.StartSession(g) {
+		t.Fatal("late reconnect started the session again")
+	}
+}
+
+func TestStartSessionPublishesOneEvent(t *testing.T) {
+	rdb := testRedis(t)
+	ctx := context.Background()
+	gm := NewGameManager(nil, rdb, &config.Config{})
+	g := NewPoolGame(fmt.Sprintf("g-start-%d", time.Now().UnixNano()), "tok", "p1", "256700000001", "t1", 0, "One", "p2", "256700000002", "t2", 0, "Two", 1000)
+
+	sub := rdb.Subscribe(ctx, "game_events")
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	connectRace(gm, g, 2)
+	connectRace(gm, g, 2)
+
+	events := 0
+	timeout := time.After(500 * time.Millisecond)
+	for {
+		select {
+		case msg := <-sub.Channel():
+			var ev map[string]interface{}
+			json.Unmarshal([]byte(msg.Payload), &ev)
+			if ev["type"] == "session_started" && ev["game_id"] == g.ID {
+				events++
+			}
+		case <-timeout:
+			if events != 1 {
+				t.Fatalf("got %d session_started events, want 1", events)
+			}
+			return
+		}
+	}
+}
+
+func TestReconnectWithinGraceDoesNotForfeit(t *testing.T) {
+	withEarlyDisconnectManager(t, nil)
+	Manager.config.DisconnectGraceSeconds = 30
+
+	g := droppedGame(t, "back", 45*time.Second, 2)
+	// 15 seconds into the grace window the player rejoins
+	away := time.Now().Add(-15 * time.Second)
+	g.Player1.DisconnectedAt = &away
+	if !g.ClearPlayerDisconnectTime("p1") {
+		t.Fatal("no pending disconnect cleared on rejoin")
+	}
+	g.SetPlayerConnected("p1", true)
+	if g.ClearPlayerDisconnectTime("p1") {
+		t.Fatal("second clear reported a pending disconnect")
+	}
+
+	// Long after the window would have closed the game is still on
+	Manager.config.DisconnectGraceSeconds = 0
+	Manager.checkDisconnectForfeits()
+	if g.Status != StatusInProgress || g.Winner != "" {
+		t.Fatalf("status=%s winner=%q after rejoin, want still in progress", g.Status, g.Winner)
+	}
+}
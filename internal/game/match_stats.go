@@ -0,0 +1,86 @@
+package game
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// matchDurationBuckets are the upper bounds of the TryMatchFromRedis duration histogram
+var matchDurationBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+}
+
+// matchStats counts work on the Redis matching path
+type matchStats struct {
+	claimsAttempted  atomic.Int64
+	claimsWon        atomic.Int64
+	selfMatchAvoided atomic.Int64
+	claimRaces       atomic.Int64
+	requeued         atomic.Int64
+
+	attempts   atomic.Int64
+	durationNs atomic.Int64
+	buckets    [10]atomic.Int64 // one per matchDurationBuckets entry, then +Inf
+}
+
+// observe records how long one TryMatchFromRedis call took
+func (s *matchStats) observe(d time.Duration) {
+	s.attempts.Add(1)
+	s.durationNs.Add(int64(d))
+	i := 0
+	for i < len(matchDurationBuckets) && d > matchDurationBuckets[i] {
+		i++
+	}
+	s.buckets[i].Add(1)
+}
+
+// DurationBucket is one histogram bucket: calls that took at most LE milliseconds (0 = +Inf)
+type DurationBucket struct {
+	LE    float64 `json:"le_ms"`
+	Count int64   `json:"count"`
+}
+
+// MatchStats is a snapshot of the Redis matching counters.
+type MatchStats struct {
+	ClaimsAttempted  int64            `json:"claims_attempted"`   // claim script runs
+	ClaimsWon        int64            `json:"claims_won"`         // runs that popped an opponent id
+	SelfMatchAvoided int64            `json:"self_match_avoided"` // popped ids that were the caller's own
+	ClaimRaces       int64            `json:"claim_races"`        // popped ids another matcher had already taken in the DB
+	Requeued         int64            `json:"requeued"`           // callers pushed back to wait in the queue
+	Attempts         int64            `json:"attempts"`           // TryMatchFromRedis calls timed
+	AvgDurationMs    float64          `json:"avg_duration_ms"`
+	Duration         []DurationBucket `json:"duration"` // non-cumulative
+}
+
+// MatchStats returns the manager's matching counters
+func (gm *GameManager) MatchStats() MatchStats {
+	s := &gm.matchStats
+	out := MatchStats{
+		ClaimsAttempted:  s.claimsAttempted.Load(),
+		ClaimsWon:        s.claimsWon.Load(),
+		SelfMatchAvoided: s.selfMatchAvoided.Load(),
+		ClaimRaces:       s.claimRaces.Load(),
+		Requeued:         s.requeued.Load(),
+		Attempts:         s.attempts.Load(),
+		Duration:         make([]DurationBucket, len(s.buckets)),
+	}
+	if out.Attempts > 0 {
+		out.AvgDurationMs = float64(s.durationNs.Load()) / float64(out.Attempts) / float64(time.Millisecond)
+	}
+	for i := range s.buckets {
+		b := DurationBucket{Count: s.buckets[i].Load()}
+		if i < len(matchDurationBuckets) {
+			b.LE = float64(matchDurationBuckets[i]) / float64(time.Millisecond)
+		}
+		out.Duration[i] = b
+	}
+	return out
+}
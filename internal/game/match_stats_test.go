@@ -0,0 +1,59 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestMatchDurationHistogram(t *testing.T) {
+	gm := NewGameManager(nil, nil, &config.Config{})
+	for _, d := range []time.Duration{2 * time.Millisecond, 5 * time.Millisecond, 40 * time.Millisecond, 3 * time.Second} {
+		gm.matchStats.observe(d)
+	}
+	st := gm.MatchStats()
+	if st.Attempts != 4 {
+		t.Fatalf("attempts = %d, want 4", st.Attempts)
+	}
+	counts := map[float64]int64{}
+	for _, b := range st.Duration {
+		counts[b.LE] = b.Count
+	}
+	// Bounds are inclusive; 0 is the +Inf bucket
+	if counts[5] != 2 || counts[50] != 1 || counts[0] != 1 {
+		t.Fatalf("buckets = %+v, want 2 in <=5ms, 1 in <=50ms, 1 over the top", st.Duration)
+	}
+}
+
+func TestMatchStatsCountClaimRace(t *testing.T) {
+	db := testDB(t)
+	rdb := testRedis(t)
+	ctx := context.Background()
+
+	stake := 800000 + int(time.Now().UnixNano()%90000)
+	gm := NewGameManager(db, rdb, &config.Config{})
+	key := queueKey(stake)
+	t.Cleanup(func() {
+		rdb.Del(ctx, key, fmt.Sprintf("processing:stake:%d", stake), fmt.Sprintf("processing_ts:stake:%d", stake))
+	})
+
+	// Another matcher has already taken this row in the DB, but its id is still listed
+	var taken int
+	if err := db.Get(&taken, `INSERT INTO matchmaking_queue (phone_number, stake_amount, queue_token, status, created_at, expires_at)
+		VALUES ('256700000011', $1, $2, 'matching', NOW(), NOW() + INTERVAL '10 minutes') RETURNING id`, stake, fmt.Sprintf("race-%d", time.Now().UnixNano())); err != nil {
+		t.Fatalf("insert queue: %v", err)
+	}
+	gm.enqueueIDs(ctx, stake, taken)
+
+	res, err := gm.TryMatchFromRedis(ctx, stake, 999999999, "256700000012", 0, "Racer")
+	if err != nil || res != nil {
+		t.Fatalf("match: result %v err %v, want queued", res, err)
+	}
+	st := gm.MatchStats()
+	if st.ClaimsWon != 1 || st.ClaimRaces != 1 || st.Requeued != 1 || st.ClaimsAttempted != 2 || st.Attempts != 1 {
+		t.Fatalf("stats = %+v, want 2 claims (1 won, lost the DB race) and 1 requeue", st)
+	}
+}
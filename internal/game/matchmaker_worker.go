@@ -9,6 +9,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/playpool/backend/internal/config"
 	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -22,26 +23,57 @@ type QueuedPlayer struct {
 	DisplayName string  `db:"display_name"`
 }
 
-// StartMatchmakerWorker runs a background job to match players from the DB queue
+// StartMatchmakerWorker runs a background job to match players from the DB queue.
+// It polls at the minimum interval while players are queued and backs off while the
+// queue stays empty, so an idle platform doesn't query the DB every few seconds.
 func StartMatchmakerWorker(ctx context.Context, db *sqlx.DB, rdb *redis.Client, cfg *config.Config) {
-	interval := time.Duration(cfg.MatchmakerPollSeconds) * time.Second
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	minInterval, maxInterval := matchmakerPollBounds(cfg)
+	interval := minInterval
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
 
-	log.Printf("[MATCHMAKER] Starting matchmaker worker (poll every %v)", interval)
+	log.Printf("[MATCHMAKER] Starting matchmaker worker (poll every %v, up to %v when idle)", minInterval, maxInterval)
 
 	for {
 		select {
 		case <-ctx.Done():
 			log.Printf("[MATCHMAKER] Worker stopped")
 			return
-		case <-ticker.C:
-			processMatchmaking(ctx, db, rdb, cfg)
+		case <-timer.C:
+			busy := processMatchmaking(ctx, db, rdb, cfg)
+			interval = nextMatchmakerInterval(interval, minInterval, maxInterval, busy)
+			timer.Reset(interval)
 		}
 	}
 }
 
-func processMatchmaking(ctx context.Context, db *sqlx.DB, rdb *redis.Client, cfg *config.Config) {
+// matchmakerPollBounds returns the worker's poll interval range from config
+func matchmakerPollBounds(cfg *config.Config) (time.Duration, time.Duration) {
+	minInterval := time.Duration(cfg.MatchmakerPollSeconds) * time.Second
+	if minInterval <= 0 {
+		minInterval = 2 * time.Second
+	}
+	maxInterval := time.Duration(cfg.MatchmakerMaxPollSeconds) * time.Second
+	if maxInterval < minInterval {
+		maxInterval = minInterval
+	}
+	return minInterval, maxInterval
+}
+
+// nextMatchmakerInterval doubles the poll interval (up to hi) after a poll that found no
+// queued players, and drops straight back to lo as soon as one did.
+func nextMatchmakerInterval(current, lo, hi time.Duration, busy bool) time.Duration {
+	if busy {
+		return lo
+	}
+	if next := current * 2; next < hi {
+		return next
+	}
+	return hi
+}
+
+// processMatchmaking pairs queued players at every stake and reports whether any were queued
+func processMatchmaking(ctx context.Context, db *sqlx.DB, rdb *redis.Client, cfg *config.Config) bool {
 	// Get distinct stake amounts with queued players
 	var stakes []float64
 	err := db.Select(&stakes, `
@@ -53,16 +85,17 @@ func processMatchmaking(ctx context.Context, db *sqlx.DB, rdb *redis.Client, cfg
 	`)
 	if err != nil {
 		log.Printf("[MATCHMAKER] Failed to get stake levels: %v", err)
-		return
+		return false
 	}
 
 	if len(stakes) == 0 {
-		return // No queued players
+		return false // No queued players
 	}
 
 	for _, stake := range stakes {
 		matchPairsAtStake(ctx, db, rdb, cfg, stake)
 	}
+	return true
 }
 
 func matchPairsAtStake(ctx context.Context, db *sqlx.DB, rdb *redis.Client, cfg *config.Config, stake float64) {
@@ -154,11 +187,16 @@ func tryMatchPair(ctx context.Context, db *sqlx.DB, rdb *redis.Client, cfg *conf
 	log.Printf("[MATCHMAKER] ✓ Match created: session=%d token=%s players=[%d,%d]",
 		sessionID, gameToken, players[0].PlayerID, players[1].PlayerID)
 
+	// Waiting screens switch now; the game itself is ready a moment later
+	announceOpponentFound(rdb, cfg, int(stake),
+		OpponentFoundPlayer{QueueToken: players[0].QueueToken, DisplayName: players[0].DisplayName},
+		OpponentFoundPlayer{QueueToken: players[1].QueueToken, DisplayName: players[1].DisplayName})
+
 	// Create in-memory pool game for WebSocket play
 	Manager.CreatePoolGameFromMatch(players[0], players[1], gameToken, stake, cfg)
 
 	// Send SMS to both players
-	go sendMatchSMS(cfg, gameToken, players[0], players[1])
+	sendMatchSMS(cfg, gameToken, players[0], players[1])
 
 	return true
 }
@@ -180,22 +218,19 @@ func sendMatchSMS(cfg *config.Config, gameToken string, player1, player2 QueuedP
 	if p2Opponent == "" {
 		p2Opponent = "an opponent"
 	}
+	if HideOpponentAtMatch(cfg) {
+		p1Opponent, p2Opponent = "an opponent", "an opponent"
+	}
 
 	// Send to player 1
-	msg1 := fmt.Sprintf("PlayPool: Match found! Playing against %s for %.0f UGX.\n\n%s",
-		p1Opponent, player1.StakeAmount, gameLink)
-	if _, err := sms.SendSMS(context.Background(), player1.PhoneNumber, msg1); err != nil {
-		log.Printf("[MATCHMAKER] Failed to send SMS to player %d: %v", player1.PlayerID, err)
-	}
+	sms.EnqueueTemplate(sms.TypeMatch, player1.PhoneNumber, templates.MatchFound,
+		templates.Params{"Opponent": p1Opponent, "Stake": int(player1.StakeAmount), "Link": gameLink})
 
 	// Send to player 2
-	msg2 := fmt.Sprintf("PlayPool: Match found! Playing against %s for %.0f UGX.\n\n%s",
-		p2Opponent, player2.StakeAmount, gameLink)
-	if _, err := sms.SendSMS(context.Background(), player2.PhoneNumber, msg2); err != nil {
-		log.Printf("[MATCHMAKER] Failed to send SMS to player %d: %v", player2.PlayerID, err)
-	}
+	sms.EnqueueTemplate(sms.TypeMatch, player2.PhoneNumber, templates.MatchFound,
+		templates.Params{"Opponent": p2Opponent, "Stake": int(player2.StakeAmount), "Link": gameLink})
 
-	log.Printf("[MATCHMAKER] SMS notifications sent for game %s", gameToken)
+	log.Printf("[MATCHMAKER] SMS notifications queued for game %s", gameToken)
 }
 
 func generateGameToken() string {
@@ -0,0 +1,87 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestMatchmakerIntervalBacksOffWhileIdle(t *testing.T) {
+	lo, hi := matchmakerPollBounds(&config.Config{MatchmakerPollSeconds: 2, MatchmakerMaxPollSeconds: 30})
+	if lo != 2*time.Second || hi != 30*time.Second {
+		t.Fatalf("bounds %v-%v, want 2s-30s", lo, hi)
+	}
+
+	interval := lo
+	var got []time.Duration
+	for i := 0; i < 6; i++ {
+		interval = nextMatchmakerInterval(interval, lo, hi, false)
+		got = append(got, interval)
+	}
+	want := []time.Duration{4 * time.Second, 8 * time.Second, 16 * time.Second, 30 * time.Second, 30 * time.Second, 30 * time.Second}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("idle polls: intervals %v, want %v", got, want)
+		}
+	}
+
+	// A queued row shows up: the next poll comes at the minimum again
+	if interval = nextMatchmakerInterval(interval, lo, hi, true); interval != lo {
+		t.Fatalf("interval after activity %v, want %v", interval, lo)
+	}
+}
+
+func TestMatchmakerPollBoundsDefaults(t *testing.T) {
+	lo, hi := matchmakerPollBounds(&config.Config{MatchmakerPollSeconds: 5})
+	if lo != 5*time.Second || hi != 5*time.Second {
+		t.Fatalf("max below min: bounds %v-%v, want a fixed 5s", lo, hi)
+	}
+	if lo, _ := matchmakerPollBounds(&config.Config{}); lo != 2*time.Second {
+		t.Fatalf("unset min: %v, want 2s", lo)
+	}
+}
+
+func TestMatchAnnouncesOpponentBeforeGameSetup(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{GameExpiryMinutes: 3, OpponentFoundEvent: true}
+	prev, prevPublish := Manager, publishQueueEvent
+	t.Cleanup(func() { Manager, publishQueueEvent = prev, prevPublish })
+	Manager = NewGameManager(db, nil, cfg)
+
+	stake := 900000 + int(time.Now().UnixNano()%90000)
+	tokens := make([]string, 2)
+	for i := range tokens {
+		var pid int
+		phone := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+int64(i))%100000000)
+		if err := db.Get(&pid, `INSERT INTO players (phone_number, display_name) VALUES ($1, $2) RETURNING id`, phone, fmt.Sprintf("Found%d", i)); err != nil {
+			t.Fatalf("insert player: %v", err)
+		}
+		tokens[i] = fmt.Sprintf("q_found_%d_%d", i, time.Now().UnixNano())
+		if _, err := db.Exec(`INSERT INTO matchmaking_queue (player_id, phone_number, stake_amount, queue_token, status, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, 'queued', NOW(), NOW() + INTERVAL '10 minutes')`, pid, phone, stake, tokens[i]); err != nil {
+			t.Fatalf("insert queue: %v", err)
+		}
+	}
+
+	var events []OpponentFoundEvent
+	publishQueueEvent = func(_ *redis.Client, ev OpponentFoundEvent) {
+		if _, err := Manager.GetGameForPlayer(tokens[0]); err == nil {
+			t.Error("opponent_found sent after the game was already set up")
+		}
+		events = append(events, ev)
+	}
+
+	if !tryMatchPair(context.Background(), db, nil, cfg, float64(stake)) {
+		t.Fatal("pair not matched")
+	}
+	if len(events) != 1 || events[0].StakeAmount != stake || events[0].Players[0].QueueToken != tokens[0] || events[0].Players[1].QueueToken != tokens[1] {
+		t.Fatalf("events %+v, want one opponent_found for %v", events, tokens)
+	}
+	if _, err := Manager.GetGameForPlayer(tokens[0]); err != nil {
+		t.Fatalf("game not set up after match: %v", err)
+	}
+}
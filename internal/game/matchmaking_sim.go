@@ -0,0 +1,185 @@
+package game
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// SimStake is one stake tier in a simulation and its share of arrivals
+type SimStake struct {
+	Amount int     `json:"amount"`
+	Weight float64 `json:"weight"`
+}
+
+// SimParams describes the traffic a matchmaking simulation replays
+type SimParams struct {
+	ArrivalsPerMinute float64    `json:"arrivals_per_minute"` // across all stakes
+	Stakes            []SimStake `json:"stakes"`
+	DurationMinutes   int        `json:"duration_minutes"`
+	ExpiryMinutes     int        `json:"expiry_minutes"` // how long an entry may wait (QUEUE_EXPIRY_MINUTES)
+	Seed              int64      `json:"seed"`           // 0 = random
+}
+
+// Simulation limits keep one run to a fraction of a second
+const (
+	simMaxArrivalsPerMinute = 1000
+	simMaxDurationMinutes   = 24 * 60
+	simMaxStakes            = 20
+)
+
+// SimStakeResult is what a stake tier can expect under the simulated traffic
+type SimStakeResult struct {
+	Stake          int     `json:"stake"`
+	Arrivals       int     `json:"arrivals"`
+	Matched        int     `json:"matched"`      // players paired into a game
+	Expired        int     `json:"expired"`      // players the expiry sweep removed
+	StillQueued    int     `json:"still_queued"` // waiting when the run ended
+	MatchRate      float64 `json:"match_rate"`   // matched / (matched + expired)
+	ExpiryRate     float64 `json:"expiry_rate"`  // expired / (matched + expired)
+	AvgWaitSeconds float64 `json:"avg_wait_seconds"`
+	MaxWaitSeconds float64 `json:"max_wait_seconds"`
+}
+
+// SimReport is the result of a simulation, one entry per stake in ascending order
+type SimReport struct {
+	Params SimParams        `json:"params"`
+	Stakes []SimStakeResult `json:"stakes"`
+	Total  SimStakeResult   `json:"total"` // Stake is 0
+}
+
+// simEntry is a player waiting in the simulated queue
+type simEntry struct {
+	joinedAt  time.Duration
+	expiresAt time.Duration
+}
+
+// Validate checks the parameters and fills in defaults
+func (p *SimParams) Validate() error {
+	switch {
+	case p.ArrivalsPerMinute <= 0 || p.ArrivalsPerMinute > simMaxArrivalsPerMinute:
+		return errors.New("arrivals_per_minute must be above 0 and at most 1000")
+	case p.DurationMinutes <= 0 || p.DurationMinutes > simMaxDurationMinutes:
+		return errors.New("duration_minutes must be between 1 and 1440")
+	case p.ExpiryMinutes <= 0:
+		return errors.New("expiry_minutes must be positive")
+	case len(p.Stakes) == 0 || len(p.Stakes) > simMaxStakes:
+		return errors.New("between 1 and 20 stakes are required")
+	}
+	seen := map[int]bool{}
+	for _, s := range p.Stakes {
+		if s.Amount <= 0 || s.Weight <= 0 {
+			return errors.New("each stake needs a positive amount and weight")
+		}
+		if seen[s.Amount] {
+			return errors.New("stakes must be distinct")
+		}
+		seen[s.Amount] = true
+	}
+	if p.Seed == 0 {
+		p.Seed = time.Now().UnixNano()
+	}
+	return nil
+}
+
+// SimulateMatchmaking replays Poisson arrivals against an in-memory copy of the public matchmaking queue:
+// each stake is a join-ordered queue, an arrival is paired with the longest-waiting player at
+// its stake or waits, and the expiry sweep runs every queueExpiryCheckInterval removing entries
+// past their expiry, as TryMatchFromRedis and StartQueueExpiryChecker do. Ranked windows, tier
+// caps and private matches are not modelled. It touches no database or Redis.
+func SimulateMatchmaking(p SimParams) (*SimReport, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(p.Seed))
+	end := time.Duration(p.DurationMinutes) * time.Minute
+	expiry := time.Duration(p.ExpiryMinutes) * time.Minute
+
+	stakes := append([]SimStake(nil), p.Stakes...)
+	sort.Slice(stakes, func(i, j int) bool { return stakes[i].Amount < stakes[j].Amount })
+	totalWeight := 0.0
+	for _, s := range stakes {
+		totalWeight += s.Weight
+	}
+
+	results := make([]SimStakeResult, len(stakes))
+	queues := make([][]simEntry, len(stakes))
+	waited := make([]time.Duration, len(stakes))
+	for i, s := range stakes {
+		results[i].Stake = s.Amount
+	}
+	matched := func(i int, wait time.Duration) {
+		results[i].Matched++
+		waited[i] += wait
+		if s := wait.Seconds(); s > results[i].MaxWaitSeconds {
+			results[i].MaxWaitSeconds = s
+		}
+	}
+	sweep := func(now time.Duration) {
+		for i := range queues {
+			// Every entry gets the same expiry, so the expired ones are at the head
+			n := 0
+			for n < len(queues[i]) && queues[i][n].expiresAt < now {
+				n++
+			}
+			queues[i] = queues[i][n:]
+			results[i].Expired += n
+		}
+	}
+
+	perNs := p.ArrivalsPerMinute / float64(time.Minute)
+	nextSweep := queueExpiryCheckInterval
+	for now := time.Duration(rng.ExpFloat64() / perNs); now < end; now += time.Duration(rng.ExpFloat64() / perNs) {
+		for ; nextSweep <= now; nextSweep += queueExpiryCheckInterval {
+			sweep(nextSweep)
+		}
+
+		i, pick := 0, rng.Float64()*totalWeight
+		for i < len(stakes)-1 && pick >= stakes[i].Weight {
+			pick -= stakes[i].Weight
+			i++
+		}
+		results[i].Arrivals++
+
+		if q := queues[i]; len(q) > 0 {
+			matched(i, now-q[0].joinedAt)
+			matched(i, 0)
+			queues[i] = q[1:]
+			continue
+		}
+		queues[i] = append(queues[i], simEntry{joinedAt: now, expiresAt: now + expiry})
+	}
+	for ; nextSweep <= end; nextSweep += queueExpiryCheckInterval {
+		sweep(nextSweep)
+	}
+
+	report := &SimReport{Params: p, Stakes: results}
+	var totalWait time.Duration
+	for i := range results {
+		results[i].StillQueued = len(queues[i])
+		finishSimResult(&results[i], waited[i])
+		t := &report.Total
+		t.Arrivals += results[i].Arrivals
+		t.Matched += results[i].Matched
+		t.Expired += results[i].Expired
+		t.StillQueued += results[i].StillQueued
+		if results[i].MaxWaitSeconds > t.MaxWaitSeconds {
+			t.MaxWaitSeconds = results[i].MaxWaitSeconds
+		}
+		totalWait += waited[i]
+	}
+	finishSimResult(&report.Total, totalWait)
+	return report, nil
+}
+
+// finishSimResult fills in r's rates and average wait from its counts
+func finishSimResult(r *SimStakeResult, waited time.Duration) {
+	if done := r.Matched + r.Expired; done > 0 {
+		r.MatchRate = float64(r.Matched) / float64(done)
+		r.ExpiryRate = float64(r.Expired) / float64(done)
+	}
+	if r.Matched > 0 {
+		r.AvgWaitSeconds = waited.Seconds() / float64(r.Matched)
+	}
+}
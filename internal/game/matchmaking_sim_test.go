@@ -0,0 +1,64 @@
+package game
+
+import "testing"
+
+func TestSimulateMatchmakingPairedArrivalsNearlyAllMatch(t *testing.T) {
+	r, err := SimulateMatchmaking(SimParams{
+		ArrivalsPerMinute: 60,
+		Stakes:            []SimStake{{Amount: 1000, Weight: 1}, {Amount: 5000, Weight: 1}},
+		DurationMinutes:   120,
+		ExpiryMinutes:     3,
+		Seed:              42,
+	})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	for _, s := range r.Stakes {
+		if s.Arrivals == 0 || s.MatchRate < 0.99 {
+			t.Errorf("stake %d: %d arrivals, match rate %.3f, want nearly all matched", s.Stake, s.Arrivals, s.MatchRate)
+		}
+		if s.AvgWaitSeconds > 5 {
+			t.Errorf("stake %d: average wait %.1fs, want a few seconds at 30 arrivals a minute", s.Stake, s.AvgWaitSeconds)
+		}
+	}
+	if got := r.Total.Matched + r.Total.Expired + r.Total.StillQueued; got != r.Total.Arrivals {
+		t.Fatalf("matched+expired+queued = %d, want every one of %d arrivals accounted for", got, r.Total.Arrivals)
+	}
+}
+
+func TestSimulateMatchmakingLopsidedArrivalsExpire(t *testing.T) {
+	r, err := SimulateMatchmaking(SimParams{
+		ArrivalsPerMinute: 20,
+		// One arrival every ~10 minutes at the top tier, well past the 3 minute expiry
+		Stakes:          []SimStake{{Amount: 1000, Weight: 199}, {Amount: 100000, Weight: 1}},
+		DurationMinutes: 600,
+		ExpiryMinutes:   3,
+		Seed:            7,
+	})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	busy, rare := r.Stakes[0], r.Stakes[1]
+	if busy.MatchRate < 0.99 {
+		t.Errorf("busy stake match rate %.3f, want nearly all matched", busy.MatchRate)
+	}
+	if rare.Expired == 0 || rare.ExpiryRate < 0.5 {
+		t.Errorf("rare stake: %d expired (rate %.3f) of %d arrivals, want most to expire", rare.Expired, rare.ExpiryRate, rare.Arrivals)
+	}
+	// The sweep runs every minute, so nobody matched after waiting past expiry plus one sweep
+	if limit := float64(3*60 + 60); rare.MaxWaitSeconds > limit {
+		t.Errorf("max wait %.0fs, want at most %.0fs", rare.MaxWaitSeconds, limit)
+	}
+}
+
+func TestSimulateMatchmakingIsRepeatableForASeed(t *testing.T) {
+	p := SimParams{ArrivalsPerMinute: 5, Stakes: []SimStake{{Amount: 1000, Weight: 1}}, DurationMinutes: 60, ExpiryMinutes: 2, Seed: 3}
+	a, _ := SimulateMatchmaking(p)
+	b, _ := SimulateMatchmaking(p)
+	if a.Total != b.Total {
+		t.Fatalf("same seed gave %+v and %+v", a.Total, b.Total)
+	}
+	if _, err := SimulateMatchmaking(SimParams{ArrivalsPerMinute: 5, DurationMinutes: 60, ExpiryMinutes: 2}); err == nil {
+		t.Fatal("simulation without stakes accepted")
+	}
+}
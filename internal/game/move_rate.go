@@ -0,0 +1,64 @@
+package game
+
+import (
+	"log"
+	"time"
+)
+
+// moveRateWindow is the period MaxMovesPerMinute is counted over
+const moveRateWindow = time.Minute
+
+// moveWindow counts the moves a session recorded since start
+type moveWindow struct {
+	start     time.Time
+	count     int
+	throttled int // moves dropped in this window (logged on the first)
+}
+
+// allowMove reports whether sessionID may record another move at now. Past MaxMovesPerMinute
+// in the current minute moves are no longer written to game_moves, so a runaway client or
+// loop can't flood the table; the game itself carries on.
+func (gm *GameManager) allowMove(sessionID int, now time.Time) bool {
+	if gm.config == nil || gm.config.MaxMovesPerMinute <= 0 {
+		return true
+	}
+	gm.moveRateMu.Lock()
+	defer gm.moveRateMu.Unlock()
+
+	if gm.moveRate == nil {
+		gm.moveRate = make(map[int]*moveWindow)
+	}
+	w, ok := gm.moveRate[sessionID]
+	if !ok {
+		// Drop windows of sessions that stopped moving without finishing (e.g. cancelled)
+		for id, old := range gm.moveRate {
+			if now.Sub(old.start) >= moveRateWindow {
+				delete(gm.moveRate, id)
+			}
+		}
+		w = &moveWindow{start: now}
+		gm.moveRate[sessionID] = w
+	}
+	if now.Sub(w.start) >= moveRateWindow {
+		if w.throttled > 0 {
+			log.Printf("[DB] Session %d: %d moves over the limit were not recorded", sessionID, w.throttled)
+		}
+		*w = moveWindow{start: now}
+	}
+	if w.count >= gm.config.MaxMovesPerMinute {
+		if w.throttled == 0 {
+			log.Printf("[DB] Session %d exceeded %d moves/minute; not recording further moves this minute", sessionID, gm.config.MaxMovesPerMinute)
+		}
+		w.throttled++
+		return false
+	}
+	w.count++
+	return true
+}
+
+// forgetMoveRate drops sessionID's move count once the game is over
+func (gm *GameManager) forgetMoveRate(sessionID int) {
+	gm.moveRateMu.Lock()
+	delete(gm.moveRate, sessionID)
+	gm.moveRateMu.Unlock()
+}
@@ -0,0 +1,49 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestMoveRateAllowsHumanPace(t *testing.T) {
+	gm := NewGameManager(nil, nil, &config.Config{MaxMovesPerMinute: 60})
+	now := time.Now()
+
+	// A brisk game: a shot every two seconds for ten minutes
+	for i := 0; i < 300; i++ {
+		if !gm.allowMove(1, now.Add(time.Duration(i)*2*time.Second)) {
+			t.Fatalf("move %d at human pace was throttled", i)
+		}
+	}
+}
+
+func TestMoveRateThrottlesSpam(t *testing.T) {
+	gm := NewGameManager(nil, nil, &config.Config{MaxMovesPerMinute: 60})
+	now := time.Now()
+
+	allowed := 0
+	for i := 0; i < 500; i++ {
+		if gm.allowMove(1, now.Add(time.Duration(i)*time.Millisecond)) {
+			allowed++
+		}
+	}
+	if allowed != 60 {
+		t.Fatalf("%d of 500 moves in half a second recorded, want 60", allowed)
+	}
+	// Another session is unaffected, and the spamming one recovers next minute
+	if !gm.allowMove(2, now) {
+		t.Error("other session throttled")
+	}
+	if !gm.allowMove(1, now.Add(moveRateWindow)) {
+		t.Error("session still throttled a minute later")
+	}
+
+	gm.config.MaxMovesPerMinute = 0
+	for i := 0; i < 500; i++ {
+		if !gm.allowMove(3, now) {
+			t.Fatal("throttled with the limit off")
+		}
+	}
+}
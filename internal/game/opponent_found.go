@@ -0,0 +1,49 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/playpool/backend/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// QueueEventsChannel carries matchmaking events for players still on the waiting screen
+const QueueEventsChannel = "queue_events"
+
+// OpponentFoundPlayer is one side of an opponent_found event
+type OpponentFoundPlayer struct {
+	QueueToken  string `json:"queue_token"`
+	DisplayName string `json:"display_name"`
+}
+
+// OpponentFoundEvent is published as soon as two queue entries are matched, before the game
+// is set up and the SMS go out, so a watching waiting screen can switch at once
+type OpponentFoundEvent struct {
+	Type        string                 `json:"type"` // "opponent_found"
+	StakeAmount int                    `json:"stake_amount"`
+	Players     [2]OpponentFoundPlayer `json:"players"`
+}
+
+// publishQueueEvent sends ev to the queue watchers on every instance; tests swap it
+var publishQueueEvent = func(rdb *redis.Client, ev OpponentFoundEvent) {
+	if rdb == nil {
+		return
+	}
+	b, _ := json.Marshal(ev)
+	if err := rdb.Publish(context.Background(), QueueEventsChannel, b).Err(); err != nil {
+		log.Printf("[MATCH] Failed to publish opponent_found for %s/%s: %v", ev.Players[0].QueueToken, ev.Players[1].QueueToken, err)
+	}
+}
+
+// announceOpponentFound tells both matched players' queue watchers they have an opponent
+func announceOpponentFound(rdb *redis.Client, cfg *config.Config, stake int, a, b OpponentFoundPlayer) {
+	if cfg == nil || !cfg.OpponentFoundEvent {
+		return
+	}
+	if HideOpponentAtMatch(cfg) {
+		a.DisplayName, b.DisplayName = "", ""
+	}
+	publishQueueEvent(rdb, OpponentFoundEvent{Type: "opponent_found", StakeAmount: stake, Players: [2]OpponentFoundPlayer{a, b}})
+}
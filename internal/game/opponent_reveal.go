@@ -0,0 +1,35 @@
+package game
+
+import (
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+// opponentRevealDelay is how long after a match the players' names stay hidden (0 = not hidden)
+func opponentRevealDelay(cfg *config.Config) time.Duration {
+	if cfg == nil || cfg.OpponentRevealDelaySeconds <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.OpponentRevealDelaySeconds) * time.Second
+}
+
+// OpponentRevealed reports whether the players may see each other's names: always when
+// OpponentRevealDelaySeconds is off, otherwise once both have connected to the game or
+// the delay has passed since the match.
+func (g *PoolGameState) OpponentRevealed(cfg *config.Config, now time.Time) bool {
+	delay := opponentRevealDelay(cfg)
+	if delay == 0 {
+		return true
+	}
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return (g.Player1.ShowedUp && g.Player2.ShowedUp) || !now.Before(g.CreatedAt.Add(delay))
+}
+
+// HideOpponentAtMatch reports whether names are withheld from what goes out when the match is
+// made (stake response, opponent_found, match SMS): nobody has connected yet, so whenever the
+// delay is on
+func HideOpponentAtMatch(cfg *config.Config) bool {
+	return opponentRevealDelay(cfg) > 0
+}
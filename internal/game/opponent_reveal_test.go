@@ -0,0 +1,54 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestOpponentRevealedAfterBothConnect(t *testing.T) {
+	cfg := &config.Config{OpponentRevealDelaySeconds: 30}
+	g := NewPoolGame("g1", "tok", "p1", "256700000001", "t1", 1, "Alice", "p2", "256700000002", "t2", 2, "Bob", 1000)
+	now := g.CreatedAt.Add(5 * time.Second)
+
+	if g.OpponentRevealed(cfg, now) {
+		t.Fatal("revealed before anyone connected")
+	}
+	g.MarkPlayerShowedUp("p1")
+	if g.OpponentRevealed(cfg, now) {
+		t.Fatal("revealed with only one player connected")
+	}
+	g.MarkPlayerShowedUp("p2")
+	if !g.OpponentRevealed(cfg, now) {
+		t.Fatal("hidden after both players connected")
+	}
+
+	late := NewPoolGame("g2", "tok2", "p1", "256700000001", "t1", 1, "Alice", "p2", "256700000002", "t2", 2, "Bob", 1000)
+	if !late.OpponentRevealed(cfg, late.CreatedAt.Add(30*time.Second)) {
+		t.Error("hidden after the delay passed")
+	}
+	if !late.OpponentRevealed(&config.Config{}, late.CreatedAt) {
+		t.Error("hidden with the delay off")
+	}
+}
+
+func TestOpponentFoundWithholdsNames(t *testing.T) {
+	prev := publishQueueEvent
+	t.Cleanup(func() { publishQueueEvent = prev })
+	var got OpponentFoundEvent
+	publishQueueEvent = func(_ *redis.Client, ev OpponentFoundEvent) { got = ev }
+
+	a := OpponentFoundPlayer{QueueToken: "qa", DisplayName: "Alice"}
+	b := OpponentFoundPlayer{QueueToken: "qb", DisplayName: "Bob"}
+	announceOpponentFound(nil, &config.Config{OpponentFoundEvent: true, OpponentRevealDelaySeconds: 30}, 1000, a, b)
+	if got.Players[0].DisplayName != "" || got.Players[1].DisplayName != "" || got.Players[0].QueueToken != "qa" {
+		t.Fatalf("event = %+v, want names withheld", got)
+	}
+
+	announceOpponentFound(nil, &config.Config{OpponentFoundEvent: true}, 1000, a, b)
+	if got.Players[0].DisplayName != "Alice" || got.Players[1].DisplayName != "Bob" {
+		t.Fatalf("event = %+v, want names with the delay off", got)
+	}
+}
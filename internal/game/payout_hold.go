@@ -0,0 +1,174 @@
+package game
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/playpool/backend/internal/accounts"
+)
+
+// Payout hold states (payout_holds.status)
+const (
+	HoldHeld     = "HELD"     // winnings wait out the dispute window
+	HoldDisputed = "DISPUTED" // the loser flagged the result; an admin decides
+	HoldReleased = "RELEASED" // winner paid
+	HoldVoided   = "VOIDED"   // stakes refunded instead (dispute upheld or game cancelled)
+)
+
+// payoutHoldCheckInterval is how often held payouts past their window are released
+const payoutHoldCheckInterval = 30 * time.Second
+
+var (
+	// ErrNoPayoutHold is returned when a session has no hold the caller may act on
+	ErrNoPayoutHold = errors.New("no held payout for this game")
+	// ErrDisputeWindowClosed is returned when the loser disputes after the hold expired
+	ErrDisputeWindowClosed = errors.New("dispute window has closed")
+)
+
+// holdsPayout reports whether winnings at stake wait for a dispute window before payment
+func (gm *GameManager) holdsPayout(stake int) bool {
+	return gm.config.PayoutHoldMinStake > 0 && stake >= gm.config.PayoutHoldMinStake
+}
+
+// holdPayout records winnings for sessionID as held until the dispute window ends
+func (gm *GameManager) holdPayout(sessionID, winnerDBID, loserDBID, stake int) error {
+	_, err := gm.db.Exec(`INSERT INTO payout_holds (session_id, winner_id, loser_id, stake_amount, status, release_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, NOW() + make_interval(secs => $6), NOW()) ON CONFLICT (session_id) DO NOTHING`,
+		sessionID, winnerDBID, loserDBID, stake, HoldHeld, gm.config.PayoutHoldSeconds)
+	return err
+}
+
+// payWinner pays the pot (less tax) to the winner and updates their stats
+func (gm *GameManager) payWinner(sessionID, winnerDBID, stake int) error {
+	if err := gm.ProcessWinnerPayout(sessionID, winnerDBID, stake); err != nil {
+		return err
+	}
+	pot := float64(stake * 2)
+	taxRate := float64(gm.config.PayoutTaxPercent) / 100.0
+	winningsNet := pot - (pot * taxRate)
+
+	if _, err := gm.db.Exec(`UPDATE players SET total_games_won = total_games_won + 1, total_winnings = total_winnings + $1 WHERE id = $2`, winningsNet, winnerDBID); err != nil {
+		log.Printf("[DB] Failed to update winner stats for session %d: %v", sessionID, err)
+	}
+	return nil
+}
+
+// releasePayout moves sessionID's hold from status from to RELEASED and pays the winner.
+// If the payout fails the hold goes back to from so it can be retried.
+func (gm *GameManager) releasePayout(sessionID int, from, by string) error {
+	var h struct {
+		WinnerID    int     `db:"winner_id"`
+		StakeAmount float64 `db:"stake_amount"`
+	}
+	err := gm.db.Get(&h, `UPDATE payout_holds SET status=$3, resolved_at=NOW(), resolved_by=$4
+		WHERE session_id=$1 AND status=$2 RETURNING winner_id, stake_amount`, sessionID, from, HoldReleased, by)
+	if err == sql.ErrNoRows {
+		return ErrNoPayoutHold
+	}
+	if err != nil {
+		return err
+	}
+	if err := gm.payWinner(sessionID, h.WinnerID, int(h.StakeAmount)); err != nil {
+		if _, rerr := gm.db.Exec(`UPDATE payout_holds SET status=$2, resolved_at=NULL, resolved_by=NULL WHERE session_id=$1`, sessionID, from); rerr != nil {
+			log.Printf("[PAYOUT HOLD] Failed to restore hold for session %d after payout error: %v", sessionID, rerr)
+		}
+		return fmt.Errorf("pay winner: %w", err)
+	}
+	return nil
+}
+
+// ReleaseDuePayouts pays every undisputed hold whose window has ended and returns how many
+func (gm *GameManager) ReleaseDuePayouts() int {
+	if gm.db == nil {
+		return 0
+	}
+	var due []int
+	if err := gm.db.Select(&due, `SELECT session_id FROM payout_holds WHERE status=$1 AND release_at <= NOW() ORDER BY release_at`, HoldHeld); err != nil {
+		log.Printf("[PAYOUT HOLD] Failed to list due holds: %v", err)
+		return 0
+	}
+	released := 0
+	for _, sessionID := range due {
+		// A dispute filed since the select leaves the hold alone (ErrNoPayoutHold)
+		if err := gm.releasePayout(sessionID, HoldHeld, "auto"); err == nil {
+			released++
+			log.Printf("[PAYOUT HOLD] Released payout for session %d (no dispute)", sessionID)
+		} else if err != ErrNoPayoutHold {
+			log.Printf("[PAYOUT HOLD] Release failed for session %d: %v", sessionID, err)
+		}
+	}
+	return released
+}
+
+// StartPayoutHoldReleaser releases held payouts once their dispute window passes, until ctx is cancelled
+func (gm *GameManager) StartPayoutHoldReleaser(ctx context.Context) {
+	ticker := time.NewTicker(payoutHoldCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			gm.ReleaseDuePayouts()
+		}
+	}
+}
+
+// DisputePayout lets the loser of sessionID flag the result while its payout is still held.
+// The payout then waits for an admin (ResolveDispute) instead of auto-releasing.
+func (gm *GameManager) DisputePayout(sessionID, playerID int, reason string) error {
+	if gm.db == nil {
+		return errors.New("no database")
+	}
+	res, err := gm.db.Exec(`UPDATE payout_holds SET status=$4, dispute_reason=$3, disputed_at=NOW()
+		WHERE session_id=$1 AND loser_id=$2 AND status=$5 AND release_at > NOW()`, sessionID, playerID, reason, HoldDisputed, HoldHeld)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 1 {
+		log.Printf("[PAYOUT HOLD] Session %d disputed by player %d", sessionID, playerID)
+		return nil
+	}
+
+	var status string
+	if err := gm.db.Get(&status, `SELECT status FROM payout_holds WHERE session_id=$1 AND loser_id=$2`, sessionID, playerID); err != nil {
+		return ErrNoPayoutHold
+	}
+	if status == HoldDisputed {
+		return nil // already disputed
+	}
+	return ErrDisputeWindowClosed
+}
+
+// ResolveDispute settles a disputed payout for an admin: release pays the winner as normal,
+// otherwise the result is voided and both stakes are refunded.
+func (gm *GameManager) ResolveDispute(sessionID int, release bool, adminUsername, reason string) error {
+	if gm.db == nil {
+		return errors.New("no database")
+	}
+	if release {
+		return gm.releasePayout(sessionID, HoldDisputed, adminUsername)
+	}
+
+	var h struct {
+		WinnerID    int     `db:"winner_id"`
+		LoserID     int     `db:"loser_id"`
+		StakeAmount float64 `db:"stake_amount"`
+	}
+	if err := gm.db.Get(&h, `SELECT winner_id, loser_id, stake_amount FROM payout_holds WHERE session_id=$1 AND status=$2`, sessionID, HoldDisputed); err != nil {
+		return ErrNoPayoutHold
+	}
+	// refundSessionStakes voids the hold in the same transaction
+	if err := gm.refundSessionStakes(sessionID, h.WinnerID, h.LoserID, h.StakeAmount, accounts.RefundDisputeUpheld, "Dispute upheld: "+reason); err != nil {
+		return err
+	}
+	if _, err := gm.db.Exec(`UPDATE payout_holds SET resolved_by=$2 WHERE session_id=$1`, sessionID, adminUsername); err != nil {
+		log.Printf("[PAYOUT HOLD] Failed to record resolver for session %d: %v", sessionID, err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestHoldsPayoutOnlyAtHighStakes(t *testing.T) {
+	gm := NewGameManager(nil, nil, &config.Config{PayoutHoldMinStake: 100000})
+	if gm.holdsPayout(99999) || !gm.holdsPayout(100000) {
+		t.Error("hold threshold not applied at PayoutHoldMinStake")
+	}
+	if NewGameManager(nil, nil, &config.Config{}).holdsPayout(5000000) {
+		t.Error("payout held with holds turned off")
+	}
+}
+
+// heldGame finishes a persisted high-stake game won by player 1, leaving its payout held
+func heldGame(t *testing.T, key string) *PoolGameState {
+	t.Helper()
+	db := Manager.db
+	escrow, err := accounts.GetOrCreateAccount(db, accounts.AccountEscrow, nil)
+	if err != nil {
+		t.Fatalf("escrow account: %v", err)
+	}
+	if _, err := db.Exec(`UPDATE accounts SET balance = balance + 200000 WHERE id=$1`, escrow.ID); err != nil {
+		t.Fatalf("fund escrow: %v", err)
+	}
+
+	g := NewPoolGame(key, key+"-tok", "p1", "256700000001", "t1", 0, "One", "p2", "256700000002", "t2", 0, "Two", 100000)
+	for i, p := range []*PoolPlayer{g.Player1, g.Player2} {
+		phone := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+int64(i))%100000000)
+		if err := db.Get(&p.DBPlayerID, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+			t.Fatalf("insert player: %v", err)
+		}
+	}
+	if err := db.Get(&g.SessionID, `INSERT INTO game_sessions (game_token, player1_id, player2_id, stake_amount, status, created_at, expiry_time) VALUES ($1, $2, $3, $4, 'IN_PROGRESS', NOW(), NOW()) RETURNING id`,
+		fmt.Sprintf("%s_%d", key, time.Now().UnixNano()), g.Player1.DBPlayerID, g.Player2.DBPlayerID, g.StakeAmount); err != nil {
+		t.Fatalf("insert session: %v", err)
+	}
+
+	g.Status, g.Winner, g.WinType = StatusCompleted, g.Player1.ID, "8ball"
+	Manager.SaveFinalGameState(g)
+	if status := holdStatus(t, g); status != HoldHeld {
+		t.Fatalf("hold status %q after completion, want HELD", status)
+	}
+	if paid(t, g) {
+		t.Fatal("winner paid before the dispute window ended")
+	}
+	return g
+}
+
+func holdStatus(t *testing.T, g *PoolGameState) string {
+	var s string
+	Manager.db.Get(&s, `SELECT status FROM payout_holds WHERE session_id=$1`, g.SessionID)
+	return s
+}
+
+func paid(t *testing.T, g *PoolGameState) bool {
+	var n int
+	if err := Manager.db.Get(&n, `SELECT COUNT(*) FROM escrow_ledger WHERE session_id=$1 AND entry_type='PAYOUT'`, g.SessionID); err != nil {
+		t.Fatalf("read ledger: %v", err)
+	}
+	return n > 0
+}
+
+// endWindow makes g's dispute window end now
+func endWindow(t *testing.T, g *PoolGameState) {
+	if _, err := Manager.db.Exec(`UPDATE payout_holds SET release_at = NOW() - INTERVAL '1 second' WHERE session_id=$1`, g.SessionID); err != nil {
+		t.Fatalf("end window: %v", err)
+	}
+}
+
+func withPayoutHoldManager(t *testing.T) {
+	t.Helper()
+	db := testDB(t)
+	prev := Manager
+	Manager = NewGameManager(db, nil, &config.Config{PayoutHoldMinStake: 50000, PayoutHoldSeconds: 600, PayoutTaxPercent: 15, EloKFactor: 32})
+	t.Cleanup(func() { Manager = prev })
+}
+
+func TestHeldPayoutAutoReleasesWithoutDispute(t *testing.T) {
+	withPayoutHoldManager(t)
+	g := heldGame(t, "hold-auto")
+
+	Manager.ReleaseDuePayouts()
+	if paid(t, g) {
+		t.Fatal("payout released inside the dispute window")
+	}
+
+	endWindow(t, g)
+	Manager.ReleaseDuePayouts()
+	if !paid(t, g) || holdStatus(t, g) != HoldReleased {
+		t.Fatalf("after window: paid=%v status=%s, want paid and RELEASED", paid(t, g), holdStatus(t, g))
+	}
+	if err := Manager.DisputePayout(g.SessionID, g.Player2.DBPlayerID, "too late"); err != ErrDisputeWindowClosed {
+		t.Errorf("dispute after release: %v, want ErrDisputeWindowClosed", err)
+	}
+}
+
+func TestDisputedPayoutWaitsForAdmin(t *testing.T) {
+	withPayoutHoldManager(t)
+	g := heldGame(t, "hold-dispute")
+
+	if err := Manager.DisputePayout(g.SessionID, g.Player1.DBPlayerID, "I won"); err != ErrNoPayoutHold {
+		t.Fatalf("winner disputing: %v, want ErrNoPayoutHold", err)
+	}
+	if err := Manager.DisputePayout(g.SessionID, g.Player2.DBPlayerID, "opponent disconnected me"); err != nil {
+		t.Fatalf("loser disputing: %v", err)
+	}
+
+	endWindow(t, g)
+	Manager.ReleaseDuePayouts()
+	if paid(t, g) || holdStatus(t, g) != HoldDisputed {
+		t.Fatalf("disputed payout auto-released: status %s", holdStatus(t, g))
+	}
+
+	if err := Manager.ResolveDispute(g.SessionID, true, "admin1", "replay checked"); err != nil {
+		t.Fatalf("admin release: %v", err)
+	}
+	if !paid(t, g) || holdStatus(t, g) != HoldReleased {
+		t.Fatalf("after admin release: paid=%v status=%s", paid(t, g), holdStatus(t, g))
+	}
+}
+
+func TestUpheldDisputeRefundsBothStakes(t *testing.T) {
+	withPayoutHoldManager(t)
+	g := heldGame(t, "hold-void")
+	if err := Manager.DisputePayout(g.SessionID, g.Player2.DBPlayerID, "cheating"); err != nil {
+		t.Fatalf("dispute: %v", err)
+	}
+	if err := Manager.ResolveDispute(g.SessionID, false, "admin1", "confirmed"); err != nil {
+		t.Fatalf("admin void: %v", err)
+	}
+
+	var refunds int
+	Manager.db.Get(&refunds, `SELECT COUNT(*) FROM escrow_ledger WHERE session_id=$1 AND entry_type=$2 AND refund_reason=$3`, g.SessionID, accounts.LedgerRefund, accounts.RefundDisputeUpheld)
+	if refunds != 2 || paid(t, g) || holdStatus(t, g) != HoldVoided {
+		t.Fatalf("voided dispute: %d refunds, paid=%v, status=%s", refunds, paid(t, g), holdStatus(t, g))
+	}
+	if err := Manager.ResolveDispute(g.SessionID, true, "admin2", "changed my mind"); err != ErrNoPayoutHold {
+		t.Errorf("release after void: %v, want ErrNoPayoutHold", err)
+	}
+}
@@ -0,0 +1,92 @@
+package game
+
+import "time"
+
+// ActionLogEntry is one action taken on a game (accepted or rejected) and its outcome.
+type ActionLogEntry struct {
+	Seq      int                    `json:"seq"` // position in the game's action history, from 1
+	At       time.Time              `json:"at"`
+	Action   string                 `json:"action"` // take_shot, shot_result, place_cue_ball, turn_timeout, forfeit, concede
+	PlayerID string                 `json:"player_id,omitempty"`
+	MoveSeq  int                    `json:"move_seq"` // game move_seq after the action
+	Detail   map[string]interface{} `json:"detail,omitempty"`
+	Error    string                 `json:"error,omitempty"` // set when the action was rejected
+}
+
+// actionLog keeps the last len(entries) actions of a game in a ring buffer.
+type actionLog struct {
+	entries []ActionLogEntry
+	total   int
+}
+
+// actionLogSize is how many actions each game keeps for debugging (0 = logging off).
+func actionLogSize() int {
+	if Manager != nil && Manager.config != nil {
+		return Manager.config.GameActionLogSize
+	}
+	return 0
+}
+
+// recordActionLocked appends an action to the game's debug log when it is enabled.
+// The log lives only in memory: it is not persisted or sent to clients. Caller must hold g.mu.
+func (g *PoolGameState) recordActionLocked(action, playerID string, detail map[string]interface{}, err error) {
+	if g.actions == nil {
+		size := actionLogSize()
+		if size <= 0 {
+			return
+		}
+		g.actions = &actionLog{entries: make([]ActionLogEntry, size)}
+	}
+
+	l := g.actions
+	l.total++
+	entry := ActionLogEntry{
+		Seq:      l.total,
+		At:       time.Now(),
+		Action:   action,
+		PlayerID: playerID,
+		MoveSeq:  g.MoveSeq,
+		Detail:   detail,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	l.entries[(l.total-1)%len(l.entries)] = entry
+}
+
+// ActionLog returns the recorded actions, oldest first (nil when logging is off).
+func (g *PoolGameState) ActionLog() []ActionLogEntry {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	l := g.actions
+	if l == nil {
+		return nil
+	}
+	n := len(l.entries)
+	if l.total < n {
+		return append([]ActionLogEntry(nil), l.entries[:l.total]...)
+	}
+	start := l.total % n
+	out := make([]ActionLogEntry, 0, n)
+	out = append(out, l.entries[start:]...)
+	return append(out, l.entries[:start]...)
+}
+
+// shotResultDetail summarises a shot outcome for the action log.
+func shotResultDetail(r *ShotResult) map[string]interface{} {
+	detail := map[string]interface{}{
+		"pocketed":     r.PocketedBalls,
+		"turn_change":  r.TurnChange,
+		"next_turn":    r.NextTurn,
+		"ball_in_hand": r.BallInHand,
+	}
+	if r.Foul != nil {
+		detail["foul"] = r.Foul.Type
+	}
+	if r.GameOver {
+		detail["winner"] = r.Winner
+		detail["win_type"] = r.WinType
+	}
+	return detail
+}
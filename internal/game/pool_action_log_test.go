@@ -0,0 +1,80 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+func withActionLog(t *testing.T, size int) {
+	t.Helper()
+	prev := Manager
+	Manager = NewGameManager(nil, nil, &config.Config{GameActionLogSize: size})
+	t.Cleanup(func() { Manager = prev })
+}
+
+func TestActionLogRecordsShotsInOrder(t *testing.T) {
+	withActionLog(t, 10)
+	g := newTestPoolGame(t)
+	shooter := g.CurrentTurn
+	other := g.GetOpponentID(shooter)
+
+	if err := g.BeginShot(other, ShotParams{Angle: 1, Power: 100}, nil); err == nil {
+		t.Fatal("out-of-turn shot should be rejected")
+	}
+	if err := g.BeginShot(shooter, ShotParams{Angle: 1, Power: 100}, nil); err != nil {
+		t.Fatalf("BeginShot: %v", err)
+	}
+	if _, err := g.ApplyShotResult(shooter, shotData(g, 1, true)); err != nil {
+		t.Fatalf("ApplyShotResult: %v", err)
+	}
+
+	log := g.ActionLog()
+	want := []struct {
+		action, player string
+		rejected       bool
+	}{
+		{"take_shot", other, true},
+		{"take_shot", shooter, false},
+		{"shot_result", shooter, false},
+	}
+	if len(log) != len(want) {
+		t.Fatalf("got %d actions, want %d: %+v", len(log), len(want), log)
+	}
+	for i, w := range want {
+		e := log[i]
+		if e.Seq != i+1 || e.Action != w.action || e.PlayerID != w.player || (e.Error != "") != w.rejected {
+			t.Errorf("action %d = %+v, want %+v", i, e, w)
+		}
+	}
+	if log[2].Detail["next_turn"] == nil || log[2].MoveSeq != g.MoveSeq {
+		t.Errorf("shot_result missing outcome: %+v", log[2])
+	}
+}
+
+func TestActionLogKeepsLastN(t *testing.T) {
+	withActionLog(t, 3)
+	g := newTestPoolGame(t)
+	for i := 0; i < 5; i++ {
+		g.PlaceCueBall(g.CurrentTurn, 0, 0) // rejected: not ball-in-hand
+	}
+
+	log := g.ActionLog()
+	if len(log) != 3 {
+		t.Fatalf("got %d actions, want 3", len(log))
+	}
+	for i, e := range log {
+		if e.Seq != i+3 || e.Action != "place_cue_ball" || e.Error == "" {
+			t.Errorf("action %d = %+v, want seq %d rejected place_cue_ball", i, e, i+3)
+		}
+	}
+}
+
+func TestActionLogOffByDefault(t *testing.T) {
+	withActionLog(t, 0)
+	g := newTestPoolGame(t)
+	g.PlaceCueBall(g.CurrentTurn, 0, 0)
+	if log := g.ActionLog(); log != nil {
+		t.Errorf("logging disabled but recorded %+v", log)
+	}
+}
@@ -3,6 +3,8 @@ package game
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
@@ -14,6 +16,9 @@ func (gm *GameManager) RecordPoolShot(sessionID int, playerID int, params ShotPa
 	if gm == nil || gm.db == nil || sessionID == 0 || playerID == 0 {
 		return
 	}
+	if !gm.allowMove(sessionID, time.Now()) {
+		return
+	}
 
 	shotData, err := json.Marshal(params)
 	if err != nil {
@@ -46,30 +51,7 @@ func (gm *GameManager) savePoolGameToRedis(g *PoolGameState) error {
 	ctx := context.Background()
 	key := "game:" + g.Token + ":state"
 
-	gameData := map[string]interface{}{
-		"id":                  g.ID,
-		"token":               g.Token,
-		"player1":             g.Player1,
-		"player2":             g.Player2,
-		"balls":               g.Balls,
-		"current_turn":        g.CurrentTurn,
-		"status":              g.Status,
-		"winner":              g.Winner,
-		"win_type":            g.WinType,
-		"stake_amount":        g.StakeAmount,
-		"shot_number":         g.ShotNumber,
-		"is_break_shot":       g.IsBreakShot,
-		"ball_in_hand":        g.BallInHand,
-		"ball_in_hand_player": g.BallInHandPlayer,
-		"created_at":          g.CreatedAt,
-		"started_at":          g.StartedAt,
-		"completed_at":        g.CompletedAt,
-		"last_activity":       g.LastActivity,
-		"session_id":          g.SessionID,
-		"game_type":           "pool",
-	}
-
-	data, err := json.Marshal(gameData)
+	data, err := encodePoolGame(g)
 	if err != nil {
 		return err
 	}
@@ -77,6 +59,220 @@ func (gm *GameManager) savePoolGameToRedis(g *PoolGameState) error {
 	return gm.rdb.SetEx(ctx, key, data, time.Hour).Err()
 }
 
+// poolGameSchemaVersion is the layout encodePoolGame writes. Bump it whenever a change to
+// poolGameRecord needs old states converting, and add the step to migratePoolGameRecord.
+//
+//	1: states written before schema_version existed (it reads as 0)
+//	2: schema_version added; same fields as 1
+const poolGameSchemaVersion = 2
+
+// encodePoolGame serializes the fields needed to resume a pool game after a restart.
+func encodePoolGame(g *PoolGameState) ([]byte, error) {
+	return json.Marshal(poolGameRecord{
+		SchemaVersion:    poolGameSchemaVersion,
+		ID:               g.ID,
+		Token:            g.Token,
+		Player1:          g.Player1,
+		Player2:          g.Player2,
+		Player1Token:     g.Player1.PlayerToken, // not in PoolPlayer JSON; needed to rejoin
+		Player2Token:     g.Player2.PlayerToken,
+		Balls:            g.Balls,
+		CurrentTurn:      g.CurrentTurn,
+		Status:           g.Status,
+		Winner:           g.Winner,
+		WinType:          g.WinType,
+		StakeAmount:      g.StakeAmount,
+		ShotNumber:       g.ShotNumber,
+		IsBreakShot:      g.IsBreakShot,
+		BallInHand:       g.BallInHand,
+		BallInHandPlayer: g.BallInHandPlayer,
+		ExpiresAt:        g.ExpiresAt,
+		CreatedAt:        g.CreatedAt,
+		StartedAt:        g.StartedAt,
+		CompletedAt:      g.CompletedAt,
+		LastActivity:     g.LastActivity,
+		SessionID:        g.SessionID,
+		MaxShots:         g.MaxShots,
+		MoveSeq:          g.MoveSeq,
+		TurnDeadline:     g.TurnDeadline,
+		TurnPausedMs:     g.TurnClockPaused.Milliseconds(),
+		GameType:         "pool",
+	})
+}
+
+// poolGameRecord is the saved Redis state of a pool game. Keys must stay readable by the
+// previous release during a rolling deploy: add fields, don't rename them.
+type poolGameRecord struct {
+	SchemaVersion    int                 `json:"schema_version"`
+	ID               string              `json:"id"`
+	Token            string              `json:"token"`
+	Player1          *PoolPlayer         `json:"player1"`
+	Player2          *PoolPlayer         `json:"player2"`
+	Player1Token     string              `json:"player1_token"`
+	Player2Token     string              `json:"player2_token"`
+	Balls            [NumBalls]BallState `json:"balls"`
+	CurrentTurn      string              `json:"current_turn"`
+	Status           GameStatus          `json:"status"`
+	Winner           string              `json:"winner"`
+	WinType          string              `json:"win_type"`
+	StakeAmount      int                 `json:"stake_amount"`
+	ShotNumber       int                 `json:"shot_number"`
+	IsBreakShot      bool                `json:"is_break_shot"`
+	BallInHand       bool                `json:"ball_in_hand"`
+	BallInHandPlayer string              `json:"ball_in_hand_player"`
+	ExpiresAt        time.Time           `json:"expires_at"`
+	CreatedAt        time.Time           `json:"created_at"`
+	StartedAt        *time.Time          `json:"started_at"`
+	CompletedAt      *time.Time          `json:"completed_at"`
+	LastActivity     time.Time           `json:"last_activity"` // informational; resumed games start idle timing afresh
+	SessionID        int                 `json:"session_id"`
+	MaxShots         int                 `json:"max_shots"`
+	MoveSeq          int                 `json:"move_seq"`
+	TurnDeadline     *time.Time          `json:"turn_deadline"`
+	TurnPausedMs     int64               `json:"turn_paused_ms"`
+	GameType         string              `json:"game_type"`
+}
+
+// migratePoolGameRecord brings a record saved by an older release up to the current schema.
+// States from a newer release are refused rather than resumed with fields silently dropped.
+func migratePoolGameRecord(rec *poolGameRecord) error {
+	if rec.SchemaVersion == 0 {
+		rec.SchemaVersion = 1
+	}
+	if rec.SchemaVersion > poolGameSchemaVersion {
+		return fmt.Errorf("game state schema_version %d is newer than supported %d", rec.SchemaVersion, poolGameSchemaVersion)
+	}
+	for rec.SchemaVersion < poolGameSchemaVersion {
+		switch rec.SchemaVersion {
+		case 1:
+			// Unversioned states can predate ball groups being saved
+			for _, p := range []*PoolPlayer{rec.Player1, rec.Player2} {
+				if p != nil && p.BallGroup == "" {
+					p.BallGroup = GroupAny
+				}
+			}
+		}
+		rec.SchemaVersion++
+	}
+	return nil
+}
+
+// loadPoolGameFromRedis rebuilds a pool game from its saved Redis state.
+// Ball positions and any pending ball-in-hand are restored exactly; connection
+// state is reset because no client is attached to the new process yet.
+func loadPoolGameFromRedis(data []byte) (*PoolGameState, error) {
+	var rec poolGameRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, err
+	}
+	if rec.GameType != "pool" {
+		return nil, fmt.Errorf("not a pool game (game_type=%q)", rec.GameType)
+	}
+	if err := migratePoolGameRecord(&rec); err != nil {
+		return nil, err
+	}
+	if rec.ID == "" || rec.Player1 == nil || rec.Player2 == nil {
+		return nil, errors.New("incomplete game state")
+	}
+	if !rec.Status.Valid() {
+		// A corrupted status would slip past every status check; don't resume such a game
+		return nil, fmt.Errorf("%w %q", ErrUnknownGameStatus, rec.Status)
+	}
+
+	rec.Player1.PlayerToken = rec.Player1Token
+	rec.Player2.PlayerToken = rec.Player2Token
+	for _, p := range []*PoolPlayer{rec.Player1, rec.Player2} {
+		p.Connected = false
+		p.DisconnectedAt = nil
+	}
+
+	g := &PoolGameState{
+		ID:               rec.ID,
+		Token:            rec.Token,
+		Player1:          rec.Player1,
+		Player2:          rec.Player2,
+		Balls:            rec.Balls,
+		CurrentTurn:      rec.CurrentTurn,
+		Status:           rec.Status,
+		Winner:           rec.Winner,
+		WinType:          rec.WinType,
+		StakeAmount:      rec.StakeAmount,
+		ShotNumber:       rec.ShotNumber,
+		IsBreakShot:      rec.IsBreakShot,
+		BallInHand:       rec.BallInHand,
+		BallInHandPlayer: rec.BallInHandPlayer,
+		ExpiresAt:        rec.ExpiresAt,
+		CreatedAt:        rec.CreatedAt,
+		StartedAt:        rec.StartedAt,
+		CompletedAt:      rec.CompletedAt,
+		LastActivity:     time.Now(),
+		SessionID:        rec.SessionID,
+		MaxShots:         rec.MaxShots,
+		MoveSeq:          rec.MoveSeq,
+	}
+
+	// A foul hands the cue ball to the incoming player; never resume with it owned by anyone else
+	if g.BallInHand && g.BallInHandPlayer == "" {
+		g.BallInHandPlayer = g.CurrentTurn
+	}
+
+	// Nobody is connected yet, so the shot clock comes back paused with the time that was left
+	g.TurnClockPaused = time.Duration(rec.TurnPausedMs) * time.Millisecond
+	if rec.TurnDeadline != nil {
+		g.TurnDeadline = rec.TurnDeadline
+		g.pauseTurnClockLocked(time.Now())
+		if rec.TurnDeadline.Before(time.Now()) {
+			// The clock ran out while the server was down; don't punish the player for it
+			g.startTurnClockLocked(time.Now())
+		}
+	}
+
+	return g, nil
+}
+
+// loadPoolGameByToken resumes a game that is no longer in memory from its Redis state.
+// Only pool records are accepted, and completed games are not brought back.
+func (gm *GameManager) loadPoolGameByToken(token string) (*PoolGameState, error) {
+	if gm.rdb == nil {
+		return nil, errors.New("redis not configured")
+	}
+
+	data, err := gm.rdb.Get(context.Background(), "game:"+token+":state").Bytes()
+	if err != nil {
+		return nil, err
+	}
+	g, err := loadPoolGameFromRedis(data)
+	if err != nil {
+		return nil, err
+	}
+	if g.Status == StatusCompleted || g.Status == StatusCancelled {
+		return nil, fmt.Errorf("game already finished (%s)", g.Status)
+	}
+
+	g = gm.adoptPoolGame(g)
+	log.Printf("[POOL] Game %s reloaded from Redis (status=%s, shot #%d)", g.ID, g.Status, g.ShotNumber)
+	return g, nil
+}
+
+// adoptPoolGame registers a game reloaded from Redis and returns the in-memory instance,
+// which is an already-registered copy if another caller got there first.
+func (gm *GameManager) adoptPoolGame(g *PoolGameState) *PoolGameState {
+	if g.ExpiresAt.IsZero() && gm.config != nil {
+		// Older records did not carry expiry; give waiting players a fresh window
+		g.ExpiresAt = time.Now().Add(time.Duration(gm.config.GameExpiryMinutes) * time.Minute)
+	}
+
+	gm.mu.Lock()
+	defer gm.mu.Unlock()
+	if existing, exists := gm.games[g.ID]; exists {
+		return existing
+	}
+	gm.games[g.ID] = g
+	gm.playerToGame[g.Player1.ID] = g.ID
+	gm.playerToGame[g.Player2.ID] = g.ID
+	return g
+}
+
 // CreatePoolGameFromMatch creates a pool game from a matchmaking result.
 func (gm *GameManager) CreatePoolGameFromMatch(player1, player2 QueuedPlayer, gameToken string, stake float64, cfg *config.Config) {
 	gm.mu.Lock()
@@ -100,8 +296,10 @@ func (gm *GameManager) CreatePoolGameFromMatch(player1, player2 QueuedPlayer, ga
 	log.Printf("[MATCHMAKER] Pool game created: %s (token=%s)", gameID, gameToken)
 }
 
-// CreateTestPoolGame creates a test pool game for development.
-func (gm *GameManager) CreateTestPoolGame(player1Phone, player2Phone string, stakeAmount int) (*PoolGameState, error) {
+// CreateTestPoolGame creates a racked, in-progress pool game for QA, bypassing matchmaking.
+// With nearWin, player 1 has already cleared solids and is on the 8-ball, so a single shot
+// exercises the pocket_8 and scratch_on_8 endings.
+func (gm *GameManager) CreateTestPoolGame(player1Phone, player2Phone string, stakeAmount int, nearWin bool) (*PoolGameState, error) {
 	gm.mu.Lock()
 	defer gm.mu.Unlock()
 
@@ -119,10 +317,26 @@ func (gm *GameManager) CreateTestPoolGame(player1Phone, player2Phone string, sta
 		stakeAmount,
 	)
 
+	// Rack via Standard8BallRack and start immediately; players joining later get the live state
+	if err := g.Initialize(); err != nil {
+		return nil, err
+	}
+
+	if nearWin {
+		g.IsBreakShot = false
+		g.Player1.BallGroup = GroupSolids
+		g.Player2.BallGroup = GroupStripes
+		for id := 1; id <= 7; id++ {
+			g.Balls[id].Active = false
+		}
+		g.updateBallGroupStatus(g.Player1)
+		g.CurrentTurn = g.Player1.ID
+	}
+
 	gm.games[gameID] = g
 	gm.playerToGame[p1ID] = gameID
 	gm.playerToGame[p2ID] = gameID
 
+	log.Printf("[TEST] Pool game created: %s (token=%s, near_win=%v)", gameID, gameToken, nearWin)
 	return g, nil
 }
-
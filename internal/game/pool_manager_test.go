@@ -0,0 +1,396 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// testRedis connects to TEST_REDIS_URL; tests that need Redis are skipped when it is unset
+func testRedis(t *testing.T) *redis.Client {
+	url := os.Getenv("TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TEST_REDIS_URL not set")
+	}
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("invalid TEST_REDIS_URL: %v", err)
+	}
+	rdb := redis.NewClient(opt)
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("redis unavailable: %v", err)
+	}
+	return rdb
+}
+
+// midGamePoolState returns a game with groups assigned, balls down and a session attached
+func midGamePoolState(t *testing.T) *PoolGameState {
+	t.Helper()
+	g := newTestPoolGame(t)
+	g.SessionID = 77
+	g.MaxShots = 40
+	g.SetShotInProgress("p1", ShotParams{Power: 1000})
+	if _, err := g.ApplyShotResult("p1", shotData(g, 2, true, 2)); err != nil {
+		t.Fatalf("ApplyShotResult: %v", err)
+	}
+	if g.Player1.BallGroup != GroupSolids || g.CurrentTurn != "p1" {
+		t.Fatalf("setup: group=%s turn=%s", g.Player1.BallGroup, g.CurrentTurn)
+	}
+	g.Player1.Connected, g.Player2.Connected = false, false
+	return g
+}
+
+func TestRestartResumesBallInHand(t *testing.T) {
+	g := newTestPoolGame(t)
+	g.SetShotInProgress("p1", ShotParams{Power: 1000})
+
+	// p1 scratches: p2 gets ball-in-hand
+	if _, err := g.ApplyShotResult("p1", shotData(g, 1, true, 0, 3)); err != nil {
+		t.Fatalf("ApplyShotResult: %v", err)
+	}
+	if !g.BallInHand || g.BallInHandPlayer != "p2" {
+		t.Fatalf("expected ball-in-hand for p2, got %v/%q", g.BallInHand, g.BallInHandPlayer)
+	}
+
+	data, err := encodePoolGame(g)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	restored, err := loadPoolGameFromRedis(data)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if !restored.BallInHand || restored.BallInHandPlayer != "p2" || restored.CurrentTurn != "p2" {
+		t.Fatalf("ball-in-hand lost: %v/%q turn=%q", restored.BallInHand, restored.BallInHandPlayer, restored.CurrentTurn)
+	}
+	if restored.Status != StatusInProgress || restored.ShotNumber != g.ShotNumber {
+		t.Fatalf("status/shot mismatch: %s #%d", restored.Status, restored.ShotNumber)
+	}
+	if restored.Balls != g.Balls {
+		t.Fatal("ball positions differ after reload")
+	}
+	if restored.Balls[3].Active {
+		t.Fatal("pocketed ball 3 came back to the table")
+	}
+	if restored.Player1.Connected || restored.Player2.Connected {
+		t.Fatal("connection state should reset after restart")
+	}
+
+	if err := restored.PlaceCueBall("p1", 0, 0); err == nil {
+		t.Fatal("p1 should not be able to place the cue ball")
+	}
+	if err := restored.PlaceCueBall("p2", -30000, 0); err != nil {
+		t.Fatalf("PlaceCueBall after restart: %v", err)
+	}
+	if restored.BallInHand || !restored.Balls[0].Active {
+		t.Fatal("cue ball placement did not clear ball-in-hand")
+	}
+	if err := restored.ValidateCanShoot("p2", ShotParams{Power: 1000}); err != nil {
+		t.Fatalf("p2 cannot shoot after placement: %v", err)
+	}
+}
+
+func TestLoadPoolGameRejectsOtherGameTypes(t *testing.T) {
+	if _, err := loadPoolGameFromRedis([]byte(`{"id":"g","game_type":"matatu"}`)); err == nil {
+		t.Fatal("expected error for non-pool state")
+	}
+}
+
+func TestLoadPoolGameRejectsUnknownStatus(t *testing.T) {
+	g := NewPoolGame("g1", "tok1", "p1", "256700000001", "t1", 0, "One", "p2", "256700000002", "t2", 0, "Two", 1000)
+	g.Status = "PAUSED"
+	data, err := encodePoolGame(g)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if restored, err := loadPoolGameFromRedis(data); !errors.Is(err, ErrUnknownGameStatus) || restored != nil {
+		t.Fatalf("load with status PAUSED: game %v err %v, want ErrUnknownGameStatus", restored, err)
+	}
+
+	g.Status = StatusInProgress
+	data, _ = encodePoolGame(g)
+	if _, err := loadPoolGameFromRedis(data); err != nil {
+		t.Fatalf("load with known status: %v", err)
+	}
+}
+
+func TestCreateTestPoolGamePlayable(t *testing.T) {
+	gm := NewGameManager(nil, nil, &config.Config{})
+	g, err := gm.CreateTestPoolGame("+256700111111", "+256700222222", 1000, false)
+	if err != nil {
+		t.Fatalf("CreateTestPoolGame: %v", err)
+	}
+	if g.Player1.PlayerToken == "" || g.Player2.PlayerToken == "" {
+		t.Fatal("player tokens missing")
+	}
+	if got, err := gm.GetGameByToken(g.Token); err != nil || got != g {
+		t.Fatalf("game not registered by token: %v", err)
+	}
+
+	for _, p := range []*PoolPlayer{g.Player1, g.Player2} {
+		g.SetPlayerConnected(p.ID, true)
+		g.MarkPlayerShowedUp(p.ID)
+	}
+	if !g.BothPlayersConnected() || g.Status != StatusInProgress || !g.IsBreakShot {
+		t.Fatalf("expected racked game ready to break, status=%s", g.Status)
+	}
+
+	shooter := g.CurrentTurn
+	params := ShotParams{Power: 3000}
+	if err := g.ValidateCanShoot(shooter, params); err != nil {
+		t.Fatalf("ValidateCanShoot: %v", err)
+	}
+	g.SetShotInProgress(shooter, params)
+	if _, err := g.ApplyShotResult(shooter, shotData(g, 1, true, 9)); err != nil {
+		t.Fatalf("ApplyShotResult: %v", err)
+	}
+	if g.ShotNumber != 1 {
+		t.Fatalf("shot not recorded, shot number %d", g.ShotNumber)
+	}
+}
+
+func TestCreateTestPoolGameNearWin(t *testing.T) {
+	tests := []struct {
+		name       string
+		pocketed   []int
+		wantType   string
+		wantWinner func(g *PoolGameState) string
+	}{
+		{"pocket 8", []int{8}, "pocket_8", func(g *PoolGameState) string { return g.Player1.ID }},
+		{"scratch on 8", []int{0}, "scratch_on_8", func(g *PoolGameState) string { return g.Player2.ID }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gm := NewGameManager(nil, nil, &config.Config{})
+			g, err := gm.CreateTestPoolGame("+256700111111", "+256700222222", 1000, true)
+			if err != nil {
+				t.Fatalf("CreateTestPoolGame: %v", err)
+			}
+			if g.Player1.BallGroup != Group8Ball || g.CurrentTurn != g.Player1.ID {
+				t.Fatalf("player 1 should be on the 8-ball, group=%s turn=%s", g.Player1.BallGroup, g.CurrentTurn)
+			}
+
+			g.SetShotInProgress(g.Player1.ID, ShotParams{Power: 1000})
+			result, err := g.ApplyShotResult(g.Player1.ID, shotData(g, 8, true, tt.pocketed...))
+			if err != nil {
+				t.Fatalf("ApplyShotResult: %v", err)
+			}
+			if !result.GameOver || result.WinType != tt.wantType || result.Winner != tt.wantWinner(g) {
+				t.Fatalf("got over=%v type=%s winner=%s", result.GameOver, result.WinType, result.Winner)
+			}
+		})
+	}
+}
+
+func TestPoolGameRoundTripMidGame(t *testing.T) {
+	g := midGamePoolState(t)
+
+	data, err := encodePoolGame(g)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	restored, err := loadPoolGameFromRedis(data)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if restored.Balls != g.Balls {
+		t.Error("balls differ after reload")
+	}
+	if !reflect.DeepEqual(restored.Player1, g.Player1) || !reflect.DeepEqual(restored.Player2, g.Player2) {
+		t.Errorf("players differ: %+v / %+v", restored.Player1, restored.Player2)
+	}
+	if restored.CurrentTurn != g.CurrentTurn || restored.BallInHand != g.BallInHand || restored.IsBreakShot != g.IsBreakShot {
+		t.Errorf("turn state differs: turn=%s bih=%v break=%v", restored.CurrentTurn, restored.BallInHand, restored.IsBreakShot)
+	}
+	if restored.ShotNumber != g.ShotNumber || restored.SessionID != 77 || restored.MaxShots != 40 ||
+		restored.StakeAmount != g.StakeAmount || restored.Status != g.Status {
+		t.Errorf("session fields differ: %+v", restored)
+	}
+	if !restored.ExpiresAt.Equal(g.ExpiresAt) || !restored.StartedAt.Equal(*g.StartedAt) {
+		t.Error("timestamps differ after reload")
+	}
+}
+
+func TestPoolGameReloadRestoresEverySavedField(t *testing.T) {
+	g := midGamePoolState(t)
+	// A finished game sets the fields a mid-game one leaves empty
+	done := g.StartedAt.Add(5 * time.Minute)
+	g.Status, g.Winner, g.WinType, g.CompletedAt = StatusCompleted, "p1", "8ball_pot", &done
+
+	first, err := encodePoolGame(g)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	restored, err := loadPoolGameFromRedis(first)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	second, err := encodePoolGame(restored)
+	if err != nil {
+		t.Fatalf("re-encode: %v", err)
+	}
+
+	// Saving the reloaded game must write back exactly what was read; a field that is
+	// saved but not restored shows up here. last_activity restarts on purpose.
+	var before, after map[string]interface{}
+	json.Unmarshal(first, &before)
+	json.Unmarshal(second, &after)
+	delete(before, "last_activity")
+	delete(after, "last_activity")
+	for key, want := range before {
+		if !reflect.DeepEqual(after[key], want) {
+			t.Errorf("%s = %v after reload, saved %v", key, after[key], want)
+		}
+	}
+	if restored.Player1.PlayerToken == "" || restored.WinType != "8ball_pot" || restored.SessionID != 77 {
+		t.Errorf("restored %+v", restored)
+	}
+}
+
+func TestGetGameByTokenReloadsFromRedis(t *testing.T) {
+	rdb := testRedis(t)
+	gm := NewGameManager(nil, rdb, &config.Config{GameExpiryMinutes: 3})
+
+	g := midGamePoolState(t)
+	g.Token = "reload-" + generateToken(4)
+	if err := gm.savePoolGameToRedis(g); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	defer rdb.Del(context.Background(), "game:"+g.Token+":state")
+
+	got, err := gm.GetGameByToken(g.Token)
+	if err != nil {
+		t.Fatalf("GetGameByToken: %v", err)
+	}
+	if got.Balls != g.Balls || got.Player1.BallGroup != GroupSolids || got.CurrentTurn != g.CurrentTurn {
+		t.Fatal("reloaded game does not match the saved state")
+	}
+	// Registered again: the next lookup is served from memory
+	again, err := gm.GetGameByToken(g.Token)
+	if err != nil || again != got {
+		t.Fatalf("second lookup returned a different instance: %v", err)
+	}
+	if id, err := gm.GetGameForPlayer("p2"); err != nil || id.ID != g.ID {
+		t.Errorf("player mapping not restored: %v", err)
+	}
+
+	// Completed games are not resumed
+	g.Token = "done-" + generateToken(4)
+	g.Status = StatusCompleted
+	gm.savePoolGameToRedis(g)
+	defer rdb.Del(context.Background(), "game:"+g.Token+":state")
+	if _, err := gm.GetGameByToken(g.Token); err == nil {
+		t.Error("completed game should not be reloaded")
+	}
+}
+
+func TestCancelledPoolGameIsNotAdopted(t *testing.T) {
+	rdb := testRedis(t)
+	gm := NewGameManager(nil, rdb, &config.Config{GameExpiryMinutes: 3})
+
+	g := midGamePoolState(t)
+	g.ID, g.Token = "cancelled-"+generateToken(4), "cancelled-"+generateToken(4)
+	g.Status = StatusCancelled
+	if err := gm.savePoolGameToRedis(g); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	defer rdb.Del(context.Background(), "game:"+g.Token+":state")
+
+	if _, err := gm.GetGameByToken(g.Token); err == nil {
+		t.Error("cancelled game should not be reloaded")
+	}
+	if err := gm.RecoverGamesFromRedis(); err != nil {
+		t.Fatalf("RecoverGamesFromRedis: %v", err)
+	}
+	if _, ok := gm.games[g.ID]; ok {
+		t.Error("cancelled game was recovered")
+	}
+}
+
+func TestPersistAllGamesSkipsFinished(t *testing.T) {
+	rdb := testRedis(t)
+	gm := NewGameManager(nil, rdb, &config.Config{GameExpiryMinutes: 3})
+
+	active := midGamePoolState(t)
+	active.ID, active.Token = "active-"+generateToken(4), "active-"+generateToken(4)
+	done := midGamePoolState(t)
+	done.ID, done.Token = "done-"+generateToken(4), "done-"+generateToken(4)
+	done.Status = StatusCompleted
+	gm.games[active.ID] = active
+	gm.games[done.ID] = done
+	defer rdb.Del(context.Background(), "game:"+active.Token+":state", "game:"+done.Token+":state")
+
+	if saved := gm.PersistAllGames(); saved != 1 {
+		t.Fatalf("saved %d games, want 1", saved)
+	}
+	if n, _ := rdb.Exists(context.Background(), "game:"+active.Token+":state").Result(); n != 1 {
+		t.Error("active game was not persisted")
+	}
+	if n, _ := rdb.Exists(context.Background(), "game:"+done.Token+":state").Result(); n != 0 {
+		t.Error("completed game should not be persisted")
+	}
+}
+
+func TestPoolGameStateRoundTripsAtCurrentSchema(t *testing.T) {
+	g := newTestPoolGame(t)
+	g.SessionID, g.MoveSeq, g.MaxShots = 42, 7, 60
+
+	data, err := encodePoolGame(g)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	var raw map[string]interface{}
+	json.Unmarshal(data, &raw)
+	if raw["schema_version"] != float64(poolGameSchemaVersion) {
+		t.Fatalf("schema_version = %v, want %d", raw["schema_version"], poolGameSchemaVersion)
+	}
+
+	restored, err := loadPoolGameFromRedis(data)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if restored.ID != g.ID || restored.Token != g.Token || restored.SessionID != 42 || restored.MoveSeq != 7 || restored.MaxShots != 60 {
+		t.Fatalf("restored %+v", restored)
+	}
+	if restored.Player1.PlayerToken != g.Player1.PlayerToken || restored.Player2.BallGroup != g.Player2.BallGroup {
+		t.Fatalf("players differ: %+v / %+v", restored.Player1, restored.Player2)
+	}
+	if restored.Balls != g.Balls || restored.CurrentTurn != g.CurrentTurn {
+		t.Fatal("table differs after reload")
+	}
+}
+
+func TestLoadPoolGameMigratesV1State(t *testing.T) {
+	// Written before schema_version, by a release that did not save ball groups
+	v1 := `{
+		"id": "g_old", "token": "tok_old", "game_type": "pool", "status": "IN_PROGRESS",
+		"player1": {"id": "p1", "phone_number": "256700000001", "display_name": "One"},
+		"player2": {"id": "p2", "phone_number": "256700000002", "display_name": "Two"},
+		"player1_token": "t1", "player2_token": "t2",
+		"current_turn": "p2", "stake_amount": 1000, "shot_number": 4, "session_id": 9
+	}`
+	g, err := loadPoolGameFromRedis([]byte(v1))
+	if err != nil {
+		t.Fatalf("load v1: %v", err)
+	}
+	if g.Player1.BallGroup != GroupAny || g.Player2.BallGroup != GroupAny {
+		t.Errorf("ball groups = %q/%q, want %q", g.Player1.BallGroup, g.Player2.BallGroup, GroupAny)
+	}
+	if g.Player2.PlayerToken != "t2" || g.CurrentTurn != "p2" || g.ShotNumber != 4 || g.SessionID != 9 {
+		t.Errorf("v1 fields lost: %+v", g)
+	}
+
+	future := `{"schema_version": 99, "id": "g", "game_type": "pool", "status": "IN_PROGRESS", "player1": {"id": "p1"}, "player2": {"id": "p2"}}`
+	if _, err := loadPoolGameFromRedis([]byte(future)); err == nil {
+		t.Fatal("state from a newer schema was resumed")
+	}
+}
@@ -1,6 +1,17 @@
 package game
 
-import "math"
+import (
+	"log"
+	"math"
+	"time"
+)
+
+// Outer-loop budget for Simulate. A real shot settles in well under a thousand passes;
+// anything past this is a non-converging setup and is stopped where it stands.
+var (
+	maxSimulationSteps = 20000
+	maxSimulationTime  = 2 * time.Second
+)
 
 // Ball represents a single pool ball's physics state.
 type Ball struct {
@@ -38,6 +49,7 @@ type PhysicsEngine struct {
 	Balls         [NumBalls]*Ball
 	Table         *Table
 	Events        []CollisionEvent
+	Truncated     bool    // Simulate hit its step/time budget and stopped the balls
 	omissionArray []*Ball // balls to skip during moveBalls
 }
 
@@ -51,14 +63,84 @@ func NewPhysicsEngine(balls [NumBalls]*Ball, table *Table) *PhysicsEngine {
 }
 
 // Simulate runs the physics until all balls stop. Returns collision events.
+// If the balls have not settled within the step or wall-clock budget, every ball is
+// stopped in place and Truncated is set instead of looping forever.
 func (pe *PhysicsEngine) Simulate() []CollisionEvent {
 	pe.Events = make([]CollisionEvent, 0)
-	for !pe.AllStopped() {
+	pe.Truncated = false
+	deadline := time.Now().Add(maxSimulationTime)
+	for steps := 0; !pe.AllStopped(); steps++ {
+		if steps >= maxSimulationSteps || time.Now().After(deadline) {
+			pe.stopAll()
+			pe.keepOnTable()
+			pe.Truncated = true
+			break
+		}
 		pe.updatePhysics()
 	}
 	return pe.Events
 }
 
+// keepOnTable moves active balls that a jammed setup flung through the cushions back to
+// the nearest point on the bed.
+func (pe *PhysicsEngine) keepOnTable() {
+	const maxX, maxY = 50*N - BallRadius, 25*N - BallRadius
+	for _, b := range pe.Balls {
+		if b.Active {
+			b.Position = NewVec2(math.Max(-maxX, math.Min(maxX, b.Position.X)), math.Max(-maxY, math.Min(maxY, b.Position.Y)))
+		}
+	}
+}
+
+// stopAll zeroes every ball's motion, including pending cue ball screw.
+func (pe *PhysicsEngine) stopAll() {
+	for _, b := range pe.Balls {
+		b.Velocity = Vec2{}
+		b.DeltaScrew = Vec2{}
+	}
+}
+
+// SimulateShot runs a shot from the given table through the physics engine and returns
+// the collision timeline. truncated reports a shot that did not settle within the budget.
+func SimulateShot(state [NumBalls]BallState, params ShotParams) (events []CollisionEvent, truncated bool) {
+	p := PredictShot(state, params)
+	return p.Events, p.SimulationTruncated
+}
+
+// PredictShot simulates a shot on a copy of the table and returns the resting positions,
+// the balls that dropped and the collision timeline. The cue ball is struck the same way
+// the client animator does it.
+func PredictShot(state [NumBalls]BallState, params ShotParams) *ShotPreview {
+	var balls [NumBalls]*Ball
+	for i, b := range state {
+		balls[i] = &Ball{ID: b.ID, Position: NewVec2(b.X, b.Y), Active: b.Active, Grip: 1}
+	}
+	if balls[0].Active {
+		balls[0].Velocity = NewVec2(math.Cos(params.Angle)*params.Power, math.Sin(params.Angle)*params.Power)
+		balls[0].Screw = params.Screw
+		balls[0].English = params.English
+	}
+	pe := NewPhysicsEngine(balls, NewStandard8BallTable())
+	events := pe.Simulate()
+	if pe.Truncated {
+		log.Printf("[POOL] Shot simulation did not converge, truncated: params=%+v", params)
+	}
+
+	preview := &ShotPreview{
+		BallPositions:       make([]BallState, NumBalls),
+		PocketedBalls:       []int{},
+		Events:              events,
+		SimulationTruncated: pe.Truncated,
+	}
+	for i, b := range pe.Balls {
+		preview.BallPositions[i] = BallState{ID: b.ID, X: b.Position.X, Y: b.Position.Y, Active: b.Active}
+		if state[i].Active && !b.Active {
+			preview.PocketedBalls = append(preview.PocketedBalls, b.ID)
+		}
+	}
+	return preview
+}
+
 // AllStopped returns true if all active balls have zero velocity.
 func (pe *PhysicsEngine) AllStopped() bool {
 	for _, b := range pe.Balls {
@@ -3,6 +3,7 @@ package game
 import (
 	"math"
 	"testing"
+	"time"
 )
 
 // Helper to create a simple 2-ball test setup: cue ball + one object ball.
@@ -262,3 +263,60 @@ func TestAllStoppedLogic(t *testing.T) {
 		t.Error("AllStopped should return false when cue ball has velocity")
 	}
 }
+
+func TestSimulateTruncatesNonConvergingShot(t *testing.T) {
+	// Eight balls jammed into the right cushion: the follow screw the cue ball picks up on
+	// every contact flings it (and the 7) through the cushion far faster than a shot can
+	// go, so the balls are still rolling when the production step budget runs out.
+	jam := []Vec2{
+		{67602.9124, 1966.8034}, {64432.4844, -43.2593}, {65986.1455, -1843.051}, {66649.8263, -1194.3611},
+		{66225.9439, 1197.3122}, {64969.7524, -2860.1656}, {68497.3872, -2725.5507}, {68044.516, 2028.5876},
+	}
+	table := NewStandard8BallTable()
+	var balls [NumBalls]*Ball
+	for i := 0; i < NumBalls; i++ {
+		balls[i] = &Ball{ID: i, Position: NewVec2(0, 100000), Active: false, Grip: 1}
+	}
+	for i, pos := range jam {
+		balls[i] = &Ball{ID: i, Position: pos, Active: true, Grip: 1}
+	}
+	angle, power := 6.187483408689399, 4817.274807649364
+	balls[0].Velocity = NewVec2(math.Cos(angle)*power, math.Sin(angle)*power)
+	balls[0].Screw, balls[0].English = -1, -1
+	engine := NewPhysicsEngine(balls, table)
+
+	done := make(chan struct{})
+	go func() {
+		engine.Simulate()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Simulate did not return")
+	}
+
+	if !engine.Truncated {
+		t.Error("expected Truncated to be set")
+	}
+	if !engine.AllStopped() {
+		t.Error("balls should be stopped after truncation")
+	}
+	for _, b := range engine.Balls {
+		if !b.Active {
+			continue
+		}
+		if math.IsNaN(b.Position.X) || math.IsNaN(b.Position.Y) ||
+			math.Abs(b.Position.X) > 50*N-BallRadius || math.Abs(b.Position.Y) > 25*N-BallRadius {
+			t.Errorf("ball %d left at %v, off the table", b.ID, b.Position)
+		}
+	}
+}
+
+func TestSimulateNormalShotNotTruncated(t *testing.T) {
+	engine := setupStraightShot(-20000, 0, 0, 0, 3000, 0)
+	engine.Simulate()
+	if engine.Truncated {
+		t.Error("a normal shot should settle within the budget")
+	}
+}
@@ -0,0 +1,95 @@
+package game
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrSimulationBusy is returned when every simulation slot is taken and the wait queue is full.
+// It is retriable: the caller should try again shortly.
+var ErrSimulationBusy = errors.New("server busy, please retry the shot preview")
+
+// simulationQueueWait is how long a queued simulation waits for a free slot.
+const simulationQueueWait = time.Second
+
+// simulationLimiter bounds how many physics simulations run at once. Excess callers
+// queue briefly; once the queue is full they are turned away with ErrSimulationBusy.
+type simulationLimiter struct {
+	slots chan struct{}
+	queue chan struct{}
+	wait  time.Duration
+}
+
+func newSimulationLimiter(slots, queue int, wait time.Duration) *simulationLimiter {
+	if slots <= 0 {
+		slots = runtime.NumCPU()
+	}
+	if queue < 0 {
+		queue = 0
+	}
+	return &simulationLimiter{
+		slots: make(chan struct{}, slots),
+		queue: make(chan struct{}, queue),
+		wait:  wait,
+	}
+}
+
+// acquire takes a simulation slot, queueing for up to l.wait. Call release when done.
+func (l *simulationLimiter) acquire() error {
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	// All slots busy: take a place in the queue, or give up if it is full
+	select {
+	case l.queue <- struct{}{}:
+	default:
+		return ErrSimulationBusy
+	}
+	defer func() { <-l.queue }()
+
+	timer := time.NewTimer(l.wait)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return ErrSimulationBusy
+	}
+}
+
+func (l *simulationLimiter) release() {
+	<-l.slots
+}
+
+var (
+	simLimiter     *simulationLimiter
+	simLimiterOnce sync.Once
+)
+
+// simulations returns the process-wide limiter, sized from config on first use.
+func simulations() *simulationLimiter {
+	simLimiterOnce.Do(func() {
+		slots, queue := 0, 16
+		if Manager != nil && Manager.config != nil {
+			slots = Manager.config.PoolMaxConcurrentSimulations
+			queue = Manager.config.PoolSimulationQueueSize
+		}
+		simLimiter = newSimulationLimiter(slots, queue, simulationQueueWait)
+	})
+	return simLimiter
+}
+
+// predictShotLimited runs PredictShot under the concurrent-simulation limit.
+func predictShotLimited(state [NumBalls]BallState, params ShotParams) (*ShotPreview, error) {
+	l := simulations()
+	if err := l.acquire(); err != nil {
+		return nil, err
+	}
+	defer l.release()
+	return PredictShot(state, params), nil
+}
@@ -0,0 +1,103 @@
+package game
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSimulationLimiterQueuesThenRejects(t *testing.T) {
+	l := newSimulationLimiter(2, 1, 200*time.Millisecond)
+
+	// Fill both slots
+	for i := 0; i < 2; i++ {
+		if err := l.acquire(); err != nil {
+			t.Fatalf("slot %d: %v", i, err)
+		}
+	}
+
+	// Third caller queues; fourth finds the queue full and is turned away at once
+	queued := make(chan error, 1)
+	go func() { queued <- l.acquire() }()
+	time.Sleep(20 * time.Millisecond)
+
+	start := time.Now()
+	if err := l.acquire(); err != ErrSimulationBusy {
+		t.Fatalf("over queue: err = %v, want ErrSimulationBusy", err)
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Error("a full queue should reject immediately, not wait")
+	}
+
+	// Freeing a slot lets the queued caller in
+	l.release()
+	if err := <-queued; err != nil {
+		t.Fatalf("queued caller: %v", err)
+	}
+
+	// With nothing freed, a queued caller gives up after the wait
+	if err := l.acquire(); err != ErrSimulationBusy {
+		t.Fatalf("queued past wait: err = %v, want ErrSimulationBusy", err)
+	}
+}
+
+func TestSimulationLimiterBoundsConcurrency(t *testing.T) {
+	l := newSimulationLimiter(2, 8, time.Second)
+	var running, peak int32
+	var wg sync.WaitGroup
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := l.acquire(); err != nil {
+				t.Errorf("acquire: %v", err)
+				return
+			}
+			defer l.release()
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+		}()
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("%d simulations ran at once, limit is 2", peak)
+	}
+}
+
+func TestDryRunShotBusy(t *testing.T) {
+	g := newTestPoolGame(t)
+
+	// Occupy every slot and queue place so the next simulation is refused at once
+	l := simulations()
+	for i := 0; i < cap(l.slots); i++ {
+		l.slots <- struct{}{}
+	}
+	for i := 0; i < cap(l.queue); i++ {
+		l.queue <- struct{}{}
+	}
+	defer func() {
+		for len(l.queue) > 0 {
+			<-l.queue
+		}
+		for len(l.slots) > 0 {
+			<-l.slots
+		}
+	}()
+
+	if _, err := g.DryRunShot("p1", ShotParams{Power: 1000}); err != ErrSimulationBusy {
+		t.Fatalf("err = %v, want ErrSimulationBusy", err)
+	}
+	if g.ShotInProgress || g.ShotNumber != 0 {
+		t.Error("a refused preview must leave the game untouched")
+	}
+}
@@ -1,7 +1,10 @@
 package game
 
 import (
+	"crypto/rand"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"math"
 	"sync"
@@ -29,6 +32,8 @@ type PoolPlayer struct {
 	ShowedUp       bool       `json:"showed_up"`
 	DisconnectedAt *time.Time `json:"-"`
 	BallGroup      BallGroup  `json:"ball_group"`
+	TurnTimeouts   int        `json:"turn_timeouts,omitempty"` // consecutive shot clock timeouts
+	Rating         int        `json:"rating,omitempty"`        // ELO when the game was created (0 = unknown)
 }
 
 // BallState represents a ball's position and status for serialization.
@@ -43,10 +48,31 @@ type BallState struct {
 type ShotParams struct {
 	Angle   float64 `json:"angle"`   // radians
 	Power   float64 `json:"power"`   // 0-5000
-	Screw   float64 `json:"screw"`   // -0.5 to 0.5
+	Screw   float64 `json:"screw"`   // -1 to 1 (negative = backspin)
 	English float64 `json:"english"` // -1 to 1
 }
 
+// Spin limits, matching the range the client spin setter produces.
+const (
+	MaxScrew   = 1.0
+	MaxEnglish = 1.0
+)
+
+// ClampSpin returns params with screw and english limited to the supported range.
+func (p ShotParams) ClampSpin() ShotParams {
+	p.Screw = math.Max(-MaxScrew, math.Min(MaxScrew, p.Screw))
+	p.English = math.Max(-MaxEnglish, math.Min(MaxEnglish, p.English))
+	return p
+}
+
+// validateShotParams checks the parts of a shot that don't depend on game state.
+func validateShotParams(params ShotParams) error {
+	if params.Power < 40 || params.Power > MaxPower {
+		return errors.New("invalid power")
+	}
+	return nil
+}
+
 // ClientShotData is the data the client sends after its physics animation completes.
 type ClientShotData struct {
 	BallPositions       []BallState `json:"ball_positions"`
@@ -57,9 +83,42 @@ type ClientShotData struct {
 }
 
 // FoulInfo describes a foul that occurred during a shot.
+// Message is for display; the remaining fields let clients and dispute review see exactly what went wrong.
 type FoulInfo struct {
-	Type    string `json:"type"`    // "scratch", "wrong_first_contact", "no_cushion", "illegal_8ball", "break_foul"
-	Message string `json:"message"`
+	Type               string `json:"type"` // "scratch", "no_contact", "wrong_first_contact", "no_cushion", "illegal_8ball", "break_foul"
+	Message            string `json:"message"`
+	Rule               string `json:"rule"`                        // rule that was broken, e.g. "cushion_or_pocket_after_contact"
+	FirstContactBallID int    `json:"first_contact_ball_id"`       // -1 if the cue ball hit nothing
+	OffendingBallID    *int   `json:"offending_ball_id,omitempty"` // ball that caused the foul, when there is one
+	AnyPocketed        bool   `json:"any_pocketed"`                // whether any ball (including the cue) was pocketed
+}
+
+// Foul rules reported in FoulInfo.Rule
+const (
+	RuleCueBallOnTable      = "cue_ball_must_stay_on_table"
+	RuleMustContactBall     = "must_contact_a_ball"
+	RuleOwnGroupFirst       = "must_hit_own_group_first"
+	RuleEightBallFirst      = "must_hit_8ball_first"
+	RuleCushionAfterContact = "cushion_or_pocket_after_contact"
+	RuleBreakCushions       = "break_requires_two_balls_to_cushion"
+	RuleEightBallAfterGroup = "8ball_only_after_group_cleared"
+	RuleEightBallOnFoul     = "8ball_pocketed_on_foul"
+)
+
+// newFoul builds a FoulInfo carrying the shot context shared by every foul type.
+func newFoul(foulType, message, rule string, firstContactBallID int, anyPocketed bool, offendingBallID int) *FoulInfo {
+	f := &FoulInfo{
+		Type:               foulType,
+		Message:            message,
+		Rule:               rule,
+		FirstContactBallID: firstContactBallID,
+		AnyPocketed:        anyPocketed,
+	}
+	if offendingBallID >= 0 {
+		id := offendingBallID
+		f.OffendingBallID = &id
+	}
+	return f
 }
 
 // ShotResult represents the outcome of a shot (game logic only, no physics).
@@ -76,6 +135,18 @@ type ShotResult struct {
 	GameOver      bool      `json:"game_over"`
 	Winner        string    `json:"winner,omitempty"`
 	WinType       string    `json:"win_type,omitempty"`
+	// Events is the server-simulated collision timeline of the shot (set when a preview ran)
+	Events []CollisionEvent `json:"events,omitempty"`
+	// SimulationTruncated is set when that simulation hit its budget and was stopped early
+	SimulationTruncated bool `json:"simulation_truncated,omitempty"`
+}
+
+// ShotPreview is the predicted outcome of a shot, computed without touching the game.
+type ShotPreview struct {
+	BallPositions       []BallState      `json:"ball_positions"`
+	PocketedBalls       []int            `json:"pocketed_balls"`
+	Events              []CollisionEvent `json:"events"`
+	SimulationTruncated bool             `json:"simulation_truncated,omitempty"`
 }
 
 // PoolGameState represents the complete state of an 8-ball pool game.
@@ -100,9 +171,17 @@ type PoolGameState struct {
 	CompletedAt      *time.Time   `json:"completed_at,omitempty"`
 	LastActivity     time.Time    `json:"last_activity"`
 	SessionID        int          `json:"session_id,omitempty"`
+	MaxShots         int          `json:"max_shots,omitempty"` // 0 = no cap
+	MoveSeq          int          `json:"move_seq"`            // bumped on every accepted move; clients echo it with take_shot
 	ShotInProgress   bool         `json:"-"`
 	ShotPlayerID     string       `json:"-"`
 	ShotParams       ShotParams   `json:"-"`
+	ShotEvents       []CollisionEvent `json:"-"`
+	ShotTruncated    bool         `json:"-"`
+	TurnDeadline     *time.Time    `json:"turn_deadline,omitempty"` // shot clock for CurrentTurn (nil = off or paused)
+	TurnClockPaused  time.Duration `json:"-"`                       // time left while the clock is paused
+	actions          *actionLog    // recent actions for debugging (GameActionLogSize, memory only)
+	sessionStarted   bool          // StartSession side effects already ran
 	mu               sync.RWMutex
 }
 
@@ -112,9 +191,11 @@ func NewPoolGame(id, token string,
 	p2ID, p2Phone, p2Token string, p2DBID int, p2DisplayName string,
 	stakeAmount int) *PoolGameState {
 
-	expiryMinutes := 3
+	expiry := 3 * time.Minute
+	maxShots := 0
 	if Manager != nil && Manager.config != nil {
-		expiryMinutes = Manager.config.GameExpiryMinutes
+		expiry = connectTimeout(Manager.config, stakeAmount)
+		maxShots = Manager.config.PoolMaxShots
 	}
 
 	g := &PoolGameState{
@@ -132,9 +213,10 @@ func NewPoolGame(id, token string,
 		},
 		Status:       StatusWaiting,
 		StakeAmount:  stakeAmount,
+		MaxShots:     maxShots,
 		IsBreakShot:  true,
 		BallInHand:   false,
-		ExpiresAt:    time.Now().Add(time.Duration(expiryMinutes) * time.Minute),
+		ExpiresAt:    time.Now().Add(expiry),
 		CreatedAt:    time.Now(),
 		LastActivity: time.Now(),
 	}
@@ -163,8 +245,11 @@ func (g *PoolGameState) Initialize() error {
 		}
 	}
 
-	// Player 1 breaks
+	// Player 1 breaks unless the break is tossed for
 	g.CurrentTurn = g.Player1.ID
+	if randomBreakEnabled() && coinFlip() {
+		g.CurrentTurn = g.Player2.ID
+	}
 	g.IsBreakShot = true
 	g.ShotNumber = 0
 
@@ -172,6 +257,7 @@ func (g *PoolGameState) Initialize() error {
 	g.StartedAt = &now
 	g.Status = StatusInProgress
 	g.LastActivity = now
+	g.startTurnClockLocked(now)
 
 	log.Printf("[POOL INIT] Game %s initialized, %s breaks", g.ID, g.CurrentTurn)
 	return nil
@@ -181,15 +267,26 @@ func (g *PoolGameState) Initialize() error {
 func (g *PoolGameState) ValidateCanShoot(playerID string, params ShotParams) error {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
+	return g.validateCanShootLocked(playerID, params)
+}
 
+// validateCanShootLocked holds the shot checks shared by ValidateCanShoot and BeginShot.
+// Caller must hold g.mu.
+func (g *PoolGameState) validateCanShootLocked(playerID string, params ShotParams) error {
 	if g.Status != StatusInProgress {
 		return errors.New("game is not in progress")
 	}
 	if g.CurrentTurn != playerID {
 		return errors.New("not your turn")
 	}
-	if params.Power < 40 || params.Power > MaxPower {
-		return errors.New("invalid power")
+	if err := validateShotParams(params); err != nil {
+		return err
+	}
+	if boardIntegrityCheckEnabled() {
+		if err := g.checkBoardIntegrityLocked(); err != nil {
+			log.Printf("[POOL] Game %s refused shot by %s: %v", g.ID, playerID, err)
+			return err
+		}
 	}
 	if !g.Balls[0].Active {
 		return errors.New("cue ball is not on the table")
@@ -200,13 +297,139 @@ func (g *PoolGameState) ValidateCanShoot(playerID string, params ShotParams) err
 	return nil
 }
 
+// boardIntegrityCheckEnabled reports whether shots should be refused on an inconsistent board.
+// Defaults to on when no manager config is loaded (tests, tools).
+func boardIntegrityCheckEnabled() bool {
+	if Manager != nil && Manager.config != nil {
+		return Manager.config.PoolBoardIntegrityCheck
+	}
+	return true
+}
+
+// randomBreakEnabled reports whether a coin flip decides who breaks.
+// Defaults to off (player 1 breaks) when no manager config is loaded.
+func randomBreakEnabled() bool {
+	return Manager != nil && Manager.config != nil && Manager.config.PoolRandomBreak
+}
+
+// coinFlip returns heads or tails with equal odds from crypto/rand, so the result can't be
+// predicted from the clock. A failed read falls back to tails (player 1 breaks).
+var coinFlip = func() bool {
+	var b [1]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return false
+	}
+	return b[0]&1 == 1
+}
+
+// checkBoardIntegrityLocked verifies the ball set is internally consistent before a shot
+// is simulated: every slot holds its own ball id, the cue ball is on the table or in hand
+// for the shooter, and the 8-ball is still on the table while the game is in progress.
+// Caller must hold g.mu.
+func (g *PoolGameState) checkBoardIntegrityLocked() error {
+	for i, b := range g.Balls {
+		if b.ID != i {
+			return fmt.Errorf("board integrity: slot %d holds ball %d", i, b.ID)
+		}
+	}
+	if !g.Balls[0].Active && !(g.BallInHand && g.BallInHandPlayer == g.CurrentTurn) {
+		return errors.New("board integrity: cue ball missing without ball-in-hand")
+	}
+	if !g.Balls[8].Active {
+		return errors.New("board integrity: 8-ball pocketed but game still in progress")
+	}
+	return nil
+}
+
 // SetShotInProgress marks that a shot is being animated client-side.
 func (g *PoolGameState) SetShotInProgress(playerID string, params ShotParams) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
+	g.startShotLocked(playerID, params)
+}
+
+// ErrStaleMove is returned when a move was sent against an older move_seq than the game's.
+var ErrStaleMove = errors.New("game state has changed, refresh and try again")
+
+// BeginShot validates and starts a shot in one step, so two identical take_shot messages
+// cannot both pass the checks. When the client sent the move_seq it last saw, a mismatch
+// means it acted on stale state and the shot is refused with ErrStaleMove.
+func (g *PoolGameState) BeginShot(playerID string, params ShotParams, expectedSeq *int) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	err := g.beginShotLocked(playerID, params, expectedSeq)
+	detail := map[string]interface{}{"angle": params.Angle, "power": params.Power, "screw": params.Screw, "english": params.English}
+	if expectedSeq != nil {
+		detail["expected_seq"] = *expectedSeq
+	}
+	g.recordActionLocked("take_shot", playerID, detail, err)
+	return err
+}
+
+func (g *PoolGameState) beginShotLocked(playerID string, params ShotParams, expectedSeq *int) error {
+	if expectedSeq != nil && *expectedSeq != g.MoveSeq {
+		return ErrStaleMove
+	}
+	if err := g.validateCanShootLocked(playerID, params); err != nil {
+		return err
+	}
+	g.startShotLocked(playerID, params)
+	return nil
+}
+
+// startShotLocked marks the shot as in progress. Caller must hold g.mu.
+func (g *PoolGameState) startShotLocked(playerID string, params ShotParams) {
 	g.ShotInProgress = true
 	g.ShotPlayerID = playerID
 	g.ShotParams = params
+	g.MoveSeq++
+	// The shot was taken in time; the clock restarts once the result is in
+	g.TurnDeadline = nil
+	g.TurnClockPaused = 0
+}
+
+// PreviewShot simulates params from the current table and records the event timeline
+// so the authoritative ShotResult for this shot carries the same events. The simulation
+// runs outside the game lock and is subject to the concurrent-simulation limit.
+func (g *PoolGameState) PreviewShot(params ShotParams) ([]CollisionEvent, error) {
+	g.mu.RLock()
+	balls := g.Balls
+	g.mu.RUnlock()
+
+	preview, err := predictShotLimited(balls, params)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.ShotEvents, g.ShotTruncated = preview.Events, preview.SimulationTruncated
+	return g.ShotEvents, nil
+}
+
+// DryRunShot predicts where the balls end up if playerID took this shot now. It runs the
+// same validation as a real shot (minus turn order) and never mutates the game.
+func (g *PoolGameState) DryRunShot(playerID string, params ShotParams) (*ShotPreview, error) {
+	g.mu.RLock()
+	status := g.Status
+	isPlayer := g.Player1.ID == playerID || g.Player2.ID == playerID
+	balls := g.Balls
+	g.mu.RUnlock()
+
+	if status != StatusInProgress {
+		return nil, errors.New("game is not in progress")
+	}
+	if !isPlayer {
+		return nil, errors.New("not a player in this game")
+	}
+	if err := validateShotParams(params); err != nil {
+		return nil, err
+	}
+	if !balls[0].Active {
+		return nil, errors.New("cue ball is not on the table")
+	}
+	return predictShotLimited(balls, params.ClampSpin())
 }
 
 // IsShotInProgress returns whether a shot is in progress for the given player.
@@ -233,10 +456,23 @@ func (g *PoolGameState) ApplyShotResult(playerID string, clientData ClientShotDa
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	result, err := g.applyShotResultLocked(playerID, clientData)
+	var detail map[string]interface{}
+	if result != nil {
+		detail = shotResultDetail(result)
+	}
+	g.recordActionLocked("shot_result", playerID, detail, err)
+	return result, err
+}
+
+func (g *PoolGameState) applyShotResultLocked(playerID string, clientData ClientShotData) (*ShotResult, error) {
 	if !g.ShotInProgress || g.ShotPlayerID != playerID {
 		return nil, errors.New("no shot in progress for this player")
 	}
 	g.ShotInProgress = false
+	g.MoveSeq++
+	shotEvents, shotTruncated := g.ShotEvents, g.ShotTruncated
+	g.ShotEvents, g.ShotTruncated = nil, false
 
 	// Basic validation of client data
 	if len(clientData.BallPositions) != NumBalls {
@@ -246,6 +482,9 @@ func (g *PoolGameState) ApplyShotResult(playerID string, clientData ClientShotDa
 	g.ShotNumber++
 	result := &ShotResult{
 		Success: true,
+		Events:  shotEvents,
+
+		SimulationTruncated: shotTruncated,
 	}
 
 	// Use client-provided collision data
@@ -273,15 +512,16 @@ func (g *PoolGameState) ApplyShotResult(playerID string, clientData ClientShotDa
 
 	// === FOUL DETECTION ===
 	var foul *FoulInfo
+	anyPocketed := len(pocketed) > 0
 
 	// Scratch (cue ball pocketed)
 	if cueBallPocketed {
-		foul = &FoulInfo{Type: "scratch", Message: "Cue ball pocketed"}
+		foul = newFoul("scratch", "Cue ball pocketed", RuleCueBallOnTable, firstContactBallID, anyPocketed, 0)
 	}
 
 	// No ball hit
 	if foul == nil && firstContactBallID == -1 {
-		foul = &FoulInfo{Type: "no_contact", Message: "Failed to hit any ball"}
+		foul = newFoul("no_contact", "Failed to hit any ball", RuleMustContactBall, firstContactBallID, anyPocketed, -1)
 	}
 
 	// Wrong first contact
@@ -289,22 +529,25 @@ func (g *PoolGameState) ApplyShotResult(playerID string, clientData ClientShotDa
 		targetGroup := ballGroup(firstContactBallID)
 		if player.BallGroup == Group8Ball {
 			if firstContactBallID != 8 {
-				foul = &FoulInfo{Type: "wrong_first_contact", Message: "Must hit the 8-ball first"}
+				foul = newFoul("wrong_first_contact", fmt.Sprintf("Must hit the 8-ball first (hit ball %d)", firstContactBallID),
+					RuleEightBallFirst, firstContactBallID, anyPocketed, firstContactBallID)
 			}
 		} else if targetGroup != player.BallGroup && firstContactBallID != 8 {
-			foul = &FoulInfo{Type: "wrong_first_contact", Message: "Hit opponent's ball first"}
+			foul = newFoul("wrong_first_contact", fmt.Sprintf("Hit opponent's ball first (ball %d)", firstContactBallID),
+				RuleOwnGroupFirst, firstContactBallID, anyPocketed, firstContactBallID)
 		}
 	}
 
 	// No cushion after contact (and nothing pocketed)
 	if foul == nil && firstContactBallID >= 0 && !cushionAfterContact && len(pocketed) == 0 {
-		foul = &FoulInfo{Type: "no_cushion", Message: "No ball hit a cushion after contact"}
+		foul = newFoul("no_cushion", fmt.Sprintf("No ball hit a cushion after contact with ball %d", firstContactBallID),
+			RuleCushionAfterContact, firstContactBallID, anyPocketed, firstContactBallID)
 	}
 
 	// Break-specific fouls
 	if foul == nil && g.IsBreakShot {
 		if clientData.BreakCushionCount+len(pocketed) < 2 {
-			foul = &FoulInfo{Type: "break_foul", Message: "Not enough balls reached cushions on break"}
+			foul = newFoul("break_foul", "Not enough balls reached cushions on break", RuleBreakCushions, firstContactBallID, anyPocketed, -1)
 		}
 	}
 
@@ -357,7 +600,11 @@ func (g *PoolGameState) ApplyShotResult(playerID string, clientData ClientShotDa
 			result.GameOver = true
 			result.Winner = opponent.ID
 			result.WinType = "illegal_8ball"
-			foul = &FoulInfo{Type: "illegal_8ball", Message: "8-ball pocketed illegally"}
+			rule := RuleEightBallAfterGroup
+			if foul != nil {
+				rule = RuleEightBallOnFoul
+			}
+			foul = newFoul("illegal_8ball", "8-ball pocketed illegally", rule, firstContactBallID, true, 8)
 			result.Foul = foul
 		} else {
 			result.GameOver = true
@@ -397,6 +644,13 @@ func (g *PoolGameState) ApplyShotResult(playerID string, clientData ClientShotDa
 	result.Player1Group = g.Player1.BallGroup
 	result.Player2Group = g.Player2.BallGroup
 
+	// === SHOT CAP ===
+	if !result.GameOver && g.MaxShots > 0 && g.ShotNumber >= g.MaxShots {
+		result.GameOver = true
+		result.Winner, result.WinType = g.shotCapOutcome()
+		log.Printf("[POOL] Game %s reached shot cap %d, outcome=%s winner=%s", g.ID, g.MaxShots, result.WinType, result.Winner)
+	}
+
 	// === TURN MANAGEMENT ===
 	g.IsBreakShot = false
 
@@ -446,6 +700,10 @@ func (g *PoolGameState) ApplyShotResult(playerID string, clientData ClientShotDa
 	}
 
 	g.LastActivity = time.Now()
+	if shooter, _ := g.getPlayerAndOpponent(playerID); shooter != nil {
+		shooter.TurnTimeouts = 0
+	}
+	g.startTurnClockLocked(g.LastActivity)
 
 	// Record move
 	if Manager != nil {
@@ -466,6 +724,12 @@ func (g *PoolGameState) PlaceCueBall(playerID string, x, y float64) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
+	err := g.placeCueBallLocked(playerID, x, y)
+	g.recordActionLocked("place_cue_ball", playerID, map[string]interface{}{"x": x, "y": y}, err)
+	return err
+}
+
+func (g *PoolGameState) placeCueBallLocked(playerID string, x, y float64) error {
 	if g.CurrentTurn != playerID {
 		return errors.New("not your turn")
 	}
@@ -496,11 +760,20 @@ func (g *PoolGameState) PlaceCueBall(playerID string, x, y float64) error {
 	g.Balls[0] = BallState{ID: 0, X: x, Y: y, Active: true}
 	g.BallInHand = false
 	g.BallInHandPlayer = ""
+	g.MoveSeq++
 
 	log.Printf("[POOL] Cue ball placed at (%.0f, %.0f) by %s", x, y, playerID)
 	return nil
 }
 
+// FullStateJSON returns the complete server-side state (every ball, both players' groups,
+// fouls, shot in progress) in the form SaveFinalGameState persists. Admin use only.
+func (g *PoolGameState) FullStateJSON() ([]byte, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return json.Marshal(g)
+}
+
 // GetGameStateForPlayer returns the game state visible to a specific player.
 func (g *PoolGameState) GetGameStateForPlayer(playerID string) map[string]interface{} {
 	g.mu.RLock()
@@ -510,23 +783,37 @@ func (g *PoolGameState) GetGameStateForPlayer(playerID string) map[string]interf
 	var myName, oppName string
 	var myConnected, oppConnected bool
 	var myGroup, oppGroup BallGroup
+	var myRating, oppRating int
 
 	if g.Player1.ID == playerID {
 		myID, oppID = g.Player1.ID, g.Player2.ID
 		myName, oppName = g.Player1.DisplayName, g.Player2.DisplayName
 		myConnected, oppConnected = g.Player1.Connected, g.Player2.Connected
 		myGroup, oppGroup = g.Player1.BallGroup, g.Player2.BallGroup
+		myRating, oppRating = g.Player1.Rating, g.Player2.Rating
 	} else {
 		myID, oppID = g.Player2.ID, g.Player1.ID
 		myName, oppName = g.Player2.DisplayName, g.Player1.DisplayName
 		myConnected, oppConnected = g.Player2.Connected, g.Player1.Connected
 		myGroup, oppGroup = g.Player2.BallGroup, g.Player1.BallGroup
+		myRating, oppRating = g.Player2.Rating, g.Player1.Rating
 	}
 
 	balls := make([]BallState, NumBalls)
 	copy(balls, g.Balls[:])
 
-	return map[string]interface{}{
+	// Shot clock: a running deadline, or the seconds banked while someone is disconnected
+	turnSecondsLeft := 0
+	if g.TurnDeadline != nil {
+		turnSecondsLeft = int(time.Until(*g.TurnDeadline).Round(time.Second) / time.Second)
+	} else if g.TurnClockPaused > 0 {
+		turnSecondsLeft = int(g.TurnClockPaused.Round(time.Second) / time.Second)
+	}
+	if turnSecondsLeft < 0 {
+		turnSecondsLeft = 0
+	}
+
+	state := map[string]interface{}{
 		"game_id":               g.ID,
 		"token":                 g.Token,
 		"status":                g.Status,
@@ -545,10 +832,26 @@ func (g *PoolGameState) GetGameStateForPlayer(playerID string) map[string]interf
 		"ball_in_hand":          g.BallInHand,
 		"ball_in_hand_player":   g.BallInHandPlayer,
 		"shot_number":           g.ShotNumber,
+		"max_shots":             g.MaxShots,
 		"stake_amount":          g.StakeAmount,
 		"winner":                g.Winner,
 		"win_type":              g.WinType,
+		"turn_deadline":         g.TurnDeadline,
+		"turn_seconds_left":     turnSecondsLeft,
+		"turn_clock_paused":     g.TurnDeadline == nil && g.TurnClockPaused > 0,
+		"move_seq":              g.MoveSeq,
+	}
+	// Own rating exact; the opponent's only as a band unless OPPONENT_RATING_BANDS is off
+	if myRating > 0 {
+		state["my_rating"] = myRating
 	}
+	if oppRating > 0 {
+		state["opponent_rating_band"] = RatingBand(oppRating)
+		if !opponentRatingBanded() {
+			state["opponent_rating"] = oppRating
+		}
+	}
+	return state
 }
 
 // === Connection management (replicates existing patterns) ===
@@ -567,6 +870,9 @@ func (g *PoolGameState) SetPlayerConnected(playerID string, connected bool) {
 			g.Player2.DisconnectedAt = nil
 		}
 	}
+	if g.Player1.Connected && g.Player2.Connected {
+		g.resumeTurnClockLocked(time.Now())
+	}
 }
 
 func (g *PoolGameState) BothPlayersConnected() bool {
@@ -602,6 +908,22 @@ func (g *PoolGameState) SetPlayerDisconnected(playerID string) {
 		g.Player2.Connected = false
 		g.Player2.DisconnectedAt = &now
 	}
+	// The shot clock waits out the disconnect grace
+	g.pauseTurnClockLocked(now)
+}
+
+// ClearPlayerDisconnectTime drops a rejoining player's disconnect timestamp so the grace
+// check cannot forfeit them. It reports whether a disconnect was pending.
+func (g *PoolGameState) ClearPlayerDisconnectTime(playerID string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, p := range []*PoolPlayer{g.Player1, g.Player2} {
+		if p != nil && p.ID == playerID && p.DisconnectedAt != nil {
+			p.DisconnectedAt = nil
+			return true
+		}
+	}
+	return false
 }
 
 func (g *PoolGameState) GetOpponentID(playerID string) string {
@@ -643,6 +965,15 @@ func (g *PoolGameState) GetOpponent() *PoolPlayer {
 	return g.Player1
 }
 
+// droppedBeforePlayLocked reports whether p disconnected within window of the game starting
+// and before the first shot. Caller holds g.mu.
+func (g *PoolGameState) droppedBeforePlayLocked(p *PoolPlayer, window time.Duration) bool {
+	if window <= 0 || g.ShotNumber > 0 || g.StartedAt == nil || p.DisconnectedAt == nil {
+		return false
+	}
+	return p.DisconnectedAt.Sub(*g.StartedAt) < window
+}
+
 // ForfeitByDisconnect forfeits the game due to disconnect.
 func (g *PoolGameState) ForfeitByDisconnect(disconnectedPlayerID string) {
 	g.mu.Lock()
@@ -657,6 +988,7 @@ func (g *PoolGameState) ForfeitByDisconnect(disconnectedPlayerID string) {
 	g.WinType = "forfeit"
 	now := time.Now()
 	g.CompletedAt = &now
+	g.recordActionLocked("forfeit", disconnectedPlayerID, map[string]interface{}{"reason": "disconnect", "winner": g.Winner}, nil)
 
 	if Manager != nil {
 		dbID := g.getDBPlayerIDLocked(disconnectedPlayerID)
@@ -681,6 +1013,7 @@ func (g *PoolGameState) ForfeitByConcede(concedingPlayerID string) {
 	g.WinType = "concede"
 	now := time.Now()
 	g.CompletedAt = &now
+	g.recordActionLocked("concede", concedingPlayerID, map[string]interface{}{"winner": g.Winner}, nil)
 
 	if Manager != nil {
 		dbID := g.getDBPlayerIDLocked(concedingPlayerID)
@@ -691,6 +1024,28 @@ func (g *PoolGameState) ForfeitByConcede(concedingPlayerID string) {
 	}
 }
 
+// ResultFor reports how a finished game went for playerID ("win", "loss" or "draw") and
+// what it netted them: the taxed pot minus their stake on a win, the stake lost otherwise.
+func (g *PoolGameState) ResultFor(playerID string) (result string, netWinnings float64) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	stake := float64(g.StakeAmount)
+	switch {
+	case g.Winner == "" || g.WinType == "draw":
+		return "draw", 0
+	case g.Winner == playerID:
+		taxPercent := 0
+		if Manager != nil && Manager.config != nil {
+			taxPercent = Manager.config.PayoutTaxPercent
+		}
+		pot := stake * 2
+		return "win", pot - pot*float64(taxPercent)/100.0 - stake
+	default:
+		return "loss", -stake
+	}
+}
+
 // SaveToRedis saves the game state via the manager.
 func (g *PoolGameState) SaveToRedis() {
 	if Manager != nil && Manager.rdb != nil {
@@ -748,6 +1103,38 @@ func ballGroup(id int) BallGroup {
 	return "" // 0 = cue, 8 = eight
 }
 
+// clearedBallCount returns how many balls of the player's group are off the table.
+func (g *PoolGameState) clearedBallCount(player *PoolPlayer) int {
+	if player.BallGroup == Group8Ball {
+		return 7
+	}
+	if player.BallGroup != GroupSolids && player.BallGroup != GroupStripes {
+		return 0
+	}
+	cleared := 0
+	for _, b := range g.Balls {
+		if !b.Active && ballGroup(b.ID) == player.BallGroup {
+			cleared++
+		}
+	}
+	return cleared
+}
+
+// shotCapOutcome resolves a game that hit its shot cap: the player with more of their
+// group cleared wins, otherwise it is a draw and both stakes are refunded.
+func (g *PoolGameState) shotCapOutcome() (winner, winType string) {
+	p1 := g.clearedBallCount(g.Player1)
+	p2 := g.clearedBallCount(g.Player2)
+	switch {
+	case p1 > p2:
+		return g.Player1.ID, "shot_cap"
+	case p2 > p1:
+		return g.Player2.ID, "shot_cap"
+	default:
+		return "", "draw"
+	}
+}
+
 // updateBallGroupStatus checks if a player has cleared all balls in their group
 // and promotes them to shooting the 8-ball.
 func (g *PoolGameState) updateBallGroupStatus(player *PoolPlayer) {
@@ -0,0 +1,483 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+// newTestPoolGame returns an initialized, post-break game with p1 to shoot.
+func newTestPoolGame(t *testing.T) *PoolGameState {
+	t.Helper()
+	g := NewPoolGame("g1", "tok", "p1", "256700000001", "t1", 1, "One", "p2", "256700000002", "t2", 2, "Two", 1000)
+	if err := g.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	g.IsBreakShot = false
+	return g
+}
+
+// shotData returns client data that leaves the balls where they are, with the given collision summary.
+func shotData(g *PoolGameState, firstContact int, cushion bool, pocketed ...int) ClientShotData {
+	positions := g.GetCurrentBallPositions()
+	for _, id := range pocketed {
+		positions[id].Active = false
+	}
+	return ClientShotData{
+		BallPositions:       positions,
+		PocketedBalls:       pocketed,
+		FirstContactBallID:  firstContact,
+		CushionAfterContact: cushion,
+		BreakCushionCount:   4,
+	}
+}
+
+func TestFoulInfoDetails(t *testing.T) {
+	tests := []struct {
+		name         string
+		setup        func(g *PoolGameState)
+		data         func(g *PoolGameState) ClientShotData
+		wantType     string
+		wantRule     string
+		wantFirst    int
+		wantOffender int // -1 for none
+		wantPocketed bool
+	}{
+		{
+			name:         "scratch",
+			data:         func(g *PoolGameState) ClientShotData { return shotData(g, 1, true, 0) },
+			wantType:     "scratch",
+			wantRule:     RuleCueBallOnTable,
+			wantFirst:    1,
+			wantOffender: 0,
+			wantPocketed: true,
+		},
+		{
+			name:         "no contact",
+			data:         func(g *PoolGameState) ClientShotData { return shotData(g, -1, false) },
+			wantType:     "no_contact",
+			wantRule:     RuleMustContactBall,
+			wantFirst:    -1,
+			wantOffender: -1,
+		},
+		{
+			name:         "opponent ball first",
+			setup:        func(g *PoolGameState) { g.Player1.BallGroup, g.Player2.BallGroup = GroupSolids, GroupStripes },
+			data:         func(g *PoolGameState) ClientShotData { return shotData(g, 9, true) },
+			wantType:     "wrong_first_contact",
+			wantRule:     RuleOwnGroupFirst,
+			wantFirst:    9,
+			wantOffender: 9,
+		},
+		{
+			name:         "must hit 8-ball first",
+			setup:        func(g *PoolGameState) { g.Player1.BallGroup, g.Player2.BallGroup = Group8Ball, GroupStripes },
+			data:         func(g *PoolGameState) ClientShotData { return shotData(g, 10, true) },
+			wantType:     "wrong_first_contact",
+			wantRule:     RuleEightBallFirst,
+			wantFirst:    10,
+			wantOffender: 10,
+		},
+		{
+			name:         "no cushion",
+			data:         func(g *PoolGameState) ClientShotData { return shotData(g, 3, false) },
+			wantType:     "no_cushion",
+			wantRule:     RuleCushionAfterContact,
+			wantFirst:    3,
+			wantOffender: 3,
+		},
+		{
+			name:  "break foul",
+			setup: func(g *PoolGameState) { g.IsBreakShot = true },
+			data: func(g *PoolGameState) ClientShotData {
+				d := shotData(g, 1, true)
+				d.BreakCushionCount = 1
+				return d
+			},
+			wantType:     "break_foul",
+			wantRule:     RuleBreakCushions,
+			wantFirst:    1,
+			wantOffender: -1,
+		},
+		{
+			name:         "8-ball before group cleared",
+			setup:        func(g *PoolGameState) { g.Player1.BallGroup, g.Player2.BallGroup = GroupSolids, GroupStripes },
+			data:         func(g *PoolGameState) ClientShotData { return shotData(g, 1, true, 8) },
+			wantType:     "illegal_8ball",
+			wantRule:     RuleEightBallAfterGroup,
+			wantFirst:    1,
+			wantOffender: 8,
+			wantPocketed: true,
+		},
+		{
+			name:         "8-ball on a scratch",
+			setup:        func(g *PoolGameState) { g.Player1.BallGroup, g.Player2.BallGroup = GroupSolids, GroupStripes },
+			data:         func(g *PoolGameState) ClientShotData { return shotData(g, 1, true, 0, 8) },
+			wantType:     "illegal_8ball",
+			wantRule:     RuleEightBallOnFoul,
+			wantFirst:    1,
+			wantOffender: 8,
+			wantPocketed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestPoolGame(t)
+			if tt.setup != nil {
+				tt.setup(g)
+			}
+			params := ShotParams{Power: 1000}
+			g.SetShotInProgress("p1", params)
+
+			result, err := g.ApplyShotResult("p1", tt.data(g))
+			if err != nil {
+				t.Fatalf("ApplyShotResult: %v", err)
+			}
+			foul := result.Foul
+			if foul == nil {
+				t.Fatalf("expected %s foul, got none", tt.wantType)
+			}
+			if foul.Type != tt.wantType || foul.Rule != tt.wantRule {
+				t.Errorf("type/rule = %s/%s, want %s/%s", foul.Type, foul.Rule, tt.wantType, tt.wantRule)
+			}
+			if foul.Message == "" {
+				t.Errorf("message should not be empty")
+			}
+			if foul.FirstContactBallID != tt.wantFirst {
+				t.Errorf("first contact = %d, want %d", foul.FirstContactBallID, tt.wantFirst)
+			}
+			if foul.AnyPocketed != tt.wantPocketed {
+				t.Errorf("any pocketed = %v, want %v", foul.AnyPocketed, tt.wantPocketed)
+			}
+			switch {
+			case tt.wantOffender < 0 && foul.OffendingBallID != nil:
+				t.Errorf("offending ball = %d, want none", *foul.OffendingBallID)
+			case tt.wantOffender >= 0 && (foul.OffendingBallID == nil || *foul.OffendingBallID != tt.wantOffender):
+				t.Errorf("offending ball = %v, want %d", foul.OffendingBallID, tt.wantOffender)
+			}
+		})
+	}
+}
+
+func TestValidateCanShootRejectsCorruptBoard(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(g *PoolGameState)
+	}{
+		{"cue missing without ball-in-hand", func(g *PoolGameState) { g.Balls[0].Active = false }},
+		{"8-ball gone mid-game", func(g *PoolGameState) { g.Balls[8].Active = false }},
+		{"duplicate ball id", func(g *PoolGameState) { g.Balls[5].ID = 4 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestPoolGame(t)
+			tt.corrupt(g)
+			err := g.ValidateCanShoot("p1", ShotParams{Power: 1000})
+			if err == nil || !strings.Contains(err.Error(), "board integrity") {
+				t.Fatalf("expected board integrity error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateCanShootAllowsBallInHandWithoutCue(t *testing.T) {
+	g := newTestPoolGame(t)
+	g.Balls[0].Active = false
+	g.BallInHand = true
+	g.BallInHandPlayer = "p1"
+
+	// Board is consistent; the shooter just has to place the cue ball first
+	err := g.ValidateCanShoot("p1", ShotParams{Power: 1000})
+	if err == nil || err.Error() != "cue ball is not on the table" {
+		t.Fatalf("expected placement error, got %v", err)
+	}
+}
+
+func TestShotCapResolvesGame(t *testing.T) {
+	tests := []struct {
+		name       string
+		pocketed   []int // balls already off the table before the capped shot
+		wantWinner string
+		wantType   string
+	}{
+		{"p1 ahead", []int{1, 2, 3, 9}, "p1", "shot_cap"},
+		{"p2 ahead", []int{1, 9, 10}, "p2", "shot_cap"},
+		{"level", []int{1, 2, 9, 10}, "", "draw"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestPoolGame(t)
+			g.MaxShots = 2
+			g.Player1.BallGroup = GroupSolids
+			g.Player2.BallGroup = GroupStripes
+			for _, id := range tt.pocketed {
+				g.Balls[id].Active = false
+			}
+
+			// First shot: legal miss, game continues
+			g.SetShotInProgress("p1", ShotParams{Power: 1000})
+			result, err := g.ApplyShotResult("p1", shotData(g, 4, true))
+			if err != nil {
+				t.Fatalf("shot 1: %v", err)
+			}
+			if result.GameOver {
+				t.Fatal("game ended before reaching the cap")
+			}
+
+			// Second shot hits the cap
+			g.SetShotInProgress("p2", ShotParams{Power: 1000})
+			result, err = g.ApplyShotResult("p2", shotData(g, 11, true))
+			if err != nil {
+				t.Fatalf("shot 2: %v", err)
+			}
+			if !result.GameOver || result.Winner != tt.wantWinner || result.WinType != tt.wantType {
+				t.Fatalf("got over=%v winner=%q type=%q, want winner=%q type=%q",
+					result.GameOver, result.Winner, result.WinType, tt.wantWinner, tt.wantType)
+			}
+			if g.Status != StatusCompleted || g.WinType != tt.wantType {
+				t.Fatalf("game state not completed: %s/%s", g.Status, g.WinType)
+			}
+		})
+	}
+}
+
+func TestShotCapDisabledByDefault(t *testing.T) {
+	g := newTestPoolGame(t)
+	for i := 0; i < 50; i++ {
+		shooter := g.CurrentTurn
+		g.SetShotInProgress(shooter, ShotParams{Power: 1000})
+		result, err := g.ApplyShotResult(shooter, shotData(g, 1, true))
+		if err != nil {
+			t.Fatalf("shot %d: %v", i, err)
+		}
+		if result.GameOver {
+			t.Fatalf("game ended after %d shots without a cap", i+1)
+		}
+	}
+}
+
+func TestPreviewShotEventsCarriedOnResult(t *testing.T) {
+	g := newTestPoolGame(t)
+	params := ShotParams{Angle: 0, Power: 4000}
+
+	events, err := g.PreviewShot(params)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if len(events) == 0 {
+		t.Fatal("expected a simulated event timeline")
+	}
+	g.SetShotInProgress("p1", params)
+
+	result, err := g.ApplyShotResult("p1", shotData(g, 1, true))
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if len(result.Events) != len(events) {
+		t.Fatalf("result events = %d, want %d", len(result.Events), len(events))
+	}
+
+	// The timeline belongs to one shot only
+	g.SetShotInProgress(result.NextTurn, params)
+	next, err := g.ApplyShotResult(result.NextTurn, shotData(g, 1, true))
+	if err != nil {
+		t.Fatalf("apply next: %v", err)
+	}
+	if next.Events != nil {
+		t.Errorf("stale events carried to the next shot: %d", len(next.Events))
+	}
+}
+
+func TestTruncatedPreviewFlaggedOnResult(t *testing.T) {
+	defer func(n int) { maxSimulationSteps = n }(maxSimulationSteps)
+	maxSimulationSteps = 5
+
+	g := newTestPoolGame(t)
+	params := ShotParams{Angle: 0, Power: 4000}
+	if _, err := g.PreviewShot(params); err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	g.SetShotInProgress("p1", params)
+
+	result, err := g.ApplyShotResult("p1", shotData(g, 1, true))
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if !result.SimulationTruncated {
+		t.Error("expected simulation_truncated on the result")
+	}
+}
+
+func TestDryRunShotDoesNotMutateGame(t *testing.T) {
+	g := newTestPoolGame(t)
+	before := g.Balls
+	shotNumber, turn := g.ShotNumber, g.CurrentTurn
+
+	preview, err := g.DryRunShot("p2", ShotParams{Angle: 0, Power: 4000, Screw: 3, English: -3})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if len(preview.BallPositions) != NumBalls || len(preview.Events) == 0 {
+		t.Fatalf("unexpected preview: %d positions, %d events", len(preview.BallPositions), len(preview.Events))
+	}
+	if preview.BallPositions[0] == before[0] {
+		t.Error("cue ball should have moved in the prediction")
+	}
+
+	if g.Balls != before {
+		t.Error("dry run changed g.Balls")
+	}
+	if g.ShotNumber != shotNumber || g.CurrentTurn != turn || g.ShotInProgress {
+		t.Errorf("dry run advanced the game: shot=%d turn=%s inProgress=%v", g.ShotNumber, g.CurrentTurn, g.ShotInProgress)
+	}
+}
+
+func TestDryRunShotValidation(t *testing.T) {
+	g := newTestPoolGame(t)
+	if _, err := g.DryRunShot("p1", ShotParams{Power: MaxPower + 1}); err == nil || err.Error() != "invalid power" {
+		t.Errorf("power above max: err = %v", err)
+	}
+	if _, err := g.DryRunShot("p3", ShotParams{Power: 1000}); err == nil {
+		t.Error("expected outsider to be refused")
+	}
+}
+
+func TestClampSpin(t *testing.T) {
+	p := ShotParams{Power: 1000, Screw: -4, English: 2.5}.ClampSpin()
+	if p.Screw != -MaxScrew || p.English != MaxEnglish || p.Power != 1000 {
+		t.Errorf("ClampSpin = %+v", p)
+	}
+	p = ShotParams{Screw: 0.3, English: -0.7}.ClampSpin()
+	if p.Screw != 0.3 || p.English != -0.7 {
+		t.Errorf("in-range spin changed: %+v", p)
+	}
+}
+
+func TestBeginShotConcurrentDuplicateOnlyOneWins(t *testing.T) {
+	g := newTestPoolGame(t)
+	seq := g.MoveSeq
+	params := ShotParams{Angle: 0.5, Power: 1000}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s := seq
+			errs[i] = g.BeginShot("p1", params, &s)
+		}(i)
+	}
+	wg.Wait()
+
+	var ok, stale int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			ok++
+		case errors.Is(err, ErrStaleMove):
+			stale++
+		}
+	}
+	if ok != 1 || stale != 1 {
+		t.Fatalf("expected one shot and one stale rejection, got %v", errs)
+	}
+	if g.MoveSeq != seq+1 {
+		t.Errorf("move_seq = %d, want %d", g.MoveSeq, seq+1)
+	}
+}
+
+func TestMoveSeqAdvancesAndRejectsStaleShots(t *testing.T) {
+	g := newTestPoolGame(t)
+	start := g.MoveSeq
+
+	if err := g.BeginShot("p1", ShotParams{Power: 1000}, &start); err != nil {
+		t.Fatalf("BeginShot: %v", err)
+	}
+	if _, err := g.ApplyShotResult("p1", shotData(g, 1, true)); err != nil {
+		t.Fatalf("ApplyShotResult: %v", err)
+	}
+	if got := g.GetGameStateForPlayer("p2")["move_seq"]; got != start+2 {
+		t.Fatalf("state move_seq = %v, want %d", got, start+2)
+	}
+
+	// p2 aims on the state before the result came in
+	if err := g.BeginShot("p2", ShotParams{Power: 1000}, &start); !errors.Is(err, ErrStaleMove) {
+		t.Fatalf("expected ErrStaleMove, got %v", err)
+	}
+	// Clients that do not send a sequence keep working
+	if err := g.BeginShot("p2", ShotParams{Power: 1000}, nil); err != nil {
+		t.Fatalf("BeginShot without seq: %v", err)
+	}
+}
+
+func TestCompactBallsRoundTrip(t *testing.T) {
+	g := newTestPoolGame(t)
+	// Move a few balls off-grid and pocket some so both active and inactive balls are covered
+	g.Balls[0].X, g.Balls[0].Y = -12345.678901234, 2345.6789012345
+	g.Balls[3].Active = false
+	g.Balls[12].Active = false
+
+	state := g.GetGameStateForPlayer("p1")
+	jsonForm, _ := json.Marshal(state["balls"])
+	var want []BallState
+	json.Unmarshal(jsonForm, &want)
+
+	encoded := EncodeCompactBalls(state["balls"].([]BallState))
+	if len(encoded) >= len(jsonForm) {
+		t.Errorf("compact form (%d bytes) is not smaller than JSON (%d bytes)", len(encoded), len(jsonForm))
+	}
+	got, err := DecodeCompactBalls(encoded)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d balls, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID || got[i].Active != want[i].Active {
+			t.Errorf("ball %d: got %+v, want %+v", i, got[i], want[i])
+		}
+		if want[i].Active && (got[i].X != want[i].X || got[i].Y != want[i].Y) {
+			t.Errorf("ball %d position: got (%v, %v), want (%v, %v)", i, got[i].X, got[i].Y, want[i].X, want[i].Y)
+		}
+	}
+
+	if _, err := DecodeCompactBalls(encoded[:len(encoded)-8]); err == nil {
+		t.Error("truncated payload should fail to decode")
+	}
+}
+
+func TestBreakCoinFlipIsFair(t *testing.T) {
+	prev := Manager
+	t.Cleanup(func() { Manager = prev })
+
+	breaks := func() (p2 int) {
+		for i := 0; i < 4000; i++ {
+			g := NewPoolGame("g1", "tok", "p1", "256700000001", "t1", 1, "One", "p2", "256700000002", "t2", 2, "Two", 1000)
+			if err := g.Initialize(); err != nil {
+				t.Fatalf("initialize: %v", err)
+			}
+			if g.CurrentTurn == "p2" {
+				p2++
+			}
+		}
+		return p2
+	}
+
+	Manager = NewGameManager(nil, nil, &config.Config{})
+	if n := breaks(); n != 0 {
+		t.Fatalf("player 2 broke %d times with the coin flip off", n)
+	}
+
+	// 4000 tosses: 50% +/- 5 points is more than six standard deviations
+	Manager = NewGameManager(nil, nil, &config.Config{PoolRandomBreak: true})
+	if n := breaks(); n < 1800 || n > 2200 {
+		t.Fatalf("player 2 broke %d of 4000 games, want roughly half", n)
+	}
+}
@@ -0,0 +1,155 @@
+package game
+
+import (
+	"errors"
+	"log"
+	"time"
+)
+
+// defaultTurnTimeoutForfeit is how many timeouts in a row lose the game when not configured.
+const defaultTurnTimeoutForfeit = 3
+
+// turnClockSeconds returns the per-turn shot clock length (0 = no clock).
+func turnClockSeconds() int {
+	if Manager != nil && Manager.config != nil {
+		return Manager.config.PoolTurnSeconds
+	}
+	return 0
+}
+
+// turnTimeoutForfeitLimit returns the consecutive timeouts that forfeit the game.
+func turnTimeoutForfeitLimit() int {
+	if Manager != nil && Manager.config != nil && Manager.config.PoolTurnTimeoutForfeit > 0 {
+		return Manager.config.PoolTurnTimeoutForfeit
+	}
+	return defaultTurnTimeoutForfeit
+}
+
+// startTurnClockLocked gives the player on turn a fresh shot clock. The clock starts
+// paused if someone is disconnected. Caller must hold g.mu.
+func (g *PoolGameState) startTurnClockLocked(now time.Time) {
+	g.TurnDeadline = nil
+	g.TurnClockPaused = 0
+
+	secs := turnClockSeconds()
+	if secs <= 0 || g.Status != StatusInProgress {
+		return
+	}
+	deadline := now.Add(time.Duration(secs) * time.Second)
+	g.TurnDeadline = &deadline
+	if !g.Player1.Connected || !g.Player2.Connected {
+		g.pauseTurnClockLocked(now)
+	}
+}
+
+// pauseTurnClockLocked freezes the running clock (disconnect grace). Caller must hold g.mu.
+func (g *PoolGameState) pauseTurnClockLocked(now time.Time) {
+	if g.TurnDeadline == nil {
+		return
+	}
+	remaining := g.TurnDeadline.Sub(now)
+	if remaining < time.Second {
+		remaining = time.Second
+	}
+	g.TurnClockPaused = remaining
+	g.TurnDeadline = nil
+}
+
+// resumeTurnClockLocked restarts a paused clock with the time that was left. Caller must hold g.mu.
+func (g *PoolGameState) resumeTurnClockLocked(now time.Time) {
+	if g.TurnClockPaused <= 0 || g.Status != StatusInProgress {
+		return
+	}
+	deadline := now.Add(g.TurnClockPaused)
+	g.TurnDeadline = &deadline
+	g.TurnClockPaused = 0
+}
+
+// TurnClock reports the shot clock for the player on turn. running is false when there is
+// no clock, it is paused, or a shot is being played.
+func (g *PoolGameState) TurnClock(now time.Time) (playerID string, secondsLeft int, running bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if g.TurnDeadline == nil || g.Status != StatusInProgress || g.ShotInProgress {
+		return "", 0, false
+	}
+	left := int(g.TurnDeadline.Sub(now).Round(time.Second) / time.Second)
+	if left < 0 {
+		left = 0
+	}
+	return g.CurrentTurn, left, true
+}
+
+// ApplyTurnTimeout handles a shot clock that ran out: the player on turn commits a foul and
+// the opponent gets ball-in-hand. After too many timeouts in a row the player forfeits.
+func (g *PoolGameState) ApplyTurnTimeout(now time.Time) (*ShotResult, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.Status != StatusInProgress {
+		return nil, errors.New("game is not in progress")
+	}
+	if g.ShotInProgress {
+		return nil, errors.New("a shot is in progress")
+	}
+	if g.TurnDeadline == nil || now.Before(*g.TurnDeadline) {
+		return nil, errors.New("shot clock has not run out")
+	}
+
+	player, opponent := g.getPlayerAndOpponent(g.CurrentTurn)
+	player.TurnTimeouts++
+	g.MoveSeq++
+	dbPlayerID := g.getDBPlayerIDLocked(player.ID)
+
+	result := &ShotResult{
+		Success:       true,
+		PocketedBalls: []int{},
+		Foul: &FoulInfo{
+			Type:               "turn_timeout",
+			Message:            "Shot clock ran out",
+			Rule:               "shot_clock",
+			FirstContactBallID: -1,
+		},
+		Player1Group: g.Player1.BallGroup,
+		Player2Group: g.Player2.BallGroup,
+	}
+	g.LastActivity = now
+
+	if player.TurnTimeouts >= turnTimeoutForfeitLimit() {
+		g.Winner = opponent.ID
+		g.WinType = "timeout"
+		g.Status = StatusCompleted
+		g.CompletedAt = &now
+		g.TurnDeadline = nil
+		g.TurnClockPaused = 0
+		result.GameOver = true
+		result.Winner = g.Winner
+		result.WinType = g.WinType
+
+		log.Printf("[POOL] Game %s: %s forfeits after %d shot clock timeouts", g.ID, player.ID, player.TurnTimeouts)
+		g.recordActionLocked("turn_timeout", player.ID, shotResultDetail(result), nil)
+		if Manager != nil {
+			if dbPlayerID > 0 {
+				Manager.RecordMove(g.SessionID, dbPlayerID, "TIMEOUT_FORFEIT")
+			}
+			Manager.SaveFinalGameState(g)
+		}
+		return result, nil
+	}
+
+	g.switchTurn()
+	g.BallInHand = true
+	g.BallInHandPlayer = g.CurrentTurn
+	g.startTurnClockLocked(now)
+	result.TurnChange = true
+	result.NextTurn = g.CurrentTurn
+	result.BallInHand = true
+
+	log.Printf("[POOL] Game %s: shot clock ran out for %s (%d in a row), ball in hand to %s",
+		g.ID, player.ID, player.TurnTimeouts, g.CurrentTurn)
+	g.recordActionLocked("turn_timeout", player.ID, shotResultDetail(result), nil)
+	if Manager != nil && dbPlayerID > 0 {
+		Manager.RecordMove(g.SessionID, dbPlayerID, "TURN_TIMEOUT")
+	}
+	return result, nil
+}
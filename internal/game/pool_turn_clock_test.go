@@ -0,0 +1,139 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+// withTurnClock installs a manager configured with a shot clock for the duration of the test
+func withTurnClock(t *testing.T, seconds, forfeitAfter int) {
+	t.Helper()
+	prev := Manager
+	Manager = NewGameManager(nil, nil, &config.Config{PoolTurnSeconds: seconds, PoolTurnTimeoutForfeit: forfeitAfter})
+	t.Cleanup(func() { Manager = prev })
+}
+
+// clockedPoolGame returns a game with both players connected and the shot clock running
+func clockedPoolGame(t *testing.T) *PoolGameState {
+	t.Helper()
+	g := newTestPoolGame(t)
+	g.SetPlayerConnected("p1", true)
+	g.SetPlayerConnected("p2", true)
+	if g.TurnDeadline == nil {
+		t.Fatal("shot clock not running with both players connected")
+	}
+	return g
+}
+
+func TestTurnTimeoutIsFoulWithBallInHand(t *testing.T) {
+	withTurnClock(t, 30, 3)
+	g := clockedPoolGame(t)
+
+	if _, err := g.ApplyTurnTimeout(time.Now()); err == nil {
+		t.Fatal("timeout applied before the clock ran out")
+	}
+
+	result, err := g.ApplyTurnTimeout(g.TurnDeadline.Add(time.Second))
+	if err != nil {
+		t.Fatalf("ApplyTurnTimeout: %v", err)
+	}
+	if result.Foul == nil || result.Foul.Type != "turn_timeout" || result.GameOver {
+		t.Fatalf("expected a timeout foul, got %+v", result)
+	}
+	if g.CurrentTurn != "p2" || !g.BallInHand || g.BallInHandPlayer != "p2" {
+		t.Fatalf("turn=%s bih=%v/%q", g.CurrentTurn, g.BallInHand, g.BallInHandPlayer)
+	}
+	if g.Player1.TurnTimeouts != 1 {
+		t.Errorf("p1 timeouts = %d", g.Player1.TurnTimeouts)
+	}
+	if _, left, running := g.TurnClock(time.Now()); !running || left < 29 {
+		t.Errorf("p2 should get a fresh clock: running=%v left=%d", running, left)
+	}
+}
+
+func TestRepeatedTurnTimeoutsForfeit(t *testing.T) {
+	withTurnClock(t, 30, 2)
+	g := clockedPoolGame(t)
+
+	timeout := func() *ShotResult {
+		t.Helper()
+		result, err := g.ApplyTurnTimeout(g.TurnDeadline.Add(time.Second))
+		if err != nil {
+			t.Fatalf("ApplyTurnTimeout: %v", err)
+		}
+		return result
+	}
+
+	timeout() // p1
+	timeout() // p2
+	if g.Status != StatusInProgress {
+		t.Fatal("non-consecutive timeouts should not forfeit")
+	}
+
+	// p1 shoots in between: their streak resets
+	g.SetShotInProgress("p1", ShotParams{Power: 1000})
+	if _, err := g.ApplyShotResult("p1", shotData(g, 1, true)); err != nil {
+		t.Fatalf("ApplyShotResult: %v", err)
+	}
+	if g.Player1.TurnTimeouts != 0 {
+		t.Fatalf("p1 timeouts not reset after a shot: %d", g.Player1.TurnTimeouts)
+	}
+
+	// p2 is on turn with one timeout already; a second forfeits
+	if g.CurrentTurn != "p2" {
+		t.Fatalf("turn = %s", g.CurrentTurn)
+	}
+	result := timeout()
+	if !result.GameOver || result.WinType != "timeout" || result.Winner != "p1" {
+		t.Fatalf("expected p2 to forfeit, got %+v", result)
+	}
+	if g.Status != StatusCompleted || g.TurnDeadline != nil {
+		t.Fatalf("status=%s deadline=%v", g.Status, g.TurnDeadline)
+	}
+}
+
+func TestTurnClockPausesWhileDisconnected(t *testing.T) {
+	withTurnClock(t, 30, 3)
+	g := clockedPoolGame(t)
+
+	g.SetPlayerDisconnected("p2")
+	if _, _, running := g.TurnClock(time.Now()); running {
+		t.Fatal("clock kept running with a player disconnected")
+	}
+	if _, err := g.ApplyTurnTimeout(time.Now().Add(time.Hour)); err == nil {
+		t.Fatal("timeout applied while paused")
+	}
+
+	g.SetPlayerConnected("p2", true)
+	if _, left, running := g.TurnClock(time.Now()); !running || left < 29 {
+		t.Fatalf("clock did not resume with the banked time: running=%v left=%d", running, left)
+	}
+}
+
+func TestTurnClockSurvivesReload(t *testing.T) {
+	withTurnClock(t, 30, 3)
+	g := clockedPoolGame(t)
+	deadline := time.Now().Add(12 * time.Second)
+	g.TurnDeadline = &deadline
+
+	data, err := encodePoolGame(g)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	restored, err := loadPoolGameFromRedis(data)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	// Players come back disconnected, so the remaining time is banked until they reconnect
+	if restored.TurnDeadline != nil || restored.TurnClockPaused < 10*time.Second || restored.TurnClockPaused > 12*time.Second {
+		t.Fatalf("deadline=%v paused=%v", restored.TurnDeadline, restored.TurnClockPaused)
+	}
+	restored.SetPlayerConnected("p1", true)
+	restored.SetPlayerConnected("p2", true)
+	if _, left, running := restored.TurnClock(time.Now()); !running || left > 12 {
+		t.Fatalf("clock after reconnect: running=%v left=%d", running, left)
+	}
+}
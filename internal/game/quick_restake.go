@@ -0,0 +1,30 @@
+package game
+
+import (
+	"github.com/playpool/backend/internal/accounts"
+)
+
+// QuickRestake is a one-tap offer to stake again at the same amount, paid from winnings
+type QuickRestake struct {
+	StakeAmount int     `json:"stake_amount"`
+	Commission  int     `json:"commission"`
+	Balance     float64 `json:"winnings_balance"`
+}
+
+// QuickRestakeFor returns the restake offer for a winner at stake, or false when quick restake
+// is off or their winnings (the payout just credited plus any remainder) don't cover the stake
+// and its commission. Held payouts aren't credited yet, so they aren't offered.
+func (gm *GameManager) QuickRestakeFor(playerDBID, stake int) (QuickRestake, bool) {
+	if gm == nil || gm.db == nil || gm.config == nil || !gm.config.QuickRestakeEnabled || playerDBID <= 0 {
+		return QuickRestake{}, false
+	}
+	acc, err := accounts.GetOrCreateAccount(gm.db, accounts.AccountPlayerWinnings, &playerDBID)
+	if err != nil {
+		return QuickRestake{}, false
+	}
+	commission := gm.config.Commission(stake)
+	if acc.Balance < float64(stake+commission) {
+		return QuickRestake{}, false
+	}
+	return QuickRestake{StakeAmount: stake, Commission: commission, Balance: acc.Balance}, true
+}
@@ -0,0 +1,49 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestQuickRestakeOnlyWhenWinningsCoverStake(t *testing.T) {
+	db := testDB(t)
+	gm := NewGameManager(db, nil, &config.Config{QuickRestakeEnabled: true, CommissionFlat: 1000})
+
+	var pid int
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if err := db.Get(&pid, `INSERT INTO players (phone_number, display_name) VALUES ($1, 'Restaker') RETURNING id`, phone); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+	acc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
+	if err != nil {
+		t.Fatalf("winnings account: %v", err)
+	}
+	fund := func(balance float64) {
+		if _, err := db.Exec(`UPDATE accounts SET balance=$1 WHERE id=$2`, balance, acc.ID); err != nil {
+			t.Fatalf("fund winnings: %v", err)
+		}
+	}
+
+	// A 5000 win leaves 9000 net winnings plus a 500 remainder: another 5000 game costs 6000
+	fund(9500)
+	offer, ok := gm.QuickRestakeFor(pid, 5000)
+	if !ok || offer.StakeAmount != 5000 || offer.Commission != 1000 || offer.Balance != 9500 {
+		t.Fatalf("offer %+v ok %v, want 5000 stake + 1000 commission from 9500", offer, ok)
+	}
+
+	// Stake covered but not its commission
+	fund(5999)
+	if _, ok := gm.QuickRestakeFor(pid, 5000); ok {
+		t.Fatal("offered a restake the winnings can't cover")
+	}
+
+	fund(6000)
+	gm.config.QuickRestakeEnabled = false
+	if _, ok := gm.QuickRestakeFor(pid, 5000); ok {
+		t.Fatal("offered a restake with quick restake off")
+	}
+}
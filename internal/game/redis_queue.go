@@ -0,0 +1,117 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+)
+
+// queueKey is the Redis sorted set of queue ids waiting at stake, scored by join time
+// (matchmaking_queue.created_at in unix nanos) so the oldest is always matched first and
+// expiry or cancellation removes an id in O(log n)
+func queueKey(stake int) string {
+	return fmt.Sprintf("queue:zset:stake:%d", stake)
+}
+
+// legacyQueuePrefix is the prefix of the per-stake lists the queue used before the sorted sets
+const legacyQueuePrefix = "queue:stake:"
+
+// joinScores returns the score for each id: its row's created_at, or now for ids without a row
+func (gm *GameManager) joinScores(ids []int) map[int]float64 {
+	now := float64(time.Now().UnixNano())
+	scores := make(map[int]float64, len(ids))
+	for _, id := range ids {
+		scores[id] = now
+	}
+	if gm.db == nil || len(ids) == 0 {
+		return scores
+	}
+	var rows []struct {
+		ID        int       `db:"id"`
+		CreatedAt time.Time `db:"created_at"`
+	}
+	if err := gm.db.Select(&rows, `SELECT id, created_at FROM matchmaking_queue WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		log.Printf("[MATCH] Failed to load join times, queueing at now: %v", err)
+		return scores
+	}
+	for _, r := range rows {
+		scores[r.ID] = float64(r.CreatedAt.UnixNano())
+	}
+	return scores
+}
+
+// enqueueIDs adds ids to stake's queue at their join time. An id already waiting keeps its place.
+func (gm *GameManager) enqueueIDs(ctx context.Context, stake int, ids ...int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	scores := gm.joinScores(ids)
+	members := make([]redis.Z, 0, len(ids))
+	for _, id := range ids {
+		members = append(members, redis.Z{Score: scores[id], Member: id})
+	}
+	return gm.rdb.ZAddNX(ctx, queueKey(stake), members...).Err()
+}
+
+// queueLen returns how many ids wait at stake
+func (gm *GameManager) queueLen(ctx context.Context, stake int) (int64, error) {
+	return gm.rdb.ZCard(ctx, queueKey(stake)).Result()
+}
+
+// popOldest removes and returns the longest-waiting id at stake (0 if none)
+func (gm *GameManager) popOldest(ctx context.Context, stake int) (int, error) {
+	z, err := gm.rdb.ZPopMin(ctx, queueKey(stake), 1).Result()
+	if err != nil || len(z) == 0 {
+		return 0, err
+	}
+	return strconv.Atoi(fmt.Sprint(z[0].Member))
+}
+
+// MigrateQueueLists drains the per-stake Redis lists left by older versions into the sorted
+// set queues, scored by each row's join time. It runs at startup before the queue is
+// rehydrated and returns how many ids it moved; stale ids are dropped later by matching.
+func (gm *GameManager) MigrateQueueLists() (int, error) {
+	if gm.rdb == nil {
+		return 0, nil
+	}
+	ctx := context.Background()
+	moved := 0
+	iter := gm.rdb.Scan(ctx, 0, legacyQueuePrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		stake, err := strconv.Atoi(strings.TrimPrefix(key, legacyQueuePrefix))
+		if err != nil {
+			continue
+		}
+
+		// Read and delete together so an id pushed by an old instance meanwhile is not lost
+		var items *redis.StringSliceCmd
+		if _, err := gm.rdb.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			items = p.LRange(ctx, key, 0, -1)
+			p.Del(ctx, key)
+			return nil
+		}); err != nil {
+			log.Printf("[MIGRATE] Failed to drain %s: %v", key, err)
+			continue
+		}
+
+		ids := make([]int, 0, len(items.Val()))
+		for _, s := range items.Val() {
+			if id, err := strconv.Atoi(s); err == nil {
+				ids = append(ids, id)
+			}
+		}
+		if err := gm.enqueueIDs(ctx, stake, ids...); err != nil {
+			return moved, fmt.Errorf("queue %d ids from %s: %w", len(ids), key, err)
+		}
+		moved += len(ids)
+		log.Printf("[MIGRATE] Moved %d queued ids from list %s to %s", len(ids), key, queueKey(stake))
+	}
+	return moved, iter.Err()
+}
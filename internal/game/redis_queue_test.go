@@ -0,0 +1,105 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// queueFixture returns a manager and a stake nobody else queues at, with its Redis keys
+// removed when the test ends, and a helper inserting a queued row that joined `ago` back
+func queueFixture(t *testing.T, db *sqlx.DB, rdb *redis.Client) (*GameManager, int, func(ago time.Duration) int) {
+	t.Helper()
+	ctx := context.Background()
+	gm := NewGameManager(db, rdb, &config.Config{})
+	stake := 600000 + int(time.Now().UnixNano()%90000)
+	t.Cleanup(func() {
+		rdb.Del(ctx, queueKey(stake), legacyQueuePrefix+fmt.Sprint(stake),
+			fmt.Sprintf("processing:stake:%d", stake), fmt.Sprintf("processing_ts:stake:%d", stake))
+	})
+	joined := func(ago time.Duration) int {
+		var id int
+		if err := db.Get(&id, `INSERT INTO matchmaking_queue (phone_number, stake_amount, status, created_at, expires_at)
+			VALUES ('256700000001', $1, 'queued', $2, NOW() + INTERVAL '10 minutes') RETURNING id`, stake, time.Now().Add(-ago)); err != nil {
+			t.Fatalf("insert queue: %v", err)
+		}
+		return id
+	}
+	return gm, stake, joined
+}
+
+func TestRedisQueueMatchesOldestFirst(t *testing.T) {
+	db := testDB(t)
+	rdb := testRedis(t)
+	ctx := context.Background()
+	gm, stake, joined := queueFixture(t, db, rdb)
+
+	newest, oldest, middle := joined(time.Second), joined(3*time.Minute), joined(time.Minute)
+	// Pushed out of join order, as after a restart or a requeue
+	for _, id := range []int{newest, oldest, middle} {
+		if err := gm.enqueueIDs(ctx, stake, id); err != nil {
+			t.Fatalf("enqueue %d: %v", id, err)
+		}
+	}
+	// Pushing a waiting id again does not move it to the back
+	gm.enqueueIDs(ctx, stake, oldest)
+
+	for _, want := range []int{oldest, middle, newest} {
+		got, err := gm.claimJobFromRedis(stake)
+		if err != nil || got != want {
+			t.Fatalf("claimed %d (err %v), want %d", got, err, want)
+		}
+	}
+	if got, _ := gm.claimJobFromRedis(stake); got != 0 {
+		t.Fatalf("claimed %d from an empty queue", got)
+	}
+}
+
+func TestExpiredEntryLeavesQueueInOrder(t *testing.T) {
+	db := testDB(t)
+	rdb := testRedis(t)
+	ctx := context.Background()
+	gm, stake, joined := queueFixture(t, db, rdb)
+
+	first, second, third := joined(3*time.Minute), joined(2*time.Minute), joined(time.Minute)
+	gm.enqueueIDs(ctx, stake, first, second, third)
+	if _, err := db.Exec(`UPDATE matchmaking_queue SET expires_at = NOW() - INTERVAL '1 second' WHERE id=$1`, second); err != nil {
+		t.Fatalf("expire row: %v", err)
+	}
+	if _, err := gm.ExpireQueuedEntries(); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+
+	items, _ := rdb.ZRange(ctx, queueKey(stake), 0, -1).Result()
+	if want := []string{fmt.Sprint(first), fmt.Sprint(third)}; fmt.Sprint(items) != fmt.Sprint(want) {
+		t.Fatalf("queue = %v, want %v", items, want)
+	}
+}
+
+func TestMigrateQueueListsKeepsJoinOrder(t *testing.T) {
+	db := testDB(t)
+	rdb := testRedis(t)
+	ctx := context.Background()
+	gm, stake, joined := queueFixture(t, db, rdb)
+
+	older, newer := joined(2*time.Minute), joined(time.Minute)
+	// The old code LPUSHed arrivals and RPOPped the oldest from the tail
+	legacy := legacyQueuePrefix + fmt.Sprint(stake)
+	rdb.LPush(ctx, legacy, older, newer)
+
+	if n, err := gm.MigrateQueueLists(); err != nil || n < 2 {
+		t.Fatalf("migrate: moved %d, err %v", n, err)
+	}
+	if n, _ := rdb.Exists(ctx, legacy).Result(); n != 0 {
+		t.Fatal("legacy list still present after migration")
+	}
+	items, _ := rdb.ZRange(ctx, queueKey(stake), 0, -1).Result()
+	if want := []string{fmt.Sprint(older), fmt.Sprint(newer)}; fmt.Sprint(items) != fmt.Sprint(want) {
+		t.Fatalf("queue = %v, want %v", items, want)
+	}
+}
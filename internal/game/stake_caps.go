@@ -0,0 +1,114 @@
+package game
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+// stakeTier returns the tier stake falls in from caps (lowest stake of tier -> max live games):
+// its stake range [lo, hi) with hi 0 for the top tier, and the limit (0 = uncapped).
+func stakeTier(caps map[int]int, stake int) (lo, hi, limit int) {
+	floors := make([]int, 0, len(caps))
+	for floor := range caps {
+		floors = append(floors, floor)
+	}
+	sort.Ints(floors)
+	for i, floor := range floors {
+		if stake < floor {
+			break
+		}
+		lo, limit = floor, caps[floor]
+		if i+1 < len(floors) {
+			hi = floors[i+1]
+		} else {
+			hi = 0
+		}
+	}
+	return lo, hi, limit
+}
+
+// connectTimeout is how long a matched game at stake may wait for both players to connect
+// before it is cancelled and refunded: its tier's override, else GameExpiryMinutes. High
+// stakes can be given less time so large escrow isn't held for no-shows.
+func connectTimeout(cfg *config.Config, stake int) time.Duration {
+	if _, _, seconds := stakeTier(cfg.StakeTierConnectSeconds, stake); seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return time.Duration(cfg.GameExpiryMinutes) * time.Minute
+}
+
+// tierAtCapacity reports whether the stake's tier already has its maximum of live games
+func (gm *GameManager) tierAtCapacity(stake int) bool {
+	lo, hi, limit := stakeTier(gm.config.StakeTierMaxGames, stake)
+	if limit <= 0 {
+		return false
+	}
+	gm.mu.RLock()
+	defer gm.mu.RUnlock()
+	live := 0
+	for _, game := range gm.games {
+		if game.StakeAmount < lo || (hi > 0 && game.StakeAmount >= hi) {
+			continue
+		}
+		if game.Status == StatusWaiting || game.Status == StatusInProgress {
+			live++
+		}
+	}
+	return live >= limit
+}
+
+// resumeCappedTier matches players who queued while stake's tier was full, now that a game
+// in it has finished. Players are paired oldest first until the tier is full again.
+func (gm *GameManager) resumeCappedTier(stake int) {
+	if gm.rdb == nil || gm.db == nil {
+		return
+	}
+	lo, hi, limit := stakeTier(gm.config.StakeTierMaxGames, stake)
+	if limit <= 0 {
+		return
+	}
+
+	var stakes []float64
+	if err := gm.db.Select(&stakes, `SELECT DISTINCT stake_amount FROM matchmaking_queue WHERE status='queued' AND is_private = FALSE AND stake_amount >= $1 AND ($2 = 0 OR stake_amount < $2) ORDER BY stake_amount`, lo, hi); err != nil {
+		log.Printf("[MATCH] Failed to list queued stakes for tier %d: %v", lo, err)
+		return
+	}
+
+	ctx := context.Background()
+	for _, s := range stakes {
+		for !gm.tierAtCapacity(int(s)) {
+			if n, err := gm.queueLen(ctx, int(s)); err != nil || n < 2 {
+				break
+			}
+			// The oldest waiting player takes the matching turn it was denied
+			id, err := gm.popOldest(ctx, int(s))
+			if err != nil || id == 0 {
+				break
+			}
+			var me struct {
+				Phone       string `db:"phone_number"`
+				PlayerID    int    `db:"player_id"`
+				DisplayName string `db:"display_name"`
+			}
+			if err := gm.db.Get(&me, `SELECT q.phone_number, COALESCE(q.player_id, 0) AS player_id, COALESCE(p.display_name, '') AS display_name
+				FROM matchmaking_queue q LEFT JOIN players p ON p.id = q.player_id WHERE q.id=$1 AND q.status='queued'`, id); err != nil {
+				// Stale id (cancelled or expired meanwhile): drop it and try the next
+				continue
+			}
+			res, err := gm.TryMatchFromRedis(ctx, int(s), id, me.Phone, me.PlayerID, me.DisplayName)
+			if err != nil {
+				log.Printf("[MATCH] Resume at stake %d failed for queue id %d: %v", int(s), id, err)
+				break
+			}
+			if res == nil {
+				// Requeued itself: no usable opponent left at this stake
+				break
+			}
+			log.Printf("[MATCH] Tier %d slot freed: matched queue id %d into game %s", lo, id, res.GameID)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestStakeTier(t *testing.T) {
+	caps := map[int]int{50000: 10, 200000: 3}
+	cases := []struct{ stake, lo, hi, limit int }{
+		{1000, 0, 0, 0},
+		{50000, 50000, 200000, 10},
+		{199999, 50000, 200000, 10},
+		{200000, 200000, 0, 3},
+		{5000000, 200000, 0, 3},
+	}
+	for _, tc := range cases {
+		lo, hi, limit := stakeTier(caps, tc.stake)
+		if lo != tc.lo || hi != tc.hi || limit != tc.limit {
+			t.Errorf("stake %d: tier [%d,%d) limit %d, want [%d,%d) limit %d", tc.stake, lo, hi, limit, tc.lo, tc.hi, tc.limit)
+		}
+	}
+}
+
+func TestTierCapacityCountsLiveGamesInTier(t *testing.T) {
+	gm := NewGameManager(nil, nil, &config.Config{StakeTierMaxGames: map[int]int{50000: 2}})
+
+	g1, _ := gm.CreateTestPoolGame("256700000001", "256700000002", 50000, false)
+	gm.CreateTestPoolGame("256700000003", "256700000004", 1000, false) // untiered, never counts
+	if gm.tierAtCapacity(80000) {
+		t.Fatal("tier full with one of two games live")
+	}
+	gm.CreateTestPoolGame("256700000005", "256700000006", 120000, false)
+	if !gm.tierAtCapacity(80000) {
+		t.Fatal("tier not full with two of two games live")
+	}
+	if gm.tierAtCapacity(1000) {
+		t.Fatal("untiered stake reported at capacity")
+	}
+
+	gm.mu.Lock()
+	g1.Status = StatusCompleted
+	gm.mu.Unlock()
+	if gm.tierAtCapacity(80000) {
+		t.Fatal("completed game still holds a slot")
+	}
+}
+
+func TestCappedTierQueuesUntilSlotFrees(t *testing.T) {
+	db := testDB(t)
+	rdb := testRedis(t)
+	ctx := context.Background()
+
+	stake := 700000 + int(time.Now().UnixNano()%90000)
+	gm := NewGameManager(db, rdb, &config.Config{StakeTierMaxGames: map[int]int{stake: 1}})
+	key := queueKey(stake)
+	t.Cleanup(func() {
+		rdb.Del(ctx, key, fmt.Sprintf("processing:stake:%d", stake), fmt.Sprintf("processing_ts:stake:%d", stake))
+	})
+
+	queued := func(n int) (int, int) {
+		var pid, qid int
+		phone := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+int64(n))%100000000)
+		if err := db.Get(&pid, `INSERT INTO players (phone_number, display_name) VALUES ($1, $2) RETURNING id`, phone, fmt.Sprintf("Cap%d", n)); err != nil {
+			t.Fatalf("insert player: %v", err)
+		}
+		acc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
+		if err != nil {
+			t.Fatalf("winnings account: %v", err)
+		}
+		if _, err := db.Exec(`UPDATE accounts SET balance=$1 WHERE id=$2`, stake, acc.ID); err != nil {
+			t.Fatalf("fund winnings: %v", err)
+		}
+		if err := db.Get(&qid, `INSERT INTO matchmaking_queue (player_id, phone_number, stake_amount, queue_token, status, created_at, expires_at)
+			VALUES ($1, $2, $3, $4, 'queued', NOW(), NOW() + INTERVAL '10 minutes') RETURNING id`, pid, phone, stake, fmt.Sprintf("cap-%d-%d", n, time.Now().UnixNano())); err != nil {
+			t.Fatalf("insert queue: %v", err)
+		}
+		return pid, qid
+	}
+	status := func(qid int) string {
+		var s string
+		db.Get(&s, `SELECT status FROM matchmaking_queue WHERE id=$1`, qid)
+		return s
+	}
+
+	// The tier's only slot is taken by a live game
+	busy, err := gm.CreateTestPoolGame("256700000001", "256700000002", stake, false)
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+
+	_, waitingQ := queued(1)
+	gm.enqueueIDs(ctx, stake, waitingQ)
+	pid, myQ := queued(2)
+	res, err := gm.TryMatchFromRedis(ctx, stake, myQ, "256700000009", pid, "Cap2")
+	if err != nil || res != nil {
+		t.Fatalf("match at capacity: result %v err %v, want queued", res, err)
+	}
+	if n, _ := rdb.ZCard(ctx, key).Result(); n != 2 || status(waitingQ) != "queued" || status(myQ) != "queued" {
+		t.Fatalf("at capacity: redis len %d, statuses %s/%s, want both queued", n, status(waitingQ), status(myQ))
+	}
+
+	// The game finishing frees the slot and pairs the two waiting players
+	gm.mu.Lock()
+	busy.Status = StatusCompleted
+	gm.mu.Unlock()
+	gm.resumeCappedTier(stake)
+
+	if status(waitingQ) != "matched" || status(myQ) != "matched" {
+		t.Fatalf("after slot freed: statuses %s/%s, want matched", status(waitingQ), status(myQ))
+	}
+	if !gm.tierAtCapacity(stake) {
+		t.Fatal("resumed match did not take the freed slot")
+	}
+}
+
+func TestHighStakeWaitingGameCancelsSooner(t *testing.T) {
+	prev := Manager
+	t.Cleanup(func() { Manager = prev })
+	gm := NewGameManager(nil, nil, &config.Config{GameExpiryMinutes: 3, StakeTierConnectSeconds: map[int]int{200000: 60}})
+	Manager = gm
+
+	low := NewPoolGame("g-low", "tok-low", "a1", "256700000001", "t1", 0, "A1", "a2", "256700000002", "t2", 0, "A2", 1000)
+	high := NewPoolGame("g-high", "tok-high", "b1", "256700000003", "t3", 0, "B1", "b2", "256700000004", "t4", 0, "B2", 500000)
+	if !high.ExpiresAt.Before(low.ExpiresAt) {
+		t.Fatalf("high stake expires %v, not before low stake %v", high.ExpiresAt, low.ExpiresAt)
+	}
+
+	// Two minutes on, neither player of either game has connected
+	for _, g := range []*PoolGameState{low, high} {
+		g.ExpiresAt = g.ExpiresAt.Add(-2 * time.Minute)
+		gm.games[g.ID] = g
+	}
+	gm.checkExpiredGames()
+	if high.Status != StatusCancelled {
+		t.Errorf("high-stake game status %s, want cancelled", high.Status)
+	}
+	if low.Status != StatusWaiting {
+		t.Errorf("low-stake game status %s, want still waiting", low.Status)
+	}
+}
@@ -1,5 +1,7 @@
 package game
 
+import "errors"
+
 // GameStatus represents the current state of the game
 type GameStatus string
 
@@ -9,3 +11,15 @@ const (
 	StatusCompleted  GameStatus = "COMPLETED"
 	StatusCancelled  GameStatus = "CANCELLED"
 )
+
+// ErrUnknownGameStatus is returned when a stored game carries a status outside the known set
+var ErrUnknownGameStatus = errors.New("unknown game status")
+
+// Valid reports whether s is one of the known statuses
+func (s GameStatus) Valid() bool {
+	switch s {
+	case StatusWaiting, StatusInProgress, StatusCompleted, StatusCancelled:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,476 @@
+package game
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
+)
+
+// Tournament statuses
+const (
+	TournamentRegistering = "REGISTERING"
+	TournamentInProgress  = "IN_PROGRESS"
+	TournamentCompleted   = "COMPLETED"
+)
+
+// TournamentMaxPlayers bounds the bracket size (six rounds)
+const TournamentMaxPlayers = 64
+
+var (
+	ErrTournamentNotFound      = errors.New("tournament not found")
+	ErrTournamentNotOpen       = errors.New("tournament is not open for registration")
+	ErrTournamentAlreadyJoined = errors.New("already registered for this tournament")
+	ErrInsufficientWinnings    = errors.New("insufficient winnings balance for the entry fee")
+)
+
+// Tournament is a tournaments row
+type Tournament struct {
+	ID          int        `db:"id" json:"id"`
+	Name        string     `db:"name" json:"name"`
+	EntryFee    float64    `db:"entry_fee" json:"entry_fee"`
+	MaxPlayers  int        `db:"max_players" json:"max_players"`
+	Status      string     `db:"status" json:"status"`
+	ChampionID  *int       `db:"champion_id" json:"champion_id,omitempty"`
+	RunnerUpID  *int       `db:"runner_up_id" json:"runner_up_id,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	StartedAt   *time.Time `db:"started_at" json:"started_at,omitempty"`
+	CompletedAt *time.Time `db:"completed_at" json:"completed_at,omitempty"`
+}
+
+// TournamentEntrant is a registered player
+type TournamentEntrant struct {
+	PlayerID    int    `db:"player_id" json:"player_id"`
+	Seed        int    `db:"seed" json:"seed"`
+	DisplayName string `db:"display_name" json:"display_name"`
+	PhoneNumber string `db:"phone_number" json:"-"`
+}
+
+// TournamentManager runs single-elimination tournaments on top of ordinary game sessions.
+// Bracket games carry no stake; entry fees sit in escrow until the final pays the finalists.
+type TournamentManager struct {
+	db *sqlx.DB
+	gm *GameManager
+}
+
+// Tournaments is the global tournament manager (set up by InitializeManager)
+var Tournaments *TournamentManager
+
+// NewTournamentManager creates a tournament manager and hooks it into gm's session results
+func NewTournamentManager(db *sqlx.DB, gm *GameManager) *TournamentManager {
+	tm := &TournamentManager{db: db, gm: gm}
+	gm.tournaments = tm
+	return tm
+}
+
+const tournamentColumns = `id, name, entry_fee, max_players, status, champion_id, runner_up_id, created_at, started_at, completed_at`
+
+// Create opens a tournament for registration. It starts once maxPlayers have joined;
+// a maxPlayers that is not a power of two gives the top seeds first-round byes.
+func (tm *TournamentManager) Create(name string, entryFee, maxPlayers, createdBy int) (*Tournament, error) {
+	var t Tournament
+	err := tm.db.Get(&t, `INSERT INTO tournaments (name, entry_fee, max_players, status, created_by) VALUES ($1,$2,$3,$4,$5) RETURNING `+tournamentColumns,
+		name, entryFee, maxPlayers, TournamentRegistering, nullableID(createdBy))
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[TOURNAMENT] Created %d %q: %d players, entry %d UGX", t.ID, name, maxPlayers, entryFee)
+	return &t, nil
+}
+
+// Get loads a tournament
+func (tm *TournamentManager) Get(id int) (*Tournament, error) {
+	var t Tournament
+	if err := tm.db.Get(&t, `SELECT `+tournamentColumns+` FROM tournaments WHERE id=$1`, id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTournamentNotFound
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Entrants lists the registered players in seed order
+func (tm *TournamentManager) Entrants(id int) ([]TournamentEntrant, error) {
+	return loadEntrants(tm.db, id)
+}
+
+func loadEntrants(q sqlx.Queryer, id int) ([]TournamentEntrant, error) {
+	var entrants []TournamentEntrant
+	err := sqlx.Select(q, &entrants, `
+		SELECT tp.player_id, tp.seed, COALESCE(p.display_name, '') AS display_name, p.phone_number
+		FROM tournament_players tp JOIN players p ON p.id = tp.player_id
+		WHERE tp.tournament_id=$1 ORDER BY tp.seed`, id)
+	return entrants, err
+}
+
+// Bracket loads the bracket of a started tournament (nil while registering)
+func (tm *TournamentManager) Bracket(id int) (*Bracket, error) {
+	return loadBracket(tm.db, id)
+}
+
+func loadBracket(q sqlx.Queryer, id int) (*Bracket, error) {
+	var rows []struct {
+		Round     int `db:"round"`
+		Slot      int `db:"slot"`
+		Player1ID int `db:"player1_id"`
+		Player2ID int `db:"player2_id"`
+		WinnerID  int `db:"winner_id"`
+		SessionID int `db:"session_id"`
+	}
+	err := sqlx.Select(q, &rows, `
+		SELECT round, slot, COALESCE(player1_id, 0) AS player1_id, COALESCE(player2_id, 0) AS player2_id,
+		       COALESCE(winner_id, 0) AS winner_id, COALESCE(session_id, 0) AS session_id
+		FROM tournament_matches WHERE tournament_id=$1 ORDER BY round, slot`, id)
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	matches := make([]BracketMatch, len(rows))
+	rounds := 0
+	for i, r := range rows {
+		matches[i] = BracketMatch{Round: r.Round, Slot: r.Slot, Player1ID: r.Player1ID, Player2ID: r.Player2ID, WinnerID: r.WinnerID, SessionID: r.SessionID}
+		if r.Round > rounds {
+			rounds = r.Round
+		}
+	}
+	return LoadBracket(rounds, matches)
+}
+
+// saveBracket writes every match of b (bracket slots are created once, then updated)
+func saveBracket(tx *sqlx.Tx, tournamentID int, b *Bracket) error {
+	for _, m := range b.All() {
+		_, err := tx.Exec(`
+			INSERT INTO tournament_matches (tournament_id, round, slot, player1_id, player2_id, winner_id, session_id)
+			VALUES ($1,$2,$3,$4,$5,$6,$7)
+			ON CONFLICT (tournament_id, round, slot) DO UPDATE
+			SET player1_id=EXCLUDED.player1_id, player2_id=EXCLUDED.player2_id, winner_id=EXCLUDED.winner_id, session_id=EXCLUDED.session_id`,
+			tournamentID, m.Round, m.Slot, nullableID(m.Player1ID), nullableID(m.Player2ID), nullableID(m.WinnerID), nullableID(m.SessionID))
+		if err != nil {
+			return fmt.Errorf("save match round %d slot %d: %w", m.Round, m.Slot, err)
+		}
+	}
+	return nil
+}
+
+func nullableID(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+// Join registers a player, moving the entry fee from their winnings into escrow.
+// The registration that fills the tournament seeds the bracket and starts round one.
+func (tm *TournamentManager) Join(tournamentID, playerID int) (*Tournament, error) {
+	tx, err := tm.db.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var t Tournament
+	if err := tx.Get(&t, `SELECT `+tournamentColumns+` FROM tournaments WHERE id=$1 FOR UPDATE`, tournamentID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTournamentNotFound
+		}
+		return nil, err
+	}
+	if t.Status != TournamentRegistering {
+		return nil, ErrTournamentNotOpen
+	}
+
+	var joined, registered int
+	if err := tx.Get(&joined, `SELECT COUNT(*) FROM tournament_players WHERE tournament_id=$1`, tournamentID); err != nil {
+		return nil, err
+	}
+	if err := tx.Get(&registered, `SELECT COUNT(*) FROM tournament_players WHERE tournament_id=$1 AND player_id=$2`, tournamentID, playerID); err != nil {
+		return nil, err
+	}
+	if registered > 0 {
+		return nil, ErrTournamentAlreadyJoined
+	}
+
+	// Entry fee: PLAYER_WINNINGS -> ESCROW
+	winningsAcc, err := accounts.GetOrCreateAccount(tm.db, accounts.AccountPlayerWinnings, &playerID)
+	if err != nil {
+		return nil, err
+	}
+	if winningsAcc.Balance < t.EntryFee {
+		return nil, ErrInsufficientWinnings
+	}
+	escrowAcc, err := accounts.GetOrCreateAccount(tm.db, accounts.AccountEscrow, nil)
+	if err != nil {
+		return nil, err
+	}
+	ref := sql.NullInt64{Int64: int64(tournamentID), Valid: true}
+	if err := accounts.Transfer(tx, winningsAcc.ID, escrowAcc.ID, t.EntryFee, "TOURNAMENT", ref, "Tournament entry fee", tm.gm.config.SettlementOverdraftGuard); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`INSERT INTO escrow_ledger (entry_type, player_id, amount, balance_after, description, created_at) VALUES ('TOURNAMENT_ENTRY',$1,$2,0.0,$3,NOW())`,
+		playerID, t.EntryFee, fmt.Sprintf("Entry to tournament %d", tournamentID)); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`INSERT INTO transactions (player_id, transaction_type, amount, status, created_at) VALUES ($1,'TOURNAMENT_ENTRY',$2,'COMPLETED',NOW())`, playerID, t.EntryFee); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`INSERT INTO tournament_players (tournament_id, player_id, seed) VALUES ($1,$2,$3)`, tournamentID, playerID, joined+1); err != nil {
+		return nil, err
+	}
+
+	var games []*tournamentGame
+	if joined+1 >= t.MaxPlayers {
+		if games, err = tm.start(tx, &t); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	log.Printf("[TOURNAMENT] Player %d joined tournament %d (%d/%d)", playerID, tournamentID, joined+1, t.MaxPlayers)
+	tm.launch(&t, games)
+	return &t, nil
+}
+
+// start seeds the bracket from the entrants (registration order) and creates round one's games
+func (tm *TournamentManager) start(tx *sqlx.Tx, t *Tournament) ([]*tournamentGame, error) {
+	entrants, err := loadEntrants(tx, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(entrants))
+	for i, e := range entrants {
+		ids[i] = e.PlayerID
+	}
+	b, err := NewBracket(ids)
+	if err != nil {
+		return nil, err
+	}
+	games, err := tm.createGames(tx, t, b)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveBracket(tx, t.ID, b); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(`UPDATE tournaments SET status=$1, started_at=NOW() WHERE id=$2`, TournamentInProgress, t.ID); err != nil {
+		return nil, err
+	}
+	t.Status = TournamentInProgress
+	log.Printf("[TOURNAMENT] Tournament %d started: %d players, %d rounds", t.ID, len(ids), b.Rounds)
+	return games, nil
+}
+
+// tournamentGame is a bracket game whose session row is written in the bracket transaction.
+// It joins the game manager (and its players are told) only after that commits.
+type tournamentGame struct {
+	game         *PoolGameState
+	round        int
+	phones       [2]string
+	playerTokens [2]string
+}
+
+// createGames opens a zero-stake game session for every playable match of b
+func (tm *TournamentManager) createGames(tx *sqlx.Tx, t *Tournament, b *Bracket) ([]*tournamentGame, error) {
+	var games []*tournamentGame
+	for _, m := range b.Playable() {
+		var p [2]struct {
+			ID          int    `db:"id"`
+			PhoneNumber string `db:"phone_number"`
+			DisplayName string `db:"display_name"`
+			Rating      int    `db:"elo_rating"`
+		}
+		for i, id := range []int{m.Player1ID, m.Player2ID} {
+			if err := tx.Get(&p[i], `SELECT id, phone_number, COALESCE(display_name, '') AS display_name, elo_rating FROM players WHERE id=$1`, id); err != nil {
+				return nil, fmt.Errorf("load player %d: %w", id, err)
+			}
+		}
+
+		tg := &tournamentGame{
+			round:        m.Round,
+			phones:       [2]string{p[0].PhoneNumber, p[1].PhoneNumber},
+			playerTokens: [2]string{generateToken(16), generateToken(16)},
+		}
+		tg.game = NewPoolGame(
+			generateGameID(), generateToken(16),
+			"player_"+p[0].PhoneNumber[len(p[0].PhoneNumber)-4:]+"_"+generateToken(4), p[0].PhoneNumber, tg.playerTokens[0], p[0].ID, p[0].DisplayName,
+			"player_"+p[1].PhoneNumber[len(p[1].PhoneNumber)-4:]+"_"+generateToken(4), p[1].PhoneNumber, tg.playerTokens[1], p[1].ID, p[1].DisplayName,
+			0,
+		)
+		tg.game.Player1.Rating, tg.game.Player2.Rating = p[0].Rating, p[1].Rating
+
+		var sessionID int
+		if err := tx.Get(&sessionID, `INSERT INTO game_sessions (game_token, player1_id, player2_id, stake_amount, status, created_at, expiry_time) VALUES ($1,$2,$3,0,$4,NOW(),$5) RETURNING id`,
+			tg.game.Token, p[0].ID, p[1].ID, string(StatusWaiting), tg.game.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("create session for round %d slot %d: %w", m.Round, m.Slot, err)
+		}
+		tg.game.SessionID = sessionID
+		m.SessionID = sessionID
+		games = append(games, tg)
+	}
+	return games, nil
+}
+
+// launch hands committed bracket games to the game manager and sends the players their links
+func (tm *TournamentManager) launch(t *Tournament, games []*tournamentGame) {
+	for _, tg := range games {
+		g := tg.game
+		tm.gm.mu.Lock()
+		tm.gm.games[g.ID] = g
+		tm.gm.playerToGame[g.Player1.ID] = g.ID
+		tm.gm.playerToGame[g.Player2.ID] = g.ID
+		tm.gm.mu.Unlock()
+		go g.SaveToRedis()
+
+		log.Printf("[TOURNAMENT] Tournament %d round %d game %s (session %d)", t.ID, tg.round, g.Token, g.SessionID)
+		if sms.Default == nil || tm.gm.config == nil {
+			continue
+		}
+		for i, phone := range tg.phones {
+			link := tm.gm.config.FrontendURL + "/g/" + g.Token + "?pt=" + tg.playerTokens[i]
+			sms.EnqueueTemplate(sms.TypeMatch, phone, templates.TournamentMatch,
+				templates.Params{"Tournament": t.Name, "Round": tg.round, "Link": link})
+		}
+	}
+}
+
+// SessionFinished advances the bracket when a tournament game ends. winnerDBID 0 (a draw,
+// or a game nobody turned up to) advances player 1. Sessions outside tournaments are ignored.
+func (tm *TournamentManager) SessionFinished(sessionID, winnerDBID int) {
+	if tm == nil || tm.db == nil || sessionID == 0 {
+		return
+	}
+	var tournamentID int
+	if err := tm.db.Get(&tournamentID, `SELECT tournament_id FROM tournament_matches WHERE session_id=$1`, sessionID); err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("[TOURNAMENT] Failed to look up session %d: %v", sessionID, err)
+		}
+		return
+	}
+	if err := tm.recordResult(tournamentID, sessionID, winnerDBID); err != nil {
+		log.Printf("[TOURNAMENT] Failed to record session %d in tournament %d: %v", sessionID, tournamentID, err)
+	}
+}
+
+func (tm *TournamentManager) recordResult(tournamentID, sessionID, winnerDBID int) error {
+	tx, err := tm.db.Beginx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var t Tournament
+	if err := tx.Get(&t, `SELECT `+tournamentColumns+` FROM tournaments WHERE id=$1 FOR UPDATE`, tournamentID); err != nil {
+		return err
+	}
+	b, err := loadBracket(tx, tournamentID)
+	if err != nil {
+		return err
+	}
+	m := b.MatchForSession(sessionID)
+	if m == nil || m.WinnerID != 0 {
+		return nil // already recorded
+	}
+	if err := b.RecordWinner(m.Round, m.Slot, winnerDBID); err != nil {
+		return err
+	}
+
+	games, err := tm.createGames(tx, &t, b)
+	if err != nil {
+		return err
+	}
+	if err := saveBracket(tx, tournamentID, b); err != nil {
+		return err
+	}
+	if champion := b.Champion(); champion != 0 {
+		if err := tm.payPrizes(tx, &t, champion, b.RunnerUp()); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	log.Printf("[TOURNAMENT] Tournament %d round %d slot %d won by player %d", tournamentID, m.Round, m.Slot, m.WinnerID)
+	// Callers hold the finished game's lock; registering games takes the manager lock, which
+	// other paths take before game locks, so do it off this goroutine
+	go tm.launch(&t, games)
+	return nil
+}
+
+// payPrizes pays the finalists from escrow and closes the tournament
+func (tm *TournamentManager) payPrizes(tx *sqlx.Tx, t *Tournament, championID, runnerUpID int) error {
+	var entrants int
+	if err := tx.Get(&entrants, `SELECT COUNT(*) FROM tournament_players WHERE tournament_id=$1`, t.ID); err != nil {
+		return err
+	}
+	platformPercent, runnerUpPercent := 0, 0
+	if tm.gm.config != nil {
+		platformPercent, runnerUpPercent = tm.gm.config.TournamentPlatformPercent, tm.gm.config.TournamentRunnerUpPercent
+	}
+	platformCut, championPrize, runnerUpPrize := TournamentPrizes(t.EntryFee, entrants, platformPercent, runnerUpPercent)
+
+	escrowAcc, err := accounts.GetOrCreateAccount(tm.db, accounts.AccountEscrow, nil)
+	if err != nil {
+		return err
+	}
+	platformAcc, err := accounts.GetOrCreateAccount(tm.db, accounts.AccountPlatform, nil)
+	if err != nil {
+		return err
+	}
+	ref := sql.NullInt64{Int64: int64(t.ID), Valid: true}
+	if platformCut > 0 {
+		if err := accounts.Transfer(tx, escrowAcc.ID, platformAcc.ID, platformCut, "TOURNAMENT", ref, "Tournament platform cut", tm.gm.config.SettlementOverdraftGuard); err != nil {
+			return err
+		}
+	}
+	for _, prize := range []struct {
+		playerID int
+		amount   float64
+		label    string
+	}{{championID, championPrize, "Tournament champion prize"}, {runnerUpID, runnerUpPrize, "Tournament runner-up prize"}} {
+		if prize.amount <= 0 {
+			continue
+		}
+		acc, err := accounts.GetOrCreateAccount(tm.db, accounts.AccountPlayerWinnings, &prize.playerID)
+		if err != nil {
+			return err
+		}
+		if err := accounts.Transfer(tx, escrowAcc.ID, acc.ID, prize.amount, "TOURNAMENT", ref, prize.label, tm.gm.config.SettlementOverdraftGuard); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO escrow_ledger (entry_type, player_id, amount, balance_after, description, created_at) VALUES ('TOURNAMENT_PRIZE',$1,$2,0.0,$3,NOW())`,
+			prize.playerID, prize.amount, fmt.Sprintf("%s (tournament %d)", prize.label, t.ID)); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE players SET total_winnings = total_winnings + $1 WHERE id = $2`, prize.amount, prize.playerID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE tournaments SET status=$1, champion_id=$2, runner_up_id=$3, completed_at=NOW() WHERE id=$4`,
+		TournamentCompleted, championID, nullableID(runnerUpID), t.ID); err != nil {
+		return err
+	}
+	t.Status = TournamentCompleted
+	log.Printf("[TOURNAMENT] Tournament %d won by player %d: champion %.2f, runner-up %.2f, platform %.2f UGX", t.ID, championID, championPrize, runnerUpPrize, platformCut)
+	return nil
+}
+
+// noShowWinner picks who advances from a tournament game that expired before it started:
+// the only player who turned up, or 0 if both or neither did.
+func noShowWinner(g *PoolGameState) int {
+	switch {
+	case g.Player1.ShowedUp && !g.Player2.ShowedUp:
+		return g.Player1.DBPlayerID
+	case g.Player2.ShowedUp && !g.Player1.ShowedUp:
+		return g.Player2.DBPlayerID
+	}
+	return 0
+}
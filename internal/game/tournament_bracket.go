@@ -0,0 +1,204 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// BracketMatch is one slot of a single-elimination bracket. Player ids are DB ids;
+// 0 is a bye (round 1) or a player not decided yet (later rounds).
+type BracketMatch struct {
+	Round     int `json:"round"` // 1 = first round
+	Slot      int `json:"slot"`  // position within the round, from 0
+	Player1ID int `json:"player1_id,omitempty"`
+	Player2ID int `json:"player2_id,omitempty"`
+	WinnerID  int `json:"winner_id,omitempty"`
+	SessionID int `json:"session_id,omitempty"`
+}
+
+// Bracket is a single-elimination bracket padded to a power of two with byes
+type Bracket struct {
+	Rounds  int
+	Matches [][]*BracketMatch // [round-1][slot]
+}
+
+// ErrBracketTooSmall is returned when fewer than two players are seeded
+var ErrBracketTooSmall = errors.New("a bracket needs at least two players")
+
+// bracketSeedOrder lists seeds (1 = best) in slot order for a bracket of size players,
+// so that the top seeds meet as late as possible: 4 -> [1 4 2 3], 8 -> [1 8 4 5 2 7 3 6].
+func bracketSeedOrder(size int) []int {
+	order := []int{1}
+	for len(order) < size {
+		next := make([]int, 0, len(order)*2)
+		for _, s := range order {
+			next = append(next, s, 2*len(order)+1-s)
+		}
+		order = next
+	}
+	return order
+}
+
+// NewBracket seeds playerIDs (best seed first) into a bracket. Seeds beyond the player count
+// are byes, which always fall against the top seeds; bye winners advance straight away.
+func NewBracket(playerIDs []int) (*Bracket, error) {
+	if len(playerIDs) < 2 {
+		return nil, ErrBracketTooSmall
+	}
+	size, rounds := 1, 0
+	for size < len(playerIDs) {
+		size *= 2
+		rounds++
+	}
+
+	b := emptyBracket(rounds)
+	order := bracketSeedOrder(size)
+	for slot, m := range b.Matches[0] {
+		m.Player1ID = seededPlayer(playerIDs, order[2*slot])
+		m.Player2ID = seededPlayer(playerIDs, order[2*slot+1])
+	}
+	for _, m := range b.Matches[0] {
+		if m.Player2ID == 0 {
+			b.advance(m, m.Player1ID)
+		} else if m.Player1ID == 0 {
+			b.advance(m, m.Player2ID)
+		}
+	}
+	return b, nil
+}
+
+// LoadBracket rebuilds a bracket from stored matches
+func LoadBracket(rounds int, matches []BracketMatch) (*Bracket, error) {
+	b := emptyBracket(rounds)
+	for _, m := range matches {
+		if m.Round < 1 || m.Round > rounds || m.Slot < 0 || m.Slot >= len(b.Matches[m.Round-1]) {
+			return nil, fmt.Errorf("match round %d slot %d outside a %d-round bracket", m.Round, m.Slot, rounds)
+		}
+		*b.Matches[m.Round-1][m.Slot] = m
+	}
+	return b, nil
+}
+
+func emptyBracket(rounds int) *Bracket {
+	b := &Bracket{Rounds: rounds, Matches: make([][]*BracketMatch, rounds)}
+	for r := 1; r <= rounds; r++ {
+		slots := 1 << (rounds - r)
+		b.Matches[r-1] = make([]*BracketMatch, slots)
+		for s := 0; s < slots; s++ {
+			b.Matches[r-1][s] = &BracketMatch{Round: r, Slot: s}
+		}
+	}
+	return b
+}
+
+func seededPlayer(playerIDs []int, seed int) int {
+	if seed > len(playerIDs) {
+		return 0
+	}
+	return playerIDs[seed-1]
+}
+
+// advance records winnerID for m and moves them into their next-round match
+func (b *Bracket) advance(m *BracketMatch, winnerID int) {
+	m.WinnerID = winnerID
+	if m.Round == b.Rounds {
+		return
+	}
+	next := b.Matches[m.Round][m.Slot/2]
+	if m.Slot%2 == 0 {
+		next.Player1ID = winnerID
+	} else {
+		next.Player2ID = winnerID
+	}
+}
+
+// Match returns the match at round/slot, or nil
+func (b *Bracket) Match(round, slot int) *BracketMatch {
+	if round < 1 || round > b.Rounds || slot < 0 || slot >= len(b.Matches[round-1]) {
+		return nil
+	}
+	return b.Matches[round-1][slot]
+}
+
+// MatchForSession returns the match played as sessionID, or nil
+func (b *Bracket) MatchForSession(sessionID int) *BracketMatch {
+	for _, m := range b.All() {
+		if m.SessionID == sessionID {
+			return m
+		}
+	}
+	return nil
+}
+
+// RecordWinner records the result of a played match. A winnerID of 0 (a draw, or neither
+// player turning up) advances player 1.
+func (b *Bracket) RecordWinner(round, slot, winnerID int) error {
+	m := b.Match(round, slot)
+	if m == nil {
+		return fmt.Errorf("no match at round %d slot %d", round, slot)
+	}
+	if m.WinnerID != 0 {
+		return fmt.Errorf("match at round %d slot %d already decided", round, slot)
+	}
+	if m.Player1ID == 0 || m.Player2ID == 0 {
+		return fmt.Errorf("match at round %d slot %d is not ready", round, slot)
+	}
+	if winnerID == 0 {
+		winnerID = m.Player1ID
+	}
+	if winnerID != m.Player1ID && winnerID != m.Player2ID {
+		return fmt.Errorf("player %d is not in match at round %d slot %d", winnerID, round, slot)
+	}
+	b.advance(m, winnerID)
+	return nil
+}
+
+// Playable returns the matches whose players are known but which have no game yet
+func (b *Bracket) Playable() []*BracketMatch {
+	var playable []*BracketMatch
+	for _, m := range b.All() {
+		if m.Player1ID != 0 && m.Player2ID != 0 && m.WinnerID == 0 && m.SessionID == 0 {
+			playable = append(playable, m)
+		}
+	}
+	return playable
+}
+
+// All returns every match, round by round
+func (b *Bracket) All() []*BracketMatch {
+	var all []*BracketMatch
+	for _, round := range b.Matches {
+		all = append(all, round...)
+	}
+	return all
+}
+
+// Champion returns the winner of the final, or 0 while it is undecided
+func (b *Bracket) Champion() int {
+	return b.Matches[b.Rounds-1][0].WinnerID
+}
+
+// RunnerUp returns the losing finalist, or 0 while the final is undecided
+func (b *Bracket) RunnerUp() int {
+	final := b.Matches[b.Rounds-1][0]
+	switch final.WinnerID {
+	case 0:
+		return 0
+	case final.Player1ID:
+		return final.Player2ID
+	default:
+		return final.Player1ID
+	}
+}
+
+// TournamentPrizes splits the entry fees of players: the platform keeps platformPercent,
+// the runner-up gets runnerUpPercent of the rest and the champion the remainder.
+func TournamentPrizes(entryFee float64, players, platformPercent, runnerUpPercent int) (platform, champion, runnerUp float64) {
+	pool := entryFee * float64(players)
+	platform = math.Round(pool*float64(platformPercent)) / 100
+	net := pool - platform
+	runnerUp = math.Round(net*float64(runnerUpPercent)) / 100
+	champion = net - runnerUp
+	return platform, champion, runnerUp
+}
@@ -0,0 +1,184 @@
+package game
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestBracketSeedOrder(t *testing.T) {
+	want := []int{1, 8, 4, 5, 2, 7, 3, 6}
+	got := bracketSeedOrder(8)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("bracketSeedOrder(8) = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestFourPlayerBracketResolvesToChampion(t *testing.T) {
+	b, err := NewBracket([]int{11, 22, 33, 44})
+	if err != nil {
+		t.Fatalf("NewBracket: %v", err)
+	}
+	if b.Rounds != 2 || len(b.Playable()) != 2 {
+		t.Fatalf("want 2 rounds with 2 playable semi-finals, got %d rounds, %d playable", b.Rounds, len(b.Playable()))
+	}
+	// Seeds 1v4 and 2v3 in the semi-finals
+	if m := b.Match(1, 0); m.Player1ID != 11 || m.Player2ID != 44 {
+		t.Errorf("semi 1 = %d v %d, want 11 v 44", m.Player1ID, m.Player2ID)
+	}
+
+	if err := b.RecordWinner(1, 0, 44); err != nil {
+		t.Fatalf("semi 1: %v", err)
+	}
+	if len(b.Playable()) != 1 || b.Champion() != 0 {
+		t.Fatal("final should wait for the second semi-final")
+	}
+	if err := b.RecordWinner(1, 1, 0); err != nil { // draw: player 1 advances
+		t.Fatalf("semi 2: %v", err)
+	}
+	final := b.Match(2, 0)
+	if final.Player1ID != 44 || final.Player2ID != 22 {
+		t.Fatalf("final = %d v %d, want 44 v 22", final.Player1ID, final.Player2ID)
+	}
+	if err := b.RecordWinner(2, 0, 33); err == nil {
+		t.Error("a player outside the match cannot win it")
+	}
+	if err := b.RecordWinner(2, 0, 22); err != nil {
+		t.Fatalf("final: %v", err)
+	}
+	if b.Champion() != 22 || b.RunnerUp() != 44 {
+		t.Errorf("champion %d runner-up %d, want 22 and 44", b.Champion(), b.RunnerUp())
+	}
+	if err := b.RecordWinner(2, 0, 44); err == nil {
+		t.Error("a decided match cannot be recorded again")
+	}
+}
+
+func TestBracketByes(t *testing.T) {
+	// 5 players in an 8 bracket: seeds 1-3 get byes, 4v5 plays
+	b, err := NewBracket([]int{1, 2, 3, 4, 5})
+	if err != nil {
+		t.Fatalf("NewBracket: %v", err)
+	}
+	playable := b.Playable()
+	// 4v5 in round 1, and 2v3 meet straight away in round 2 (both had byes)
+	if len(playable) != 2 {
+		t.Fatalf("want 2 playable matches, got %+v", playable)
+	}
+	if m := playable[0]; m.Round != 1 || m.Player1ID != 4 || m.Player2ID != 5 {
+		t.Errorf("first playable = %+v, want round 1 4v5", m)
+	}
+	if m := playable[1]; m.Round != 2 || m.Player1ID != 2 || m.Player2ID != 3 {
+		t.Errorf("second playable = %+v, want round 2 2v3", m)
+	}
+	if m := b.Match(2, 0); m.Player1ID != 1 || m.Player2ID != 0 {
+		t.Errorf("seed 1 should wait for the 4v5 winner, got %+v", m)
+	}
+
+	if _, err := NewBracket([]int{1}); err != ErrBracketTooSmall {
+		t.Errorf("one player: got %v, want ErrBracketTooSmall", err)
+	}
+}
+
+func TestTournamentPrizes(t *testing.T) {
+	platform, champion, runnerUp := TournamentPrizes(5000, 4, 10, 30)
+	if platform != 2000 || runnerUp != 5400 || champion != 12600 {
+		t.Errorf("got platform %.2f champion %.2f runner-up %.2f, want 2000/12600/5400", platform, champion, runnerUp)
+	}
+	if platform+champion+runnerUp != 20000 {
+		t.Error("prizes must add up to the entry fees")
+	}
+}
+
+// testDB connects to TEST_DATABASE_URL (a migrated schema); tests that need Postgres are skipped when it is unset
+func testDB(t *testing.T) *sqlx.DB {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Skipf("postgres unavailable: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestTournamentPaysFinalists(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{GameExpiryMinutes: 3, TournamentPlatformPercent: 10, TournamentRunnerUpPercent: 30}
+	gm := NewGameManager(db, nil, cfg)
+	tm := NewTournamentManager(db, gm)
+
+	tour, err := tm.Create("Friday Cup", 5000, 4, 0)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	players := make([]int, 4)
+	for i := range players {
+		phone := fmt.Sprintf("2567%08d", (time.Now().UnixNano()+int64(i))%100000000)
+		if err := db.Get(&players[i], `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+			t.Fatalf("insert player: %v", err)
+		}
+		acc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &players[i])
+		if err != nil {
+			t.Fatalf("winnings account: %v", err)
+		}
+		if _, err := db.Exec(`UPDATE accounts SET balance=10000 WHERE id=$1`, acc.ID); err != nil {
+			t.Fatalf("fund: %v", err)
+		}
+		if _, err := tm.Join(tour.ID, players[i]); err != nil {
+			t.Fatalf("join %d: %v", i, err)
+		}
+	}
+	if _, err := tm.Join(tour.ID, players[0]); err != ErrTournamentNotOpen {
+		t.Errorf("join after start: got %v, want ErrTournamentNotOpen", err)
+	}
+
+	// Player 1 of every match wins until the final is decided
+	for round := 0; round < 3; round++ {
+		b, err := tm.Bracket(tour.ID)
+		if err != nil || b == nil {
+			t.Fatalf("bracket: %v", err)
+		}
+		for _, m := range b.All() {
+			if m.SessionID != 0 && m.WinnerID == 0 {
+				tm.SessionFinished(m.SessionID, m.Player1ID)
+			}
+		}
+	}
+
+	done, err := tm.Get(tour.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if done.Status != TournamentCompleted || done.ChampionID == nil || *done.ChampionID != players[0] {
+		t.Fatalf("tournament %+v, want completed with seed 1 as champion", done)
+	}
+	balance := func(pid int) float64 {
+		acc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &pid)
+		if err != nil {
+			t.Fatalf("read winnings: %v", err)
+		}
+		return acc.Balance
+	}
+	// 20000 in fees: 2000 platform, 5400 runner-up (seed 2), 12600 champion
+	if got := balance(players[0]); got != 5000+12600 {
+		t.Errorf("champion balance %.2f, want 17600", got)
+	}
+	if got := balance(players[1]); got != 5000+5400 {
+		t.Errorf("runner-up balance %.2f, want 10400", got)
+	}
+	if got := balance(players[3]); got != 5000 {
+		t.Errorf("semi-finalist balance %.2f, want 5000", got)
+	}
+}
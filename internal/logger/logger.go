@@ -0,0 +1,60 @@
+// Package logger writes structured JSON logs through log/slog. Every line carries its level,
+// the component that wrote it and, when the context has one, the request_id of the HTTP
+// request that caused it (set by middleware.RequestID).
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync/atomic"
+)
+
+type requestIDKey struct{}
+
+var (
+	level slog.LevelVar
+	base  atomic.Pointer[slog.Logger]
+)
+
+func init() {
+	SetOutput(os.Stdout)
+}
+
+// SetOutput sends the JSON logs to w (stdout by default)
+func SetOutput(w io.Writer) {
+	base.Store(slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: &level})))
+}
+
+// SetLevel sets the minimum level written: "debug", "info", "warn" or "error" (default info)
+func SetLevel(name string) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		l = slog.LevelInfo
+	}
+	level.Set(l)
+}
+
+// WithRequestID returns a copy of ctx carrying the request id
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request id carried by ctx, or ""
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// For returns the logger for component, tagged with the request id in ctx if there is one
+func For(ctx context.Context, component string) *slog.Logger {
+	l := base.Load().With("component", component)
+	if id := RequestID(ctx); id != "" {
+		l = l.With("request_id", id)
+	}
+	return l
+}
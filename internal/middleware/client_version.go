@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+)
+
+// ClientVersionHeader carries the app version (e.g. "1.4.2") on REST calls
+const ClientVersionHeader = "X-Client-Version"
+
+// clientVersionQuery is the fallback for WebSocket upgrades, where browsers cannot set headers
+const clientVersionQuery = "client_version"
+
+// ClientVersion rejects requests from clients older than cfg.MinClientVersion with 426 and an
+// update message, so a schema change surfaces as "please update" rather than a protocol error.
+// The minimum is read per request, so a runtime_config change applies immediately.
+// Requests that don't report a version pass: only versioned clients can be told to update.
+func ClientVersion(cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		minimum := strings.TrimSpace(cfg.MinClientVersion)
+		if minimum == "" {
+			c.Next()
+			return
+		}
+		minParts, ok := parseVersion(minimum)
+		if !ok {
+			log.Printf("[CONFIG] Ignoring invalid min_client_version %q", minimum)
+			c.Next()
+			return
+		}
+
+		reported := strings.TrimSpace(c.GetHeader(ClientVersionHeader))
+		if reported == "" {
+			reported = strings.TrimSpace(c.Query(clientVersionQuery))
+		}
+		if reported == "" {
+			c.Next()
+			return
+		}
+
+		// An unparseable version is treated as outdated
+		if parts, ok := parseVersion(reported); ok && compareVersions(parts, minParts) >= 0 {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusUpgradeRequired, gin.H{
+			"error":           "client_update_required",
+			"message":         "A new version of the app is available. Please update to keep playing.",
+			"min_version":     minimum,
+			"current_version": reported,
+		})
+		c.Abort()
+	}
+}
+
+// parseVersion reads "major.minor.patch" (missing parts are 0), ignoring a leading "v"
+// and any pre-release/build suffix.
+func parseVersion(v string) ([3]int, bool) {
+	var parts [3]int
+	v = strings.TrimPrefix(strings.TrimPrefix(v, "v"), "V")
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v = v[:i]
+	}
+	fields := strings.Split(v, ".")
+	if len(fields) > 3 {
+		return parts, false
+	}
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil || n < 0 {
+			return parts, false
+		}
+		parts[i] = n
+	}
+	return parts, true
+}
+
+// compareVersions returns -1, 0 or 1 as a is older than, equal to or newer than b
+func compareVersions(a, b [3]int) int {
+	for i := range a {
+		if a[i] < b[i] {
+			return -1
+		}
+		if a[i] > b[i] {
+			return 1
+		}
+	}
+	return 0
+}
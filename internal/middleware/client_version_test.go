@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestClientVersionGate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{MinClientVersion: "1.4.0"}
+
+	r := gin.New()
+	r.GET("/game/:token/ws", ClientVersion(cfg), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	cases := []struct {
+		name   string
+		header string
+		query  string
+		want   int
+	}{
+		{"old header", "1.3.9", "", http.StatusUpgradeRequired},
+		{"old query (websocket)", "", "1.2", http.StatusUpgradeRequired},
+		{"garbage", "latest", "", http.StatusUpgradeRequired},
+		{"current", "1.4.0", "", http.StatusOK},
+		{"newer with suffix", "v1.10.0-beta", "", http.StatusOK},
+		{"current query", "", "2.0.0", http.StatusOK},
+		{"unversioned", "", "", http.StatusOK},
+	}
+	for _, tc := range cases {
+		url := "/game/tok/ws"
+		if tc.query != "" {
+			url += "?client_version=" + tc.query
+		}
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if tc.header != "" {
+			req.Header.Set(ClientVersionHeader, tc.header)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, w.Code, tc.want)
+			continue
+		}
+		if tc.want == http.StatusUpgradeRequired {
+			var body map[string]string
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if body["error"] != "client_update_required" || body["min_version"] != "1.4.0" || body["message"] == "" {
+				t.Errorf("%s: unexpected body %s", tc.name, w.Body.String())
+			}
+		}
+	}
+
+	// Lowering the minimum at runtime takes effect on the next request
+	cfg.MinClientVersion = "1.0"
+	req := httptest.NewRequest(http.MethodGet, "/game/tok/ws", nil)
+	req.Header.Set(ClientVersionHeader, "1.3.9")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("after lowering minimum: status %d, want 200", w.Code)
+	}
+}
@@ -21,7 +21,7 @@ func CORSMiddleware(cfg *config.Config) gin.HandlerFunc {
 		AllowHeaders: []string{
 			"Origin", "Content-Length", "Content-Type", "Authorization",
 			"X-Phone-Number", "X-Game-Token", "Accept", "Cache-Control",
-			"X-Requested-With",
+			"X-Requested-With", "Idempotency-Key", "X-Client-Version",
 		},
 		ExposeHeaders: []string{
 			"Content-Length", "X-Game-ID", "X-Player-Count",
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// IdempotencyHeader is the request header clients use to tag retries of the same request
+const IdempotencyHeader = "Idempotency-Key"
+
+// idempotencyPending marks a key whose first request is still being processed
+const idempotencyPending = "pending"
+
+type cachedResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// idempotencyWriter captures the response body so it can be replayed for retries
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotencyCaller identifies who sent the request: the authenticated player, else the
+// player session cookie, else the phone_number in the JSON body (the stake endpoint takes no
+// auth), else the client IP. The body is put back for the handler.
+func idempotencyCaller(c *gin.Context) string {
+	if pid := c.GetInt("player_id"); pid > 0 {
+		return fmt.Sprintf("player:%d", pid)
+	}
+	if cookie, err := c.Cookie("player_session"); err == nil && cookie != "" {
+		h := sha256.Sum256([]byte(cookie))
+		return "session:" + hex.EncodeToString(h[:])
+	}
+	if c.Request.Body != nil {
+		body, err := io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		var req struct {
+			PhoneNumber string `json:"phone_number"`
+		}
+		if err == nil && json.Unmarshal(body, &req) == nil && strings.TrimSpace(req.PhoneNumber) != "" {
+			return "phone:" + strings.TrimSpace(req.PhoneNumber)
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// Idempotency replays the first response for any request carrying a repeated Idempotency-Key.
+// Keys are scoped to the caller and route, so one client's key never replays another's
+// response. The key is claimed with SETNX so that concurrent duplicates cannot both reach
+// the handler. Requests without the header pass through untouched.
+func Idempotency(rdb *redis.Client, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimSpace(c.GetHeader(IdempotencyHeader))
+		if key == "" || rdb == nil {
+			c.Next()
+			return
+		}
+		if len(key) > 128 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key too long"})
+			c.Abort()
+			return
+		}
+
+		ctx := context.Background()
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		redisKey := fmt.Sprintf("idempotency:%s %s:%s:%s", c.Request.Method, route, idempotencyCaller(c), key)
+
+		ok, err := rdb.SetNX(ctx, redisKey, idempotencyPending, ttl).Result()
+		if err != nil {
+			// Redis unavailable: fall back to the handler's own duplicate checks
+			log.Printf("[IDEMPOTENCY] SETNX failed for %s: %v", redisKey, err)
+			c.Next()
+			return
+		}
+
+		if !ok {
+			val, err := rdb.Get(ctx, redisKey).Result()
+			if err != nil || val == idempotencyPending {
+				c.JSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is already in progress"})
+				c.Abort()
+				return
+			}
+			var cached cachedResponse
+			if err := json.Unmarshal([]byte(val), &cached); err != nil {
+				log.Printf("[IDEMPOTENCY] Corrupt cached response for %s: %v", redisKey, err)
+				c.JSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key was already processed"})
+				c.Abort()
+				return
+			}
+			c.Header("Idempotent-Replay", "true")
+			c.Data(cached.Status, cached.ContentType, cached.Body)
+			c.Abort()
+			return
+		}
+
+		w := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+
+		status := w.Status()
+		if status >= http.StatusInternalServerError {
+			// Let the client retry server-side failures with the same key
+			rdb.Del(ctx, redisKey)
+			return
+		}
+
+		payload, err := json.Marshal(cachedResponse{
+			Status:      status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        w.body.Bytes(),
+		})
+		if err != nil {
+			rdb.Del(ctx, redisKey)
+			return
+		}
+		if err := rdb.Set(ctx, redisKey, payload, ttl).Err(); err != nil {
+			log.Printf("[IDEMPOTENCY] Failed to cache response for %s: %v", redisKey, err)
+		}
+	}
+}
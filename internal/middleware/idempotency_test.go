@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// testRedis connects to TEST_REDIS_URL; tests that need Redis are skipped when it is unset
+func testRedis(t *testing.T) *redis.Client {
+	url := os.Getenv("TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TEST_REDIS_URL not set")
+	}
+	opt, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("invalid TEST_REDIS_URL: %v", err)
+	}
+	rdb := redis.NewClient(opt)
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Skipf("redis unavailable: %v", err)
+	}
+	return rdb
+}
+
+func TestIdempotencyKeyScopedToCallerAndRoute(t *testing.T) {
+	rdb := testRedis(t)
+	gin.SetMode(gin.TestMode)
+
+	var runs int32
+	handler := func(c *gin.Context) {
+		n := atomic.AddInt32(&runs, 1)
+		c.JSON(http.StatusOK, gin.H{"run": n})
+	}
+	router := gin.New()
+	router.POST("/stake", Idempotency(rdb, time.Minute), handler)
+	router.POST("/other", Idempotency(rdb, time.Minute), handler)
+
+	key := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	defer func() {
+		ctx := context.Background()
+		if keys, err := rdb.Keys(ctx, "idempotency:*"+key).Result(); err == nil && len(keys) > 0 {
+			rdb.Del(ctx, keys...)
+		}
+	}()
+	send := func(path, phone string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(fmt.Sprintf(`{"phone_number":%q}`, phone)))
+		req.Header.Set(IdempotencyHeader, key)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	send("/stake", "256700000001")
+	if rec := send("/stake", "256700000002"); rec.Header().Get("Idempotent-Replay") == "true" {
+		t.Fatal("another caller's response was replayed")
+	}
+	if rec := send("/other", "256700000001"); rec.Header().Get("Idempotent-Replay") == "true" {
+		t.Fatal("another route's response was replayed")
+	}
+	if got := atomic.LoadInt32(&runs); got != 3 {
+		t.Fatalf("handler ran %d times, want 3", got)
+	}
+
+	// The same caller retrying the same route gets the first response back
+	if rec := send("/stake", "256700000001"); rec.Code != http.StatusOK || rec.Header().Get("Idempotent-Replay") != "true" {
+		t.Fatalf("expected replayed 200, got %d replay=%q", rec.Code, rec.Header().Get("Idempotent-Replay"))
+	}
+	if got := atomic.LoadInt32(&runs); got != 3 {
+		t.Fatalf("retry re-ran handler: %d runs", got)
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/playpool/backend/internal/logger"
+)
+
+func TestRequestIDTagsResponseAndLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	t.Cleanup(func() { logger.SetOutput(os.Stdout) })
+
+	r := gin.New()
+	r.Use(RequestID())
+	r.GET("/stake", func(c *gin.Context) {
+		logger.For(c.Request.Context(), "match").Info("queued", "stake", 1000)
+		c.String(http.StatusOK, c.GetString("request_id"))
+	})
+
+	cases := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"generated", "", false},
+		{"from proxy", "edge-7f3a.1", true},
+		{"header injection", "abc\ninjected", false},
+		{"too long", strings.Repeat("a", maxRequestIDLen+1), false},
+	}
+	for _, tc := range cases {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/stake", nil)
+		if tc.incoming != "" {
+			req.Header.Set(RequestIDHeader, tc.incoming)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		id := w.Header().Get(RequestIDHeader)
+		if id == "" {
+			t.Errorf("%s: no %s response header", tc.name, RequestIDHeader)
+			continue
+		}
+		if tc.keep && id != tc.incoming {
+			t.Errorf("%s: request id %q, want incoming %q kept", tc.name, id, tc.incoming)
+		}
+		if !tc.keep && id == tc.incoming {
+			t.Errorf("%s: invalid incoming id %q was kept", tc.name, tc.incoming)
+		}
+		if w.Body.String() != id {
+			t.Errorf("%s: gin context id %q, header %q", tc.name, w.Body.String(), id)
+		}
+
+		var line map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+			t.Fatalf("%s: log line is not JSON: %v\n%s", tc.name, err, buf.String())
+		}
+		if line["level"] != "INFO" || line["component"] != "match" || line["request_id"] != id || line["stake"] != float64(1000) {
+			t.Errorf("%s: log line %v missing level/component/request_id/stake", tc.name, line)
+		}
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/logger"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -224,7 +225,7 @@ func (c *Client) Payin(ctx context.Context, req PayinRequest) (*PayinResponse, e
 		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	log.Printf("[PAYMENT] Initiating payin: phone=%s amount=%.2f txn=%s endpoint=%s", req.Phone, req.Amount, req.TransactionID, endpoint)
+	logger.For(ctx, "payment").Info("initiating payin", "phone", req.Phone, "amount", req.Amount, "txn", req.TransactionID, "endpoint", endpoint)
 	log.Printf("[PAYMENT] Payin payload: %s", string(jsonPayload))
 
 	// Send request with retry for transient errors
@@ -303,6 +304,7 @@ type PayoutRequest struct {
 	Phone         string
 	Amount        float64
 	TransactionID string
+	NotifyURL     string
 	Description   string
 }
 
@@ -313,9 +315,17 @@ type PayoutResponse struct {
 	TransactionID   string `json:"transaction_id"`
 	SPTransactionID string `json:"sp_transaction_id"`
 	Message         string `json:"message"`
+	HTTPStatus      int    `json:"-"` // HTTP status of the last attempt
+}
+
+// Rejected reports whether the provider definitely refused the payout: a 4xx answer or an
+// explicit Failed status. A 5xx may still have been accepted and is not a rejection.
+func (r *PayoutResponse) Rejected() bool {
+	return (r.HTTPStatus >= 400 && r.HTTPStatus < 500) || strings.EqualFold(r.Status, "Failed")
 }
 
-// Payout initiates a mobile money payout (synchronous - immediate response)
+// Payout initiates a mobile money disbursement. The final outcome arrives on NotifyURL
+// (or via GetTransactionStatus); the immediate response only confirms acceptance.
 func (c *Client) Payout(ctx context.Context, req PayoutRequest) (*PayoutResponse, error) {
 	if c == nil {
 		return nil, errors.New("dmark pay client not initialized")
@@ -366,12 +376,16 @@ func (c *Client) Payout(ctx context.Context, req PayoutRequest) (*PayoutResponse
 		"sp_transaction_id": req.TransactionID,
 	}
 
+	if req.NotifyURL != "" {
+		payload["notify_url"] = req.NotifyURL
+	}
+
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	log.Printf("[PAYMENT] Initiating payout: phone=%s amount=%.2f txn=%s", req.Phone, req.Amount, req.TransactionID)
+	logger.For(ctx, "payment").Info("initiating payout", "phone", req.Phone, "amount", req.Amount, "txn", req.TransactionID)
 
 	// Send request with retry for transient errors
 	for attempt := 0; attempt < 3; attempt++ {
@@ -401,9 +415,10 @@ func (c *Client) Payout(ctx context.Context, req PayoutRequest) (*PayoutResponse
 		}
 
 		var payoutResp PayoutResponse
-		if err := json.Unmarshal(body, &payoutResp); err != nil {
+		if err := json.Unmarshal(body, &payoutResp); err != nil && resp.StatusCode < 500 {
 			return nil, fmt.Errorf("failed to decode response: %w", err)
 		}
+		payoutResp.HTTPStatus = resp.StatusCode
 
 		// Success
 		if resp.StatusCode == http.StatusOK || resp.StatusCode == http.StatusCreated {
@@ -419,7 +434,7 @@ func (c *Client) Payout(ctx context.Context, req PayoutRequest) (*PayoutResponse
 			continue
 		}
 
-		// 4xx errors - don't retry
+		// 4xx errors (or 5xx on the last attempt) - don't retry
 		return &payoutResp, fmt.Errorf("payout failed: %d - %s", resp.StatusCode, payoutResp.Message)
 	}
 
@@ -0,0 +1,93 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+// newMockDMark serves the token endpoint and answers payouts with status/body
+func newMockDMark(t *testing.T, status int, body string, got *map[string]interface{}) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/o/token/":
+			w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+		case "/api/v1/accounts/acc/transactions/payout/":
+			if r.Header.Get("Authorization") != "Bearer tok" {
+				t.Errorf("missing bearer token")
+			}
+			if got != nil {
+				json.NewDecoder(r.Body).Decode(got)
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	return NewClient(&config.Config{
+		DMarkPayBaseURL:     srv.URL,
+		DMarkPayTokenURL:    "/o/token/",
+		DMarkPayUsername:    "user",
+		DMarkPayPassword:    "pass",
+		DMarkPayAccountCode: "acc",
+		DMarkPayWallet:      "dmark",
+		DMarkPayTimeout:     5,
+	}, nil)
+}
+
+func TestPayoutSendsNotifyURL(t *testing.T) {
+	var got map[string]interface{}
+	c := newMockDMark(t, http.StatusCreated, `{"status":"Pending","status_code":"1","transaction_id":"dm-9","sp_transaction_id":"42"}`, &got)
+
+	resp, err := c.Payout(context.Background(), PayoutRequest{
+		Phone:         "0772000001",
+		Amount:        25000,
+		TransactionID: "42",
+		NotifyURL:     "https://api.example.com/api/v1/webhooks/dmark/payout",
+		Description:   "withdraw",
+	})
+	if err != nil {
+		t.Fatalf("Payout: %v", err)
+	}
+	if resp.TransactionID != "dm-9" || resp.Status != "Pending" {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if got["notify_url"] != "https://api.example.com/api/v1/webhooks/dmark/payout" {
+		t.Errorf("notify_url = %v", got["notify_url"])
+	}
+	if got["sp_transaction_id"] != "42" || got["amount"] != "25000.00" || got["msisdn"] != "256772000001" {
+		t.Errorf("unexpected payload: %v", got)
+	}
+}
+
+func TestPayoutRejectedReturnsResponse(t *testing.T) {
+	c := newMockDMark(t, http.StatusBadRequest, `{"status":"Failed","status_code":"400","message":"insufficient float"}`, nil)
+
+	resp, err := c.Payout(context.Background(), PayoutRequest{Phone: "0772000001", Amount: 25000, TransactionID: "43"})
+	if err == nil {
+		t.Fatal("expected error for rejected payout")
+	}
+	if resp == nil || resp.Message != "insufficient float" || !resp.Rejected() {
+		t.Fatalf("rejection details lost: %+v", resp)
+	}
+}
+
+func TestPayoutServerErrorIsNotRejection(t *testing.T) {
+	c := newMockDMark(t, http.StatusServiceUnavailable, `{"message":"try later"}`, nil)
+
+	resp, err := c.Payout(context.Background(), PayoutRequest{Phone: "0772000001", Amount: 25000, TransactionID: "44"})
+	if err == nil {
+		t.Fatal("expected error for a 5xx answer")
+	}
+	if resp == nil || resp.HTTPStatus != http.StatusServiceUnavailable || resp.Rejected() {
+		t.Fatalf("5xx treated as a rejection: %+v", resp)
+	}
+}
@@ -0,0 +1,44 @@
+package payment
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrPayinNotFound is returned when the player has no stake payin with that DMarkPay id
+	ErrPayinNotFound = errors.New("payment not found")
+	// ErrPayinNotPending is returned when the payin already completed, failed, expired or was cancelled
+	ErrPayinNotPending = errors.New("payment is no longer pending")
+)
+
+// CancelPayin abandons playerID's PENDING stake payin before it is approved on the phone.
+// No money has moved yet, so nothing is refunded. The status checker keeps polling it until
+// DMarkPay reports a final status; if that is success, ProcessPayinSuccess credits the
+// deposit but does not queue the player.
+func CancelPayin(db *sqlx.DB, playerID int, dmarkID string) error {
+	res, err := db.Exec(`UPDATE transactions SET
+        status='CANCELLED',
+        provider_status_message='Cancelled by player'
+        WHERE dmark_transaction_id=$1 AND player_id=$2 AND transaction_type='STAKE' AND status='PENDING'`, dmarkID, playerID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("[PAYMENT] Payin %s cancelled by player %d", dmarkID, playerID)
+		return nil
+	}
+
+	var status string
+	err = db.Get(&status, `SELECT status FROM transactions WHERE dmark_transaction_id=$1 AND player_id=$2 AND transaction_type='STAKE'`, dmarkID, playerID)
+	if err == sql.ErrNoRows {
+		return ErrPayinNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return ErrPayinNotPending
+}
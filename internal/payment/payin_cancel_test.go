@@ -0,0 +1,130 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestCancelPayin(t *testing.T) {
+	db := testDB(t)
+
+	var pid, otherPID int
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if err := db.Get(&pid, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+	if err := db.Get(&otherPID, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, fmt.Sprintf("2567%08d", (time.Now().UnixNano()+1)%100000000)); err != nil {
+		t.Fatalf("insert other player: %v", err)
+	}
+	payin := func(status string) string {
+		t.Helper()
+		id := fmt.Sprintf("dm-cancel-%s-%d", status, time.Now().UnixNano())
+		if _, err := db.Exec(`INSERT INTO transactions (player_id, transaction_type, amount, status, dmark_transaction_id, created_at)
+			VALUES ($1,'STAKE',11000,$2,$3,NOW())`, pid, status, id); err != nil {
+			t.Fatalf("insert transaction: %v", err)
+		}
+		return id
+	}
+	status := func(id string) string {
+		var s string
+		db.Get(&s, `SELECT status FROM transactions WHERE dmark_transaction_id=$1`, id)
+		return s
+	}
+
+	pending := payin("PENDING")
+	if err := CancelPayin(db, otherPID, pending); !errors.Is(err, ErrPayinNotFound) {
+		t.Fatalf("cancel by another player: err %v, want ErrPayinNotFound", err)
+	}
+	if err := CancelPayin(db, pid, pending); err != nil {
+		t.Fatalf("cancel pending: %v", err)
+	}
+	if status(pending) != "CANCELLED" {
+		t.Fatalf("status after cancel = %s, want CANCELLED", status(pending))
+	}
+	if err := CancelPayin(db, pid, pending); !errors.Is(err, ErrPayinNotPending) {
+		t.Fatalf("second cancel: err %v, want ErrPayinNotPending", err)
+	}
+
+	completed := payin("COMPLETED")
+	if err := CancelPayin(db, pid, completed); !errors.Is(err, ErrPayinNotPending) {
+		t.Fatalf("cancel completed: err %v, want ErrPayinNotPending", err)
+	}
+	if status(completed) != "COMPLETED" {
+		t.Fatalf("completed payin changed to %s", status(completed))
+	}
+}
+
+// useStatusMock points Default at a fake DMarkPay that reports status for every transaction
+func useStatusMock(t *testing.T, status string) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/o/token/" {
+			w.Write([]byte(`{"access_token":"tok","expires_in":3600}`))
+			return
+		}
+		fmt.Fprintf(w, `{"status":%q,"status_code":"0"}`, status)
+	}))
+	prev := Default
+	SetDefault(NewClient(&config.Config{
+		DMarkPayBaseURL: srv.URL, DMarkPayTokenURL: "/o/token/", DMarkPayUsername: "user",
+		DMarkPayPassword: "pass", DMarkPayAccountCode: "acc", DMarkPayWallet: "dmark", DMarkPayTimeout: 5,
+	}, nil))
+	t.Cleanup(func() {
+		SetDefault(prev)
+		srv.Close()
+	})
+}
+
+func TestCancelledPayinPolledUntilFinal(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{}
+
+	var pid int
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if err := db.Get(&pid, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+	cancelled := func() int {
+		t.Helper()
+		var id int
+		if err := db.Get(&id, `INSERT INTO transactions (player_id, transaction_type, amount, status, dmark_transaction_id, created_at)
+			VALUES ($1,'STAKE',11000,'CANCELLED',$2,NOW()) RETURNING id`, pid, fmt.Sprintf("dm-cancelled-%d", time.Now().UnixNano())); err != nil {
+			t.Fatalf("insert transaction: %v", err)
+		}
+		return id
+	}
+	status := func(id int) string {
+		var s string
+		db.Get(&s, `SELECT status FROM transactions WHERE id=$1`, id)
+		return s
+	}
+
+	// Approved on the phone after the cancel: credited, no longer polled
+	approved := cancelled()
+	useStatusMock(t, "Successful")
+	checkPendingTransactions(context.Background(), db, nil, cfg)
+	if status(approved) != "COMPLETED" {
+		t.Fatalf("approved cancelled payin is %s, want COMPLETED", status(approved))
+	}
+
+	// Declined on the phone: stays cancelled, and the checker stops asking about it
+	declined := cancelled()
+	useStatusMock(t, "Failed")
+	checkPendingTransactions(context.Background(), db, nil, cfg)
+	if status(declined) != "CANCELLED" {
+		t.Fatalf("declined cancelled payin is %s, want CANCELLED", status(declined))
+	}
+	var open int
+	if err := db.Get(&open, `SELECT COUNT(*) FROM transactions WHERE id IN ($1, $2) AND completed_at IS NULL`, approved, declined); err != nil {
+		t.Fatalf("read transactions: %v", err)
+	}
+	if open != 0 {
+		t.Errorf("%d payins still open after a final provider status", open)
+	}
+}
@@ -0,0 +1,97 @@
+package payment
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
+)
+
+// pendingPayin is a real payin the status checker found still PENDING at the provider.
+// Status is ours: PENDING, or CANCELLED while the provider may still complete it.
+type pendingPayin struct {
+	ID                 int          `db:"id"`
+	PlayerID           int          `db:"player_id"`
+	Amount             float64      `db:"amount"`
+	Status             string       `db:"status"`
+	DMarkTransactionID string       `db:"dmark_transaction_id"`
+	PhoneNumber        string       `db:"phone_number"`
+	CreatedAt          time.Time    `db:"created_at"`
+	ReminderSentAt     sql.NullTime `db:"reminder_sent_at"`
+}
+
+// sendPayinReminder delivers the resume SMS (replaced in tests)
+var sendPayinReminder = func(phone string, params templates.Params) {
+	if sms.Default == nil {
+		return
+	}
+	sms.EnqueueTemplate(sms.TypePayment, phone, templates.PayinReminder, params)
+}
+
+// handlePayinTimeout reminds the player once when a payin outlives its timeout, then voids it
+// when the grace runs out too. Nothing is refunded: a real payin only moves money on success.
+func handlePayinTimeout(db *sqlx.DB, cfg *config.Config, txn pendingPayin, now time.Time) {
+	if cfg.PayinTimeoutMinutes <= 0 {
+		return
+	}
+	timeout := time.Duration(cfg.PayinTimeoutMinutes) * time.Minute
+	grace := time.Duration(cfg.PayinResumeGraceMinutes) * time.Minute
+	age := now.Sub(txn.CreatedAt)
+	if age < timeout {
+		return
+	}
+
+	if age < timeout+grace {
+		if txn.ReminderSentAt.Valid {
+			return
+		}
+		// Claim the reminder so a second checker (or the next tick) never sends it again
+		res, err := db.Exec(`UPDATE transactions SET reminder_sent_at=NOW() WHERE id=$1 AND status='PENDING' AND reminder_sent_at IS NULL`, txn.ID)
+		if err != nil {
+			log.Printf("[PAYMENT] Failed to mark reminder for transaction %d: %v", txn.ID, err)
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return
+		}
+
+		stake := float64(cfg.StakeFromGross(int(txn.Amount)))
+		link := fmt.Sprintf("%s/?stake=%.0f&resume=%d", cfg.FrontendURL, stake, txn.ID)
+		sendPayinReminder(txn.PhoneNumber, templates.Params{"Stake": int(stake), "Minutes": cfg.PayinResumeGraceMinutes, "Link": link})
+		log.Printf("[PAYMENT] Payin %d pending for %v, reminder sent", txn.ID, age.Round(time.Second))
+		return
+	}
+
+	ProcessPayinExpired(db, txn.ID)
+}
+
+// ProcessPayinExpired voids a payin that never completed. It refuses to touch a transaction
+// that already has ledger entries, since those mean money did move.
+func ProcessPayinExpired(db *sqlx.DB, txnID int) {
+	var entries int
+	if err := db.Get(&entries, `SELECT COUNT(*) FROM account_transactions WHERE reference_type='TRANSACTION' AND reference_id=$1`, txnID); err != nil {
+		log.Printf("[PAYMENT] Failed to check ledger for transaction %d: %v", txnID, err)
+		return
+	}
+	if entries > 0 {
+		log.Printf("[PAYMENT] Transaction %d has %d ledger entries but is still PENDING; not voiding", txnID, entries)
+		return
+	}
+
+	res, err := db.Exec(`UPDATE transactions SET
+        status='EXPIRED',
+        provider_status_message='Payment not completed in time'
+        WHERE id=$1 AND status='PENDING'`, txnID)
+	if err != nil {
+		log.Printf("[PAYMENT] Failed to expire transaction %d: %v", txnID, err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("[PAYMENT] Payin %d timed out, stake voided (no funds moved)", txnID)
+	}
+}
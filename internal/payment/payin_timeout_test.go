@@ -0,0 +1,99 @@
+package payment
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	_ "github.com/lib/pq"
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/sms/templates"
+)
+
+// testDB connects to TEST_DATABASE_URL (a migrated schema); tests that need Postgres are skipped when it is unset
+func testDB(t *testing.T) *sqlx.DB {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := sqlx.Connect("postgres", url)
+	if err != nil {
+		t.Skipf("postgres unavailable: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestTimedOutPayinVoidsWithoutLedgerChanges(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{PayinTimeoutMinutes: 15, PayinResumeGraceMinutes: 10, CommissionFlat: 1000, FrontendURL: "https://play.example"}
+
+	var reminders []string
+	prev := sendPayinReminder
+	sendPayinReminder = func(phone string, params templates.Params) {
+		msg, _ := templates.Render(templates.English, templates.PayinReminder, params)
+		reminders = append(reminders, msg)
+	}
+	t.Cleanup(func() { sendPayinReminder = prev })
+
+	var pid, txnID int
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if err := db.Get(&pid, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+	created := time.Now().Add(-16 * time.Minute)
+	if err := db.Get(&txnID, `INSERT INTO transactions (player_id, transaction_type, amount, status, dmark_transaction_id, created_at)
+		VALUES ($1,'STAKE',11000,'PENDING','dm-timeout',$2) RETURNING id`, pid, created); err != nil {
+		t.Fatalf("insert transaction: %v", err)
+	}
+
+	var ledgerBefore int
+	db.Get(&ledgerBefore, `SELECT COUNT(*) FROM account_transactions`)
+
+	load := func() pendingPayin {
+		t.Helper()
+		var txn pendingPayin
+		if err := db.Get(&txn, `SELECT t.id, t.player_id, t.amount, t.dmark_transaction_id, p.phone_number, t.created_at, t.reminder_sent_at
+			FROM transactions t JOIN players p ON t.player_id = p.id WHERE t.id=$1`, txnID); err != nil {
+			t.Fatalf("load transaction: %v", err)
+		}
+		return txn
+	}
+	status := func() string {
+		var s string
+		db.Get(&s, `SELECT status FROM transactions WHERE id=$1`, txnID)
+		return s
+	}
+
+	// Inside the grace: one reminder, however many times the checker runs
+	now := time.Now()
+	handlePayinTimeout(db, cfg, load(), now)
+	handlePayinTimeout(db, cfg, load(), now.Add(time.Minute))
+	if len(reminders) != 1 {
+		t.Fatalf("reminders sent = %d, want 1", len(reminders))
+	}
+	if !strings.Contains(reminders[0], "https://play.example/?stake=10000&resume=") {
+		t.Errorf("reminder missing resume link: %q", reminders[0])
+	}
+	if status() != "PENDING" {
+		t.Fatalf("voided during grace: %s", status())
+	}
+
+	// Grace over: voided, no reminder repeated, ledger untouched
+	handlePayinTimeout(db, cfg, load(), now.Add(10*time.Minute))
+	if status() != "EXPIRED" {
+		t.Fatalf("status after grace = %s, want EXPIRED", status())
+	}
+	if len(reminders) != 1 {
+		t.Errorf("reminder repeated on void: %d", len(reminders))
+	}
+	var ledgerAfter, accountsForPlayer int
+	db.Get(&ledgerAfter, `SELECT COUNT(*) FROM account_transactions`)
+	db.Get(&accountsForPlayer, `SELECT COUNT(*) FROM accounts WHERE owner_player_id=$1`, pid)
+	if ledgerAfter != ledgerBefore || accountsForPlayer != 0 {
+		t.Errorf("ledger changed: entries %d -> %d, player accounts %d", ledgerBefore, ledgerAfter, accountsForPlayer)
+	}
+}
@@ -0,0 +1,178 @@
+package payment
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+)
+
+// PayoutStatusSettlementHold marks a payout the provider delivered that settlement could not
+// cover; it waits for an admin to fund settlement and settle it again
+const PayoutStatusSettlementHold = "SETTLEMENT_HOLD"
+
+// payoutRequest is the withdraw_requests row a payout callback refers to
+type payoutRequest struct {
+	ID       int     `db:"id"`
+	PlayerID int     `db:"player_id"`
+	Amount   float64 `db:"amount"`
+	Status   string  `db:"status"`
+}
+
+// ProcessPayoutSuccess settles a confirmed payout (called by both webhook and status checker).
+// The reserved amount leaves the settlement account and the withdraw request is COMPLETED;
+// if the overdraft guard refuses the debit the request is put on SETTLEMENT_HOLD instead.
+func ProcessPayoutSuccess(db *sqlx.DB, cfg *config.Config, reqID int, statusCode, statusMessage string) {
+	log.Printf("[PAYOUT] Processing payout success for withdraw %d", reqID)
+
+	tx, err := db.Beginx()
+	if err != nil {
+		log.Printf("[PAYOUT] Failed to begin transaction: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	// Lock the request so a concurrent webhook and status check settle it only once
+	var wr payoutRequest
+	if err := tx.Get(&wr, `SELECT id, player_id, amount, status FROM withdraw_requests WHERE id=$1 FOR UPDATE`, reqID); err != nil {
+		log.Printf("[PAYOUT] Failed to load withdraw %d: %v", reqID, err)
+		return
+	}
+	if wr.Status != "PROCESSING" && wr.Status != PayoutStatusSettlementHold {
+		log.Printf("[PAYOUT] Withdraw %d already processed (status=%s), skipping", reqID, wr.Status)
+		return
+	}
+
+	settlementAcc, err := accounts.GetOrCreateAccount(db, accounts.AccountSettlement, nil)
+	if err != nil {
+		log.Printf("[PAYOUT] Failed to get settlement account: %v", err)
+		return
+	}
+
+	// Money leaves the system: debit settlement with no credit account
+	if err := accounts.DebitSettlement(tx, settlementAcc.ID, wr.Amount, cfg.SettlementOverdraftGuard); err != nil {
+		if errors.Is(err, accounts.ErrSettlementOverdraft) {
+			holdOverdrawnPayout(tx, wr, settlementAcc.ID, statusCode, statusMessage)
+			return
+		}
+		log.Printf("[PAYOUT] Failed to debit settlement: %v", err)
+		return
+	}
+	if _, err := tx.Exec(`INSERT INTO account_transactions (debit_account_id, credit_account_id, amount, reference_type, reference_id, description, created_at) VALUES ($1,NULL,$2,'WITHDRAW',$3,'Payout to external',NOW())`,
+		settlementAcc.ID, wr.Amount, reqID); err != nil {
+		log.Printf("[PAYOUT] Failed to record payout: %v", err)
+		return
+	}
+
+	if _, err := tx.Exec(`INSERT INTO transactions (player_id, transaction_type, amount, status, created_at) VALUES ($1,'WITHDRAW',$2,'COMPLETED',NOW())`, wr.PlayerID, wr.Amount); err != nil {
+		log.Printf("[PAYOUT] Failed to insert transaction: %v", err)
+		return
+	}
+
+	if _, err := tx.Exec(`UPDATE withdraw_requests SET
+        status='COMPLETED',
+        processed_at=NOW(),
+        provider_status_code=$1,
+        provider_status_message=$2
+        WHERE id=$3`,
+		statusCode, statusMessage, reqID); err != nil {
+		log.Printf("[PAYOUT] Failed to update withdraw %d: %v", reqID, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[PAYOUT] Failed to commit: %v", err)
+		return
+	}
+
+	log.Printf("[PAYOUT] ✓ Payout completed: withdraw=%d amount=%.2f", reqID, wr.Amount)
+}
+
+// holdOverdrawnPayout records the settlement shortfall on a delivered payout and moves it to
+// SETTLEMENT_HOLD, so it is neither refunded (the player was paid) nor left PROCESSING
+func holdOverdrawnPayout(tx *sqlx.Tx, wr payoutRequest, settlementAccountID int, statusCode, statusMessage string) {
+	var balance float64
+	if err := tx.Get(&balance, `SELECT balance FROM accounts WHERE id=$1`, settlementAccountID); err != nil {
+		log.Printf("[PAYOUT] Failed to read settlement balance: %v", err)
+		return
+	}
+	note := fmt.Sprintf("Paid out but settlement short by %.2f (held %.2f)", wr.Amount-balance, balance)
+	if _, err := tx.Exec(`UPDATE withdraw_requests SET
+        status=$1,
+        note=$2,
+        provider_status_code=$3,
+        provider_status_message=$4
+        WHERE id=$5`,
+		PayoutStatusSettlementHold, note, statusCode, statusMessage, wr.ID); err != nil {
+		log.Printf("[PAYOUT] Failed to hold withdraw %d: %v", wr.ID, err)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("[PAYOUT] Failed to commit hold: %v", err)
+		return
+	}
+	log.Printf("[PAYOUT] ⚠ Withdraw %d on %s: %s", wr.ID, PayoutStatusSettlementHold, note)
+}
+
+// ProcessPayoutFailed refunds a payout the provider rejected or could not deliver
+// (called by both webhook and status checker): settlement -> player_winnings, request FAILED.
+func ProcessPayoutFailed(db *sqlx.DB, cfg *config.Config, reqID int, statusCode, message string) {
+	log.Printf("[PAYOUT] Payout failed for withdraw %d: %s", reqID, message)
+
+	tx, err := db.Beginx()
+	if err != nil {
+		log.Printf("[PAYOUT] Failed to begin transaction: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var wr payoutRequest
+	if err := tx.Get(&wr, `SELECT id, player_id, amount, status FROM withdraw_requests WHERE id=$1 FOR UPDATE`, reqID); err != nil {
+		log.Printf("[PAYOUT] Failed to load withdraw %d: %v", reqID, err)
+		return
+	}
+	if wr.Status != "PROCESSING" && wr.Status != "PENDING" {
+		log.Printf("[PAYOUT] Withdraw %d already processed (status=%s), skipping", reqID, wr.Status)
+		return
+	}
+
+	settlementAcc, err := accounts.GetOrCreateAccount(db, accounts.AccountSettlement, nil)
+	if err != nil {
+		log.Printf("[PAYOUT] Failed to get settlement account: %v", err)
+		return
+	}
+	winningsAcc, err := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &wr.PlayerID)
+	if err != nil {
+		log.Printf("[PAYOUT] Failed to get player winnings account: %v", err)
+		return
+	}
+
+	if err := accounts.Transfer(tx, settlementAcc.ID, winningsAcc.ID, wr.Amount, "WITHDRAW_REFUND",
+		sql.NullInt64{Int64: int64(reqID), Valid: true}, "Payout failed - refunded", cfg.SettlementOverdraftGuard); err != nil {
+		log.Printf("[PAYOUT] Refund transfer failed: %v", err)
+		return
+	}
+
+	if _, err := tx.Exec(`UPDATE withdraw_requests SET
+        status='FAILED',
+        processed_at=NOW(),
+        note=$1,
+        provider_status_code=$2,
+        provider_status_message=$3
+        WHERE id=$4`,
+		"Payout failed - refunded", statusCode, message, reqID); err != nil {
+		log.Printf("[PAYOUT] Failed to update withdraw %d: %v", reqID, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("[PAYOUT] Failed to commit refund: %v", err)
+		return
+	}
+
+	log.Printf("[PAYOUT] ✓ Payout refunded: withdraw=%d amount=%.2f", reqID, wr.Amount)
+}
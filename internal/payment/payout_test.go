@@ -0,0 +1,47 @@
+package payment
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestPayoutSuccessHeldWhenSettlementShort(t *testing.T) {
+	db := testDB(t)
+	cfg := &config.Config{SettlementOverdraftGuard: true}
+
+	var pid, reqID int
+	phone := fmt.Sprintf("2567%08d", time.Now().UnixNano()%100000000)
+	if err := db.Get(&pid, `INSERT INTO players (phone_number) VALUES ($1) RETURNING id`, phone); err != nil {
+		t.Fatalf("insert player: %v", err)
+	}
+	sett, err := accounts.GetOrCreateAccount(db, accounts.AccountSettlement, nil)
+	if err != nil {
+		t.Fatalf("settlement account: %v", err)
+	}
+	// The provider paid out 5000 more than settlement holds
+	amount := sett.Balance + 5000
+	if err := db.Get(&reqID, `INSERT INTO withdraw_requests (player_id, amount, fee, net_amount, method, destination, status)
+		VALUES ($1, $2, 0, $2, 'mobile_money', $3, 'PROCESSING') RETURNING id`, pid, amount, phone); err != nil {
+		t.Fatalf("insert withdraw: %v", err)
+	}
+
+	ProcessPayoutSuccess(db, cfg, reqID, "0", "Successful")
+
+	var status, note string
+	if err := db.QueryRowx(`SELECT status, COALESCE(note, '') FROM withdraw_requests WHERE id=$1`, reqID).Scan(&status, &note); err != nil {
+		t.Fatalf("read withdraw: %v", err)
+	}
+	if status != PayoutStatusSettlementHold || !strings.Contains(note, "short by 5000.00") {
+		t.Fatalf("status %q note %q, want %s with the shortfall", status, note, PayoutStatusSettlementHold)
+	}
+	var balance float64
+	db.Get(&balance, `SELECT balance FROM accounts WHERE id=$1`, sett.ID)
+	if balance != sett.Balance {
+		t.Fatalf("settlement moved from %.2f to %.2f on a held payout", sett.Balance, balance)
+	}
+}
@@ -11,10 +11,12 @@ import (
 	"github.com/playpool/backend/internal/accounts"
 	"github.com/playpool/backend/internal/config"
 	"github.com/playpool/backend/internal/sms"
+	"github.com/playpool/backend/internal/sms/templates"
 	"github.com/redis/go-redis/v9"
 )
 
-// StartStatusChecker runs a background job to check status of PENDING transactions via DMarkPay API
+// StartStatusChecker runs a background job to check status of PENDING transactions and
+// PROCESSING payouts via DMarkPay API
 func StartStatusChecker(ctx context.Context, db *sqlx.DB, rdb *redis.Client, cfg *config.Config, intervalMinutes int) {
 	ticker := time.NewTicker(time.Duration(intervalMinutes) * time.Minute)
 	defer ticker.Stop()
@@ -23,6 +25,7 @@ func StartStatusChecker(ctx context.Context, db *sqlx.DB, rdb *redis.Client, cfg
 
 	// Run once immediately on startup
 	checkPendingTransactions(ctx, db, rdb, cfg)
+	checkProcessingPayouts(ctx, db, cfg)
 
 	for {
 		select {
@@ -31,6 +34,7 @@ func StartStatusChecker(ctx context.Context, db *sqlx.DB, rdb *redis.Client, cfg
 			return
 		case <-ticker.C:
 			checkPendingTransactions(ctx, db, rdb, cfg)
+			checkProcessingPayouts(ctx, db, cfg)
 		}
 	}
 }
@@ -41,21 +45,15 @@ func checkPendingTransactions(ctx context.Context, db *sqlx.DB, rdb *redis.Clien
 		return
 	}
 
-	// Get all PENDING transactions
-	var transactions []struct {
-		ID                 int     `db:"id"`
-		PlayerID           int     `db:"player_id"`
-		Amount             float64 `db:"amount"`
-		DMarkTransactionID string  `db:"dmark_transaction_id"`
-		PhoneNumber        string  `db:"phone_number"`
-		CreatedAt          time.Time `db:"created_at"`
-	}
+	// Get all PENDING transactions, plus cancelled ones the provider hasn't finished with:
+	// the player may still approve those on the phone
+	var transactions []pendingPayin
 
 	err := db.Select(&transactions, `
-		SELECT t.id, t.player_id, t.amount, t.dmark_transaction_id, p.phone_number, t.created_at
+		SELECT t.id, t.player_id, t.amount, t.status, t.dmark_transaction_id, p.phone_number, t.created_at, t.reminder_sent_at
 		FROM transactions t
 		JOIN players p ON t.player_id = p.id
-		WHERE t.status = 'PENDING'
+		WHERE (t.status = 'PENDING' OR (t.status = 'CANCELLED' AND t.completed_at IS NULL))
 		  AND t.dmark_transaction_id IS NOT NULL
 		  AND t.dmark_transaction_id != ''
 		ORDER BY t.created_at ASC
@@ -100,8 +98,65 @@ func checkPendingTransactions(ctx context.Context, db *sqlx.DB, rdb *redis.Clien
 			ProcessPayinFailed(db, txn.ID, statusResp.StatusCode, statusResp.Message)
 		case "Pending":
 			log.Printf("[PAYMENT-STATUS] Transaction %d still pending, will check again later", txn.ID)
+			if txn.Status == "PENDING" {
+				handlePayinTimeout(db, cfg, txn, time.Now())
+			}
 		default:
 			log.Printf("[PAYMENT-STATUS] Transaction %d has unknown status '%s', treating as pending", txn.ID, statusResp.Status)
+			if txn.Status == "PENDING" {
+				handlePayinTimeout(db, cfg, txn, time.Now())
+			}
+		}
+	}
+}
+
+// checkProcessingPayouts polls DMarkPay for withdrawals whose payout callback has not arrived yet
+func checkProcessingPayouts(ctx context.Context, db *sqlx.DB, cfg *config.Config) {
+	if Default == nil {
+		return
+	}
+
+	var payouts []struct {
+		ID                 int       `db:"id"`
+		DMarkTransactionID string    `db:"dmark_transaction_id"`
+		CreatedAt          time.Time `db:"created_at"`
+	}
+
+	err := db.Select(&payouts, `
+		SELECT id, dmark_transaction_id, created_at
+		FROM withdraw_requests
+		WHERE status = 'PROCESSING'
+		  AND dmark_transaction_id IS NOT NULL
+		  AND dmark_transaction_id != ''
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		log.Printf("[PAYMENT-STATUS] Failed to fetch processing payouts: %v", err)
+		return
+	}
+
+	if len(payouts) == 0 {
+		return
+	}
+
+	log.Printf("[PAYMENT-STATUS] Checking %d processing payout(s)", len(payouts))
+
+	for _, p := range payouts {
+		statusResp, err := Default.GetTransactionStatus(ctx, p.DMarkTransactionID)
+		if err != nil {
+			log.Printf("[PAYMENT-STATUS] Failed to get status for withdraw %d: %v", p.ID, err)
+			continue
+		}
+
+		switch statusResp.Status {
+		case "Successful":
+			log.Printf("[PAYMENT-STATUS] Payout for withdraw %d succeeded", p.ID)
+			ProcessPayoutSuccess(db, cfg, p.ID, statusResp.StatusCode, statusResp.Status)
+		case "Failed":
+			log.Printf("[PAYMENT-STATUS] Payout for withdraw %d failed, refunding", p.ID)
+			ProcessPayoutFailed(db, cfg, p.ID, statusResp.StatusCode, statusResp.Message)
+		default:
+			log.Printf("[PAYMENT-STATUS] Payout for withdraw %d still %s (age=%v)", p.ID, statusResp.Status, time.Since(p.CreatedAt).Round(time.Second))
 		}
 	}
 }
@@ -129,15 +184,26 @@ func ProcessPayinSuccess(db *sqlx.DB, rdb *redis.Client, cfg *config.Config, txn
 	}
 	defer tx.Rollback()
 
+	// Re-read under the row lock: a cancel that landed since the check above decides
+	// whether the player is queued, and a concurrent success is not credited twice
+	if err := tx.Get(&currentStatus, `SELECT status FROM transactions WHERE id=$1 FOR UPDATE`, txnID); err != nil {
+		log.Printf("[PAYMENT] Failed to lock transaction %d: %v", txnID, err)
+		return
+	}
+	if currentStatus == "COMPLETED" {
+		log.Printf("[PAYMENT] Transaction %d already completed, skipping", txnID)
+		return
+	}
+
 	// Get accounts
 	settlementAcc, _ := accounts.GetOrCreateAccount(db, accounts.AccountSettlement, nil)
 	platformAcc, _ := accounts.GetOrCreateAccount(db, accounts.AccountPlatform, nil)
 	winningsAcc, _ := accounts.GetOrCreateAccount(db, accounts.AccountPlayerWinnings, &playerID)
 
-	// Calculate commission
-	commission := float64(cfg.CommissionFlat)
+	// Split the gross back into stake and the commission that was quoted on it
 	grossAmount := amount
-	netAmount := grossAmount - commission
+	netAmount := float64(cfg.StakeFromGross(int(grossAmount)))
+	commission := grossAmount - netAmount
 
 	// Credit settlement account with gross amount
 	_, err = tx.Exec(`UPDATE accounts SET balance = balance + $1, updated_at = NOW() WHERE id = $2`, grossAmount, settlementAcc.ID)
@@ -158,7 +224,7 @@ func ProcessPayinSuccess(db *sqlx.DB, rdb *redis.Client, cfg *config.Config, txn
 
 	// Transfer: SETTLEMENT → PLATFORM (commission)
 	err = accounts.Transfer(tx, settlementAcc.ID, platformAcc.ID, commission,
-		"TRANSACTION", sql.NullInt64{Int64: int64(txnID), Valid: true}, "Commission (flat)")
+		"TRANSACTION", sql.NullInt64{Int64: int64(txnID), Valid: true}, "Commission", cfg.SettlementOverdraftGuard)
 	if err != nil {
 		log.Printf("[PAYMENT] Failed to transfer commission: %v", err)
 		return
@@ -166,7 +232,7 @@ func ProcessPayinSuccess(db *sqlx.DB, rdb *redis.Client, cfg *config.Config, txn
 
 	// Transfer: SETTLEMENT → PLAYER_WINNINGS (net)
 	err = accounts.Transfer(tx, settlementAcc.ID, winningsAcc.ID, netAmount,
-		"TRANSACTION", sql.NullInt64{Int64: int64(txnID), Valid: true}, "Deposit (net)")
+		"TRANSACTION", sql.NullInt64{Int64: int64(txnID), Valid: true}, "Deposit (net)", cfg.SettlementOverdraftGuard)
 	if err != nil {
 		log.Printf("[PAYMENT] Failed to transfer net amount: %v", err)
 		return
@@ -192,17 +258,16 @@ func ProcessPayinSuccess(db *sqlx.DB, rdb *redis.Client, cfg *config.Config, txn
 
 	log.Printf("[PAYMENT] ✓ Payin completed: txn=%d gross=%.2f commission=%.2f net=%.2f", txnID, grossAmount, commission, netAmount)
 
-	// Add player to matchmaking queue after successful payment
-	go AddToMatchmakingQueue(db, rdb, cfg, playerID, phone, netAmount, txnID)
+	// Add player to matchmaking queue after successful payment, unless they abandoned it
+	if currentStatus == "CANCELLED" {
+		log.Printf("[PAYMENT] Payin %d was cancelled by the player before it succeeded; funds credited, not queued", txnID)
+	} else {
+		go AddToMatchmakingQueue(db, rdb, cfg, playerID, phone, netAmount, txnID)
+	}
 
 	// Best-effort SMS
 	if sms.Default != nil {
-		msg := fmt.Sprintf("PlayPool: Payment of %.0f UGX received. You can now join a game!", amount)
-		go func() {
-			if _, err := sms.SendSMS(context.Background(), phone, msg); err != nil {
-				log.Printf("[PAYMENT] Failed to send deposit SMS: %v", err)
-			}
-		}()
+		sms.EnqueueTemplate(sms.TypePayment, phone, templates.PaymentReceived, templates.Params{"Amount": int(amount)})
 	}
 }
 
@@ -217,6 +282,13 @@ func ProcessPayinFailed(db *sqlx.DB, txnID int, statusCode, message string) {
 		log.Printf("[PAYMENT] Failed to check transaction status: %v", err)
 		return
 	}
+	if currentStatus == "CANCELLED" {
+		// The player gave up first; keep it cancelled but note that the provider is done with it
+		if _, err := db.Exec(`UPDATE transactions SET completed_at=NOW(), provider_status_code=$1 WHERE id=$2 AND status='CANCELLED'`, statusCode, txnID); err != nil {
+			log.Printf("[PAYMENT] Failed to close cancelled transaction %d: %v", txnID, err)
+		}
+		return
+	}
 	if currentStatus == "FAILED" || currentStatus == "COMPLETED" {
 		log.Printf("[PAYMENT] Transaction %d already processed (status=%s), skipping", txnID, currentStatus)
 		return
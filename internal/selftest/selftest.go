@@ -0,0 +1,131 @@
+// Package selftest runs optional startup checks that catch a misconfigured deployment
+// (wrong database, missing migrations, unreachable Redis) before the server takes traffic.
+package selftest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/accounts"
+	"github.com/playpool/backend/internal/game"
+	"github.com/redis/go-redis/v9"
+)
+
+// Check is one named self-test step
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// systemAccountTypes are the accounts every money movement depends on
+var systemAccountTypes = []string{accounts.AccountEscrow, accounts.AccountSettlement, accounts.AccountPlatform, accounts.AccountTax}
+
+// Checks returns the standard startup checks against db and rdb
+func Checks(db *sqlx.DB, rdb *redis.Client) []Check {
+	return []Check{
+		dbCheck(db),
+		redisCheck(rdb),
+		systemAccountsCheck(func(accountType string) error {
+			_, err := accounts.GetOrCreateAccount(db, accountType, nil)
+			return err
+		}),
+		gameCheck(),
+	}
+}
+
+// Run runs every check, logging a PASS or FAIL line for each, and returns the failures
+// joined (nil when all passed)
+func Run(ctx context.Context, checks []Check) error {
+	var errs []error
+	for _, c := range checks {
+		start := time.Now()
+		if err := c.Run(ctx); err != nil {
+			log.Printf("[SELFTEST] FAIL %s: %v", c.Name, err)
+			errs = append(errs, fmt.Errorf("%s: %w", c.Name, err))
+			continue
+		}
+		log.Printf("[SELFTEST] PASS %s (%s)", c.Name, time.Since(start).Round(time.Millisecond))
+	}
+	if len(errs) > 0 {
+		log.Printf("[SELFTEST] %d of %d checks failed", len(errs), len(checks))
+		return errors.Join(errs...)
+	}
+	log.Printf("[SELFTEST] All %d checks passed", len(checks))
+	return nil
+}
+
+// dbCheck makes a database round-trip
+func dbCheck(db *sqlx.DB) Check {
+	return Check{Name: "database", Run: func(ctx context.Context) error {
+		var one int
+		if err := db.GetContext(ctx, &one, `SELECT 1`); err != nil {
+			return err
+		}
+		if one != 1 {
+			return fmt.Errorf("SELECT 1 returned %d", one)
+		}
+		return nil
+	}}
+}
+
+// redisCheck writes, reads back and deletes a short-lived key
+func redisCheck(rdb *redis.Client) Check {
+	return Check{Name: "redis", Run: func(ctx context.Context) error {
+		key := fmt.Sprintf("selftest:%d", time.Now().UnixNano())
+		if err := rdb.Set(ctx, key, "ok", 30*time.Second).Err(); err != nil {
+			return fmt.Errorf("set: %w", err)
+		}
+		defer rdb.Del(ctx, key)
+		got, err := rdb.Get(ctx, key).Result()
+		if err != nil {
+			return fmt.Errorf("get: %w", err)
+		}
+		if got != "ok" {
+			return fmt.Errorf("read back %q, want \"ok\"", got)
+		}
+		return nil
+	}}
+}
+
+// systemAccountsCheck creates (or finds) each system account through ensure
+func systemAccountsCheck(ensure func(accountType string) error) Check {
+	return Check{Name: "system accounts", Run: func(ctx context.Context) error {
+		for _, t := range systemAccountTypes {
+			if err := ensure(t); err != nil {
+				return fmt.Errorf("%s account: %w", t, err)
+			}
+		}
+		return nil
+	}}
+}
+
+// gameCheck racks an in-memory game and plays a scripted break that leaves the balls in place
+func gameCheck() Check {
+	return Check{Name: "game engine", Run: func(ctx context.Context) error {
+		g := game.NewPoolGame("selftest", "selftest", "st1", "256700000001", "t1", 0, "One", "st2", "256700000002", "t2", 0, "Two", 1000)
+		if err := g.Initialize(); err != nil {
+			return fmt.Errorf("initialize: %w", err)
+		}
+		breaker := g.CurrentTurn
+		if err := g.BeginShot(breaker, game.ShotParams{Angle: 0, Power: 3000}, nil); err != nil {
+			return fmt.Errorf("begin shot: %w", err)
+		}
+		result, err := g.ApplyShotResult(breaker, game.ClientShotData{
+			BallPositions:       g.GetCurrentBallPositions(),
+			FirstContactBallID:  1,
+			CushionAfterContact: true,
+			BreakCushionCount:   4,
+		})
+		if err != nil {
+			return fmt.Errorf("apply shot: %w", err)
+		}
+		if result.NextTurn == "" {
+			return errors.New("scripted break left no player to shoot next")
+		}
+		return nil
+	}}
+}
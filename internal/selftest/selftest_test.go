@@ -0,0 +1,43 @@
+package selftest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/playpool/backend/internal/accounts"
+)
+
+func TestSelfTestPassesOnHealthyStub(t *testing.T) {
+	var created []string
+	checks := []Check{
+		systemAccountsCheck(func(accountType string) error {
+			created = append(created, accountType)
+			return nil
+		}),
+		gameCheck(),
+	}
+	if err := Run(context.Background(), checks); err != nil {
+		t.Fatalf("self-test failed: %v", err)
+	}
+	if len(created) != len(systemAccountTypes) {
+		t.Fatalf("created %v, want every system account", created)
+	}
+}
+
+func TestSelfTestFailsOnBrokenAccountLayer(t *testing.T) {
+	broken := systemAccountsCheck(func(accountType string) error {
+		if accountType == accounts.AccountSettlement {
+			return errors.New(`relation "accounts" does not exist`)
+		}
+		return nil
+	})
+	err := Run(context.Background(), []Check{broken, gameCheck()})
+	if err == nil {
+		t.Fatal("self-test passed with a broken account layer")
+	}
+	if !strings.Contains(err.Error(), "system accounts") || !strings.Contains(err.Error(), accounts.AccountSettlement) {
+		t.Fatalf("error %q does not name the failing check and account", err)
+	}
+}
@@ -0,0 +1,69 @@
+package sms
+
+import (
+	"context"
+	"log"
+	"strings"
+	"unicode/utf16"
+)
+
+// GSM 03.38 characters. Extension characters take two septets; anything outside both
+// sets forces UCS-2, which fits far fewer characters per segment.
+const (
+	gsmBasic     = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+	gsmExtension = "^{}\\[~]|€\f"
+)
+
+// Segments returns how many SMS parts message is billed as: 160 GSM-7 characters
+// (153 per part when concatenated), or 70 UCS-2 characters (67 per part).
+func Segments(message string) int {
+	septets := 0
+	for _, r := range message {
+		switch {
+		case strings.ContainsRune(gsmBasic, r):
+			septets++
+		case strings.ContainsRune(gsmExtension, r):
+			septets += 2
+		default:
+			return segmentsOf(len(utf16.Encode([]rune(message))), 70, 67)
+		}
+	}
+	return segmentsOf(septets, 160, 153)
+}
+
+func segmentsOf(units, single, multi int) int {
+	if units <= single {
+		return 1
+	}
+	return (units + multi - 1) / multi
+}
+
+// estimatedCost is what a message of segments parts of smsType is expected to cost (UGX)
+func (c *Client) estimatedCost(smsType string, segments int) float64 {
+	perSegment := c.costPerSegment
+	if v, ok := c.costByType[smsType]; ok {
+		perSegment = v
+	}
+	return float64(perSegment * segments)
+}
+
+// SentMessage is an SMS the provider accepted, as recorded for spend reporting.
+type SentMessage struct {
+	Type          string
+	Phone         string
+	Segments      int
+	EstimatedCost float64
+	ProviderID    string
+}
+
+// recordSent logs an accepted message and its estimated cost to sms_messages.
+// Swapped out in tests.
+var recordSent = func(ctx context.Context, m SentMessage) {
+	if prefsDB == nil {
+		return
+	}
+	if _, err := prefsDB.ExecContext(ctx, `INSERT INTO sms_messages (sms_type, phone_number, segments, estimated_cost, provider_message_id, created_at)
+        VALUES ($1, $2, $3, $4, NULLIF($5, ''), NOW())`, m.Type, m.Phone, m.Segments, m.EstimatedCost, m.ProviderID); err != nil {
+		log.Printf("[SMS] Failed to record sent message to %s: %v", m.Phone, err)
+	}
+}
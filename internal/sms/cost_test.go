@@ -0,0 +1,98 @@
+package sms
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestSegments(t *testing.T) {
+	cases := []struct {
+		name    string
+		message string
+		want    int
+	}{
+		{"empty", "", 1},
+		{"gsm single", strings.Repeat("a", 160), 1},
+		{"gsm two parts", strings.Repeat("a", 161), 2},
+		{"gsm three parts", strings.Repeat("a", 307), 3},
+		{"extension chars count double", strings.Repeat("€", 80), 1},
+		{"extension chars overflow", strings.Repeat("€", 81), 2},
+		{"ucs2 single", strings.Repeat("ü", 60) + "✓", 1},
+		{"ucs2 two parts", strings.Repeat("a", 70) + "✓", 2},
+	}
+	for _, tc := range cases {
+		if got := Segments(tc.message); got != tc.want {
+			t.Errorf("%s: %d segments, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSentMessagesRecordEstimatedCost(t *testing.T) {
+	newMockSMS(t, func(cfg *config.Config) {
+		cfg.SMSCostPerSegment = 25
+		cfg.SMSCostPerSegmentByType = map[string]int{TypeOTP: 40}
+	})
+	var recorded []SentMessage
+	prev := recordSent
+	recordSent = func(ctx context.Context, m SentMessage) { recorded = append(recorded, m) }
+	defer func() { recordSent = prev }()
+
+	ctx := context.Background()
+	Notify(ctx, TypeOTP, "256700000001", "Your PlayPool OTP is 1234")
+	Notify(ctx, TypeMatch, "256700000002", strings.Repeat("x", 200)) // two segments
+	Default.SendSMS(ctx, "256700000003", "hello")
+
+	if len(recorded) != 3 {
+		t.Fatalf("recorded %d messages, want 3", len(recorded))
+	}
+	want := []SentMessage{
+		{Type: TypeOTP, Phone: "256700000001", Segments: 1, EstimatedCost: 40, ProviderID: "m1"},
+		{Type: TypeMatch, Phone: "256700000002", Segments: 2, EstimatedCost: 50, ProviderID: "m1"},
+		{Type: TypeOther, Phone: "256700000003", Segments: 1, EstimatedCost: 25, ProviderID: "m1"},
+	}
+	total := 0.0
+	for i, m := range recorded {
+		if m != want[i] {
+			t.Errorf("message %d = %+v, want %+v", i, m, want[i])
+		}
+		total += m.EstimatedCost
+	}
+	if total != 115 {
+		t.Errorf("total estimated cost %.0f, want 115", total)
+	}
+}
+
+func TestSentMessageLogMasksPhone(t *testing.T) {
+	sent := newMockSMS(t, func(cfg *config.Config) {
+		cfg.SMSLogPhoneLast4 = true
+		cfg.SMSDeliveryReportURL = "https://api.example/api/v1/webhooks/dmark/sms?token=t"
+	})
+	var recorded []SentMessage
+	prev := recordSent
+	recordSent = func(ctx context.Context, m SentMessage) { recorded = append(recorded, m) }
+	defer func() { recordSent = prev }()
+
+	Notify(context.Background(), TypeMatch, "256772123456", "Match found")
+
+	if len(recorded) != 1 || recorded[0].Phone != "********3456" || recorded[0].ProviderID != "m1" {
+		t.Fatalf("recorded %+v, want masked phone and provider id", recorded)
+	}
+	if (*sent)[0]["dlr_url"] != "https://api.example/api/v1/webhooks/dmark/sms?token=t" {
+		t.Errorf("dlr_url = %v, want the delivery report URL", (*sent)[0]["dlr_url"])
+	}
+}
+
+func TestDeliveryStatus(t *testing.T) {
+	for reported, want := range map[string]string{
+		"DELIVRD": DeliveryDelivered, "delivered": DeliveryDelivered,
+		"UNDELIV": DeliveryFailed, "REJECTD": DeliveryFailed, "EXPIRED": DeliveryFailed,
+		"ACCEPTD": "", "": "",
+	} {
+		if got := DeliveryStatus(reported); got != want {
+			t.Errorf("DeliveryStatus(%q) = %q, want %q", reported, got, want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package sms
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Delivery states of a logged message (sms_messages.status)
+const (
+	DeliverySent      = "SENT"      // accepted by DMark, no report yet
+	DeliveryDelivered = "DELIVERED" // handset received it
+	DeliveryFailed    = "FAILED"    // the network gave up on it
+)
+
+// DeliveryStatus maps a DMark delivery-report status to a log state. Interim or unknown
+// statuses map to "" and leave the message as it is.
+func DeliveryStatus(reported string) string {
+	switch strings.ToUpper(strings.TrimSpace(reported)) {
+	case "DELIVRD", "DELIVERED", "SUCCESS":
+		return DeliveryDelivered
+	case "UNDELIV", "UNDELIVERED", "FAILED", "REJECTD", "REJECTED", "EXPIRED":
+		return DeliveryFailed
+	}
+	return ""
+}
+
+// UpdateDeliveryStatus records a delivery report for the message DMark knows as providerID.
+// It reports whether a logged message matched.
+func UpdateDeliveryStatus(ctx context.Context, db *sqlx.DB, providerID, status string) (bool, error) {
+	res, err := db.ExecContext(ctx, `UPDATE sms_messages SET status=$2, updated_at=NOW() WHERE provider_message_id=$1`, providerID, status)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// loggedPhone is the number as stored in the SMS log: in full, or only its last four digits
+func (c *Client) loggedPhone(phone string) string {
+	if !c.logPhoneLast4 || len(phone) <= 4 {
+		return phone
+	}
+	return strings.Repeat("*", len(phone)-4) + phone[len(phone)-4:]
+}
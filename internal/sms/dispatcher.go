@@ -0,0 +1,123 @@
+package sms
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+
+	"github.com/playpool/backend/internal/sms/templates"
+)
+
+// Dispatcher sends notification SMS from a bounded queue on a fixed pool of workers, so a
+// burst of matches can't spawn a goroutine (and a provider call) per message.
+type Dispatcher struct {
+	workers int
+	jobs    chan dispatchJob
+	dropped atomic.Int64
+
+	// send delivers one message; Notify unless replaced in tests
+	send func(ctx context.Context, smsType, phone, message string) (string, error)
+}
+
+type dispatchJob struct {
+	smsType, phone, message string
+
+	// key, when set, is rendered with params in the recipient's language in place of message
+	key    string
+	params templates.Params
+}
+
+// NewDispatcher returns a dispatcher with workers senders and room for queueSize waiting messages.
+// Nothing is sent until Start.
+func NewDispatcher(workers, queueSize int) *Dispatcher {
+	return &Dispatcher{
+		workers: max(workers, 1),
+		jobs:    make(chan dispatchJob, max(queueSize, 1)),
+		send:    Notify,
+	}
+}
+
+// Start runs the workers until ctx is cancelled
+func (d *Dispatcher) Start(ctx context.Context) {
+	for i := 0; i < d.workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case j := <-d.jobs:
+					if j.key != "" {
+						msg, err := templates.Render(playerLanguage(ctx, j.phone), j.key, j.params)
+						if err != nil {
+							log.Printf("[SMS] Failed to render %s SMS to %s: %v", j.key, j.phone, err)
+							continue
+						}
+						j.message = msg
+					}
+					if msgID, err := d.send(context.Background(), j.smsType, j.phone, j.message); err != nil {
+						log.Printf("[SMS] Failed to send %s SMS to %s: %v", j.smsType, j.phone, err)
+					} else if msgID != "" {
+						log.Printf("[SMS] %s SMS sent to %s msg_id=%s", j.smsType, j.phone, msgID)
+					}
+				}
+			}
+		}()
+	}
+}
+
+// Enqueue queues a typed SMS (see Notify) without blocking. It returns false, and the
+// message is dropped, when the queue is full.
+func (d *Dispatcher) Enqueue(smsType, phone, message string) bool {
+	return d.enqueue(dispatchJob{smsType: smsType, phone: phone, message: message})
+}
+
+// EnqueueTemplate queues the template key with params like Enqueue; it is rendered in the
+// recipient's language when a worker picks it up. An unknown key is dropped straight away.
+func (d *Dispatcher) EnqueueTemplate(smsType, phone, key string, params templates.Params) bool {
+	if !templates.Has(key) {
+		log.Printf("[SMS] Unknown template %q for %s SMS to %s", key, smsType, phone)
+		return false
+	}
+	return d.enqueue(dispatchJob{smsType: smsType, phone: phone, key: key, params: params})
+}
+
+func (d *Dispatcher) enqueue(j dispatchJob) bool {
+	select {
+	case d.jobs <- j:
+		return true
+	default:
+		n := d.dropped.Add(1)
+		log.Printf("[SMS] Send queue full, dropped %s SMS to %s (%d dropped so far)", j.smsType, j.phone, n)
+		return false
+	}
+}
+
+// Dropped returns how many messages were dropped because the queue was full
+func (d *Dispatcher) Dropped() int64 {
+	return d.dropped.Load()
+}
+
+// dispatcher is the package dispatcher used by Enqueue (set from main on startup)
+var dispatcher *Dispatcher
+
+// SetDispatcher sets the package dispatcher.
+func SetDispatcher(d *Dispatcher) {
+	dispatcher = d
+}
+
+// Enqueue queues a typed SMS on the package dispatcher. It reports false when SMS isn't
+// configured or the queue is full.
+func Enqueue(smsType, phone, message string) bool {
+	if dispatcher == nil || Default == nil {
+		return false
+	}
+	return dispatcher.Enqueue(smsType, phone, message)
+}
+
+// EnqueueTemplate queues a templated SMS on the package dispatcher (see Dispatcher.EnqueueTemplate).
+func EnqueueTemplate(smsType, phone, key string, params templates.Params) bool {
+	if dispatcher == nil || Default == nil {
+		return false
+	}
+	return dispatcher.EnqueueTemplate(smsType, phone, key, params)
+}
@@ -0,0 +1,109 @@
+package sms
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/sms/templates"
+)
+
+func TestDispatcherBoundsConcurrentSends(t *testing.T) {
+	const workers, messages = 3, 40
+	d := NewDispatcher(workers, messages)
+
+	var inFlight, peak atomic.Int32
+	var wg sync.WaitGroup
+	wg.Add(messages)
+	d.send = func(ctx context.Context, smsType, phone, message string) (string, error) {
+		defer wg.Done()
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		inFlight.Add(-1)
+		return "", nil
+	}
+
+	for i := 0; i < messages; i++ {
+		if !d.Enqueue(TypeMatch, "256700000001", "Match found") {
+			t.Fatalf("enqueue %d refused with room in the queue", i)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx)
+
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("queued messages were not all sent")
+	}
+	if p := peak.Load(); p > workers {
+		t.Errorf("%d sends in flight at once, want at most %d", p, workers)
+	}
+}
+
+func TestDispatcherDropsWhenQueueFull(t *testing.T) {
+	d := NewDispatcher(1, 2) // not started: nothing drains the queue
+	for i := 0; i < 2; i++ {
+		if !d.Enqueue(TypeExpiry, "256700000001", "No match found") {
+			t.Fatalf("enqueue %d refused with room in the queue", i)
+		}
+	}
+	if d.Enqueue(TypeExpiry, "256700000001", "No match found") {
+		t.Fatal("enqueue onto a full queue accepted")
+	}
+	if d.Dropped() != 1 {
+		t.Errorf("dropped %d, want 1", d.Dropped())
+	}
+}
+
+func TestDispatcherRendersTemplateInPlayerLanguage(t *testing.T) {
+	prev := playerLanguage
+	playerLanguage = func(ctx context.Context, phone string) string {
+		if phone == "256700000002" {
+			return templates.Luganda
+		}
+		return templates.English
+	}
+	defer func() { playerLanguage = prev }()
+
+	d := NewDispatcher(1, 4)
+	sent := make(chan string, 2)
+	d.send = func(ctx context.Context, smsType, phone, message string) (string, error) {
+		sent <- message
+		return "", nil
+	}
+	if d.EnqueueTemplate(TypeDecline, "256700000001", "no_such_template", nil) {
+		t.Fatal("unknown template was queued")
+	}
+	params := templates.Params{"Code": "ABC123"}
+	d.EnqueueTemplate(TypeDecline, "256700000001", templates.InviteDeclined, params)
+	d.EnqueueTemplate(TypeDecline, "256700000002", templates.InviteDeclined, params)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx)
+
+	for _, want := range []string{
+		"Your PlayPool match invite (Code: ABC123) was declined. You can create a new match anytime!",
+		"Okuyita kwo ku PlayPool (Koodi: ABC123) kugaaniddwa. Osobola okutandika omuzannyo omulala essaawa yonna!",
+	} {
+		select {
+		case got := <-sent:
+			if got != want {
+				t.Errorf("sent %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("templated message not sent")
+		}
+	}
+}
@@ -9,8 +9,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/playpool/backend/internal/config"
@@ -27,8 +29,39 @@ type Client struct {
 	rateLimitSeconds     int
 	tokenFallbackSeconds int
 	cacheKeyPrefix       string
+
+	// Estimated cost per segment (UGX), by SMS type where configured
+	costPerSegment int
+	costByType     map[string]int
+
+	// Retries of transient failures, and the breaker that pauses sends when DMark is down
+	maxAttempts int
+	retryBase   time.Duration
+	breaker     *breaker
+	stats       sendStats
+
+	// Delivery reports requested from DMark, and whether the SMS log masks numbers
+	dlrURL        string
+	logPhoneLast4 bool
+
+	// Sandbox: every message goes to sandboxNumber (or is only logged) and is recorded
+	sandbox       bool
+	sandboxNumber string
+	sandboxMu     sync.Mutex
+	deliveries    []Delivery
 }
 
+// Delivery is a message handled by the sandbox, kept so staging and tests can inspect it.
+type Delivery struct {
+	Recipient string // the number the message was meant for
+	SentTo    string // the sandbox number it went to ("" when only logged)
+	Message   string // the text as delivered, including the sandbox banner
+	At        time.Time
+}
+
+// maxSandboxDeliveries bounds the in-memory delivery log
+const maxSandboxDeliveries = 200
+
 // Default package-level client (set from main on startup)
 var Default *Client
 
@@ -38,8 +71,13 @@ func SetDefault(c *Client) {
 }
 
 // NewClient constructs a DMark client. Returns nil if not configured.
+// A log-only sandbox (sandbox mode without a test number) needs no DMark credentials.
 func NewClient(cfg *config.Config, rdb *redis.Client) *Client {
-	if cfg == nil || cfg.SMSServiceBaseURL == "" || cfg.SMSServiceUsername == "" || cfg.SMSServicePassword == "" {
+	if cfg == nil {
+		return nil
+	}
+	logOnlySandbox := cfg.SMSSandboxMode && cfg.SMSSandboxNumber == ""
+	if !logOnlySandbox && (cfg.SMSServiceBaseURL == "" || cfg.SMSServiceUsername == "" || cfg.SMSServicePassword == "") {
 		return nil
 	}
 
@@ -52,15 +90,62 @@ func NewClient(cfg *config.Config, rdb *redis.Client) *Client {
 		rateLimitSeconds:     cfg.SMSRateLimitSeconds,
 		tokenFallbackSeconds: cfg.SMSTokenFallbackSeconds,
 		cacheKeyPrefix:       "sms_token:",
+		sandbox:              cfg.SMSSandboxMode,
+		sandboxNumber:        cfg.SMSSandboxNumber,
+		costPerSegment:       cfg.SMSCostPerSegment,
+		costByType:           cfg.SMSCostPerSegmentByType,
+		maxAttempts:          max(cfg.SMSMaxAttempts, 1),
+		retryBase:            time.Duration(cfg.SMSRetryBaseMillis) * time.Millisecond,
+		breaker:              &breaker{threshold: cfg.SMSBreakerFailures, cooldown: time.Duration(cfg.SMSBreakerCooldownSeconds) * time.Second},
+		dlrURL:               cfg.SMSDeliveryReportURL,
+		logPhoneLast4:        cfg.SMSLogPhoneLast4,
+	}
+}
+
+// sandboxRedirect rewrites a message for the sandbox and records it.
+// send is false when there is no test number and the message should only be logged.
+func (c *Client) sandboxRedirect(phone, message string) (to, text string, send bool) {
+	text = fmt.Sprintf("[SANDBOX to %s] %s", phone, message)
+
+	c.sandboxMu.Lock()
+	c.deliveries = append(c.deliveries, Delivery{Recipient: phone, SentTo: c.sandboxNumber, Message: text, At: time.Now()})
+	if len(c.deliveries) > maxSandboxDeliveries {
+		c.deliveries = c.deliveries[len(c.deliveries)-maxSandboxDeliveries:]
+	}
+	c.sandboxMu.Unlock()
+
+	if c.sandboxNumber == "" {
+		log.Printf("[SMS SANDBOX] %s", text)
+		return "", text, false
+	}
+	log.Printf("[SMS SANDBOX] redirecting SMS for %s to %s", phone, c.sandboxNumber)
+	return c.sandboxNumber, text, true
+}
+
+// SandboxDeliveries returns the messages the sandbox has handled, oldest first.
+func (c *Client) SandboxDeliveries() []Delivery {
+	if c == nil {
+		return nil
 	}
+	c.sandboxMu.Lock()
+	defer c.sandboxMu.Unlock()
+	out := make([]Delivery, len(c.deliveries))
+	copy(out, c.deliveries)
+	return out
 }
 
 // SendSMS sends a single SMS to the given phone number using DMark API.
 // Returns a provider message id (if available) and an error if the operation definitively failed.
 func (c *Client) SendSMS(ctx context.Context, phone string, message string) (string, error) {
+	return c.send(ctx, TypeOther, phone, message)
+}
+
+// send delivers message and records its estimated cost under smsType once DMark accepts it
+func (c *Client) send(ctx context.Context, smsType, phone, message string) (string, error) {
 	if c == nil {
 		return "", errors.New("sms client not configured")
 	}
+	recipient := phone
 
 	// Rate limit per phone
 	if c.rdb != nil && c.rateLimitSeconds > 0 {
@@ -72,23 +157,43 @@ func (c *Client) SendSMS(ctx context.Context, phone string, message string) (str
 		// ignore Redis errors and proceed
 	}
 
+	// Sandbox: rate limit by the real recipient above, deliver only to the test number
+	if c.sandbox {
+		to, text, send := c.sandboxRedirect(phone, message)
+		if !send {
+			return "", nil
+		}
+		phone, message = to, text
+	}
+
 	formatted := formatPhoneForDMark(phone)
 
-	// Retry loop for transient errors
+	if !c.breaker.allow() {
+		c.stats.shortCircuited.Add(1)
+		return "", ErrCircuitOpen
+	}
+
+	// Retry loop for transient errors (token fetch, network, 5xx)
 	var lastErr error
-	for attempt := 0; attempt < 3; attempt++ {
+	for attempt := 0; attempt < c.maxAttempts; attempt++ {
+		if attempt > 0 {
+			if err := c.backoff(ctx, attempt); err != nil {
+				lastErr = err
+				break
+			}
+		}
+		c.stats.attempts.Add(1)
+
 		accessToken, err := c.getAccessToken(ctx)
 		if err != nil {
 			lastErr = err
-			// small backoff
-			time.Sleep(time.Duration(100+attempt*200) * time.Millisecond)
 			continue
 		}
 
 		payload := map[string]interface{}{
 			"msg":     message,
 			"numbers": formatted,
-			"dlr_url": "",
+			"dlr_url": c.dlrURL,
 			"scan_ip": false,
 		}
 
@@ -100,41 +205,46 @@ func (c *Client) SendSMS(ctx context.Context, phone string, message string) (str
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
 			lastErr = err
-			if attempt < 2 {
-				time.Sleep(time.Duration(100+attempt*200) * time.Millisecond)
-				continue
-			}
-			break
+			continue
 		}
 
 		body, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
 
 		if resp.StatusCode == 200 {
+			c.stats.successes.Add(1)
+			c.breaker.success()
+			var msgID string
 			var parsed map[string]interface{}
 			if err := json.Unmarshal(body, &parsed); err == nil {
 				// try common keys for message id
 				if v, ok := parsed["msg_id"].(string); ok {
-					return v, nil
-				}
-				if v, ok := parsed["message_id"].(string); ok {
-					return v, nil
+					msgID = v
+				} else if v, ok := parsed["message_id"].(string); ok {
+					msgID = v
 				}
 			}
-			return "", nil
+			segments := Segments(message)
+			recordSent(ctx, SentMessage{Type: smsType, Phone: c.loggedPhone(recipient), Segments: segments, EstimatedCost: c.estimatedCost(smsType, segments), ProviderID: msgID})
+			return msgID, nil
 		}
 
 		// For 5xx transient errors retry
-		if resp.StatusCode >= 500 && attempt < 2 {
+		if resp.StatusCode >= 500 {
 			lastErr = fmt.Errorf("sms provider error %d: %s", resp.StatusCode, string(body))
-			time.Sleep(time.Duration(100+attempt*200) * time.Millisecond)
 			continue
 		}
 
-		// 4xx or exhausted retries
+		// 4xx: the request itself was refused, so retrying won't help and DMark is up
+		c.stats.failures.Add(1)
 		return "", fmt.Errorf("sms send failed: %d %s", resp.StatusCode, string(body))
 	}
 
+	c.stats.failures.Add(1)
+	if ctx.Err() == nil {
+		// Only the provider failing counts towards the breaker, not a caller giving up
+		c.breaker.failure()
+	}
 	if lastErr != nil {
 		return "", lastErr
 	}
@@ -0,0 +1,54 @@
+package sms
+
+import (
+	"context"
+	"testing"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+func TestSandboxRedirectsToTestNumber(t *testing.T) {
+	sent := newMockSMS(t, func(cfg *config.Config) {
+		cfg.SMSSandboxMode = true
+		cfg.SMSSandboxNumber = "256700999999"
+	})
+
+	msg := "PlayPool: Match found! Playing against Two for 1000 UGX."
+	if _, err := Notify(context.Background(), TypeMatch, "256772123456", msg); err != nil {
+		t.Fatalf("notify: %v", err)
+	}
+
+	if len(*sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(*sent))
+	}
+	if (*sent)[0]["numbers"] != "0700999999" {
+		t.Errorf("sent to %v, want the sandbox number", (*sent)[0]["numbers"])
+	}
+	want := "[SANDBOX to 256772123456] " + msg
+	if (*sent)[0]["msg"] != want {
+		t.Errorf("msg = %q, want %q", (*sent)[0]["msg"], want)
+	}
+
+	d := Default.SandboxDeliveries()
+	if len(d) != 1 || d[0].Recipient != "256772123456" || d[0].SentTo != "256700999999" || d[0].Message != want {
+		t.Errorf("deliveries = %+v", d)
+	}
+}
+
+func TestSandboxWithoutNumberOnlyLogs(t *testing.T) {
+	prev := Default
+	defer func() { Default = prev }()
+	// No DMark credentials needed for a log-only sandbox
+	SetDefault(NewClient(&config.Config{SMSSandboxMode: true}, nil))
+	if Default == nil {
+		t.Fatal("log-only sandbox client not created")
+	}
+
+	if _, err := SendSMS(context.Background(), "256772123456", "hello"); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	d := Default.SandboxDeliveries()
+	if len(d) != 1 || d[0].SentTo != "" || d[0].Message != "[SANDBOX to 256772123456] hello" {
+		t.Errorf("deliveries = %+v", d)
+	}
+}
@@ -0,0 +1,29 @@
+package sms
+
+import (
+	"context"
+
+	"github.com/playpool/backend/internal/sms/templates"
+)
+
+// playerLanguage returns the SMS language of the player with this phone, English if unknown.
+// Swapped out in tests.
+var playerLanguage = func(ctx context.Context, phone string) string {
+	if prefsDB == nil {
+		return templates.DefaultLanguage
+	}
+	var lang string
+	if err := prefsDB.GetContext(ctx, &lang, `SELECT language FROM players WHERE phone_number=$1`, phone); err != nil {
+		return templates.DefaultLanguage
+	}
+	return lang
+}
+
+// NotifyTemplate renders the template key in the recipient's language and sends it as Notify does.
+func NotifyTemplate(ctx context.Context, smsType, phone, key string, params templates.Params) (string, error) {
+	msg, err := templates.Render(playerLanguage(ctx, phone), key, params)
+	if err != nil {
+		return "", err
+	}
+	return Notify(ctx, smsType, phone, msg)
+}
@@ -0,0 +1,73 @@
+package sms
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/playpool/backend/internal/logger"
+)
+
+// SMS types a player can be notified with. OTP is mandatory; the rest can be opted out of.
+const (
+	TypeOTP     = "otp"
+	TypeMatch   = "match"
+	TypeInvite  = "invite"
+	TypeExpiry  = "expiry"
+	TypeDecline = "decline"
+	TypePayment = "payment"
+	TypeAccount = "account" // account notices (blocks); mandatory like OTP
+	TypeOther   = "other"   // untyped sends (SendSMS); only used for cost reporting
+)
+
+// OptionalTypes lists the SMS types a player may turn off.
+var OptionalTypes = []string{TypeMatch, TypeInvite, TypeExpiry, TypeDecline, TypePayment}
+
+// IsOptionalType reports whether smsType can be opted out of.
+func IsOptionalType(smsType string) bool {
+	for _, t := range OptionalTypes {
+		if t == smsType {
+			return true
+		}
+	}
+	return false
+}
+
+// prefsDB backs the opt-out lookup and the sent-message log (set from main on startup)
+var prefsDB *sqlx.DB
+
+// SetPreferencesDB sets the database used to read notification preferences.
+func SetPreferencesDB(db *sqlx.DB) {
+	prefsDB = db
+}
+
+// optedOut reports whether the player with this phone turned smsType off.
+// Swapped out in tests.
+var optedOut = func(ctx context.Context, phone, smsType string) bool {
+	if prefsDB == nil {
+		return false
+	}
+	var enabled bool
+	err := prefsDB.GetContext(ctx, &enabled, `SELECT np.enabled FROM notification_preferences np
+        JOIN players p ON p.id = np.player_id
+        WHERE p.phone_number=$1 AND np.sms_type=$2`, phone, smsType)
+	if err != nil {
+		// No row (default on) or lookup failure: don't drop the notification
+		return false
+	}
+	return !enabled
+}
+
+// Notify sends a typed SMS through the Default client, honouring the recipient's
+// notification preferences. OTP (and any unknown type) always sends.
+// A suppressed message returns an empty id and no error.
+func Notify(ctx context.Context, smsType, phone, message string) (string, error) {
+	if IsOptionalType(smsType) && optedOut(ctx, phone, smsType) {
+		logger.For(ctx, "sms").Info("sms suppressed by player preference", "type", smsType, "phone", phone)
+		return "", nil
+	}
+	if Default == nil {
+		return "", errors.New("sms not configured")
+	}
+	return Default.send(ctx, smsType, phone, message)
+}
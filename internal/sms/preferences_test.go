@@ -0,0 +1,97 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+// newMockSMS installs a Default client backed by a fake DMark server and returns the sent messages.
+// mutate (optional) adjusts the client config, e.g. to enable the sandbox.
+func newMockSMS(t *testing.T, mutate func(cfg *config.Config)) *[]map[string]interface{} {
+	t.Helper()
+	var mu sync.Mutex
+	sent := &[]map[string]interface{}{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/get_token/":
+			w.Write([]byte(`{"access_token":"tok"}`))
+		case "/v3/api/send_sms/":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			mu.Lock()
+			*sent = append(*sent, body)
+			mu.Unlock()
+			w.Write([]byte(`{"msg_id":"m1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := &config.Config{
+		SMSServiceBaseURL:       srv.URL,
+		SMSServiceUsername:      "user",
+		SMSServicePassword:      "pass",
+		SMSTokenFallbackSeconds: 60,
+	}
+	if mutate != nil {
+		mutate(cfg)
+	}
+	prev := Default
+	SetDefault(NewClient(cfg, nil))
+	t.Cleanup(func() { Default = prev })
+	return sent
+}
+
+func TestNotifyHonoursOptOut(t *testing.T) {
+	sent := newMockSMS(t, nil)
+
+	prev := optedOut
+	optedOut = func(ctx context.Context, phone, smsType string) bool {
+		return phone == "256700000001" && smsType == TypeMatch
+	}
+	defer func() { optedOut = prev }()
+
+	ctx := context.Background()
+	if id, err := Notify(ctx, TypeMatch, "256700000001", "Match found"); err != nil || id != "" {
+		t.Fatalf("suppressed match SMS: id=%q err=%v", id, err)
+	}
+	if len(*sent) != 0 {
+		t.Fatalf("opted-out match SMS was sent: %v", *sent)
+	}
+
+	// OTP is mandatory even for a player who opted out of everything
+	optedOut = func(ctx context.Context, phone, smsType string) bool { return true }
+	if _, err := Notify(ctx, TypeOTP, "256700000001", "Your PlayPool OTP is 1234"); err != nil {
+		t.Fatalf("otp: %v", err)
+	}
+	// Other players and types are unaffected
+	optedOut = prev
+	if _, err := Notify(ctx, TypeMatch, "256700000002", "Match found"); err != nil {
+		t.Fatalf("match to other player: %v", err)
+	}
+
+	if len(*sent) != 2 {
+		t.Fatalf("sent %d messages, want 2", len(*sent))
+	}
+	if (*sent)[0]["msg"] != "Your PlayPool OTP is 1234" {
+		t.Errorf("first message = %v, want the OTP", (*sent)[0]["msg"])
+	}
+}
+
+func TestIsOptionalType(t *testing.T) {
+	if IsOptionalType(TypeOTP) {
+		t.Error("OTP must not be optional")
+	}
+	for _, typ := range []string{TypeMatch, TypeInvite, TypeExpiry, TypeDecline, TypePayment} {
+		if !IsOptionalType(typ) {
+			t.Errorf("%s should be optional", typ)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting DMark while the breaker is open
+var ErrCircuitOpen = errors.New("sms provider unavailable (circuit open)")
+
+// breaker refuses sends for cooldown once threshold messages in a row have failed,
+// so callers don't pile up behind a provider that is down. Once the cooldown passes
+// sends go through again; the next failure reopens it, the next success closes it.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+}
+
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !time.Now().Before(b.openUntil)
+}
+
+func (b *breaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return time.Now().Before(b.openUntil)
+}
+
+func (b *breaker) success() {
+	b.mu.Lock()
+	b.failures = 0
+	b.openUntil = time.Time{}
+	b.mu.Unlock()
+}
+
+func (b *breaker) failure() {
+	if b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.cooldown)
+		log.Printf("[SMS] %d consecutive send failures, pausing sends for %v", b.failures, b.cooldown)
+	}
+}
+
+// sendStats counts outbound send work; attempts include retries
+type sendStats struct {
+	attempts       atomic.Int64
+	successes      atomic.Int64
+	failures       atomic.Int64
+	shortCircuited atomic.Int64
+}
+
+// SendStats is a snapshot of a client's send counters.
+type SendStats struct {
+	Attempts       int64 `json:"attempts"`        // HTTP sends tried, retries included
+	Successes      int64 `json:"successes"`       // messages accepted
+	Failures       int64 `json:"failures"`        // messages given up on
+	ShortCircuited int64 `json:"short_circuited"` // messages refused while the breaker was open
+	CircuitOpen    bool  `json:"circuit_open"`
+}
+
+// Stats returns the client's send counters (zero for a nil client).
+func (c *Client) Stats() SendStats {
+	if c == nil {
+		return SendStats{}
+	}
+	return SendStats{
+		Attempts:       c.stats.attempts.Load(),
+		Successes:      c.stats.successes.Load(),
+		Failures:       c.stats.failures.Load(),
+		ShortCircuited: c.stats.shortCircuited.Load(),
+		CircuitOpen:    c.breaker.open(),
+	}
+}
+
+// backoff waits before retry attempt (1-based): retryBase doubled per retry, with the
+// upper half jittered so concurrent senders don't retry in lockstep
+func (c *Client) backoff(ctx context.Context, attempt int) error {
+	d := c.retryBase << (attempt - 1)
+	if d > 0 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
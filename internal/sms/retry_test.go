@@ -0,0 +1,124 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/playpool/backend/internal/config"
+)
+
+// roundTripFunc fakes DMark at the transport level
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func reply(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}}
+}
+
+// flakyClient returns a client whose send endpoint answers with the next of responses
+// (a nil response means a network error) and a count of send requests made
+func flakyClient(t *testing.T, breakerFailures int, responses ...*http.Response) (*Client, func() int) {
+	t.Helper()
+	var mu sync.Mutex
+	sends := 0
+	c := NewClient(&config.Config{
+		SMSServiceBaseURL:         "http://dmark.test",
+		SMSServiceUsername:        "user",
+		SMSServicePassword:        "pass",
+		SMSMaxAttempts:            3,
+		SMSRetryBaseMillis:        1,
+		SMSBreakerFailures:        breakerFailures,
+		SMSBreakerCooldownSeconds: 60,
+	}, nil)
+	c.httpClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/api/get_token/" {
+			return reply(200, `{"access_token":"tok"}`), nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		i := sends
+		sends++
+		if i >= len(responses) {
+			i = len(responses) - 1
+		}
+		if responses[i] == nil {
+			return nil, errors.New("connection reset")
+		}
+		return responses[i], nil
+	})}
+	return c, func() int { mu.Lock(); defer mu.Unlock(); return sends }
+}
+
+func TestSendRetriesTransientErrors(t *testing.T) {
+	c, sends := flakyClient(t, 0, nil, reply(503, "busy"), reply(200, `{"msg_id":"m9"}`))
+
+	id, err := c.SendSMS(context.Background(), "256700000001", "hello")
+	if err != nil || id != "m9" {
+		t.Fatalf("send: id %q err %v, want m9 after retries", id, err)
+	}
+	if sends() != 3 {
+		t.Errorf("%d send requests, want 3", sends())
+	}
+	if s := c.Stats(); s.Attempts != 3 || s.Successes != 1 || s.Failures != 0 {
+		t.Errorf("stats %+v, want 3 attempts, 1 success", s)
+	}
+}
+
+func TestSendGivesUpOnPermanentError(t *testing.T) {
+	c, sends := flakyClient(t, 0, reply(503, "busy"), reply(400, "bad number"))
+
+	if _, err := c.SendSMS(context.Background(), "256700000001", "hello"); err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatalf("send: err %v, want the 400", err)
+	}
+	if sends() != 2 {
+		t.Errorf("%d send requests, want 2 (no retry after 4xx)", sends())
+	}
+	if s := c.Stats(); s.Attempts != 2 || s.Failures != 1 {
+		t.Errorf("stats %+v, want 2 attempts, 1 failure", s)
+	}
+}
+
+func TestBreakerShortCircuitsFailingProvider(t *testing.T) {
+	c, sends := flakyClient(t, 2, reply(500, "down"))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.SendSMS(ctx, "256700000001", "hello"); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("send %d: err %v, want provider error", i, err)
+		}
+	}
+	if sends() != 6 {
+		t.Fatalf("%d send requests, want 3 attempts for each of 2 messages", sends())
+	}
+
+	if _, err := c.SendSMS(ctx, "256700000001", "hello"); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("send with breaker open: err %v, want ErrCircuitOpen", err)
+	}
+	if sends() != 6 {
+		t.Errorf("breaker open but DMark was called (%d requests)", sends())
+	}
+	if s := c.Stats(); !s.CircuitOpen || s.ShortCircuited != 1 || s.Failures != 2 {
+		t.Errorf("stats %+v, want open breaker, 1 short-circuited, 2 failures", s)
+	}
+
+	// After the cooldown a success closes the breaker again
+	c.breaker.mu.Lock()
+	c.breaker.openUntil = time.Now()
+	c.breaker.mu.Unlock()
+	c.httpClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return reply(200, `{"access_token":"tok","msg_id":"m1"}`), nil
+	})
+	if _, err := c.SendSMS(ctx, "256700000001", "hello"); err != nil {
+		t.Fatalf("send after cooldown: %v", err)
+	}
+	if c.Stats().CircuitOpen {
+		t.Error("breaker still open after a successful send")
+	}
+}
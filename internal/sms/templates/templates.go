@@ -0,0 +1,153 @@
+// Package templates holds the copy for every SMS the platform sends, keyed by name and
+// translated per language, so wording lives in one place instead of at each call site.
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Supported languages (players.language)
+const (
+	English = "en"
+	Luganda = "lg"
+)
+
+// DefaultLanguage is used for players without a preference and for missing translations
+const DefaultLanguage = English
+
+// Template keys
+const (
+	MatchFound          = "match_found"           // matchmaker paired two paid players
+	Match               = "match"                 // instant match from the stake queue
+	PrivateMatch        = "private_match"         // private match code joined
+	TournamentMatch     = "tournament_match"      // tournament round game ready
+	QueueExpired        = "queue_expired"         // no opponent before the queue timeout
+	Invite              = "invite"                // private match invite to a friend
+	InviteDeclined      = "invite_declined"       // the invited friend declined
+	PaymentReceived     = "payment_received"      // payin succeeded
+	PayinReminder       = "payin_reminder"        // payin still pending at the provider
+	OTP                 = "otp"                   // player login code
+	AdminOTP            = "admin_otp"             // admin login code
+	AccountBlocked      = "account_blocked"       // an admin blocked the player
+	AccountBlockedUntil = "account_blocked_until" // an admin blocked the player for a while
+	AccountUnblocked    = "account_unblocked"     // the block was lifted
+	StakingSuspended    = "staking_suspended"     // automatic block after repeated no-shows
+	TransferReceived    = "transfer_received"     // another player sent winnings
+)
+
+// ErrUnknownTemplate is returned for a key with no template
+var ErrUnknownTemplate = errors.New("unknown sms template")
+
+// Params are the values a template refers to as {{.Name}}
+type Params map[string]interface{}
+
+// texts is every template's text by key, then language. Each key has an English version.
+var texts = map[string]map[string]string{
+	MatchFound: {
+		English: "PlayPool: Match found! Playing against {{.Opponent}} for {{.Stake}} UGX.\n\n{{.Link}}",
+		Luganda: "PlayPool: Ozuuliddwa omuzannyi! Ozannya ne {{.Opponent}} ku {{.Stake}} UGX.\n\n{{.Link}}",
+	},
+	Match: {
+		English: "Matched on PlayPool vs {{.Opponent}}! Stake {{.Stake}} UGX. Join: {{.Link}}",
+		Luganda: "PlayPool: Ozannya ne {{.Opponent}}! Ssente {{.Stake}} UGX. Yingira: {{.Link}}",
+	},
+	PrivateMatch: {
+		English: "Private match found with {{.Opponent}}! Stake {{.Stake}} UGX. Join: {{.Link}}",
+		Luganda: "PlayPool: Omuzannyo gwo ne {{.Opponent}} gwetegese! Ssente {{.Stake}} UGX. Yingira: {{.Link}}",
+	},
+	TournamentMatch: {
+		English: "{{.Tournament}} round {{.Round}}: your match is ready. Join: {{.Link}}",
+		Luganda: "{{.Tournament}} omutendera {{.Round}}: omuzannyo gwo gwetegese. Yingira: {{.Link}}",
+	},
+	QueueExpired: {
+		English: "PlayPool: No match found for your {{.Stake}} UGX stake. Click to try again: {{.Link}}",
+		Luganda: "PlayPool: Tetufunye muzannyi ku {{.Stake}} UGX zo. Nyiga wano oddemu: {{.Link}}",
+	},
+	Invite: {
+		English: "Join my PlayPool match!\nCode: {{.Code}}\nStake: {{.Stake}} UGX\n\n{{.Link}}",
+		Luganda: "Yingira omuzannyo gwange ku PlayPool!\nKoodi: {{.Code}}\nSsente: {{.Stake}} UGX\n\n{{.Link}}",
+	},
+	InviteDeclined: {
+		English: "Your PlayPool match invite (Code: {{.Code}}) was declined. You can create a new match anytime!",
+		Luganda: "Okuyita kwo ku PlayPool (Koodi: {{.Code}}) kugaaniddwa. Osobola okutandika omuzannyo omulala essaawa yonna!",
+	},
+	PaymentReceived: {
+		English: "PlayPool: Payment of {{.Amount}} UGX received. You can now join a game!",
+		Luganda: "PlayPool: Tufunye {{.Amount}} UGX. Kati osobola okuyingira omuzannyo!",
+	},
+	PayinReminder: {
+		English: "PlayPool: Your {{.Stake}} UGX stake is waiting for payment. Approve it on your phone within {{.Minutes}} min or restart here: {{.Link}}",
+		Luganda: "PlayPool: {{.Stake}} UGX zo zirinze okusasulwa. Kkiriza ku ssimu yo mu dakiika {{.Minutes}} oba tandika buggya wano: {{.Link}}",
+	},
+	OTP: {
+		English: "Your PlayPool OTP is {{.Code}}. It expires in {{.Minutes}} minutes.",
+		Luganda: "Koodi yo eya PlayPool ye {{.Code}}. Eggwaako mu dakiika {{.Minutes}}.",
+	},
+	AdminOTP: {
+		English: "Your PlayPool admin OTP is: {{.Code}}. Valid for 5 minutes.",
+	},
+	AccountBlocked: {
+		English: "PlayPool: Your account has been blocked from staking. Reason: {{.Reason}}. Any queued stake was refunded to your winnings.",
+		Luganda: "PlayPool: Akawunti yo eziyiziddwa okuteeka ssente. Ensonga: {{.Reason}}. Ssente zo ezaali zirinze zizziddwa mu winnings zo.",
+	},
+	AccountBlockedUntil: {
+		English: "PlayPool: Your account has been blocked from staking until {{.Until}}. Reason: {{.Reason}}. Any queued stake was refunded to your winnings.",
+		Luganda: "PlayPool: Akawunti yo eziyiziddwa okuteeka ssente okutuusa {{.Until}}. Ensonga: {{.Reason}}. Ssente zo ezaali zirinze zizziddwa mu winnings zo.",
+	},
+	AccountUnblocked: {
+		English: "PlayPool: Your account has been unblocked. You can stake and play again!",
+		Luganda: "PlayPool: Akawunti yo eggyiddwako ekiziyiza. Osobola okuddamu okuzannya!",
+	},
+	StakingSuspended: {
+		English: "PlayPool: You missed or abandoned {{.Strikes}} matched games, so staking is paused until {{.Until}}.",
+		Luganda: "PlayPool: Emizannyo {{.Strikes}} gy'otaazannya oba gy'walekawo, n'olwekyo okuteeka ssente kuyimiriziddwa okutuusa {{.Until}}.",
+	},
+	TransferReceived: {
+		English: "PlayPool: {{.Sender}} sent you {{.Amount}} UGX. It is in your winnings, ready to stake or withdraw.",
+		Luganda: "PlayPool: {{.Sender}} akuweerezza {{.Amount}} UGX. Ziri mu winnings zo, osobola okuzizannyisa oba okuziggyayo.",
+	},
+}
+
+// parsed holds the compiled templates, by key then language
+var parsed = func() map[string]map[string]*template.Template {
+	out := make(map[string]map[string]*template.Template, len(texts))
+	for key, langs := range texts {
+		out[key] = make(map[string]*template.Template, len(langs))
+		for lang, text := range langs {
+			out[key][lang] = template.Must(template.New(key + "." + lang).Option("missingkey=error").Parse(text))
+		}
+	}
+	return out
+}()
+
+// Has reports whether key names a template
+func Has(key string) bool {
+	_, ok := parsed[key]
+	return ok
+}
+
+// Supported reports whether lang is a language players can choose
+func Supported(lang string) bool {
+	return lang == English || lang == Luganda
+}
+
+// Render fills in the template key in lang with params. A language the template has no
+// translation for falls back to English; an unknown key or a missing param is an error.
+func Render(lang, key string, params Params) (string, error) {
+	langs, ok := parsed[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownTemplate, key)
+	}
+	t, ok := langs[lang]
+	if !ok {
+		t = langs[DefaultLanguage]
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, params); err != nil {
+		return "", fmt.Errorf("render %s: %w", key, err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,58 @@
+package templates
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRenderKnownKey(t *testing.T) {
+	params := Params{"Opponent": "Musa", "Stake": 5000, "Link": "https://play.example/g/abc"}
+	got, err := Render(English, Match, params)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if want := "Matched on PlayPool vs Musa! Stake 5000 UGX. Join: https://play.example/g/abc"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	got, err = Render(Luganda, Match, params)
+	if err != nil {
+		t.Fatalf("render lg: %v", err)
+	}
+	if want := "PlayPool: Ozannya ne Musa! Ssente 5000 UGX. Yingira: https://play.example/g/abc"; got != want {
+		t.Errorf("lg: got %q, want %q", got, want)
+	}
+}
+
+func TestRenderFallsBackToEnglish(t *testing.T) {
+	// No Luganda admin copy, and no Swahili at all
+	for _, lang := range []string{Luganda, "sw", ""} {
+		got, err := Render(lang, AdminOTP, Params{"Code": "123456"})
+		if err != nil || got != "Your PlayPool admin OTP is: 123456. Valid for 5 minutes." {
+			t.Errorf("lang %q: got %q, %v", lang, got, err)
+		}
+	}
+}
+
+func TestRenderUnknownKeyErrors(t *testing.T) {
+	if _, err := Render(English, "no_such_template", nil); !errors.Is(err, ErrUnknownTemplate) {
+		t.Fatalf("err = %v, want ErrUnknownTemplate", err)
+	}
+	if Has("no_such_template") {
+		t.Error("Has reports an unknown key")
+	}
+}
+
+func TestRenderMissingParamErrors(t *testing.T) {
+	if _, err := Render(English, OTP, Params{"Code": "123456"}); err == nil {
+		t.Fatal("rendered OTP without Minutes")
+	}
+}
+
+func TestEveryTemplateHasEnglish(t *testing.T) {
+	for key, langs := range texts {
+		if _, ok := langs[English]; !ok {
+			t.Errorf("template %s has no English copy", key)
+		}
+	}
+}
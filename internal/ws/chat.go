@@ -0,0 +1,57 @@
+package ws
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/playpool/backend/internal/game"
+)
+
+const (
+	chatMaxRunes    = 200         // longer messages are cut
+	chatMinInterval = time.Second // faster messages are dropped
+)
+
+// ChatData is the payload of a "chat" message
+type ChatData struct {
+	Message string `json:"message"`
+}
+
+// relayChat forwards a player's chat line to their opponent (spectators never see chat) and
+// logs it for moderation when logging is on. It returns a reason when the line is refused.
+func (h *Hub) relayChat(c *Client, g *game.PoolGameState, raw json.RawMessage, now time.Time) string {
+	if wsConfig == nil || !wsConfig.GameChatEnabled {
+		return "Chat is disabled"
+	}
+	var data ChatData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return "Invalid chat data"
+	}
+	text := strings.TrimSpace(data.Message)
+	if text == "" {
+		return "Empty message"
+	}
+	if utf8.RuneCountInString(text) > chatMaxRunes {
+		text = string([]rune(text)[:chatMaxRunes])
+	}
+	if now.Sub(c.lastChat) < chatMinInterval {
+		return "You are sending messages too fast"
+	}
+	c.lastChat = now
+
+	h.SendToPlayer(g.GetOpponentID(c.playerID), map[string]interface{}{
+		"type":    "chat",
+		"from":    c.playerID,
+		"message": text,
+		"sent_at": now.UTC(),
+	})
+
+	if wsConfig.GameChatLogging {
+		if p := g.GetPlayerByID(c.playerID); p != nil {
+			game.Manager.RecordChat(g.SessionID, p.DBPlayerID, text)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,39 @@
+package ws
+
+import (
+	"strings"
+
+	"github.com/playpool/backend/internal/game"
+)
+
+// compactStateCap is the capability a client lists in ?caps= to receive compact game_state
+const compactStateCap = "compact_state"
+
+// wantsCompactState reports whether the comma-separated caps include compact state and the
+// server has it enabled. Everyone else keeps the plain JSON ball list.
+func wantsCompactState(caps string) bool {
+	if wsConfig == nil || !wsConfig.PoolCompactState {
+		return false
+	}
+	for _, c := range strings.Split(caps, ",") {
+		if strings.TrimSpace(c) == compactStateCap {
+			return true
+		}
+	}
+	return false
+}
+
+// gameStateMessage builds the game_state sent to this client on (re)connect and resync.
+// Compact clients get balls_compact (see game.EncodeCompactBalls) instead of balls.
+func (c *Client) gameStateMessage(g *game.PoolGameState) map[string]interface{} {
+	state := g.GetGameStateForPlayer(c.playerID)
+	state["type"] = "game_state"
+	if c.compactState {
+		if balls, ok := state["balls"].([]game.BallState); ok {
+			delete(state, "balls")
+			state["balls_compact"] = game.EncodeCompactBalls(balls)
+			state["balls_encoding"] = game.CompactBallsEncoding
+		}
+	}
+	return state
+}
@@ -0,0 +1,74 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Drain marks the hub as shutting down and sends server_draining to every player and
+// spectator, so clients reconnect (to another instance) instead of reporting an error.
+// It returns how many connections were notified.
+func (h *Hub) Drain() int {
+	h.draining.Store(true)
+
+	notice := refusalHint(RefusalDraining)
+	notice["type"] = "server_draining"
+	notice["message"] = "Server is restarting. Your game is saved; reconnecting shortly."
+	data, _ := json.Marshal(notice)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	notified := 0
+	notify := func(c *Client) {
+		select {
+		case c.send <- data:
+			notified++
+		default:
+			log.Printf("[WS] Drain notice dropped for player %s (buffer full)", c.playerID)
+		}
+	}
+	for _, c := range h.clients {
+		notify(c)
+	}
+	for _, watchers := range h.spectators {
+		for c := range watchers {
+			notify(c)
+		}
+	}
+	return notified
+}
+
+// Draining reports whether the hub has started shutting down.
+func (h *Hub) Draining() bool {
+	return h.draining.Load()
+}
+
+// CloseAll closes every player and spectator connection with a going-away close frame
+// carrying the retry hint.
+func (h *Hub) CloseAll() {
+	h.mu.RLock()
+	var conns []*websocket.Conn
+	for _, c := range h.clients {
+		if c.conn != nil {
+			conns = append(conns, c.conn)
+		}
+	}
+	for _, watchers := range h.spectators {
+		for c := range watchers {
+			if c.conn != nil {
+				conns = append(conns, c.conn)
+			}
+		}
+	}
+	h.mu.RUnlock()
+
+	closeMsg := refusalCloseMessage(websocket.CloseGoingAway, RefusalDraining)
+	for _, conn := range conns {
+		conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second))
+		conn.Close()
+	}
+}
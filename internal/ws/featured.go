@@ -0,0 +1,104 @@
+package ws
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/playpool/backend/internal/game"
+)
+
+// viewerScore is a game's decayed spectator count as of at
+type viewerScore struct {
+	value float64
+	at    time.Time
+}
+
+// FeaturedGame is one entry of the featured (watchable) games list
+type FeaturedGame struct {
+	Token       string  `json:"token"`
+	StakeAmount int     `json:"stake_amount"`
+	Player1     string  `json:"player1"`
+	Player2     string  `json:"player2"`
+	Spectators  int     `json:"spectators"`
+	ViewerScore float64 `json:"viewer_score"`
+	Rank        float64 `json:"rank"`
+}
+
+// featuredHalfLife is how long a departed audience keeps half its weight in the ranking
+func featuredHalfLife() time.Duration {
+	if wsConfig != nil && wsConfig.FeaturedViewerHalfLifeSeconds > 0 {
+		return time.Duration(wsConfig.FeaturedViewerHalfLifeSeconds) * time.Second
+	}
+	return 2 * time.Minute
+}
+
+// featuredStakePerViewer is how many UGX of stake rank the same as one viewer
+func featuredStakePerViewer() float64 {
+	if wsConfig != nil && wsConfig.FeaturedStakePerViewer > 0 {
+		return float64(wsConfig.FeaturedStakePerViewer)
+	}
+	return 5000
+}
+
+// decayViewers returns the viewer score at now: the current audience, or the earlier score
+// decayed exponentially, whichever is larger. New viewers count at once; departed ones fade.
+func decayViewers(s viewerScore, current int, now time.Time, halfLife time.Duration) float64 {
+	decayed := 0.0
+	if !s.at.IsZero() {
+		decayed = s.value * math.Exp2(-float64(now.Sub(s.at))/float64(halfLife))
+	}
+	return math.Max(float64(current), decayed)
+}
+
+// touchViewerScoreLocked folds the audience so far into the game's score. Call it just
+// before the spectator count changes. Caller must hold h.mu for writing.
+func (h *Hub) touchViewerScoreLocked(gameID string, now time.Time) {
+	current := len(h.spectators[gameID])
+	h.viewerScores[gameID] = viewerScore{
+		value: decayViewers(h.viewerScores[gameID], current, now, featuredHalfLife()),
+		at:    now,
+	}
+}
+
+// FeaturedGames ranks games by decayed viewer score plus stake, best first (limit <= 0 = all).
+// Scores of games that are no longer offered are dropped.
+func (h *Hub) FeaturedGames(games []*game.PoolGameState, now time.Time, limit int) []FeaturedGame {
+	halfLife := featuredHalfLife()
+	perViewer := featuredStakePerViewer()
+
+	h.mu.Lock()
+	offered := make(map[string]bool, len(games))
+	featured := make([]FeaturedGame, 0, len(games))
+	for _, g := range games {
+		offered[g.ID] = true
+		spectators := len(h.spectators[g.ID])
+		score := decayViewers(h.viewerScores[g.ID], spectators, now, halfLife)
+		featured = append(featured, FeaturedGame{
+			Token:       g.Token,
+			StakeAmount: g.StakeAmount,
+			Player1:     g.Player1.DisplayName,
+			Player2:     g.Player2.DisplayName,
+			Spectators:  spectators,
+			ViewerScore: math.Round(score*100) / 100,
+			Rank:        score + float64(g.StakeAmount)/perViewer,
+		})
+	}
+	for id := range h.viewerScores {
+		if !offered[id] && len(h.spectators[id]) == 0 {
+			delete(h.viewerScores, id)
+		}
+	}
+	h.mu.Unlock()
+
+	sort.SliceStable(featured, func(i, j int) bool {
+		if featured[i].Rank != featured[j].Rank {
+			return featured[i].Rank > featured[j].Rank
+		}
+		return featured[i].Token < featured[j].Token
+	})
+	if limit > 0 && len(featured) > limit {
+		featured = featured[:limit]
+	}
+	return featured
+}
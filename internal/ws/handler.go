@@ -5,10 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/playpool/backend/internal/logger"
 	"github.com/redis/go-redis/v9"
 
 	"github.com/gorilla/websocket"
@@ -29,16 +32,32 @@ type Client struct {
 	opponentID string
 	gameID     string
 	gameToken  string
+	spectator  bool // watch-only connection, never in clients/gameRooms
 	send       chan []byte
+
+	lastThinking time.Time // last "thinking" signal relayed to the opponent
+	lastChat     time.Time // last chat line relayed to the opponent
+	compactState bool      // client asked for compact ball positions in game_state
+	requestID    string    // id of the upgrade request, carried into connection logs
+}
+
+// logger returns a structured logger tagged with the connection's request, game and player
+func (c *Client) logger() *slog.Logger {
+	ctx := logger.WithRequestID(context.Background(), c.requestID)
+	return logger.For(ctx, "ws").With("game_id", c.gameID, "player_id", c.playerID)
 }
 
 // Hub maintains the set of active clients
 type Hub struct {
 	clients    map[string]*Client            // playerID -> Client
 	gameRooms  map[string]map[string]*Client // gameID -> playerID -> Client
+	spectators map[string]map[*Client]bool   // gameID -> spectator clients
 	register   chan *Client
 	unregister chan *Client
 	mu         sync.RWMutex
+	draining   atomic.Bool // set on shutdown; new connections are refused
+
+	viewerScores map[string]viewerScore // gameID -> decayed audience size (featured ranking)
 }
 
 // NewHub creates a new Hub
@@ -46,8 +65,11 @@ func NewHub() *Hub {
 	return &Hub{
 		clients:    make(map[string]*Client),
 		gameRooms:  make(map[string]map[string]*Client),
+		spectators: make(map[string]map[*Client]bool),
 		register:   make(chan *Client),
 		unregister: make(chan *Client),
+
+		viewerScores: make(map[string]viewerScore),
 	}
 }
 
@@ -74,6 +96,33 @@ func (h *Hub) BroadcastToGame(gameID string, message interface{}) {
 	}
 }
 
+// BroadcastToSpectators sends a message to everyone watching a game
+func (h *Hub) BroadcastToSpectators(gameID string, message interface{}) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		log.Printf("Error marshaling message: %v", err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.spectators[gameID] {
+		select {
+		case client.send <- data:
+		default:
+			log.Printf("[WS] Spectator send buffer full in game %s, dropping message", gameID)
+		}
+	}
+}
+
+// SpectatorCount returns the number of spectators watching a game
+func (h *Hub) SpectatorCount(gameID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.spectators[gameID])
+}
+
 // SendToPlayer sends a message to a specific player
 func (h *Hub) SendToPlayer(playerID string, message interface{}) {
 	data, err := json.Marshal(message)
@@ -103,9 +152,30 @@ type WSMessage struct {
 	Data json.RawMessage `json:"data"`
 }
 
+// pingInterval is how often writePump pings the peer
+func pingInterval() time.Duration {
+	if wsConfig != nil && wsConfig.WSPingIntervalSeconds > 0 {
+		return time.Duration(wsConfig.WSPingIntervalSeconds) * time.Second
+	}
+	return 30 * time.Second
+}
+
+// pongWait is how long readPump waits for a pong (or any message) before treating the
+// connection as dead. It always leaves room for at least one ping.
+func pongWait() time.Duration {
+	wait := 60 * time.Second
+	if wsConfig != nil && wsConfig.WSPongTimeoutSeconds > 0 {
+		wait = time.Duration(wsConfig.WSPongTimeoutSeconds) * time.Second
+	}
+	if wait <= pingInterval() {
+		wait = 2 * pingInterval()
+	}
+	return wait
+}
+
 // writePump writes messages to the WebSocket connection
 func (c *Client) writePump() {
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(pingInterval())
 	defer func() {
 		ticker.Stop()
 		c.conn.Close()
@@ -3,14 +3,18 @@ package ws
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/playpool/backend/internal/game"
+	"github.com/playpool/backend/internal/logger"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -20,6 +24,7 @@ type TakeShotData struct {
 	Power   float64 `json:"power"`
 	Screw   float64 `json:"screw"`
 	English float64 `json:"english"`
+	MoveSeq *int    `json:"move_seq,omitempty"` // move_seq from the state the shot was aimed on
 }
 
 type PlaceCueBallData struct {
@@ -45,14 +50,20 @@ func init() {
 }
 
 // HandleWebSocket handles WebSocket connections for pool games.
+// Passing spectate=true instead of a player token opens a watch-only connection.
 func HandleWebSocket(c *gin.Context) {
 	gameToken := c.Query("token")
 	playerToken := c.Query("pt")
+	spectate := c.Query("spectate") == "true"
 
-	if gameToken == "" || playerToken == "" {
+	if gameToken == "" || (playerToken == "" && !spectate) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "token and pt required"})
 		return
 	}
+	if GameHub.Draining() {
+		refuseConnection(c, http.StatusServiceUnavailable, RefusalDraining, "server is restarting, reconnect shortly")
+		return
+	}
 
 	g, err := game.Manager.GetGameByToken(gameToken)
 	if err != nil {
@@ -60,6 +71,26 @@ func HandleWebSocket(c *gin.Context) {
 		return
 	}
 
+	if spectate {
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			logger.For(c.Request.Context(), "ws").Warn("upgrade failed", "game_id", g.ID, "error", err)
+			return
+		}
+		client := &Client{
+			conn:      conn,
+			gameID:    g.ID,
+			gameToken: gameToken,
+			spectator: true,
+			send:      make(chan []byte, 256),
+			requestID: logger.RequestID(c.Request.Context()),
+		}
+		GameHub.register <- client
+		go client.writePump()
+		go client.readPump()
+		return
+	}
+
 	var playerID string
 	if g.Player1.PlayerToken == playerToken {
 		playerID = g.Player1.ID
@@ -72,17 +103,19 @@ func HandleWebSocket(c *gin.Context) {
 
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		log.Printf("[WS] Upgrade error: %v", err)
+		logger.For(c.Request.Context(), "ws").Warn("upgrade failed", "game_id", g.ID, "player_id", playerID, "error", err)
 		return
 	}
 
 	client := &Client{
-		conn:       conn,
-		playerID:   playerID,
-		opponentID: g.GetOpponentID(playerID),
-		gameID:     g.ID,
-		gameToken:  gameToken,
-		send:       make(chan []byte, 256),
+		conn:         conn,
+		playerID:     playerID,
+		opponentID:   g.GetOpponentID(playerID),
+		gameID:       g.ID,
+		gameToken:    gameToken,
+		send:         make(chan []byte, 256),
+		compactState: wantsCompactState(c.Query("caps")),
+		requestID:    logger.RequestID(c.Request.Context()),
 	}
 
 	GameHub.register <- client
@@ -96,11 +129,17 @@ func runGameHub(h *Hub) {
 	for {
 		select {
 		case client := <-h.register:
+			if client.spectator {
+				h.addSpectator(client)
+				continue
+			}
 			h.mu.Lock()
 
+			// A reconnect replaces only this player's entries; the game's spectators
+			// and viewer score are left alone
 			isReconnect := false
 			if oldClient, exists := h.clients[client.playerID]; exists {
-				log.Printf("[WS] Player %s reconnecting - closing old connection", client.playerID)
+				client.logger().Info("player reconnecting, closing old connection")
 				if err := oldClient.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "replaced by new connection"), time.Now().Add(5*time.Second)); err != nil {
 					log.Printf("Error writing close control to old client %s: %v", oldClient.playerID, err)
 				}
@@ -124,7 +163,7 @@ func runGameHub(h *Hub) {
 			h.gameRooms[client.gameID][client.playerID] = client
 			h.mu.Unlock()
 
-			log.Printf("[WS] Player %s connected to game %s", client.playerID, client.gameID)
+			client.logger().Info("player connected", "reconnect", isReconnect)
 
 			g, err := game.Manager.GetGameByToken(client.gameToken)
 			if err != nil {
@@ -133,6 +172,8 @@ func runGameHub(h *Hub) {
 			}
 
 			client.opponentID = g.GetOpponentID(client.playerID)
+			// Cleared before marking connected so a rejoin within grace is never forfeited
+			rejoined := g.ClearPlayerDisconnectTime(client.playerID)
 			g.SetPlayerConnected(client.playerID, true)
 			g.MarkPlayerShowedUp(client.playerID)
 
@@ -149,10 +190,9 @@ func runGameHub(h *Hub) {
 						return
 					}
 
-					if gRef.SessionID > 0 && game.Manager != nil && gRef.StartedAt != nil {
-						if err := game.Manager.MarkSessionStarted(gRef.SessionID, *gRef.StartedAt); err != nil {
-							log.Printf("[DB] MarkSessionStarted failed for session %d: %v", gRef.SessionID, err)
-						}
+					if !game.Manager.StartSession(gRef) {
+						// A racing connect already started the game and told both players
+						return
 					}
 
 					h.BroadcastToGame(client.gameID, map[string]interface{}{
@@ -173,9 +213,7 @@ func runGameHub(h *Hub) {
 					"message": "Waiting for opponent...",
 				})
 			} else {
-				state := g.GetGameStateForPlayer(client.playerID)
-				state["type"] = "game_state"
-				h.SendToPlayer(client.playerID, state)
+				h.SendToPlayer(client.playerID, client.gameStateMessage(g))
 
 				oppID := g.GetOpponentID(client.playerID)
 				if oppID != "" {
@@ -185,7 +223,9 @@ func runGameHub(h *Hub) {
 				}
 			}
 
-			if isReconnect && g.Status == game.StatusInProgress {
+			// Tell the opponent the player is back, whether the old connection was still
+			// being replaced or had already dropped and started the grace timer
+			if (isReconnect || rejoined) && g.Status == game.StatusInProgress {
 				h.BroadcastToGame(client.gameID, map[string]interface{}{
 					"type":    "player_connected",
 					"player":  client.playerID,
@@ -194,7 +234,12 @@ func runGameHub(h *Hub) {
 			}
 
 		case client := <-h.unregister:
+			if client.spectator {
+				h.removeSpectator(client)
+				continue
+			}
 			h.mu.Lock()
+			// A connection already replaced by a reconnect finds a different client here and is a no-op
 			if cur, ok := h.clients[client.playerID]; ok && cur == client {
 				delete(h.clients, client.playerID)
 				if room, exists := h.gameRooms[client.gameID]; exists {
@@ -204,7 +249,7 @@ func runGameHub(h *Hub) {
 					}
 				}
 
-				log.Printf("[WS] Player %s disconnected from game %s", client.playerID, client.gameID)
+				client.logger().Info("player disconnected")
 
 				if g, err := game.Manager.GetGameByToken(client.gameToken); err == nil {
 					g.SetPlayerDisconnected(client.playerID)
@@ -238,6 +283,51 @@ func runGameHub(h *Hub) {
 	}
 }
 
+// addSpectator joins a watch-only client to its game and sends the current table.
+func (h *Hub) addSpectator(client *Client) {
+	h.mu.Lock()
+	h.touchViewerScoreLocked(client.gameID, time.Now())
+	if _, exists := h.spectators[client.gameID]; !exists {
+		h.spectators[client.gameID] = make(map[*Client]bool)
+	}
+	h.spectators[client.gameID][client] = true
+	h.mu.Unlock()
+
+	client.logger().Info("spectator joined")
+
+	if g, err := game.Manager.GetGameByToken(client.gameToken); err == nil {
+		d, _ := json.Marshal(spectatorState(g))
+		select {
+		case client.send <- d:
+		default:
+		}
+	}
+}
+
+// removeSpectator drops a watch-only client from its game.
+func (h *Hub) removeSpectator(client *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if room, exists := h.spectators[client.gameID]; exists && room[client] {
+		h.touchViewerScoreLocked(client.gameID, time.Now())
+		delete(room, client)
+		if len(room) == 0 {
+			delete(h.spectators, client.gameID)
+		}
+		close(client.send)
+		client.logger().Info("spectator left")
+	}
+}
+
+// spectatorState is the table as seen by a spectator (player 1's perspective, no hidden fields).
+func spectatorState(g *game.PoolGameState) map[string]interface{} {
+	state := g.GetGameStateForPlayer(g.Player1.ID)
+	state["type"] = "spectator_state"
+	state["spectator"] = true
+	delete(state, "my_turn")
+	return state
+}
+
 // readPump reads messages for pool games.
 func (c *Client) readPump() {
 	defer func() {
@@ -245,17 +335,23 @@ func (c *Client) readPump() {
 		c.conn.Close()
 	}()
 
+	// Half-open connections (dropped mobile data) stop answering pings: the read deadline
+	// then expires and the unregister above marks the player disconnected.
+	wait := pongWait()
 	c.conn.SetReadLimit(65536)
-	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+	c.conn.SetReadDeadline(time.Now().Add(wait))
 	c.conn.SetPongHandler(func(string) error {
-		c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+		c.conn.SetReadDeadline(time.Now().Add(wait))
 		return nil
 	})
 
 	for {
 		_, message, err := c.conn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				c.logger().Info("connection dead, no pong received", "timeout_seconds", int(wait.Seconds()))
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket error (unexpected) for player %s: %v", c.playerID, err)
 			} else {
 				log.Printf("WebSocket read error for player %s: %v", c.playerID, err)
@@ -264,7 +360,7 @@ func (c *Client) readPump() {
 		}
 
 		// Update idle tracking in Redis
-		if rdbClient != nil && wsConfig != nil {
+		if rdbClient != nil && wsConfig != nil && !c.spectator {
 			ctx := context.Background()
 			member := fmt.Sprintf("g:%s:p:%s", c.gameToken, c.playerID)
 			now := time.Now().Unix()
@@ -287,6 +383,9 @@ func (c *Client) readPump() {
 			}
 		}
 
+		// Any message proves the connection is alive
+		c.conn.SetReadDeadline(time.Now().Add(wait))
+
 		var msg WSMessage
 		if err := json.Unmarshal(message, &msg); err != nil {
 			continue
@@ -304,6 +403,17 @@ func (c *Client) handleMessage(msg WSMessage) {
 		return
 	}
 
+	// Spectators can only ask for the table
+	if c.spectator {
+		if msg.Type != "get_state" {
+			c.sendError("Spectators cannot make moves")
+			return
+		}
+		d, _ := json.Marshal(spectatorState(g))
+		c.send <- d
+		return
+	}
+
 	switch msg.Type {
 	case "take_shot":
 		var data TakeShotData
@@ -330,19 +440,46 @@ func (c *Client) handleMessage(msg WSMessage) {
 		c.handlePlaceCueBall(g, data)
 
 	case "get_state":
-		state := g.GetGameStateForPlayer(c.playerID)
-		state["type"] = "game_state"
-		d, _ := json.Marshal(state)
+		d, _ := json.Marshal(c.gameStateMessage(g))
 		c.send <- d
 
 	case "concede":
 		c.handleConcede(g)
 
+	case "thinking":
+		GameHub.relayThinking(c, g, time.Now())
+
+	case "chat":
+		if reason := GameHub.relayChat(c, g, msg.Data, time.Now()); reason != "" {
+			c.sendError(reason)
+		}
+
 	default:
 		c.sendError("Unknown message type")
 	}
 }
 
+// relayThinking tells the opponent that the player on turn is lining up a shot. Signals from
+// the other player, during a shot, or faster than the configured interval are dropped silently.
+func (h *Hub) relayThinking(c *Client, g *game.PoolGameState, now time.Time) bool {
+	if wsConfig == nil || wsConfig.PoolThinkingIndicatorSeconds <= 0 {
+		return false
+	}
+	if cur := g.GetCurrentPlayer(); cur == nil || cur.ID != c.playerID || g.IsShotInProgress(c.playerID) {
+		return false
+	}
+	if now.Sub(c.lastThinking) < time.Duration(wsConfig.PoolThinkingIndicatorSeconds)*time.Second {
+		return false
+	}
+	c.lastThinking = now
+
+	h.SendToPlayer(c.opponentID, map[string]interface{}{
+		"type":   "opponent_thinking",
+		"player": c.playerID,
+	})
+	return true
+}
+
 // handleTakeShot validates the shot and relays it to the opponent.
 // The server no longer runs physics — it waits for shot_complete from the shooting client.
 func (c *Client) handleTakeShot(g *game.PoolGameState, data TakeShotData) {
@@ -351,21 +488,29 @@ func (c *Client) handleTakeShot(g *game.PoolGameState, data TakeShotData) {
 		Power:   data.Power,
 		Screw:   data.Screw,
 		English: data.English,
-	}
-
-	if err := g.ValidateCanShoot(c.playerID, params); err != nil {
+	}.ClampSpin()
+
+	// Validate and claim the shot atomically so a double-tap cannot start it twice
+	if err := g.BeginShot(c.playerID, params, data.MoveSeq); err != nil {
+		if errors.Is(err, game.ErrStaleMove) {
+			// Resync the client so its next shot carries the current move_seq
+			d, _ := json.Marshal(c.gameStateMessage(g))
+			c.send <- d
+		}
 		c.sendError(err.Error())
 		return
 	}
 
-	g.SetShotInProgress(c.playerID, params)
+	var events []game.CollisionEvent
+	if wsConfig != nil && wsConfig.PoolSpectatorShotPreview {
+		// A busy simulator only costs spectators the preview, never the shot itself
+		var err error
+		if events, err = g.PreviewShot(params); err != nil {
+			log.Printf("[POOL] Spectator preview skipped for game %s: %v", c.gameID, err)
+		}
+	}
 
-	// Relay shot params to opponent so they start client-side animation
-	GameHub.SendToPlayer(c.opponentID, map[string]interface{}{
-		"type":        "shot_relay",
-		"player":      c.playerID,
-		"shot_params": params,
-	})
+	GameHub.broadcastShotStart(c.gameID, c.playerID, c.opponentID, params, events)
 
 	// Start timeout — if shot_complete doesn't arrive within 30s, treat as foul
 	go func(gameID, gameToken, playerID string) {
@@ -393,32 +538,13 @@ func (c *Client) handleTakeShot(g *game.PoolGameState, data TakeShotData) {
 			return
 		}
 
-		GameHub.BroadcastToGame(gameID, map[string]interface{}{
-			"type":           "shot_result",
-			"player":         playerID,
-			"pocketed_balls": result.PocketedBalls,
-			"foul":           result.Foul,
-			"group_assigned": result.GroupAssigned,
-			"player1_group":  result.Player1Group,
-			"player2_group":  result.Player2Group,
-			"turn_change":    result.TurnChange,
-			"next_turn":      result.NextTurn,
-			"ball_in_hand":   result.BallInHand,
-			"game_over":      result.GameOver,
-			"winner":         result.Winner,
-			"win_type":       result.WinType,
-			"timeout":        true,
-		})
+		msg := shotResultMessage(playerID, result)
+		msg["timeout"] = true
+		GameHub.broadcastShotResult(gameID, msg)
 
-		if g2.Player1 != nil {
-			state := g2.GetGameStateForPlayer(g2.Player1.ID)
-			state["type"] = "game_update"
-			GameHub.SendToPlayer(g2.Player1.ID, state)
-		}
-		if g2.Player2 != nil {
-			state := g2.GetGameStateForPlayer(g2.Player2.ID)
-			state["type"] = "game_update"
-			GameHub.SendToPlayer(g2.Player2.ID, state)
+		GameHub.broadcastGameState(g2)
+		if result.GameOver {
+			GameHub.broadcastGameComplete(g2)
 		}
 		g2.SaveToRedis()
 	}(c.gameID, c.gameToken, c.playerID)
@@ -440,10 +566,48 @@ func (c *Client) handleShotComplete(g *game.PoolGameState, data ShotCompleteData
 		return
 	}
 
-	// Broadcast shot result to both players
-	GameHub.BroadcastToGame(c.gameID, map[string]interface{}{
+	// Broadcast shot result to both players and any spectators
+	GameHub.broadcastShotResult(c.gameID, shotResultMessage(c.playerID, result))
+
+	// Reset idle timers for both players
+	resetIdleTimersForGame(c.gameToken, g.Player1.ID, g.Player2.ID)
+	GameHub.BroadcastToGame(c.gameID, map[string]interface{}{"type": "player_idle_canceled", "player": c.playerID})
+
+	// Send updated game state to each player
+	c.broadcastGameState(g)
+	if result.GameOver {
+		GameHub.broadcastGameComplete(g)
+	}
+
+	// Save to Redis
+	g.SaveToRedis()
+}
+
+// broadcastShotStart relays a new shot: the opponent gets the params to animate it, and when
+// a preview ran, spectators get the params plus the simulated event timeline. Players never
+// see the preview — their outcome is the authoritative shot_result.
+func (h *Hub) broadcastShotStart(gameID, playerID, opponentID string, params game.ShotParams, events []game.CollisionEvent) {
+	h.SendToPlayer(opponentID, map[string]interface{}{
+		"type":        "shot_relay",
+		"player":      playerID,
+		"shot_params": params,
+	})
+
+	if events != nil {
+		h.BroadcastToSpectators(gameID, map[string]interface{}{
+			"type":        "shot_preview",
+			"player":      playerID,
+			"shot_params": params,
+			"events":      events,
+		})
+	}
+}
+
+// shotResultMessage builds the shot_result payload from an applied shot.
+func shotResultMessage(playerID string, result *game.ShotResult) map[string]interface{} {
+	return map[string]interface{}{
 		"type":           "shot_result",
-		"player":         c.playerID,
+		"player":         playerID,
 		"pocketed_balls": result.PocketedBalls,
 		"foul":           result.Foul,
 		"group_assigned": result.GroupAssigned,
@@ -455,17 +619,15 @@ func (c *Client) handleShotComplete(g *game.PoolGameState, data ShotCompleteData
 		"game_over":      result.GameOver,
 		"winner":         result.Winner,
 		"win_type":       result.WinType,
-	})
-
-	// Reset idle timers for both players
-	resetIdleTimersForGame(c.gameToken, g.Player1.ID, g.Player2.ID)
-	GameHub.BroadcastToGame(c.gameID, map[string]interface{}{"type": "player_idle_canceled", "player": c.playerID})
 
-	// Send updated game state to each player
-	c.broadcastGameState(g)
+		"simulation_truncated": result.SimulationTruncated,
+	}
+}
 
-	// Save to Redis
-	g.SaveToRedis()
+// broadcastShotResult sends the authoritative result to the players and the spectators.
+func (h *Hub) broadcastShotResult(gameID string, msg map[string]interface{}) {
+	h.BroadcastToGame(gameID, msg)
+	h.BroadcastToSpectators(gameID, msg)
 }
 
 // handlePlaceCueBall processes cue ball placement.
@@ -475,11 +637,13 @@ func (c *Client) handlePlaceCueBall(g *game.PoolGameState, data PlaceCueBallData
 		return
 	}
 
-	GameHub.BroadcastToGame(c.gameID, map[string]interface{}{
+	placed := map[string]interface{}{
 		"type": "ball_placed",
 		"x":    data.X,
 		"y":    data.Y,
-	})
+	}
+	GameHub.BroadcastToGame(c.gameID, placed)
+	GameHub.BroadcastToSpectators(c.gameID, placed)
 
 	c.broadcastGameState(g)
 	g.SaveToRedis()
@@ -501,18 +665,92 @@ func (c *Client) handleConcede(g *game.PoolGameState) {
 	})
 
 	c.broadcastGameState(g)
+	GameHub.broadcastGameComplete(g)
 }
 
 // broadcastGameState sends personalized state to each player.
 func (c *Client) broadcastGameState(g *game.PoolGameState) {
+	GameHub.broadcastGameState(g)
+}
+
+// quickRestakeFor looks up a winner's quick restake offer (replaced in tests)
+var quickRestakeFor = func(playerDBID, stake int) (game.QuickRestake, bool) {
+	return game.Manager.QuickRestakeFor(playerDBID, stake)
+}
+
+// gameCompleteMessage tells playerID how the game ended and what they can do next, so the
+// client does not have to work out links itself.
+func gameCompleteMessage(g *game.PoolGameState, playerID string) map[string]interface{} {
+	frontendURL := ""
+	rematch := false
+	if wsConfig != nil {
+		frontendURL, rematch = wsConfig.FrontendURL, wsConfig.RematchEnabled
+	}
+
+	result, net := g.ResultFor(playerID)
+	state := g.GetGameStateForPlayer(playerID)
+
+	var actions []map[string]string
+	if opp := g.GetPlayerByID(g.GetOpponentID(playerID)); rematch && opp != nil && opp.PhoneNumber != "" {
+		actions = append(actions, map[string]string{
+			"action": "rematch",
+			"url":    fmt.Sprintf("%s/rematch?opponent=%s&stake=%d", frontendURL, url.QueryEscape(opp.PhoneNumber), g.StakeAmount),
+		})
+	}
+	if me := g.GetPlayerByID(playerID); me != nil {
+		actions = append(actions, map[string]string{
+			"action": "requeue",
+			"url":    fmt.Sprintf("%s/requeue?phone=%s", frontendURL, url.QueryEscape(me.PhoneNumber)),
+		})
+	}
+	actions = append(actions, map[string]string{"action": "view_stats", "url": frontendURL + "/profile"})
+
+	msg := map[string]interface{}{
+		"type":         "game_complete",
+		"result":       result,
+		"winner":       state["winner"],
+		"win_type":     state["win_type"],
+		"stake_amount": g.StakeAmount,
+		"net_winnings": net,
+	}
+	// A winner whose winnings cover another game at this stake can restake in one tap
+	// (POST /api/v1/game/stake with source "winnings" and this stake_amount)
+	if me := g.GetPlayerByID(playerID); result == "win" && me != nil {
+		if offer, ok := quickRestakeFor(me.DBPlayerID, g.StakeAmount); ok {
+			msg["quick_restake"] = offer
+			actions = append([]map[string]string{{
+				"action": "quick_restake",
+				"url":    fmt.Sprintf("%s/?stake=%d&source=winnings", frontendURL, g.StakeAmount),
+			}}, actions...)
+		}
+	}
+	msg["next_actions"] = actions
+	return msg
+}
+
+// broadcastGameComplete sends each player their personalised game_complete event.
+func (h *Hub) broadcastGameComplete(g *game.PoolGameState) {
+	if g.Player1 != nil {
+		h.SendToPlayer(g.Player1.ID, gameCompleteMessage(g, g.Player1.ID))
+	}
+	if g.Player2 != nil {
+		h.SendToPlayer(g.Player2.ID, gameCompleteMessage(g, g.Player2.ID))
+	}
+}
+
+// broadcastGameState sends personalized state to each player and the shared view to spectators.
+func (h *Hub) broadcastGameState(g *game.PoolGameState) {
 	if g.Player1 != nil {
 		state := g.GetGameStateForPlayer(g.Player1.ID)
 		state["type"] = "game_update"
-		GameHub.SendToPlayer(g.Player1.ID, state)
+		h.SendToPlayer(g.Player1.ID, state)
 	}
 	if g.Player2 != nil {
 		state := g.GetGameStateForPlayer(g.Player2.ID)
 		state["type"] = "game_update"
-		GameHub.SendToPlayer(g.Player2.ID, state)
+		h.SendToPlayer(g.Player2.ID, state)
+	}
+	if g.Player1 != nil && h.SpectatorCount(g.ID) > 0 {
+		h.BroadcastToSpectators(g.ID, spectatorState(g))
 	}
 }
@@ -0,0 +1,674 @@
+package ws
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
+)
+
+// drain decodes every message queued for a fake client
+func drain(t *testing.T, c *Client) []map[string]interface{} {
+	t.Helper()
+	var msgs []map[string]interface{}
+	for {
+		select {
+		case data := <-c.send:
+			var m map[string]interface{}
+			if err := json.Unmarshal(data, &m); err != nil {
+				t.Fatalf("bad message: %v", err)
+			}
+			msgs = append(msgs, m)
+		default:
+			return msgs
+		}
+	}
+}
+
+func TestSpectatorShotPreview(t *testing.T) {
+	g := game.NewPoolGame("g1", "tok", "p1", "256700000001", "t1", 1, "One", "p2", "256700000002", "t2", 2, "Two", 1000)
+	if err := g.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	h := NewHub()
+	p1 := &Client{playerID: "p1", gameID: g.ID, send: make(chan []byte, 16)}
+	p2 := &Client{playerID: "p2", gameID: g.ID, send: make(chan []byte, 16)}
+	spec := &Client{gameID: g.ID, spectator: true, send: make(chan []byte, 16)}
+	h.clients["p1"], h.clients["p2"] = p1, p2
+	h.gameRooms[g.ID] = map[string]*Client{"p1": p1, "p2": p2}
+	h.spectators[g.ID] = map[*Client]bool{spec: true}
+
+	params := game.ShotParams{Angle: 0, Power: 4000}
+	events, err := g.PreviewShot(params)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	g.SetShotInProgress("p1", params)
+	h.broadcastShotStart(g.ID, "p1", "p2", params, events)
+
+	result, err := g.ApplyShotResult("p1", game.ClientShotData{
+		BallPositions:      g.GetCurrentBallPositions(),
+		PocketedBalls:      []int{},
+		FirstContactBallID: 1,
+		BreakCushionCount:  4,
+	})
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	h.broadcastShotResult(g.ID, shotResultMessage("p1", result))
+
+	specMsgs := drain(t, spec)
+	if len(specMsgs) != 2 || specMsgs[0]["type"] != "shot_preview" || specMsgs[1]["type"] != "shot_result" {
+		t.Fatalf("spectator messages = %v", specMsgs)
+	}
+	preview := specMsgs[0]
+	if sp, ok := preview["shot_params"].(map[string]interface{}); !ok || sp["power"] != 4000.0 {
+		t.Errorf("preview shot_params = %v", preview["shot_params"])
+	}
+	if ev, ok := preview["events"].([]interface{}); !ok || len(ev) != len(events) || len(ev) == 0 {
+		t.Errorf("preview events = %v, want %d", preview["events"], len(events))
+	}
+
+	for _, c := range []*Client{p1, p2} {
+		gotResult := false
+		for _, m := range drain(t, c) {
+			if m["type"] == "shot_preview" {
+				t.Errorf("player %s received the spectator preview", c.playerID)
+			}
+			if m["type"] == "shot_result" {
+				gotResult = true
+				for _, k := range []string{"pocketed_balls", "foul", "turn_change", "next_turn", "ball_in_hand", "game_over"} {
+					if _, ok := m[k]; !ok {
+						t.Errorf("player %s shot_result missing %s", c.playerID, k)
+					}
+				}
+				if m["next_turn"] != "p2" {
+					t.Errorf("next_turn = %v, want p2", m["next_turn"])
+				}
+			}
+		}
+		if !gotResult {
+			t.Errorf("player %s did not receive shot_result", c.playerID)
+		}
+	}
+}
+
+func TestNoSpectatorPreviewWithoutEvents(t *testing.T) {
+	h := NewHub()
+	spec := &Client{gameID: "g1", spectator: true, send: make(chan []byte, 4)}
+	h.spectators["g1"] = map[*Client]bool{spec: true}
+
+	h.broadcastShotStart("g1", "p1", "p2", game.ShotParams{Power: 1000}, nil)
+
+	if msgs := drain(t, spec); len(msgs) != 0 {
+		t.Errorf("preview sent with the feature off: %v", msgs)
+	}
+}
+
+func TestThinkingRelayedOnlyFromActivePlayer(t *testing.T) {
+	prev := wsConfig
+	wsConfig = &config.Config{PoolThinkingIndicatorSeconds: 2}
+	t.Cleanup(func() { wsConfig = prev })
+
+	g := game.NewPoolGame("g1", "tok", "p1", "256700000001", "t1", 1, "One", "p2", "256700000002", "t2", 2, "Two", 1000)
+	if err := g.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	h := NewHub()
+	p1 := &Client{playerID: "p1", opponentID: "p2", gameID: g.ID, send: make(chan []byte, 4)}
+	p2 := &Client{playerID: "p2", opponentID: "p1", gameID: g.ID, send: make(chan []byte, 4)}
+	h.clients["p1"], h.clients["p2"] = p1, p2
+
+	now := time.Now()
+	if !h.relayThinking(p1, g, now) {
+		t.Fatal("thinking from the player on turn was dropped")
+	}
+	msgs := drain(t, p2)
+	if len(msgs) != 1 || msgs[0]["type"] != "opponent_thinking" || msgs[0]["player"] != "p1" {
+		t.Fatalf("opponent got %v", msgs)
+	}
+
+	// Rate limited until the interval passes
+	if h.relayThinking(p1, g, now.Add(time.Second)) {
+		t.Error("thinking relayed inside the rate limit")
+	}
+	if !h.relayThinking(p1, g, now.Add(2*time.Second)) {
+		t.Error("thinking dropped after the rate limit")
+	}
+	drain(t, p2)
+
+	// The player waiting for their turn cannot signal
+	if h.relayThinking(p2, g, now.Add(time.Minute)) {
+		t.Error("thinking relayed from the non-active player")
+	}
+	if msgs := drain(t, p1); len(msgs) != 0 {
+		t.Errorf("active player received %v", msgs)
+	}
+}
+
+// actionNames lists the next_actions of a game_complete message in order
+func actionNames(m map[string]interface{}) []string {
+	var names []string
+	actions, _ := m["next_actions"].([]interface{})
+	for _, a := range actions {
+		names = append(names, a.(map[string]interface{})["action"].(string))
+	}
+	return names
+}
+
+func TestGameCompleteNextActions(t *testing.T) {
+	prev := wsConfig
+	t.Cleanup(func() { wsConfig = prev })
+
+	for _, rematch := range []bool{true, false} {
+		wsConfig = &config.Config{FrontendURL: "https://play.example", RematchEnabled: rematch}
+
+		g := game.NewPoolGame("g1", "tok", "p1", "256700000001", "t1", 1, "One", "p2", "256700000002", "t2", 2, "Two", 1000)
+		if err := g.Initialize(); err != nil {
+			t.Fatalf("initialize: %v", err)
+		}
+		h := NewHub()
+		p1 := &Client{playerID: "p1", gameID: g.ID, send: make(chan []byte, 4)}
+		p2 := &Client{playerID: "p2", gameID: g.ID, send: make(chan []byte, 4)}
+		h.clients["p1"], h.clients["p2"] = p1, p2
+
+		g.ForfeitByConcede("p1")
+		h.broadcastGameComplete(g)
+
+		loser, winner := drain(t, p1), drain(t, p2)
+		if len(loser) != 1 || len(winner) != 1 {
+			t.Fatalf("rematch=%v: got %d/%d messages", rematch, len(loser), len(winner))
+		}
+		l, w := loser[0], winner[0]
+		if l["type"] != "game_complete" || l["result"] != "loss" || l["net_winnings"] != -1000.0 || l["win_type"] != "concede" {
+			t.Errorf("loser event: %v", l)
+		}
+		if w["result"] != "win" || w["net_winnings"] != 1000.0 || w["winner"] != "p2" {
+			t.Errorf("winner event: %v", w)
+		}
+
+		want := "[requeue view_stats]"
+		if rematch {
+			want = "[rematch requeue view_stats]"
+		}
+		if got := fmt.Sprint(actionNames(l)); got != want {
+			t.Errorf("rematch=%v: actions = %s, want %s", rematch, got, want)
+		}
+		if rematch {
+			first := l["next_actions"].([]interface{})[0].(map[string]interface{})
+			if first["url"] != "https://play.example/rematch?opponent=256700000002&stake=1000" {
+				t.Errorf("rematch url = %v", first["url"])
+			}
+		}
+	}
+}
+
+func TestGameCompleteOffersQuickRestakeToWinner(t *testing.T) {
+	prevCfg, prevLookup := wsConfig, quickRestakeFor
+	t.Cleanup(func() { wsConfig, quickRestakeFor = prevCfg, prevLookup })
+	wsConfig = &config.Config{FrontendURL: "https://play.example"}
+
+	var asked []int
+	for _, covered := range []bool{true, false} {
+		quickRestakeFor = func(playerDBID, stake int) (game.QuickRestake, bool) {
+			asked = append(asked, playerDBID)
+			return game.QuickRestake{StakeAmount: stake, Commission: 1000, Balance: 2900}, covered
+		}
+		g := game.NewPoolGame("g1", "tok", "p1", "256700000001", "t1", 1, "One", "p2", "256700000002", "t2", 2, "Two", 1000)
+		if err := g.Initialize(); err != nil {
+			t.Fatalf("initialize: %v", err)
+		}
+		g.ForfeitByConcede("p1")
+
+		w := roundTrip(t, gameCompleteMessage(g, "p2"))
+		l := roundTrip(t, gameCompleteMessage(g, "p1"))
+		if _, ok := l["quick_restake"]; ok {
+			t.Errorf("loser offered a quick restake: %v", l)
+		}
+		offer, ok := w["quick_restake"].(map[string]interface{})
+		if ok != covered {
+			t.Fatalf("covered=%v: winner quick_restake = %v", covered, w["quick_restake"])
+		}
+		if !covered {
+			continue
+		}
+		if offer["stake_amount"] != 1000.0 || offer["commission"] != 1000.0 {
+			t.Errorf("offer = %v", offer)
+		}
+		first := w["next_actions"].([]interface{})[0].(map[string]interface{})
+		if first["action"] != "quick_restake" || first["url"] != "https://play.example/?stake=1000&source=winnings" {
+			t.Errorf("first action = %v", first)
+		}
+	}
+	// Only the winner's balance is ever looked up
+	for _, id := range asked {
+		if id != 2 {
+			t.Errorf("looked up player %d, want only the winner (2)", id)
+		}
+	}
+}
+
+// roundTrip encodes m as it would go over the socket and decodes it again
+func roundTrip(t *testing.T, m map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	b, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var out map[string]interface{}
+	json.Unmarshal(b, &out)
+	return out
+}
+
+func TestDrainNotifiesClientsAndRefusesNewConnections(t *testing.T) {
+	h := NewHub()
+	p1 := &Client{playerID: "p1", gameID: "g1", send: make(chan []byte, 4)}
+	watcher := &Client{playerID: "s1", gameID: "g1", spectator: true, send: make(chan []byte, 4)}
+	h.clients["p1"] = p1
+	h.spectators["g1"] = map[*Client]bool{watcher: true}
+
+	if h.Draining() {
+		t.Fatal("new hub should not be draining")
+	}
+	if n := h.Drain(); n != 2 {
+		t.Fatalf("notified %d clients, want 2", n)
+	}
+	if !h.Draining() {
+		t.Error("hub should be draining after Drain")
+	}
+	for _, c := range []*Client{p1, watcher} {
+		msgs := drain(t, c)
+		if len(msgs) != 1 || msgs[0]["type"] != "server_draining" {
+			t.Errorf("%s got %v, want one server_draining", c.playerID, msgs)
+		}
+	}
+
+	// Connections without a socket (as in tests) are skipped
+	h.CloseAll()
+}
+
+func TestRefusalsCarryRetryHint(t *testing.T) {
+	prevCfg, prevHub := wsConfig, GameHub
+	t.Cleanup(func() { wsConfig, GameHub = prevCfg, prevHub })
+	wsConfig = &config.Config{WSRetryAfterSeconds: 12}
+
+	GameHub = NewHub()
+	p1 := &Client{playerID: "p1", gameID: "g1", send: make(chan []byte, 4)}
+	GameHub.clients["p1"] = p1
+	GameHub.Drain()
+
+	// Upgrade refused while draining
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/ws?token=tok&pt=pt", nil)
+	HandleWebSocket(c)
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "12" {
+		t.Fatalf("refused upgrade: status %d Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if body["reason"] != RefusalDraining || body["retry_after_seconds"] != 12.0 || body["retriable"] != true {
+		t.Errorf("refused upgrade body = %v", body)
+	}
+
+	// Drain notice to connected clients
+	msgs := drain(t, p1)
+	if len(msgs) != 1 || msgs[0]["reason"] != RefusalDraining || msgs[0]["retry_after_seconds"] != 12.0 {
+		t.Errorf("drain notice = %v", msgs)
+	}
+
+	// Close frame sent by CloseAll
+	frame := refusalCloseMessage(websocket.CloseGoingAway, RefusalDraining)
+	if len(frame) > 125 {
+		t.Fatalf("close frame payload is %d bytes, over the control frame limit", len(frame))
+	}
+	var hint map[string]interface{}
+	if code := binary.BigEndian.Uint16(frame); code != websocket.CloseGoingAway {
+		t.Errorf("close code = %d", code)
+	}
+	if err := json.Unmarshal(frame[2:], &hint); err != nil || hint["reason"] != RefusalDraining || hint["retry_after_seconds"] != 12.0 {
+		t.Errorf("close reason = %s (%v)", frame[2:], err)
+	}
+}
+
+func TestGameStateMessageCompactCapability(t *testing.T) {
+	prev := wsConfig
+	t.Cleanup(func() { wsConfig = prev })
+
+	g := game.NewPoolGame("g1", "tok", "p1", "256700000001", "t1", 1, "One", "p2", "256700000002", "t2", 2, "Two", 1000)
+	if err := g.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+
+	for _, enabled := range []bool{true, false} {
+		wsConfig = &config.Config{PoolCompactState: enabled}
+		c := &Client{playerID: "p1", compactState: wantsCompactState("spectate_v2, compact_state")}
+		state := c.gameStateMessage(g)
+		_, hasCompact := state["balls_compact"]
+		_, hasJSON := state["balls"]
+		if hasCompact != enabled || hasJSON == enabled {
+			t.Errorf("enabled=%v: balls_compact=%v balls=%v", enabled, hasCompact, hasJSON)
+		}
+	}
+
+	// Clients that don't advertise the capability keep the JSON list
+	wsConfig = &config.Config{PoolCompactState: true}
+	c := &Client{playerID: "p1", compactState: wantsCompactState("")}
+	if _, ok := c.gameStateMessage(g)["balls"]; !ok {
+		t.Error("plain client should get balls")
+	}
+}
+
+func TestFeaturedRankingDecaysDepartedViewers(t *testing.T) {
+	prev := wsConfig
+	t.Cleanup(func() { wsConfig = prev })
+	wsConfig = &config.Config{FeaturedViewerHalfLifeSeconds: 60, FeaturedStakePerViewer: 1000000}
+
+	h := NewHub()
+	faded := game.NewPoolGame("g1", "faded", "p1", "256700000001", "t1", 1, "One", "p2", "256700000002", "t2", 2, "Two", 1000)
+	busy := game.NewPoolGame("g2", "busy", "p3", "256700000003", "t3", 3, "Three", "p4", "256700000004", "t4", 4, "Four", 1000)
+	games := []*game.PoolGameState{faded, busy}
+
+	// setViewers changes a game's audience the way add/removeSpectator do
+	setViewers := func(gameID string, n int, at time.Time) {
+		h.touchViewerScoreLocked(gameID, at)
+		room := make(map[*Client]bool, n)
+		for i := 0; i < n; i++ {
+			room[&Client{playerID: fmt.Sprintf("s%d", i), gameID: gameID, spectator: true}] = true
+		}
+		h.spectators[gameID] = room
+	}
+
+	t0 := time.Now()
+	setViewers("g1", 10, t0)
+	setViewers("g1", 1, t0.Add(time.Minute)) // audience left after a minute
+	setViewers("g2", 4, t0.Add(time.Minute))
+
+	// Right after the drop the departed audience still counts (10 halved once)
+	ranked := h.FeaturedGames(games, t0.Add(2*time.Minute), 0)
+	if ranked[0].Token != "faded" || ranked[0].ViewerScore != 5 || ranked[0].Spectators != 1 {
+		t.Fatalf("after one half-life got %+v, want faded first with score 5", ranked)
+	}
+
+	// Later the busy game overtakes it; the faded score never drops below its live viewers
+	ranked = h.FeaturedGames(games, t0.Add(10*time.Minute), 0)
+	if ranked[0].Token != "busy" || ranked[0].ViewerScore != 4 || ranked[1].ViewerScore != 1 {
+		t.Fatalf("after decay got %+v, want busy first", ranked)
+	}
+
+	if got := h.FeaturedGames(games, t0.Add(10*time.Minute), 1); len(got) != 1 {
+		t.Errorf("limit 1 returned %d games", len(got))
+	}
+
+	// Scores of games that ended are dropped
+	delete(h.spectators, "g1")
+	h.FeaturedGames(games[1:], t0.Add(11*time.Minute), 0)
+	if _, ok := h.viewerScores["g1"]; ok {
+		t.Error("score for finished game was kept")
+	}
+}
+
+func TestChatRelayedToOpponentOnly(t *testing.T) {
+	prev := wsConfig
+	t.Cleanup(func() { wsConfig = prev })
+
+	h := NewHub()
+	g := game.NewPoolGame("g1", "tok", "p1", "256700000001", "t1", 1, "One", "p2", "256700000002", "t2", 2, "Two", 1000)
+	p1 := &Client{playerID: "p1", gameID: "g1", send: make(chan []byte, 4)}
+	p2 := &Client{playerID: "p2", gameID: "g1", send: make(chan []byte, 4)}
+	watcher := &Client{playerID: "s1", gameID: "g1", spectator: true, send: make(chan []byte, 4)}
+	h.clients["p1"], h.clients["p2"] = p1, p2
+	h.spectators["g1"] = map[*Client]bool{watcher: true}
+
+	now := time.Now()
+	wsConfig = &config.Config{GameChatEnabled: false}
+	if reason := h.relayChat(p1, g, []byte(`{"message":"gg"}`), now); reason == "" {
+		t.Fatal("chat should be refused while disabled")
+	}
+
+	wsConfig = &config.Config{GameChatEnabled: true}
+	if reason := h.relayChat(p1, g, []byte(`{"message":"  good game  "}`), now); reason != "" {
+		t.Fatalf("chat refused: %s", reason)
+	}
+	msgs := drain(t, p2)
+	if len(msgs) != 1 || msgs[0]["type"] != "chat" || msgs[0]["message"] != "good game" || msgs[0]["from"] != "p1" {
+		t.Fatalf("opponent got %v", msgs)
+	}
+	if len(drain(t, p1)) != 0 || len(drain(t, watcher)) != 0 {
+		t.Error("chat must only reach the opponent")
+	}
+
+	if reason := h.relayChat(p1, g, []byte(`{"message":"again"}`), now.Add(100*time.Millisecond)); reason == "" {
+		t.Error("a second line within the interval should be dropped")
+	}
+	if reason := h.relayChat(p1, g, []byte(`{"message":"   "}`), now.Add(2*time.Second)); reason == "" {
+		t.Error("blank lines should be refused")
+	}
+}
+
+func TestKeepaliveDropsConnectionThatStopsAnsweringPings(t *testing.T) {
+	prev := wsConfig
+	t.Cleanup(func() { wsConfig = prev })
+	wsConfig = &config.Config{WSPingIntervalSeconds: 1, WSPongTimeoutSeconds: 2}
+
+	closed := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		c := &Client{conn: conn, playerID: r.URL.Query().Get("p"), send: make(chan []byte, 4)}
+		go c.writePump()
+		c.readPump()
+		closed <- c.playerID
+	}))
+	defer srv.Close()
+
+	dial := func(player string, answerPings bool) {
+		conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/?p="+player, nil)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		if !answerPings {
+			// A half-open peer: pings arrive but no pong ever goes back
+			conn.SetPingHandler(func(string) error { return nil })
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+	}
+	dial("silent", false)
+	dial("alive", true)
+
+	select {
+	case p := <-closed:
+		if p != "silent" {
+			t.Fatalf("%s dropped, want the silent connection", p)
+		}
+	case <-time.After(4 * time.Second):
+		t.Fatal("silent connection not dropped after the pong timeout")
+	}
+	select {
+	case p := <-closed:
+		t.Fatalf("%s dropped although it answers pings", p)
+	case <-time.After(time.Second):
+	}
+}
+
+func TestPongWaitLeavesRoomForAPing(t *testing.T) {
+	prev := wsConfig
+	t.Cleanup(func() { wsConfig = prev })
+
+	wsConfig = &config.Config{WSPingIntervalSeconds: 20, WSPongTimeoutSeconds: 10}
+	if got := pongWait(); got != 40*time.Second {
+		t.Errorf("pongWait = %v with timeout below interval, want 40s", got)
+	}
+	wsConfig = nil
+	if pingInterval() != 30*time.Second || pongWait() != 60*time.Second {
+		t.Errorf("defaults = %v/%v, want 30s/60s", pingInterval(), pongWait())
+	}
+}
+
+func TestPlayerReconnectKeepsSpectators(t *testing.T) {
+	prev := game.Manager
+	t.Cleanup(func() { game.Manager = prev })
+	game.Manager = game.NewGameManager(nil, nil, &config.Config{})
+	g, err := game.Manager.CreateTestPoolGame("256700000001", "256700000002", 1000, false)
+	if err != nil {
+		t.Fatalf("create game: %v", err)
+	}
+
+	// Player connections need a real socket: a replaced client gets a close frame
+	conns := make(chan *websocket.Conn, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+			conns <- conn
+		}
+	}))
+	defer srv.Close()
+	playerConn := func() *websocket.Conn {
+		c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { c.Close() })
+		return <-conns
+	}
+
+	h := NewHub()
+	go runGameHub(h)
+	// Each send on the unbuffered hub channels returns once the previous event is handled
+	settle := func() {
+		for i := 0; i < 2; i++ {
+			h.unregister <- &Client{playerID: "nobody"}
+		}
+	}
+
+	client := func(conn *websocket.Conn, playerID string, spectator bool) *Client {
+		return &Client{conn: conn, playerID: playerID, gameID: g.ID, gameToken: g.Token, spectator: spectator, send: make(chan []byte, 64)}
+	}
+	watchers := []*Client{client(nil, "", true), client(nil, "", true)}
+	for _, w := range watchers {
+		h.register <- w
+	}
+	oldP1 := client(playerConn(), g.Player1.ID, false)
+	h.register <- oldP1
+	settle()
+	h.mu.RLock()
+	scoreBefore := h.viewerScores[g.ID]
+	h.mu.RUnlock()
+
+	// Player 1 reconnects; the replaced connection's read loop then unregisters it
+	newP1 := client(playerConn(), g.Player1.ID, false)
+	h.register <- newP1
+	h.unregister <- oldP1
+	settle()
+
+	if n := h.SpectatorCount(g.ID); n != len(watchers) {
+		t.Fatalf("spectators after reconnect = %d, want %d", n, len(watchers))
+	}
+	h.mu.RLock()
+	for _, w := range watchers {
+		if !h.spectators[g.ID][w] {
+			t.Error("spectator dropped by a player reconnect")
+		}
+	}
+	if h.clients[g.Player1.ID] != newP1 {
+		t.Error("reconnected player not registered")
+	}
+	if h.viewerScores[g.ID] != scoreBefore {
+		t.Errorf("viewer score changed on reconnect: %+v -> %+v", scoreBefore, h.viewerScores[g.ID])
+	}
+	h.mu.RUnlock()
+}
+
+func TestQueueWatcherGetsOpponentFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/watch/:token", func(c *gin.Context) { HandleQueueWatch(c, c.Param("token")) })
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/watch/q_alice", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	for i := 0; ; i++ {
+		queueWatchers.mu.Lock()
+		n := len(queueWatchers.watchers["q_alice"])
+		queueWatchers.mu.Unlock()
+		if n == 1 {
+			break
+		}
+		if i == 100 {
+			t.Fatal("watcher never registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	deliverOpponentFound(game.OpponentFoundEvent{Type: "opponent_found", StakeAmount: 5000, Players: [2]game.OpponentFoundPlayer{
+		{QueueToken: "q_bob", DisplayName: "Bob"},
+		{QueueToken: "q_alice", DisplayName: "Alice"},
+	}})
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg map[string]interface{}
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if msg["type"] != "opponent_found" || msg["opponent_name"] != "Bob" || msg["stake_amount"] != float64(5000) {
+		t.Fatalf("watcher got %v, want opponent_found against Bob at 5000", msg)
+	}
+	// The watch is done once the opponent is found
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Fatalf("after opponent_found: %v, want a normal close", err)
+	}
+}
+
+func TestHubConnectionCounts(t *testing.T) {
+	h := NewHub()
+	p1 := &Client{playerID: "p1", gameID: "g1", send: make(chan []byte, 1)}
+	p2 := &Client{playerID: "p2", gameID: "g1", send: make(chan []byte, 1)}
+	p3 := &Client{playerID: "p3", gameID: "g2", send: make(chan []byte, 1)}
+	spec := &Client{gameID: "g2", spectator: true, send: make(chan []byte, 1)}
+	h.clients["p1"], h.clients["p2"], h.clients["p3"] = p1, p2, p3
+	h.gameRooms["g1"] = map[string]*Client{"p1": p1, "p2": p2}
+	h.gameRooms["g2"] = map[string]*Client{"p3": p3}
+	h.spectators["g2"] = map[*Client]bool{spec: true}
+
+	if n := h.ConnectedCount(); n != 3 {
+		t.Errorf("connected = %d, want 3 (spectators excluded)", n)
+	}
+	if a, b, none := h.RoomSize("g1"), h.RoomSize("g2"), h.RoomSize("g3"); a != 2 || b != 1 || none != 0 {
+		t.Errorf("room sizes g1=%d g2=%d g3=%d, want 2/1/0", a, b, none)
+	}
+	if !h.IsPlayerConnected("p3") || h.IsPlayerConnected("p4") {
+		t.Error("IsPlayerConnected wrong for p3/p4")
+	}
+
+	rooms := map[string]RoomStats{}
+	for _, r := range h.Rooms() {
+		rooms[r.GameID] = r
+	}
+	if len(rooms) != 2 || rooms["g1"].Players != 2 || rooms["g2"].Players != 1 || rooms["g2"].Spectators != 1 {
+		t.Errorf("rooms = %+v", rooms)
+	}
+}
@@ -0,0 +1,147 @@
+package ws
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/playpool/backend/internal/game"
+	"github.com/playpool/backend/internal/logger"
+)
+
+// queueWatch holds the waiting-screen connections of players still in the queue
+type queueWatch struct {
+	mu       sync.Mutex
+	watchers map[string]map[chan []byte]struct{} // queue token -> watcher sends
+}
+
+var queueWatchers = &queueWatch{watchers: make(map[string]map[chan []byte]struct{})}
+
+// add registers a watcher for token and returns the channel its messages arrive on
+func (q *queueWatch) add(token string) chan []byte {
+	ch := make(chan []byte, 4)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.watchers[token] == nil {
+		q.watchers[token] = make(map[chan []byte]struct{})
+	}
+	q.watchers[token][ch] = struct{}{}
+	return ch
+}
+
+// remove drops a watcher added for token
+func (q *queueWatch) remove(token string, ch chan []byte) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.watchers[token], ch)
+	if len(q.watchers[token]) == 0 {
+		delete(q.watchers, token)
+	}
+}
+
+// notify sends msg to token's watchers on this instance, skipping any that are backed up,
+// and returns how many got it
+func (q *queueWatch) notify(token string, msg []byte) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	sent := 0
+	for ch := range q.watchers[token] {
+		select {
+		case ch <- msg:
+			sent++
+		default:
+		}
+	}
+	return sent
+}
+
+// opponentFoundMessages builds each matched player's opponent_found message, keyed by queue token
+func opponentFoundMessages(ev game.OpponentFoundEvent) map[string][]byte {
+	out := make(map[string][]byte, 2)
+	for i, me := range ev.Players {
+		if me.QueueToken == "" {
+			continue
+		}
+		opponent := ev.Players[1-i].DisplayName
+		if opponent == "" {
+			opponent = "an opponent"
+		}
+		b, _ := json.Marshal(map[string]interface{}{
+			"type":          "opponent_found",
+			"queue_token":   me.QueueToken,
+			"opponent_name": opponent,
+			"stake_amount":  ev.StakeAmount,
+		})
+		out[me.QueueToken] = b
+	}
+	return out
+}
+
+// deliverOpponentFound forwards a published opponent_found event to the watchers held here
+func deliverOpponentFound(ev game.OpponentFoundEvent) {
+	for token, msg := range opponentFoundMessages(ev) {
+		if n := queueWatchers.notify(token, msg); n > 0 {
+			log.Printf("[WS] opponent_found sent to %d watcher(s) of queue %s", n, token)
+		}
+	}
+}
+
+// HandleQueueWatch upgrades a waiting-screen connection for queueToken. The socket only
+// carries opponent_found and closes after sending it; the client then fetches the game
+// link from /queue/status as before.
+func HandleQueueWatch(c *gin.Context, queueToken string) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		logger.For(c.Request.Context(), "ws").Warn("queue watch upgrade failed", "queue_token", queueToken, "error", err)
+		return
+	}
+	ch := queueWatchers.add(queueToken)
+	done := make(chan struct{})
+
+	// Reader: notices the client leaving (or going silent) and ends the watch
+	go func() {
+		defer close(done)
+		wait := pongWait()
+		conn.SetReadLimit(512)
+		conn.SetReadDeadline(time.Now().Add(wait))
+		conn.SetPongHandler(func(string) error {
+			conn.SetReadDeadline(time.Now().Add(wait))
+			return nil
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		ticker := time.NewTicker(pingInterval())
+		defer func() {
+			ticker.Stop()
+			queueWatchers.remove(queueToken, ch)
+			conn.Close()
+		}()
+		for {
+			select {
+			case <-done:
+				return
+			case msg := <-ch:
+				conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+				if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+					return
+				}
+				conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "opponent found"))
+				return
+			case <-ticker.C:
+				conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
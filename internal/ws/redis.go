@@ -6,6 +6,7 @@ import (
 	"log"
 
 	"github.com/playpool/backend/internal/config"
+	"github.com/playpool/backend/internal/game"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -24,8 +25,13 @@ func StartIdleEventSubscriber(ctx context.Context) {
 		return
 	}
 
-	pubsub := rdbClient.Subscribe(ctx, "idle_events", "game_events")
+	pubsub := rdbClient.Subscribe(ctx, "idle_events", "game_events", game.QueueEventsChannel)
 	ch := pubsub.Channel()
+	// Closing the subscription ends the loop below on shutdown
+	go func() {
+		<-ctx.Done()
+		pubsub.Close()
+	}()
 	go func() {
 		log.Println("[WS] idle_events/game_events subscriber started")
 		for msg := range ch {
@@ -111,6 +117,13 @@ func StartIdleEventSubscriber(ctx context.Context) {
 				GameHub.mu.RUnlock()
 				GameHub.BroadcastToGame(gameID, msg)
 
+				// Result and next actions for each player, when this instance holds the game
+				if game.Manager != nil {
+					if g, err := game.Manager.GetGameByToken(gameToken); err == nil {
+						GameHub.broadcastGameComplete(g)
+					}
+				}
+
 			case "game_draw":
 				// Mirror player_forfeit handling to send personalized final states and broadcast game_over
 				if p1, ok := payload["player1_state"].(map[string]interface{}); ok {
@@ -202,6 +215,19 @@ func StartIdleEventSubscriber(ctx context.Context) {
 				// nothing else to do - WS handler will have already handled broadcasted cancel
 				break
 
+			case "opponent_found":
+				// Sent from queue_events: both players' waiting screens switch straight away
+				var ev game.OpponentFoundEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+					log.Printf("[WS] invalid opponent_found payload: %v", err)
+					break
+				}
+				deliverOpponentFound(ev)
+
+			case "session_started":
+				// The connect that started the game already sent game_starting to both players
+				break
+
 			default:
 				log.Printf("[WS] unknown event type: %s", typeStr)
 			}
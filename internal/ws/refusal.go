@@ -0,0 +1,52 @@
+package ws
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Reasons the server turns a WebSocket client away. Every refusal carries the same
+// retry hint so clients back off instead of reconnecting in a tight loop.
+const (
+	RefusalDraining = "server_draining"
+)
+
+// defaultRetryAfter applies when no config is loaded (tests)
+const defaultRetryAfter = 5 * time.Second
+
+// retryAfter is how long a refused client should wait before reconnecting
+func retryAfter() time.Duration {
+	if wsConfig != nil && wsConfig.WSRetryAfterSeconds > 0 {
+		return time.Duration(wsConfig.WSRetryAfterSeconds) * time.Second
+	}
+	return defaultRetryAfter
+}
+
+// refusalHint is the retry hint shared by HTTP refusals, notices and close frames
+func refusalHint(reason string) map[string]interface{} {
+	return map[string]interface{}{
+		"reason":              reason,
+		"retriable":           true,
+		"retry_after_seconds": int(retryAfter().Seconds()),
+	}
+}
+
+// refuseConnection rejects an upgrade request with status and the standard retry hint,
+// also set as a Retry-After header for generic HTTP clients.
+func refuseConnection(c *gin.Context, status int, reason, message string) {
+	body := refusalHint(reason)
+	body["error"] = message
+	c.Header("Retry-After", strconv.Itoa(int(retryAfter().Seconds())))
+	c.JSON(status, body)
+}
+
+// refusalCloseMessage formats a close frame whose reason text is the JSON retry hint
+// (well under the 123-byte limit on close reasons).
+func refusalCloseMessage(code int, reason string) []byte {
+	text, _ := json.Marshal(refusalHint(reason))
+	return websocket.FormatCloseMessage(code, string(text))
+}
@@ -0,0 +1,60 @@
+package ws
+
+// ConnectedCount returns the number of connected players (spectators not included)
+func (h *Hub) ConnectedCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// RoomSize returns how many players of a game are connected
+func (h *Hub) RoomSize(gameID string) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.gameRooms[gameID])
+}
+
+// IsPlayerConnected reports whether the player has a live connection
+func (h *Hub) IsPlayerConnected(playerID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, ok := h.clients[playerID]
+	return ok
+}
+
+// RoomStats is the connection count of one game room
+type RoomStats struct {
+	GameID     string `json:"game_id"`
+	Players    int    `json:"players"`
+	Spectators int    `json:"spectators"`
+}
+
+// Rooms returns the player and spectator counts of every game with a connection
+func (h *Hub) Rooms() []RoomStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	rooms := make(map[string]*RoomStats)
+	room := func(gameID string) *RoomStats {
+		if rooms[gameID] == nil {
+			rooms[gameID] = &RoomStats{GameID: gameID}
+		}
+		return rooms[gameID]
+	}
+	for gameID, players := range h.gameRooms {
+		if len(players) > 0 {
+			room(gameID).Players = len(players)
+		}
+	}
+	for gameID, watchers := range h.spectators {
+		if len(watchers) > 0 {
+			room(gameID).Spectators = len(watchers)
+		}
+	}
+
+	out := make([]RoomStats, 0, len(rooms))
+	for _, r := range rooms {
+		out = append(out, *r)
+	}
+	return out
+}
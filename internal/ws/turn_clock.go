@@ -0,0 +1,67 @@
+package ws
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/playpool/backend/internal/game"
+)
+
+// StartTurnClock ticks every second, broadcasting the shot clock for each running pool game
+// and applying the timeout foul (or forfeit) when it runs out.
+func StartTurnClock(ctx context.Context) {
+	ticker := time.NewTicker(time.Second)
+	go func() {
+		defer ticker.Stop()
+		log.Println("[WS] pool shot clock started")
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if game.Manager == nil {
+					continue
+				}
+				for _, g := range game.Manager.ActivePoolGames() {
+					GameHub.tickTurnClock(g, now)
+				}
+			}
+		}
+	}()
+}
+
+// tickTurnClock sends the remaining seconds to the room, or applies the timeout once they are gone.
+func (h *Hub) tickTurnClock(g *game.PoolGameState, now time.Time) {
+	playerID, secondsLeft, running := g.TurnClock(now)
+	if !running {
+		return
+	}
+
+	if secondsLeft > 0 {
+		msg := map[string]interface{}{
+			"type":         "turn_clock",
+			"player":       playerID,
+			"seconds_left": secondsLeft,
+		}
+		h.BroadcastToGame(g.ID, msg)
+		h.BroadcastToSpectators(g.ID, msg)
+		return
+	}
+
+	result, err := g.ApplyTurnTimeout(now)
+	if err != nil {
+		// The player shot or the clock was paused between the two reads
+		return
+	}
+
+	msg := shotResultMessage(playerID, result)
+	msg["turn_timeout"] = true
+	h.broadcastShotResult(g.ID, msg)
+
+	h.broadcastGameState(g)
+	if result.GameOver {
+		h.broadcastGameComplete(g)
+	}
+	g.SaveToRedis()
+}
@@ -0,0 +1,2 @@
+DELETE FROM runtime_config WHERE key = 'withdraw_auto_approve_limit';
+ALTER TABLE withdraw_requests DROP COLUMN IF EXISTS approved_by;
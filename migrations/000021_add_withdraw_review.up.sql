@@ -0,0 +1,6 @@
+-- Admin review for large withdrawals: record who approved the payout
+ALTER TABLE withdraw_requests ADD COLUMN IF NOT EXISTS approved_by TEXT;
+
+INSERT INTO runtime_config (key, value, value_type, description) VALUES
+    ('withdraw_auto_approve_limit', '0', 'int', 'Withdrawals above this amount in UGX need admin approval (0 disables review)')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,3 @@
+-- Rollback notification preferences
+
+DROP TABLE IF EXISTS notification_preferences;
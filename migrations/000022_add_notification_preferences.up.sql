@@ -0,0 +1,8 @@
+-- Per-player SMS opt-outs. A missing row means the type is enabled; OTP is never stored here.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    player_id INTEGER NOT NULL REFERENCES players(id) ON DELETE CASCADE,
+    sms_type TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (player_id, sms_type)
+);
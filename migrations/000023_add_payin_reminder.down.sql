@@ -0,0 +1,4 @@
+-- Rollback payin reminder tracking
+
+ALTER TABLE transactions
+DROP COLUMN IF EXISTS reminder_sent_at;
@@ -0,0 +1,3 @@
+-- Track the one resume reminder sent for a payin that stayed PENDING past its timeout
+ALTER TABLE transactions
+ADD COLUMN IF NOT EXISTS reminder_sent_at TIMESTAMP;
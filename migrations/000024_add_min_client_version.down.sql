@@ -0,0 +1 @@
+DELETE FROM runtime_config WHERE key = 'min_client_version';
@@ -0,0 +1,4 @@
+-- Minimum app version; older clients are asked to update (empty disables the gate)
+INSERT INTO runtime_config (key, value, value_type, description) VALUES
+    ('min_client_version', '', 'string', 'Clients reporting an older X-Client-Version are told to update (empty or 0 disables the check)')
+ON CONFLICT (key) DO NOTHING;
@@ -0,0 +1,7 @@
+-- Rollback stake limits
+
+DROP INDEX IF EXISTS idx_transactions_player_type_created;
+
+ALTER TABLE players
+DROP COLUMN IF EXISTS daily_stake_limit,
+DROP COLUMN IF EXISTS monthly_stake_limit;
@@ -0,0 +1,6 @@
+-- Responsible gaming: per-player stake limit overrides (NULL = use the global limit, 0 = unlimited)
+ALTER TABLE players
+ADD COLUMN IF NOT EXISTS daily_stake_limit INTEGER,
+ADD COLUMN IF NOT EXISTS monthly_stake_limit INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_transactions_player_type_created ON transactions(player_id, transaction_type, created_at);
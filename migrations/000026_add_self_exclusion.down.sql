@@ -0,0 +1,4 @@
+-- Rollback self-exclusion
+
+ALTER TABLE players
+DROP COLUMN IF EXISTS self_excluded_until;
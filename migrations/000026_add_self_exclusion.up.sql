@@ -0,0 +1,3 @@
+-- Responsible gaming: a player may lock themselves out of staking until this time
+ALTER TABLE players
+ADD COLUMN IF NOT EXISTS self_excluded_until TIMESTAMP;
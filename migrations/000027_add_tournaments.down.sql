@@ -0,0 +1,5 @@
+-- Rollback tournaments
+
+DROP TABLE IF EXISTS tournament_matches;
+DROP TABLE IF EXISTS tournament_players;
+DROP TABLE IF EXISTS tournaments;
@@ -0,0 +1,39 @@
+-- Single-elimination tournaments. Entry fees are held in escrow until the final is decided.
+CREATE TABLE IF NOT EXISTS tournaments (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    entry_fee DECIMAL(12,2) NOT NULL,
+    max_players INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'REGISTERING', -- REGISTERING, IN_PROGRESS, COMPLETED
+    champion_id INTEGER REFERENCES players(id),
+    runner_up_id INTEGER REFERENCES players(id),
+    created_by INTEGER REFERENCES players(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS tournament_players (
+    tournament_id INTEGER NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+    player_id INTEGER NOT NULL REFERENCES players(id),
+    seed INTEGER NOT NULL, -- registration order, 1 = first
+    joined_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (tournament_id, player_id),
+    UNIQUE (tournament_id, seed)
+);
+
+-- One row per bracket slot; player ids fill in as earlier rounds finish (NULL = bye or not yet known)
+CREATE TABLE IF NOT EXISTS tournament_matches (
+    id SERIAL PRIMARY KEY,
+    tournament_id INTEGER NOT NULL REFERENCES tournaments(id) ON DELETE CASCADE,
+    round INTEGER NOT NULL,
+    slot INTEGER NOT NULL,
+    player1_id INTEGER REFERENCES players(id),
+    player2_id INTEGER REFERENCES players(id),
+    winner_id INTEGER REFERENCES players(id),
+    session_id INTEGER REFERENCES game_sessions(id),
+    UNIQUE (tournament_id, round, slot)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tournaments_status ON tournaments(status);
+CREATE INDEX IF NOT EXISTS idx_tournament_matches_session ON tournament_matches(session_id);
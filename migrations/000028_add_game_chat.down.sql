@@ -0,0 +1,3 @@
+-- Rollback game chat log
+
+DROP TABLE IF EXISTS game_chat_messages;
@@ -0,0 +1,10 @@
+-- In-game chat, written only when GAME_CHAT_LOGGING is on; kept so admins can review harassment reports
+CREATE TABLE IF NOT EXISTS game_chat_messages (
+    id SERIAL PRIMARY KEY,
+    session_id INTEGER NOT NULL REFERENCES game_sessions(id) ON DELETE CASCADE,
+    player_id INTEGER REFERENCES players(id),
+    message TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_game_chat_session ON game_chat_messages(session_id, created_at);
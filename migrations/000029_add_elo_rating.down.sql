@@ -0,0 +1,3 @@
+-- Rollback ELO rating
+
+ALTER TABLE players DROP COLUMN IF EXISTS elo_rating;
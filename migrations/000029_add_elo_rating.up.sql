@@ -0,0 +1,2 @@
+-- ELO rating per player (updated when a game completes; used by ranked matchmaking)
+ALTER TABLE players ADD COLUMN IF NOT EXISTS elo_rating INTEGER NOT NULL DEFAULT 1200;
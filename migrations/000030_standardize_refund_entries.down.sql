@@ -0,0 +1,9 @@
+-- Rollback refund taxonomy (restore the old per-path entry types)
+
+DROP INDEX IF EXISTS idx_escrow_refund_reason;
+ALTER TABLE escrow_ledger DROP CONSTRAINT IF EXISTS escrow_ledger_refund_reason_check;
+
+UPDATE escrow_ledger SET entry_type = 'DRAW_REFUND' WHERE entry_type = 'REFUND' AND refund_reason = 'DRAW';
+UPDATE escrow_ledger SET entry_type = 'SESSION_CANCEL' WHERE entry_type = 'REFUND' AND refund_reason IN ('SESSION_EXPIRED', 'EARLY_DISCONNECT', 'ADMIN_CANCEL');
+
+ALTER TABLE escrow_ledger DROP COLUMN IF EXISTS refund_reason;
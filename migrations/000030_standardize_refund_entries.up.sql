@@ -0,0 +1,18 @@
+-- Refunds are all entry_type 'REFUND'; refund_reason says why (see accounts.Refund* constants)
+ALTER TABLE escrow_ledger ADD COLUMN IF NOT EXISTS refund_reason VARCHAR(20);
+
+UPDATE escrow_ledger SET entry_type = 'REFUND', refund_reason = 'DRAW' WHERE entry_type = 'DRAW_REFUND';
+UPDATE escrow_ledger SET entry_type = 'REFUND',
+    refund_reason = CASE
+        WHEN description LIKE 'Admin cancelled%' THEN 'ADMIN_CANCEL'
+        WHEN description LIKE 'Early disconnect%' THEN 'EARLY_DISCONNECT'
+        ELSE 'SESSION_EXPIRED'
+    END
+WHERE entry_type = 'SESSION_CANCEL';
+-- Before this migration plain REFUND rows were only written for cancelled queue entries
+UPDATE escrow_ledger SET refund_reason = 'QUEUE_CANCEL' WHERE entry_type = 'REFUND' AND refund_reason IS NULL;
+
+ALTER TABLE escrow_ledger ADD CONSTRAINT escrow_ledger_refund_reason_check
+    CHECK ((entry_type = 'REFUND') = (refund_reason IS NOT NULL));
+
+CREATE INDEX IF NOT EXISTS idx_escrow_refund_reason ON escrow_ledger(refund_reason) WHERE refund_reason IS NOT NULL;
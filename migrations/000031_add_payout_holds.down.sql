@@ -0,0 +1,3 @@
+-- Rollback payout holds
+
+DROP TABLE IF EXISTS payout_holds;
@@ -0,0 +1,17 @@
+-- High-stake winnings held for a dispute window before they are paid (see PAYOUT_HOLD_MIN_STAKE)
+CREATE TABLE IF NOT EXISTS payout_holds (
+    session_id INT PRIMARY KEY REFERENCES game_sessions(id),
+    winner_id INT NOT NULL REFERENCES players(id),
+    loser_id INT NOT NULL REFERENCES players(id),
+    stake_amount DECIMAL(12,2) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'HELD' CHECK (status IN ('HELD', 'DISPUTED', 'RELEASED', 'VOIDED')),
+    release_at TIMESTAMP NOT NULL,
+    dispute_reason TEXT,
+    disputed_at TIMESTAMP,
+    resolved_at TIMESTAMP,
+    resolved_by VARCHAR(100),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payout_holds_due ON payout_holds(release_at) WHERE status = 'HELD';
+CREATE INDEX IF NOT EXISTS idx_payout_holds_disputed ON payout_holds(disputed_at) WHERE status = 'DISPUTED';
@@ -0,0 +1,3 @@
+-- Rollback SMS message log
+
+DROP TABLE IF EXISTS sms_messages;
@@ -0,0 +1,12 @@
+-- Outbound SMS log with an estimated cost per message, for SMS spend reporting (see SMS_COST_PER_SEGMENT)
+CREATE TABLE IF NOT EXISTS sms_messages (
+    id SERIAL PRIMARY KEY,
+    sms_type VARCHAR(20) NOT NULL,
+    phone_number VARCHAR(20) NOT NULL,
+    segments INT NOT NULL,
+    estimated_cost DECIMAL(12,2) NOT NULL,
+    provider_message_id VARCHAR(100),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sms_messages_created_at ON sms_messages(created_at);
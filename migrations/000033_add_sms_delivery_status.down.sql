@@ -0,0 +1,6 @@
+-- Rollback SMS delivery status
+
+DROP INDEX IF EXISTS idx_sms_messages_provider_id;
+ALTER TABLE sms_messages
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS status;
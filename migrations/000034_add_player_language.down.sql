@@ -0,0 +1,3 @@
+-- Rollback player SMS language
+
+ALTER TABLE players DROP COLUMN IF EXISTS language;
@@ -0,0 +1,2 @@
+-- Language for a player's SMS (en = English, lg = Luganda)
+ALTER TABLE players ADD COLUMN IF NOT EXISTS language VARCHAR(5) NOT NULL DEFAULT 'en';